@@ -0,0 +1,106 @@
+package golite
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Affinity is the type affinity of a column, which SQLite derives from its
+// declared type and uses to convert values stored in or compared with it.
+type Affinity int
+
+const (
+	AffinityBlob Affinity = iota
+	AffinityText
+	AffinityNumeric
+	AffinityInteger
+	AffinityReal
+)
+
+// String returns the name of the affinity as used in the SQLite
+// documentation, such as "INTEGER".
+func (a Affinity) String() string {
+	switch a {
+	case AffinityBlob:
+		return "BLOB"
+	case AffinityText:
+		return "TEXT"
+	case AffinityNumeric:
+		return "NUMERIC"
+	case AffinityInteger:
+		return "INTEGER"
+	case AffinityReal:
+		return "REAL"
+	}
+	return fmt.Sprintf("Affinity(%d)", int(a))
+}
+
+// Affinity returns the type affinity of the column, derived from its
+// declared type.
+func (c ColumnInfo) Affinity() Affinity {
+	return columnAffinity(c.Type)
+}
+
+// columnAffinity maps a declared column type to its affinity, following the
+// rules of section 3.1 of https://www.sqlite.org/datatype3.html.
+func columnAffinity(declType string) Affinity {
+	t := strings.ToUpper(declType)
+	switch {
+	case strings.Contains(t, "INT"):
+		return AffinityInteger
+	case strings.Contains(t, "CHAR"), strings.Contains(t, "CLOB"), strings.Contains(t, "TEXT"):
+		return AffinityText
+	case t == "" || strings.Contains(t, "BLOB"):
+		return AffinityBlob
+	case strings.Contains(t, "REAL"), strings.Contains(t, "FLOA"), strings.Contains(t, "DOUB"):
+		return AffinityReal
+	}
+	return AffinityNumeric
+}
+
+// Apply converts a value the way SQLite does before storing it in, or
+// comparing it with, a column of this affinity: TEXT that looks like a number
+// is converted for numeric columns, and numbers are rendered as TEXT for text
+// columns.
+func (a Affinity) Apply(value any) any {
+	return a.apply(value)
+}
+
+// apply implements Apply.
+func (a Affinity) apply(value any) any {
+	switch a {
+	case AffinityText:
+		switch v := value.(type) {
+		case int64, float64:
+			return formatScalar(v)
+		}
+	case AffinityNumeric, AffinityInteger, AffinityReal:
+		if s, ok := value.(string); ok {
+			if n, ok := parseNumeric(s); ok {
+				return n
+			}
+		}
+	}
+	return value
+}
+
+// parseNumeric converts TEXT to an INTEGER or REAL value if it is a
+// well-formed decimal number, preferring INTEGER when no precision is lost.
+func parseNumeric(s string) (any, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.ContainsAny(s, "xXpPiInN_") { // Not hex, Inf or NaN.
+		return nil, false
+	}
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i, true
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, false
+	}
+	if i, err := toInt64(f); err == nil {
+		return i, true
+	}
+	return f, true
+}
@@ -0,0 +1,58 @@
+package golite
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestColumnInfo_Affinity(t *testing.T) {
+	testCases := map[string]Affinity{
+		"INTEGER":          AffinityInteger,
+		"TINYINT":          AffinityInteger,
+		"VARCHAR(255)":     AffinityText,
+		"clob":             AffinityText,
+		"BLOB":             AffinityBlob,
+		"":                 AffinityBlob,
+		"DOUBLE":           AffinityReal,
+		"FLOAT":            AffinityReal,
+		"DECIMAL(10,5)":    AffinityNumeric,
+		"BOOLEAN":          AffinityNumeric,
+		"CHARINT":          AffinityInteger, // Rule 1 wins over rule 2.
+		"POINT":            AffinityInteger, // "INT" in "POINT".
+		"STRING":           AffinityNumeric,
+		"DATETIME":         AffinityNumeric,
+		"FLOATING POINT":   AffinityInteger,
+		"VARYING CHARACTE": AffinityText,
+	}
+	for declType, want := range testCases {
+		if got := (ColumnInfo{Type: declType}).Affinity(); got != want {
+			t.Errorf("affinity of %q = %v, want %v", declType, got, want)
+		}
+	}
+}
+
+func TestAffinity_apply(t *testing.T) {
+	testCases := []struct {
+		affinity Affinity
+		value    any
+		want     any
+	}{
+		{AffinityInteger, "42", int64(42)},
+		{AffinityInteger, " 42.0 ", int64(42)},
+		{AffinityNumeric, "1e3", int64(1000)},
+		{AffinityReal, "2.5", 2.5},
+		{AffinityInteger, "abc", "abc"},
+		{AffinityInteger, "0x10", "0x10"},
+		{AffinityInteger, "Inf", "Inf"},
+		{AffinityText, int64(42), "42"},
+		{AffinityText, 2.0, "2.0"},
+		{AffinityBlob, "42", "42"},
+		{AffinityBlob, int64(42), int64(42)},
+		{AffinityInteger, SQLNull, SQLNull},
+	}
+	for _, tc := range testCases {
+		if got := tc.affinity.apply(tc.value); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("affinity %v: apply(%#v) = %#v, want %#v", tc.affinity, tc.value, got, tc.want)
+		}
+	}
+}
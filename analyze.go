@@ -0,0 +1,157 @@
+package golite
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// ObjectStorage aggregates the space used by the B-Tree of one table or index.
+type ObjectStorage struct {
+	// Name is the name of the table or index.
+	Name string
+	// IsIndex is true if the object is an index.
+	IsIndex bool
+	// InteriorPages and LeafPages count the B-Tree pages of each kind.
+	InteriorPages int
+	LeafPages     int
+	// Depth is the number of levels in the B-Tree.
+	Depth int
+	// Entries is the number of cells on leaf pages, i.e. rows or index entries.
+	Entries int
+	// PayloadBytes is the total size of the cell payloads.
+	PayloadBytes int
+	// UnusedBytes is the total free space on the object's pages.
+	UnusedBytes int
+	// OverheadBytes is the space used by page headers, cell pointers and the
+	// cell headers that precede each payload.
+	OverheadBytes int
+	// Fragmentation is the fraction of leaf pages, in key order, that do not
+	// immediately follow the previous leaf page in the file. A value of 0
+	// means the leaves can be read sequentially.
+	Fragmentation float64
+}
+
+// Pages returns the total number of pages used by the object.
+func (o ObjectStorage) Pages() int {
+	return o.InteriorPages + o.LeafPages
+}
+
+// AverageFill returns the fraction of the object's page bytes that are in use.
+func (o ObjectStorage) AverageFill(pageSize int) float64 {
+	total := o.Pages() * pageSize
+	if total == 0 {
+		return 0
+	}
+	return float64(total-o.UnusedBytes) / float64(total)
+}
+
+// StorageReport is a summary of how space is used in a database file, in the
+// manner of the sqlite3_analyzer tool. Overflow pages are not yet supported
+// by golite and are not accounted for.
+type StorageReport struct {
+	// PageSize is the database page size in bytes.
+	PageSize int
+	// FilePages is the number of pages in the database file.
+	FilePages int
+	// FreelistPages is the number of pages on the freelist, from the header.
+	FreelistPages int
+	// Objects holds one entry per table and index, in the order DBStat lists them.
+	Objects []ObjectStorage
+}
+
+// Analyze aggregates the per-page statistics returned by DBStat into a
+// storage report for each table and index.
+func (db *Database) Analyze() (*StorageReport, error) {
+	stats, err := db.DBStat()
+	if err != nil {
+		return nil, err
+	}
+	filePages, err := db.filePageCount()
+	if err != nil {
+		return nil, err
+	}
+	schema, err := db.GetSchema()
+	if err != nil {
+		return nil, err
+	}
+	report := &StorageReport{
+		PageSize:      int(db.Header.PageSize),
+		FilePages:     filePages,
+		FreelistPages: int(db.Header.FreelistPages),
+	}
+
+	var current *ObjectStorage
+	var leaves, outOfOrder, prevLeaf int
+	finish := func() {
+		if current != nil && leaves > 1 {
+			current.Fragmentation = float64(outOfOrder) / float64(leaves-1)
+		}
+	}
+	for _, stat := range stats {
+		if current == nil || current.Name != stat.Name {
+			finish()
+			// The pages of WITHOUT ROWID tables are index pages, so the
+			// kind of object comes from the schema.
+			_, isIndex := schema.Indexes[stat.Name]
+			report.Objects = append(report.Objects, ObjectStorage{
+				Name:    stat.Name,
+				IsIndex: isIndex,
+			})
+			current = &report.Objects[len(report.Objects)-1]
+			leaves, outOfOrder, prevLeaf = 0, 0, 0
+		}
+		current.Depth = max(current.Depth, stat.Depth+1)
+		current.PayloadBytes += stat.PayloadBytes
+		current.UnusedBytes += stat.UnusedBytes
+		current.OverheadBytes += report.PageSize - stat.PayloadBytes - stat.UnusedBytes
+		switch stat.PageType {
+		case PageTypeLeafTable, PageTypeLeafIndex:
+			current.LeafPages++
+			current.Entries += stat.CellCount
+			if leaves > 0 && stat.PageNum != prevLeaf+1 {
+				outOfOrder++
+			}
+			leaves++
+			prevLeaf = stat.PageNum
+		default:
+			current.InteriorPages++
+		}
+	}
+	finish()
+	return report, nil
+}
+
+// WriteText writes the report to w in a human-readable form.
+func (r *StorageReport) WriteText(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "Page size:      %d bytes\n", r.PageSize)
+	fmt.Fprintf(bw, "Pages in file:  %d\n", r.FilePages)
+	fmt.Fprintf(bw, "Freelist pages: %d\n", r.FreelistPages)
+
+	for _, o := range r.Objects {
+		kind := "Table"
+		if o.IsIndex {
+			kind = "Index"
+		}
+		allocated := o.Pages() * r.PageSize
+		fmt.Fprintf(bw, "\n*** %s %s ***\n", kind, o.Name)
+		fmt.Fprintf(bw, "Pages:          %d (%d interior, %d leaf)\n", o.Pages(), o.InteriorPages, o.LeafPages)
+		fmt.Fprintf(bw, "Depth:          %d\n", o.Depth)
+		fmt.Fprintf(bw, "Entries:        %d\n", o.Entries)
+		fmt.Fprintf(bw, "Payload:        %d bytes (%s)\n", o.PayloadBytes, percent(o.PayloadBytes, allocated))
+		fmt.Fprintf(bw, "Overhead:       %d bytes (%s)\n", o.OverheadBytes, percent(o.OverheadBytes, allocated))
+		fmt.Fprintf(bw, "Unused:         %d bytes (%s)\n", o.UnusedBytes, percent(o.UnusedBytes, allocated))
+		fmt.Fprintf(bw, "Average fill:   %.1f%%\n", 100*o.AverageFill(r.PageSize))
+		fmt.Fprintf(bw, "Fragmentation:  %.1f%%\n", 100*o.Fragmentation)
+	}
+	return bw.Flush()
+}
+
+// percent formats part as a percentage of total.
+func percent(part, total int) string {
+	if total == 0 {
+		return "0.0%"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(part)/float64(total))
+}
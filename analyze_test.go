@@ -0,0 +1,88 @@
+package golite
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDatabase_Analyze(t *testing.T) {
+	dbPath := createTestDB(t, "analyze_test.sqlite")
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+
+	report, err := db.Analyze()
+	if err != nil {
+		t.Fatalf("Analyze() failed: %v", err)
+	}
+	if report.PageSize != 4096 {
+		t.Errorf("expected page size 4096, got %d", report.PageSize)
+	}
+
+	objects := make(map[string]ObjectStorage)
+	totalPages := 0
+	for _, o := range report.Objects {
+		objects[o.Name] = o
+		totalPages += o.Pages()
+		if o.PayloadBytes+o.OverheadBytes+o.UnusedBytes != o.Pages()*report.PageSize {
+			t.Errorf("%s: payload, overhead and unused bytes do not add up to the allocated space", o.Name)
+		}
+		if fill := o.AverageFill(report.PageSize); fill <= 0 || fill > 1 {
+			t.Errorf("%s: average fill %v out of range", o.Name, fill)
+		}
+	}
+	if totalPages+report.FreelistPages != report.FilePages {
+		t.Errorf("expected objects and freelist to account for all %d pages, got %d", report.FilePages, totalPages+report.FreelistPages)
+	}
+
+	table := objects["test"]
+	if table.IsIndex || table.Entries != 500 || table.Depth != 2 || table.InteriorPages != 1 {
+		t.Errorf("unexpected storage for table 'test': %+v", table)
+	}
+	index := objects["idx_name"]
+	if !index.IsIndex || index.Entries == 0 {
+		t.Errorf("unexpected storage for index 'idx_name': %+v", index)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText() failed: %v", err)
+	}
+	for _, want := range []string{"*** Table test ***", "*** Index idx_name ***", "Entries:        500"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected report to contain %q, got:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestDatabase_Analyze_WithoutRowID(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "analyze_without_rowid.sqlite")
+	runSQL(t, dbPath, `CREATE TABLE kv (k TEXT PRIMARY KEY, v TEXT) WITHOUT ROWID;
+CREATE INDEX kv_v ON kv (v);
+INSERT INTO kv VALUES ('a', 'x'), ('b', 'y');`)
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+
+	report, err := db.Analyze()
+	if err != nil {
+		t.Fatalf("Analyze() failed: %v", err)
+	}
+	isIndex := make(map[string]bool)
+	for _, o := range report.Objects {
+		if o.Name != "sqlite_schema" {
+			isIndex[o.Name] = o.IsIndex
+		}
+	}
+	want := map[string]bool{"kv": false, "kv_v": true}
+	if !reflect.DeepEqual(isIndex, want) {
+		t.Errorf("expected objects %v (name: is index), got %v", want, isIndex)
+	}
+}
@@ -0,0 +1,96 @@
+package golite
+
+import "unsafe"
+
+// WithRecordArena makes the records decoded from a page be allocated in
+// bulk: the value slices of all the records of the page are carved out of
+// shared chunks, and the copies of their TEXT and BLOB values out of a single
+// buffer, instead of one allocation per record and per value. Records that
+// TableScan must extend with the rowid are allocated the same way. Large
+// scans then make a few allocations per page rather than several per row.
+//
+// The memory of the records of a page is released as a whole, once none of
+// them is referenced anymore, so a single retained record keeps the records
+// of its whole page in memory. Use Record.Copy on records that outlive the
+// scan that produced them. Combined with WithZeroCopy, the TEXT and BLOB
+// values alias the page buffer as usual and only the records are allocated
+// in bulk.
+func WithRecordArena() OpenOption {
+	return func(db *Database) {
+		db.arena = true
+	}
+}
+
+// arenaChunkSize is the number of values allocated at once by a recordArena.
+const arenaChunkSize = 1024
+
+// recordArena allocates the records decoded from a page. A nil *recordArena
+// allocates each record separately.
+type recordArena struct {
+	values      []any   // The unused part of the current chunk.
+	serialTypes []int64 // Scratch space for record headers.
+}
+
+// record returns an empty record with capacity n.
+func (a *recordArena) record(n int) Record {
+	if a == nil {
+		return make(Record, 0, n)
+	}
+	if len(a.values) < n {
+		a.values = make([]any, max(n, arenaChunkSize))
+	}
+	r := a.values[:0:n]
+	a.values = a.values[n:]
+	return r
+}
+
+// prependRowID returns a record holding rowID followed by the values of
+// record, allocated from a.
+func (a *recordArena) prependRowID(rowID int64, record Record) Record {
+	return append(append(a.record(len(record)+1), rowID), record...)
+}
+
+// detachRecordsBulk is like detachRecords, but copies the TEXT and BLOB values
+// of all the records of the page into a single buffer.
+func (p *Page) detachRecordsBulk() {
+	size := 0
+	p.eachRecord(func(r Record) {
+		for _, v := range r {
+			switch v := v.(type) {
+			case string:
+				size += len(v)
+			case []byte:
+				size += len(v)
+			}
+		}
+	})
+	buf := make([]byte, 0, size)
+	p.eachRecord(func(r Record) {
+		for i, v := range r {
+			start := len(buf)
+			switch v := v.(type) {
+			case string:
+				if len(v) > 0 {
+					buf = append(buf, v...)
+					r[i] = unsafe.String(&buf[start], len(v))
+				}
+			case []byte:
+				buf = append(buf, v...)
+				r[i] = buf[start:len(buf):len(buf)]
+			}
+		}
+	})
+}
+
+// eachRecord calls fn with the record of each cell of the page.
+func (p *Page) eachRecord(fn func(Record)) {
+	for _, cell := range p.LeafCells {
+		fn(cell.Record)
+	}
+	for _, cell := range p.LeafIndexCells {
+		fn(cell.Payload)
+	}
+	for _, cell := range p.InteriorIndexCells {
+		fn(cell.Payload)
+	}
+}
@@ -0,0 +1,121 @@
+package golite
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+func TestWithRecordArena(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "arena.sqlite")
+	runSQL(t, dbPath, `
+		CREATE TABLE t (a TEXT, b BLOB, c REAL);
+		WITH RECURSIVE seq(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM seq WHERE i < 2000)
+		INSERT INTO t SELECT 'text' || i, iif(i % 3, randomblob(i % 20), x''), i * 0.5 FROM seq;
+		INSERT INTO t VALUES ('', NULL, NULL);
+		CREATE INDEX t_a ON t(a, b);
+	`)
+	scan := func(opts ...OpenOption) ([]Record, []Record) {
+		db, err := Open(dbPath, opts...)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		defer db.Close()
+		table, err := db.Table("t")
+		if err != nil {
+			t.Fatalf("Table() failed: %v", err)
+		}
+		index, err := db.Index("t_a")
+		if err != nil {
+			t.Fatalf("Index() failed: %v", err)
+		}
+		rows, err := CollectRecords(db.TableScan(table))
+		if err != nil {
+			t.Fatalf("TableScan() failed: %v", err)
+		}
+		keys, err := CollectRecords(db.IndexScan(index))
+		if err != nil {
+			t.Fatalf("IndexScan() failed: %v", err)
+		}
+		return rows, keys
+	}
+
+	wantRows, wantKeys := scan()
+	for name, opts := range map[string][]OpenOption{
+		"arena":           {WithRecordArena()},
+		"arena+zero copy": {WithRecordArena(), WithZeroCopy()},
+	} {
+		rows, keys := scan(opts...)
+		if len(rows) != 2001 || !reflect.DeepEqual(rows, wantRows) {
+			t.Errorf("%s: TableScan() yielded %d records differing from the default scan", name, len(rows))
+		}
+		if !reflect.DeepEqual(keys, wantKeys) {
+			t.Errorf("%s: IndexScan() yielded records differing from the default scan", name)
+		}
+	}
+}
+
+func TestPage_detachRecordsBulk(t *testing.T) {
+	db, err := Open(createTestDB(t, "arena_detach.sqlite"), WithRecordArena())
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	table, err := db.Table("test")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+	// Find a leaf page of the table.
+	page, err := db.ReadPage(table.RootPage)
+	if err != nil {
+		t.Fatalf("ReadPage() failed: %v", err)
+	}
+	for page.Type == PageTypeInteriorTable {
+		if page, err = db.ReadPage(int(page.RightMostPtr)); err != nil {
+			t.Fatalf("ReadPage() failed: %v", err)
+		}
+	}
+	if len(page.LeafCells) < 2 {
+		t.Fatalf("expected a leaf page with several cells, got %d", len(page.LeafCells))
+	}
+	raw := uintptr(unsafe.Pointer(unsafe.SliceData(page.RawData)))
+	var prevEnd uintptr
+	for i, cell := range page.LeafCells {
+		name := cell.Record[1].(string)
+		p := uintptr(unsafe.Pointer(unsafe.StringData(name)))
+		if p >= raw && p < raw+uintptr(len(page.RawData)) {
+			t.Fatalf("cell %d: value %q aliases the page buffer", i, name)
+		}
+		if i > 0 && p != prevEnd {
+			t.Errorf("cell %d: expected the values to be copied into one buffer", i)
+		}
+		prevEnd = p + uintptr(len(name))
+	}
+}
+
+func TestWithRecordArena_allocations(t *testing.T) {
+	dbPath := createTestDB(t, "arena_allocs.sqlite")
+	allocs := func(opts ...OpenOption) float64 {
+		db, err := Open(dbPath, opts...)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		defer db.Close()
+		index, err := db.Index("idx_name")
+		if err != nil {
+			t.Fatalf("Index() failed: %v", err)
+		}
+		return testing.AllocsPerRun(5, func() {
+			for _, err := range db.IndexScan(index) {
+				if err != nil {
+					t.Fatalf("IndexScan() failed: %v", err)
+				}
+			}
+		})
+	}
+	plain, arena := allocs(), allocs(WithRecordArena())
+	if arena >= plain/2 {
+		t.Errorf("expected far fewer allocations with an arena, got %.0f against %.0f", arena, plain)
+	}
+}
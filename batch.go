@@ -0,0 +1,161 @@
+package golite
+
+import (
+	"fmt"
+	"iter"
+)
+
+// RowBatchIterator is an iterator over batches of rows, as returned by
+// TableScanBatches. The slices it yields are reused: they, and their backing
+// arrays, are only valid until the next iteration. The rows themselves can be
+// kept.
+type RowBatchIterator = iter.Seq2[[]Row, error]
+
+// DefaultBatchSize is the number of rows per batch used when a batch size of
+// 0 or less is given.
+const DefaultBatchSize = 256
+
+// TableScanBatches is like TableScan, but yields the rows of the table in
+// batches of up to n rows, n being DefaultBatchSize if it is 0 or less. Large
+// scans that process the rows of a batch in a plain loop avoid the cost of
+// one yield call per row. The batch slice is reused, see RowBatchIterator.
+func (db *Database) TableScanBatches(table TableInfo, n int) RowBatchIterator {
+	if table.WithoutRowID || table.Virtual {
+		return BatchRows(table, db.TableScan(table), n)
+	}
+	return interruptible(db, func(yield func([]Row, error) bool) {
+		batch := make([]Row, 0, batchSize(n))
+		ok := db.tableScanPage(table.RootPage, table, func(record Record, err error) bool {
+			if err != nil {
+				if len(batch) > 0 && !yield(batch, nil) {
+					return false
+				}
+				yield(nil, err)
+				return false
+			}
+			batch = append(batch, Row{Table: table, Record: record})
+			if len(batch) == cap(batch) {
+				if !yield(batch, nil) {
+					return false
+				}
+				batch = batch[:0]
+			}
+			return true
+		})
+		if ok && len(batch) > 0 {
+			yield(batch, nil)
+		}
+	})
+}
+
+// BatchRows adapts an iterator over the records of a table into an iterator
+// over batches of up to n rows, n being DefaultBatchSize if it is 0 or less.
+// An error from records ends the iteration, after the rows read before it
+// have been yielded. The batch slice is reused, see RowBatchIterator.
+func BatchRows(table TableInfo, records RecordIterator, n int) RowBatchIterator {
+	return func(yield func([]Row, error) bool) {
+		batch := make([]Row, 0, batchSize(n))
+		for record, err := range records {
+			if err != nil {
+				if len(batch) > 0 && !yield(batch, nil) {
+					return
+				}
+				yield(nil, err)
+				return
+			}
+			batch = append(batch, Row{Table: table, Record: record})
+			if len(batch) == cap(batch) {
+				if !yield(batch, nil) {
+					return
+				}
+				batch = batch[:0]
+			}
+		}
+		if len(batch) > 0 {
+			yield(batch, nil)
+		}
+	}
+}
+
+// batchSize returns n, or DefaultBatchSize if n is 0 or less.
+func batchSize(n int) int {
+	if n <= 0 {
+		return DefaultBatchSize
+	}
+	return n
+}
+
+// FilterBatches is the batched counterpart of Filter: it yields, for each
+// batch of input, the rows for which predicate returns true. Batches with no
+// such rows are skipped, so the batches yielded may be smaller than those of
+// input. The batch slice is reused, see RowBatchIterator.
+func FilterBatches(input RowBatchIterator, predicate func(row Row) (bool, error)) RowBatchIterator {
+	return func(yield func([]Row, error) bool) {
+		var out []Row
+		for batch, err := range input {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			out = out[:0]
+			for _, row := range batch {
+				matches, err := predicate(row)
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				if matches {
+					out = append(out, row)
+				}
+			}
+			if len(out) > 0 && !yield(out, nil) {
+				return
+			}
+		}
+	}
+}
+
+// ProjectBatches yields, for each batch of input, the values of the named
+// columns of each row, in that order, as Row.Get returns them. The records
+// yielded and their values share a reused backing array, so they too are
+// only valid until the next iteration; Copy the records to keep. It fails if
+// a column does not exist.
+func ProjectBatches(input RowBatchIterator, columns []string) iter.Seq2[[]Record, error] {
+	return func(yield func([]Record, error) bool) {
+		var (
+			out     []Record
+			values  []any
+			indexes = make([]int, len(columns))
+		)
+		for batch, err := range input {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if len(batch) == 0 {
+				continue
+			}
+			table := batch[0].Table
+			for i, name := range columns {
+				if indexes[i] = table.lookupColumn(name); indexes[i] == -1 {
+					yield(nil, fmt.Errorf("no such column: %s", name))
+					return
+				}
+			}
+			if need := len(batch) * len(columns); cap(values) < need {
+				values = make([]any, need)
+			}
+			out = out[:0]
+			for j, row := range batch {
+				record := Record(values[j*len(columns) : (j+1)*len(columns) : (j+1)*len(columns)])
+				for i, column := range indexes {
+					record[i] = table.columnValue(row.Record, column)
+				}
+				out = append(out, record)
+			}
+			if !yield(out, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package golite
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTableScanBatches(t *testing.T) {
+	db, err := Open(createTestDB(t, "batch.sqlite"))
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	table, err := db.Table("test")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+	want, err := CollectRecords(db.TableScan(table))
+	if err != nil {
+		t.Fatalf("TableScan() failed: %v", err)
+	}
+
+	for _, n := range []int{0, 1, 7, 500, 1000} {
+		var got []Record
+		batches := 0
+		for batch, err := range db.TableScanBatches(table, n) {
+			if err != nil {
+				t.Fatalf("TableScanBatches(%d) failed: %v", n, err)
+			}
+			if size := batchSize(n); len(batch) > size || len(batch) == 0 {
+				t.Errorf("TableScanBatches(%d) yielded a batch of %d rows", n, len(batch))
+			}
+			batches++
+			for _, row := range batch {
+				got = append(got, row.Record)
+			}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("TableScanBatches(%d) yielded %d rows, want the %d rows of TableScan", n, len(got), len(want))
+		}
+		if size := batchSize(n); batches != (len(want)+size-1)/size {
+			t.Errorf("TableScanBatches(%d) yielded %d batches", n, batches)
+		}
+	}
+
+	// Stopping early must not fail.
+	for batch, err := range db.TableScanBatches(table, 10) {
+		if err != nil || len(batch) != 10 {
+			t.Errorf("unexpected first batch (%d rows, %v)", len(batch), err)
+		}
+		break
+	}
+}
+
+func TestFilterProjectBatches(t *testing.T) {
+	db, err := Open(createTestDB(t, "batch.sqlite"))
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	table, err := db.Table("test")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+
+	even := func(row Row) (bool, error) {
+		id, _ := row.Get("id")
+		return id.(int64)%2 == 0, nil
+	}
+	var ids []int64
+	var names []any
+	for batch, err := range ProjectBatches(FilterBatches(db.TableScanBatches(table, 64), even), []string{"id", "name"}) {
+		if err != nil {
+			t.Fatalf("ProjectBatches() failed: %v", err)
+		}
+		for _, record := range batch {
+			ids = append(ids, record[0].(int64))
+			names = append(names, record[1])
+		}
+	}
+	if len(ids) != 250 || ids[0] != 2 || ids[249] != 500 {
+		t.Errorf("expected the 250 even ids, got %d ids from %v to %v", len(ids), ids[0], ids[len(ids)-1])
+	}
+	if names[0] != "name2" {
+		t.Errorf("expected the name of row 2, got %v", names[0])
+	}
+
+	for _, err := range ProjectBatches(db.TableScanBatches(table, 0), []string{"nope"}) {
+		if err == nil {
+			t.Errorf("expected an error for an unknown column")
+		}
+	}
+
+	errBoom := errors.New("boom")
+	count := 0
+	var lastErr error
+	for batch, err := range BatchRows(table, numbers(25, errBoom), 10) {
+		count += len(batch)
+		lastErr = err
+	}
+	if count != 25 || !errors.Is(lastErr, errBoom) {
+		t.Errorf("expected 25 rows then the error, got %d rows and %v", count, lastErr)
+	}
+	failing := func(Row) (bool, error) { return false, errBoom }
+	for _, err := range FilterBatches(BatchRows(table, numbers(5, nil), 0), failing) {
+		if !errors.Is(err, errBoom) {
+			t.Errorf("expected the predicate error, got %v", err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package golite
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+// benchmarkSizes are the numbers of rows in the benchmark databases.
+var benchmarkSizes = []int{1000, 10000, 100000}
+
+// openBenchmarkDB creates a database with a table bench(id INTEGER PRIMARY
+// KEY, name TEXT, value REAL) holding rows rows, and an index on name.
+func openBenchmarkDB(b *testing.B, rows int) (*Database, TableInfo, IndexInfo) {
+	b.Helper()
+	dbPath := filepath.Join(b.TempDir(), "bench.sqlite")
+	runSQL(b, dbPath, fmt.Sprintf(`
+		CREATE TABLE bench(id INTEGER PRIMARY KEY, name TEXT, value REAL);
+		WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < %d)
+		INSERT INTO bench SELECT i, 'name' || i, i * 0.5 FROM n;
+		CREATE INDEX bench_name ON bench(name);
+	`, rows))
+	db, err := Open(dbPath)
+	if err != nil {
+		b.Fatalf("Open() failed with error: %v", err)
+	}
+	b.Cleanup(func() { db.Close() })
+	table, err := db.Table("bench")
+	if err != nil {
+		b.Fatalf("Table() failed: %v", err)
+	}
+	index, err := db.Index("bench_name")
+	if err != nil {
+		b.Fatalf("Index() failed: %v", err)
+	}
+	return db, table, index
+}
+
+// reportStats reports the database counters per benchmark iteration.
+func reportStats(b *testing.B, db *Database, before Stats) {
+	after := db.Stats()
+	b.ReportMetric(float64(after.PagesRead-before.PagesRead)/float64(b.N), "pages/op")
+	b.ReportMetric(float64(after.RecordsDecoded-before.RecordsDecoded)/float64(b.N), "records/op")
+}
+
+func BenchmarkTableSeek(b *testing.B) {
+	for _, rows := range benchmarkSizes {
+		b.Run(fmt.Sprintf("rows=%d", rows), func(b *testing.B) {
+			db, table, _ := openBenchmarkDB(b, rows)
+			before := db.Stats()
+			b.ResetTimer()
+			for i := range b.N {
+				for _, err := range db.TableSeek(table, int64(i%rows+1)) {
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+			reportStats(b, db, before)
+		})
+	}
+}
+
+func BenchmarkIndexSeek(b *testing.B) {
+	for _, rows := range benchmarkSizes {
+		b.Run(fmt.Sprintf("rows=%d", rows), func(b *testing.B) {
+			db, _, index := openBenchmarkDB(b, rows)
+			before := db.Stats()
+			b.ResetTimer()
+			for i := range b.N {
+				key := Record{fmt.Sprintf("name%d", i%rows+1)}
+				for _, err := range db.IndexSeek(index, key) {
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+			reportStats(b, db, before)
+		})
+	}
+}
+
+func BenchmarkTableScan(b *testing.B) {
+	for _, rows := range benchmarkSizes {
+		b.Run(fmt.Sprintf("rows=%d", rows), func(b *testing.B) {
+			db, table, _ := openBenchmarkDB(b, rows)
+			before := db.Stats()
+			b.ResetTimer()
+			for range b.N {
+				for _, err := range db.TableScan(table) {
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+			reportStats(b, db, before)
+		})
+	}
+}
+
+func BenchmarkIndexScan(b *testing.B) {
+	for _, rows := range benchmarkSizes {
+		b.Run(fmt.Sprintf("rows=%d", rows), func(b *testing.B) {
+			db, _, index := openBenchmarkDB(b, rows)
+			before := db.Stats()
+			b.ResetTimer()
+			for range b.N {
+				for _, err := range db.IndexScan(index) {
+					if err != nil {
+						b.Fatal(err)
+					}
+				}
+			}
+			reportStats(b, db, before)
+		})
+	}
+}
@@ -0,0 +1,810 @@
+package golite
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// builderPageSize is the page size of the databases written by a
+// DatabaseBuilder, SQLite's default.
+const builderPageSize = 4096
+
+// DatabaseBuilder writes a new database file from the records of iterators,
+// in a single pass: the pages of a table are written as its rows are
+// inserted, each leaf being filled before the next is started, and the
+// interior pages above them as they fill in turn. The entries of its indexes
+// are kept in memory and sorted when the table is done, as are the rows of a
+// WITHOUT ROWID table. The schema and the file header are written last, by
+// Finalize.
+//
+// Its methods can be chained: once one fails, the following do nothing, and
+// Finalize reports the error, removing the incomplete file.
+type DatabaseBuilder struct {
+	path string
+	file *os.File
+	w    *bufio.Writer
+	// pages is the number of pages of the file so far, page 1 being written
+	// last.
+	pages int
+	// schema holds the rows of sqlite_schema, their rootPage being set when
+	// the B-Tree is written.
+	schema []schemaRow
+	// table is the table being filled, or nil.
+	table *builtTable
+	// sequences holds the largest rowid of the AUTOINCREMENT tables, by name,
+	// in the order they were created.
+	sequences []sequenceRow
+	err       error
+}
+
+// sequenceRow is a row of sqlite_sequence.
+type sequenceRow struct {
+	name string
+	seq  int64
+}
+
+// builtTable is a table being filled by a DatabaseBuilder.
+type builtTable struct {
+	info   TableInfo
+	schema int // The index of its row in DatabaseBuilder.schema.
+	// tree receives the rows of a rowid table, while those of a WITHOUT
+	// ROWID table are collected in rows.
+	tree    *btreeWriter
+	rows    []Record
+	indexes []*builtIndex
+	// lastRowID is the rowid of the last row inserted, if there is one.
+	lastRowID     int64
+	hasRows       bool
+	autoincrement bool
+}
+
+// builtIndex is an index of a table being filled by a DatabaseBuilder.
+type builtIndex struct {
+	info    IndexInfo
+	schema  int   // The index of its row in DatabaseBuilder.schema.
+	columns []int // The indexes in the table's Columns of its columns.
+	entries []Record
+}
+
+// NewDatabaseBuilder returns a builder writing a new database file at path.
+// It is an error if the file exists.
+func NewDatabaseBuilder(path string) *DatabaseBuilder {
+	b := &DatabaseBuilder{path: path}
+	b.file, b.err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if b.err != nil {
+		return b
+	}
+	b.w = bufio.NewWriter(b.file)
+	// Page 1 is overwritten by Finalize.
+	b.writePage(make([]byte, builderPageSize))
+	return b
+}
+
+// CreateTable adds a table, given its CREATE TABLE statement, to the
+// database, along with the indexes SQLite creates for its UNIQUE and PRIMARY
+// KEY constraints. The rows of the table are then added with InsertFrom, and
+// its other indexes with CreateIndex. Tables with generated columns are not
+// supported, nor indexes on columns whose collating sequence is not BINARY.
+func (b *DatabaseBuilder) CreateTable(sql string) *DatabaseBuilder {
+	if b.err != nil {
+		return b
+	}
+	if b.err = b.finishTable(); b.err != nil {
+		return b
+	}
+	sql = strings.TrimRight(strings.TrimSpace(sql), ";")
+	name, err := createdName(sql, "TABLE")
+	if err != nil {
+		b.err = err
+		return b
+	}
+	def, err := parseCreateTable(sql)
+	if err != nil {
+		b.err = fmt.Errorf("failed to parse schema for table %q: %w", name, err)
+		return b
+	}
+	if def.withoutRowID && def.primaryKey == nil {
+		b.err = fmt.Errorf("PRIMARY KEY missing on table %s", name)
+		return b
+	}
+	for _, row := range b.schema {
+		if strings.EqualFold(row.name, name) {
+			b.err = fmt.Errorf("%s %s already exists", row.kind, name)
+			return b
+		}
+	}
+	for _, col := range def.columns {
+		if col.Hidden != ColumnNormal {
+			b.err = unsupported("generated columns")
+			return b
+		}
+	}
+	t := &builtTable{
+		info: TableInfo{
+			Name:             name,
+			SQL:              sql,
+			Columns:          def.columns,
+			RowIDColumnIndex: def.rowIDColumnIndex,
+			ForeignKeys:      def.foreignKeys,
+			Checks:           def.checks,
+			WithoutRowID:     def.withoutRowID,
+			PrimaryKey:       def.primaryKey,
+		},
+		schema:        len(b.schema),
+		autoincrement: strings.Contains(strings.ToUpper(sql), "AUTOINCREMENT"),
+	}
+	if !t.info.WithoutRowID {
+		t.tree = &btreeWriter{b: b}
+	}
+	b.schema = append(b.schema, schemaRow{kind: "table", name: name, tableName: name, sql: sql})
+	b.table = t
+
+	for i, key := range def.uniqueKeys {
+		if key == nil {
+			continue
+		}
+		columns := make([]IndexColumn, len(key))
+		for j, name := range key {
+			columns[j].Name = name
+		}
+		if slices.Equal(key, columnNamesOf(def.primaryKey)) {
+			columns = slices.Clone(def.primaryKey)
+		}
+		index := IndexInfo{
+			Name:      fmt.Sprintf("sqlite_autoindex_%s_%d", name, i+1),
+			TableName: name,
+			Columns:   columns,
+			Unique:    true,
+		}
+		if b.err = b.addIndex(index, SQLNull); b.err != nil {
+			return b
+		}
+	}
+	return b
+}
+
+// columnNamesOf returns the names of index columns.
+func columnNamesOf(columns []IndexColumn) []string {
+	names := make([]string, len(columns))
+	for i, col := range columns {
+		names[i] = col.Name
+	}
+	return names
+}
+
+// CreateIndex adds an index, given its CREATE INDEX statement, to the table
+// created last, which must have no rows yet. Indexes on expressions, partial
+// indexes and indexes with a COLLATE clause are not supported.
+func (b *DatabaseBuilder) CreateIndex(sql string) *DatabaseBuilder {
+	if b.err != nil {
+		return b
+	}
+	sql = strings.TrimRight(strings.TrimSpace(sql), ";")
+	name, err := createdName(sql, "INDEX")
+	if err != nil {
+		b.err = err
+		return b
+	}
+	tokens := tokenizeSQL(sql)
+	on := slices.IndexFunc(tokens, func(tok sqlToken) bool { return tok.is("ON") })
+	if on == -1 || on+1 == len(tokens) {
+		b.err = fmt.Errorf("invalid CREATE INDEX statement: missing ON clause")
+		return b
+	}
+	tableName := tokens[on+1].unquoted()
+	if slices.ContainsFunc(tokens, func(tok sqlToken) bool { return tok.isAny("COLLATE", "WHERE") }) {
+		b.err = unsupported("partial indexes and indexes with a COLLATE clause")
+		return b
+	}
+	t := b.table
+	switch {
+	case t == nil || !strings.EqualFold(t.info.Name, tableName):
+		b.err = fmt.Errorf("cannot create index %s: %s is not the table created last", name, tableName)
+		return b
+	case t.hasRows || len(t.rows) > 0:
+		b.err = fmt.Errorf("cannot create index %s: table %s already has rows", name, tableName)
+		return b
+	}
+	for _, row := range b.schema {
+		if strings.EqualFold(row.name, name) {
+			b.err = fmt.Errorf("%s %s already exists", row.kind, name)
+			return b
+		}
+	}
+	def, err := parseCreateIndex(sql)
+	if err != nil {
+		b.err = fmt.Errorf("failed to parse schema for index %q: %w", name, err)
+		return b
+	}
+	index := IndexInfo{Name: name, TableName: t.info.Name, SQL: sql, Columns: def.columns, Unique: def.unique}
+	b.err = b.addIndex(index, sql)
+	return b
+}
+
+// addIndex adds an index of the table created last, whose SQL is sql or
+// SQLNull for an automatic index.
+func (b *DatabaseBuilder) addIndex(index IndexInfo, sql any) error {
+	t := b.table
+	columns := make([]int, len(index.Columns))
+	for i, col := range index.Columns {
+		column := t.info.lookupColumn(col.Name)
+		switch {
+		case col.Name == "":
+			return unsupported("indexes on expressions")
+		case column < 0:
+			return fmt.Errorf("no such column: %s", col.Name)
+		}
+		if !binaryCollation(t.info.Columns[column].Collation) {
+			return unsupported("indexes on columns with a collating sequence other than BINARY")
+		}
+		index.Columns[i].affinity = t.info.Columns[column].Affinity()
+		columns[i] = column
+	}
+	if t.info.WithoutRowID {
+		// The entries of the indexes of a WITHOUT ROWID table end with the
+		// columns of the primary key they do not already hold.
+		for _, col := range t.info.PrimaryKey {
+			column := t.info.lookupColumn(col.Name)
+			if !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+	}
+	t.indexes = append(t.indexes, &builtIndex{info: index, schema: len(b.schema), columns: columns})
+	b.schema = append(b.schema, schemaRow{kind: "index", name: index.Name, tableName: t.info.Name, sql: sql})
+	return nil
+}
+
+// createdName returns the name of the table or index created by a CREATE
+// statement, the last token before the parenthesis or the ON clause.
+func createdName(sql, kind string) (string, error) {
+	tokens := tokenizeSQL(sql)
+	end := slices.IndexFunc(tokens, func(tok sqlToken) bool { return tok.text == "(" || tok.is("ON") || tok.is("AS") })
+	if len(tokens) < 3 || !tokens[0].is("CREATE") || end < 2 || !slices.ContainsFunc(tokens[:end], func(tok sqlToken) bool { return tok.is(kind) }) {
+		return "", fmt.Errorf("invalid CREATE %s statement", kind)
+	}
+	if tokens[1].isAny("TEMP", "TEMPORARY", "VIRTUAL") {
+		return "", unsupported("temporary and virtual tables")
+	}
+	if tokens[end-1].is(kind) || tokens[end-1].is("EXISTS") {
+		return "", fmt.Errorf("invalid CREATE %s statement: missing name", kind)
+	}
+	return tokens[end-1].unquoted(), nil
+}
+
+// InsertFrom adds the records of rows to the table created last. Each
+// record holds the values of the columns of the table in the order they are
+// declared, to which the affinity of the column is applied. The rows of a
+// table with a rowid must come in increasing rowid order: the rowid is the
+// value of its INTEGER PRIMARY KEY column, or, if it is NULL or the table has
+// none, one more than that of the previous row. Other constraints than the
+// uniqueness of PRIMARY KEY and UNIQUE columns are not checked.
+func (b *DatabaseBuilder) InsertFrom(rows RecordIterator) *DatabaseBuilder {
+	if b.err != nil {
+		return b
+	}
+	t := b.table
+	if t == nil {
+		b.err = errors.New("no table to insert into")
+		return b
+	}
+	for record, err := range rows {
+		if err != nil {
+			b.err = err
+			return b
+		}
+		if b.err = b.insert(t, record); b.err != nil {
+			return b
+		}
+	}
+	return b
+}
+
+// insert adds a row to a table.
+func (b *DatabaseBuilder) insert(t *builtTable, record Record) error {
+	if len(record) != len(t.info.Columns) {
+		return fmt.Errorf("table %s has %d columns but %d values were supplied", t.info.Name, len(t.info.Columns), len(record))
+	}
+	values := make(Record, len(record))
+	for i, value := range record {
+		if value == nil {
+			value = SQLNull
+		}
+		values[i] = t.info.Columns[i].Affinity().apply(value)
+	}
+
+	if t.info.WithoutRowID {
+		for _, col := range t.info.PrimaryKey {
+			if isNull(values[t.info.lookupColumn(col.Name)]) {
+				return fmt.Errorf("NOT NULL constraint failed: %s.%s", t.info.Name, col.Name)
+			}
+		}
+		t.rows = append(t.rows, values)
+		for _, index := range t.indexes {
+			index.add(values, nil)
+		}
+		return nil
+	}
+
+	rowID := t.lastRowID + 1
+	if i := t.info.RowIDColumnIndex; i != -1 && !isNull(values[i]) {
+		id, ok := values[i].(int64)
+		if !ok {
+			return fmt.Errorf("datatype mismatch: %s.%s is not an integer", t.info.Name, t.info.Columns[i].Name)
+		}
+		if t.hasRows && id <= t.lastRowID {
+			return fmt.Errorf("rows of table %s must be inserted in increasing rowid order, got %d after %d", t.info.Name, id, t.lastRowID)
+		}
+		rowID = id
+	}
+	t.lastRowID, t.hasRows = rowID, true
+
+	for _, index := range t.indexes {
+		index.add(values, rowID)
+	}
+	stored := values
+	if i := t.info.RowIDColumnIndex; i != -1 {
+		stored = slices.Clone(values)
+		stored[i] = SQLNull // The rowid is not stored in the record.
+	}
+	payload, err := EncodeRecord(stored)
+	if err != nil {
+		return err
+	}
+	return t.tree.addTableRow(rowID, payload)
+}
+
+// add adds the entry of a row to the index, the values of the row being
+// followed by its rowid unless rowID is nil.
+func (index *builtIndex) add(values Record, rowID any) {
+	entry := make(Record, 0, len(index.columns)+1)
+	for _, column := range index.columns {
+		entry = append(entry, values[column])
+	}
+	if rowID != nil {
+		entry = append(entry, rowID)
+	}
+	index.entries = append(index.entries, entry)
+}
+
+// compareKeys compares the first n values of two records, the columns
+// flagged in desc being sorted in descending order.
+func compareKeys(a, b Record, desc []bool) int {
+	for i := range a {
+		c := compareValues(a[i], b[i])
+		if i < len(desc) && desc[i] {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// finishTable writes the indexes of the table created last, and its rows if
+// it is a WITHOUT ROWID table, and sets the root pages of their schema rows.
+func (b *DatabaseBuilder) finishTable() error {
+	t := b.table
+	if t == nil {
+		return nil
+	}
+	b.table = nil
+	if t.autoincrement {
+		b.sequences = append(b.sequences, sequenceRow{t.info.Name, t.lastRowID})
+	}
+
+	if t.info.WithoutRowID {
+		desc := make([]bool, len(t.info.PrimaryKey))
+		for i, col := range t.info.PrimaryKey {
+			desc[i] = col.Desc
+		}
+		order := t.info.StorageOrder()
+		records := make([]Record, len(t.rows))
+		for i, row := range t.rows {
+			records[i] = make(Record, len(order))
+			for j, column := range order {
+				records[i][j] = row[column]
+			}
+		}
+		t.rows = nil
+		root, err := b.writeIndexTree(records, len(desc), desc, true, "PRIMARY KEY", t.info.Name, columnNamesOf(t.info.PrimaryKey))
+		if err != nil {
+			return err
+		}
+		b.schema[t.schema].rootPage = root
+	} else {
+		root, err := t.tree.finish()
+		if err != nil {
+			return err
+		}
+		b.schema[t.schema].rootPage = root
+	}
+
+	for _, index := range t.indexes {
+		desc := make([]bool, len(index.info.Columns))
+		for i, col := range index.info.Columns {
+			desc[i] = col.Desc
+		}
+		kind := "UNIQUE"
+		if index.info.SQL == "" && slices.Equal(columnNamesOf(index.info.Columns), columnNamesOf(t.info.PrimaryKey)) {
+			kind = "PRIMARY KEY"
+		}
+		root, err := b.writeIndexTree(index.entries, len(desc), desc, index.info.Unique, kind, t.info.Name, columnNamesOf(index.info.Columns))
+		index.entries = nil
+		if err != nil {
+			return err
+		}
+		b.schema[index.schema].rootPage = root
+	}
+	return nil
+}
+
+// writeIndexTree sorts the records of an index B-Tree by their first n
+// values, then by the others in ascending order, and writes it, returning
+// its root page. If unique, two records must not have the same first n
+// values unless one of them is NULL, as the named constraint on the columns
+// of table requires.
+func (b *DatabaseBuilder) writeIndexTree(records []Record, n int, desc []bool, unique bool, constraint, table string, columns []string) (int, error) {
+	slices.SortStableFunc(records, func(x, y Record) int {
+		if c := compareKeys(x[:n], y[:n], desc); c != 0 {
+			return c
+		}
+		return CompareRecords(x[n:], y[n:])
+	})
+	tree := &btreeWriter{b: b, index: true}
+	for i, record := range records {
+		if unique && i > 0 && compareKeys(record[:n], records[i-1][:n], desc) == 0 && !slices.ContainsFunc(record[:n], isNull) {
+			qualified := make([]string, len(columns))
+			for j, name := range columns {
+				qualified[j] = table + "." + name
+			}
+			return 0, fmt.Errorf("%s constraint failed: %s", constraint, strings.Join(qualified, ", "))
+		}
+		payload, err := EncodeRecord(record)
+		if err != nil {
+			return 0, err
+		}
+		if err := tree.addIndexEntry(payload); err != nil {
+			return 0, err
+		}
+	}
+	return tree.finish()
+}
+
+// Finalize writes the schema and the file header, and closes the file. It
+// returns the first error met by the builder, in which case the file is
+// removed.
+func (b *DatabaseBuilder) Finalize() error {
+	if b.file == nil {
+		return b.err
+	}
+	if b.err == nil {
+		b.err = b.finishTable()
+	}
+	if b.err == nil && len(b.sequences) > 0 {
+		b.err = b.writeSequences()
+	}
+	if b.err == nil {
+		b.err = b.writeFirstPage()
+	}
+	if err := b.file.Close(); b.err == nil {
+		b.err = err
+	}
+	if b.err != nil {
+		os.Remove(b.path)
+	}
+	b.file = nil
+	return b.err
+}
+
+// writeSequences writes sqlite_sequence, which SQLite creates along with the
+// first AUTOINCREMENT table, holding the largest rowid of each.
+func (b *DatabaseBuilder) writeSequences() error {
+	tree := &btreeWriter{b: b}
+	for i, row := range b.sequences {
+		payload, err := EncodeRecord(Record{row.name, row.seq})
+		if err != nil {
+			return err
+		}
+		if err := tree.addTableRow(int64(i+1), payload); err != nil {
+			return err
+		}
+	}
+	root, err := tree.finish()
+	if err != nil {
+		return err
+	}
+	// SQLite lists it right after the first AUTOINCREMENT table and its
+	// automatic indexes.
+	first := slices.IndexFunc(b.schema, func(row schemaRow) bool {
+		return row.kind == "table" && strings.EqualFold(row.name, b.sequences[0].name)
+	})
+	for first+1 < len(b.schema) && b.schema[first+1].sql == SQLNull {
+		first++
+	}
+	row := schemaRow{kind: "table", name: "sqlite_sequence", tableName: "sqlite_sequence", rootPage: root, sql: "CREATE TABLE sqlite_sequence(name,seq)"}
+	b.schema = slices.Insert(b.schema, first+1, row)
+	return nil
+}
+
+// writeFirstPage writes the schema, whose B-Tree is rooted at page 1, and
+// the file header.
+func (b *DatabaseBuilder) writeFirstPage() error {
+	tree := &btreeWriter{b: b, firstPage: true}
+	for i, row := range b.schema {
+		payload, err := EncodeRecord(Record{row.kind, row.name, row.tableName, int64(row.rootPage), row.sql})
+		if err != nil {
+			return err
+		}
+		if err := tree.addTableRow(int64(i+1), payload); err != nil {
+			return err
+		}
+	}
+	if _, err := tree.finish(); err != nil {
+		return err
+	}
+	if err := b.w.Flush(); err != nil {
+		return err
+	}
+
+	data := tree.root
+	copy(data, HeaderString)
+	binary.BigEndian.PutUint16(data[16:18], builderPageSize)
+	data[18], data[19] = 1, 1
+	data[21], data[22], data[23] = 64, 32, 32                // Payload fractions.
+	binary.BigEndian.PutUint32(data[24:28], 1)               // Change counter.
+	binary.BigEndian.PutUint32(data[28:32], uint32(b.pages)) // Database size.
+	binary.BigEndian.PutUint32(data[40:44], 1)               // Schema cookie.
+	binary.BigEndian.PutUint32(data[44:48], 4)               // Schema format.
+	binary.BigEndian.PutUint32(data[56:60], 1)               // UTF-8.
+	binary.BigEndian.PutUint32(data[92:96], 1)               // Version valid for.
+	_, err := b.file.WriteAt(data, 0)
+	return err
+}
+
+// writePage appends a page to the file and returns its number.
+func (b *DatabaseBuilder) writePage(data []byte) int {
+	if b.err == nil {
+		_, b.err = b.w.Write(data)
+	}
+	b.pages++
+	return b.pages
+}
+
+// btreeWriter writes a B-Tree whose entries are added in order. It fills
+// pages from the leaves up: when a page is full, it is written and a cell
+// pointing to it is added to its parent, so that only the last page of each
+// level is kept in memory. As a full interior page gives its last cell to
+// its parent, keeping the child of that cell as its right-most pointer, the
+// pages that are written all hold cells.
+type btreeWriter struct {
+	b *DatabaseBuilder
+	// index is true for an index B-Tree, and false for a table B-Tree.
+	index bool
+	// firstPage is true for the B-Tree of sqlite_schema, whose root is
+	// page 1, kept in root by finish rather than written.
+	firstPage bool
+	root      []byte
+	// levels holds the page being filled at each level, the leaves first.
+	levels []*pageCells
+	// lastRowID is the rowid of the last row added to a table B-Tree.
+	lastRowID int64
+}
+
+// pageCells is the content of a page being filled.
+type pageCells struct {
+	cells [][]byte
+	size  int // The size of the cells and of their pointers.
+}
+
+// usableSize is the number of bytes of a page available to a B-Tree, as no
+// bytes are reserved.
+const usableSize = builderPageSize
+
+// fits reports whether a cell can be added to a page with the given header
+// size.
+func (p *pageCells) fits(cell []byte, headerSize int) bool {
+	return headerSize+p.size+len(cell)+2 <= usableSize
+}
+
+func (p *pageCells) add(cell []byte) {
+	p.cells = append(p.cells, cell)
+	p.size += len(cell) + 2
+}
+
+// level returns the page being filled at level i, adding it if needed.
+func (w *btreeWriter) level(i int) *pageCells {
+	if i == len(w.levels) {
+		w.levels = append(w.levels, &pageCells{})
+	}
+	return w.levels[i]
+}
+
+// spill appends to cell the number of the first overflow page holding rest,
+// and writes these pages, unless rest is empty.
+func (w *btreeWriter) spill(cell, rest []byte) []byte {
+	if len(rest) == 0 {
+		return cell
+	}
+	const chunk = usableSize - 4
+	first := w.b.pages + 1
+	pages := (len(rest) + chunk - 1) / chunk
+	for i := range pages {
+		data := make([]byte, builderPageSize)
+		if i < pages-1 {
+			binary.BigEndian.PutUint32(data, uint32(first+i+1))
+		}
+		copy(data[4:], rest[i*chunk:])
+		w.b.writePage(data)
+	}
+	return binary.BigEndian.AppendUint32(cell, uint32(first))
+}
+
+// addTableRow adds a row to a table B-Tree. Rows must be added in
+// increasing rowid order.
+func (w *btreeWriter) addTableRow(rowID int64, payload []byte) error {
+	cell := appendVarint(nil, uint64(len(payload)))
+	cell = appendVarint(cell, uint64(rowID))
+	local := int(localPayloadSize(int64(len(payload)), usableSize, true))
+	cell = w.spill(append(cell, payload[:local]...), payload[local:])
+
+	leaf := w.level(0)
+	if !leaf.fits(cell, 8) {
+		// The leaf is full: it is written, and the largest rowid it holds
+		// becomes the key of its cell in the parent.
+		pageNum := w.writeLeaf(leaf)
+		w.levels[0] = &pageCells{}
+		if err := w.addInterior(1, pageNum, appendVarint(nil, uint64(w.lastRowID))); err != nil {
+			return err
+		}
+		leaf = w.levels[0]
+	}
+	leaf.add(cell)
+	w.lastRowID = rowID
+	return nil
+}
+
+// addIndexEntry adds an entry to an index B-Tree. Entries must be added in
+// index order.
+func (w *btreeWriter) addIndexEntry(payload []byte) error {
+	cell := appendVarint(nil, uint64(len(payload)))
+	local := int(localPayloadSize(int64(len(payload)), usableSize, false))
+	cell = w.spill(append(cell, payload[:local]...), payload[local:])
+	leaf := w.level(0)
+	if !leaf.fits(cell, 8) {
+		// The leaf is full: its last entry moves to the parent, in a cell
+		// pointing to the rest of the leaf.
+		last := leaf.cells[len(leaf.cells)-1]
+		leaf.cells = leaf.cells[:len(leaf.cells)-1]
+		pageNum := w.writeLeaf(leaf)
+		w.levels[0] = &pageCells{}
+		if err := w.addInterior(1, pageNum, last); err != nil {
+			return err
+		}
+		leaf = w.levels[0]
+	}
+	leaf.add(cell)
+	return nil
+}
+
+// addInterior adds a cell pointing to the page child, followed by key, to
+// the interior page at level i.
+func (w *btreeWriter) addInterior(i, child int, key []byte) error {
+	cell := binary.BigEndian.AppendUint32(nil, uint32(child))
+	cell = append(cell, key...)
+	page := w.level(i)
+	if !page.fits(cell, 12) {
+		// The page is full: its last cell moves to the parent, the page
+		// keeping the child of the cell as its right-most pointer.
+		last := page.cells[len(page.cells)-1]
+		page.cells = page.cells[:len(page.cells)-1]
+		pageNum := w.b.writePage(w.interiorPage(page, int(binary.BigEndian.Uint32(last)), 0))
+		w.levels[i] = &pageCells{}
+		if err := w.addInterior(i+1, pageNum, last[4:]); err != nil {
+			return err
+		}
+		page = w.levels[i]
+	}
+	page.add(cell)
+	return nil
+}
+
+// finish writes the pages being filled and returns the root page number.
+// The root of the B-Tree of sqlite_schema is kept in root instead.
+func (w *btreeWriter) finish() (int, error) {
+	leaf := w.level(0)
+	if len(w.levels) == 1 {
+		return w.finishRoot(w.leafPage(leaf, w.firstPage))
+	}
+	child := w.writeLeaf(leaf)
+	for i := 1; i < len(w.levels)-1; i++ {
+		child = w.b.writePage(w.interiorPage(w.levels[i], child, 0))
+	}
+	return w.finishRoot(w.interiorPage(w.levels[len(w.levels)-1], child, w.headerOffset()))
+}
+
+// finishRoot writes the root page, unless it is page 1.
+func (w *btreeWriter) finishRoot(data []byte) (int, error) {
+	if !w.firstPage {
+		return w.b.writePage(data), nil
+	}
+	if len(data) > builderPageSize {
+		return 0, errors.New("schema is too large for the first page")
+	}
+	w.root = data
+	return 1, nil
+}
+
+// headerOffset returns the offset of the page header of the root page.
+func (w *btreeWriter) headerOffset() int {
+	if w.firstPage {
+		return HeaderSize
+	}
+	return 0
+}
+
+// writeLeaf writes a leaf page, which is not the root.
+func (w *btreeWriter) writeLeaf(p *pageCells) int {
+	return w.b.writePage(w.leafPage(p, false))
+}
+
+// leafPage returns the content of a leaf page. On page 1 it follows the file
+// header, and may then need more than a page, see finishRoot.
+func (w *btreeWriter) leafPage(p *pageCells, first bool) []byte {
+	pageType := byte(PageTypeLeafTable)
+	if w.index {
+		pageType = PageTypeLeafIndex
+	}
+	offset := 0
+	if first {
+		offset = HeaderSize
+	}
+	return buildPage(pageType, p.cells, 0, offset)
+}
+
+// interiorPage returns the content of an interior page, whose right-most
+// pointer is rightMost.
+func (w *btreeWriter) interiorPage(p *pageCells, rightMost, offset int) []byte {
+	pageType := byte(PageTypeInteriorTable)
+	if w.index {
+		pageType = PageTypeInteriorIndex
+	}
+	return buildPage(pageType, p.cells, rightMost, offset)
+}
+
+// buildPage returns the content of a B-Tree page whose header starts at
+// offset, with the cells stored in order from the end of the page. If they
+// do not fit, the result is longer than a page.
+func buildPage(pageType byte, cells [][]byte, rightMost, offset int) []byte {
+	headerSize := 8
+	if pageType == PageTypeInteriorTable || pageType == PageTypeInteriorIndex {
+		headerSize = 12
+	}
+	size := offset + headerSize + 2*len(cells)
+	for _, cell := range cells {
+		size += len(cell)
+	}
+	data := make([]byte, max(builderPageSize, size))
+	content := len(data)
+	pointer := offset + headerSize
+	for _, cell := range cells {
+		content -= len(cell)
+		copy(data[content:], cell)
+		binary.BigEndian.PutUint16(data[pointer:], uint16(content))
+		pointer += 2
+	}
+	header := data[offset:]
+	header[0] = pageType
+	binary.BigEndian.PutUint16(header[3:5], uint16(len(cells)))
+	binary.BigEndian.PutUint16(header[5:7], uint16(content))
+	if headerSize == 12 {
+		binary.BigEndian.PutUint32(header[8:12], uint32(rightMost))
+	}
+	return data
+}
@@ -0,0 +1,172 @@
+package golite
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// builderRows returns the rows i = 1..n of a table, made by row.
+func builderRows(n int, row func(i int) Record) RecordIterator {
+	return func(yield func(Record, error) bool) {
+		for i := 1; i <= n; i++ {
+			if !yield(row(i), nil) {
+				return
+			}
+		}
+	}
+}
+
+func TestDatabaseBuilder(t *testing.T) {
+	dir := t.TempDir()
+	built := filepath.Join(dir, "built.sqlite")
+	err := NewDatabaseBuilder(built).
+		CreateTable("CREATE TABLE a (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT UNIQUE, n REAL, big BLOB)").
+		CreateIndex("CREATE INDEX a_n ON a(n DESC, id)").
+		InsertFrom(builderRows(3000, func(i int) Record {
+			var big any = SQLNull
+			if i%250 == 0 {
+				big = make([]byte, 10000)
+			}
+			return Record{int64(2 * i), fmt.Sprintf("%0200d", i), int64(i % 17), big}
+		})).
+		CreateTable("CREATE TABLE b (x INTEGER, y TEXT)").
+		InsertFrom(builderRows(20000, func(i int) Record { return Record{int64(i % 100), int64(i)} })).
+		CreateTable("CREATE TABLE w (k TEXT, v INTEGER UNIQUE, PRIMARY KEY (k DESC)) WITHOUT ROWID").
+		CreateIndex("CREATE INDEX w_k ON w(k)").
+		InsertFrom(builderRows(500, func(i int) Record { return Record{fmt.Sprintf("k%0100d", i), int64(-i)} })).
+		CreateTable("CREATE TABLE empty (e TEXT)").
+		Finalize()
+	if err != nil {
+		t.Fatalf("Finalize() failed: %v", err)
+	}
+
+	reference := filepath.Join(dir, "reference.sqlite")
+	runSQL(t, reference, `
+		CREATE TABLE a (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT UNIQUE, n REAL, big BLOB);
+		CREATE INDEX a_n ON a(n DESC, id);
+		INSERT INTO a SELECT 2 * value, printf('%0200d', value), value % 17, CASE WHEN value % 250 = 0 THEN zeroblob(10000) END FROM generate_series(1, 3000);
+		CREATE TABLE b (x INTEGER, y TEXT);
+		INSERT INTO b SELECT value % 100, value FROM generate_series(1, 20000);
+		CREATE TABLE w (k TEXT, v INTEGER UNIQUE, PRIMARY KEY (k DESC)) WITHOUT ROWID;
+		CREATE INDEX w_k ON w(k);
+		INSERT INTO w SELECT printf('k%0100d', value), -value FROM generate_series(1, 500);
+		CREATE TABLE empty (e TEXT);
+	`)
+
+	query := func(path, sql string) string {
+		t.Helper()
+		output, err := exec.Command("sqlite3", path, sql).CombinedOutput()
+		if err != nil {
+			t.Fatalf("sqlite3 failed: %v\nOutput: %s", err, output)
+		}
+		return string(output)
+	}
+	if got := query(built, "PRAGMA integrity_check;"); got != "ok\n" {
+		t.Errorf("integrity_check = %q", got)
+	}
+	for _, sql := range []string{
+		"SELECT type, name, tbl_name, sql FROM sqlite_schema;",
+		"SELECT * FROM sqlite_sequence;",
+		"SELECT id, name, typeof(n), n, length(big) FROM a ORDER BY id;",
+		"SELECT id FROM a INDEXED BY a_n WHERE n < 5 ORDER BY n DESC, id;",
+		"SELECT id FROM a INDEXED BY sqlite_autoindex_a_1 WHERE name > '0000' ORDER BY name;",
+		"SELECT typeof(x), typeof(y), sum(y) FROM b GROUP BY x;",
+		"SELECT k, v FROM w ORDER BY k DESC;",
+		"SELECT k FROM w INDEXED BY w_k WHERE k > 'k00' ORDER BY k;",
+		"SELECT k FROM w INDEXED BY sqlite_autoindex_w_1 WHERE v < -100 ORDER BY v;",
+		"SELECT count(*) FROM empty;",
+	} {
+		if got, want := query(built, sql), query(reference, sql); got != want {
+			t.Errorf("%s: results differ", sql)
+		}
+	}
+	// The database is writable.
+	if got := query(built, "INSERT INTO a (name) VALUES ('new'); SELECT id FROM a WHERE name = 'new';"); got != "6001\n" {
+		t.Errorf("inserted row has rowid %q", got)
+	}
+
+	db, err := Open(built)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	table, err := db.Table("b")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+	records, err := CollectRecords(db.TableScan(table))
+	if err != nil {
+		t.Fatalf("TableScan() failed: %v", err)
+	}
+	if len(records) != 20000 || table.RowID(records[19999]) != 20000 {
+		t.Errorf("TableScan() returned %d records", len(records))
+	}
+}
+
+func TestDatabaseBuilderErrors(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name  string
+		build func(b *DatabaseBuilder) *DatabaseBuilder
+	}{
+		{"duplicate unique", func(b *DatabaseBuilder) *DatabaseBuilder {
+			return b.CreateTable("CREATE TABLE t (a TEXT UNIQUE)").
+				InsertFrom(builderRows(3, func(i int) Record { return Record{"same"} }))
+		}},
+		{"decreasing rowid", func(b *DatabaseBuilder) *DatabaseBuilder {
+			return b.CreateTable("CREATE TABLE t (id INTEGER PRIMARY KEY)").
+				InsertFrom(builderRows(3, func(i int) Record { return Record{int64(10 - i)} }))
+		}},
+		{"duplicate primary key", func(b *DatabaseBuilder) *DatabaseBuilder {
+			return b.CreateTable("CREATE TABLE t (k INTEGER PRIMARY KEY) WITHOUT ROWID").
+				InsertFrom(builderRows(3, func(i int) Record { return Record{int64(i % 2)} }))
+		}},
+		{"column count", func(b *DatabaseBuilder) *DatabaseBuilder {
+			return b.CreateTable("CREATE TABLE t (a INTEGER, b INTEGER)").
+				InsertFrom(builderRows(1, func(i int) Record { return Record{int64(i)} }))
+		}},
+		{"index after rows", func(b *DatabaseBuilder) *DatabaseBuilder {
+			return b.CreateTable("CREATE TABLE t (a INTEGER)").
+				InsertFrom(builderRows(1, func(i int) Record { return Record{int64(i)} })).
+				CreateIndex("CREATE INDEX t_a ON t(a)")
+		}},
+		{"index on another table", func(b *DatabaseBuilder) *DatabaseBuilder {
+			return b.CreateTable("CREATE TABLE t (a INTEGER)").CreateTable("CREATE TABLE u (a INTEGER)").
+				CreateIndex("CREATE INDEX t_a ON t(a)")
+		}},
+		{"expression index", func(b *DatabaseBuilder) *DatabaseBuilder {
+			return b.CreateTable("CREATE TABLE t (a INTEGER)").CreateIndex("CREATE INDEX t_a ON t(a + 1)")
+		}},
+		{"generated column", func(b *DatabaseBuilder) *DatabaseBuilder {
+			return b.CreateTable("CREATE TABLE t (a INTEGER, b INTEGER AS (a + 1))")
+		}},
+		{"duplicate table", func(b *DatabaseBuilder) *DatabaseBuilder {
+			return b.CreateTable("CREATE TABLE t (a INTEGER)").CreateTable("CREATE TABLE T (b INTEGER)")
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, test.name+".sqlite")
+			if err := test.build(NewDatabaseBuilder(path)).Finalize(); err == nil {
+				t.Fatalf("Finalize() succeeded")
+			}
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("Finalize() left a file behind after an error")
+			}
+		})
+	}
+
+	path := filepath.Join(dir, "exists.sqlite")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewDatabaseBuilder(path).Finalize(); !os.IsExist(err) {
+		t.Errorf("Finalize() on an existing file: got %v, want an error matching os.IsExist", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("the existing file was removed: %v", err)
+	}
+}
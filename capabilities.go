@@ -0,0 +1,66 @@
+package golite
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Version is the semantic version of the golite library.
+const Version = "0.1.0"
+
+// Features describes which parts of the SQLite file format the current build
+// of golite can handle.
+type Features struct {
+	// Version is the library version, as in the Version constant.
+	Version string
+	// WAL is true if databases in WAL mode can be read, including frames that
+	// have not yet been checkpointed into the main file.
+	WAL bool
+	// WAL2 is true if databases in the WAL2 journal mode, which uses two WAL
+	// files, can be read.
+	WAL2 bool
+	// TextEncodings lists the header text encodings (1: UTF-8, 2: UTF-16le,
+	// 3: UTF-16be) whose TEXT values are decoded correctly by default.
+	// UTF-16 databases can be read by opening them with WithUTF8Text.
+	TextEncodings []uint32
+	// OverflowPages is true if records spilling onto overflow pages can be read.
+	OverflowPages bool
+	// Encryption is true if encrypted databases can be read.
+	Encryption bool
+	// Write is true if databases can be modified.
+	Write bool
+}
+
+// Capabilities reports the format features supported by this build of golite.
+// Higher-level tools can use it to gate functionality, or call CheckHeader to
+// find out whether a particular file can be read in full.
+func Capabilities() Features {
+	return Features{
+		Version:       Version,
+		TextEncodings: []uint32{1},
+	}
+}
+
+// CheckHeader returns an error naming every feature used by the database with
+// the given header that these capabilities do not cover, or nil if the file
+// can be read in full. The error joins one *UnsupportedFeatureError per
+// feature.
+func (f Features) CheckHeader(h *Header) error {
+	var missing []error
+	switch {
+	case h.ReadVersion == 2 && !f.WAL:
+		missing = append(missing, unsupported("WAL journal mode"))
+	case h.ReadVersion == wal2Version && !f.WAL2:
+		missing = append(missing, unsupported("WAL2 journal mode"))
+	}
+	encodingSupported := false
+	for _, enc := range f.TextEncodings {
+		if enc == h.TextEncoding {
+			encodingSupported = true
+		}
+	}
+	if !encodingSupported {
+		missing = append(missing, unsupported(fmt.Sprintf("text encoding %d", h.TextEncoding)))
+	}
+	return errors.Join(missing...)
+}
@@ -0,0 +1,66 @@
+package golite
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestCapabilities(t *testing.T) {
+	caps := Capabilities()
+	if caps.Version != Version {
+		t.Errorf("expected Version %q, got %q", Version, caps.Version)
+	}
+	if caps.Write {
+		t.Error("expected Write to be false for a read-only library")
+	}
+
+	t.Run("supported file", func(t *testing.T) {
+		dbPath := createTestDB(t, "caps_test.sqlite")
+		db, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		defer db.Close()
+
+		if err := caps.CheckHeader(db.Header); err != nil {
+			t.Errorf("CheckHeader() returned an unexpected error: %v", err)
+		}
+	})
+
+	t.Run("WAL file", func(t *testing.T) {
+		dbPath := createTestDB(t, "caps_wal_test.sqlite")
+		cmd := exec.Command("sqlite3", dbPath, "PRAGMA journal_mode=WAL;")
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("failed to enable WAL mode: %v\nOutput: %s", err, string(output))
+		}
+		db, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		defer db.Close()
+
+		if db.Header.ReadVersion != 2 || db.Header.WriteVersion != 2 {
+			t.Errorf("expected read/write versions 2/2, got %d/%d", db.Header.ReadVersion, db.Header.WriteVersion)
+		}
+		err = caps.CheckHeader(db.Header)
+		if err == nil || !strings.Contains(err.Error(), "WAL") {
+			t.Errorf("expected an error mentioning WAL, got %v", err)
+		}
+	})
+
+	t.Run("WAL2 file", func(t *testing.T) {
+		h := &Header{ReadVersion: 3, WriteVersion: 3, TextEncoding: 1}
+		err := caps.CheckHeader(h)
+		if err == nil || !strings.Contains(err.Error(), "WAL2") {
+			t.Errorf("expected an error mentioning WAL2, got %v", err)
+		}
+	})
+
+	t.Run("UTF-16 file", func(t *testing.T) {
+		h := &Header{ReadVersion: 1, WriteVersion: 1, TextEncoding: 2}
+		if err := caps.CheckHeader(h); err == nil {
+			t.Error("expected an error for a UTF-16le database")
+		}
+	})
+}
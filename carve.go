@@ -0,0 +1,212 @@
+package golite
+
+import (
+	"iter"
+	"sort"
+)
+
+// CarvedRecord is the remnant of a deleted row found by CarveDeleted.
+type CarvedRecord struct {
+	// PageNum is the page the remnant was found on.
+	PageNum int
+	// Offset is the byte offset of the record within the page.
+	Offset int
+	// Record holds the values of the table's declared columns, as returned by
+	// TableInfo.ColumnValues. A rowid alias column holds the rowid if
+	// HasRowID, and is NULL otherwise.
+	Record Record
+	// RowID is the rowid of the deleted row, if HasRowID. Freeing a cell
+	// into a freeblock overwrites its rowid, so it is only known for cells
+	// found intact in the unallocated gap.
+	RowID    int64
+	HasRowID bool
+}
+
+// CarveDeleted searches the pages of a table's B-Tree for remnants of deleted
+// rows. When SQLite deletes a cell it only unlinks it, leaving the bytes in
+// a freeblock or in the unallocated gap between the cell pointer array and
+// the cell content area until they are reused. CarveDeleted walks each
+// page's freeblock chain and that gap, and yields every byte range that
+// decodes as a record with the table's column count.
+//
+// The first four bytes of a freed cell are overwritten by the freeblock
+// header, which destroys the cell's payload size and rowid and often the
+// start of the record header. CarveDeleted reconstructs the header when the
+// lost part can be inferred from the schema. Cells in the unallocated gap
+// keep their header, so their rowid is recovered too. Matching is heuristic:
+// unrelated bytes may occasionally decode as a plausible record.
+func (db *Database) CarveDeleted(table TableInfo) iter.Seq2[CarvedRecord, error] {
+	return interruptible(db, func(yield func(CarvedRecord, error) bool) {
+		owners := make(map[int]TableInfo)
+		db.collectTablePages(table.RootPage, table, owners)
+		pageNums := make([]int, 0, len(owners))
+		for pageNum := range owners {
+			pageNums = append(pageNums, pageNum)
+		}
+		sort.Ints(pageNums)
+
+		columnCount := len(table.Columns)
+		for _, pageNum := range pageNums {
+			page, err := db.ReadPage(pageNum)
+			if err != nil || page.Type != PageTypeLeafTable {
+				continue
+			}
+			for _, region := range unallocatedRegions(page) {
+				for offset := region.start; offset < region.end; offset++ {
+					data := page.RawData[offset:region.end]
+					carved := CarvedRecord{PageNum: pageNum, Offset: offset}
+					var cellHeaderSize, size int
+					var ok bool
+					if region.freeblock {
+						carved.Record, size, ok = carveFreedCell(data, columnCount, table.RowIDColumnIndex)
+					} else if carved.Record, carved.RowID, cellHeaderSize, size, ok = carveCell(data, columnCount, table.RowIDColumnIndex); ok {
+						carved.Offset += cellHeaderSize
+						carved.HasRowID = true
+						if table.RowIDColumnIndex != -1 {
+							carved.Record[table.RowIDColumnIndex] = carved.RowID
+						}
+					} else {
+						carved.Record, size, ok = carveRecord(data, columnCount, table.RowIDColumnIndex)
+					}
+					if !ok {
+						continue
+					}
+					db.ownValues(carved.Record)
+					db.decodeText(carved.Record)
+					if !yield(carved, nil) {
+						return
+					}
+					offset += size - 1
+					if region.freeblock {
+						// Adjacent freed cells are merged into one freeblock, but
+						// each of them lost its first four bytes when it was
+						// freed. Try the next cell before scanning byte by byte.
+						next := offset + 1 + 4
+						if next < region.end {
+							if _, _, ok := carveFreedCell(page.RawData[next:region.end], columnCount, table.RowIDColumnIndex); ok {
+								offset = next - 1
+							}
+						}
+					}
+				}
+			}
+		}
+	})
+}
+
+// unallocatedRegions returns the unallocated gap and the body of each
+// freeblock of a page.
+func unallocatedRegions(page *Page) []freeRegion {
+	var regions []freeRegion
+	pointersEnd := page.cellPointersEnd()
+	contentStart := min(page.cellContentStart(), len(page.RawData))
+	if pointersEnd < contentStart {
+		regions = append(regions, freeRegion{start: pointersEnd, end: contentStart})
+	}
+	return append(regions, page.freeblocks()...)
+}
+
+// carveCell attempts to decode an intact leaf table cell at the start of
+// data: its payload size and rowid varints, followed by a record of exactly
+// that size with columnCount columns, as carveRecord decodes it. It returns
+// the record, the rowid, the size of the two varints and that of the cell.
+func carveCell(data []byte, columnCount, rowIDColumnIndex int) (record Record, rowID int64, headerSize, size int, ok bool) {
+	payloadSize, n, err := readVarintChecked(data)
+	if err != nil || payloadSize <= 0 {
+		return nil, 0, 0, 0, false
+	}
+	rowID, m, err := readVarintChecked(data[n:])
+	if err != nil || payloadSize > int64(len(data)-n-m) {
+		return nil, 0, 0, 0, false
+	}
+	headerSize = n + m
+	record, size, ok = carveRecord(data[headerSize:headerSize+int(payloadSize)], columnCount, rowIDColumnIndex)
+	if !ok || size != int(payloadSize) {
+		return nil, 0, 0, 0, false
+	}
+	return record, rowID, headerSize, headerSize + size, true
+}
+
+// carveFreedCell attempts to decode the record of a cell whose first four
+// bytes were overwritten by a freeblock header; data starts just after those
+// bytes. The lost bytes always hold the payload size and rowid varints and,
+// depending on their lengths, the record header size and possibly the first
+// serial type. The hypotheses tried are, in order: the record is intact; only
+// the header size was lost; the header size and the first serial type were
+// lost, which can be recovered only if the first column is a rowid alias
+// (whose serial type is always 0).
+func carveFreedCell(data []byte, columnCount, rowIDColumnIndex int) (Record, int, bool) {
+	if record, size, ok := carveRecord(data, columnCount, rowIDColumnIndex); ok {
+		return record, size, true
+	}
+	if record, size, ok := carveHeaderless(data, nil, columnCount, rowIDColumnIndex); ok {
+		return record, size, true
+	}
+	if rowIDColumnIndex == 0 {
+		return carveHeaderless(data, []int64{0}, columnCount, rowIDColumnIndex)
+	}
+	return nil, 0, false
+}
+
+// carveHeaderless decodes a record whose header size varint and leading
+// serial types are missing. lostTypes supplies the missing serial types, and
+// data starts with the remaining ones, immediately followed by the body.
+func carveHeaderless(data []byte, lostTypes []int64, columnCount, rowIDColumnIndex int) (Record, int, bool) {
+	serialTypes := append([]int64(nil), lostTypes...)
+	offset := 0
+	for len(serialTypes) < columnCount {
+		st, m, err := readVarintChecked(data[offset:])
+		if err != nil || st == 10 || st == 11 {
+			return nil, 0, false
+		}
+		serialTypes = append(serialTypes, st)
+		offset += m
+	}
+	if !plausibleSerialTypes(serialTypes, columnCount, rowIDColumnIndex) {
+		return nil, 0, false
+	}
+	record, bodySize, err := decodeRecordBody(serialTypes, data[offset:], nil)
+	if err != nil {
+		return nil, 0, false
+	}
+	return record, offset + bodySize, true
+}
+
+// plausibleSerialTypes reports whether serialTypes could describe a row of a
+// table with the given column count and rowid alias column.
+func plausibleSerialTypes(serialTypes []int64, columnCount, rowIDColumnIndex int) bool {
+	if len(serialTypes) != columnCount {
+		return false
+	}
+	return rowIDColumnIndex == -1 || serialTypes[rowIDColumnIndex] == 0
+}
+
+// carveRecord attempts to decode a record with exactly columnCount columns at
+// the start of data. If rowIDColumnIndex is not -1, that column must be NULL,
+// as it always is for rows stored in a table with a rowid alias.
+func carveRecord(data []byte, columnCount, rowIDColumnIndex int) (Record, int, bool) {
+	if columnCount == 0 {
+		return nil, 0, false
+	}
+	headerSize, n, err := readVarintChecked(data)
+	if err != nil || headerSize <= int64(n) || headerSize > int64(len(data)) || headerSize > int64(n+9*columnCount) {
+		return nil, 0, false
+	}
+	var serialTypes []int64
+	for offset := n; offset < int(headerSize); {
+		st, m, err := readVarintChecked(data[offset:headerSize])
+		if err != nil || st == 10 || st == 11 {
+			return nil, 0, false
+		}
+		serialTypes = append(serialTypes, st)
+		offset += m
+	}
+	if !plausibleSerialTypes(serialTypes, columnCount, rowIDColumnIndex) {
+		return nil, 0, false
+	}
+	record, size, err := parseRecordPrefix(data)
+	if err != nil {
+		return nil, 0, false
+	}
+	return record, size, true
+}
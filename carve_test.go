@@ -0,0 +1,100 @@
+package golite
+
+import (
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDatabase_CarveDeleted(t *testing.T) {
+	dbPath := createTestDB(t, "carve_test.sqlite")
+	deleteCmd := exec.Command("sqlite3", dbPath, "PRAGMA secure_delete = OFF; DELETE FROM test WHERE id BETWEEN 100 AND 110 OR id BETWEEN 200 AND 210;")
+	if output, err := deleteCmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to delete rows: %v\nOutput: %s", err, string(output))
+	}
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+
+	schema, err := db.GetSchema()
+	if err != nil {
+		t.Fatalf("GetSchema() failed: %v", err)
+	}
+	testTable := schema.Tables["test"]
+
+	found := make(map[string]bool)
+	for carved, err := range db.CarveDeleted(testTable) {
+		if err != nil {
+			t.Fatalf("CarveDeleted() returned an unexpected error: %v", err)
+		}
+		if len(carved.Record) != 2 {
+			t.Fatalf("expected carved records to have 2 columns, got %d", len(carved.Record))
+		}
+		if name, ok := carved.Record[1].(string); ok {
+			found[name] = true
+		}
+	}
+
+	for _, name := range []string{"name100", "name105", "name110", "name200", "name210"} {
+		if !found[name] {
+			t.Errorf("expected to carve deleted row %q", name)
+		}
+	}
+	if found["name99"] || found["name111"] {
+		t.Error("carved a row that was never deleted")
+	}
+}
+
+func TestDatabase_CarveDeleted_RowID(t *testing.T) {
+	// The most recent rows are at the start of the cell content area, so
+	// deleting them widens the unallocated gap instead of making freeblocks,
+	// and their cells are left intact.
+	dbPath := filepath.Join(t.TempDir(), "carve_rowid.sqlite")
+	runSQL(t, dbPath, `PRAGMA secure_delete = OFF;
+CREATE TABLE alias (id INTEGER PRIMARY KEY, v TEXT);
+CREATE TABLE plain (v TEXT);
+INSERT INTO alias VALUES (1, 'a'), (2, 'b'), (3, 'c');
+INSERT INTO plain (rowid, v) VALUES (1, 'a'), (2, 'b'), (3, 'c');
+DELETE FROM alias WHERE id = 3;
+DELETE FROM plain WHERE rowid = 3;`)
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	schema, err := db.GetSchema()
+	if err != nil {
+		t.Fatalf("GetSchema() failed: %v", err)
+	}
+
+	for name, want := range map[string]Record{"alias": {int64(3), "c"}, "plain": {"c"}} {
+		var carved []CarvedRecord
+		for c, err := range db.CarveDeleted(schema.Tables[name]) {
+			if err != nil {
+				t.Fatalf("CarveDeleted() returned an unexpected error: %v", err)
+			}
+			carved = append(carved, c)
+		}
+		if len(carved) != 1 || !carved[0].HasRowID || carved[0].RowID != 3 || !reflect.DeepEqual(carved[0].Record, want) {
+			t.Errorf("%s: expected to carve row 3 %v, got %+v", name, want, carved)
+		}
+	}
+}
+
+func FuzzCarveFreedCell(f *testing.F) {
+	f.Add([]byte{0x03, 0x00, 0x0f, 0x61}, 2, 0)
+	f.Add([]byte{0x00, 0x0f, 0x61}, 2, 0)
+	f.Add([]byte{0x0f, 0x61}, 2, 0)
+	f.Fuzz(func(t *testing.T, data []byte, columnCount, rowIDColumnIndex int) {
+		// Carving must never panic, whatever the bytes of the page.
+		if columnCount < 0 || columnCount > 100 || rowIDColumnIndex < -1 || rowIDColumnIndex >= columnCount {
+			return
+		}
+		carveFreedCell(data, columnCount, rowIDColumnIndex)
+		carveCell(data, columnCount, rowIDColumnIndex)
+	})
+}
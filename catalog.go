@@ -0,0 +1,182 @@
+package golite
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Catalog groups several open databases under schema names, like the
+// databases attached to an SQLite connection with ATTACH, so that tables can
+// be referred to as "schema.table". The first database is named "main".
+// A Catalog is safe for concurrent use.
+type Catalog struct {
+	mu    sync.RWMutex
+	names []string // In the order the databases were attached.
+	dbs   map[string]*Database
+}
+
+// NewCatalog returns a Catalog holding main under the name "main".
+func NewCatalog(main *Database) *Catalog {
+	return &Catalog{names: []string{"main"}, dbs: map[string]*Database{"main": main}}
+}
+
+// Attach adds db to the catalog under the given schema name. Schema names are
+// matched ignoring case and must be unique.
+func (c *Catalog) Attach(name string, db *Database) error {
+	key := strings.ToLower(name)
+	if key == "" || strings.Contains(key, ".") {
+		return fmt.Errorf("invalid schema name %q", name)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.dbs[key]; ok {
+		return fmt.Errorf("database %s is already in use", name)
+	}
+	c.names = append(c.names, key)
+	c.dbs[key] = db
+	return nil
+}
+
+// Detach removes the named database from the catalog, without closing it.
+// The main database cannot be detached.
+func (c *Catalog) Detach(name string) error {
+	key := strings.ToLower(name)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if key == "main" {
+		return errors.New("cannot detach database main")
+	}
+	if _, ok := c.dbs[key]; !ok {
+		return fmt.Errorf("no such database: %s", name)
+	}
+	delete(c.dbs, key)
+	c.names = slices.DeleteFunc(c.names, func(n string) bool { return n == key })
+	return nil
+}
+
+// Names returns the schema names of the databases in the catalog, in the
+// order they were attached, starting with "main".
+func (c *Catalog) Names() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return slices.Clone(c.names)
+}
+
+// Database returns the database attached under the given schema name.
+func (c *Catalog) Database(name string) (*Database, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	db, ok := c.dbs[strings.ToLower(name)]
+	return db, ok
+}
+
+// Table resolves a table reference, either qualified as "schema.table" or
+// unqualified, and returns the database holding the table together with its
+// schema information. As in SQLite, an unqualified name is looked up in each
+// database in the order they were attached, starting with main.
+func (c *Catalog) Table(ref string) (*Database, TableInfo, error) {
+	db, err := c.resolve(ref, func(db *Database, name string) (bool, error) {
+		schema, err := db.GetSchema()
+		if err != nil {
+			return false, err
+		}
+		_, ok := schema.Tables[name]
+		return ok, nil
+	})
+	if err != nil {
+		return nil, TableInfo{}, err
+	}
+	table, err := db.Table(objectName(ref))
+	if err != nil {
+		return nil, TableInfo{}, err
+	}
+	return db, table, nil
+}
+
+// Index resolves an index reference the way Table resolves a table
+// reference.
+func (c *Catalog) Index(ref string) (*Database, IndexInfo, error) {
+	db, err := c.resolve(ref, func(db *Database, name string) (bool, error) {
+		schema, err := db.GetSchema()
+		if err != nil {
+			return false, err
+		}
+		_, ok := schema.Indexes[name]
+		return ok, nil
+	})
+	if err != nil {
+		return nil, IndexInfo{}, err
+	}
+	index, err := db.Index(objectName(ref))
+	if err != nil {
+		return nil, IndexInfo{}, err
+	}
+	return db, index, nil
+}
+
+// TableScan resolves a table reference as Table does and scans the table.
+func (c *Catalog) TableScan(ref string) RecordIterator {
+	db, table, err := c.Table(ref)
+	if err != nil {
+		return func(yield func(Record, error) bool) { yield(nil, err) }
+	}
+	return db.TableScan(table)
+}
+
+// TableSeek resolves a table reference as Table does and seeks the row with
+// the given rowid in the table.
+func (c *Catalog) TableSeek(ref string, rowID int64) RecordIterator {
+	db, table, err := c.Table(ref)
+	if err != nil {
+		return func(yield func(Record, error) bool) { yield(nil, err) }
+	}
+	return db.TableSeek(table, rowID)
+}
+
+// Close closes all the databases in the catalog.
+func (c *Catalog) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var errs []error
+	for _, name := range c.names {
+		errs = append(errs, c.dbs[name].Close())
+	}
+	return errors.Join(errs...)
+}
+
+// resolve returns the database holding the object named by ref, using has to
+// tell whether a database holds an object of the given name.
+func (c *Catalog) resolve(ref string, has func(db *Database, name string) (bool, error)) (*Database, error) {
+	schemaName, _, qualified := strings.Cut(ref, ".")
+	name := objectName(ref)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if qualified {
+		db, ok := c.dbs[strings.ToLower(schemaName)]
+		if !ok {
+			return nil, fmt.Errorf("no such database: %s", schemaName)
+		}
+		return db, nil
+	}
+	for _, key := range c.names {
+		ok, err := has(c.dbs[key], name)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return c.dbs[key], nil
+		}
+	}
+	return nil, fmt.Errorf("no such object: %s", ref)
+}
+
+// objectName returns the object name of a possibly qualified reference.
+func objectName(ref string) string {
+	if _, name, ok := strings.Cut(ref, "."); ok {
+		return name
+	}
+	return ref
+}
@@ -0,0 +1,128 @@
+package golite
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCatalog(t *testing.T) {
+	mainPath := createTestDB(t, "catalog_main.sqlite")
+	auxPath := filepath.Join(t.TempDir(), "catalog_aux.sqlite")
+	runSQL(t, auxPath, `
+		CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT);
+		CREATE TABLE test (x TEXT);
+		INSERT INTO users VALUES (1, 'a@x'), (2, 'b@x');
+		INSERT INTO test VALUES ('aux');
+	`)
+	mainDB, err := Open(mainPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	auxDB, err := Open(auxPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	catalog := NewCatalog(mainDB)
+	defer catalog.Close()
+	if err := catalog.Attach("Aux", auxDB); err != nil {
+		t.Fatalf("Attach() failed: %v", err)
+	}
+	if err := catalog.Attach("aux", auxDB); err == nil {
+		t.Errorf("expected an error attaching the same name twice")
+	}
+	if got := catalog.Names(); !slices.Equal(got, []string{"main", "aux"}) {
+		t.Errorf("expected names [main aux], got %v", got)
+	}
+
+	testCases := []struct {
+		ref    string
+		db     *Database
+		rows   int
+		errors bool
+	}{
+		{ref: "users", db: auxDB, rows: 2},
+		{ref: "aux.users", db: auxDB, rows: 2},
+		{ref: "test", db: mainDB, rows: 500},
+		{ref: "AUX.test", db: auxDB, rows: 1},
+		{ref: "main.users", errors: true},
+		{ref: "other.users", errors: true},
+		{ref: "missing", errors: true},
+	}
+	for _, tc := range testCases {
+		db, _, err := catalog.Table(tc.ref)
+		if (err != nil) != tc.errors || db != tc.db {
+			t.Errorf("Table(%q) = (%p, %v), want database %p", tc.ref, db, err, tc.db)
+			continue
+		}
+		records, err := CollectRecords(catalog.TableScan(tc.ref))
+		if (err != nil) != tc.errors || len(records) != tc.rows {
+			t.Errorf("TableScan(%q) = (%d records, %v), want %d records", tc.ref, len(records), err, tc.rows)
+		}
+	}
+
+	if records, err := CollectRecords(catalog.TableSeek("aux.users", 2)); err != nil || len(records) != 1 || records[0][1] != "b@x" {
+		t.Errorf("TableSeek() = (%v, %v)", records, err)
+	}
+	if db, _, err := catalog.Index("idx_name"); err != nil || db != mainDB {
+		t.Errorf("Index() = (%p, %v), want the main database", db, err)
+	}
+
+	if err := catalog.Detach("main"); err == nil {
+		t.Errorf("expected an error detaching main")
+	}
+	if err := catalog.Detach("aux"); err != nil {
+		t.Fatalf("Detach() failed: %v", err)
+	}
+	if _, _, err := catalog.Table("users"); err == nil {
+		t.Errorf("expected the detached tables to be unreachable")
+	}
+	auxDB.Close()
+}
+
+func TestJoin_AcrossDatabases(t *testing.T) {
+	dir := t.TempDir()
+	usersPath, ordersPath := filepath.Join(dir, "users.sqlite"), filepath.Join(dir, "orders.sqlite")
+	runSQL(t, usersPath, `
+		CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);
+		INSERT INTO users VALUES (1, 'ann'), (2, 'bob');
+	`)
+	runSQL(t, ordersPath, `
+		CREATE TABLE orders (id INTEGER PRIMARY KEY, user_id INTEGER, item TEXT);
+		CREATE INDEX orders_user ON orders (user_id);
+		INSERT INTO orders VALUES (10, 2, 'pen'), (11, 1, 'ink'), (12, 2, 'pad');
+	`)
+	usersDB, err := Open(usersPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	ordersDB, err := Open(ordersPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	catalog := NewCatalog(usersDB)
+	defer catalog.Close()
+	if err := catalog.Attach("shop", ordersDB); err != nil {
+		t.Fatalf("Attach() failed: %v", err)
+	}
+	_, index, err := catalog.Index("shop.orders_user")
+	if err != nil {
+		t.Fatalf("Index() failed: %v", err)
+	}
+
+	joined := Join(catalog.TableScan("main.users"), func(user Record) RecordIterator {
+		return Join(ordersDB.IndexSeek(index, Record{user[0]}), func(entry Record) RecordIterator {
+			return catalog.TableSeek("shop.orders", entry[1].(int64))
+		})
+	})
+	var got []string
+	for record, err := range joined {
+		if err != nil {
+			t.Fatalf("Join() returned an unexpected error: %v", err)
+		}
+		got = append(got, record[1].(string)+":"+record[len(record)-1].(string))
+	}
+	if want := []string{"ann:ink", "bob:pen", "bob:pad"}; !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
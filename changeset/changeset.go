@@ -0,0 +1,324 @@
+// Package changeset reads and writes the changesets and patchsets of the
+// session extension of SQLite (sqlite3session_changeset and
+// sqlite3session_patchset), as described in the comments of
+// sqlite3session.c, and computes changesets from two states of a database.
+//
+// A changeset is a sequence of tables, each followed by the changes made to
+// its rows. A change is an INSERT, holding the values of the new row, a
+// DELETE, holding those of the old row, or an UPDATE, holding both. Only the
+// values needed to identify the row and those that changed are recorded in
+// an UPDATE; the others are Undefined. A patchset is a more compact form in
+// which a DELETE holds only the primary key of the row, and an UPDATE only
+// the primary key and the new values of the changed columns.
+package changeset
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+
+	"github.com/arnodel/golite"
+)
+
+// ErrMalformed is returned, wrapped, when the data is not a valid changeset
+// or patchset.
+var ErrMalformed = errors.New("malformed changeset")
+
+// Op is the kind of a change.
+type Op int
+
+// The kinds of changes, with the values of the SQLITE_INSERT, SQLITE_UPDATE
+// and SQLITE_DELETE constants that encode them.
+const (
+	Delete Op = 9
+	Insert Op = 18
+	Update Op = 23
+)
+
+func (op Op) String() string {
+	switch op {
+	case Delete:
+		return "DELETE"
+	case Insert:
+		return "INSERT"
+	case Update:
+		return "UPDATE"
+	}
+	return fmt.Sprintf("Op(%d)", int(op))
+}
+
+// UndefinedType is the type of Undefined.
+type UndefinedType struct{}
+
+// Undefined is the value of the columns a change does not record.
+var Undefined = UndefinedType{}
+
+// Table describes a table whose changes are recorded.
+type Table struct {
+	Name string
+	// PrimaryKey has an entry per column of the table, true for those that
+	// are part of its primary key.
+	PrimaryKey []bool
+	// Patchset is true if the changes to the table are recorded as a
+	// patchset.
+	Patchset bool
+}
+
+// Change is a change to a row of a table.
+type Change struct {
+	Table *Table
+	Op    Op
+	// Indirect is true if the change was made by a trigger or a foreign key
+	// action rather than directly by a statement.
+	Indirect bool
+	// Old holds the values of the row before the change, for a DELETE or an
+	// UPDATE, and New those after it, for an INSERT or an UPDATE. Each has an
+	// entry per column of the table, which is Undefined if it is not
+	// recorded.
+	Old, New golite.Record
+}
+
+// PrimaryKey returns the values of the primary key of the row, taken from
+// New for an INSERT and from Old otherwise.
+func (c Change) PrimaryKey() golite.Record {
+	values := c.Old
+	if c.Op == Insert {
+		values = c.New
+	}
+	var key golite.Record
+	for i, pk := range c.Table.PrimaryKey {
+		if pk && i < len(values) {
+			key = append(key, values[i])
+		}
+	}
+	return key
+}
+
+// Read parses a changeset or a patchset and returns its changes.
+func Read(data []byte) ([]Change, error) {
+	var changes []Change
+	for change, err := range Changes(data) {
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+	return changes, nil
+}
+
+// Changes returns an iterator over the changes of a changeset or a patchset.
+// The changes to a table share the same *Table.
+func Changes(data []byte) iter.Seq2[Change, error] {
+	return func(yield func(Change, error) bool) {
+		r := reader{data: data}
+		var table *Table
+		for r.pos < len(data) {
+			switch op := data[r.pos]; op {
+			case 'T', 'P':
+				t, err := r.readTable()
+				if err != nil {
+					yield(Change{}, err)
+					return
+				}
+				table = t
+			case byte(Insert), byte(Update), byte(Delete):
+				if table == nil {
+					yield(Change{}, r.errorf("change before the first table"))
+					return
+				}
+				change, err := r.readChange(table)
+				if err != nil {
+					yield(Change{}, err)
+					return
+				}
+				if !yield(change, nil) {
+					return
+				}
+			default:
+				yield(Change{}, r.errorf("unexpected byte 0x%02x", op))
+				return
+			}
+		}
+	}
+}
+
+// reader reads the data of a changeset.
+type reader struct {
+	data []byte
+	pos  int
+}
+
+func (r *reader) errorf(format string, args ...any) error {
+	return fmt.Errorf("%w: %s at offset %d", ErrMalformed, fmt.Sprintf(format, args...), r.pos)
+}
+
+// readTable reads a table header: 'T' for a changeset or 'P' for a
+// patchset, the number of columns, a byte per column which is not zero for
+// the columns of the primary key, and the nul-terminated table name.
+func (r *reader) readTable() (*Table, error) {
+	t := &Table{Patchset: r.data[r.pos] == 'P'}
+	r.pos++
+	n, err := r.readVarint()
+	if err != nil {
+		return nil, err
+	}
+	if n == 0 || n > uint64(len(r.data)-r.pos) {
+		return nil, r.errorf("invalid column count %d", n)
+	}
+	t.PrimaryKey = make([]bool, n)
+	for i := range t.PrimaryKey {
+		t.PrimaryKey[i] = r.data[r.pos+i] != 0
+	}
+	r.pos += int(n)
+	end := r.pos
+	for end < len(r.data) && r.data[end] != 0 {
+		end++
+	}
+	if end == len(r.data) {
+		return nil, r.errorf("unterminated table name")
+	}
+	t.Name = string(r.data[r.pos:end])
+	r.pos = end + 1
+	return t, nil
+}
+
+// readChange reads a change: its kind, the indirect flag and the records of
+// its values.
+func (r *reader) readChange(t *Table) (Change, error) {
+	c := Change{Table: t, Op: Op(r.data[r.pos])}
+	r.pos++
+	if r.pos == len(r.data) {
+		return c, r.errorf("truncated change")
+	}
+	c.Indirect = r.data[r.pos] != 0
+	r.pos++
+	var err error
+	switch {
+	case c.Op == Insert:
+		c.New, err = r.readRecord(len(t.PrimaryKey))
+	case c.Op == Delete && t.Patchset:
+		c.Old, err = r.readPrimaryKey(t)
+	case c.Op == Delete:
+		c.Old, err = r.readRecord(len(t.PrimaryKey))
+	case t.Patchset:
+		// The single record holds the primary key, which is the old one,
+		// and the new values of the other columns.
+		c.New, err = r.readRecord(len(t.PrimaryKey))
+		if err == nil {
+			c.Old = make(golite.Record, len(t.PrimaryKey))
+			for i, pk := range t.PrimaryKey {
+				c.Old[i] = Undefined
+				if pk {
+					c.Old[i], c.New[i] = c.New[i], Undefined
+				}
+			}
+		}
+	default:
+		c.Old, err = r.readRecord(len(t.PrimaryKey))
+		if err == nil {
+			c.New, err = r.readRecord(len(t.PrimaryKey))
+		}
+	}
+	return c, err
+}
+
+// readPrimaryKey reads the record of a DELETE in a patchset, which holds the
+// values of the primary key only, and returns the values of all the
+// columns.
+func (r *reader) readPrimaryKey(t *Table) (golite.Record, error) {
+	n := 0
+	for _, pk := range t.PrimaryKey {
+		if pk {
+			n++
+		}
+	}
+	key, err := r.readRecord(n)
+	if err != nil {
+		return nil, err
+	}
+	values := make(golite.Record, len(t.PrimaryKey))
+	for i, pk := range t.PrimaryKey {
+		values[i] = Undefined
+		if pk {
+			values[i], key = key[0], key[1:]
+		}
+	}
+	return values, nil
+}
+
+// readRecord reads n values, each encoded as a type byte followed by its
+// data: 0 for an undefined value, 1 for a 64-bit big-endian integer, 2 for a
+// big-endian IEEE double, 3 and 4 for a text and a blob, whose size comes
+// first as a varint, and 5 for NULL.
+func (r *reader) readRecord(n int) (golite.Record, error) {
+	record := make(golite.Record, n)
+	for i := range record {
+		if r.pos == len(r.data) {
+			return nil, r.errorf("truncated record")
+		}
+		kind := r.data[r.pos]
+		r.pos++
+		switch kind {
+		case 0:
+			record[i] = Undefined
+		case 1, 2:
+			if len(r.data)-r.pos < 8 {
+				return nil, r.errorf("truncated value")
+			}
+			var bits uint64
+			for _, b := range r.data[r.pos : r.pos+8] {
+				bits = bits<<8 | uint64(b)
+			}
+			r.pos += 8
+			if kind == 1 {
+				record[i] = int64(bits)
+			} else {
+				record[i] = math.Float64frombits(bits)
+			}
+		case 3, 4:
+			size, err := r.readVarint()
+			if err != nil {
+				return nil, err
+			}
+			if size > uint64(len(r.data)-r.pos) {
+				return nil, r.errorf("truncated value")
+			}
+			value := r.data[r.pos : r.pos+int(size)]
+			r.pos += int(size)
+			if kind == 3 {
+				record[i] = string(value)
+			} else {
+				record[i] = append([]byte{}, value...)
+			}
+		case 5:
+			record[i] = golite.SQLNull
+		default:
+			r.pos--
+			return nil, r.errorf("invalid value type %d", kind)
+		}
+	}
+	return record, nil
+}
+
+// readVarint reads a variable-length integer in the format of the SQLite
+// file format: up to 8 bytes holding 7 bits each, the high bit being set on
+// all but the last, and a 9th byte holding 8 bits.
+func (r *reader) readVarint() (uint64, error) {
+	var v uint64
+	for i := 0; i < 9; i++ {
+		if r.pos == len(r.data) {
+			return 0, r.errorf("truncated varint")
+		}
+		b := r.data[r.pos]
+		r.pos++
+		if i == 8 {
+			return v<<8 | uint64(b), nil
+		}
+		v = v<<7 | uint64(b&0x7f)
+		if b < 0x80 {
+			return v, nil
+		}
+	}
+	return v, nil
+}
@@ -0,0 +1,122 @@
+package changeset
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/arnodel/golite"
+)
+
+// blob builds changeset data; its methods append to it.
+type blob []byte
+
+func (b blob) table(kind byte, name string, pk ...byte) blob {
+	b = append(b, kind, byte(len(pk)))
+	b = append(b, pk...)
+	return append(append(b, name...), 0)
+}
+
+func (b blob) change(op Op, indirect byte) blob {
+	return append(b, byte(op), indirect)
+}
+
+func (b blob) values(values ...any) blob {
+	for _, v := range values {
+		switch v := v.(type) {
+		case UndefinedType:
+			b = append(b, 0)
+		case int:
+			b = binary.BigEndian.AppendUint64(append(b, 1), uint64(v))
+		case float64:
+			b = binary.BigEndian.AppendUint64(append(b, 2), math.Float64bits(v))
+		case string:
+			b = append(append(b, 3, byte(len(v))), v...)
+		case []byte:
+			b = append(append(b, 4, byte(len(v))), v...)
+		case golite.NullType:
+			b = append(b, 5)
+		}
+	}
+	return b
+}
+
+func TestRead(t *testing.T) {
+	data := blob(nil).
+		table('T', "t", 1, 0, 0).
+		change(Insert, 0).values(1, "one", 1.5).
+		change(Update, 1).values(2, Undefined, 2.5).values(Undefined, Undefined, golite.SQLNull).
+		change(Delete, 0).values(3, []byte{1, 2}, golite.SQLNull).
+		table('P', "u", 0, 1, 1).
+		change(Update, 0).values(10, "a", Undefined).
+		change(Delete, 0).values("b", -1)
+	changes, err := Read(data)
+	if err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+	tTable := &Table{Name: "t", PrimaryKey: []bool{true, false, false}}
+	uTable := &Table{Name: "u", PrimaryKey: []bool{false, true, true}, Patchset: true}
+	u := Undefined
+	want := []Change{
+		{tTable, Insert, false, nil, golite.Record{int64(1), "one", 1.5}},
+		{tTable, Update, true, golite.Record{int64(2), u, 2.5}, golite.Record{u, u, golite.SQLNull}},
+		{tTable, Delete, false, golite.Record{int64(3), []byte{1, 2}, golite.SQLNull}, nil},
+		{uTable, Update, false, golite.Record{u, "a", u}, golite.Record{int64(10), u, u}},
+		{uTable, Delete, false, golite.Record{u, "b", int64(-1)}, nil},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("Read() =\n%v\nwant\n%v", changes, want)
+	}
+	if encoded, err := Encode(changes); err != nil || !bytes.Equal(encoded, data) {
+		t.Errorf("Encode() = %x, %v, want %x", encoded, err, []byte(data))
+	}
+	if changes[0].Table != changes[2].Table {
+		t.Errorf("changes to the same table do not share their *Table")
+	}
+
+	keys := []golite.Record{{int64(1)}, {int64(2)}, {int64(3)}, {"a", u}, {"b", int64(-1)}}
+	for i, change := range changes {
+		if got := change.PrimaryKey(); !reflect.DeepEqual(got, keys[i]) {
+			t.Errorf("changes[%d].PrimaryKey() = %v, want %v", i, got, keys[i])
+		}
+	}
+	if got := changes[1].Op.String(); got != "UPDATE" {
+		t.Errorf("Op.String() = %q", got)
+	}
+
+	n := 0
+	for range Changes(data) {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Errorf("Changes() did not stop after a break")
+	}
+	if changes, err := Read(nil); err != nil || len(changes) != 0 {
+		t.Errorf("Read(nil) = %v, %v", changes, err)
+	}
+}
+
+func TestReadMalformed(t *testing.T) {
+	header := func() blob { return blob(nil).table('T', "t", 1, 0) }
+	n := len(header())
+	tests := map[string][]byte{
+		"change before table": blob(nil).change(Insert, 0).values(1, 2),
+		"unknown byte":        append(header(), 'X'),
+		"unterminated name":   header()[:n-1],
+		"no columns":          blob(nil).table('T', "t"),
+		"truncated change":    header().change(Insert, 0)[:n+1],
+		"truncated record":    header().change(Insert, 0).values(1),
+		"truncated integer":   header().change(Insert, 0).values(1, 2)[:n+10],
+		"truncated text":      header().change(Insert, 0).values(1, "text")[:n+14],
+		"invalid value type":  append(header().change(Delete, 0), 7),
+	}
+	for name, data := range tests {
+		if _, err := Read(data); !errors.Is(err, ErrMalformed) {
+			t.Errorf("%s: got %v, want an error wrapping ErrMalformed", name, err)
+		}
+	}
+}
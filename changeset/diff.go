@@ -0,0 +1,168 @@
+package changeset
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/arnodel/golite"
+)
+
+// DiffToChangeset returns a changeset holding the changes that turn the rows
+// of the named tables in before into those in after, as the session
+// extension would have recorded them had the changes been made to before.
+// Rows are matched on their primary key, so a row whose key changed is
+// deleted and inserted again. As with the session extension, tables must
+// have a PRIMARY KEY, and it is an error if a table has different columns in
+// the two databases. A table that is missing from one of them is taken to
+// be empty there.
+func DiffToChangeset(before, after *golite.Database, tables []string) ([]byte, error) {
+	var changes []Change
+	for _, name := range tables {
+		tableChanges, err := diffTable(before, after, name)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, tableChanges...)
+	}
+	return Encode(changes)
+}
+
+// diffTable returns the changes made to a table, in primary key order.
+func diffTable(before, after *golite.Database, name string) ([]Change, error) {
+	infoBefore, errBefore := before.Table(name)
+	infoAfter, errAfter := after.Table(name)
+	if errBefore != nil && errAfter != nil {
+		return nil, errAfter
+	}
+	info := infoAfter
+	if errAfter != nil {
+		info = infoBefore
+	}
+	if info.PrimaryKey == nil {
+		return nil, fmt.Errorf("table %s has no primary key", info.Name)
+	}
+	if errBefore == nil && errAfter == nil && !sameColumns(infoBefore, infoAfter) {
+		return nil, fmt.Errorf("table %s has different columns in the two databases", info.Name)
+	}
+	table := &Table{Name: info.Name, PrimaryKey: make([]bool, len(info.Columns))}
+	var key []int // The indexes of the columns of the primary key, in order.
+	for _, col := range info.PrimaryKey {
+		i := slices.IndexFunc(info.Columns, func(c golite.ColumnInfo) bool { return strings.EqualFold(c.Name, col.Name) })
+		if i == -1 {
+			return nil, fmt.Errorf("no such column: %s", col.Name)
+		}
+		table.PrimaryKey[i] = true
+		key = append(key, i)
+	}
+
+	var oldRows, newRows []golite.Record
+	var err error
+	if errBefore == nil {
+		if oldRows, err = sortedRows(before, infoBefore, key); err != nil {
+			return nil, err
+		}
+	}
+	if errAfter == nil {
+		if newRows, err = sortedRows(after, infoAfter, key); err != nil {
+			return nil, err
+		}
+	}
+
+	var changes []Change
+	for len(oldRows) > 0 || len(newRows) > 0 {
+		c := 0
+		switch {
+		case len(newRows) == 0:
+			c = -1
+		case len(oldRows) == 0:
+			c = 1
+		default:
+			c = compareKey(oldRows[0], newRows[0], key)
+		}
+		switch {
+		case c < 0:
+			changes = append(changes, Change{Table: table, Op: Delete, Old: oldRows[0]})
+			oldRows = oldRows[1:]
+		case c > 0:
+			changes = append(changes, Change{Table: table, Op: Insert, New: newRows[0]})
+			newRows = newRows[1:]
+		default:
+			if change, ok := updateChange(table, oldRows[0], newRows[0]); ok {
+				changes = append(changes, change)
+			}
+			oldRows, newRows = oldRows[1:], newRows[1:]
+		}
+	}
+	return changes, nil
+}
+
+// sameColumns reports whether two versions of a table have the same columns
+// and primary key.
+func sameColumns(a, b golite.TableInfo) bool {
+	if len(a.Columns) != len(b.Columns) || len(a.PrimaryKey) != len(b.PrimaryKey) {
+		return false
+	}
+	for i := range a.Columns {
+		if !strings.EqualFold(a.Columns[i].Name, b.Columns[i].Name) {
+			return false
+		}
+	}
+	for i := range a.PrimaryKey {
+		if !strings.EqualFold(a.PrimaryKey[i].Name, b.PrimaryKey[i].Name) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortedRows returns the column values of the rows of a table, sorted by
+// the columns of key.
+func sortedRows(db *golite.Database, table golite.TableInfo, key []int) ([]golite.Record, error) {
+	var rows []golite.Record
+	for record, err := range db.TableScan(table) {
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, slices.Clone(table.ColumnValues(record)))
+	}
+	slices.SortFunc(rows, func(a, b golite.Record) int { return compareKey(a, b, key) })
+	return rows, nil
+}
+
+// compareKey compares the values of the columns of key of two rows.
+func compareKey(a, b golite.Record, key []int) int {
+	for _, i := range key {
+		if c := golite.CompareRecords(golite.Record{a[i]}, golite.Record{b[i]}); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// updateChange returns the UPDATE turning row old into row new, which have
+// the same primary key, and whether they differ. Its old values are those of
+// the primary key and of the columns that changed, its new values those of
+// the columns that changed.
+func updateChange(table *Table, old, new golite.Record) (Change, bool) {
+	change := Change{Table: table, Op: Update, Old: make(golite.Record, len(old)), New: make(golite.Record, len(new))}
+	changed := false
+	for i := range old {
+		change.Old[i], change.New[i] = Undefined, Undefined
+		if !identical(old[i], new[i]) {
+			change.Old[i], change.New[i] = old[i], new[i]
+			changed = true
+		} else if table.PrimaryKey[i] {
+			change.Old[i] = old[i]
+		}
+	}
+	return change, changed
+}
+
+// identical reports whether two values are the same, with the same storage
+// class: unlike for CompareRecords, 1 and 1.0 differ.
+func identical(a, b any) bool {
+	_, aIsInt := a.(int64)
+	_, bIsInt := b.(int64)
+	return aIsInt == bIsInt && golite.CompareRecords(golite.Record{a}, golite.Record{b}) == 0
+}
@@ -0,0 +1,162 @@
+package changeset
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/arnodel/golite"
+)
+
+// runSQL runs a script with sqlite3 on a database and returns its output.
+func runSQL(t *testing.T, path, sql string) string {
+	t.Helper()
+	output, err := exec.Command("sqlite3", path, sql).CombinedOutput()
+	if err != nil {
+		t.Fatalf("sqlite3 failed: %v\nOutput: %s", err, output)
+	}
+	return string(output)
+}
+
+func openDB(t *testing.T, path string) *golite.Database {
+	t.Helper()
+	db, err := golite.Open(path)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// sqlLiteral formats a value of a change as an SQL literal.
+func sqlLiteral(v any) string {
+	switch v := v.(type) {
+	case string:
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	case []byte:
+		return fmt.Sprintf("x'%x'", v)
+	case float64:
+		return fmt.Sprintf("%#v", v)
+	case golite.NullType:
+		return "NULL"
+	}
+	return fmt.Sprint(v)
+}
+
+// applySQL returns SQL statements applying changes, as sqlite3changeset_apply
+// would when there are no conflicts.
+func applySQL(changes []Change) string {
+	var sql strings.Builder
+	where := func(c Change) string {
+		var conds []string
+		for i, pk := range c.Table.PrimaryKey {
+			if pk {
+				conds = append(conds, fmt.Sprintf("c%d IS %s", i, sqlLiteral(c.Old[i])))
+			}
+		}
+		return strings.Join(conds, " AND ")
+	}
+	for _, c := range changes {
+		switch c.Op {
+		case Insert:
+			values := make([]string, len(c.New))
+			for i, v := range c.New {
+				values[i] = sqlLiteral(v)
+			}
+			fmt.Fprintf(&sql, "INSERT INTO %s VALUES (%s);\n", c.Table.Name, strings.Join(values, ", "))
+		case Delete:
+			fmt.Fprintf(&sql, "DELETE FROM %s WHERE %s;\n", c.Table.Name, where(c))
+		case Update:
+			var sets []string
+			for i, v := range c.New {
+				if v != Undefined {
+					sets = append(sets, fmt.Sprintf("c%d = %s", i, sqlLiteral(v)))
+				}
+			}
+			fmt.Fprintf(&sql, "UPDATE %s SET %s WHERE %s;\n", c.Table.Name, strings.Join(sets, ", "), where(c))
+		}
+	}
+	return sql.String()
+}
+
+func TestDiffToChangeset(t *testing.T) {
+	dir := t.TempDir()
+	before := filepath.Join(dir, "before.sqlite")
+	runSQL(t, before, `
+		CREATE TABLE t (c0 INTEGER PRIMARY KEY, c1 TEXT, c2 REAL, c3 BLOB);
+		CREATE TABLE w (c0 TEXT, c1 INTEGER, c2 TEXT, PRIMARY KEY (c1, c0)) WITHOUT ROWID;
+		CREATE TABLE r (c0 TEXT PRIMARY KEY, c1 INTEGER);
+		CREATE TABLE nopk (c0 INTEGER);
+		INSERT INTO t SELECT value, 'v' || value, value / 2.0, x'0102' FROM generate_series(1, 100);
+		INSERT INTO w SELECT 'k' || (value % 7), value / 7, value FROM generate_series(1, 50);
+		INSERT INTO r SELECT 'r' || value, value FROM generate_series(1, 20);
+	`)
+	after := filepath.Join(dir, "after.sqlite")
+	runSQL(t, before, "VACUUM INTO '"+after+"'")
+	runSQL(t, after, `
+		DELETE FROM t WHERE c0 % 10 = 0;
+		UPDATE t SET c1 = NULL WHERE c0 % 10 = 1;
+		UPDATE t SET c2 = c0, c3 = 'text' WHERE c0 % 10 = 2;
+		UPDATE t SET c2 = c2 WHERE c0 % 10 = 3;
+		INSERT INTO t VALUES (1000, 'it''s', -1.5, NULL);
+		UPDATE w SET c2 = 'changed' WHERE c1 = 3;
+		UPDATE w SET c0 = 'moved' WHERE c1 = 4 AND c0 = 'k1';
+		DELETE FROM r;
+	`)
+
+	data, err := DiffToChangeset(openDB(t, before), openDB(t, after), []string{"t", "w", "r"})
+	if err != nil {
+		t.Fatalf("DiffToChangeset() failed: %v", err)
+	}
+	changes, err := Read(data)
+	if err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+	counts := map[string]int{}
+	for _, c := range changes {
+		counts[c.Table.Name+" "+c.Op.String()]++
+	}
+	want := map[string]int{
+		"t DELETE": 10, "t UPDATE": 20, "t INSERT": 1,
+		"w UPDATE": 7, "w DELETE": 1, "w INSERT": 1,
+		"r DELETE": 20,
+	}
+	if !reflect.DeepEqual(counts, want) {
+		t.Errorf("changes = %v, want %v", counts, want)
+	}
+	first := changes[0]
+	wantFirst := Change{
+		Table: first.Table, Op: Update,
+		Old: golite.Record{int64(1), "v1", Undefined, Undefined},
+		New: golite.Record{Undefined, golite.SQLNull, Undefined, Undefined},
+	}
+	if !reflect.DeepEqual(first, wantFirst) {
+		t.Errorf("first change = %v, want %v", first, wantFirst)
+	}
+
+	// Applying the changes to before gives after.
+	runSQL(t, before, applySQL(changes))
+	for _, table := range []string{"t", "w", "r"} {
+		sql := fmt.Sprintf("SELECT *, typeof(c1), typeof(c2) FROM %s ORDER BY 1, 2;", table)
+		if table == "r" {
+			sql = "SELECT * FROM r;"
+		}
+		if got, want := runSQL(t, before, sql), runSQL(t, after, sql); got != want {
+			t.Errorf("table %s differs after applying the changeset", table)
+		}
+	}
+
+	same, err := DiffToChangeset(openDB(t, after), openDB(t, after), []string{"t", "w"})
+	if err != nil || len(same) != 0 {
+		t.Errorf("DiffToChangeset() of identical databases = %x, %v", same, err)
+	}
+	if _, err := DiffToChangeset(openDB(t, before), openDB(t, after), []string{"nopk"}); err == nil {
+		t.Errorf("expected an error for a table without a primary key")
+	}
+	if _, err := DiffToChangeset(openDB(t, before), openDB(t, after), []string{"nope"}); err == nil {
+		t.Errorf("expected an error for an unknown table")
+	}
+}
@@ -0,0 +1,136 @@
+package changeset
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/arnodel/golite"
+)
+
+// Encode returns the changeset, or patchset, holding changes, in the format
+// read by Read. A table header is written before the first change and each
+// time the table of a change differs from that of the previous one; the
+// values of a change must have an entry per column of its table, and only
+// those read from a patchset are omitted from it.
+func Encode(changes []Change) ([]byte, error) {
+	var data []byte
+	var table *Table
+	for _, c := range changes {
+		if c.Table != table {
+			table = c.Table
+			data = appendTable(data, table)
+		}
+		var err error
+		data = append(data, byte(c.Op), 0)
+		if c.Indirect {
+			data[len(data)-1] = 1
+		}
+		switch {
+		case c.Op == Insert:
+			data, err = appendRecord(data, table, c.New, nil)
+		case c.Op == Delete && table.Patchset:
+			data, err = appendRecord(data, table, c.Old, table.PrimaryKey)
+		case c.Op == Delete:
+			data, err = appendRecord(data, table, c.Old, nil)
+		case c.Op == Update && table.Patchset:
+			// The primary key, taken from Old, and the new values of the
+			// other columns share a single record.
+			if len(c.Old) != len(c.New) {
+				return nil, fmt.Errorf("change to table %s has %d old values and %d new values", table.Name, len(c.Old), len(c.New))
+			}
+			values := make(golite.Record, len(c.New))
+			for i, pk := range table.PrimaryKey {
+				values[i] = c.New[i]
+				if pk {
+					values[i] = c.Old[i]
+				}
+			}
+			data, err = appendRecord(data, table, values, nil)
+		case c.Op == Update:
+			data, err = appendRecord(data, table, c.Old, nil)
+			if err == nil {
+				data, err = appendRecord(data, table, c.New, nil)
+			}
+		default:
+			return nil, fmt.Errorf("invalid change kind %s", c.Op)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// appendTable appends the header of a table.
+func appendTable(data []byte, t *Table) []byte {
+	kind := byte('T')
+	if t.Patchset {
+		kind = 'P'
+	}
+	data = appendVarint(append(data, kind), uint64(len(t.PrimaryKey)))
+	for _, pk := range t.PrimaryKey {
+		if pk {
+			data = append(data, 1)
+		} else {
+			data = append(data, 0)
+		}
+	}
+	data = append(data, t.Name...)
+	return append(data, 0)
+}
+
+// appendRecord appends the values of a record, which has an entry per
+// column of the table. If only is not nil, the values of the columns for
+// which it is false are left out.
+func appendRecord(data []byte, t *Table, values golite.Record, only []bool) ([]byte, error) {
+	if len(values) != len(t.PrimaryKey) {
+		return nil, fmt.Errorf("change to table %s has %d values, expected %d", t.Name, len(values), len(t.PrimaryKey))
+	}
+	for i, value := range values {
+		if only != nil && !only[i] {
+			continue
+		}
+		switch v := value.(type) {
+		case UndefinedType:
+			data = append(data, 0)
+		case int64:
+			data = binary.BigEndian.AppendUint64(append(data, 1), uint64(v))
+		case float64:
+			data = binary.BigEndian.AppendUint64(append(data, 2), math.Float64bits(v))
+		case string:
+			data = append(appendVarint(append(data, 3), uint64(len(v))), v...)
+		case []byte:
+			data = append(appendVarint(append(data, 4), uint64(len(v))), v...)
+		case golite.NullType, nil:
+			data = append(data, 5)
+		default:
+			return nil, fmt.Errorf("unsupported value type %T in change to table %s", value, t.Name)
+		}
+	}
+	return data, nil
+}
+
+// appendVarint appends a variable-length integer, as read by
+// reader.readVarint.
+func appendVarint(data []byte, v uint64) []byte {
+	if v > 1<<56-1 {
+		// The 9th byte holds 8 bits, the 8 bytes before it 7 bits each.
+		var b [9]byte
+		b[8] = byte(v)
+		v >>= 8
+		for i := 7; i >= 0; i-- {
+			b[i] = byte(v&0x7f) | 0x80
+			v >>= 7
+		}
+		return append(data, b[:]...)
+	}
+	var b [8]byte
+	i := len(b) - 1
+	b[i] = byte(v & 0x7f)
+	for v >>= 7; v != 0; v >>= 7 {
+		i--
+		b[i] = byte(v&0x7f) | 0x80
+	}
+	return append(data, b[i:]...)
+}
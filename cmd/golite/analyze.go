@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+
+	"github.com/arnodel/golite"
+)
+
+// runAnalyze implements "golite analyze", printing a storage report.
+func runAnalyze(args []string) error {
+	fs := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("expected exactly one database path")
+	}
+
+	db, err := golite.Open(fs.Arg(0), golite.WithUTF8Text())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	report, err := db.Analyze()
+	if err != nil {
+		return err
+	}
+	return report.WriteText(os.Stdout)
+}
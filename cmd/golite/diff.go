@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/arnodel/golite"
+)
+
+// runDiff implements "golite diff", writing to stdout how the first database
+// differs from the second, as a report or, with -sql, as the SQL statements
+// that transform one into the other.
+func runDiff(args []string) error {
+	return diff(os.Stdout, args)
+}
+
+// diff implements runDiff, writing to w.
+func diff(w io.Writer, args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	asSQL := fs.Bool("sql", false, "print SQL statements that transform the first database into the second")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 && fs.NArg() != 3 {
+		return errors.New("expected two database paths and an optional table name")
+	}
+	table := fs.Arg(2)
+
+	a, err := golite.Open(fs.Arg(0), golite.WithUTF8Text())
+	if err != nil {
+		return err
+	}
+	defer a.Close()
+	b, err := golite.Open(fs.Arg(1), golite.WithUTF8Text())
+	if err != nil {
+		return err
+	}
+	defer b.Close()
+
+	if table != "" {
+		_, errA := a.Table(table)
+		_, errB := b.Table(table)
+		if errA != nil && errB != nil {
+			return fmt.Errorf("no such table: %s", table)
+		}
+	}
+	switch {
+	case !*asSQL:
+		return writeDiffReport(w, a, b, table)
+	case table != "":
+		return golite.WriteTableDiffSQL(w, a, b, table)
+	default:
+		return golite.WriteDiffSQL(w, a, b)
+	}
+}
+
+// writeDiffReport writes to w a line for every table, index and row that
+// differs between a and b, restricted to the named table and its indexes
+// unless table is empty.
+func writeDiffReport(w io.Writer, a, b *golite.Database, table string) error {
+	schemaA, err := a.GetSchema()
+	if err != nil {
+		return err
+	}
+	schemaB, err := b.GetSchema()
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	for _, change := range golite.DiffSchemas(schemaA, schemaB) {
+		if table != "" && !changeConcerns(change, schemaA, schemaB, table) {
+			continue
+		}
+		fmt.Fprintf(bw, "%s %s: %s\n", change.Type, change.Name, change.Kind)
+		if change.OldSQL != "" {
+			fmt.Fprintf(bw, "  - %s\n", change.OldSQL)
+		}
+		if change.NewSQL != "" {
+			fmt.Fprintf(bw, "  + %s\n", change.NewSQL)
+		}
+	}
+
+	for _, name := range sortedTableNames(schemaB) {
+		tableA, inA := schemaA.Tables[name]
+		if !inA || (table != "" && name != table) || strings.HasPrefix(name, "sqlite_") {
+			continue
+		}
+		tableB := schemaB.Tables[name]
+		for change, err := range golite.DiffRows(a, tableA, b, tableB) {
+			if err != nil {
+				return fmt.Errorf("failed to diff table %q: %w", name, err)
+			}
+			if tableB.WithoutRowID {
+				fmt.Fprintf(bw, "%s: row %s %s", name, formatValues(change.Key), change.Kind)
+			} else {
+				fmt.Fprintf(bw, "%s: row %d %s", name, change.RowID, change.Kind)
+			}
+			switch change.Kind {
+			case golite.Added:
+				fmt.Fprintf(bw, ": %s\n", formatValues(change.New))
+			case golite.Removed:
+				fmt.Fprintf(bw, ": %s\n", formatValues(change.Old))
+			default:
+				fmt.Fprintf(bw, ": %s -> %s\n", formatValues(change.Old), formatValues(change.New))
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// changeConcerns reports whether a schema change is to the named table or to
+// one of its indexes.
+func changeConcerns(change golite.SchemaChange, schemaA, schemaB *golite.Schema, table string) bool {
+	if change.Type == "index" {
+		return schemaA.Indexes[change.Name].TableName == table || schemaB.Indexes[change.Name].TableName == table
+	}
+	return change.Name == table
+}
+
+// sortedTableNames returns the names of the tables of a schema in
+// alphabetical order.
+func sortedTableNames(schema *golite.Schema) []string {
+	names := make([]string, 0, len(schema.Tables))
+	for name := range schema.Tables {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// formatValues formats the values of a row as a parenthesised list of SQL
+// literals.
+func formatValues(values golite.Record) string {
+	var sb strings.Builder
+	sb.WriteByte('(')
+	for i, value := range values {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		switch v := value.(type) {
+		case nil:
+			sb.WriteString("NULL")
+		case string:
+			sb.WriteString("'" + strings.ReplaceAll(v, "'", "''") + "'")
+		case []byte:
+			fmt.Fprintf(&sb, "X'%X'", v)
+		default:
+			fmt.Fprint(&sb, v)
+		}
+	}
+	sb.WriteByte(')')
+	return sb.String()
+}
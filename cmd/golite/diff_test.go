@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runSQL executes SQL statements against the database at dbPath using sqlite3.
+func runSQL(t *testing.T, dbPath, sql string) {
+	t.Helper()
+	cmd := exec.Command("sqlite3", dbPath)
+	cmd.Stdin = strings.NewReader(sql)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("sqlite3 failed: %v\nOutput: %s", err, string(output))
+	}
+}
+
+// createDiffDBs returns the paths of two databases that differ in their
+// tables t and u.
+func createDiffDBs(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	pathA, pathB := filepath.Join(dir, "a.sqlite"), filepath.Join(dir, "b.sqlite")
+	runSQL(t, pathA, `
+		CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT);
+		INSERT INTO t VALUES (1, 'one'), (2, 'two'), (3, 'three');
+		CREATE TABLE u (x);
+		INSERT INTO u VALUES (1);
+	`)
+	runSQL(t, pathB, `
+		CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT);
+		INSERT INTO t VALUES (1, 'one'), (2, 'TWO'), (4, 'four');
+		CREATE INDEX t_name ON t(name);
+		CREATE TABLE u (x);
+		INSERT INTO u VALUES (2);
+	`)
+	return pathA, pathB
+}
+
+func TestDiff_Report(t *testing.T) {
+	pathA, pathB := createDiffDBs(t)
+	testCases := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "all tables",
+			args: []string{pathA, pathB},
+			want: `index t_name: added
+  + CREATE INDEX t_name ON t(name)
+t: row 2 modified: (2, 'two') -> (2, 'TWO')
+t: row 3 removed: (3, 'three')
+t: row 4 added: (4, 'four')
+u: row 1 modified: (1) -> (2)
+`,
+		},
+		{
+			name: "one table",
+			args: []string{pathA, pathB, "u"},
+			want: "u: row 1 modified: (1) -> (2)\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := diff(&buf, tc.args); err != nil {
+				t.Fatalf("diff() failed: %v", err)
+			}
+			if got := buf.String(); got != tc.want {
+				t.Errorf("diff() wrote:\n%s\nwant:\n%s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestDiff_SQL(t *testing.T) {
+	pathA, pathB := createDiffDBs(t)
+	var buf bytes.Buffer
+	if err := diff(&buf, []string{"-sql", pathA, pathB}); err != nil {
+		t.Fatalf("diff() failed: %v", err)
+	}
+	runSQL(t, pathA, buf.String())
+
+	// After applying the script, the databases no longer differ.
+	var report bytes.Buffer
+	if err := diff(&report, []string{pathA, pathB}); err != nil {
+		t.Fatalf("diff() failed: %v", err)
+	}
+	if report.Len() != 0 {
+		t.Errorf("databases still differ after applying the script:\n%s\nScript:\n%s", report.String(), buf.String())
+	}
+}
+
+func TestDiff_SQLOneTable(t *testing.T) {
+	pathA, pathB := createDiffDBs(t)
+	var buf bytes.Buffer
+	if err := diff(&buf, []string{"-sql", pathA, pathB, "u"}); err != nil {
+		t.Fatalf("diff() failed: %v", err)
+	}
+	if want := "UPDATE u SET x=2 WHERE rowid=1;\n"; buf.String() != want {
+		t.Errorf("diff() wrote:\n%s\nwant:\n%s", buf.String(), want)
+	}
+}
+
+func TestDiff_NoSuchTable(t *testing.T) {
+	pathA, pathB := createDiffDBs(t)
+	var buf bytes.Buffer
+	if err := diff(&buf, []string{pathA, pathB, "missing"}); err == nil {
+		t.Errorf("diff() succeeded for a missing table")
+	}
+}
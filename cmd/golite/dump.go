@@ -0,0 +1,28 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+
+	"github.com/arnodel/golite"
+)
+
+// runDump implements "golite dump", writing the database as SQL to stdout.
+func runDump(args []string) error {
+	fs := flag.NewFlagSet("dump", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("expected exactly one database path")
+	}
+
+	db, err := golite.Open(fs.Arg(0), golite.WithUTF8Text())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Dump(os.Stdout)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/arnodel/golite"
+	"github.com/arnodel/golite/importer"
+)
+
+// runImport implements "golite import", creating a database holding a table
+// filled from a CSV or NDJSON file. golite cannot write to existing
+// databases, so the database must not exist.
+func runImport(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := fs.String("format", "csv", "format of the file: csv or ndjson")
+	header := fs.Bool("header", false, "the first line of a CSV file names the columns")
+	schema := fs.String("schema", "", "CREATE TABLE statement of the table (default TEXT columns named by the CSV header)")
+	tableName := fs.String("table", "", "name of the table created without -schema (default the base name of the file)")
+	null := fs.String("null", "", "CSV field standing for NULL (default none)")
+	skipErrors := fs.Bool("skip-errors", false, "report lines that cannot be imported to stderr and skip them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errors.New("expected a database path and a file path")
+	}
+	var opts []importer.Option
+	if *header {
+		opts = append(opts, importer.WithHeader())
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "null" {
+			opts = append(opts, importer.WithNull(*null))
+		}
+	})
+	if *skipErrors {
+		opts = append(opts, importer.WithErrorHandler(func(err *importer.LineError) error {
+			fmt.Fprintf(os.Stderr, "golite import: %s: %v\n", fs.Arg(1), err)
+			return nil
+		}))
+	}
+
+	f, err := os.Open(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	sql := *schema
+	if sql == "" {
+		if *format != "csv" || !*header {
+			return errors.New("-schema is required unless importing CSV with -header")
+		}
+		name := *tableName
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(fs.Arg(1)), filepath.Ext(fs.Arg(1)))
+		}
+		if sql, err = headerSchema(f, name); err != nil {
+			return err
+		}
+	}
+	table, err := importer.Table(sql)
+	if err != nil {
+		return err
+	}
+
+	var rows golite.RecordIterator
+	switch *format {
+	case "csv":
+		rows = importer.CSV(f, table, opts...)
+	case "ndjson":
+		rows = importer.NDJSON(f, table, opts...)
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	return golite.NewDatabaseBuilder(fs.Arg(0)).
+		CreateTable(table.SQL).
+		InsertFrom(rows).
+		Finalize()
+}
+
+// headerSchema returns the CREATE TABLE statement of a table with a TEXT
+// column named by each field of the first line of a CSV file, as the sqlite3
+// shell's .import creates, and rewinds the file.
+func headerSchema(f *os.File, name string) (string, error) {
+	names, err := csv.NewReader(f).Read()
+	if err == io.EOF {
+		return "", errors.New("the file has no header")
+	}
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	columns := make([]string, len(names))
+	for i, column := range names {
+		columns[i] = quoteIdentifier(column) + " TEXT"
+	}
+	return fmt.Sprintf("CREATE TABLE %s (%s)", quoteIdentifier(name), strings.Join(columns, ", ")), nil
+}
+
+// quoteIdentifier returns name as a quoted SQL identifier.
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
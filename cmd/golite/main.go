@@ -0,0 +1,65 @@
+// Command golite inspects SQLite database files using the golite library.
+//
+// Usage:
+//
+//	golite <command> [arguments]
+//
+// The commands are:
+//
+//	analyze  report how space is used by each table and index
+//	diff     report how two databases differ, or print SQL turning one into the other
+//	dump     print the database as a script of SQL statements
+//	import   create a database from a CSV or NDJSON file
+//	page     print an annotated view of a single page
+//	recover  salvage rows from a damaged database as SQL statements
+//	verify   cross-check what golite reads against the sqlite3 shell
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// command is a golite subcommand. run receives the arguments following the
+// command name.
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{name: "analyze", usage: "analyze <database>", run: runAnalyze},
+	{name: "diff", usage: "diff [-sql] <database1> <database2> [table]", run: runDiff},
+	{name: "dump", usage: "dump <database>", run: runDump},
+	{name: "import", usage: "import [-format csv|ndjson] [-header] [-schema sql] [-table name] [-null text] [-skip-errors] <database> <file>", run: runImport},
+	{name: "page", usage: "page [-hex] <database> <page number>", run: runPage},
+	{name: "recover", usage: "recover <database>", run: runRecover},
+	{name: "verify", usage: "verify [-sqlite path] [-tables t1,t2] [-lookups n] <database>", run: runVerify},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(2)
+	}
+	for _, cmd := range commands {
+		if cmd.name == os.Args[1] {
+			if err := cmd.run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "golite %s: %v\n", cmd.name, err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "golite: unknown command %q\n", os.Args[1])
+	printUsage()
+	os.Exit(2)
+}
+
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "\tgolite %s\n", cmd.usage)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/arnodel/golite"
+)
+
+// runPage implements "golite page", printing an annotated view of one page.
+// With -hex, a plain hexdump of the whole page follows the annotations.
+// Pages that are not B-Tree pages, such as freelist and overflow pages, or
+// that are too damaged to be parsed, are printed as a hexdump.
+func runPage(args []string) error {
+	fs := flag.NewFlagSet("page", flag.ContinueOnError)
+	showHex := fs.Bool("hex", false, "also print a hexdump of the whole page")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errors.New("expected a database path and a page number")
+	}
+	pageNum, err := strconv.Atoi(fs.Arg(1))
+	if err != nil || pageNum < 1 {
+		return fmt.Errorf("invalid page number %q", fs.Arg(1))
+	}
+
+	db, err := golite.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if pageNum > db.PageCount() {
+		return fmt.Errorf("page %d is beyond the end of the database (%d pages)", pageNum, db.PageCount())
+	}
+
+	data, err := readRawPage(fs.Arg(0), pageNum, int(db.Header.PageSize))
+	if err != nil {
+		return err
+	}
+	page, err := golite.ParsePage(data, pageNum)
+	if err != nil {
+		fmt.Printf("page %d cannot be parsed as a B-Tree page: %v\n\n", pageNum, err)
+		fmt.Print(hex.Dump(data))
+		return nil
+	}
+	fmt.Print(page.Describe())
+	if *showHex {
+		fmt.Println()
+		fmt.Print(hex.Dump(data))
+	}
+	return nil
+}
+
+// readRawPage reads the bytes of a page of the database file at path, whose
+// pages are pageSize bytes long.
+func readRawPage(path string, pageNum, pageSize int) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data := make([]byte, pageSize)
+	if _, err := f.ReadAt(data, int64(pageNum-1)*int64(pageSize)); err != nil {
+		return nil, fmt.Errorf("failed to read page %d: %w", pageNum, err)
+	}
+	return data, nil
+}
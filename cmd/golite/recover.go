@@ -0,0 +1,29 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+
+	"github.com/arnodel/golite"
+)
+
+// runRecover implements "golite recover", writing the rows salvaged from a
+// damaged database as SQL to stdout.
+func runRecover(args []string) error {
+	fs := flag.NewFlagSet("recover", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("expected exactly one database path")
+	}
+
+	db, err := golite.Open(fs.Arg(0), golite.WithUTF8Text())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.RecoverDump(os.Stdout)
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/arnodel/golite/verify"
+)
+
+// runVerify implements "golite verify", comparing what golite reads from a
+// database with what the sqlite3 shell reads. It fails if they diverge.
+func runVerify(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	sqlite := fs.String("sqlite", "sqlite3", "path of the sqlite3 binary to compare with")
+	tables := fs.String("tables", "", "comma-separated list of the tables to check (default all)")
+	lookups := fs.Int("lookups", 100, "number of keys to look up in each index")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("expected exactly one database path")
+	}
+
+	opts := []verify.Option{verify.WithSQLite(*sqlite), verify.WithLookups(*lookups)}
+	if *tables != "" {
+		opts = append(opts, verify.WithTables(strings.Split(*tables, ",")...))
+	}
+	report, err := verify.Verify(fs.Arg(0), opts...)
+	if err != nil {
+		return err
+	}
+	if err := report.WriteText(os.Stdout); err != nil {
+		return err
+	}
+	if !report.OK() {
+		return fmt.Errorf("%d divergences found", len(report.Divergences)+report.Omitted)
+	}
+	return nil
+}
@@ -0,0 +1,301 @@
+package golite
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConstraintOp is the comparison operator of a Constraint.
+type ConstraintOp int
+
+const (
+	OpEq     ConstraintOp = iota // column = value
+	OpLt                         // column < value
+	OpLe                         // column <= value
+	OpGt                         // column > value
+	OpGe                         // column >= value
+	OpIsNull                     // column IS NULL, Value is ignored
+)
+
+// String returns the SQL spelling of the operator.
+func (op ConstraintOp) String() string {
+	switch op {
+	case OpEq:
+		return "="
+	case OpLt:
+		return "<"
+	case OpLe:
+		return "<="
+	case OpGt:
+		return ">"
+	case OpGe:
+		return ">="
+	case OpIsNull:
+		return "IS NULL"
+	}
+	return fmt.Sprintf("ConstraintOp(%d)", int(op))
+}
+
+// Constraint is a comparison of a column with a constant, one of the terms
+// of a WHERE clause made of terms joined by AND.
+type Constraint struct {
+	Column string
+	Op     ConstraintOp
+	Value  any
+}
+
+// Between returns the constraints of "column BETWEEN low AND high".
+func Between(column string, low, high any) []Constraint {
+	return []Constraint{{column, OpGe, low}, {column, OpLe, high}}
+}
+
+// IndexRange is the part of an index holding the records that may satisfy
+// a set of constraints, as found by ExtractRange, and the constraints the
+// range does not account for.
+type IndexRange struct {
+	Index IndexInfo
+	// Lower and Upper bound the range, compared as with IndexScanRange with
+	// as many leading columns of the records as they have values. A nil
+	// bound leaves that side of the range open.
+	Lower, Upper                   Record
+	LowerInclusive, UpperInclusive bool
+	// Empty is true if no row can satisfy the constraints, as when a column
+	// is compared with NULL. The other fields are then unset.
+	Empty bool
+	// Residual holds the constraints that the records of the range do not
+	// all satisfy, to be checked on the rows, see MatchConstraints.
+	Residual []Constraint
+}
+
+// ExtractRange finds the range of an index of the table holding the records
+// of the rows that may satisfy all the constraints. Equality and IS NULL
+// constraints on the leading columns of the index give the values of a
+// prefix of the key, and the inequalities on the next column bound it, the
+// tightest bound on each side being kept. The other constraints are left in
+// the Residual of the range. As the records are compared in binary order
+// and ascending, the range ends at the first column of the index that is an
+// expression, is sorted in descending order, or has a collating sequence
+// other than BINARY.
+func (t TableInfo) ExtractRange(index IndexInfo, constraints []Constraint) (IndexRange, error) {
+	r := IndexRange{Index: index}
+	if !strings.EqualFold(index.TableName, t.Name) {
+		return r, fmt.Errorf("%s is not an index of table %s", index.Name, t.Name)
+	}
+	columns := make([]int, len(constraints))
+	for i, c := range constraints {
+		if columns[i] = t.lookupColumn(c.Column); columns[i] == -1 {
+			return r, fmt.Errorf("no such column: %s", c.Column)
+		}
+		if c.Op != OpIsNull && isNull(c.Value) {
+			r.Empty = true // Comparisons with NULL are never true.
+		}
+	}
+	if r.Empty {
+		return r, nil
+	}
+	used := make([]bool, len(constraints))
+
+	var prefix Record
+	for _, col := range index.Columns {
+		column := t.lookupColumn(col.Name)
+		if col.Name == "" || col.Desc || column == -1 || !binaryCollation(t.Columns[column].Collation) {
+			break
+		}
+		// An equality on the column extends the prefix.
+		eq := -1
+		for i, c := range constraints {
+			if columns[i] == column && (c.Op == OpEq || c.Op == OpIsNull) {
+				eq = i
+				break
+			}
+		}
+		if eq != -1 {
+			used[eq] = true
+			value := constraints[eq].Value
+			if constraints[eq].Op == OpIsNull {
+				value = SQLNull
+			}
+			prefix = append(prefix, col.affinity.apply(value))
+			continue
+		}
+
+		// Otherwise the inequalities on the column bound the range.
+		var lower, upper any
+		lowerOp, upperOp := OpGe, OpLe
+		for i, c := range constraints {
+			if columns[i] != column {
+				continue
+			}
+			value := col.affinity.apply(c.Value)
+			switch c.Op {
+			case OpGt, OpGe:
+				if cmp := compareValues(value, lower); lower == nil || cmp > 0 || cmp == 0 && c.Op == OpGt {
+					lower, lowerOp = value, c.Op
+				}
+			case OpLt, OpLe:
+				if cmp := compareValues(value, upper); upper == nil || cmp < 0 || cmp == 0 && c.Op == OpLt {
+					upper, upperOp = value, c.Op
+				}
+			default:
+				continue
+			}
+			used[i] = true
+		}
+		if lower == nil && upper != nil {
+			// NULL sorts first but never satisfies a comparison.
+			lower, lowerOp = SQLNull, OpGt
+		}
+		if lower != nil {
+			r.Lower = append(prefix[:len(prefix):len(prefix)], lower)
+			r.LowerInclusive = lowerOp == OpGe
+		}
+		if upper != nil {
+			r.Upper = append(prefix[:len(prefix):len(prefix)], upper)
+			r.UpperInclusive = upperOp == OpLe
+		}
+		break
+	}
+	if len(prefix) > 0 {
+		if r.Lower == nil {
+			r.Lower, r.LowerInclusive = prefix, true
+		}
+		if r.Upper == nil {
+			r.Upper, r.UpperInclusive = prefix, true
+		}
+	}
+	for i, c := range constraints {
+		if !used[i] {
+			r.Residual = append(r.Residual, c)
+		}
+	}
+	return r, nil
+}
+
+// binaryCollation reports whether a collating sequence is BINARY.
+func binaryCollation(name string) bool {
+	return name == "" || strings.EqualFold(name, "BINARY")
+}
+
+// MatchConstraints returns a predicate for Filter telling whether a row of
+// the table, as yielded by TableScan, satisfies all the constraints. As in
+// SQLite, the affinity of the column is applied to the value it is compared
+// with, and a comparison involving NULL is never true.
+func (t TableInfo) MatchConstraints(constraints []Constraint) (func(Record) (bool, error), error) {
+	columns := make([]int, len(constraints))
+	affinities := make([]Affinity, len(constraints))
+	for i, c := range constraints {
+		if columns[i] = t.lookupColumn(c.Column); columns[i] == -1 {
+			return nil, fmt.Errorf("no such column: %s", c.Column)
+		}
+		affinities[i] = AffinityInteger
+		if columns[i] != rowIDColumn {
+			affinities[i] = t.Columns[columns[i]].Affinity()
+		}
+	}
+	return func(row Record) (bool, error) {
+		for i, c := range constraints {
+			value := t.columnValue(row, columns[i])
+			if c.Op == OpIsNull {
+				if !isNull(value) {
+					return false, nil
+				}
+				continue
+			}
+			if isNull(value) || isNull(c.Value) {
+				return false, nil
+			}
+			cmp := compareValues(value, affinities[i].apply(c.Value))
+			var ok bool
+			switch c.Op {
+			case OpEq:
+				ok = cmp == 0
+			case OpLt:
+				ok = cmp < 0
+			case OpLe:
+				ok = cmp <= 0
+			case OpGt:
+				ok = cmp > 0
+			case OpGe:
+				ok = cmp >= 0
+			default:
+				return false, fmt.Errorf("unknown constraint operator %v", c.Op)
+			}
+			if !ok {
+				return false, nil
+			}
+		}
+		return true, nil
+	}, nil
+}
+
+// RangeScan returns an iterator over the records of the index in the range,
+// in index order. Unlike IndexScanRange, either bound may be inclusive or
+// exclusive. The Residual constraints are not checked.
+func (db *Database) RangeScan(r IndexRange) RecordIterator {
+	return interruptible(db, func(yield func(Record, error) bool) {
+		if r.Empty {
+			return
+		}
+		var lower, upper Record
+		if r.Lower != nil {
+			lower = r.Index.coerceKey(r.Lower)
+		}
+		if r.Upper != nil {
+			upper = r.Index.coerceKey(r.Upper)
+		}
+		for record, err := range db.IndexScanRange(r.Index, lower, nil) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !r.LowerInclusive && lower != nil && CompareRecords(record[:min(len(lower), len(record))], lower) == 0 {
+				continue
+			}
+			if upper != nil {
+				cmp := CompareRecords(record[:min(len(upper), len(record))], upper)
+				if cmp > 0 || cmp == 0 && !r.UpperInclusive {
+					return
+				}
+			}
+			if !yield(record, nil) {
+				return
+			}
+		}
+	})
+}
+
+// ConstrainedScan returns an iterator over the rows of the table that
+// satisfy all the constraints, as TableScan yields them, in the order of
+// index. The range of the index found by ExtractRange is scanned, each of
+// its records is looked up in the table, and the residual constraints are
+// checked on the rows.
+func (db *Database) ConstrainedScan(table TableInfo, index IndexInfo, constraints []Constraint) RecordIterator {
+	return interruptible(db, func(yield func(Record, error) bool) {
+		r, err := table.ExtractRange(index, constraints)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		match, err := table.MatchConstraints(r.Residual)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		for record, err := range db.RangeScan(r) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			rows, err := db.indexedRow(table, index, record)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for row, err := range Filter(rows, match) {
+				if !yield(row, err) || err != nil {
+					return
+				}
+			}
+		}
+	})
+}
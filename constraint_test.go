@@ -0,0 +1,167 @@
+package golite
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestConstrainedScan(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "constraint.sqlite")
+	tests := []struct {
+		where       string
+		constraints []Constraint
+	}{
+		{"b = 3", []Constraint{{"b", OpEq, int64(3)}}},
+		{"b = '3'", []Constraint{{"b", OpEq, "3"}}},
+		{"b > 3", []Constraint{{"b", OpGt, int64(3)}}},
+		{"b >= 3 AND b < 7", []Constraint{{"b", OpGe, int64(3)}, {"b", OpLt, int64(7)}}},
+		{"b BETWEEN 2 AND 4", Between("b", int64(2), int64(4))},
+		{"b < 2", []Constraint{{"b", OpLt, int64(2)}}},
+		{"b <= 2.5", []Constraint{{"b", OpLe, 2.5}}},
+		{"b > 'x100'", []Constraint{{"b", OpGt, "x100"}}},
+		{"b IS NULL", []Constraint{{"b", OpIsNull, nil}}},
+		{"b = 3 AND c > 'c2'", []Constraint{{"b", OpEq, int64(3)}, {"c", OpGt, "c2"}}},
+		{"b = 3 AND c <= 'c3' AND c >= 'c1' AND c > 'c1'", []Constraint{{"b", OpEq, int64(3)}, {"c", OpLe, "c3"}, {"c", OpGe, "c1"}, {"c", OpGt, "c1"}}},
+		{"b > 5 AND c = 'c4'", []Constraint{{"b", OpGt, int64(5)}, {"c", OpEq, "c4"}}},
+		{"b = 3 AND a > 200", []Constraint{{"b", OpEq, int64(3)}, {"a", OpGt, int64(200)}}},
+		{"b = 3 AND b = 4", []Constraint{{"b", OpEq, int64(3)}, {"b", OpEq, int64(4)}}},
+		{"c = 'c4'", []Constraint{{"c", OpEq, "c4"}}},
+		{"b = NULL", []Constraint{{"b", OpEq, SQLNull}}},
+	}
+	var sql strings.Builder
+	sql.WriteString(`
+		CREATE TABLE t (a INTEGER PRIMARY KEY, b INTEGER, c TEXT);
+		CREATE INDEX t_bc ON t(b, c);
+		WITH RECURSIVE seq(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM seq WHERE i < 300)
+		INSERT INTO t SELECT i, CASE WHEN i % 37 = 0 THEN NULL WHEN i % 41 = 0 THEN 'x' || i ELSE i % 10 END, 'c' || (i % 7) FROM seq;
+		CREATE TABLE expected (id INTEGER, rowids TEXT);
+	`)
+	for i, test := range tests {
+		fmt.Fprintf(&sql, "INSERT INTO expected SELECT %d, group_concat(a) FROM (SELECT a FROM t WHERE %s ORDER BY a);\n", i, test.where)
+	}
+	runSQL(t, dbPath, sql.String())
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	expected := map[int64]string{}
+	table, err := db.Table("expected")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+	for record, err := range db.TableScan(table) {
+		if err != nil {
+			t.Fatalf("TableScan() failed: %v", err)
+		}
+		values := table.ColumnValues(record)
+		if s, ok := values[1].(string); ok {
+			expected[values[0].(int64)] = s
+		}
+	}
+
+	tTable, err := db.Table("t")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+	index, err := db.Index("t_bc")
+	if err != nil {
+		t.Fatalf("Index() failed: %v", err)
+	}
+	for i, test := range tests {
+		var rowIDs []int64
+		for row, err := range db.ConstrainedScan(tTable, index, test.constraints) {
+			if err != nil {
+				t.Fatalf("WHERE %s: ConstrainedScan() failed: %v", test.where, err)
+			}
+			rowIDs = append(rowIDs, tTable.RowID(row))
+		}
+		slices.Sort(rowIDs)
+		got := make([]string, len(rowIDs))
+		for j, rowID := range rowIDs {
+			got[j] = formatScalar(rowID)
+		}
+		if want := expected[int64(i)]; strings.Join(got, ",") != want {
+			t.Errorf("WHERE %s: got %s, want %s", test.where, strings.Join(got, ","), want)
+		}
+	}
+
+	if _, err := CollectRecords(db.ConstrainedScan(tTable, index, []Constraint{{"nope", OpEq, int64(1)}})); err == nil {
+		t.Errorf("expected an error for an unknown column")
+	}
+}
+
+func TestExtractRange(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "range.sqlite")
+	runSQL(t, dbPath, `
+		CREATE TABLE t (a INTEGER PRIMARY KEY, b INTEGER, c TEXT, d TEXT COLLATE NOCASE);
+		CREATE INDEX t_bc ON t(b, c);
+		CREATE INDEX t_bdesc ON t(b DESC, c);
+		CREATE INDEX t_db ON t(d, b);
+	`)
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	table, err := db.Table("t")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+
+	tests := []struct {
+		index       string
+		constraints []Constraint
+		want        IndexRange
+	}{
+		{"t_bc", []Constraint{{"b", OpEq, "3"}, {"c", OpGt, "c2"}, {"a", OpLt, int64(9)}}, IndexRange{
+			Lower: Record{int64(3), "c2"}, Upper: Record{int64(3)}, UpperInclusive: true,
+			Residual: []Constraint{{"a", OpLt, int64(9)}},
+		}},
+		{"t_bc", []Constraint{{"c", OpEq, "c2"}, {"b", OpLt, int64(5)}}, IndexRange{
+			Lower: Record{SQLNull}, Upper: Record{int64(5)},
+			Residual: []Constraint{{"c", OpEq, "c2"}},
+		}},
+		{"t_bc", Between("b", int64(1), int64(4)), IndexRange{
+			Lower: Record{int64(1)}, LowerInclusive: true, Upper: Record{int64(4)}, UpperInclusive: true,
+		}},
+		{"t_bc", []Constraint{{"b", OpIsNull, nil}, {"c", OpIsNull, nil}}, IndexRange{
+			Lower: Record{SQLNull, SQLNull}, LowerInclusive: true, Upper: Record{SQLNull, SQLNull}, UpperInclusive: true,
+		}},
+		{"t_bdesc", []Constraint{{"b", OpEq, int64(3)}}, IndexRange{
+			Residual: []Constraint{{"b", OpEq, int64(3)}},
+		}},
+		{"t_db", []Constraint{{"d", OpEq, "x"}}, IndexRange{
+			Residual: []Constraint{{"d", OpEq, "x"}},
+		}},
+		{"t_bc", []Constraint{{"b", OpGt, nil}}, IndexRange{Empty: true}},
+	}
+	for _, test := range tests {
+		index, err := db.Index(test.index)
+		if err != nil {
+			t.Fatalf("Index() failed: %v", err)
+		}
+		got, err := table.ExtractRange(index, test.constraints)
+		if err != nil {
+			t.Fatalf("ExtractRange(%s, %v) failed: %v", test.index, test.constraints, err)
+		}
+		test.want.Index = index
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ExtractRange(%s, %v) = %+v, want %+v", test.index, test.constraints, got, test.want)
+		}
+	}
+
+	other, err := db.Index("t_bc")
+	if err != nil {
+		t.Fatalf("Index() failed: %v", err)
+	}
+	other.TableName = "u"
+	if _, err := table.ExtractRange(other, nil); err == nil {
+		t.Errorf("expected an error for an index of another table")
+	}
+}
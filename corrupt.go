@@ -0,0 +1,114 @@
+package golite
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CorruptReason classifies the kind of corruption reported by a CorruptError.
+type CorruptReason int
+
+const (
+	// CorruptFileHeader means the 100-byte database file header is invalid.
+	CorruptFileHeader CorruptReason = iota + 1
+	// CorruptPageNumber means a page number is out of range for the file.
+	CorruptPageNumber
+	// CorruptPageHeader means a B-Tree page header is truncated or inconsistent.
+	CorruptPageHeader
+	// CorruptCellPointer means a cell pointer points outside the cell content area.
+	CorruptCellPointer
+	// CorruptCell means a cell is truncated or its header varints are malformed.
+	CorruptCell
+	// CorruptPayload means a cell's payload size is invalid.
+	CorruptPayload
+	// CorruptRecord means a record's header or body cannot be decoded.
+	CorruptRecord
+	// CorruptFreeSpace means a page's freeblock chain or fragmented byte
+	// count is inconsistent with its cells.
+	CorruptFreeSpace
+	// CorruptTree means the B-Tree structure is inconsistent, for instance a
+	// child pointer leading to a page of the wrong type.
+	CorruptTree
+	// CorruptSchema means a row of the sqlite_schema table is malformed.
+	CorruptSchema
+)
+
+// String returns a short lowercase description of the reason.
+func (r CorruptReason) String() string {
+	switch r {
+	case CorruptFileHeader:
+		return "file header"
+	case CorruptPageNumber:
+		return "page number"
+	case CorruptPageHeader:
+		return "page header"
+	case CorruptCellPointer:
+		return "cell pointer"
+	case CorruptCell:
+		return "cell"
+	case CorruptPayload:
+		return "payload"
+	case CorruptRecord:
+		return "record"
+	case CorruptFreeSpace:
+		return "free space"
+	case CorruptTree:
+		return "b-tree"
+	case CorruptSchema:
+		return "schema"
+	}
+	return fmt.Sprintf("CorruptReason(%d)", int(r))
+}
+
+// CorruptError reports malformed content in a database file, as opposed to
+// an I/O error or a feature golite does not support. Use errors.As to tell
+// it apart and to find where the corruption was detected.
+type CorruptError struct {
+	// PageNum is the 1-based number of the corrupt page, or 0 if unknown.
+	PageNum int
+	// Offset is the byte offset of the corrupt structure within the page, or
+	// -1 if unknown.
+	Offset int
+	// Cell is the index of the corrupt cell within the page, or -1 if the
+	// corruption is not in a cell.
+	Cell int
+	// Reason classifies the corruption.
+	Reason CorruptReason
+	// Err describes the problem.
+	Err error
+}
+
+// newCorruptError returns a CorruptError for the given location.
+func newCorruptError(pageNum, cell, offset int, reason CorruptReason, err error) *CorruptError {
+	return &CorruptError{PageNum: pageNum, Offset: offset, Cell: cell, Reason: reason, Err: err}
+}
+
+// Error returns the location of the corruption followed by its description.
+// Errors without a known page, such as those returned by ParseRecord, return
+// the description alone.
+func (e *CorruptError) Error() string {
+	if e.PageNum == 0 {
+		return e.Err.Error()
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "database corrupt at page %d", e.PageNum)
+	if e.Cell >= 0 {
+		fmt.Fprintf(&b, ", cell %d", e.Cell)
+	}
+	if e.Offset >= 0 {
+		fmt.Fprintf(&b, ", offset %d", e.Offset)
+	}
+	fmt.Fprintf(&b, ": %v", e.Err)
+	return b.String()
+}
+
+// Unwrap returns the underlying description of the problem.
+func (e *CorruptError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrCorrupt, so that errors.Is(err, ErrCorrupt)
+// holds for every CorruptError.
+func (e *CorruptError) Is(target error) bool {
+	return target == ErrCorrupt
+}
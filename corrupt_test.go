@@ -0,0 +1,134 @@
+package golite
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCorruptError(t *testing.T) {
+	t.Run("ParsePage", func(t *testing.T) {
+		data := craftPage(PageTypeLeafTable, []uint16{40}, map[int][]byte{40: {0x1c, 0x01}})
+		_, err := ParsePage(data, 3)
+		var corrupt *CorruptError
+		if !errors.As(err, &corrupt) {
+			t.Fatalf("expected a *CorruptError, got %v", err)
+		}
+		if corrupt.PageNum != 3 || corrupt.Cell != 0 || corrupt.Offset != 40 || corrupt.Reason != CorruptPayload {
+			t.Errorf("unexpected error fields: %+v", corrupt)
+		}
+	})
+
+	t.Run("ParseRecord", func(t *testing.T) {
+		_, err := ParseRecord([]byte{0x02, 0x0b})
+		var corrupt *CorruptError
+		if !errors.As(err, &corrupt) {
+			t.Fatalf("expected a *CorruptError, got %v", err)
+		}
+		if corrupt.PageNum != 0 || corrupt.Reason != CorruptRecord {
+			t.Errorf("unexpected error fields: %+v", corrupt)
+		}
+	})
+
+	t.Run("ParseHeader", func(t *testing.T) {
+		_, err := ParseHeader(make([]byte, HeaderSize))
+		var corrupt *CorruptError
+		if !errors.As(err, &corrupt) || corrupt.Reason != CorruptFileHeader {
+			t.Fatalf("expected a file header *CorruptError, got %v", err)
+		}
+	})
+
+	t.Run("scan of a corrupt page", func(t *testing.T) {
+		dbPath := createTestDB(t, "corrupt_error_test.sqlite")
+		db, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		schema, err := db.GetSchema()
+		db.Close()
+		if err != nil {
+			t.Fatalf("GetSchema() failed: %v", err)
+		}
+		table := schema.Tables["test"]
+		// Turn the table's root page into an index page.
+		f, err := os.OpenFile(dbPath, os.O_RDWR, 0)
+		if err != nil {
+			t.Fatalf("failed to open database file: %v", err)
+		}
+		_, err = f.WriteAt([]byte{PageTypeLeafIndex}, int64(table.RootPage-1)*4096)
+		f.Close()
+		if err != nil {
+			t.Fatalf("failed to write database file: %v", err)
+		}
+
+		db, err = Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		defer db.Close()
+		var scanErr error
+		for _, err := range db.TableScan(table) {
+			scanErr = err
+			break
+		}
+		var corrupt *CorruptError
+		if !errors.As(scanErr, &corrupt) {
+			t.Fatalf("expected a *CorruptError, got %v", scanErr)
+		}
+		if corrupt.PageNum != table.RootPage {
+			t.Errorf("expected corruption on page %d, got page %d", table.RootPage, corrupt.PageNum)
+		}
+	})
+
+	t.Run("page beyond end of file", func(t *testing.T) {
+		db, err := Open(createTestDB(t, "corrupt_error_eof_test.sqlite"))
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		defer db.Close()
+		_, err = db.ReadPage(100000)
+		var corrupt *CorruptError
+		if !errors.As(err, &corrupt) || corrupt.Reason != CorruptPageNumber {
+			t.Fatalf("expected a page number *CorruptError, got %v", err)
+		}
+	})
+	t.Run("root page beyond the database", func(t *testing.T) {
+		dbPath := filepath.Join(t.TempDir(), "corrupt_root_test.sqlite")
+		runSQL(t, dbPath, "CREATE TABLE t (x INTEGER);\n.dbconfig defensive off\n"+
+			"PRAGMA writable_schema = ON;\nUPDATE sqlite_schema SET rootpage = 1000 WHERE name = 't';\n")
+		db, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		defer db.Close()
+		_, err = db.GetSchema()
+		var corrupt *CorruptError
+		if !errors.As(err, &corrupt) || corrupt.Reason != CorruptSchema || !strings.Contains(err.Error(), "page 1000 is beyond the end of the database") {
+			t.Fatalf("expected a schema *CorruptError, got %v", err)
+		}
+	})
+
+	t.Run("pointer-map page", func(t *testing.T) {
+		dbPath := filepath.Join(t.TempDir(), "corrupt_ptrmap_test.sqlite")
+		runSQL(t, dbPath, `
+			PRAGMA auto_vacuum = FULL;
+			CREATE TABLE t (x INTEGER);
+			INSERT INTO t VALUES (1);
+		`)
+		db, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		defer db.Close()
+		if _, err := db.GetSchema(); err != nil {
+			t.Fatalf("GetSchema() failed: %v", err)
+		}
+		_, err = db.ReadPage(2)
+		var corrupt *CorruptError
+		if !errors.As(err, &corrupt) || corrupt.Reason != CorruptPageNumber || !strings.Contains(err.Error(), "pointer-map page") {
+			t.Fatalf("expected a page number *CorruptError, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,156 @@
+package golite
+
+import (
+	"fmt"
+	"strings"
+)
+
+// IsCovering reports whether the records of index, an index of the table,
+// hold the values of all the named columns, so that a query reading only
+// these columns can be answered from the index alone, without looking rows
+// up in the table. Besides the indexed columns, the records of an index hold
+// the rowid, and thus the INTEGER PRIMARY KEY column, or for a WITHOUT ROWID
+// table the primary key columns. Names are matched ignoring case, and
+// "rowid" names the rowid.
+func (t TableInfo) IsCovering(index IndexInfo, columns []string) bool {
+	for _, name := range columns {
+		if t.indexPosition(index, name) == -1 {
+			return false
+		}
+	}
+	return true
+}
+
+// indexPosition returns the position in the records of index of the value
+// of the named column, or -1 if they do not hold it.
+func (t TableInfo) indexPosition(index IndexInfo, name string) int {
+	column := t.lookupColumn(name)
+	if column == -1 {
+		return -1
+	}
+	if t.WithoutRowID && index.RootPage == t.RootPage {
+		// The index is the B-Tree of the table itself.
+		for i, c := range t.StorageOrder() {
+			if c == column {
+				return i
+			}
+		}
+		return -1
+	}
+	for i, col := range index.Columns {
+		if col.Name != "" && t.lookupColumn(col.Name) == column {
+			return i
+		}
+	}
+	if !t.WithoutRowID {
+		if column == rowIDColumn || column == t.RowIDColumnIndex {
+			return len(index.Columns)
+		}
+		return -1
+	}
+	// The primary key columns that are not indexed follow the indexed ones.
+	pos := len(index.Columns)
+	for _, key := range t.PrimaryKey {
+		if index.hasColumn(key.Name) {
+			continue
+		}
+		if t.lookupColumn(key.Name) == column {
+			return pos
+		}
+		pos++
+	}
+	return -1
+}
+
+// hasColumn reports whether the named column is one of the indexed columns.
+func (index IndexInfo) hasColumn(name string) bool {
+	for _, col := range index.Columns {
+		if strings.EqualFold(col.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// IndexedLookup searches index, an index of the table, for key as IndexSeek
+// does, and yields for each match a record holding the values of the named
+// columns, in that order. If the index covers the columns, as reported by
+// IsCovering, they are read from the index records alone. Otherwise each
+// match is looked up in the table.
+func (db *Database) IndexedLookup(table TableInfo, index IndexInfo, key Record, columns []string) RecordIterator {
+	return interruptible(db, func(yield func(Record, error) bool) {
+		if table.IsCovering(index, columns) {
+			positions := make([]int, len(columns))
+			for i, name := range columns {
+				positions[i] = table.indexPosition(index, name)
+			}
+			for record, err := range db.IndexSeek(index, key) {
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				values := make(Record, len(positions))
+				for i, pos := range positions {
+					values[i] = record[pos]
+				}
+				if !yield(values, nil) {
+					return
+				}
+			}
+			return
+		}
+
+		indexes := make([]int, len(columns))
+		for i, name := range columns {
+			if indexes[i] = table.lookupColumn(name); indexes[i] == -1 {
+				yield(nil, fmt.Errorf("no such column: %s", name))
+				return
+			}
+		}
+		for record, err := range db.IndexSeek(index, key) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			rows, err := db.indexedRow(table, index, record)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for row, err := range rows {
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				values := make(Record, len(indexes))
+				for i, column := range indexes {
+					values[i] = table.columnValue(row, column)
+				}
+				if !yield(values, nil) {
+					return
+				}
+			}
+		}
+	})
+}
+
+// indexedRow returns an iterator yielding the row of the table an index
+// record points to.
+func (db *Database) indexedRow(table TableInfo, index IndexInfo, record Record) (RecordIterator, error) {
+	if !table.WithoutRowID {
+		rowID, ok := record[len(record)-1].(int64)
+		if !ok {
+			return nil, fmt.Errorf("index %s holds a non-integer rowid", index.Name)
+		}
+		return db.TableSeek(table, rowID), nil
+	}
+	key := make(Record, len(table.PrimaryKey))
+	for i, col := range table.PrimaryKey {
+		pos := table.indexPosition(index, col.Name)
+		if pos == -1 || pos >= len(record) {
+			return nil, fmt.Errorf("index %s does not hold the primary key of %s", index.Name, table.Name)
+		}
+		key[i] = record[pos]
+	}
+	return db.PrimaryKeySeek(table, key), nil
+}
@@ -0,0 +1,138 @@
+package golite
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIsCovering(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "covering.sqlite")
+	runSQL(t, dbPath, `
+		CREATE TABLE t (a INTEGER PRIMARY KEY, b TEXT, c INTEGER);
+		CREATE INDEX t_b ON t(b);
+		CREATE INDEX t_cb ON t(c, b);
+		CREATE INDEX t_expr ON t(c + 1);
+		CREATE TABLE w (k TEXT, j INTEGER, v TEXT, x TEXT, PRIMARY KEY (k, j)) WITHOUT ROWID;
+		CREATE INDEX w_v ON w(v);
+		CREATE INDEX w_jv ON w(j, v);
+	`)
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+
+	tests := []struct {
+		table, index string
+		columns      []string
+		want         bool
+	}{
+		{"t", "t_b", []string{"b"}, true},
+		{"t", "t_b", []string{"B", "a", "rowid"}, true},
+		{"t", "t_b", []string{"b", "c"}, false},
+		{"t", "t_cb", []string{"b", "c", "a"}, true},
+		{"t", "t_cb", []string{"nope"}, false},
+		{"t", "t_expr", []string{"c"}, false},
+		{"t", "t_expr", []string{"a"}, true},
+		{"w", "w_v", []string{"v", "k", "j"}, true},
+		{"w", "w_v", []string{"x"}, false},
+		{"w", "w_v", []string{"rowid"}, false},
+		{"w", "w_jv", []string{"k", "j", "v"}, true},
+	}
+	for _, test := range tests {
+		table, err := db.Table(test.table)
+		if err != nil {
+			t.Fatalf("Table() failed: %v", err)
+		}
+		index, err := db.Index(test.index)
+		if err != nil {
+			t.Fatalf("Index() failed: %v", err)
+		}
+		if got := table.IsCovering(index, test.columns); got != test.want {
+			t.Errorf("IsCovering(%s, %q) = %v, want %v", test.index, test.columns, got, test.want)
+		}
+	}
+
+	// The B-Tree of a WITHOUT ROWID table holds all its columns.
+	w, err := db.Table("w")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+	pk, _ := w.PrimaryKeyIndex()
+	if !w.IsCovering(pk, []string{"x", "k", "v"}) {
+		t.Errorf("expected the primary key index to cover all columns")
+	}
+}
+
+func TestIndexedLookup(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "lookup.sqlite")
+	runSQL(t, dbPath, `
+		CREATE TABLE t (a INTEGER PRIMARY KEY, b TEXT, c INTEGER);
+		CREATE INDEX t_b ON t(b);
+		WITH RECURSIVE seq(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM seq WHERE i < 1000)
+		INSERT INTO t SELECT i, 'name' || (i % 100), i * 10 FROM seq;
+		CREATE TABLE w (k TEXT, j INTEGER, v TEXT, x TEXT, PRIMARY KEY (k, j)) WITHOUT ROWID;
+		CREATE INDEX w_v ON w(v);
+		INSERT INTO w VALUES ('a', 1, 'red', 'x1'), ('a', 2, 'blue', 'x2'), ('b', 1, 'red', 'x3');
+	`)
+	tracer := &recordingTracer{}
+	db, err := Open(dbPath, WithTracer(tracer))
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+
+	lookup := func(tableName, indexName string, key Record, columns ...string) ([]Record, int) {
+		t.Helper()
+		table, err := db.Table(tableName)
+		if err != nil {
+			t.Fatalf("Table() failed: %v", err)
+		}
+		index, err := db.Index(indexName)
+		if err != nil {
+			t.Fatalf("Index() failed: %v", err)
+		}
+		*tracer = recordingTracer{}
+		records, err := CollectRecords(db.IndexedLookup(table, index, key, columns))
+		if err != nil {
+			t.Fatalf("IndexedLookup() failed: %v", err)
+		}
+		return records, len(tracer.seeks)
+	}
+
+	records, seeks := lookup("t", "t_b", Record{"name7"}, "a", "b")
+	if len(records) != 10 || seeks != 1 {
+		t.Errorf("covering lookup: got %d records with %d seeks, want 10 records with 1 seek", len(records), seeks)
+	}
+	for _, record := range records {
+		if record[1] != "name7" || record[0].(int64)%100 != 7 {
+			t.Errorf("unexpected record %v", record)
+		}
+	}
+
+	records, seeks = lookup("t", "t_b", Record{"name7"}, "c", "a")
+	if len(records) != 10 || seeks != 11 {
+		t.Errorf("table lookup: got %d records with %d seeks, want 10 records with 11 seeks", len(records), seeks)
+	}
+	for _, record := range records {
+		if record[0] != record[1].(int64)*10 {
+			t.Errorf("unexpected record %v", record)
+		}
+	}
+
+	records, _ = lookup("w", "w_v", Record{"red"}, "k", "j")
+	if want := []Record{{"a", int64(1)}, {"b", int64(1)}}; !reflect.DeepEqual(records, want) {
+		t.Errorf("covering lookup in WITHOUT ROWID table = %v, want %v", records, want)
+	}
+	records, _ = lookup("w", "w_v", Record{"red"}, "x", "v")
+	if want := []Record{{"x1", "red"}, {"x3", "red"}}; !reflect.DeepEqual(records, want) {
+		t.Errorf("table lookup in WITHOUT ROWID table = %v, want %v", records, want)
+	}
+
+	table, _ := db.Table("t")
+	index, _ := db.Index("t_b")
+	if _, err := CollectRecords(db.IndexedLookup(table, index, Record{"name7"}, []string{"nope"})); err == nil {
+		t.Errorf("expected an error for an unknown column")
+	}
+}
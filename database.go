@@ -1,65 +1,212 @@
 package golite
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
-	"os"
+	"io"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 // Database represents an open SQLite database file.
-// It holds the file handle and the parsed database header.
+// It holds the Pager giving access to the file and the parsed database header.
 type Database struct {
-	file   *os.File
+	pager  Pager
 	Header *Header
+
+	// empty is true if the file has zero length, which SQLite treats as an
+	// empty database. Page 1 is then synthesized by readPageData.
+	empty bool
+	// pageCount is the number of pages in the database, established by Open
+	// and read again when a page beyond it is referenced.
+	pageCount atomic.Int64
+	// zeroCopy is set by WithZeroCopy.
+	zeroCopy bool
+	// arena is set by WithRecordArena.
+	arena bool
+	// cache is set by WithPageCache, and nil if pages are not cached.
+	cache *pageCache
+	// prefetch is set by WithPrefetch.
+	prefetch bool
+	// utf8Text is set by WithUTF8Text.
+	utf8Text bool
+	// nullValue is the value of NULL set by WithNullValue, if customNull.
+	nullValue  any
+	customNull bool
+	// counters are reported by Stats.
+	counters counters
+	// tracer is set by WithTracer, and nil if nothing is traced.
+	tracer Tracer
+	// interrupt is the state of Interrupt.
+	interrupt interrupter
+	// journal is the rollback journal found by Open.
+	journal Journal
+
+	// schemaMu guards the fields below, which cache the result of GetSchema.
+	schemaMu sync.Mutex
+	// schema is the cached schema, or nil if it has not been read yet.
+	schema *Schema
+	// changeCounter and schemaCookie are the header values that were current
+	// when the cached schema was read.
+	changeCounter, schemaCookie uint32
 }
 
-// ErrNotFound is returned by Find when a record with the specified rowID cannot be found.
-var ErrNotFound = errors.New("record not found")
+// OpenOption configures a Database opened with Open.
+type OpenOption func(*Database)
+
+// WithZeroCopy makes the TEXT and BLOB values of records read from the
+// database alias the buffer of the page they were read from, instead of
+// being copied. This saves an allocation and a copy per value, which matters
+// for scan-heavy workloads, but a single retained value keeps its whole page
+// in memory. Use Record.Detach or Record.Copy on records that outlive the
+// scan that produced them.
+func WithZeroCopy() OpenOption {
+	return func(db *Database) {
+		db.zeroCopy = true
+	}
+}
 
-// Open opens an SQLite database file from the given path.
-func Open(path string) (*Database, error) {
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open database file: %w", err)
+// ownValues detaches record unless the database was opened with
+// WithZeroCopy, for records decoded directly from page buffers.
+func (db *Database) ownValues(record Record) {
+	if !db.zeroCopy {
+		record.Detach()
+	}
+}
+
+// OpenPager opens an SQLite database whose file is accessed through pager.
+// The pager is closed if the database cannot be opened, and otherwise when
+// the Database is closed.
+func OpenPager(pager Pager, opts ...OpenOption) (*Database, error) {
+	// A freshly created database file is empty until something is written
+	// to it.
+	if size, err := pager.Size(); err == nil && size == 0 {
+		db := &Database{pager: pager, Header: emptyHeader(), empty: true}
+		db.pageCount.Store(1)
+		for _, opt := range opts {
+			opt(db)
+		}
+		return db, nil
 	}
 
 	headerBytes := make([]byte, HeaderSize)
-	if _, err := file.ReadAt(headerBytes, 0); err != nil {
-		file.Close()
+	if _, err := pager.ReadAt(headerBytes, 0); err != nil {
+		pager.Close()
 		return nil, fmt.Errorf("failed to read database header: %w", err)
 	}
 
 	header, err := ParseHeader(headerBytes)
 	if err != nil {
-		file.Close()
+		pager.Close()
 		return nil, fmt.Errorf("failed to parse database header: %w", err)
 	}
 
-	return &Database{file: file, Header: header}, nil
+	db := &Database{pager: pager, Header: header}
+	for _, opt := range opts {
+		opt(db)
+	}
+	pageCount := int(header.DatabaseSize)
+	if !header.DatabaseSizeValid() {
+		// The header was last written by a legacy version of SQLite, so the
+		// page count must be derived from the file size.
+		if pageCount, err = db.filePageCount(); err != nil {
+			pager.Close()
+			return nil, err
+		}
+	}
+	db.pageCount.Store(int64(pageCount))
+	return db, nil
+}
+
+// PageCount returns the number of pages in the database. This is the size
+// recorded in the header, unless Header.DatabaseSizeValid reports that it
+// cannot be trusted, in which case it is derived from the size of the file.
+// It is read when the database is opened, and again when a page beyond it is
+// referenced, as another connection may have grown the database since.
+func (db *Database) PageCount() int {
+	return int(db.pageCount.Load())
+}
+
+// filePageCount returns the number of pages in the file, derived from its size
+// rather than from the header, which may not be trustworthy.
+func (db *Database) filePageCount() (int, error) {
+	size, err := db.pager.Size()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get the size of the database file: %w", err)
+	}
+	return int(size / int64(db.Header.PageSize)), nil
 }
 
 // Close closes the underlying database file.
 func (db *Database) Close() error {
-	return db.file.Close()
+	return db.pager.Close()
 }
 
 // ReadPage reads a single page from the database file.
+// It returns a *CorruptError if pageNum cannot be the number of a B-Tree
+// page: if it is beyond the end of the database, or is the lock-byte page or
+// a pointer-map page.
 func (db *Database) ReadPage(pageNum int) (*Page, error) {
+	if err := db.checkBTreePage(pageNum); err != nil {
+		return nil, err
+	}
+	pageData, err := db.readPageData(pageNum)
+	if err != nil {
+		return nil, err
+	}
+	return db.parsePage(pageData, pageNum)
+}
+
+// readPageData reads the raw bytes of a single page from the database file.
+func (db *Database) readPageData(pageNum int) ([]byte, error) {
+	if db.interrupt.interrupted.Load() {
+		return nil, ErrInterrupted
+	}
+	if pageNum < 1 {
+		return nil, newCorruptError(pageNum, -1, -1, CorruptPageNumber, fmt.Errorf("invalid page number %d", pageNum))
+	}
+	if db.empty && pageNum == 1 {
+		return emptyPage1(db.Header), nil
+	}
+	if db.cache != nil {
+		if data, ok := db.cache.get(pageNum); ok {
+			db.counters.cacheHits.Add(1)
+			db.tracePageRead(pageNum, PageReadCache)
+			return data, nil
+		}
+		db.counters.cacheMisses.Add(1)
+	}
 	pageData := make([]byte, db.Header.PageSize)
 	offset := int64(pageNum-1) * int64(db.Header.PageSize)
-	_, err := db.file.ReadAt(pageData, offset)
+	n, err := db.pager.ReadAt(pageData, offset)
+	db.counters.bytesRead.Add(int64(n))
+	if errors.Is(err, io.EOF) {
+		return nil, newCorruptError(pageNum, -1, -1, CorruptPageNumber, errors.New("page is beyond the end of the file"))
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to read page %d: %w", pageNum, err)
 	}
-	return ParsePage(pageData, pageNum)
+	db.counters.pagesRead.Add(1)
+	db.tracePageRead(pageNum, PageReadFile)
+	if db.cache != nil {
+		db.cache.put(pageNum, pageData)
+	}
+	return pageData, nil
 }
 
 // TableSeek searches for a record with a specific rowID within a table's B-Tree.
 // It returns a RecordIterator that will yield at most one record. If the record
 // is not found, the iterator will be empty.
 func (db *Database) TableSeek(table TableInfo, rowID int64) RecordIterator {
-	return func(yield func(Record, error) bool) {
+	if table.Virtual {
+		return virtualTableRecords(table)
+	}
+	return interruptible(db, func(yield func(Record, error) bool) {
+		db.traceSeek(table.RootPage, Record{rowID})
 		pageNum := table.RootPage
 		for {
 			page, err := db.ReadPage(pageNum)
@@ -79,6 +226,9 @@ func (db *Database) TableSeek(table TableInfo, rowID int64) RecordIterator {
 					// Found it.
 					cell := page.LeafCells[i]
 					record := cell.Record
+					if order := table.virtualColumnsOrder(); order != nil {
+						record = table.fromStorage(record, order)
+					}
 					if table.RowIDColumnIndex != -1 {
 						record[table.RowIDColumnIndex] = cell.RowID
 						yield(record, nil)
@@ -102,88 +252,45 @@ func (db *Database) TableSeek(table TableInfo, rowID int64) RecordIterator {
 					pageNum = int(page.RightMostPtr)
 				}
 			default:
-				yield(nil, fmt.Errorf("unexpected page type %02x encountered during search", page.Type))
+				if !emptyRoot(page, pageNum, table.RootPage) {
+					yield(nil, unexpectedPageType(pageNum, page, "search"))
+				}
 				return
 			}
 		}
-	}
+	})
 }
 
 // IndexSeek searches for a key within an index's B-Tree. It returns a RecordIterator
 // that yields all matching index records. For a unique index, this will be at most one record.
-// The key is a Record containing the values of the indexed columns.
+// The key is a Record containing the values of the indexed columns. As in
+// SQLite, the affinity of each indexed column is applied to the key before it
+// is compared, so that for instance the TEXT '42' finds the INTEGER 42 in a
+// numeric column.
 func (db *Database) IndexSeek(index IndexInfo, key Record) RecordIterator {
-	return func(yield func(Record, error) bool) {
-		pageNum := index.RootPage
-		for {
-			page, err := db.ReadPage(pageNum)
-			if err != nil {
-				yield(nil, err)
-				return
-			}
-
-			switch page.Type {
-			case PageTypeLeafIndex:
-				// We've reached a leaf page. The cells are sorted by payload, so we can binary search.
-				i := sort.Search(len(page.LeafIndexCells), func(i int) bool {
-					// We are looking for the first record that is >= our key.
-					// The cell payload is (key_values..., rowid). We only compare the key part.
-					// This is safe because a valid index payload will always be longer than the key.
-					return CompareRecords(page.LeafIndexCells[i].Payload[:len(key)], key) >= 0
-				})
-
-				// Now, iterate from the found position as long as the keys match.
-				for ; i < len(page.LeafIndexCells); i++ {
-					cell := page.LeafIndexCells[i]
-					if len(cell.Payload) < len(key) {
-						continue
-					}
-					if CompareRecords(cell.Payload[:len(key)], key) == 0 {
-						if !yield(cell.Payload, nil) {
-							return // Consumer requested stop
-						}
-					} else {
-						// Since the cells are sorted, we can stop as soon as we find a non-match.
-						break
-					}
-				}
-				return // We are done searching this branch.
-
-			case PageTypeInteriorIndex:
-				// It's an interior page. Find the correct child page to descend into.
-				i := sort.Search(len(page.InteriorIndexCells), func(i int) bool {
-					return CompareRecords(key, page.InteriorIndexCells[i].Payload) <= 0
-				})
-
-				if i < len(page.InteriorIndexCells) {
-					pageNum = int(page.InteriorIndexCells[i].LeftChildPageNum)
-				} else {
-					pageNum = int(page.RightMostPtr)
-				}
-			default:
-				yield(nil, fmt.Errorf("unexpected page type %02x encountered during index search", page.Type))
-				return
-			}
-		}
-	}
+	return interruptible(db, db.indexEqualRange(index, index.coerceKey(key)))
 }
 
 // IndexScan returns an iterator over all records in an index.
 // The records are yielded in the order of the index.
 // The yielded record is the index record itself, not the table record.
 func (db *Database) IndexScan(index IndexInfo) RecordIterator {
-	return func(yield func(Record, error) bool) {
-		db.indexScanPage(index.RootPage, yield)
-	}
+	return interruptible(db, func(yield func(Record, error) bool) {
+		db.indexScanPage(index.RootPage, index, yield)
+	})
 }
 
 // indexScanPage is the recursive helper for IndexScan. It traverses the B-Tree in-order.
-func (db *Database) indexScanPage(pageNum int, yield func(Record, error) bool) bool {
+func (db *Database) indexScanPage(pageNum int, index IndexInfo, yield func(Record, error) bool) bool {
 	page, err := db.ReadPage(pageNum)
 	if err != nil {
 		return yield(nil, err)
 	}
+	return db.indexScanParsed(page, index, yield)
+}
 
+// indexScanParsed continues an IndexScan with an already read page.
+func (db *Database) indexScanParsed(page *Page, index IndexInfo, yield func(Record, error) bool) bool {
 	switch page.Type {
 	case PageTypeLeafIndex:
 		for _, cell := range page.LeafIndexCells {
@@ -194,26 +301,56 @@ func (db *Database) indexScanPage(pageNum int, yield func(Record, error) bool) b
 		return true // Continue scan
 
 	case PageTypeInteriorIndex:
+		children := make([]int, 0, len(page.InteriorIndexCells)+1)
 		for _, cell := range page.InteriorIndexCells {
-			if !db.indexScanPage(int(cell.LeftChildPageNum), yield) {
-				return false // Stop scan
+			children = append(children, int(cell.LeftChildPageNum))
+		}
+		children = append(children, int(page.RightMostPtr))
+		return db.visitChildPages(children, func(i int, child *Page, err error) bool {
+			if err != nil {
+				return yield(nil, err)
 			}
-			if !yield(cell.Payload, nil) {
+			if !db.indexScanParsed(child, index, yield) {
 				return false // Stop scan
 			}
-		}
-		return db.indexScanPage(int(page.RightMostPtr), yield)
+			// Each cell's payload sorts after its left child's subtree.
+			if i < len(page.InteriorIndexCells) {
+				return yield(page.InteriorIndexCells[i].Payload, nil)
+			}
+			return true
+		})
 	default:
-		return yield(nil, fmt.Errorf("unexpected page type %02x encountered during index scan", page.Type))
+		if emptyRoot(page, page.pageNum, index.RootPage) {
+			return true
+		}
+		return yield(nil, unexpectedPageType(page.pageNum, page, "index scan"))
 	}
 }
 
 // TableScan returns an iterator over all records in a table.
 // The iterator can be used with a for...range loop.
+// The records of a WITHOUT ROWID table hold its columns in declaration order,
+// and are yielded in primary key order. Virtual generated columns, which are
+// not stored, are NULL.
 // Note: This API requires Go 1.22+ with GOEXPERIMENT=rangefunc, or Go 1.23+.
 func (db *Database) TableScan(table TableInfo) RecordIterator {
-	return func(yield func(Record, error) bool) {
+	if table.Virtual {
+		return virtualTableRecords(table)
+	}
+	if index, ok := table.PrimaryKeyIndex(); ok {
+		return withoutRowIDRecords(table, db.IndexScan(index))
+	}
+	return interruptible(db, func(yield func(Record, error) bool) {
 		db.tableScanPage(table.RootPage, table, yield)
+	})
+}
+
+// virtualTableRecords returns the iterator over the records of a virtual
+// table, which only yields an *UnsupportedFeatureError: the rows of virtual
+// tables are provided by their module.
+func virtualTableRecords(table TableInfo) RecordIterator {
+	return func(yield func(Record, error) bool) {
+		yield(nil, unsupported(fmt.Sprintf("virtual tables (%s)", table.Name)))
 	}
 }
 
@@ -224,17 +361,25 @@ func (db *Database) tableScanPage(pageNum int, table TableInfo, yield func(Recor
 	if err != nil {
 		return yield(nil, err)
 	}
+	return db.tableScanParsed(page, table, yield)
+}
 
+// tableScanParsed continues a TableScan with an already read page.
+func (db *Database) tableScanParsed(page *Page, table TableInfo, yield func(Record, error) bool) bool {
 	switch page.Type {
 	case PageTypeLeafTable:
+		order := table.virtualColumnsOrder()
 		for _, cell := range page.LeafCells {
 			record := cell.Record
+			if order != nil {
+				record = table.fromStorage(record, order)
+			}
 			var finalRecord Record
 			if table.RowIDColumnIndex != -1 {
 				record[table.RowIDColumnIndex] = cell.RowID
 				finalRecord = record
 			} else {
-				finalRecord = append(Record{cell.RowID}, record...)
+				finalRecord = page.arena.prependRowID(cell.RowID, record)
 			}
 			if !yield(finalRecord, nil) {
 				return false // Stop scan
@@ -243,33 +388,297 @@ func (db *Database) tableScanPage(pageNum int, table TableInfo, yield func(Recor
 		return true // Continue scan
 
 	case PageTypeInteriorTable:
+		children := make([]int, 0, len(page.InteriorCells)+1)
 		for _, cell := range page.InteriorCells {
-			if !db.tableScanPage(int(cell.LeftChildPageNum), table, yield) {
-				return false // Stop scan
-			}
+			children = append(children, int(cell.LeftChildPageNum))
 		}
-		return db.tableScanPage(int(page.RightMostPtr), table, yield)
+		children = append(children, int(page.RightMostPtr))
+		return db.visitChildPages(children, func(_ int, child *Page, err error) bool {
+			if err != nil {
+				return yield(nil, err)
+			}
+			return db.tableScanParsed(child, table, yield)
+		})
 	default:
-		return yield(nil, fmt.Errorf("unexpected page type %02x encountered during scan", page.Type))
+		if emptyRoot(page, page.pageNum, table.RootPage) {
+			return true
+		}
+		return yield(nil, unexpectedPageType(page.pageNum, page, "scan"))
 	}
 }
 
-// GetSchema reads and parses the entire database schema from the sqlite_schema table.
+// maxPageRun is the maximum number of adjacent pages fetched by one read.
+const maxPageRun = 32
+
+// visitChildPages reads the pages numbered pageNums and calls visit with the
+// index of each one in pageNums and the parsed page, or the error that
+// prevented reading it. Runs of adjacent page numbers, which are common among
+// the children of an interior page, are fetched with a single read rather
+// than one read per page. With WithPrefetch, the next run is read in the
+// background while the current one is visited. It stops as soon as visit
+// returns false and reports whether all pages were visited.
+func (db *Database) visitChildPages(pageNums []int, visit func(i int, page *Page, err error) bool) bool {
+	return db.visitChildPageData(pageNums, func(i int, data []byte, err error) bool {
+		if err != nil {
+			return visit(i, nil, err)
+		}
+		page, err := db.parsePage(data, pageNums[i])
+		return visit(i, page, err)
+	})
+}
+
+// visitChildPageData is like visitChildPages, but calls visit with the raw
+// data of each page, leaving it to visit to parse it.
+func (db *Database) visitChildPageData(pageNums []int, visit func(i int, data []byte, err error) bool) bool {
+	// Split pageNums into runs of adjacent pages, given by their start index.
+	var runStarts []int
+	for start := 0; start < len(pageNums); {
+		runStarts = append(runStarts, start)
+		end := start + 1
+		for end < len(pageNums) && end-start < maxPageRun && pageNums[end] == pageNums[end-1]+1 {
+			end++
+		}
+		start = end
+	}
+	runEnd := func(r int) int {
+		if r+1 < len(runStarts) {
+			return runStarts[r+1]
+		}
+		return len(pageNums)
+	}
+	var next <-chan pageRun
+	if db.prefetch && len(runStarts) > 0 {
+		next = db.fetchPageRun(pageNums[0], runEnd(0))
+	}
+
+	for r, start := range runStarts {
+		var run pageRun
+		if next != nil {
+			run = <-next
+			// Start fetching the following run before decoding this one.
+			next = nil
+			if r+1 < len(runStarts) {
+				next = db.fetchPageRun(pageNums[runStarts[r+1]], runEnd(r+1)-runStarts[r+1])
+			}
+		} else {
+			run.pages, run.err = db.readPageRun(pageNums[start], runEnd(r)-start)
+		}
+		for i, data := range run.pages {
+			if err := db.checkBTreePage(pageNums[start+i]); err != nil {
+				return visit(start+i, nil, err)
+			}
+			if !visit(start+i, data, nil) {
+				return false
+			}
+		}
+		if run.err != nil {
+			return visit(start+len(run.pages), nil, run.err)
+		}
+	}
+	return true
+}
+
+// readPageRun reads count adjacent pages starting at page first with a
+// single read. The returned page buffers share one allocation. If some of
+// the pages cannot be read, it returns those that precede them along with
+// the error for the first one that failed.
+func (db *Database) readPageRun(first, count int) ([][]byte, error) {
+	if db.interrupt.interrupted.Load() {
+		return nil, ErrInterrupted
+	}
+	if count == 1 || db.empty || first < 1 {
+		data, err := db.readPageData(first)
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{data}, nil
+	}
+	if pages, ok := db.cachedPageRun(first, count); ok {
+		db.counters.cacheHits.Add(int64(count))
+		for i := range count {
+			db.tracePageRead(first+i, PageReadCache)
+		}
+		return pages, nil
+	}
+	if db.cache != nil {
+		db.counters.cacheMisses.Add(int64(count))
+	}
+	pageSize := int(db.Header.PageSize)
+	buf := make([]byte, count*pageSize)
+	n, err := db.pager.ReadAt(buf, int64(first-1)*int64(pageSize))
+	db.counters.bytesRead.Add(int64(n))
+	db.counters.pagesRead.Add(int64(min(n/pageSize, count)))
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read pages %d to %d: %w", first, first+count-1, err)
+	}
+	pages := make([][]byte, 0, count)
+	for i := range count {
+		if (i+1)*pageSize > n {
+			// Let readPageData describe why the page cannot be read.
+			data, err := db.readPageData(first + i)
+			if err != nil {
+				return pages, err
+			}
+			pages = append(pages, data)
+			continue
+		}
+		data := buf[i*pageSize : (i+1)*pageSize : (i+1)*pageSize]
+		db.tracePageRead(first+i, PageReadBatch)
+		if db.cache != nil {
+			db.cache.put(first+i, data)
+		}
+		pages = append(pages, data)
+	}
+	return pages, nil
+}
+
+// cachedPageRun returns a run of pages if all of them are in the page cache.
+func (db *Database) cachedPageRun(first, count int) ([][]byte, bool) {
+	if db.cache == nil {
+		return nil, false
+	}
+	pages := make([][]byte, count)
+	for i := range pages {
+		data, ok := db.cache.get(first + i)
+		if !ok {
+			return nil, false
+		}
+		pages[i] = data
+	}
+	return pages, true
+}
+
+// emptyRoot reports whether page is the uninitialized root page of a B-Tree,
+// which holds an empty tree, as can happen in a freshly created database.
+func emptyRoot(page *Page, pageNum, rootPage int) bool {
+	return pageNum == rootPage && page.IsUninitialized()
+}
+
+// unexpectedPageType reports a B-Tree page whose type does not fit the tree
+// being traversed.
+func unexpectedPageType(pageNum int, page *Page, operation string) error {
+	if page.IsUninitialized() {
+		return newCorruptError(pageNum, -1, page.headerOffset, CorruptTree,
+			fmt.Errorf("uninitialized page encountered during %s", operation))
+	}
+	return newCorruptError(pageNum, -1, page.headerOffset, CorruptTree,
+		fmt.Errorf("unexpected page type %02x encountered during %s", page.Type, operation))
+}
+
+// GetSchema returns the database schema, read from the sqlite_schema table.
+// The parsed schema is cached and shared between callers, so it must not be
+// modified. The cache is invalidated when the schema cookie in the file
+// header changes, as it does whenever another connection alters the schema.
 func (db *Database) GetSchema() (*Schema, error) {
-	schema := &Schema{
-		Tables:  make(map[string]TableInfo),
-		Indexes: make(map[string]IndexInfo),
+	db.schemaMu.Lock()
+	defer db.schemaMu.Unlock()
+
+	changeCounter, schemaCookie, err := db.readCounters()
+	if err != nil {
+		return nil, err
+	}
+	if changeCounter != db.changeCounter && db.cache != nil {
+		// The file was modified, so cached pages may be stale.
+		db.cache.clear()
+	}
+	if db.schema != nil && schemaCookie == db.schemaCookie {
+		db.changeCounter = changeCounter
+		return db.schema, nil
+	}
+	schema, err := db.readSchema()
+	if err != nil {
+		return nil, err
+	}
+	db.schema, db.changeCounter, db.schemaCookie = schema, changeCounter, schemaCookie
+	return schema, nil
+}
+
+// Table returns the schema information of the named table.
+func (db *Database) Table(name string) (TableInfo, error) {
+	schema, err := db.GetSchema()
+	if err != nil {
+		return TableInfo{}, err
+	}
+	table, ok := schema.Tables[name]
+	if !ok {
+		return TableInfo{}, fmt.Errorf("no such table: %s", name)
+	}
+	return table, nil
+}
+
+// View returns the schema information of the named view.
+func (db *Database) View(name string) (ViewInfo, error) {
+	schema, err := db.GetSchema()
+	if err != nil {
+		return ViewInfo{}, err
 	}
+	view, ok := schema.Views[name]
+	if !ok {
+		return ViewInfo{}, fmt.Errorf("no such view: %s", name)
+	}
+	return view, nil
+}
+
+// Index returns the schema information of the named index.
+func (db *Database) Index(name string) (IndexInfo, error) {
+	schema, err := db.GetSchema()
+	if err != nil {
+		return IndexInfo{}, err
+	}
+	index, ok := schema.Indexes[name]
+	if !ok {
+		return IndexInfo{}, fmt.Errorf("no such index: %s", name)
+	}
+	return index, nil
+}
+
+// readCounters reads the current file change counter and schema cookie from
+// the file header.
+func (db *Database) readCounters() (changeCounter, schemaCookie uint32, err error) {
+	if db.empty {
+		return 0, 0, nil
+	}
+	buf := make([]byte, 20)
+	if _, err := db.pager.ReadAt(buf, 24); err != nil {
+		return 0, 0, fmt.Errorf("failed to read database header: %w", err)
+	}
+	return binary.BigEndian.Uint32(buf[0:4]), binary.BigEndian.Uint32(buf[16:20]), nil
+}
+
+// autoindexColumns infers the columns of an automatic index from the UNIQUE
+// and PRIMARY KEY constraints of its table. Automatic indexes are named
+// sqlite_autoindex_TABLE_N, where N numbers the constraints that need an
+// index from 1, in the order they appear in the CREATE TABLE statement.
+func autoindexColumns(index IndexInfo, table TableInfo) ([]IndexColumn, error) {
+	n, err := strconv.Atoi(strings.TrimPrefix(index.Name, "sqlite_autoindex_"+index.TableName+"_"))
+	if err != nil {
+		return nil, newCorruptError(0, -1, -1, CorruptSchema, fmt.Errorf("malformed automatic index name %q", index.Name))
+	}
+	def, err := parseCreateTable(table.SQL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema for table %q: %w", table.Name, err)
+	}
+	if n < 1 || n > len(def.uniqueKeys) || def.uniqueKeys[n-1] == nil {
+		return nil, newCorruptError(0, -1, -1, CorruptSchema, fmt.Errorf("automatic index %q does not match a constraint of table %q", index.Name, table.Name))
+	}
+	columns := make([]IndexColumn, len(def.uniqueKeys[n-1]))
+	for i, name := range def.uniqueKeys[n-1] {
+		columns[i].Name = name
+	}
+	return columns, nil
+}
 
-	// The schema table is always rooted at page 1.
-	// We create a "bootstrap" TableInfo for the schema table itself to use the TableScan method.
-	// The schema table has no INTEGER PRIMARY KEY, so its RowIDColumnIndex is -1.
-	schemaTableInfo := TableInfo{
-		Name:             "sqlite_schema",
-		RootPage:         1,
-		SQL:              "CREATE TABLE sqlite_schema(type text, name text, tbl_name text, rootpage integer, sql text)",
-		RowIDColumnIndex: -1,
+// readSchema reads and parses the entire database schema from the sqlite_schema table.
+func (db *Database) readSchema() (*Schema, error) {
+	schema := &Schema{
+		Tables:   make(map[string]TableInfo),
+		Indexes:  make(map[string]IndexInfo),
+		Views:    make(map[string]ViewInfo),
+		Triggers: make(map[string]TriggerInfo),
 	}
+
+	// We use the "bootstrap" TableInfo for the schema table itself to use the TableScan method.
+	schemaTableInfo := SchemaTable()
 	schema.Tables[schemaTableInfo.Name] = schemaTableInfo
 
 	for record, err := range db.TableScan(schemaTableInfo) {
@@ -280,12 +689,12 @@ func (db *Database) GetSchema() (*Schema, error) {
 		// Schema table format: type, name, tbl_name, rootpage, sql
 		// After prepending the implicit rowid, we expect 6 columns.
 		if len(record) < 6 {
-			return nil, fmt.Errorf("malformed schema record: expected at least 6 columns, got %d", len(record))
+			return nil, newCorruptError(0, -1, -1, CorruptSchema, fmt.Errorf("malformed schema record: expected at least 6 columns, got %d", len(record)))
 		}
 
 		itemType, ok := record[1].(string)
 		if !ok {
-			return nil, fmt.Errorf("malformed schema record: column 1 (type) is not a string")
+			return nil, newCorruptError(0, -1, -1, CorruptSchema, errors.New("malformed schema record: column 1 (type) is not a string"))
 		}
 		switch itemType {
 		case "table":
@@ -293,10 +702,14 @@ func (db *Database) GetSchema() (*Schema, error) {
 			rootPage, okRootPage := record[4].(int64)
 			sql, okSQL := record[5].(string)
 			if !okName || !okRootPage || !okSQL {
-				return nil, fmt.Errorf("malformed schema record for table %q: one or more columns have an unexpected type", name)
+				return nil, newCorruptError(0, -1, -1, CorruptSchema, fmt.Errorf("malformed schema record for table %q: one or more columns have an unexpected type", name))
 			}
 
-			columns, rowIndex, err := ParseTableSQL(sql)
+			if table, ok := internalTable(name, int(rootPage), sql); ok {
+				schema.Tables[name] = table
+				continue
+			}
+			def, err := parseCreateTable(sql)
 			if err != nil {
 				return nil, fmt.Errorf("failed to parse schema for table %q: %w", name, err)
 			}
@@ -304,24 +717,103 @@ func (db *Database) GetSchema() (*Schema, error) {
 				Name:             name,
 				RootPage:         int(rootPage),
 				SQL:              sql,
-				Columns:          columns,
-				RowIDColumnIndex: rowIndex,
+				Columns:          def.columns,
+				RowIDColumnIndex: def.rowIDColumnIndex,
+				ForeignKeys:      def.foreignKeys,
+				Checks:           def.checks,
+				WithoutRowID:     def.withoutRowID,
+				Virtual:          def.virtual,
+				PrimaryKey:       def.primaryKey,
+			}
+		case "view":
+			name, okName := record[2].(string)
+			sql, okSQL := record[5].(string)
+			if !okName || !okSQL {
+				return nil, newCorruptError(0, -1, -1, CorruptSchema, fmt.Errorf("malformed schema record for view %q: one or more columns have an unexpected type", name))
+			}
+			def, err := parseCreateView(sql)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse schema for view %q: %w", name, err)
+			}
+			schema.Views[name] = ViewInfo{Name: name, SQL: sql, Columns: def.columns, Query: def.query}
+		case "trigger":
+			name, okName := record[2].(string)
+			tableName, okTableName := record[3].(string)
+			sql, okSQL := record[5].(string)
+			if !okName || !okTableName || !okSQL {
+				return nil, newCorruptError(0, -1, -1, CorruptSchema, fmt.Errorf("malformed schema record for trigger %q: one or more columns have an unexpected type", name))
 			}
+			schema.Triggers[name] = TriggerInfo{Name: name, TableName: tableName, SQL: sql}
 		case "index":
 			name, okName := record[2].(string)
 			tableName, okTableName := record[3].(string)
 			rootPage, okRootPage := record[4].(int64)
 			sql, okSQL := record[5].(string)
+			if isNull(record[5]) && strings.HasPrefix(name, "sqlite_autoindex_") {
+				// Indexes created for UNIQUE and PRIMARY KEY constraints
+				// have no SQL. Their columns are filled in below.
+				okSQL = true
+			}
 			if !okName || !okTableName || !okRootPage || !okSQL {
-				return nil, fmt.Errorf("malformed schema record for index %q: one or more columns have an unexpected type", name)
+				return nil, newCorruptError(0, -1, -1, CorruptSchema, fmt.Errorf("malformed schema record for index %q: one or more columns have an unexpected type", name))
+			}
+			def := &indexDef{unique: true} // Automatic indexes enforce constraints.
+			if sql != "" {
+				def, err = parseCreateIndex(sql)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse schema for index %q: %w", name, err)
+				}
 			}
 			schema.Indexes[name] = IndexInfo{
 				Name:      name,
 				TableName: tableName,
 				RootPage:  int(rootPage),
 				SQL:       sql,
+				Columns:   def.columns,
+				Unique:    def.unique,
+			}
+		}
+	}
+
+	// Index keys are compared using the affinity of the table columns, which
+	// are only all known once the whole schema has been read.
+	for name, index := range schema.Indexes {
+		table := schema.Tables[index.TableName]
+		if index.SQL == "" {
+			columns, err := autoindexColumns(index, table)
+			if err != nil {
+				return nil, err
 			}
+			index.Columns = columns
+			schema.Indexes[name] = index
+		}
+		for i, col := range index.Columns {
+			if j := table.lookupColumn(col.Name); j >= 0 {
+				index.Columns[i].affinity = table.Columns[j].Affinity()
+			}
+		}
+	}
+	// Like SQLite, refuse a schema whose root pages cannot be read, rather
+	// than fail in confusing ways when the tree is. Virtual tables have no
+	// B-Tree, and a root page of 0.
+	for _, table := range schema.Tables {
+		if table.Virtual || table.RootPage == 0 {
+			continue
+		}
+		if problem := db.pageRefProblem(table.RootPage); problem != "" {
+			return nil, newCorruptError(0, -1, -1, CorruptSchema, fmt.Errorf("invalid root page for table %q: %s", table.Name, problem))
+		}
+	}
+	for _, index := range schema.Indexes {
+		if problem := db.pageRefProblem(index.RootPage); problem != "" {
+			return nil, newCorruptError(0, -1, -1, CorruptSchema, fmt.Errorf("invalid root page for index %q: %s", index.Name, problem))
+		}
+	}
+	for name, table := range schema.Tables {
+		for _, index := range schema.IndexesOnTable(name) {
+			table.Indexes = append(table.Indexes, &index)
 		}
+		schema.Tables[name] = table
 	}
 	return schema, nil
 }
@@ -1,6 +1,12 @@
 package golite
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -116,6 +122,90 @@ func TestDatabase_IndexSeek(t *testing.T) {
 		}
 	})
 
+	t.Run("key affinity", func(t *testing.T) {
+		dbPath := createTestDB(t, "index_seek_affinity_test.sqlite")
+		runSQL(t, dbPath, `
+			CREATE TABLE nums(i INTEGER, r REAL, s TEXT);
+			INSERT INTO nums VALUES (42, 42.0, '42'), (7, 7.5, '7');
+			CREATE INDEX idx_i ON nums(i);
+			CREATE INDEX idx_r ON nums(r);
+			CREATE INDEX idx_s ON nums(s);
+		`)
+		db, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		defer db.Close()
+
+		testCases := []struct {
+			index string
+			key   any
+		}{
+			{"idx_i", "42"},
+			{"idx_i", 42.0},
+			{"idx_r", int64(42)},
+			{"idx_r", "42"},
+			{"idx_s", int64(42)},
+			{"idx_s", "42"},
+		}
+		for _, tc := range testCases {
+			index, err := db.Index(tc.index)
+			if err != nil {
+				t.Fatalf("Index() failed: %v", err)
+			}
+			count := 0
+			for _, err := range db.IndexSeek(index, Record{tc.key}) {
+				if err != nil {
+					t.Fatalf("IndexSeek() returned an unexpected error: %v", err)
+				}
+				count++
+			}
+			if count != 1 {
+				t.Errorf("%s: expected key %#v to find 1 record, found %d", tc.index, tc.key, count)
+			}
+		}
+	})
+
+	t.Run("descending columns and keys over several pages", func(t *testing.T) {
+		dbPath := createTestDB(t, "index_seek_desc_test.sqlite")
+		runSQL(t, dbPath, `
+			CREATE TABLE t(a INTEGER, b TEXT);
+			INSERT INTO t SELECT value % 5, printf('%0100d', value) FROM generate_series(1, 2000);
+			CREATE INDEX idx_a ON t(a);
+			CREATE INDEX idx_desc ON t(a DESC, b);
+			CREATE UNIQUE INDEX idx_b ON t(b DESC);
+		`)
+		db, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		defer db.Close()
+
+		testCases := []struct {
+			index string
+			key   Record
+			want  int
+		}{
+			{"idx_a", Record{int64(3)}, 400},
+			{"idx_desc", Record{int64(3)}, 400},
+			{"idx_desc", Record{int64(3), fmt.Sprintf("%0100d", 1003)}, 1},
+			{"idx_b", Record{fmt.Sprintf("%0100d", 1234)}, 1},
+		}
+		for _, tc := range testCases {
+			index, err := db.Index(tc.index)
+			if err != nil {
+				t.Fatalf("Index() failed: %v", err)
+			}
+			records, err := CollectRecords(db.IndexSeek(index, tc.key))
+			if err != nil {
+				t.Fatalf("IndexSeek() returned an unexpected error: %v", err)
+			}
+			if len(records) != tc.want {
+				t.Errorf("%s: expected key %v to find %d records, found %d", tc.index, tc.key, tc.want, len(records))
+			}
+		}
+	})
+
 	t.Run("index seek non-existent key", func(t *testing.T) {
 		key := Record{"non_existent_name"}
 		iterator := db.IndexSeek(indexInfo, key)
@@ -217,3 +307,247 @@ func TestDatabase_IndexScan(t *testing.T) {
 		}
 	})
 }
+
+func TestDatabase_UninitializedPages(t *testing.T) {
+	t.Run("zero-length file", func(t *testing.T) {
+		dbPath := filepath.Join(t.TempDir(), "empty.sqlite")
+		if err := os.WriteFile(dbPath, nil, 0644); err != nil {
+			t.Fatalf("failed to create empty file: %v", err)
+		}
+		db, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		defer db.Close()
+		schema, err := db.GetSchema()
+		if err != nil {
+			t.Fatalf("GetSchema() failed: %v", err)
+		}
+		if len(schema.Tables) != 1 || len(schema.Indexes) != 0 {
+			t.Errorf("expected only the schema table, got %d tables and %d indexes", len(schema.Tables), len(schema.Indexes))
+		}
+		if _, err := db.ReadPage(2); err == nil {
+			t.Errorf("expected an error reading page 2 of an empty database")
+		}
+	})
+
+	t.Run("uninitialized root page", func(t *testing.T) {
+		dbPath := createTestDB(t, "uninitialized_root_test.sqlite")
+		runSQL(t, dbPath, "CREATE TABLE empty(a TEXT, b TEXT); CREATE INDEX idx_empty ON empty(a);")
+		db, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		schema, err := db.GetSchema()
+		db.Close()
+		if err != nil {
+			t.Fatalf("GetSchema() failed: %v", err)
+		}
+		table, index := schema.Tables["empty"], schema.Indexes["idx_empty"]
+		corruptPage(t, dbPath, table.RootPage, 4096)
+		corruptPage(t, dbPath, index.RootPage, 4096)
+
+		db, err = Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		defer db.Close()
+		for _, it := range []RecordIterator{db.TableScan(table), db.TableSeek(table, 1), db.IndexScan(index), db.IndexSeek(index, Record{"a"})} {
+			for record, err := range it {
+				t.Errorf("expected no records, got %v (error %v)", record, err)
+			}
+		}
+	})
+
+	t.Run("uninitialized child page", func(t *testing.T) {
+		dbPath := createTestDB(t, "uninitialized_child_test.sqlite")
+		db, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		schema, err := db.GetSchema()
+		if err != nil {
+			t.Fatalf("GetSchema() failed: %v", err)
+		}
+		table := schema.Tables["test"]
+		root, err := db.ReadPage(table.RootPage)
+		db.Close()
+		if err != nil {
+			t.Fatalf("ReadPage() failed: %v", err)
+		}
+		corruptPage(t, dbPath, int(root.InteriorCells[0].LeftChildPageNum), 4096)
+
+		db, err = Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		defer db.Close()
+		var scanErr error
+		for _, err := range db.TableScan(table) {
+			if err != nil {
+				scanErr = err
+				break
+			}
+		}
+		var corrupt *CorruptError
+		if !errors.As(scanErr, &corrupt) || !strings.Contains(scanErr.Error(), "uninitialized page") {
+			t.Errorf("expected an uninitialized page *CorruptError, got %v", scanErr)
+		}
+	})
+}
+
+func TestDatabase_PageCount(t *testing.T) {
+	dbPath := createTestDB(t, "page_count_test.sqlite")
+	info, err := os.Stat(dbPath)
+	if err != nil {
+		t.Fatalf("failed to stat database: %v", err)
+	}
+	filePages := int(info.Size() / 4096)
+
+	t.Run("header size", func(t *testing.T) {
+		db, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		defer db.Close()
+		if db.PageCount() != int(db.Header.DatabaseSize) || db.PageCount() != filePages {
+			t.Errorf("expected %d pages, got %d", filePages, db.PageCount())
+		}
+	})
+
+	t.Run("legacy header", func(t *testing.T) {
+		// Simulate a file last modified by SQLite before 3.7.0: the change
+		// counter moved on without the version-valid-for number, leaving a
+		// stale database size behind.
+		f, err := os.OpenFile(dbPath, os.O_WRONLY, 0)
+		if err != nil {
+			t.Fatalf("failed to open database file: %v", err)
+		}
+		_, err = f.WriteAt([]byte{0, 0, 0, 2}, 28)
+		if err == nil {
+			_, err = f.WriteAt([]byte{0xff, 0xff, 0xff, 0xff}, 92)
+		}
+		f.Close()
+		if err != nil {
+			t.Fatalf("failed to write header: %v", err)
+		}
+
+		db, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		defer db.Close()
+		if db.Header.DatabaseSizeValid() {
+			t.Error("expected DatabaseSizeValid() to be false")
+		}
+		if db.PageCount() != filePages {
+			t.Errorf("expected %d pages derived from the file size, got %d", filePages, db.PageCount())
+		}
+	})
+}
+
+func TestOpen_WithZeroCopy(t *testing.T) {
+	dbPath := createTestDB(t, "zero_copy_test.sqlite")
+	collect := func(opts ...OpenOption) []Record {
+		db, err := Open(dbPath, opts...)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		defer db.Close()
+		schema, err := db.GetSchema()
+		if err != nil {
+			t.Fatalf("GetSchema() failed: %v", err)
+		}
+		var records []Record
+		for record, err := range db.TableScan(schema.Tables["test"]) {
+			if err != nil {
+				t.Fatalf("TableScan() failed: %v", err)
+			}
+			records = append(records, record)
+		}
+		return records
+	}
+	copied, zeroCopy := collect(), collect(WithZeroCopy())
+	if len(copied) != 500 || !reflect.DeepEqual(copied, zeroCopy) {
+		t.Errorf("expected zero-copy scan to match the default scan, got %d and %d records", len(copied), len(zeroCopy))
+	}
+}
+
+func TestDatabase_readPageRun(t *testing.T) {
+	db, err := Open(createTestDB(t, "page_run_test.sqlite"))
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	pageCount := db.PageCount()
+
+	t.Run("matches single page reads", func(t *testing.T) {
+		pages, err := db.readPageRun(2, pageCount-1)
+		if err != nil {
+			t.Fatalf("readPageRun() failed: %v", err)
+		}
+		if len(pages) != pageCount-1 {
+			t.Fatalf("expected %d pages, got %d", pageCount-1, len(pages))
+		}
+		for i, data := range pages {
+			want, err := db.readPageData(2 + i)
+			if err != nil {
+				t.Fatalf("readPageData() failed: %v", err)
+			}
+			if !reflect.DeepEqual(data, want) {
+				t.Errorf("page %d differs from a single page read", 2+i)
+			}
+		}
+	})
+
+	t.Run("run past end of file", func(t *testing.T) {
+		pages, err := db.readPageRun(pageCount-1, 4)
+		if len(pages) != 2 {
+			t.Errorf("expected the 2 existing pages, got %d", len(pages))
+		}
+		var corrupt *CorruptError
+		if !errors.As(err, &corrupt) || corrupt.PageNum != pageCount+1 || corrupt.Reason != CorruptPageNumber {
+			t.Errorf("expected a page number *CorruptError for page %d, got %v", pageCount+1, err)
+		}
+	})
+}
+
+func TestDatabase_SchemaCache(t *testing.T) {
+	dbPath := createTestDB(t, "schema_cache_test.sqlite")
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+
+	first, err := db.GetSchema()
+	if err != nil {
+		t.Fatalf("GetSchema() failed: %v", err)
+	}
+	second, err := db.GetSchema()
+	if err != nil {
+		t.Fatalf("GetSchema() failed: %v", err)
+	}
+	if first != second {
+		t.Error("expected the second GetSchema() call to return the cached schema")
+	}
+
+	if table, err := db.Table("test"); err != nil || table.RootPage != first.Tables["test"].RootPage {
+		t.Errorf("Table(\"test\") = %+v, %v", table, err)
+	}
+	if index, err := db.Index("idx_name"); err != nil || index.TableName != "test" {
+		t.Errorf("Index(\"idx_name\") = %+v, %v", index, err)
+	}
+	if _, err := db.Table("missing"); err == nil {
+		t.Error("expected an error for a missing table")
+	}
+	if _, err := db.Index("missing"); err == nil {
+		t.Error("expected an error for a missing index")
+	}
+
+	// Changing the schema bumps the schema cookie, which invalidates the cache.
+	runSQL(t, dbPath, "CREATE TABLE added(a TEXT);")
+	if _, err := db.Table("added"); err != nil {
+		t.Errorf("expected the new table to be found after a schema change, got %v", err)
+	}
+}
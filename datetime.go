@@ -0,0 +1,673 @@
+package golite
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The functions below implement SQLite's date and time functions of the
+// same names, following https://www.sqlite.org/lang_datefunc.html. The time
+// value may be TEXT in one of the formats SQLite accepts, such as
+// "2006-01-02 15:04:05" or "now", or a number, which is a Julian day number
+// unless a "unixepoch" or "auto" modifier follows. The modifiers are applied
+// in order. As in SQLite, an invalid time value or modifier yields SQLNull
+// rather than an error.
+
+// Date returns the date as text in the format YYYY-MM-DD.
+func Date(value any, modifiers ...string) any {
+	p, ok := evalDateTime(value, modifiers)
+	if !ok {
+		return SQLNull
+	}
+	return p.dateText()
+}
+
+// Time returns the time of day as text in the format HH:MM:SS, or
+// HH:MM:SS.SSS with the "subsec" modifier.
+func Time(value any, modifiers ...string) any {
+	p, ok := evalDateTime(value, modifiers)
+	if !ok {
+		return SQLNull
+	}
+	return p.timeText()
+}
+
+// DateTime returns the date and time as text in the format
+// YYYY-MM-DD HH:MM:SS, or YYYY-MM-DD HH:MM:SS.SSS with the "subsec" modifier.
+func DateTime(value any, modifiers ...string) any {
+	p, ok := evalDateTime(value, modifiers)
+	if !ok {
+		return SQLNull
+	}
+	return p.dateText() + " " + p.timeText()
+}
+
+// JulianDay returns the Julian day number as a float64: the number of days
+// since noon in Greenwich on November 24, 4714 B.C.
+func JulianDay(value any, modifiers ...string) any {
+	p, ok := evalDateTime(value, modifiers)
+	if !ok {
+		return SQLNull
+	}
+	return float64(p.jd) / 86400000
+}
+
+// UnixEpoch returns the number of seconds since 1970-01-01 00:00:00 UTC as an
+// int64, or as a float64 with the "subsec" modifier.
+func UnixEpoch(value any, modifiers ...string) any {
+	p, ok := evalDateTime(value, modifiers)
+	if !ok {
+		return SQLNull
+	}
+	if p.subsec {
+		return float64(p.jd-unixEpochJD) / 1000
+	}
+	return p.jd/1000 - unixEpochJD/1000
+}
+
+// Strftime returns the date formatted according to format, which supports
+// the substitutions of SQLite's strftime: %d, %e, %f, %F, %G, %g, %H, %I, %j,
+// %J, %k, %l, %m, %M, %p, %P, %R, %s, %S, %T, %u, %U, %V, %w, %W, %Y and %%.
+func Strftime(format string, value any, modifiers ...string) any {
+	p, ok := evalDateTime(value, modifiers)
+	if !ok {
+		return SQLNull
+	}
+	var b strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			b.WriteByte(format[i])
+			continue
+		}
+		i++
+		if i == len(format) {
+			return SQLNull
+		}
+		switch c := format[i]; c {
+		case 'd':
+			fmt.Fprintf(&b, "%02d", p.d)
+		case 'e':
+			fmt.Fprintf(&b, "%2d", p.d)
+		case 'f':
+			fmt.Fprintf(&b, "%06.3f", min(p.s, 59.999))
+		case 'F':
+			fmt.Fprintf(&b, "%04d-%02d-%02d", p.y, p.mo, p.d)
+		case 'G', 'g', 'V':
+			// The ISO 8601 week-numbering year is that of the Thursday of
+			// the same week.
+			thursday := dateTime{jd: p.jd + (3-(p.jd+43200000)/86400000%7)*86400000, validJD: true}
+			thursday.computeYMDHMS()
+			switch c {
+			case 'G':
+				fmt.Fprintf(&b, "%04d", thursday.y)
+			case 'g':
+				fmt.Fprintf(&b, "%02d", thursday.y%100)
+			default:
+				fmt.Fprintf(&b, "%02d", thursday.dayOfYear()/7+1)
+			}
+		case 'H':
+			fmt.Fprintf(&b, "%02d", p.h)
+		case 'k':
+			fmt.Fprintf(&b, "%2d", p.h)
+		case 'I', 'l':
+			h := p.h
+			if h > 12 {
+				h -= 12
+			}
+			if h == 0 {
+				h = 12
+			}
+			if c == 'I' {
+				fmt.Fprintf(&b, "%02d", h)
+			} else {
+				fmt.Fprintf(&b, "%2d", h)
+			}
+		case 'j':
+			fmt.Fprintf(&b, "%03d", p.dayOfYear()+1)
+		case 'J':
+			b.WriteString(strconv.FormatFloat(float64(p.jd)/86400000, 'g', 16, 64))
+		case 'm':
+			fmt.Fprintf(&b, "%02d", p.mo)
+		case 'M':
+			fmt.Fprintf(&b, "%02d", p.mi)
+		case 'p', 'P':
+			ampm := "AM"
+			if p.h >= 12 {
+				ampm = "PM"
+			}
+			if c == 'P' {
+				ampm = strings.ToLower(ampm)
+			}
+			b.WriteString(ampm)
+		case 'R':
+			fmt.Fprintf(&b, "%02d:%02d", p.h, p.mi)
+		case 's':
+			if p.subsec {
+				fmt.Fprintf(&b, "%.3f", float64(p.jd-unixEpochJD)/1000)
+			} else {
+				fmt.Fprintf(&b, "%d", p.jd/1000-unixEpochJD/1000)
+			}
+		case 'S':
+			fmt.Fprintf(&b, "%02d", int(p.s))
+		case 'T':
+			fmt.Fprintf(&b, "%02d:%02d:%02d", p.h, p.mi, int(p.s))
+		case 'u':
+			fmt.Fprintf(&b, "%d", (p.jd+43200000)/86400000%7+1)
+		case 'U':
+			fmt.Fprintf(&b, "%02d", (p.dayOfYear()+7-int((p.jd+129600000)/86400000%7))/7)
+		case 'w':
+			fmt.Fprintf(&b, "%d", (p.jd+129600000)/86400000%7)
+		case 'W':
+			fmt.Fprintf(&b, "%02d", (p.dayOfYear()+7-int((p.jd+43200000)/86400000%7))/7)
+		case 'Y':
+			fmt.Fprintf(&b, "%04d", p.y)
+		case '%':
+			b.WriteByte('%')
+		default:
+			return SQLNull
+		}
+	}
+	return b.String()
+}
+
+// unixEpochJD is the Unix epoch in Julian day milliseconds.
+const unixEpochJD = 210866760000000
+
+// maxJD is the largest valid time in Julian day milliseconds, the end of
+// the year 9999.
+const maxJD = 464269060799999
+
+// dateTime is the state of a date computation, following the DateTime
+// structure of SQLite's date.c: a time is known either as a Julian day
+// number, as broken-down fields, or both.
+type dateTime struct {
+	jd       int64 // The Julian day number times 86400000.
+	y, mo, d int
+	h, mi    int
+	s        float64
+	tz       int     // Timezone offset in minutes.
+	raw      float64 // The time value when it was a number.
+	validJD  bool
+	validYMD bool
+	validHMS bool
+	validTZ  bool
+	rawS     bool // raw holds a number not yet interpreted by a modifier.
+	subsec   bool // Set by the "subsec" modifier.
+	invalid  bool
+}
+
+// evalDateTime parses a time value, applies modifiers and returns the
+// result with all its fields computed, or false if it is invalid.
+func evalDateTime(value any, modifiers []string) (*dateTime, bool) {
+	p := &dateTime{}
+	switch v := value.(type) {
+	case int64:
+		p.setRawNumber(float64(v))
+	case float64:
+		p.setRawNumber(v)
+	case string:
+		if !p.parse(v) {
+			return nil, false
+		}
+	case []byte:
+		if !p.parse(string(v)) {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+	for i, modifier := range modifiers {
+		if !p.applyModifier(strings.ToLower(modifier), i) {
+			return nil, false
+		}
+	}
+	p.computeJD()
+	if p.invalid || p.jd < 0 || p.jd > maxJD {
+		return nil, false
+	}
+	if len(modifiers) == 0 && p.validYMD && p.d > 28 {
+		// Normalize a day past the end of the month, as in 2000-02-31.
+		p.validYMD = false
+	}
+	p.computeYMDHMS()
+	return p, !p.invalid
+}
+
+// setRawNumber sets the time value from a number, which is taken as a Julian
+// day number unless a modifier reinterprets it.
+func (p *dateTime) setRawNumber(r float64) {
+	p.raw, p.rawS = r, true
+	if r >= 0 && r < 5373484.5 {
+		p.jd, p.validJD = int64(r*86400000+0.5), true
+	}
+}
+
+// parse parses a time value given as text.
+func (p *dateTime) parse(s string) bool {
+	if p.parseYMD(s) || p.parseHMS(s) {
+		return true
+	}
+	if strings.EqualFold(s, "now") {
+		p.jd, p.validJD = time.Now().UnixMilli()+unixEpochJD, true
+		return true
+	}
+	t := strings.TrimSpace(s)
+	if t != "" && !strings.ContainsAny(t, "xXpPiInN_") {
+		if r, err := strconv.ParseFloat(t, 64); err == nil {
+			p.setRawNumber(r)
+			return true
+		}
+	}
+	return false
+}
+
+// getDigits parses a fixed number of decimal digits at the start of s and
+// checks that the result lies in [lo, hi].
+func getDigits(s string, n, lo, hi int) (int, bool) {
+	if len(s) < n {
+		return 0, false
+	}
+	v := 0
+	for _, c := range []byte(s[:n]) {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		v = v*10 + int(c-'0')
+	}
+	return v, v >= lo && v <= hi
+}
+
+// parseYMD parses text of the form YYYY-MM-DD, optionally followed by a
+// time of day as accepted by parseHMS.
+func (p *dateTime) parseYMD(s string) bool {
+	neg := strings.HasPrefix(s, "-")
+	if neg {
+		s = s[1:]
+	}
+	y, ok1 := getDigits(s, 4, 0, 9999)
+	if !ok1 || len(s) < 10 || s[4] != '-' || s[7] != '-' {
+		return false
+	}
+	mo, ok2 := getDigits(s[5:], 2, 1, 12)
+	d, ok3 := getDigits(s[8:], 2, 1, 31)
+	if !ok2 || !ok3 {
+		return false
+	}
+	rest := strings.TrimLeft(s[10:], " \t\n\r\fT")
+	if !p.parseHMS(rest) {
+		if rest != "" {
+			return false
+		}
+		p.validHMS = false
+	}
+	if neg {
+		y = -y
+	}
+	p.validJD, p.validYMD = false, true
+	p.y, p.mo, p.d = y, mo, d
+	if p.validTZ {
+		p.computeJD()
+	}
+	return true
+}
+
+// parseHMS parses text of the form HH:MM, HH:MM:SS or HH:MM:SS.SSS,
+// optionally followed by a timezone, "Z" or [+-]HH:MM.
+func (p *dateTime) parseHMS(s string) bool {
+	h, ok1 := getDigits(s, 2, 0, 24)
+	if !ok1 || len(s) < 5 || s[2] != ':' {
+		return false
+	}
+	mi, ok2 := getDigits(s[3:], 2, 0, 59)
+	if !ok2 {
+		return false
+	}
+	s = s[5:]
+	sec := 0.0
+	if len(s) > 0 && s[0] == ':' {
+		whole, ok := getDigits(s[1:], 2, 0, 59)
+		if !ok {
+			return false
+		}
+		sec = float64(whole)
+		s = s[3:]
+		if len(s) > 1 && s[0] == '.' && s[1] >= '0' && s[1] <= '9' {
+			frac, scale := 0.0, 1.0
+			s = s[1:]
+			for len(s) > 0 && s[0] >= '0' && s[0] <= '9' {
+				frac = frac*10 + float64(s[0]-'0')
+				scale *= 10
+				s = s[1:]
+			}
+			sec += frac / scale
+		}
+	}
+	tz, ok := parseTimezone(s)
+	if !ok {
+		return false
+	}
+	p.validJD, p.rawS, p.validHMS = false, false, true
+	p.h, p.mi, p.s = h, mi, sec
+	p.tz, p.validTZ = tz, tz != 0
+	return true
+}
+
+// parseTimezone parses the optional timezone following a time of day and
+// returns its offset in minutes.
+func parseTimezone(s string) (int, bool) {
+	s = strings.TrimLeft(s, " \t\n\r\f")
+	if s == "" {
+		return 0, true
+	}
+	sign := 1
+	switch s[0] {
+	case '-':
+		sign = -1
+	case '+':
+	case 'Z', 'z':
+		return 0, strings.TrimSpace(s[1:]) == ""
+	default:
+		return 0, false
+	}
+	h, ok1 := getDigits(s[1:], 2, 0, 14)
+	if !ok1 || len(s) < 6 || s[3] != ':' {
+		return 0, false
+	}
+	mi, ok2 := getDigits(s[4:], 2, 0, 59)
+	if !ok2 || strings.TrimSpace(s[6:]) != "" {
+		return 0, false
+	}
+	return sign * (h*60 + mi), true
+}
+
+// computeJD computes the Julian day number from the broken-down fields.
+func (p *dateTime) computeJD() {
+	if p.validJD {
+		return
+	}
+	y, mo, d := 2000, 1, 1
+	if p.validYMD {
+		y, mo, d = p.y, p.mo, p.d
+	}
+	if y < -4713 || y > 9999 || p.rawS {
+		p.invalid = true
+		return
+	}
+	if mo <= 2 {
+		y--
+		mo += 12
+	}
+	a := y / 100
+	b := 2 - a + a/4
+	x1 := 36525 * (y + 4716) / 100
+	x2 := 306001 * (mo + 1) / 10000
+	p.jd = int64((float64(x1+x2+d+b) - 1524.5) * 86400000)
+	p.validJD = true
+	if p.validHMS {
+		p.jd += int64(p.h)*3600000 + int64(p.mi)*60000 + int64(p.s*1000+0.5)
+		if p.validTZ {
+			p.jd -= int64(p.tz) * 60000
+			p.clearFields()
+		}
+	}
+}
+
+// computeYMDHMS computes the broken-down fields from the Julian day number.
+func (p *dateTime) computeYMDHMS() {
+	if !p.validYMD && !p.validJD {
+		// A time of day without a date is on 2000-01-01.
+		p.y, p.mo, p.d = 2000, 1, 1
+		p.validYMD = true
+	}
+	if !p.validYMD {
+		p.computeJD()
+		if p.invalid || p.jd < 0 || p.jd > maxJD {
+			p.invalid = true
+			return
+		}
+		z := int((p.jd + 43200000) / 86400000)
+		a := int((float64(z) - 1867216.25) / 36524.25)
+		a = z + 1 + a - a/4
+		b := a + 1524
+		c := int((float64(b) - 122.1) / 365.25)
+		d := (36525 * (c & 32767)) / 100
+		e := int(float64(b-d) / 30.6001)
+		p.d = b - d - int(30.6001*float64(e))
+		if e < 14 {
+			p.mo = e - 1
+		} else {
+			p.mo = e - 13
+		}
+		if p.mo > 2 {
+			p.y = c - 4716
+		} else {
+			p.y = c - 4715
+		}
+		p.validYMD = true
+	}
+	if !p.validHMS {
+		p.computeJD()
+		dayMs := int((p.jd + 43200000) % 86400000)
+		p.s = float64(dayMs%60000) / 1000
+		p.mi = dayMs / 60000 % 60
+		p.h = dayMs / 3600000
+		p.rawS, p.validHMS = false, true
+	}
+}
+
+// clearFields marks the broken-down fields as stale after the Julian day
+// number has changed.
+func (p *dateTime) clearFields() {
+	p.validYMD, p.validHMS, p.validTZ = false, false, false
+}
+
+// dayOfYear returns the number of days since January 1 of the year, 0 for
+// January 1 itself.
+func (p *dateTime) dayOfYear() int {
+	jan1 := *p
+	jan1.validJD, jan1.mo, jan1.d = false, 1, 1
+	jan1.computeJD()
+	return int((p.jd - jan1.jd + 43200000) / 86400000)
+}
+
+// dateText formats the date as YYYY-MM-DD.
+func (p *dateTime) dateText() string {
+	if p.y < 0 {
+		return fmt.Sprintf("-%04d-%02d-%02d", -p.y, p.mo, p.d)
+	}
+	return fmt.Sprintf("%04d-%02d-%02d", p.y, p.mo, p.d)
+}
+
+// timeText formats the time of day as HH:MM:SS or HH:MM:SS.SSS.
+func (p *dateTime) timeText() string {
+	if p.subsec {
+		return fmt.Sprintf("%02d:%02d:%06.3f", p.h, p.mi, p.s)
+	}
+	return fmt.Sprintf("%02d:%02d:%02d", p.h, p.mi, int(p.s))
+}
+
+// dateUnits are the units of the "NNN unit" modifiers, with the number of
+// seconds in each and the largest magnitude accepted.
+var dateUnits = []struct {
+	name    string
+	seconds float64
+	limit   float64
+}{
+	{"second", 1, 4.6427e+14},
+	{"minute", 60, 7.7379e+12},
+	{"hour", 3600, 1.2897e+11},
+	{"day", 86400, 5373485},
+	{"month", 30 * 86400, 176546},
+	{"year", 365 * 86400, 14713},
+}
+
+// applyModifier applies a lowercase modifier, the idx-th of the list.
+func (p *dateTime) applyModifier(m string, idx int) bool {
+	switch m {
+	case "auto":
+		if idx > 0 {
+			return false
+		}
+		if !p.rawS || p.validJD {
+			p.rawS = false
+			return true
+		}
+		if p.raw >= -210866760000 && p.raw <= 253402300799 {
+			return p.applyModifier("unixepoch", idx)
+		}
+		return false
+	case "julianday":
+		if idx > 0 || !p.rawS || !p.validJD {
+			return false
+		}
+		p.rawS = false
+		return true
+	case "unixepoch":
+		if idx > 0 || !p.rawS {
+			return false
+		}
+		r := p.raw*1000 + unixEpochJD
+		if r < 0 || r >= maxJD+1 {
+			return false
+		}
+		p.clearFields()
+		p.jd, p.validJD, p.rawS = int64(r+0.5), true, false
+		return true
+	case "localtime", "utc":
+		p.computeJD()
+		if p.invalid {
+			return false
+		}
+		utc := time.UnixMilli(p.jd - unixEpochJD).UTC()
+		var offset int
+		if m == "localtime" {
+			_, offset = utc.In(time.Local).Zone()
+		} else {
+			// Read the fields as a local wall clock time.
+			local := time.Date(utc.Year(), utc.Month(), utc.Day(), utc.Hour(), utc.Minute(), utc.Second(), utc.Nanosecond(), time.Local)
+			_, offset = local.Zone()
+			offset = -offset
+		}
+		p.jd += int64(offset) * 1000
+		p.clearFields()
+		return true
+	case "subsec", "subsecond":
+		p.subsec = true
+		return true
+	case "start of day", "start of month", "start of year":
+		p.computeYMDHMS()
+		if p.invalid {
+			return false
+		}
+		p.validHMS, p.h, p.mi, p.s = true, 0, 0, 0
+		p.rawS, p.validTZ, p.validJD = false, false, false
+		switch m {
+		case "start of month":
+			p.d = 1
+		case "start of year":
+			p.mo, p.d = 1, 1
+		}
+		return true
+	}
+
+	if rest, ok := strings.CutPrefix(m, "weekday "); ok {
+		r, err := strconv.ParseFloat(strings.TrimSpace(rest), 64)
+		n := int(r)
+		if err != nil || r < 0 || r >= 7 || float64(n) != r {
+			return false
+		}
+		p.computeYMDHMS()
+		p.validTZ, p.validJD = false, false
+		p.computeJD()
+		if p.invalid {
+			return false
+		}
+		z := int((p.jd + 129600000) / 86400000 % 7)
+		if z > n {
+			z -= 7
+		}
+		p.jd += int64(n-z) * 86400000
+		p.clearFields()
+		return true
+	}
+
+	// The remaining modifiers start with a number: a time shift such as
+	// "+01:30" or a quantity of some unit such as "-3 days".
+	end := strings.IndexFunc(m, func(r rune) bool {
+		return !(r >= '0' && r <= '9' || r == '+' || r == '-' || r == '.')
+	})
+	if end == -1 {
+		end = len(m)
+	}
+	if end < len(m) && m[end] == ':' {
+		digits := m
+		if m[0] == '+' || m[0] == '-' {
+			digits = m[1:]
+		}
+		var shift dateTime
+		if !shift.parseHMS(digits) || shift.validTZ {
+			return false
+		}
+		ms := int64(shift.h)*3600000 + int64(shift.mi)*60000 + int64(shift.s*1000+0.5)
+		if m[0] == '-' {
+			ms = -ms
+		}
+		p.computeJD()
+		if p.invalid {
+			return false
+		}
+		p.clearFields()
+		p.jd += ms
+		return true
+	}
+	r, err := strconv.ParseFloat(m[:end], 64)
+	if err != nil {
+		return false
+	}
+	unit := strings.TrimSpace(m[end:])
+	if len(unit) > 3 {
+		unit = strings.TrimSuffix(unit, "s")
+	}
+	for _, u := range dateUnits {
+		if unit != u.name {
+			continue
+		}
+		if r < -u.limit || r > u.limit {
+			return false
+		}
+		switch u.name {
+		case "month":
+			p.computeYMDHMS()
+			p.mo += int(r)
+			var x int
+			if p.mo > 0 {
+				x = (p.mo - 1) / 12
+			} else {
+				x = (p.mo - 12) / 12
+			}
+			p.y += x
+			p.mo -= x * 12
+			p.validJD = false
+			r -= float64(int(r))
+		case "year":
+			p.computeYMDHMS()
+			p.y += int(r)
+			p.validJD = false
+			r -= float64(int(r))
+		}
+		p.computeJD()
+		if p.invalid {
+			return false
+		}
+		rounder := 0.5
+		if r < 0 {
+			rounder = -0.5
+		}
+		p.jd += int64(r*1000*u.seconds + rounder)
+		p.clearFields()
+		return true
+	}
+	return false
+}
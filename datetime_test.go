@@ -0,0 +1,101 @@
+package golite
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDateTimeFunctions(t *testing.T) {
+	// SQLite computes the expected results for each time value and modifier.
+	const format = "%d|%e|%f|%F|%G|%g|%H|%I|%j|%J|%k|%l|%m|%M|%p|%P|%R|%s|%S|%T|%u|%U|%V|%w|%W|%Y|%%"
+	dbPath := filepath.Join(t.TempDir(), "datetime.sqlite")
+	runSQL(t, dbPath, `
+		CREATE TABLE times (v BLOB);
+		INSERT INTO times VALUES ('2024-02-29'), ('2024-02-29 13:45'), ('2023-12-31T23:59:59.987'),
+			('2021-01-03 00:00:00Z'), ('2020-06-15 08:30:00+05:30'), ('2020-06-15 08:30:00 -02:00'),
+			('12:34:56'), ('-0044-03-15'), ('9999-12-31 23:59:59'), ('2000-02-31'), ('2460000.25'),
+			(2451545.0), (2460676), (1700000000), (1700000000.5), (-86400), ('not a date'),
+			('2024-13-01'), ('23:59:59.999'), ('1582-10-04 12:00');
+		CREATE TABLE modifiers (m TEXT);
+		INSERT INTO modifiers VALUES (NULL), ('+1 day'), ('-36 hours'), ('+90 minutes'), ('+1.5 seconds'),
+			('+1 month'), ('-13 months'), ('+1.5 months'), ('+2 years'), ('-0.5 years'),
+			('start of month'), ('start of year'), ('start of day'), ('weekday 0'), ('weekday 3'),
+			('unixepoch'), ('auto'), ('julianday'), ('subsec'), ('+01:30'), ('-00:00:30.5'),
+			('localtime'), ('utc'), ('+1 fortnight'), ('weekday 7');
+		CREATE TABLE results (v BLOB, m TEXT, d BLOB, t BLOB, dt BLOB, jd BLOB, ue BLOB, f BLOB);
+		INSERT INTO results SELECT v, m,
+			CASE WHEN m IS NULL THEN date(v) ELSE date(v, m) END,
+			CASE WHEN m IS NULL THEN time(v) ELSE time(v, m) END,
+			CASE WHEN m IS NULL THEN datetime(v) ELSE datetime(v, m) END,
+			CASE WHEN m IS NULL THEN julianday(v) ELSE julianday(v, m) END,
+			CASE WHEN m IS NULL THEN unixepoch(v) ELSE unixepoch(v, m) END,
+			CASE WHEN m IS NULL THEN strftime('`+format+`', v) ELSE strftime('`+format+`', v, m) END
+			FROM times, modifiers
+			-- SQLite returns arbitrary results when converting an invalid
+			-- number to or from local time.
+			WHERE NOT (m IN ('localtime', 'utc') AND typeof(v) != 'text' AND julianday(v) IS NULL);
+	`)
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	table, err := db.Table("results")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+
+	count := 0
+	for record, err := range db.TableScan(table) {
+		if err != nil {
+			t.Fatalf("TableScan() failed: %v", err)
+		}
+		count++
+		values := table.ColumnValues(record)
+		var modifiers []string
+		if m, ok := values[1].(string); ok {
+			modifiers = []string{m}
+		}
+		check := func(name string, got, want any) {
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s(%#v, %q) = %#v, want %#v", name, values[0], modifiers, got, want)
+			}
+		}
+		check("Date", Date(values[0], modifiers...), values[2])
+		check("Time", Time(values[0], modifiers...), values[3])
+		check("DateTime", DateTime(values[0], modifiers...), values[4])
+		check("JulianDay", JulianDay(values[0], modifiers...), values[5])
+		check("UnixEpoch", UnixEpoch(values[0], modifiers...), values[6])
+		check("Strftime", Strftime(format, values[0], modifiers...), values[7])
+	}
+	if count == 0 {
+		t.Fatalf("expected results to compare against")
+	}
+}
+
+func TestDateTimeFunctions_Modifiers(t *testing.T) {
+	tests := []struct {
+		value     any
+		modifiers []string
+		want      any
+	}{
+		{"2024-01-31", []string{"+1 month"}, "2024-03-02 00:00:00"},
+		{"2024-03-15 10:00", []string{"start of month", "+1 month", "-1 day"}, "2024-03-31 00:00:00"},
+		{int64(1700000000), []string{"unixepoch", "start of day"}, "2023-11-14 00:00:00"},
+		{"2024-03-15", []string{"unixepoch"}, SQLNull},
+		{"2024-03-15", []string{"start of week"}, SQLNull},
+		{SQLNull, nil, SQLNull},
+	}
+	for _, test := range tests {
+		if got := DateTime(test.value, test.modifiers...); got != test.want {
+			t.Errorf("DateTime(%#v, %q) = %#v, want %#v", test.value, test.modifiers, got, test.want)
+		}
+	}
+	if got := Strftime("%Q", "2024-03-15"); got != SQLNull {
+		t.Errorf("expected NULL for an unknown substitution, got %#v", got)
+	}
+	if got := Date("now"); got == SQLNull {
+		t.Errorf("expected a date for now")
+	}
+}
@@ -0,0 +1,107 @@
+package golite
+
+import "fmt"
+
+// PageStat describes the space usage of a single B-Tree page, like a row of
+// SQLite's dbstat virtual table.
+type PageStat struct {
+	// Name is the name of the table or index the page belongs to.
+	Name string
+	// PageNum is the page number.
+	PageNum int
+	// PageType is the B-Tree page type, one of the PageType constants.
+	PageType byte
+	// Depth is the distance from the root of the B-Tree, which is at depth 0.
+	Depth int
+	// CellCount is the number of cells on the page.
+	CellCount int
+	// PayloadBytes is the total size of the cell payloads stored on the page.
+	// Interior table pages hold no payload.
+	PayloadBytes int
+	// UnusedBytes is the number of bytes on the page not used by the page
+	// header, the cell pointer array or cells, as returned by Page.FreeBytes.
+	UnusedBytes int
+	// MaxPayload is the size of the largest cell payload on the page.
+	MaxPayload int
+}
+
+// DBStat returns a PageStat for every page of every table and index B-Tree in
+// the database, the same information SQLite's dbstat virtual table exposes.
+// Trees are listed tables first then indexes, each in name order, and the
+// pages of each tree in depth-first order.
+func (db *Database) DBStat() ([]PageStat, error) {
+	schema, err := db.GetSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	var stats []PageStat
+	for _, table := range sortedTables(schema) {
+		if table.Virtual {
+			continue // Virtual tables have no B-Tree.
+		}
+		if stats, err = db.treeStats(table.Name, table.RootPage, 0, stats); err != nil {
+			return nil, err
+		}
+	}
+	for _, index := range sortedIndexes(schema) {
+		if stats, err = db.treeStats(index.Name, index.RootPage, 0, stats); err != nil {
+			return nil, err
+		}
+	}
+	return stats, nil
+}
+
+// treeStats appends the PageStat of pageNum and of all its descendants to stats.
+func (db *Database) treeStats(name string, pageNum, depth int, stats []PageStat) ([]PageStat, error) {
+	page, err := db.ReadPage(pageNum)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read page of %q: %w", name, err)
+	}
+
+	stat := PageStat{
+		Name:        name,
+		PageNum:     pageNum,
+		PageType:    page.Type,
+		Depth:       depth,
+		CellCount:   int(page.CellCount),
+		UnusedBytes: page.FreeBytes(),
+	}
+	addPayload := func(size int64) {
+		stat.PayloadBytes += int(size)
+		stat.MaxPayload = max(stat.MaxPayload, int(size))
+	}
+
+	var children []int
+	switch page.Type {
+	case PageTypeLeafTable:
+		for _, cell := range page.LeafCells {
+			addPayload(cell.PayloadSize)
+		}
+	case PageTypeLeafIndex:
+		for _, cell := range page.LeafIndexCells {
+			addPayload(cell.PayloadSize)
+		}
+	case PageTypeInteriorTable:
+		for _, cell := range page.InteriorCells {
+			children = append(children, int(cell.LeftChildPageNum))
+		}
+		children = append(children, int(page.RightMostPtr))
+	case PageTypeInteriorIndex:
+		for _, cell := range page.InteriorIndexCells {
+			addPayload(cell.PayloadSize)
+			children = append(children, int(cell.LeftChildPageNum))
+		}
+		children = append(children, int(page.RightMostPtr))
+	default:
+		return nil, fmt.Errorf("unexpected page type %02x encountered in %q", page.Type, name)
+	}
+
+	stats = append(stats, stat)
+	for _, child := range children {
+		if stats, err = db.treeStats(name, child, depth+1, stats); err != nil {
+			return nil, err
+		}
+	}
+	return stats, nil
+}
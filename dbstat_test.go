@@ -0,0 +1,67 @@
+package golite
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDatabase_DBStat(t *testing.T) {
+	dbPath := createTestDB(t, "dbstat_test.sqlite")
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+
+	stats, err := db.DBStat()
+	if err != nil {
+		t.Fatalf("DBStat() failed: %v", err)
+	}
+
+	pages := make(map[string]int)
+	leafCells := make(map[string]int)
+	unused := make(map[string]int)
+	for _, stat := range stats {
+		pages[stat.Name]++
+		unused[stat.Name] += stat.UnusedBytes
+		if stat.PageType == PageTypeLeafTable || stat.PageType == PageTypeLeafIndex {
+			leafCells[stat.Name] += stat.CellCount
+		}
+		if stat.UnusedBytes < 0 || stat.UnusedBytes > int(db.Header.PageSize) {
+			t.Errorf("page %d: unused bytes %d out of range", stat.PageNum, stat.UnusedBytes)
+		}
+		if stat.MaxPayload > stat.PayloadBytes {
+			t.Errorf("page %d: max payload %d exceeds total payload %d", stat.PageNum, stat.MaxPayload, stat.PayloadBytes)
+		}
+		if stat.PageNum == 1 && stat.Depth != 0 {
+			t.Errorf("expected page 1 to be at depth 0, got %d", stat.Depth)
+		}
+	}
+
+	if leafCells["test"] != 500 {
+		t.Errorf("expected 500 cells on the leaves of 'test', got %d", leafCells["test"])
+	}
+	if leafCells["sqlite_schema"] != 2 {
+		t.Errorf("expected 2 cells in 'sqlite_schema', got %d", leafCells["sqlite_schema"])
+	}
+
+	// Cross-check against the reference implementation's own dbstat table.
+	for _, name := range []string{"test", "idx_name"} {
+		query := "SELECT count(*), sum(unused) FROM dbstat WHERE name = '" + name + "'"
+		output, err := exec.Command("sqlite3", dbPath, query).CombinedOutput()
+		if err != nil {
+			t.Skipf("sqlite3 does not provide dbstat: %v", err)
+		}
+		fields := strings.Split(strings.TrimSpace(string(output)), "|")
+		wantPages, _ := strconv.Atoi(fields[0])
+		wantUnused, _ := strconv.Atoi(fields[1])
+		if pages[name] != wantPages {
+			t.Errorf("%s: expected %d pages, got %d", name, wantPages, pages[name])
+		}
+		if unused[name] != wantUnused {
+			t.Errorf("%s: expected %d unused bytes, got %d", name, wantUnused, unused[name])
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package golite
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultValue evaluates the DEFAULT clause of the column, returning the
+// value SQLite would store for it with the column's affinity applied. A
+// column without a DEFAULT clause defaults to SQLNull.
+//
+// Only constant defaults are supported: NULL, TRUE and FALSE, numbers,
+// strings, blobs, CURRENT_TIME, CURRENT_DATE and CURRENT_TIMESTAMP, optionally
+// in parentheses. Other expressions yield an UnsupportedFeatureError.
+func (c ColumnInfo) DefaultValue() (any, error) {
+	return c.defaultValue(time.Now())
+}
+
+// defaultValue is DefaultValue with the current time passed in.
+func (c ColumnInfo) defaultValue(now time.Time) (any, error) {
+	value, err := evalDefault(c.Default, now)
+	if err != nil {
+		return nil, fmt.Errorf("default of column %s: %w", c.Name, err)
+	}
+	aff := c.Affinity()
+	value = aff.apply(value)
+	if i, ok := value.(int64); ok && aff == AffinityReal {
+		value = float64(i)
+	}
+	return value, nil
+}
+
+// paddingValue returns the value of the column in a record written before it
+// was added by ALTER TABLE, which is its default or NULL if the default
+// cannot be evaluated.
+func (c ColumnInfo) paddingValue() any {
+	if c.Default == "" {
+		return SQLNull
+	}
+	value, err := c.DefaultValue()
+	if err != nil {
+		return SQLNull
+	}
+	return value
+}
+
+// evalDefault evaluates the text of a DEFAULT clause.
+func evalDefault(text string, now time.Time) (any, error) {
+	text = strings.TrimSpace(text)
+	for len(text) >= 2 && text[0] == '(' && text[len(text)-1] == ')' {
+		text = strings.TrimSpace(text[1 : len(text)-1])
+	}
+	upper := strings.ToUpper(text)
+	switch upper {
+	case "", "NULL":
+		return SQLNull, nil
+	case "TRUE":
+		return int64(1), nil
+	case "FALSE":
+		return int64(0), nil
+	case "CURRENT_TIME":
+		return now.UTC().Format("15:04:05"), nil
+	case "CURRENT_DATE":
+		return now.UTC().Format("2006-01-02"), nil
+	case "CURRENT_TIMESTAMP":
+		return now.UTC().Format("2006-01-02 15:04:05"), nil
+	}
+	switch {
+	case text[0] == '\'' && len(text) >= 2 && text[len(text)-1] == '\'':
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	case strings.HasPrefix(upper, "X'") && len(text) >= 3 && text[len(text)-1] == '\'':
+		blob, err := hex.DecodeString(text[2 : len(text)-1])
+		if err != nil {
+			return nil, fmt.Errorf("malformed blob literal %s", text)
+		}
+		return blob, nil
+	case text[0] == '"' && len(text) >= 2 && text[len(text)-1] == '"':
+		// SQLite accepts a quoted identifier as a string in this position.
+		return strings.ReplaceAll(text[1:len(text)-1], `""`, `"`), nil
+	}
+	if value, ok := parseNumberLiteral(text); ok {
+		return value, nil
+	}
+	return nil, unsupported(fmt.Sprintf("DEFAULT expression %s", text))
+}
+
+// parseNumberLiteral parses an optionally signed numeric literal: a decimal
+// or hexadecimal integer, or a real number.
+func parseNumberLiteral(text string) (any, bool) {
+	digits := strings.TrimSpace(strings.TrimLeft(text, "+-"))
+	if digits == "" || digits[0] != '.' && (digits[0] < '0' || digits[0] > '9') {
+		return nil, false
+	}
+	negative := strings.Count(text[:len(text)-len(strings.TrimLeft(text, "+-"))], "-")%2 == 1
+	if len(digits) > 2 && (digits[:2] == "0x" || digits[:2] == "0X") {
+		u, err := strconv.ParseUint(digits[2:], 16, 64)
+		if err != nil {
+			return nil, false
+		}
+		i := int64(u) // Hexadecimal literals are 64-bit two's complement.
+		if negative {
+			i = -i
+		}
+		return i, true
+	}
+	if negative {
+		digits = "-" + digits
+	}
+	if i, err := strconv.ParseInt(digits, 10, 64); err == nil {
+		return i, true
+	}
+	// Reals out of range, such as 1e999, are infinite, as in SQLite.
+	f, err := strconv.ParseFloat(digits, 64)
+	if err != nil && !errors.Is(err, strconv.ErrRange) || strings.ContainsAny(digits, "iInN_") {
+		return nil, false
+	}
+	return f, true
+}
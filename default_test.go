@@ -0,0 +1,84 @@
+package golite
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestColumnInfo_DefaultValue(t *testing.T) {
+	now := time.Date(2024, 3, 9, 14, 5, 6, 0, time.UTC)
+	testCases := []struct {
+		col  ColumnInfo
+		want any
+	}{
+		{col: ColumnInfo{Type: "TEXT"}, want: SQLNull},
+		{col: ColumnInfo{Type: "TEXT", Default: "NULL"}, want: SQLNull},
+		{col: ColumnInfo{Type: "INTEGER", Default: "42"}, want: int64(42)},
+		{col: ColumnInfo{Type: "INTEGER", Default: "- 7"}, want: int64(-7)},
+		{col: ColumnInfo{Type: "INTEGER", Default: "0x10"}, want: int64(16)},
+		{col: ColumnInfo{Type: "INTEGER", Default: "'12'"}, want: int64(12)},
+		{col: ColumnInfo{Type: "INTEGER", Default: "-9223372036854775808"}, want: int64(-9223372036854775808)},
+		{col: ColumnInfo{Type: "REAL", Default: "3"}, want: 3.0},
+		{col: ColumnInfo{Type: "REAL", Default: "-1.5e-3"}, want: -1.5e-3},
+		{col: ColumnInfo{Type: "TEXT", Default: "5"}, want: "5"},
+		{col: ColumnInfo{Type: "TEXT", Default: "'it''s'"}, want: "it's"},
+		{col: ColumnInfo{Type: "BLOB", Default: "x'00FF'"}, want: []byte{0, 0xff}},
+		{col: ColumnInfo{Type: "BOOLEAN", Default: "TRUE"}, want: int64(1)},
+		{col: ColumnInfo{Type: "INTEGER", Default: "((7))"}, want: int64(7)},
+		{col: ColumnInfo{Type: "TEXT", Default: "CURRENT_TIMESTAMP"}, want: "2024-03-09 14:05:06"},
+		{col: ColumnInfo{Type: "TEXT", Default: "current_date"}, want: "2024-03-09"},
+		{col: ColumnInfo{Type: "TEXT", Default: "CURRENT_TIME"}, want: "14:05:06"},
+	}
+	for _, tc := range testCases {
+		got, err := tc.col.defaultValue(now)
+		if err != nil || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("default %q of a %s column: got (%#v, %v), want %#v", tc.col.Default, tc.col.Type, got, err, tc.want)
+		}
+	}
+
+	col := ColumnInfo{Name: "n", Type: "INTEGER", Default: "(abs(-1))"}
+	if _, err := col.DefaultValue(); !errors.Is(err, ErrUnsupportedFeature) {
+		t.Errorf("expected an unsupported feature error for an expression, got %v", err)
+	}
+	if got := col.paddingValue(); got != SQLNull {
+		t.Errorf("expected an unsupported default to pad with NULL, got %v", got)
+	}
+}
+
+func TestRow_AddedColumnDefaults(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "defaults.sqlite")
+	runSQL(t, dbPath, `
+		CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT);
+		INSERT INTO t (name) VALUES ('old');
+		ALTER TABLE t ADD COLUMN score INTEGER DEFAULT '2';
+		ALTER TABLE t ADD COLUMN tag TEXT DEFAULT 'none';
+		INSERT INTO t (name, score, tag) VALUES ('new', 5, 'x');
+	`)
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	table, err := db.Table("t")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+
+	var got []map[string]any
+	for row, err := range Rows(table, db.TableScan(table)) {
+		if err != nil {
+			t.Fatalf("Rows() returned an unexpected error: %v", err)
+		}
+		got = append(got, row.Map())
+	}
+	want := []map[string]any{
+		{"id": int64(1), "name": "old", "score": int64(2), "tag": "none"},
+		{"id": int64(2), "name": "new", "score": int64(5), "tag": "x"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
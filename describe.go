@@ -0,0 +1,204 @@
+package golite
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// pageTypeNames maps B-Tree page types to human-readable names.
+var pageTypeNames = map[byte]string{
+	PageTypeInteriorIndex: "interior index",
+	PageTypeInteriorTable: "interior table",
+	PageTypeLeafIndex:     "leaf index",
+	PageTypeLeafTable:     "leaf table",
+}
+
+// Describe renders an annotated view of the raw page: the page header, the
+// cell pointer array, each cell's varints, record header and values, and the
+// freeblocks. Every line starts with the hexadecimal byte offset within the
+// page and the bytes it describes.
+func (p *Page) Describe() string {
+	d := &pageDescriber{data: p.RawData}
+
+	if p.headerOffset > 0 {
+		d.line(0, p.headerOffset, "database file header")
+	}
+	h := p.headerOffset
+	typeName := pageTypeNames[p.Type]
+	if typeName == "" {
+		typeName = "unknown"
+		if p.IsUninitialized() {
+			typeName = "uninitialized"
+		}
+	}
+	d.line(h, 1, fmt.Sprintf("page type: %s (0x%02x)", typeName, p.Type))
+	d.line(h+1, 2, fmt.Sprintf("first freeblock: %d", p.Freeblock))
+	d.line(h+3, 2, fmt.Sprintf("cell count: %d", p.CellCount))
+	d.line(h+5, 2, fmt.Sprintf("cell content area: %d", p.cellContentStart()))
+	d.line(h+7, 1, fmt.Sprintf("fragmented bytes: %d", p.Fragmented))
+	pointersStart := h + 8
+	if p.Type == PageTypeInteriorIndex || p.Type == PageTypeInteriorTable {
+		d.line(h+8, 4, fmt.Sprintf("right-most pointer: %d", p.RightMostPtr))
+		pointersStart = h + 12
+	}
+	for i, ptr := range p.CellPointers {
+		d.line(pointersStart+2*i, 2, fmt.Sprintf("cell pointer %d: %d", i, ptr))
+	}
+
+	for i, ptr := range p.CellPointers {
+		d.section(fmt.Sprintf("cell %d", i))
+		d.cell(p.Type, int(ptr))
+	}
+
+	for _, fb := range p.freeblocks() {
+		start := fb.start - 4
+		d.section(fmt.Sprintf("freeblock at %d", start))
+		d.line(start, 2, fmt.Sprintf("next freeblock: %d", binary.BigEndian.Uint16(p.RawData[start:])))
+		d.line(start+2, 2, fmt.Sprintf("size: %d", fb.end-start))
+		d.line(fb.start, fb.end-fb.start, "free space")
+	}
+	return d.String()
+}
+
+// pageDescriber accumulates the lines of an annotated page view.
+type pageDescriber struct {
+	strings.Builder
+	data []byte
+}
+
+// maxDescribedBytes is the number of bytes shown on one line before eliding.
+const maxDescribedBytes = 8
+
+// line appends the annotation text for the n bytes at offset. Offsets and
+// lengths are clamped to the page, so corrupt pointers cannot cause a panic.
+func (d *pageDescriber) line(offset, n int, text string) {
+	start := min(max(offset, 0), len(d.data))
+	end := min(max(start+n, start), len(d.data))
+	shown := d.data[start:min(end, start+maxDescribedBytes)]
+	bytesText := hex.EncodeToString(shown)
+	var spaced strings.Builder
+	for i := 0; i < len(bytesText); i += 2 {
+		if i > 0 {
+			spaced.WriteByte(' ')
+		}
+		spaced.WriteString(bytesText[i : i+2])
+	}
+	if end-start > maxDescribedBytes {
+		spaced.WriteString(" ..")
+	}
+	fmt.Fprintf(d, "%04x: %-26s %s\n", offset, spaced.String(), text)
+}
+
+// section starts a new titled group of lines.
+func (d *pageDescriber) section(title string) {
+	fmt.Fprintf(d, "-- %s --\n", title)
+}
+
+// varint annotates the varint at offset and returns its value and length.
+func (d *pageDescriber) varint(offset int, label string) (int64, int) {
+	if offset >= len(d.data) {
+		d.line(offset, 0, label+": out of bounds")
+		return 0, 0
+	}
+	v, n := readVarint(d.data[offset:])
+	d.line(offset, n, fmt.Sprintf("%s: %d", label, v))
+	return v, n
+}
+
+// cell annotates the cell at offset for a page of the given type.
+func (d *pageDescriber) cell(pageType byte, offset int) {
+	if offset+4 > len(d.data) {
+		d.line(offset, 0, "cell offset out of bounds")
+		return
+	}
+	switch pageType {
+	case PageTypeLeafTable:
+		size, n := d.varint(offset, "payload size")
+		_, m := d.varint(offset+n, "rowid")
+		d.record(offset+n+m, int(size))
+	case PageTypeInteriorTable:
+		d.line(offset, 4, fmt.Sprintf("left child page: %d", binary.BigEndian.Uint32(d.data[offset:])))
+		d.varint(offset+4, "key")
+	case PageTypeLeafIndex:
+		size, n := d.varint(offset, "payload size")
+		d.record(offset+n, int(size))
+	case PageTypeInteriorIndex:
+		d.line(offset, 4, fmt.Sprintf("left child page: %d", binary.BigEndian.Uint32(d.data[offset:])))
+		size, n := d.varint(offset+4, "payload size")
+		d.record(offset+4+n, int(size))
+	}
+}
+
+// record annotates the record header and values of a payload of the given
+// size starting at offset.
+func (d *pageDescriber) record(offset, size int) {
+	if offset >= len(d.data) {
+		d.line(offset, 0, "record out of bounds")
+		return
+	}
+	headerSize, n := d.varint(offset, "record header size")
+	var serialTypes []int64
+	for pos := offset + n; pos < offset+int(headerSize) && pos < len(d.data); {
+		st, m := readVarint(d.data[pos:])
+		d.line(pos, m, fmt.Sprintf("column %d serial type: %d (%s)", len(serialTypes), st, describeSerialType(st)))
+		serialTypes = append(serialTypes, st)
+		pos += m
+	}
+
+	pos := offset + int(headerSize)
+	end := min(offset+size, len(d.data))
+	for i, st := range serialTypes {
+		if pos > end {
+			d.line(pos, 0, fmt.Sprintf("column %d: out of bounds", i))
+			return
+		}
+		value, n, err := serialTypeToValue(st, d.data[pos:end])
+		if err != nil {
+			d.line(pos, 0, fmt.Sprintf("column %d: %v", i, err))
+			return
+		}
+		d.line(pos, n, fmt.Sprintf("column %d: %s", i, describeValue(value)))
+		pos += n
+	}
+}
+
+// describeSerialType returns a short description of a record serial type.
+func describeSerialType(st int64) string {
+	switch {
+	case st == 0:
+		return "NULL"
+	case st >= 1 && st <= 6:
+		widths := []int{1, 2, 3, 4, 6, 8}
+		return fmt.Sprintf("%d-byte integer", widths[st-1])
+	case st == 7:
+		return "float"
+	case st == 8:
+		return "integer 0"
+	case st == 9:
+		return "integer 1"
+	case st >= 12 && st%2 == 0:
+		return fmt.Sprintf("BLOB, %d bytes", (st-12)/2)
+	case st >= 13:
+		return fmt.Sprintf("TEXT, %d bytes", (st-13)/2)
+	}
+	return "reserved"
+}
+
+// describeValue renders a decoded value for display.
+func describeValue(v any) string {
+	switch v := v.(type) {
+	case NullType, nil:
+		return "NULL"
+	case string:
+		if len(v) > 32 {
+			v = v[:32] + "..."
+		}
+		return strconv.Quote(v)
+	case []byte:
+		return fmt.Sprintf("x'%x'", v)
+	}
+	return fmt.Sprint(v)
+}
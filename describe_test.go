@@ -0,0 +1,63 @@
+package golite
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPage_Describe(t *testing.T) {
+	dbPath := createTestDB(t, "describe_test.sqlite")
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+
+	t.Run("schema page", func(t *testing.T) {
+		page, err := db.ReadPage(1)
+		if err != nil {
+			t.Fatalf("ReadPage(1) failed: %v", err)
+		}
+		desc := page.Describe()
+		for _, want := range []string{
+			"0000: 53 51 4c 69 74 65 20 66 .. database file header",
+			"0064: 0d                         page type: leaf table (0x0d)",
+			"cell count: 2",
+			"-- cell 0 --",
+			"column 1 serial type",
+			`column 4: "CREATE TABLE test(id INTEGER PRI..."`,
+		} {
+			if !strings.Contains(desc, want) {
+				t.Errorf("expected description to contain %q, got:\n%s", want, desc)
+			}
+		}
+	})
+
+	t.Run("interior page", func(t *testing.T) {
+		schema, err := db.GetSchema()
+		if err != nil {
+			t.Fatalf("GetSchema() failed: %v", err)
+		}
+		page, err := db.ReadPage(schema.Tables["test"].RootPage)
+		if err != nil {
+			t.Fatalf("ReadPage() failed: %v", err)
+		}
+		desc := page.Describe()
+		for _, want := range []string{"page type: interior table (0x05)", "right-most pointer:", "left child page:", "key:"} {
+			if !strings.Contains(desc, want) {
+				t.Errorf("expected description to contain %q, got:\n%s", want, desc)
+			}
+		}
+	})
+
+	t.Run("corrupt cell pointer", func(t *testing.T) {
+		page, err := db.ReadPage(1)
+		if err != nil {
+			t.Fatalf("ReadPage(1) failed: %v", err)
+		}
+		page.CellPointers = append(page.CellPointers, 0xfffe)
+		if desc := page.Describe(); !strings.Contains(desc, "cell offset out of bounds") {
+			t.Errorf("expected description to flag the bad pointer, got:\n%s", desc)
+		}
+	})
+}
@@ -0,0 +1,460 @@
+package golite
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"iter"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ChangeKind describes how an object or row differs between two databases.
+type ChangeKind int
+
+const (
+	// Added means the object or row only exists in the second database.
+	Added ChangeKind = iota
+	// Removed means the object or row only exists in the first database.
+	Removed
+	// Modified means the object or row exists in both but differs.
+	Modified
+)
+
+// String returns the lowercase name of the change kind.
+func (k ChangeKind) String() string {
+	switch k {
+	case Added:
+		return "added"
+	case Removed:
+		return "removed"
+	case Modified:
+		return "modified"
+	}
+	return fmt.Sprintf("ChangeKind(%d)", int(k))
+}
+
+// SchemaChange describes a table or index whose definition differs between
+// two databases.
+type SchemaChange struct {
+	// Type is "table" or "index".
+	Type string
+	// Name is the name of the table or index.
+	Name string
+	// Kind tells whether the object was added, removed or modified.
+	Kind ChangeKind
+	// OldSQL and NewSQL are the object's definitions in the first and second
+	// database respectively, empty where the object does not exist.
+	OldSQL, NewSQL string
+}
+
+// RowChange describes a row that differs between two versions of a table.
+type RowChange struct {
+	// Table is the name of the table.
+	Table string
+	// Kind tells whether the row was added, removed or modified.
+	Kind ChangeKind
+	// RowID is the rowid the rows were matched on, or 0 for a WITHOUT ROWID
+	// table.
+	RowID int64
+	// Key holds the values of the primary key the rows of a WITHOUT ROWID
+	// table were matched on, in the order of the key, and is nil for other
+	// tables.
+	Key Record
+	// Old and New are the row's column values, as returned by
+	// TableInfo.ColumnValues, in the first and second database respectively.
+	// Old is nil for added rows and New is nil for removed rows.
+	Old, New Record
+}
+
+// DiffSchemas compares the tables and indexes of two schemas, returning the
+// changes needed to turn a into b, ordered by type then name. Internal
+// sqlite_ objects are ignored.
+func DiffSchemas(a, b *Schema) []SchemaChange {
+	var changes []SchemaChange
+	add := func(typ, name, oldSQL, newSQL string, inA, inB bool) {
+		if strings.HasPrefix(name, "sqlite_") {
+			return
+		}
+		switch {
+		case !inA:
+			changes = append(changes, SchemaChange{Type: typ, Name: name, Kind: Added, NewSQL: newSQL})
+		case !inB:
+			changes = append(changes, SchemaChange{Type: typ, Name: name, Kind: Removed, OldSQL: oldSQL})
+		case oldSQL != newSQL:
+			changes = append(changes, SchemaChange{Type: typ, Name: name, Kind: Modified, OldSQL: oldSQL, NewSQL: newSQL})
+		}
+	}
+	for _, name := range unionKeys(a.Tables, b.Tables) {
+		ta, inA := a.Tables[name]
+		tb, inB := b.Tables[name]
+		add("table", name, ta.SQL, tb.SQL, inA, inB)
+	}
+	for _, name := range unionKeys(a.Indexes, b.Indexes) {
+		ia, inA := a.Indexes[name]
+		ib, inB := b.Indexes[name]
+		add("index", name, ia.SQL, ib.SQL, inA, inB)
+	}
+	return changes
+}
+
+// unionKeys returns the sorted union of the keys of two maps.
+func unionKeys[V any](a, b map[string]V) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range []map[string]V{a, b} {
+		for k := range m {
+			if !seen[k] {
+				seen[k] = true
+				keys = append(keys, k)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// DiffRows compares the rows of a table in two databases, matching them on
+// rowid, or on primary key for a WITHOUT ROWID table, and yields a RowChange
+// for every row that was added, removed or modified going from a to b. Both
+// tables are scanned once, in the order of their B-Tree.
+func DiffRows(a *Database, tableA TableInfo, b *Database, tableB TableInfo) iter.Seq2[RowChange, error] {
+	return func(yield func(RowChange, error) bool) {
+		m, err := newRowMatcher(tableA, tableB)
+		if err != nil {
+			yield(RowChange{}, err)
+			return
+		}
+		nextA, stopA := iter.Pull2(iter.Seq2[Record, error](a.TableScan(tableA)))
+		defer stopA()
+		nextB, stopB := iter.Pull2(iter.Seq2[Record, error](b.TableScan(tableB)))
+		defer stopB()
+
+		// pull returns the next record of a scan, or nil at the end.
+		pull := func(next func() (Record, error, bool)) (Record, error) {
+			record, err, ok := next()
+			if !ok {
+				return nil, nil
+			}
+			return record, err
+		}
+
+		recA, err := pull(nextA)
+		if err != nil {
+			yield(RowChange{}, err)
+			return
+		}
+		recB, err := pull(nextB)
+		if err != nil {
+			yield(RowChange{}, err)
+			return
+		}
+		for recA != nil || recB != nil {
+			var change RowChange
+			var advanceA, advanceB bool
+			c := 0
+			if recA != nil && recB != nil {
+				c = m.compare(recA, recB)
+			}
+			switch {
+			case recB == nil || (recA != nil && c < 0):
+				change = RowChange{Kind: Removed, RowID: tableA.rowIDOf(recA), Key: m.keyA(recA), Old: tableA.ColumnValues(recA)}
+				advanceA = true
+			case recA == nil || c > 0:
+				change = RowChange{Kind: Added, RowID: tableB.rowIDOf(recB), Key: m.keyB(recB), New: tableB.ColumnValues(recB)}
+				advanceB = true
+			default:
+				oldValues, newValues := tableA.ColumnValues(recA), tableB.ColumnValues(recB)
+				if !recordsIdentical(oldValues, newValues) {
+					change = RowChange{Kind: Modified, RowID: tableA.rowIDOf(recA), Key: m.keyA(recA), Old: oldValues, New: newValues}
+				}
+				advanceA, advanceB = true, true
+			}
+			if change.Old != nil || change.New != nil {
+				change.Table = tableB.Name
+				if change.Kind == Removed {
+					change.Table = tableA.Name
+				}
+				if !yield(change, nil) {
+					return
+				}
+			}
+			if advanceA {
+				if recA, err = pull(nextA); err != nil {
+					yield(RowChange{}, err)
+					return
+				}
+			}
+			if advanceB {
+				if recB, err = pull(nextB); err != nil {
+					yield(RowChange{}, err)
+					return
+				}
+			}
+		}
+	}
+}
+
+// rowIDOf returns the rowid of a record of the table, or 0 for a WITHOUT
+// ROWID table.
+func (t TableInfo) rowIDOf(record Record) int64 {
+	if t.WithoutRowID {
+		return 0
+	}
+	return t.RowID(record)
+}
+
+// rowMatcher matches the rows of two versions of a table for DiffRows: on
+// rowid, or on the primary key of WITHOUT ROWID tables, which are scanned in
+// the order of their key.
+type rowMatcher struct {
+	tableA, tableB TableInfo
+	// columnsA and columnsB hold the positions of the columns of the primary
+	// key in the rows of each table, and are nil to match on rowid.
+	columnsA, columnsB []int
+	desc               []bool
+}
+
+// newRowMatcher returns the rowMatcher of two versions of a table.
+func newRowMatcher(tableA, tableB TableInfo) (*rowMatcher, error) {
+	m := &rowMatcher{tableA: tableA, tableB: tableB}
+	if tableA.WithoutRowID != tableB.WithoutRowID {
+		return nil, fmt.Errorf("table %s is WITHOUT ROWID in only one of the databases", tableB.Name)
+	}
+	if !tableB.WithoutRowID {
+		return m, nil
+	}
+	for _, col := range tableB.PrimaryKey {
+		a, b := tableA.lookupColumn(col.Name), tableB.lookupColumn(col.Name)
+		if a < 0 || b < 0 {
+			return nil, fmt.Errorf("no such column: %s", col.Name)
+		}
+		if !binaryCollation(tableB.Columns[b].Collation) {
+			return nil, unsupported("diffing WITHOUT ROWID tables whose primary key has a collating sequence other than BINARY")
+		}
+		m.columnsA, m.columnsB = append(m.columnsA, a), append(m.columnsB, b)
+		m.desc = append(m.desc, col.Desc)
+	}
+	return m, nil
+}
+
+// keyA and keyB return the primary key of a row of each table, or nil if
+// rows are matched on rowid.
+func (m *rowMatcher) keyA(record Record) Record { return keyValues(record, m.columnsA) }
+func (m *rowMatcher) keyB(record Record) Record { return keyValues(record, m.columnsB) }
+
+// keyValues returns the values of a row of a WITHOUT ROWID table at the
+// given positions, or nil if there are none.
+func keyValues(record Record, columns []int) Record {
+	if columns == nil {
+		return nil
+	}
+	key := make(Record, len(columns))
+	for i, column := range columns {
+		key[i] = record[column]
+	}
+	return key
+}
+
+// compare compares a row of the first table with one of the second, in the
+// order the tables are scanned.
+func (m *rowMatcher) compare(recA, recB Record) int {
+	if m.columnsB == nil {
+		return cmp.Compare(m.tableA.RowID(recA), m.tableB.RowID(recB))
+	}
+	return compareKeys(m.keyA(recA), m.keyB(recB), m.desc)
+}
+
+// recordsIdentical reports whether two records hold the same values with the
+// same storage classes. Unlike CompareRecords, it distinguishes 1 from 1.0.
+func recordsIdentical(a, b Record) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		_, aIsInt := a[i].(int64)
+		_, bIsInt := b[i].(int64)
+		if aIsInt != bIsInt || compareValues(a[i], b[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteDiffSQL writes to w a script of SQL statements that transforms the
+// contents of database a into those of database b, in the manner of the
+// sqldiff tool. Tables whose definition changed are dropped and recreated
+// with all the rows of b; other tables are updated row by row, matching rows
+// on rowid, or on primary key for WITHOUT ROWID tables.
+func WriteDiffSQL(w io.Writer, a, b *Database) error {
+	return writeDiffSQL(w, a, b, "")
+}
+
+// WriteTableDiffSQL is like WriteDiffSQL but only transforms the named table
+// and its indexes.
+func WriteTableDiffSQL(w io.Writer, a, b *Database, table string) error {
+	return writeDiffSQL(w, a, b, table)
+}
+
+// writeDiffSQL implements WriteDiffSQL, restricted to the named table and
+// its indexes unless only is empty.
+func writeDiffSQL(w io.Writer, a, b *Database, only string) error {
+	schemaA, err := a.GetSchema()
+	if err != nil {
+		return err
+	}
+	schemaB, err := b.GetSchema()
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	recreated := make(map[string]bool)
+	dropped := make(map[string]bool)
+	indexChanges := make(map[string]SchemaChange)
+	changes := DiffSchemas(schemaA, schemaB)
+	if only != "" {
+		changes = slices.DeleteFunc(changes, func(change SchemaChange) bool {
+			if change.Type == "index" {
+				return schemaA.Indexes[change.Name].TableName != only && schemaB.Indexes[change.Name].TableName != only
+			}
+			return change.Name != only
+		})
+	}
+	for _, change := range changes {
+		if change.Type == "index" {
+			indexChanges[change.Name] = change
+			continue
+		}
+		if change.Kind != Added {
+			fmt.Fprintf(bw, "DROP TABLE %s;\n", quoteIdentifier(change.Name))
+			dropped[change.Name] = true
+		}
+		if change.Kind != Removed {
+			fmt.Fprintf(bw, "%s;\n", change.NewSQL)
+			recreated[change.Name] = true
+		}
+	}
+
+	for _, table := range sortedTables(schemaB) {
+		if strings.HasPrefix(table.Name, "sqlite_") || (only != "" && table.Name != only) {
+			continue
+		}
+		if recreated[table.Name] {
+			for record, err := range b.TableScan(table) {
+				if err != nil {
+					return err
+				}
+				writeRowInsert(bw, table, table.rowIDOf(record), table.ColumnValues(record))
+			}
+			continue
+		}
+		for change, err := range DiffRows(a, schemaA.Tables[table.Name], b, table) {
+			if err != nil {
+				return err
+			}
+			switch change.Kind {
+			case Removed:
+				fmt.Fprintf(bw, "DELETE FROM %s", quoteIdentifier(table.Name))
+				writeRowWhere(bw, table, change)
+			case Added:
+				writeRowInsert(bw, table, change.RowID, change.New)
+			case Modified:
+				fmt.Fprintf(bw, "UPDATE %s SET ", quoteIdentifier(table.Name))
+				first := true
+				for i, value := range change.New {
+					if i < len(change.Old) && recordsIdentical(Record{change.Old[i]}, Record{value}) {
+						continue
+					}
+					if !first {
+						bw.WriteString(", ")
+					}
+					first = false
+					fmt.Fprintf(bw, "%s=%s", quoteIdentifier(table.Columns[i].Name), quoteLiteral(value))
+				}
+				writeRowWhere(bw, table, change)
+			}
+		}
+	}
+
+	// Dropping a table drops its indexes, so the indexes of recreated tables
+	// are all created afresh and only the others are diffed.
+	for _, index := range sortedIndexes(schemaB) {
+		if recreated[index.TableName] && index.SQL != "" {
+			if _, changed := indexChanges[index.Name]; !changed {
+				fmt.Fprintf(bw, "%s;\n", index.SQL)
+			}
+		}
+	}
+	for _, change := range changes {
+		if change.Type != "index" {
+			continue
+		}
+		tableA := schemaA.Indexes[change.Name].TableName
+		if change.Kind != Added && !dropped[tableA] {
+			fmt.Fprintf(bw, "DROP INDEX %s;\n", quoteIdentifier(change.Name))
+		}
+		if change.Kind != Removed && change.NewSQL != "" {
+			fmt.Fprintf(bw, "%s;\n", change.NewSQL)
+		}
+	}
+	return bw.Flush()
+}
+
+// writeRowWhere writes the WHERE clause selecting the row of a change, on
+// its rowid or, for a WITHOUT ROWID table, its primary key, and ends the
+// statement.
+func writeRowWhere(w *bufio.Writer, table TableInfo, change RowChange) {
+	if !table.WithoutRowID {
+		fmt.Fprintf(w, " WHERE rowid=%d;\n", change.RowID)
+		return
+	}
+	for i, col := range table.PrimaryKey {
+		if i == 0 {
+			w.WriteString(" WHERE ")
+		} else {
+			w.WriteString(" AND ")
+		}
+		fmt.Fprintf(w, "%s=%s", quoteIdentifier(col.Name), quoteLiteral(change.Key[i]))
+	}
+	w.WriteString(";\n")
+}
+
+// writeRowInsert writes an INSERT statement that preserves the row's rowid,
+// unless the table is a WITHOUT ROWID table. Generated and hidden columns
+// are left out, since they cannot be inserted.
+func writeRowInsert(w *bufio.Writer, table TableInfo, rowID int64, values Record) {
+	fmt.Fprintf(w, "INSERT INTO %s(", quoteIdentifier(table.Name))
+	if !table.WithoutRowID {
+		w.WriteString("rowid")
+	}
+	first := table.WithoutRowID
+	for _, col := range table.Columns[:min(len(values), len(table.Columns))] {
+		if col.Hidden != ColumnNormal {
+			continue
+		}
+		if !first {
+			w.WriteString(",")
+		}
+		first = false
+		w.WriteString(quoteIdentifier(col.Name))
+	}
+	w.WriteString(") VALUES(")
+	if !table.WithoutRowID {
+		fmt.Fprintf(w, "%d", rowID)
+	}
+	first = table.WithoutRowID
+	for i, value := range values {
+		if i < len(table.Columns) && table.Columns[i].Hidden != ColumnNormal {
+			continue
+		}
+		if !first {
+			w.WriteString(",")
+		}
+		first = false
+		w.WriteString(quoteLiteral(value))
+	}
+	w.WriteString(");\n")
+}
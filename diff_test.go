@@ -0,0 +1,208 @@
+package golite
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// copyFile copies the file at src to a new file at dst.
+func copyFile(t *testing.T, src, dst string) {
+	t.Helper()
+	data, err := os.ReadFile(src)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", src, err)
+	}
+	if err := os.WriteFile(dst, data, 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", dst, err)
+	}
+}
+
+// runSQL executes SQL statements against the database at dbPath using sqlite3.
+func runSQL(t testing.TB, dbPath, sql string) {
+	t.Helper()
+	cmd := exec.Command("sqlite3", dbPath)
+	cmd.Stdin = bytes.NewBufferString(sql)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("sqlite3 failed: %v\nOutput: %s", err, string(output))
+	}
+}
+
+// dumpFile returns the output of Dump for the database at dbPath.
+func dumpFile(t *testing.T, dbPath string) string {
+	t.Helper()
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	var buf bytes.Buffer
+	if err := db.Dump(&buf); err != nil {
+		t.Fatalf("Dump() failed: %v", err)
+	}
+	return buf.String()
+}
+
+func TestDiff(t *testing.T) {
+	pathA := createTestDB(t, "diff_a.sqlite")
+	pathB := filepath.Join(t.TempDir(), "diff_b.sqlite")
+	copyFile(t, pathA, pathB)
+	runSQL(t, pathB, `
+		DELETE FROM test WHERE id IN (10, 20);
+		UPDATE test SET name = 'renamed' WHERE id = 30;
+		INSERT INTO test(id, name) VALUES (1000, 'new');
+		CREATE TABLE extra(a TEXT, b BLOB);
+		INSERT INTO extra VALUES ('x', X'0102');
+		DROP INDEX idx_name;
+		CREATE INDEX idx_name2 ON test(name, id);
+	`)
+
+	dbA, err := Open(pathA)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer dbA.Close()
+	dbB, err := Open(pathB)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer dbB.Close()
+
+	schemaA, err := dbA.GetSchema()
+	if err != nil {
+		t.Fatalf("GetSchema() failed: %v", err)
+	}
+	schemaB, err := dbB.GetSchema()
+	if err != nil {
+		t.Fatalf("GetSchema() failed: %v", err)
+	}
+
+	t.Run("schema changes", func(t *testing.T) {
+		changes := DiffSchemas(schemaA, schemaB)
+		want := []struct {
+			typ, name string
+			kind      ChangeKind
+		}{
+			{"table", "extra", Added},
+			{"index", "idx_name", Removed},
+			{"index", "idx_name2", Added},
+		}
+		if len(changes) != len(want) {
+			t.Fatalf("expected %d schema changes, got %d: %+v", len(want), len(changes), changes)
+		}
+		for i, w := range want {
+			if changes[i].Type != w.typ || changes[i].Name != w.name || changes[i].Kind != w.kind {
+				t.Errorf("change %d: expected %s %s %s, got %+v", i, w.kind, w.typ, w.name, changes[i])
+			}
+		}
+	})
+
+	t.Run("row changes", func(t *testing.T) {
+		kinds := make(map[int64]ChangeKind)
+		for change, err := range DiffRows(dbA, schemaA.Tables["test"], dbB, schemaB.Tables["test"]) {
+			if err != nil {
+				t.Fatalf("DiffRows() returned an unexpected error: %v", err)
+			}
+			kinds[change.RowID] = change.Kind
+		}
+		want := map[int64]ChangeKind{10: Removed, 20: Removed, 30: Modified, 1000: Added}
+		if len(kinds) != len(want) {
+			t.Fatalf("expected %d row changes, got %v", len(want), kinds)
+		}
+		for rowID, kind := range want {
+			if kinds[rowID] != kind {
+				t.Errorf("row %d: expected %s, got %s", rowID, kind, kinds[rowID])
+			}
+		}
+	})
+
+	t.Run("SQL transforms A into B", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteDiffSQL(&buf, dbA, dbB); err != nil {
+			t.Fatalf("WriteDiffSQL() failed: %v", err)
+		}
+		patchedPath := filepath.Join(t.TempDir(), "patched.sqlite")
+		copyFile(t, pathA, patchedPath)
+		runSQL(t, patchedPath, buf.String())
+
+		if got, want := dumpFile(t, patchedPath), dumpFile(t, pathB); got != want {
+			t.Errorf("patched database differs from target.\nScript:\n%s", buf.String())
+		}
+	})
+
+	t.Run("SQL restricted to one table", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteTableDiffSQL(&buf, dbA, dbB, "extra"); err != nil {
+			t.Fatalf("WriteTableDiffSQL() failed: %v", err)
+		}
+		script := buf.String()
+		if !strings.Contains(script, "CREATE TABLE extra") || strings.Contains(script, "test") {
+			t.Errorf("WriteTableDiffSQL() should only transform table extra:\n%s", script)
+		}
+	})
+}
+
+func TestDiff_WithoutRowID(t *testing.T) {
+	pathA := filepath.Join(t.TempDir(), "a.sqlite")
+	runSQL(t, pathA, `
+		CREATE TABLE kv (k TEXT, n INTEGER, v TEXT, PRIMARY KEY (k, n DESC)) WITHOUT ROWID;
+		INSERT INTO kv VALUES ('a', 1, 'one'), ('a', 2, 'two'), ('b', 1, 'three'), ('c', 1, 'four');
+	`)
+	pathB := filepath.Join(t.TempDir(), "b.sqlite")
+	copyFile(t, pathA, pathB)
+	runSQL(t, pathB, `
+		DELETE FROM kv WHERE k = 'a' AND n = 1;
+		UPDATE kv SET v = 'THREE' WHERE k = 'b';
+		INSERT INTO kv VALUES ('a', 3, 'new'), ('d', 1, 'last');
+	`)
+
+	dbA, err := Open(pathA)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer dbA.Close()
+	dbB, err := Open(pathB)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer dbB.Close()
+	tableA, err := dbA.Table("kv")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+	tableB, err := dbB.Table("kv")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+
+	var got []string
+	for change, err := range DiffRows(dbA, tableA, dbB, tableB) {
+		if err != nil {
+			t.Fatalf("DiffRows() returned an unexpected error: %v", err)
+		}
+		got = append(got, fmt.Sprintf("%s %v", change.Kind, change.Key))
+	}
+	want := []string{"added [a 3]", "removed [a 1]", "modified [b 1]", "added [d 1]"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DiffRows() = %q, want %q", got, want)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteDiffSQL(&buf, dbA, dbB); err != nil {
+		t.Fatalf("WriteDiffSQL() failed: %v", err)
+	}
+	if strings.Contains(buf.String(), "rowid") {
+		t.Errorf("WriteDiffSQL() refers to the rowid of a WITHOUT ROWID table:\n%s", buf.String())
+	}
+	patchedPath := filepath.Join(t.TempDir(), "patched.sqlite")
+	copyFile(t, pathA, patchedPath)
+	runSQL(t, patchedPath, buf.String())
+	if got, want := dumpFile(t, patchedPath), dumpFile(t, pathB); got != want {
+		t.Errorf("patched database differs from target.\nScript:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,633 @@
+package golite
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Statement is an INSERT, UPDATE or DELETE statement parsed by
+// ParseStatement: an *InsertStatement, an *UpdateStatement or a
+// *DeleteStatement. Its values are constants, those of the parameters of the
+// statement being bound to the arguments of ParseStatement.
+type Statement interface {
+	// TableName returns the name of the table the statement modifies.
+	TableName() string
+}
+
+// InsertStatement is an INSERT statement, or a REPLACE statement, which is
+// an INSERT OR REPLACE.
+type InsertStatement struct {
+	Table string
+	// Or is the conflict resolution algorithm of an INSERT OR statement, in
+	// upper case, or "" if there is none.
+	Or string
+	// Columns lists the columns the values are for, or is nil if the
+	// statement does not name them, in which case the values are for all the
+	// columns of the table in the order they are declared. It is empty for
+	// INSERT ... DEFAULT VALUES.
+	Columns []string
+	// Rows holds the rows of the VALUES clause. INSERT ... DEFAULT VALUES has
+	// a single empty row, and INSERT ... SELECT none.
+	Rows []Record
+	// Query is the text of the SELECT statement of INSERT ... SELECT, which
+	// is not parsed, or "" for the other forms.
+	Query string
+}
+
+// Assignment is a term of the SET clause of an UPDATE statement.
+type Assignment struct {
+	Column string
+	Value  any
+}
+
+// UpdateStatement is an UPDATE statement.
+type UpdateStatement struct {
+	Table string
+	// Or is the conflict resolution algorithm of an UPDATE OR statement, in
+	// upper case, or "" if there is none.
+	Or  string
+	Set []Assignment
+	// Where holds the terms of the WHERE clause, which are all satisfied by
+	// the rows to update. It is empty if the statement has none.
+	Where []Constraint
+}
+
+// DeleteStatement is a DELETE statement.
+type DeleteStatement struct {
+	Table string
+	// Where holds the terms of the WHERE clause, which are all satisfied by
+	// the rows to delete. It is empty if the statement has none.
+	Where []Constraint
+}
+
+func (s *InsertStatement) TableName() string { return s.Table }
+func (s *UpdateStatement) TableName() string { return s.Table }
+func (s *DeleteStatement) TableName() string { return s.Table }
+
+// ParseStatement parses an INSERT, UPDATE or DELETE statement. Values must
+// be literals, optionally signed numbers, or parameters, and a WHERE clause
+// must be made of comparisons of a column with a value joined by AND, so that
+// it can be expressed as Constraints. Other expressions, RETURNING clauses,
+// upserts and common table expressions are reported as
+// *UnsupportedFeatureError.
+//
+// Parameters are numbered as SQLite numbers them: "?" is one more than the
+// largest number so far, "?NNN" is NNN, and a named parameter, ":name",
+// "@name" or "$name", is one more than the largest number so far when it
+// first appears. Parameter N is bound to args[N-1], which is an int64,
+// float64, string, []byte, NullType or nil for NULL; ints and bools are
+// converted to int64. Parameters without an argument are NULL, as with
+// sqlite3_bind.
+func ParseStatement(sql string, args ...any) (Statement, error) {
+	p := &dmlParser{sql: sql, tokens: tokenizeSQL(sql), args: args, named: map[string]int{}}
+	if n := len(p.tokens); n > 0 && p.tokens[n-1].text == ";" {
+		p.tokens = p.tokens[:n-1]
+	}
+	var stmt Statement
+	var err error
+	switch {
+	case p.peekIs("INSERT"), p.peekIs("REPLACE"):
+		stmt, err = p.parseInsert()
+	case p.peekIs("UPDATE"):
+		stmt, err = p.parseUpdate()
+	case p.peekIs("DELETE"):
+		stmt, err = p.parseDelete()
+	case p.peekIs("WITH"):
+		return nil, unsupported("common table expressions")
+	default:
+		return nil, fmt.Errorf("expected an INSERT, UPDATE or DELETE statement")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.peekIs("RETURNING") {
+		return nil, unsupported("RETURNING clauses")
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q at the end of the statement", p.tokens[p.pos].text)
+	}
+	return stmt, nil
+}
+
+// dmlParser holds the state of ParseStatement.
+type dmlParser struct {
+	sql    string
+	tokens []sqlToken
+	pos    int // The index of the next token.
+	args   []any
+	// lastParam is the largest parameter number so far, and named maps the
+	// named parameters to their numbers.
+	lastParam int
+	named     map[string]int
+}
+
+// peek returns the next token, or a token with no text at the end of the
+// statement.
+func (p *dmlParser) peek() sqlToken {
+	if p.pos == len(p.tokens) {
+		return sqlToken{start: len(p.sql), end: len(p.sql)}
+	}
+	return p.tokens[p.pos]
+}
+
+// peekIs reports whether the next token is the given keyword.
+func (p *dmlParser) peekIs(keyword string) bool {
+	return p.peek().is(keyword)
+}
+
+// next consumes the next token and returns it.
+func (p *dmlParser) next() sqlToken {
+	tok := p.peek()
+	if p.pos < len(p.tokens) {
+		p.pos++
+	}
+	return tok
+}
+
+// accept consumes the next token if it is the given keyword or punctuation.
+func (p *dmlParser) accept(keyword string) bool {
+	if p.peekIs(keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// expect consumes the next token, which must be the given keyword or
+// punctuation.
+func (p *dmlParser) expect(keyword string) error {
+	if !p.accept(keyword) {
+		return p.errorf("expected %s", keyword)
+	}
+	return nil
+}
+
+// errorf returns an error about the next token.
+func (p *dmlParser) errorf(format string, args ...any) error {
+	tok := p.peek()
+	if tok.text == "" {
+		return fmt.Errorf(format+" at the end of the statement", args...)
+	}
+	return fmt.Errorf(format+" but found %q", append(args, tok.text)...)
+}
+
+// name consumes an identifier.
+func (p *dmlParser) name() (string, error) {
+	tok := p.peek()
+	if tok.text == "" || tok.text[0] == '\'' || !isWordStart(tok.text[0]) && !strings.ContainsRune("\"`[", rune(tok.text[0])) {
+		return "", p.errorf("expected a name")
+	}
+	p.pos++
+	return tok.unquoted(), nil
+}
+
+// isWordStart reports whether a token starting with c is a word, as opposed
+// to a literal, a parameter or punctuation.
+func isWordStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+}
+
+// tableName consumes the name of a table, possibly qualified by the name of
+// its schema, which is dropped.
+func (p *dmlParser) tableName() (string, error) {
+	start := p.pos
+	name, err := p.name()
+	if err != nil {
+		return "", err
+	}
+	// The tokenizer keeps dots in words, so schema.table may be one token,
+	// and "schema".table two.
+	if i := strings.LastIndexByte(name, '.'); i != -1 && isWordStart(p.tokens[start].text[0]) {
+		return name[i+1:], nil
+	}
+	if next := p.peek(); next.start == p.tokens[start].end && strings.HasPrefix(next.text, ".") {
+		p.pos++
+		if next.text == "." {
+			return p.name()
+		}
+		return next.text[1:], nil
+	}
+	return name, nil
+}
+
+// conflictClause consumes the OR clause of INSERT OR and UPDATE OR.
+func (p *dmlParser) conflictClause() (string, error) {
+	if !p.accept("OR") {
+		return "", nil
+	}
+	if !p.peek().isAny("ROLLBACK", "ABORT", "REPLACE", "FAIL", "IGNORE") {
+		return "", p.errorf("expected a conflict resolution algorithm")
+	}
+	return strings.ToUpper(p.next().text), nil
+}
+
+func (p *dmlParser) parseInsert() (*InsertStatement, error) {
+	stmt := &InsertStatement{}
+	var err error
+	if p.accept("REPLACE") {
+		stmt.Or = "REPLACE"
+	} else {
+		p.next()
+		if stmt.Or, err = p.conflictClause(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect("INTO"); err != nil {
+		return nil, err
+	}
+	if stmt.Table, err = p.tableName(); err != nil {
+		return nil, err
+	}
+	if p.accept("AS") {
+		if _, err := p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if p.accept("(") {
+		stmt.Columns = []string{}
+		for {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			stmt.Columns = append(stmt.Columns, name)
+			if !p.accept(",") {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case p.accept("DEFAULT"):
+		if err := p.expect("VALUES"); err != nil {
+			return nil, err
+		}
+		if stmt.Columns != nil {
+			return nil, fmt.Errorf("DEFAULT VALUES cannot be used with a column list")
+		}
+		stmt.Columns, stmt.Rows = []string{}, []Record{{}}
+		return stmt, nil
+	case p.peekIs("SELECT"), p.peekIs("WITH"), p.peekIs("VALUES") && p.selectFollows():
+		// The query extends to the end of the statement, or to an upsert
+		// clause, which is not supported.
+		start := p.pos
+		for p.pos < len(p.tokens) {
+			if tok := p.peek(); tok.is("ON") && p.pos+1 < len(p.tokens) && p.tokens[p.pos+1].is("CONFLICT") {
+				return nil, unsupported("upserts")
+			}
+			if p.peekIs("RETURNING") {
+				break
+			}
+			p.pos++
+		}
+		stmt.Query = exprText(p.sql, p.tokens[start:p.pos])
+		return stmt, nil
+	}
+
+	if err := p.expect("VALUES"); err != nil {
+		return nil, err
+	}
+	for {
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		var row Record
+		for {
+			value, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			row = append(row, value)
+			if !p.accept(",") {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		if len(stmt.Rows) > 0 && len(row) != len(stmt.Rows[0]) {
+			return nil, fmt.Errorf("all VALUES must have the same number of terms")
+		}
+		if stmt.Columns != nil && len(row) != len(stmt.Columns) {
+			return nil, fmt.Errorf("%d values for %d columns", len(row), len(stmt.Columns))
+		}
+		stmt.Rows = append(stmt.Rows, row)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if p.peekIs("ON") {
+		return nil, unsupported("upserts")
+	}
+	return stmt, nil
+}
+
+// selectFollows reports whether the VALUES clause starting at the next token
+// is followed by more of a SELECT statement, such as a UNION, ORDER BY or
+// LIMIT clause, in which case it is the query of INSERT ... SELECT.
+func (p *dmlParser) selectFollows() bool {
+	for i := p.pos + 1; i < len(p.tokens); i++ {
+		tok := p.tokens[i]
+		switch {
+		case tok.text == "(":
+			if i = closingToken(p.tokens, i); i == -1 {
+				return false
+			}
+		case tok.text == ",":
+		default:
+			return tok.isAny("UNION", "INTERSECT", "EXCEPT", "ORDER", "LIMIT")
+		}
+	}
+	return false
+}
+
+func (p *dmlParser) parseUpdate() (*UpdateStatement, error) {
+	p.next()
+	stmt := &UpdateStatement{}
+	var err error
+	if stmt.Or, err = p.conflictClause(); err != nil {
+		return nil, err
+	}
+	if stmt.Table, err = p.tableName(); err != nil {
+		return nil, err
+	}
+	if err := p.expect("SET"); err != nil {
+		return nil, err
+	}
+	for {
+		if p.peek().text == "(" {
+			return nil, unsupported("assignments of column lists")
+		}
+		column, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("="); err != nil {
+			return nil, err
+		}
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Set = append(stmt.Set, Assignment{Column: column, Value: value})
+		if !p.accept(",") {
+			break
+		}
+	}
+	if p.peekIs("FROM") {
+		return nil, unsupported("UPDATE FROM")
+	}
+	if stmt.Where, err = p.where(); err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+func (p *dmlParser) parseDelete() (*DeleteStatement, error) {
+	p.next()
+	if err := p.expect("FROM"); err != nil {
+		return nil, err
+	}
+	stmt := &DeleteStatement{}
+	var err error
+	if stmt.Table, err = p.tableName(); err != nil {
+		return nil, err
+	}
+	if stmt.Where, err = p.where(); err != nil {
+		return nil, err
+	}
+	return stmt, nil
+}
+
+// where consumes a WHERE clause, if there is one, and returns its terms.
+func (p *dmlParser) where() ([]Constraint, error) {
+	if !p.accept("WHERE") {
+		return nil, nil
+	}
+	var constraints []Constraint
+	for {
+		terms, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		constraints = append(constraints, terms...)
+		if !p.accept("AND") {
+			break
+		}
+	}
+	if p.peekIs("OR") {
+		return nil, unsupported("OR in WHERE clauses")
+	}
+	return constraints, nil
+}
+
+// flippedOps maps the comparison operators to those comparing their
+// operands the other way around.
+var flippedOps = map[ConstraintOp]ConstraintOp{OpEq: OpEq, OpLt: OpGt, OpLe: OpGe, OpGt: OpLt, OpGe: OpLe}
+
+// term consumes a term of a WHERE clause: a comparison of a column with a
+// value, either way around, "column IS NULL" or "column BETWEEN low AND
+// high".
+func (p *dmlParser) term() ([]Constraint, error) {
+	start := p.pos
+	column, err := p.name()
+	if err != nil {
+		// The term may be a value compared with a column.
+		p.pos = start
+		value, err := p.value()
+		if err != nil {
+			return nil, p.termError(start, err)
+		}
+		op, ok := p.comparison()
+		if !ok {
+			return nil, p.unsupportedTerm(start)
+		}
+		if column, err = p.name(); err != nil {
+			return nil, p.unsupportedTerm(start)
+		}
+		return []Constraint{{column, flippedOps[op], value}}, nil
+	}
+	switch {
+	case p.accept("IS"):
+		if !p.accept("NULL") {
+			return nil, p.unsupportedTerm(start)
+		}
+		return []Constraint{{Column: column, Op: OpIsNull}}, nil
+	case p.accept("BETWEEN"):
+		low, err := p.value()
+		if err != nil {
+			return nil, p.termError(start, err)
+		}
+		if err := p.expect("AND"); err != nil {
+			return nil, err
+		}
+		high, err := p.value()
+		if err != nil {
+			return nil, p.termError(start, err)
+		}
+		return Between(column, low, high), nil
+	}
+	op, ok := p.comparison()
+	if !ok {
+		return nil, p.unsupportedTerm(start)
+	}
+	value, err := p.value()
+	if err != nil {
+		return nil, p.termError(start, err)
+	}
+	return []Constraint{{column, op, value}}, nil
+}
+
+// comparisonOps maps the comparison operators that a Constraint can express
+// to its operators.
+var comparisonOps = map[string]ConstraintOp{"=": OpEq, "==": OpEq, "<": OpLt, "<=": OpLe, ">": OpGt, ">=": OpGe}
+
+// comparison consumes a comparison operator that a Constraint can express.
+func (p *dmlParser) comparison() (ConstraintOp, bool) {
+	op, ok := comparisonOps[p.peek().text]
+	if ok {
+		p.pos++
+	}
+	return op, ok
+}
+
+// unsupportedTerm returns the error for a term of a WHERE clause starting at
+// the token start that is not a comparison of a column with a value.
+func (p *dmlParser) unsupportedTerm(start int) error {
+	end := p.pos
+	for end < len(p.tokens) && !p.tokens[end].isAny("AND", "OR", "RETURNING") {
+		end++
+	}
+	return unsupported(fmt.Sprintf("WHERE term %s", exprText(p.sql, p.tokens[start:max(end, start+1)])))
+}
+
+// termError returns err, an error met parsing the value of a term of a WHERE
+// clause starting at the token start, or the error of unsupportedTerm if the
+// value is an unsupported expression.
+func (p *dmlParser) termError(start int, err error) error {
+	var unsupportedErr *UnsupportedFeatureError
+	if errors.As(err, &unsupportedErr) {
+		return p.unsupportedTerm(start)
+	}
+	return err
+}
+
+// value consumes a value, which is a literal, optionally signed number, or
+// parameter, and returns it.
+func (p *dmlParser) value() (any, error) {
+	start := p.pos
+	value, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); expressionOps[tok.text] || tok.isAny("COLLATE", "NOT", "IN", "IS", "LIKE", "GLOB", "REGEXP", "MATCH") {
+		return nil, unsupported(fmt.Sprintf("expression %s", exprText(p.sql, p.tokens[start:p.pos+1])))
+	}
+	return value, nil
+}
+
+// expressionOps holds the operators that make a value part of an expression.
+var expressionOps = map[string]bool{"+": true, "-": true, "*": true, "/": true, "%": true, "||": true, "&": true, "|": true, "<<": true, ">>": true, "(": true}
+
+// operand consumes a literal, optionally signed number, or parameter, and
+// returns its value.
+func (p *dmlParser) operand() (any, error) {
+	start := p.pos
+	sign := ""
+	for p.peek().text == "-" || p.peek().text == "+" {
+		sign += p.next().text
+	}
+	tok := p.next()
+	switch {
+	case tok.text == "":
+		return nil, p.errorf("expected a value")
+	case tok.text == "?" || tok.text == ":" || tok.text == "@" || tok.text[0] == '$':
+		value, err := p.parameter(tok)
+		if err != nil {
+			return nil, err
+		}
+		if sign != "" {
+			return nil, unsupported(fmt.Sprintf("expression %s", exprText(p.sql, p.tokens[start:p.pos])))
+		}
+		return value, nil
+	case sign == "" && (tok.text[0] == '\'' || tok.is("NULL") || tok.is("TRUE") || tok.is("FALSE")):
+		return evalDefault(tok.text, time.Time{})
+	case sign == "" && tok.isAny("X") && p.peek().text != "" && p.peek().text[0] == '\'' && p.peek().start == tok.end:
+		return evalDefault(tok.text+p.next().text, time.Time{})
+	}
+	text := tok.text
+	if n := len(p.tokens); (strings.HasSuffix(text, "e") || strings.HasSuffix(text, "E")) && p.pos+1 < n {
+		// The tokenizer splits the signed exponent of a number such as 1e-3.
+		if sign, digits := p.tokens[p.pos], p.tokens[p.pos+1]; sign.start == tok.end && digits.start == sign.end && (sign.text == "-" || sign.text == "+") {
+			text += sign.text + digits.text
+			p.pos += 2
+		}
+	}
+	if value, ok := parseNumberLiteral(sign + text); ok {
+		return value, nil
+	}
+	p.pos = start
+	return nil, unsupported(fmt.Sprintf("expression %s", p.peek().text))
+}
+
+// parameter returns the value bound to the parameter starting with tok.
+func (p *dmlParser) parameter(tok sqlToken) (any, error) {
+	var n int
+	switch {
+	case tok.text == "?":
+		if next := p.peek(); next.start == tok.end && next.text != "" && next.text[0] >= '0' && next.text[0] <= '9' {
+			p.pos++
+			i, err := strconv.Atoi(next.text)
+			if err != nil || i < 1 {
+				return nil, fmt.Errorf("invalid parameter ?%s", next.text)
+			}
+			n = i
+		} else {
+			n = p.lastParam + 1
+		}
+	default:
+		name := tok.text
+		if tok.text[0] != '$' {
+			next := p.peek()
+			if next.start != tok.end || next.text == "" || !isWordStart(next.text[0]) && (next.text[0] < '0' || next.text[0] > '9') {
+				return nil, fmt.Errorf("invalid parameter %s", tok.text)
+			}
+			p.pos++
+			name += next.text
+		}
+		var ok bool
+		if n, ok = p.named[name]; !ok {
+			n = p.lastParam + 1
+			p.named[name] = n
+		}
+	}
+	p.lastParam = max(p.lastParam, n)
+	if n > len(p.args) {
+		return SQLNull, nil
+	}
+	return bindValue(p.args[n-1], n)
+}
+
+// bindValue returns the value of the argument bound to parameter n.
+func bindValue(arg any, n int) (any, error) {
+	switch v := arg.(type) {
+	case nil:
+		return SQLNull, nil
+	case int64, float64, string, []byte, NullType:
+		return v, nil
+	case int:
+		return int64(v), nil
+	case bool:
+		if v {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	}
+	return nil, fmt.Errorf("unsupported type %T for parameter %d", arg, n)
+}
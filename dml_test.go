@@ -0,0 +1,129 @@
+package golite
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseStatement(t *testing.T) {
+	testCases := []struct {
+		name string
+		sql  string
+		args []any
+		want Statement
+	}{
+		{
+			name: "insert values",
+			sql:  "INSERT INTO t (a, \"b c\") VALUES (1, 'it''s'), (-2.5e-1, X'0aff'), (NULL, +0x10);",
+			want: &InsertStatement{
+				Table:   "t",
+				Columns: []string{"a", "b c"},
+				Rows:    []Record{{int64(1), "it's"}, {-0.25, []byte{0x0a, 0xff}}, {SQLNull, int64(16)}},
+			},
+		},
+		{
+			name: "insert or replace with schema",
+			sql:  "insert or replace into main.t values (true, false)",
+			want: &InsertStatement{Table: "t", Or: "REPLACE", Rows: []Record{{int64(1), int64(0)}}},
+		},
+		{
+			name: "replace",
+			sql:  `REPLACE INTO "main"."t" VALUES (1)`,
+			want: &InsertStatement{Table: "t", Or: "REPLACE", Rows: []Record{{int64(1)}}},
+		},
+		{
+			name: "default values",
+			sql:  "INSERT INTO t DEFAULT VALUES",
+			want: &InsertStatement{Table: "t", Columns: []string{}, Rows: []Record{{}}},
+		},
+		{
+			name: "insert select",
+			sql:  "INSERT INTO t (a) SELECT x FROM u WHERE y > 1 -- copy\n;",
+			want: &InsertStatement{Table: "t", Columns: []string{"a"}, Query: "SELECT x FROM u WHERE y > 1"},
+		},
+		{
+			name: "insert values query",
+			sql:  "INSERT INTO t VALUES (1), (2) UNION SELECT 3",
+			want: &InsertStatement{Table: "t", Query: "VALUES (1), (2) UNION SELECT 3"},
+		},
+		{
+			name: "parameters",
+			sql:  "INSERT INTO t VALUES (?, :a, ?5, @b, :a, ?, $c)",
+			args: []any{1, "x", nil, true, []byte("y"), 2.5},
+			want: &InsertStatement{Table: "t", Rows: []Record{{int64(1), "x", []byte("y"), 2.5, "x", SQLNull, SQLNull}}},
+		},
+		{
+			name: "update",
+			sql:  "UPDATE OR IGNORE t SET a = ?, b = 'x' WHERE id = 3 AND 5 < c AND d IS NULL AND e BETWEEN ? AND 9",
+			args: []any{int64(7), int64(4)},
+			want: &UpdateStatement{
+				Table: "t",
+				Or:    "IGNORE",
+				Set:   []Assignment{{"a", int64(7)}, {"b", "x"}},
+				Where: []Constraint{{"id", OpEq, int64(3)}, {"c", OpGt, int64(5)}, {"d", OpIsNull, nil}, {"e", OpGe, int64(4)}, {"e", OpLe, int64(9)}},
+			},
+		},
+		{
+			name: "delete all",
+			sql:  "DELETE FROM t",
+			want: &DeleteStatement{Table: "t"},
+		},
+		{
+			name: "delete where",
+			sql:  "DELETE FROM [t] WHERE a >= :min",
+			args: []any{"m"},
+			want: &DeleteStatement{Table: "t", Where: []Constraint{{"a", OpGe, "m"}}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := ParseStatement(tc.sql, tc.args...)
+			if err != nil {
+				t.Fatalf("ParseStatement() failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("ParseStatement() = %#v, want %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseStatementErrors(t *testing.T) {
+	testCases := []struct {
+		name            string
+		sql             string
+		args            []any
+		wantUnsupported bool
+	}{
+		{name: "select", sql: "SELECT 1"},
+		{name: "missing values", sql: "INSERT INTO t (a)"},
+		{name: "column count", sql: "INSERT INTO t (a, b) VALUES (1)"},
+		{name: "row lengths", sql: "INSERT INTO t VALUES (1, 2), (3)"},
+		{name: "trailing tokens", sql: "DELETE FROM t WHERE a = 1 b"},
+		{name: "bad conflict", sql: "INSERT OR"},
+		{name: "bad argument", sql: "DELETE FROM t WHERE a = ?", args: []any{struct{}{}}},
+		{name: "expression", sql: "INSERT INTO t VALUES (1 + 2)", wantUnsupported: true},
+		{name: "function", sql: "UPDATE t SET a = abs(b)", wantUnsupported: true},
+		{name: "or", sql: "DELETE FROM t WHERE a = 1 OR b = 2", wantUnsupported: true},
+		{name: "not equal", sql: "DELETE FROM t WHERE a <> 1", wantUnsupported: true},
+		{name: "column comparison", sql: "DELETE FROM t WHERE a = b", wantUnsupported: true},
+		{name: "returning", sql: "DELETE FROM t RETURNING *", wantUnsupported: true},
+		{name: "upsert", sql: "INSERT INTO t VALUES (1) ON CONFLICT DO NOTHING", wantUnsupported: true},
+		{name: "with", sql: "WITH x AS (SELECT 1) DELETE FROM t", wantUnsupported: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseStatement(tc.sql, tc.args...)
+			if err == nil {
+				t.Fatal("ParseStatement() succeeded, want an error")
+			}
+			var unsupportedErr *UnsupportedFeatureError
+			if got := errors.As(err, &unsupportedErr); got != tc.wantUnsupported {
+				t.Errorf("ParseStatement() error = %v, unsupported = %v, want %v", err, got, tc.wantUnsupported)
+			}
+		})
+	}
+}
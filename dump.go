@@ -0,0 +1,212 @@
+package golite
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Dump writes the contents of the database to w as a script of SQL statements,
+// equivalent to the sqlite3 shell's .dump command. Feeding the output to
+// sqlite3 recreates the tables, their rows, their indexes, and the views and
+// triggers, and restores the sequence numbers of AUTOINCREMENT tables.
+func (db *Database) Dump(w io.Writer) error {
+	schema, err := db.GetSchema()
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("PRAGMA foreign_keys=OFF;\n")
+	bw.WriteString("BEGIN TRANSACTION;\n")
+
+	for _, table := range sortedTables(schema) {
+		if strings.HasPrefix(table.Name, "sqlite_") {
+			continue // Internal tables are recreated by SQLite itself.
+		}
+		fmt.Fprintf(bw, "%s;\n", table.SQL)
+		for record, err := range db.TableScan(table) {
+			if err != nil {
+				return fmt.Errorf("failed to dump table %q: %w", table.Name, err)
+			}
+			writeRowValues(bw, table, record)
+		}
+	}
+
+	// Inserting rows into AUTOINCREMENT tables fills sqlite_sequence, so its
+	// contents replace the rows it was given, as in the sqlite3 shell.
+	if sequence, ok := schema.Tables["sqlite_sequence"]; ok {
+		bw.WriteString("DELETE FROM sqlite_sequence;\n")
+		for record, err := range db.TableScan(sequence) {
+			if err != nil {
+				return fmt.Errorf("failed to dump table %q: %w", sequence.Name, err)
+			}
+			writeInsert(bw, sequence.Name, nil, sequence.ColumnValues(record))
+		}
+	}
+
+	// Indexes, views and triggers come after the data, so that triggers do not
+	// fire while the rows are inserted, and in the order of the schema table,
+	// which creates the objects they refer to before them.
+	schemaTable := SchemaTable()
+	for record, err := range db.TableScan(schemaTable) {
+		if err != nil {
+			return fmt.Errorf("failed to scan schema table: %w", err)
+		}
+		values := schemaTable.ColumnValues(record)
+		if len(values) < 5 {
+			continue
+		}
+		sql, ok := values[4].(string)
+		if !ok {
+			continue // Implicit indexes are created along with their table.
+		}
+		switch values[0] {
+		case "index", "view", "trigger":
+			fmt.Fprintf(bw, "%s;\n", sql)
+		}
+	}
+
+	bw.WriteString("COMMIT;\n")
+	return bw.Flush()
+}
+
+// writeRowValues writes an INSERT statement adding a row of table, from a
+// record yielded by TableScan. Generated and hidden columns cannot be
+// inserted, so like the sqlite3 shell it leaves them out and lists the
+// columns it inserts instead.
+func writeRowValues(w *bufio.Writer, table TableInfo, record Record) {
+	var columns []string
+	values := make(Record, 0, len(table.Columns))
+	for i, col := range table.Columns {
+		if col.Hidden != ColumnNormal {
+			continue
+		}
+		columns = append(columns, col.Name)
+		values = append(values, table.columnValue(record, i))
+	}
+	if len(columns) == len(table.Columns) {
+		columns = nil
+	}
+	writeInsert(w, table.Name, columns, values)
+}
+
+// writeInsert writes an INSERT statement adding values to the named table,
+// into the given columns, or into all of them in order if columns is nil.
+func writeInsert(w *bufio.Writer, tableName string, columns []string, values Record) {
+	w.WriteString("INSERT INTO ")
+	w.WriteString(quoteIdentifier(tableName))
+	if columns != nil {
+		w.WriteByte('(')
+		for i, column := range columns {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(quoteIdentifier(column))
+		}
+		w.WriteByte(')')
+	}
+	w.WriteString(" VALUES(")
+	for i, value := range values {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		w.WriteString(quoteLiteral(value))
+	}
+	w.WriteString(");\n")
+}
+
+// sortedTables returns the tables in the schema ordered by name.
+func sortedTables(schema *Schema) []TableInfo {
+	tables := make([]TableInfo, 0, len(schema.Tables))
+	for _, table := range schema.Tables {
+		tables = append(tables, table)
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Name < tables[j].Name })
+	return tables
+}
+
+// sortedIndexes returns the indexes in the schema ordered by name.
+func sortedIndexes(schema *Schema) []IndexInfo {
+	indexes := make([]IndexInfo, 0, len(schema.Indexes))
+	for _, index := range schema.Indexes {
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i].Name < indexes[j].Name })
+	return indexes
+}
+
+// sqlKeywords are the keywords of SQLite, which must be quoted to be used as
+// identifiers, from https://www.sqlite.org/lang_keywords.html.
+var sqlKeywords = map[string]bool{}
+
+func init() {
+	for _, keyword := range strings.Fields(`
+		ABORT ACTION ADD AFTER ALL ALTER ALWAYS ANALYZE AND AS ASC ATTACH
+		AUTOINCREMENT BEFORE BEGIN BETWEEN BY CASCADE CASE CAST CHECK COLLATE
+		COLUMN COMMIT CONFLICT CONSTRAINT CREATE CROSS CURRENT CURRENT_DATE
+		CURRENT_TIME CURRENT_TIMESTAMP DATABASE DEFAULT DEFERRABLE DEFERRED
+		DELETE DESC DETACH DISTINCT DO DROP EACH ELSE END ESCAPE EXCEPT EXCLUDE
+		EXCLUSIVE EXISTS EXPLAIN FAIL FILTER FIRST FOLLOWING FOR FOREIGN FROM
+		FULL GENERATED GLOB GROUP GROUPS HAVING IF IGNORE IMMEDIATE IN INDEX
+		INDEXED INITIALLY INNER INSERT INSTEAD INTERSECT INTO IS ISNULL JOIN KEY
+		LAST LEFT LIKE LIMIT MATCH MATERIALIZED NATURAL NO NOT NOTHING NOTNULL
+		NULL NULLS OF OFFSET ON OR ORDER OTHERS OUTER OVER PARTITION PLAN PRAGMA
+		PRECEDING PRIMARY QUERY RAISE RANGE RECURSIVE REFERENCES REGEXP REINDEX
+		RELEASE RENAME REPLACE RESTRICT RETURNING RIGHT ROLLBACK ROW ROWS
+		SAVEPOINT SELECT SET TABLE TEMP TEMPORARY THEN TIES TO TRANSACTION
+		TRIGGER UNBOUNDED UNION UNIQUE UPDATE USING VACUUM VALUES VIEW VIRTUAL
+		WHEN WHERE WINDOW WITH WITHOUT`) {
+		sqlKeywords[keyword] = true
+	}
+}
+
+// quoteIdentifier returns name unchanged if it is a plain identifier, and
+// otherwise, or if it is an SQL keyword, wraps it in double quotes, doubling
+// any embedded quotes.
+func quoteIdentifier(name string) string {
+	plain := name != "" && !sqlKeywords[strings.ToUpper(name)]
+	for i, c := range name {
+		isLetter := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		isDigit := c >= '0' && c <= '9'
+		if !isLetter && (!isDigit || i == 0) {
+			plain = false
+			break
+		}
+	}
+	if plain {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// quoteLiteral renders a record value as an SQL literal: NULL, a number, a
+// single-quoted string or an X'..' blob.
+func quoteLiteral(v any) string {
+	switch v := v.(type) {
+	case NullType, nil:
+		return "NULL"
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			return "NULL"
+		case math.IsInf(v, 1):
+			return "1e999"
+		case math.IsInf(v, -1):
+			return "-1e999"
+		}
+		return formatScalar(v)
+	case string:
+		return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+	case []byte:
+		return "X'" + strings.ToUpper(hex.EncodeToString(v)) + "'"
+	}
+	return fmt.Sprintf("'%v'", v)
+}
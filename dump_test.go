@@ -0,0 +1,157 @@
+package golite
+
+import (
+	"bytes"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDatabase_Dump(t *testing.T) {
+	dbPath := createTestDB(t, "dump_test.sqlite")
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+
+	var buf bytes.Buffer
+	if err := db.Dump(&buf); err != nil {
+		t.Fatalf("Dump() failed: %v", err)
+	}
+	dump := buf.String()
+
+	for _, want := range []string{
+		"CREATE TABLE test(id INTEGER PRIMARY KEY, name TEXT);\n",
+		"INSERT INTO test VALUES(1,'name1');\n",
+		"INSERT INTO test VALUES(500,'name500');\n",
+		"CREATE INDEX idx_name ON test(name);\n",
+	} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("expected dump to contain %q", want)
+		}
+	}
+
+	// The dump must be loadable by the reference implementation.
+	restoredPath := filepath.Join(t.TempDir(), "restored.sqlite")
+	cmd := exec.Command("sqlite3", restoredPath)
+	cmd.Stdin = strings.NewReader(dump)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("sqlite3 failed to load dump: %v\nOutput: %s", err, string(output))
+	}
+	output, err := exec.Command("sqlite3", restoredPath, "SELECT count(*), max(name) FROM test").CombinedOutput()
+	if err != nil {
+		t.Fatalf("failed to query restored database: %v\nOutput: %s", err, string(output))
+	}
+	if got := strings.TrimSpace(string(output)); got != "500|name99" {
+		t.Errorf("expected restored table to report \"500|name99\", got %q", got)
+	}
+}
+
+// sqliteDump returns the output of the sqlite3 shell's .dump command for the
+// database at dbPath.
+func sqliteDump(t *testing.T, dbPath string) string {
+	t.Helper()
+	output, err := exec.Command("sqlite3", dbPath, ".dump").CombinedOutput()
+	if err != nil {
+		t.Fatalf("sqlite3 .dump failed: %v\nOutput: %s", err, string(output))
+	}
+	return string(output)
+}
+
+// loadDump returns the path of a new database created by sqlite3 from the
+// output of Dump for the database at dbPath.
+func loadDump(t *testing.T, dbPath string) string {
+	t.Helper()
+	restoredPath := filepath.Join(t.TempDir(), "restored.sqlite")
+	runSQL(t, restoredPath, dumpFile(t, dbPath))
+	return restoredPath
+}
+
+func TestDatabase_Dump_RoundTrip(t *testing.T) {
+	testCases := []struct {
+		name string
+		sql  string
+	}{
+		{
+			name: "views and triggers",
+			sql: `CREATE TABLE item (id INTEGER PRIMARY KEY, name TEXT);
+CREATE TABLE log (item_id INTEGER, note TEXT);
+CREATE VIEW named AS SELECT * FROM item WHERE name IS NOT NULL;
+CREATE VIEW a_named AS SELECT name FROM named;
+CREATE TRIGGER item_log AFTER INSERT ON item BEGIN INSERT INTO log VALUES (new.id, 'added'); END;
+INSERT INTO item VALUES (1, 'one'), (2, NULL);
+DELETE FROM log WHERE item_id = 2;`,
+		},
+		{
+			name: "autoincrement",
+			sql: `CREATE TABLE t (id INTEGER PRIMARY KEY AUTOINCREMENT, v TEXT);
+INSERT INTO t (v) VALUES ('a'), ('b'), ('c');
+DELETE FROM t WHERE id = 3;`,
+		},
+		{
+			name: "keywords",
+			sql: `CREATE TABLE "order" ("group" TEXT, "select" INTEGER);
+INSERT INTO "order" VALUES ('a', 1);`,
+		},
+		{
+			name: "generated columns",
+			sql: `CREATE TABLE g (a INTEGER, b AS (a * 2) VIRTUAL, c AS (a + 1) STORED, d TEXT);
+INSERT INTO g (a, d) VALUES (1, 'x');
+INSERT INTO g (a, d) VALUES (2, NULL);`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dbPath := filepath.Join(t.TempDir(), "dump.sqlite")
+			runSQL(t, dbPath, tc.sql)
+			want := sqliteDump(t, dbPath)
+			if got := sqliteDump(t, loadDump(t, dbPath)); got != want {
+				t.Errorf("restored database differs from the original:\n%s\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestQuoteLiteral(t *testing.T) {
+	testCases := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{"null", SQLNull, "NULL"},
+		{"int", int64(-12), "-12"},
+		{"float", 1.5, "1.5"},
+		{"integral float", 2.0, "2.0"},
+		{"text", "it's", "'it''s'"},
+		{"blob", []byte{0xca, 0xfe}, "X'CAFE'"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := quoteLiteral(tc.value); got != tc.want {
+				t.Errorf("quoteLiteral(%v) = %q, want %q", tc.value, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	testCases := map[string]string{
+		"users":     "users",
+		"_x1":       "_x1",
+		"1abc":      `"1abc"`,
+		"two words": `"two words"`,
+		`say "hi"`:  `"say ""hi"""`,
+		"":          `""`,
+		"order":     `"order"`,
+		"Group":     `"Group"`,
+		"SELECT":    `"SELECT"`,
+		"selection": "selection",
+	}
+	for name, want := range testCases {
+		if got := quoteIdentifier(name); got != want {
+			t.Errorf("quoteIdentifier(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package golite
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// EncodeRecord serializes a record in the SQLite record format, the inverse
+// of ParseRecord. Values may be SQLNull (or nil), int64, float64, string or
+// []byte. Integers are stored in the smallest serial type that holds them,
+// using the constant types 8 and 9 for 0 and 1 as schema format 4 allows.
+// As in SQLite, a NaN float is stored as NULL.
+func EncodeRecord(record Record) ([]byte, error) {
+	var header, body []byte
+	for i, value := range record {
+		serialType, err := appendValue(&body, value)
+		if err != nil {
+			return nil, fmt.Errorf("column %d: %w", i, err)
+		}
+		header = appendVarint(header, uint64(serialType))
+	}
+
+	// The header size includes the varint that encodes it.
+	headerSize := len(header) + 1
+	for varintLen(uint64(headerSize)) != headerSize-len(header) {
+		headerSize = len(header) + varintLen(uint64(headerSize))
+	}
+
+	data := make([]byte, 0, headerSize+len(body))
+	data = appendVarint(data, uint64(headerSize))
+	data = append(data, header...)
+	return append(data, body...), nil
+}
+
+// appendValue appends the body encoding of a value to body and returns its
+// serial type.
+func appendValue(body *[]byte, value any) (int64, error) {
+	switch v := value.(type) {
+	case nil, NullType:
+		return 0, nil
+	case int64:
+		switch {
+		case v == 0:
+			return 8, nil
+		case v == 1:
+			return 9, nil
+		case v >= math.MinInt8 && v <= math.MaxInt8:
+			*body = append(*body, byte(v))
+			return 1, nil
+		case v >= math.MinInt16 && v <= math.MaxInt16:
+			*body = binary.BigEndian.AppendUint16(*body, uint16(v))
+			return 2, nil
+		case v >= -1<<23 && v < 1<<23:
+			*body = append(*body, byte(v>>16), byte(v>>8), byte(v))
+			return 3, nil
+		case v >= math.MinInt32 && v <= math.MaxInt32:
+			*body = binary.BigEndian.AppendUint32(*body, uint32(v))
+			return 4, nil
+		case v >= -1<<47 && v < 1<<47:
+			*body = append(*body, byte(v>>40), byte(v>>32))
+			*body = binary.BigEndian.AppendUint32(*body, uint32(v))
+			return 5, nil
+		}
+		*body = binary.BigEndian.AppendUint64(*body, uint64(v))
+		return 6, nil
+	case float64:
+		if math.IsNaN(v) {
+			return 0, nil
+		}
+		*body = binary.BigEndian.AppendUint64(*body, math.Float64bits(v))
+		return 7, nil
+	case string:
+		*body = append(*body, v...)
+		return int64(len(v))*2 + 13, nil
+	case []byte:
+		*body = append(*body, v...)
+		return int64(len(v))*2 + 12, nil
+	}
+	return 0, fmt.Errorf("cannot encode value of type %T", value)
+}
+
+// appendVarint appends the SQLite varint encoding of v to buf: big-endian
+// groups of 7 bits with the high bit set on all but the last byte, except
+// that a ninth byte, if needed, holds 8 bits.
+func appendVarint(buf []byte, v uint64) []byte {
+	if v > 1<<56-1 {
+		var b [9]byte
+		b[8] = byte(v)
+		v >>= 8
+		for i := 7; i >= 0; i-- {
+			b[i] = byte(v&0x7f) | 0x80
+			v >>= 7
+		}
+		return append(buf, b[:]...)
+	}
+	n := varintLen(v)
+	for i := n - 1; i >= 0; i-- {
+		b := byte(v>>(7*i)) & 0x7f
+		if i > 0 {
+			b |= 0x80
+		}
+		buf = append(buf, b)
+	}
+	return buf
+}
+
+// varintLen returns the number of bytes in the varint encoding of v.
+func varintLen(v uint64) int {
+	n := 1
+	for v > 0x7f && n < 9 {
+		v >>= 7
+		n++
+	}
+	return n
+}
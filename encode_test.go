@@ -0,0 +1,105 @@
+package golite
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestAppendVarint(t *testing.T) {
+	values := []uint64{0, 1, 0x7f, 0x80, 0x3fff, 0x4000, 1<<56 - 1, 1 << 56, math.MaxInt64, math.MaxUint64}
+	for _, v := range values {
+		data := appendVarint(nil, v)
+		if len(data) != varintLen(v) {
+			t.Errorf("appendVarint(%#x) wrote %d bytes, varintLen says %d", v, len(data), varintLen(v))
+		}
+		got, n := readVarint(data)
+		if uint64(got) != v || n != len(data) {
+			t.Errorf("appendVarint(%#x) = %x, read back as %#x (%d bytes)", v, data, got, n)
+		}
+	}
+}
+
+func TestEncodeRecord(t *testing.T) {
+	t.Run("round trip", func(t *testing.T) {
+		records := []Record{
+			{},
+			{SQLNull, int64(0), int64(1), int64(-1), int64(127), int64(-129), int64(1 << 20), int64(-1 << 30), int64(1 << 40), int64(math.MinInt64)},
+			{3.25, math.Inf(-1), "", "héllo", []byte{}, []byte{0, 1, 2}},
+			{strings.Repeat("x", 200)}, // Needs a 2-byte serial type.
+		}
+		for _, record := range records {
+			data, err := EncodeRecord(record)
+			if err != nil {
+				t.Fatalf("EncodeRecord(%v) failed: %v", record, err)
+			}
+			got, err := ParseRecord(data)
+			if err != nil {
+				t.Fatalf("ParseRecord() of encoded %v failed: %v", record, err)
+			}
+			if len(record) == 0 && len(got) == 0 {
+				continue
+			}
+			if !reflect.DeepEqual(got, record) {
+				t.Errorf("round trip of %v gave %v", record, got)
+			}
+		}
+	})
+
+	t.Run("special values", func(t *testing.T) {
+		data, err := EncodeRecord(Record{nil, math.NaN()})
+		if err != nil {
+			t.Fatalf("EncodeRecord() failed: %v", err)
+		}
+		if want := []byte{0x03, 0x00, 0x00}; !bytes.Equal(data, want) {
+			t.Errorf("expected nil and NaN to encode as NULL %x, got %x", want, data)
+		}
+		if _, err := EncodeRecord(Record{int64(1), true}); err == nil || err.Error() != "column 1: cannot encode value of type bool" {
+			t.Errorf("expected an error for a bool value, got %v", err)
+		}
+	})
+
+	t.Run("matches sqlite3", func(t *testing.T) {
+		dbPath := createTestDB(t, "encode_test.sqlite")
+		runSQL(t, dbPath, `
+			CREATE TABLE enc(a, b, c, d, e);
+			INSERT INTO enc VALUES (NULL, 0, 1, 2.5, 'text');
+			INSERT INTO enc VALUES (-200, 70000, 1099511627776, x'cafe', -9223372036854775808);
+		`)
+		db, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		defer db.Close()
+		table := SchemaTable()
+		var root int
+		for record, err := range db.TableScan(table) {
+			if err != nil {
+				t.Fatalf("TableScan() failed: %v", err)
+			}
+			if record[2] == "enc" {
+				root = int(record[4].(int64))
+			}
+		}
+		page, err := db.ReadPage(root)
+		if err != nil {
+			t.Fatalf("ReadPage() failed: %v", err)
+		}
+		for i, cell := range page.LeafCells {
+			// Skip the payload size and rowid varints to find the payload.
+			offset := int(page.CellPointers[i])
+			_, n := readVarint(page.RawData[offset:])
+			_, m := readVarint(page.RawData[offset+n:])
+			want := page.RawData[offset+n+m : offset+n+m+int(cell.PayloadSize)]
+			got, err := EncodeRecord(cell.Record)
+			if err != nil {
+				t.Fatalf("EncodeRecord() failed: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("row %d: expected %x as written by sqlite3, got %x", cell.RowID, want, got)
+			}
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package golite
+
+import "errors"
+
+// Errors returned by golite can be classified with errors.Is against the
+// following sentinel values. Errors not matching any of them are I/O errors
+// from the underlying file or invalid arguments.
+var (
+	// ErrNotFound is returned by Find when a record with the specified rowID cannot be found.
+	ErrNotFound = errors.New("record not found")
+
+	// ErrCorrupt matches every *CorruptError, which reports a file that does
+	// not follow the SQLite file format.
+	ErrCorrupt = errors.New("database disk image is malformed")
+
+	// ErrUnsupportedFeature matches every *UnsupportedFeatureError, which
+	// reports a valid file using a feature golite cannot read.
+	ErrUnsupportedFeature = errors.New("unsupported feature")
+
+	// ErrEncrypted is returned by Open for files that are not SQLite
+	// databases and whose first bytes look random, as is the case for
+	// databases encrypted with extensions such as SEE or SQLCipher.
+	ErrEncrypted = errors.New("file is encrypted or is not a database")
+
+	// ErrLimitExceeded matches every *LimitError, which reports an iteration
+	// aborted by one of the limits set with Guard or Recursive.
+	ErrLimitExceeded = errors.New("limit exceeded")
+
+	// ErrNoSuchFunction is returned by CallFunc, LookupFunc and NewAggregate
+	// for a name under which no function is registered.
+	ErrNoSuchFunction = errors.New("no such function")
+
+	// ErrInterrupted is returned by the iterations in progress on a
+	// Database when its Interrupt method is called.
+	ErrInterrupted = errors.New("interrupted")
+)
+
+// UnsupportedFeatureError reports that a database uses a feature of the
+// SQLite file format that golite does not support.
+type UnsupportedFeatureError struct {
+	// Feature names the unsupported feature.
+	Feature string
+}
+
+// Error returns a description naming the feature.
+func (e *UnsupportedFeatureError) Error() string {
+	return "unsupported feature: " + e.Feature
+}
+
+// Is reports whether target is ErrUnsupportedFeature.
+func (e *UnsupportedFeatureError) Is(target error) bool {
+	return target == ErrUnsupportedFeature
+}
+
+// unsupported returns an UnsupportedFeatureError for the given feature.
+func unsupported(feature string) error {
+	return &UnsupportedFeatureError{Feature: feature}
+}
@@ -0,0 +1,107 @@
+package golite
+
+import (
+	"errors"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestErrorKinds(t *testing.T) {
+	t.Run("corrupt", func(t *testing.T) {
+		data := craftPage(PageTypeLeafTable, []uint16{40}, map[int][]byte{40: {0x1c, 0x01}})
+		if _, err := ParsePage(data, 3); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("expected an error matching ErrCorrupt, got %v", err)
+		}
+		if _, err := ParseHeader(make([]byte, HeaderSize)); !errors.Is(err, ErrCorrupt) || errors.Is(err, ErrEncrypted) {
+			t.Errorf("expected a zeroed header to match only ErrCorrupt, got %v", err)
+		}
+	})
+
+	t.Run("unsupported feature", func(t *testing.T) {
+		err := Capabilities().CheckHeader(&Header{ReadVersion: 2, TextEncoding: 3})
+		var unsupported *UnsupportedFeatureError
+		if !errors.Is(err, ErrUnsupportedFeature) || !errors.As(err, &unsupported) {
+			t.Fatalf("expected an *UnsupportedFeatureError, got %v", err)
+		}
+		if unsupported.Feature != "WAL journal mode" {
+			t.Errorf("expected the WAL feature first, got %q", unsupported.Feature)
+		}
+
+		dbPath := createTestDB(t, "errors_read_version_test.sqlite")
+		data, err := os.ReadFile(dbPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data[19] = 4 // A read version this library predates.
+		if err := os.WriteFile(dbPath, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Open(dbPath); !errors.Is(err, ErrUnsupportedFeature) {
+			t.Errorf("expected Open() to fail with ErrUnsupportedFeature, got %v", err)
+		}
+
+		// A database in WAL2 journal mode must not be read without its WAL
+		// files.
+		data[18], data[19] = 3, 3
+		if err := os.WriteFile(dbPath, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		_, err = Open(dbPath)
+		if !errors.As(err, &unsupported) || unsupported.Feature != "WAL2 journal mode" {
+			t.Errorf("expected Open() to fail with the WAL2 feature, got %v", err)
+		}
+	})
+
+	t.Run("overflow pages", func(t *testing.T) {
+		// An index cell holds at most 1002 bytes of payload locally on a page
+		// of 4096 bytes, and a table leaf cell 4061, so both of these spill
+		// onto overflow pages.
+		dbPath := filepath.Join(t.TempDir(), "overflow.sqlite")
+		runSQL(t, dbPath, `CREATE TABLE t (a TEXT);
+CREATE INDEX t_a ON t (a);
+INSERT INTO t VALUES (hex(zeroblob(750)));
+CREATE TABLE u (a TEXT);
+INSERT INTO u VALUES (hex(zeroblob(3000)));`)
+		db, err := Open(dbPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+		schema, err := db.GetSchema()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := CollectRecords(db.TableScan(schema.Tables["t"])); err != nil {
+			t.Errorf("scanning t: %v", err)
+		}
+		for name, records := range map[string]RecordIterator{
+			"index t_a": db.IndexScan(schema.Indexes["t_a"]),
+			"table u":   db.TableScan(schema.Tables["u"]),
+			"columns u": db.TableScanColumns(schema.Tables["u"], []string{"a"}),
+			"reuse u":   db.TableScanReuse(schema.Tables["u"]),
+		} {
+			_, err := CollectRecords(records)
+			var unsupported *UnsupportedFeatureError
+			if !errors.As(err, &unsupported) || errors.Is(err, ErrCorrupt) {
+				t.Errorf("%s: expected an *UnsupportedFeatureError, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("encrypted", func(t *testing.T) {
+		data := make([]byte, 4096)
+		rng := rand.New(rand.NewPCG(1, 2))
+		for i := range data {
+			data[i] = byte(rng.Uint32())
+		}
+		dbPath := filepath.Join(t.TempDir(), "encrypted.sqlite")
+		if err := os.WriteFile(dbPath, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := Open(dbPath); !errors.Is(err, ErrEncrypted) {
+			t.Errorf("expected Open() to fail with ErrEncrypted, got %v", err)
+		}
+	})
+}
@@ -1,5 +1,11 @@
 package golite
 
+import (
+	"context"
+	"iter"
+	"sync"
+)
+
 // Filter is an execution primitive that takes a RecordIterator and a predicate function.
 // It returns a new iterator that only yields rows for which the predicate returns true.
 func Filter(input RecordIterator, predicate func(record Record) (bool, error)) RecordIterator {
@@ -24,3 +30,271 @@ func Filter(input RecordIterator, predicate func(record Record) (bool, error)) R
 		}
 	}
 }
+
+// Take returns an iterator that yields at most the first n records of input.
+// Errors from input are passed through and end the iteration.
+func Take(input RecordIterator, n int) RecordIterator {
+	return func(yield func(Record, error) bool) {
+		if n <= 0 {
+			return
+		}
+		count := 0
+		for record, err := range input {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(record, nil) {
+				return
+			}
+			count++
+			if count == n {
+				return
+			}
+		}
+	}
+}
+
+// Skip returns an iterator that yields the records of input after the first
+// n. Errors from input are passed through and end the iteration, even while
+// records are being skipped.
+func Skip(input RecordIterator, n int) RecordIterator {
+	return func(yield func(Record, error) bool) {
+		skipped := 0
+		for record, err := range input {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if skipped < n {
+				skipped++
+				continue
+			}
+			if !yield(record, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Chain returns an iterator that yields the records of each input in turn.
+// An error from any input ends the iteration.
+func Chain(inputs ...RecordIterator) RecordIterator {
+	return func(yield func(Record, error) bool) {
+		for _, input := range inputs {
+			for record, err := range input {
+				if !yield(record, err) || err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Join returns an iterator implementing a nested loop join: for each record
+// of outer, it yields the concatenation of that record with each record of
+// inner(record). inner is typically an IndexSeek or TableSeek keyed by a value
+// of the outer record, or a Filter over the Scan of a materialized Relation.
+// The inputs may come from different databases, for instance from tables
+// resolved through a Catalog. An error from either side ends the iteration.
+func Join(outer RecordIterator, inner func(outer Record) RecordIterator) RecordIterator {
+	return func(yield func(Record, error) bool) {
+		for left, err := range outer {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for right, err := range inner(left) {
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				joined := make(Record, 0, len(left)+len(right))
+				if !yield(append(append(joined, left...), right...), nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Peek reads the first record of input and returns it, together with an
+// iterator that yields all the records of input, starting with that first
+// one. The first record is nil if input is empty, and err is set if reading
+// it failed. Peek starts the input right away, and the returned iterator can
+// only be used once: range over it, if only to break out immediately, to
+// release the input.
+func Peek(input RecordIterator) (first Record, rest RecordIterator, err error) {
+	next, stop := iter.Pull2(iter.Seq2[Record, error](input))
+	first, err, ok := next()
+	if !ok || err != nil {
+		stop()
+		return first, func(yield func(Record, error) bool) {
+			if err != nil {
+				yield(nil, err)
+			}
+		}, err
+	}
+	rest = func(yield func(Record, error) bool) {
+		defer stop()
+		if !yield(first, nil) {
+			return
+		}
+		for {
+			record, err, ok := next()
+			if !ok || !yield(record, err) || err != nil {
+				return
+			}
+		}
+	}
+	return first, rest, nil
+}
+
+// Tee returns n iterators that each yield all the records of input, reading
+// input only once. The iterators may be consumed one after another or
+// concurrently; records read by one iterator are buffered until all the
+// others have also yielded them, so consuming them in step keeps memory use
+// low. Each iterator can only be used once, and input is only released once
+// all of them have been ranged over. The iterators share the yielded records,
+// which must therefore not be modified.
+func Tee(input RecordIterator, n int) []RecordIterator {
+	t := &tee{positions: make([]int, n), active: n}
+	t.next, t.stop = iter.Pull2(iter.Seq2[Record, error](input))
+	outputs := make([]RecordIterator, n)
+	for i := range outputs {
+		outputs[i] = t.output(i)
+	}
+	return outputs
+}
+
+// teeItem is a value read from the input of a Tee.
+type teeItem struct {
+	record Record
+	err    error
+}
+
+// tee is the state shared by the iterators returned by Tee.
+type tee struct {
+	mu        sync.Mutex
+	next      func() (Record, error, bool)
+	stop      func()
+	buffer    []teeItem // Items read but not yet yielded by every output.
+	base      int       // Position in the input of buffer[0].
+	positions []int     // Position in the input of each output, -1 once done.
+	active    int       // Number of outputs not yet done.
+	finished  bool      // Whether the input is exhausted.
+}
+
+// output returns the iterator for output i.
+func (t *tee) output(i int) RecordIterator {
+	return func(yield func(Record, error) bool) {
+		t.mu.Lock()
+		used := t.positions[i] == -1
+		t.mu.Unlock()
+		if used {
+			return
+		}
+		defer t.done(i)
+		for {
+			item, ok := t.read(i)
+			if !ok || !yield(item.record, item.err) || item.err != nil {
+				return
+			}
+		}
+	}
+}
+
+// read returns the next item for output i, reading it from the input if no
+// other output has already done so.
+func (t *tee) read(i int) (teeItem, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	offset := t.positions[i] - t.base
+	if offset == len(t.buffer) {
+		if t.finished {
+			return teeItem{}, false
+		}
+		record, err, ok := t.next()
+		if !ok {
+			t.finished = true
+			return teeItem{}, false
+		}
+		t.buffer = append(t.buffer, teeItem{record: record, err: err})
+	}
+	item := t.buffer[offset]
+	t.positions[i]++
+	t.trim()
+	return item, true
+}
+
+// done marks output i as done, releasing the input once all outputs are.
+func (t *tee) done(i int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.positions[i] = -1
+	t.active--
+	if t.active == 0 {
+		t.stop()
+		t.buffer = nil
+		return
+	}
+	t.trim()
+}
+
+// trim drops the buffered items that every active output has yielded.
+func (t *tee) trim() {
+	lowest := -1
+	for _, pos := range t.positions {
+		if pos != -1 && (lowest == -1 || pos < lowest) {
+			lowest = pos
+		}
+	}
+	if lowest > t.base {
+		clear(t.buffer[:lowest-t.base])
+		t.buffer = t.buffer[lowest-t.base:]
+		t.base = lowest
+	}
+}
+
+// CollectRecords reads all the records of input into a slice. It stops at the
+// first error, returning the records read until then along with it.
+func CollectRecords(input RecordIterator) ([]Record, error) {
+	var records []Record
+	for record, err := range input {
+		if err != nil {
+			return records, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// RecordResult is a value received from the channel returned by ToChannel:
+// either a record or the error that ended the input.
+type RecordResult struct {
+	Record Record
+	Err    error
+}
+
+// ToChannel reads input in a new goroutine and sends its records to the
+// returned channel, which is closed when input is exhausted. An error from
+// input is sent as the last result before the channel is closed. Canceling
+// ctx stops the goroutine and closes the channel without sending anything
+// further, so consumers that stop early should cancel ctx to release input.
+func ToChannel(ctx context.Context, input RecordIterator) <-chan RecordResult {
+	ch := make(chan RecordResult)
+	go func() {
+		defer close(ch)
+		for record, err := range input {
+			select {
+			case ch <- RecordResult{Record: record, Err: err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return ch
+}
@@ -1,7 +1,11 @@
 package golite
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"reflect"
+	"sync"
 	"testing"
 )
 
@@ -72,3 +76,198 @@ func TestFilter(t *testing.T) {
 		}
 	})
 }
+
+// numbers returns an iterator over records holding 1 to n, failing with err
+// after them if it is not nil.
+func numbers(n int, err error) RecordIterator {
+	return func(yield func(Record, error) bool) {
+		for i := 1; i <= n; i++ {
+			if !yield(Record{int64(i)}, nil) {
+				return
+			}
+		}
+		if err != nil {
+			yield(nil, err)
+		}
+	}
+}
+
+// collectInts returns the first column of each record yielded by it, and the
+// first error.
+func collectInts(it RecordIterator) ([]int64, error) {
+	var values []int64
+	for record, err := range it {
+		if err != nil {
+			return values, err
+		}
+		values = append(values, record[0].(int64))
+	}
+	return values, nil
+}
+
+func TestCombinators(t *testing.T) {
+	errBoom := errors.New("boom")
+	testCases := []struct {
+		name    string
+		it      RecordIterator
+		want    []int64
+		wantErr error
+	}{
+		{name: "take", it: Take(numbers(5, nil), 3), want: []int64{1, 2, 3}},
+		{name: "take more than available", it: Take(numbers(2, errBoom), 5), want: []int64{1, 2}, wantErr: errBoom},
+		{name: "take zero", it: Take(numbers(2, nil), 0)},
+		{name: "skip", it: Skip(numbers(5, nil), 3), want: []int64{4, 5}},
+		{name: "skip everything", it: Skip(numbers(2, errBoom), 5), wantErr: errBoom},
+		{name: "chain", it: Chain(numbers(2, nil), numbers(0, nil), numbers(1, nil)), want: []int64{1, 2, 1}},
+		{name: "chain stops on error", it: Chain(numbers(1, errBoom), numbers(1, nil)), want: []int64{1}, wantErr: errBoom},
+		{name: "take of skip", it: Take(Skip(numbers(10, nil), 2), 2), want: []int64{3, 4}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := collectInts(tc.it)
+			if !reflect.DeepEqual(got, tc.want) || err != tc.wantErr {
+				t.Errorf("got (%v, %v), want (%v, %v)", got, err, tc.want, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestPeek(t *testing.T) {
+	first, rest, err := Peek(numbers(3, nil))
+	if err != nil || !reflect.DeepEqual(first, Record{int64(1)}) {
+		t.Fatalf("Peek() = (%v, %v), want ([1], nil)", first, err)
+	}
+	if got, err := collectInts(rest); err != nil || !reflect.DeepEqual(got, []int64{1, 2, 3}) {
+		t.Errorf("expected the rest to start with the peeked record, got (%v, %v)", got, err)
+	}
+
+	first, rest, err = Peek(numbers(0, nil))
+	if first != nil || err != nil {
+		t.Errorf("expected no first record for an empty input, got (%v, %v)", first, err)
+	}
+	if got, _ := collectInts(rest); len(got) != 0 {
+		t.Errorf("expected the rest to be empty, got %v", got)
+	}
+
+	errBoom := errors.New("boom")
+	if _, rest, err = Peek(numbers(0, errBoom)); err != errBoom {
+		t.Errorf("expected Peek() to return the input error, got %v", err)
+	}
+	if _, err := collectInts(rest); err != errBoom {
+		t.Errorf("expected the rest to yield the input error, got %v", err)
+	}
+
+	// Breaking out of the rest immediately must release the input.
+	_, rest, _ = Peek(numbers(3, nil))
+	for range rest {
+		break
+	}
+}
+
+func TestTee(t *testing.T) {
+	t.Run("sequential", func(t *testing.T) {
+		reads := 0
+		input := func(yield func(Record, error) bool) {
+			for record, err := range numbers(100, nil) {
+				reads++
+				if !yield(record, err) {
+					return
+				}
+			}
+		}
+		outputs := Tee(input, 3)
+		want, _ := collectInts(numbers(100, nil))
+		for i, output := range outputs {
+			if got, err := collectInts(output); err != nil || !reflect.DeepEqual(got, want) {
+				t.Errorf("output %d: got (%d records, %v)", i, len(got), err)
+			}
+		}
+		if reads != 100 {
+			t.Errorf("expected the input to be read once, got %d reads", reads)
+		}
+	})
+
+	t.Run("concurrent with early stop", func(t *testing.T) {
+		outputs := Tee(numbers(1000, nil), 4)
+		results := make([][]int64, len(outputs))
+		var wg sync.WaitGroup
+		for i, output := range outputs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if i == 0 {
+					results[i], _ = collectInts(Take(output, 10))
+				} else {
+					results[i], _ = collectInts(output)
+				}
+			}()
+		}
+		wg.Wait()
+		if len(results[0]) != 10 {
+			t.Errorf("expected 10 records from the stopped output, got %d", len(results[0]))
+		}
+		for i := 1; i < len(results); i++ {
+			if len(results[i]) != 1000 {
+				t.Errorf("output %d: expected 1000 records, got %d", i, len(results[i]))
+			}
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		for i, output := range Tee(numbers(2, errBoom), 2) {
+			if got, err := collectInts(output); err != errBoom || len(got) != 2 {
+				t.Errorf("output %d: got (%v, %v)", i, got, err)
+			}
+		}
+	})
+}
+
+func TestCollectRecords(t *testing.T) {
+	records, err := CollectRecords(numbers(3, nil))
+	if err != nil || !reflect.DeepEqual(records, []Record{{int64(1)}, {int64(2)}, {int64(3)}}) {
+		t.Errorf("CollectRecords() = (%v, %v)", records, err)
+	}
+	errBoom := errors.New("boom")
+	if records, err := CollectRecords(numbers(2, errBoom)); err != errBoom || len(records) != 2 {
+		t.Errorf("expected 2 records and the input error, got (%v, %v)", records, err)
+	}
+}
+
+func TestToChannel(t *testing.T) {
+	t.Run("records and error", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		var got []int64
+		var gotErr error
+		for result := range ToChannel(context.Background(), numbers(3, errBoom)) {
+			if result.Err != nil {
+				gotErr = result.Err
+				continue
+			}
+			got = append(got, result.Record[0].(int64))
+		}
+		if !reflect.DeepEqual(got, []int64{1, 2, 3}) || gotErr != errBoom {
+			t.Errorf("got (%v, %v)", got, gotErr)
+		}
+	})
+
+	t.Run("cancellation", func(t *testing.T) {
+		released := make(chan struct{})
+		input := func(yield func(Record, error) bool) {
+			defer close(released)
+			for i := int64(0); ; i++ {
+				if !yield(Record{i}, nil) {
+					return
+				}
+			}
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		ch := ToChannel(ctx, input)
+		<-ch
+		cancel()
+		<-released
+		for range ch {
+			// Drain anything sent before the cancellation was noticed.
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/arnodel/golite"
+)
+
+// WriteCSV writes the records produced by it to w as CSV. The first line is a
+// header made of the names in table.Columns; records are expected in the shape
+// TableScan yields for that table, so an implicit rowid is dropped, and
+// records written before a column was added are padded with its default.
+func WriteCSV(w io.Writer, table golite.TableInfo, it golite.RecordIterator, opts ...Option) error {
+	o := buildOptions(opts)
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(columnNames(table)); err != nil {
+		return err
+	}
+
+	var fields []string
+	for record, err := range it {
+		if err != nil {
+			return err
+		}
+		record = table.Row(record).Values()
+		fields = fields[:0]
+		for _, value := range record {
+			fields = append(fields, o.csvField(value))
+		}
+		if err := cw.Write(fields); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvField renders a single value as a CSV field.
+func (o options) csvField(value any) string {
+	switch v := value.(type) {
+	case golite.NullType, nil:
+		return o.null
+	case string:
+		return v
+	case []byte:
+		return o.encodeBlob(v)
+	case int64, float64:
+		return formatNumber(v)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,115 @@
+package export
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/arnodel/golite"
+)
+
+// testTable has a rowid alias, so records are in the same shape as its columns.
+var testTable = golite.TableInfo{
+	Name: "items",
+	Columns: []golite.ColumnInfo{
+		{Name: "id", Type: "INTEGER"},
+		{Name: "name", Type: "TEXT"},
+		{Name: "price", Type: "REAL"},
+		{Name: "data", Type: "BLOB"},
+	},
+	RowIDColumnIndex: 0,
+}
+
+var testRecords = []golite.Record{
+	{int64(1), "apple", 1.5, []byte("hi")},
+	{int64(2), "pear, \"ripe\"", 2.0, golite.SQLNull},
+}
+
+// alteredTable has had columns b and c added by ALTER TABLE ADD COLUMN, so
+// the records written before lack them.
+var alteredTable = golite.TableInfo{
+	Name: "altered",
+	Columns: []golite.ColumnInfo{
+		{Name: "id", Type: "INTEGER"},
+		{Name: "a", Type: "TEXT"},
+		{Name: "b", Type: "INTEGER", Default: "7"},
+		{Name: "c", Type: "TEXT"},
+	},
+	RowIDColumnIndex: 0,
+}
+
+// recordsOf returns an iterator yielding the given records.
+func recordsOf(records ...golite.Record) golite.RecordIterator {
+	return func(yield func(golite.Record, error) bool) {
+		for _, r := range records {
+			if !yield(r, nil) {
+				return
+			}
+		}
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	testCases := []struct {
+		name string
+		opts []Option
+		want string
+	}{
+		{
+			name: "defaults",
+			want: "id,name,price,data\n1,apple,1.5,6869\n2,\"pear, \"\"ripe\"\"\",2.0,\n",
+		},
+		{
+			name: "null text and base64 blobs",
+			opts: []Option{WithNull("NULL"), WithBlobEncoding(BlobBase64)},
+			want: "id,name,price,data\n1,apple,1.5,aGk=\n2,\"pear, \"\"ripe\"\"\",2.0,NULL\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteCSV(&buf, testTable, recordsOf(testRecords...), tc.opts...); err != nil {
+				t.Fatalf("WriteCSV() failed: %v", err)
+			}
+			if got := buf.String(); got != tc.want {
+				t.Errorf("WriteCSV() wrote %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	t.Run("implicit rowid is dropped", func(t *testing.T) {
+		table := golite.TableInfo{
+			Columns:          []golite.ColumnInfo{{Name: "a", Type: "TEXT"}},
+			RowIDColumnIndex: -1,
+		}
+		var buf bytes.Buffer
+		if err := WriteCSV(&buf, table, recordsOf(golite.Record{int64(7), "x"})); err != nil {
+			t.Fatalf("WriteCSV() failed: %v", err)
+		}
+		if got, want := buf.String(), "a\nx\n"; got != want {
+			t.Errorf("WriteCSV() wrote %q, want %q", got, want)
+		}
+	})
+
+	t.Run("short records are padded", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteCSV(&buf, alteredTable, recordsOf(golite.Record{int64(1), "x"})); err != nil {
+			t.Fatalf("WriteCSV() failed: %v", err)
+		}
+		if got, want := buf.String(), "id,a,b,c\n1,x,7,\n"; got != want {
+			t.Errorf("WriteCSV() wrote %q, want %q", got, want)
+		}
+	})
+
+	t.Run("iterator error", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		it := func(yield func(golite.Record, error) bool) {
+			yield(nil, errBoom)
+		}
+		var buf bytes.Buffer
+		if err := WriteCSV(&buf, testTable, it); !errors.Is(err, errBoom) {
+			t.Errorf("expected iterator error to be returned, got %v", err)
+		}
+	})
+}
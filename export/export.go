@@ -0,0 +1,89 @@
+// Package export writes golite records to common interchange formats.
+package export
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"strconv"
+
+	"github.com/arnodel/golite"
+)
+
+// BlobEncoding selects how BLOB values are rendered in text-based formats.
+type BlobEncoding int
+
+const (
+	// BlobHex renders blobs as lowercase hexadecimal.
+	BlobHex BlobEncoding = iota
+	// BlobBase64 renders blobs using standard base64 encoding.
+	BlobBase64
+	// BlobRaw writes the blob bytes unchanged.
+	BlobRaw
+)
+
+// options holds the settings shared by all exporters.
+type options struct {
+	null      string
+	nullIsSet bool
+	blobs     BlobEncoding
+}
+
+// Option configures an exporter.
+type Option func(*options)
+
+// WithNull sets the text written in place of NULL values. For CSV the default
+// is an empty field; for JSON, NULLs are written as null unless this option
+// is given, in which case the text is written as a string.
+func WithNull(s string) Option {
+	return func(o *options) {
+		o.null = s
+		o.nullIsSet = true
+	}
+}
+
+// WithBlobEncoding sets how BLOB values are rendered. The default is BlobHex.
+func WithBlobEncoding(enc BlobEncoding) Option {
+	return func(o *options) {
+		o.blobs = enc
+	}
+}
+
+func buildOptions(opts []Option) options {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// encodeBlob renders a blob according to the configured encoding.
+func (o options) encodeBlob(b []byte) string {
+	switch o.blobs {
+	case BlobBase64:
+		return base64.StdEncoding.EncodeToString(b)
+	case BlobRaw:
+		return string(b)
+	default:
+		return hex.EncodeToString(b)
+	}
+}
+
+// columnNames returns the names of the table's declared columns.
+func columnNames(table golite.TableInfo) []string {
+	names := make([]string, len(table.Columns))
+	for i, col := range table.Columns {
+		names[i] = col.Name
+	}
+	return names
+}
+
+// formatNumber renders an INTEGER or REAL value as text, the way SQLite does.
+func formatNumber(v any) string {
+	switch v := v.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return golite.FormatReal(v)
+	}
+	return ""
+}
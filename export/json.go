@@ -0,0 +1,105 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"math"
+
+	"github.com/arnodel/golite"
+)
+
+// WriteJSON writes the records produced by it to w as a JSON array with one
+// object per record. Object keys are the names in table.Columns, in order.
+// Records written before a column was added hold its default under its key.
+func WriteJSON(w io.Writer, table golite.TableInfo, it golite.RecordIterator, opts ...Option) error {
+	return writeJSON(w, table, it, buildOptions(opts), false)
+}
+
+// WriteNDJSON writes the records produced by it to w as newline-delimited
+// JSON, with one object per line. Object keys are the names in table.Columns.
+func WriteNDJSON(w io.Writer, table golite.TableInfo, it golite.RecordIterator, opts ...Option) error {
+	return writeJSON(w, table, it, buildOptions(opts), true)
+}
+
+func writeJSON(w io.Writer, table golite.TableInfo, it golite.RecordIterator, o options, ndjson bool) error {
+	bw := bufio.NewWriter(w)
+
+	// Column names are marshalled once up front.
+	keys := make([][]byte, len(table.Columns))
+	for i, name := range columnNames(table) {
+		key, err := json.Marshal(name)
+		if err != nil {
+			return err
+		}
+		keys[i] = key
+	}
+
+	if !ndjson {
+		bw.WriteByte('[')
+	}
+	first := true
+	for record, err := range it {
+		if err != nil {
+			return err
+		}
+		record = table.Row(record).Values()
+		if !ndjson && !first {
+			bw.WriteByte(',')
+		}
+		first = false
+
+		bw.WriteByte('{')
+		for i, value := range record {
+			if i > 0 {
+				bw.WriteByte(',')
+			}
+			bw.Write(keys[i])
+			bw.WriteByte(':')
+			if err := o.writeJSONValue(bw, value); err != nil {
+				return err
+			}
+		}
+		bw.WriteByte('}')
+		if ndjson {
+			bw.WriteByte('\n')
+		}
+	}
+	if !ndjson {
+		bw.WriteString("]\n")
+	}
+	return bw.Flush()
+}
+
+// writeJSONValue writes a single value as JSON.
+func (o options) writeJSONValue(w *bufio.Writer, value any) error {
+	var data []byte
+	var err error
+	switch v := value.(type) {
+	case golite.NullType, nil:
+		if !o.nullIsSet {
+			_, err = w.WriteString("null")
+			return err
+		}
+		data, err = json.Marshal(o.null)
+	case int64:
+		_, err = w.WriteString(formatNumber(v))
+		return err
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			_, err = w.WriteString("null") // Not representable in JSON.
+			return err
+		}
+		_, err = w.WriteString(formatNumber(v))
+		return err
+	case []byte:
+		data, err = json.Marshal(o.encodeBlob(v))
+	default:
+		data, err = json.Marshal(v)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
@@ -0,0 +1,57 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/arnodel/golite"
+)
+
+func TestWriteJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, testTable, recordsOf(testRecords...)); err != nil {
+		t.Fatalf("WriteJSON() failed: %v", err)
+	}
+	want := `[{"id":1,"name":"apple","price":1.5,"data":"6869"},` +
+		`{"id":2,"name":"pear, \"ripe\"","price":2.0,"data":null}]` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WriteJSON() wrote %s, want %s", got, want)
+	}
+	if !json.Valid(buf.Bytes()) {
+		t.Error("WriteJSON() output is not valid JSON")
+	}
+
+	t.Run("short records are padded", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteJSON(&buf, alteredTable, recordsOf(golite.Record{int64(1), "x"})); err != nil {
+			t.Fatalf("WriteJSON() failed: %v", err)
+		}
+		if got, want := buf.String(), `[{"id":1,"a":"x","b":7,"c":null}]`+"\n"; got != want {
+			t.Errorf("WriteJSON() wrote %s, want %s", got, want)
+		}
+	})
+
+	t.Run("empty input", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := WriteJSON(&buf, testTable, recordsOf()); err != nil {
+			t.Fatalf("WriteJSON() failed: %v", err)
+		}
+		if got, want := buf.String(), "[]\n"; got != want {
+			t.Errorf("WriteJSON() wrote %q, want %q", got, want)
+		}
+	})
+}
+
+func TestWriteNDJSON(t *testing.T) {
+	var buf bytes.Buffer
+	opts := []Option{WithNull(""), WithBlobEncoding(BlobBase64)}
+	if err := WriteNDJSON(&buf, testTable, recordsOf(testRecords...), opts...); err != nil {
+		t.Fatalf("WriteNDJSON() failed: %v", err)
+	}
+	want := `{"id":1,"name":"apple","price":1.5,"data":"aGk="}` + "\n" +
+		`{"id":2,"name":"pear, \"ripe\"","price":2.0,"data":""}` + "\n"
+	if got := buf.String(); got != want {
+		t.Errorf("WriteNDJSON() wrote %s, want %s", got, want)
+	}
+}
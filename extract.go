@@ -0,0 +1,359 @@
+package golite
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ExtractTables writes a new database file at dst holding the named tables
+// of db, with their indexes, including those created for UNIQUE and PRIMARY
+// KEY constraints, and their triggers. The pages of their B-Trees, and the
+// overflow pages of their records, are copied as they are, only the page
+// numbers they refer to being rewritten, and a schema listing them is written
+// to page 1. The sqlite_sequence table is included if one of the tables uses
+// AUTOINCREMENT. Views and the statistics gathered by ANALYZE are left out.
+// The new file has the page size, text encoding and user version of db, and
+// neither free pages nor auto-vacuum. It is an error if dst exists.
+func (db *Database) ExtractTables(dst string, tables ...string) (err error) {
+	page1, err := db.readPageData(1)
+	if err != nil {
+		return err
+	}
+	x := &extraction{
+		db:       db,
+		usable:   int(db.Header.PageSize) - int(page1[20]),
+		newPages: map[int]int{},
+	}
+	if err := x.selectSchema(tables); err != nil {
+		return err
+	}
+	for _, row := range x.schema {
+		if row.rootPage > 0 {
+			if err := x.collectTree(row.rootPage); err != nil {
+				return err
+			}
+		}
+	}
+	first, err := x.firstPage(page1)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(dst)
+		}
+	}()
+	w := bufio.NewWriter(f)
+	if _, err := w.Write(first); err != nil {
+		return err
+	}
+	for _, page := range x.pages {
+		data, err := x.rewritePage(page)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// extraction holds the state of an ExtractTables.
+type extraction struct {
+	db     *Database
+	usable int // The usable size of a page, without the reserved bytes.
+	// schema holds the rows of sqlite_schema to copy.
+	schema []schemaRow
+	// pages lists the pages to copy, in the order they are written from
+	// page 2 on, and newPages maps their numbers in db to those in the new
+	// file.
+	pages    []extractedPage
+	newPages map[int]int
+}
+
+// schemaRow is a row of sqlite_schema.
+type schemaRow struct {
+	kind, name, tableName string
+	rootPage              int
+	sql                   any // A string, or SQLNull for automatic indexes.
+}
+
+// extractedPage is a page to copy.
+type extractedPage struct {
+	pageNum  int
+	overflow bool // Whether it is an overflow page rather than a B-Tree page.
+}
+
+// selectSchema reads the rows of sqlite_schema describing the named tables
+// and the indexes and triggers on them.
+func (x *extraction) selectSchema(tables []string) error {
+	selected := map[string]bool{}
+	autoincrement := false
+	for _, name := range tables {
+		table, err := x.db.Table(name)
+		if err != nil {
+			return err
+		}
+		if table.RootPage == 1 {
+			return fmt.Errorf("cannot extract %s", table.Name)
+		}
+		selected[strings.ToLower(table.Name)] = true
+		autoincrement = autoincrement || strings.Contains(strings.ToUpper(table.SQL), "AUTOINCREMENT")
+	}
+	if autoincrement {
+		selected["sqlite_sequence"] = true
+	}
+
+	schemaTable := SchemaTable()
+	for record, err := range x.db.TableScan(schemaTable) {
+		if err != nil {
+			return err
+		}
+		values := schemaTable.ColumnValues(record)
+		if len(values) < 5 {
+			return newCorruptError(1, -1, -1, CorruptSchema, fmt.Errorf("malformed schema record: expected at least 5 columns, got %d", len(values)))
+		}
+		kind, _ := values[0].(string)
+		tableName, _ := values[2].(string)
+		if kind != "table" && kind != "index" && kind != "trigger" || !selected[strings.ToLower(tableName)] {
+			continue
+		}
+		name, okName := values[1].(string)
+		rootPage, okRootPage := values[3].(int64)
+		if !okName || !okRootPage {
+			return newCorruptError(1, -1, -1, CorruptSchema, fmt.Errorf("malformed schema record for %s %q", kind, name))
+		}
+		x.schema = append(x.schema, schemaRow{kind, name, tableName, int(rootPage), values[4]})
+	}
+	return nil
+}
+
+// collectTree adds the pages of the B-Tree rooted at pageNum, and their
+// overflow pages, to the pages to copy.
+func (x *extraction) collectTree(pageNum int) error {
+	page, err := x.addPage(pageNum, false)
+	if err != nil {
+		return err
+	}
+	for i, cellOffset := range page.CellPointers {
+		if page.Type == PageTypeInteriorTable || page.Type == PageTypeInteriorIndex {
+			if int(cellOffset)+4 > len(page.RawData) {
+				return newCorruptError(pageNum, i, int(cellOffset), CorruptCell, errors.New("cell extends beyond the page"))
+			}
+			if err := x.collectTree(int(binary.BigEndian.Uint32(page.RawData[cellOffset:]))); err != nil {
+				return err
+			}
+		}
+		pos, err := x.overflowPointer(page, i)
+		if err != nil {
+			return err
+		}
+		if pos != -1 {
+			if err := x.collectOverflow(int(binary.BigEndian.Uint32(page.RawData[pos:]))); err != nil {
+				return err
+			}
+		}
+	}
+	if page.Type == PageTypeInteriorTable || page.Type == PageTypeInteriorIndex {
+		return x.collectTree(int(page.RightMostPtr))
+	}
+	return nil
+}
+
+// collectOverflow adds the overflow pages of a chain starting at pageNum to
+// the pages to copy.
+func (x *extraction) collectOverflow(pageNum int) error {
+	for pageNum != 0 {
+		page, err := x.addPage(pageNum, true)
+		if err != nil {
+			return err
+		}
+		pageNum = int(binary.BigEndian.Uint32(page.RawData))
+	}
+	return nil
+}
+
+// addPage reads a page and gives it the next page number of the new file.
+// Only the header of B-Tree pages is parsed.
+func (x *extraction) addPage(pageNum int, overflow bool) (*Page, error) {
+	if _, ok := x.newPages[pageNum]; ok || pageNum == 1 {
+		return nil, newCorruptError(pageNum, -1, -1, CorruptTree, errors.New("page is referenced more than once"))
+	}
+	data, err := x.db.readPageData(pageNum)
+	if err != nil {
+		return nil, err
+	}
+	x.pages = append(x.pages, extractedPage{pageNum, overflow})
+	x.newPages[pageNum] = len(x.pages) + 1
+	if overflow {
+		return &Page{RawData: data}, nil
+	}
+	page, err := parsePageHeader(data, pageNum)
+	if err != nil {
+		return nil, err
+	}
+	switch page.Type {
+	case PageTypeLeafTable, PageTypeInteriorTable, PageTypeLeafIndex, PageTypeInteriorIndex:
+		return page, nil
+	}
+	return nil, unexpectedPageType(pageNum, page, "extraction")
+}
+
+// overflowPointer returns the offset within the page of the overflow page
+// number of cell i, or -1 if its payload is all stored on the page.
+func (x *extraction) overflowPointer(page *Page, i int) (int, error) {
+	return overflowPointer(page, i, x.usable)
+}
+
+// overflowPointer returns the offset within a page whose usable size is
+// usable of the overflow page number of cell i, or -1 if its payload is all
+// stored on the page. Only the header of the page needs to be parsed.
+func overflowPointer(page *Page, i, usable int) (int, error) {
+	offset := int(page.CellPointers[i])
+	data := page.RawData
+	switch page.Type {
+	case PageTypeInteriorTable:
+		return -1, nil
+	case PageTypeInteriorIndex:
+		offset += 4
+	}
+	if offset >= len(data) {
+		return 0, newCorruptError(page.pageNum, i, offset, CorruptCell, errors.New("cell extends beyond the page"))
+	}
+	payloadSize, n := readVarint(data[offset:])
+	offset += n
+	if page.Type == PageTypeLeafTable {
+		_, n := readVarint(data[offset:])
+		offset += n
+	}
+	local := localPayloadSize(payloadSize, usable, page.Type == PageTypeLeafTable)
+	if local == payloadSize {
+		return -1, nil
+	}
+	if offset+int(local)+4 > len(data) {
+		return 0, newCorruptError(page.pageNum, i, offset, CorruptPayload, errors.New("payload extends beyond the page"))
+	}
+	return offset + int(local), nil
+}
+
+// localPayloadSize returns how many bytes of a payload of the given size are
+// stored in a cell of a page whose usable size is usable, the rest spilling
+// to overflow pages, following section 1.6 of
+// https://www.sqlite.org/fileformat.html.
+func localPayloadSize(payloadSize int64, usable int, tableLeaf bool) int64 {
+	u := int64(usable)
+	maxLocal := (u-12)*64/255 - 23
+	if tableLeaf {
+		maxLocal = u - 35
+	}
+	if payloadSize <= maxLocal {
+		return payloadSize
+	}
+	minLocal := (u-12)*32/255 - 23
+	k := minLocal + (payloadSize-minLocal)%(u-4)
+	if k <= maxLocal {
+		return k
+	}
+	return minLocal
+}
+
+// rewritePage returns a copy of a page with the page numbers it holds
+// replaced by those of the new file.
+func (x *extraction) rewritePage(p extractedPage) ([]byte, error) {
+	data, err := x.db.readPageData(p.pageNum)
+	if err != nil {
+		return nil, err
+	}
+	data = append([]byte(nil), data...)
+	renumber := func(pos int) {
+		if old := int(binary.BigEndian.Uint32(data[pos:])); old != 0 {
+			binary.BigEndian.PutUint32(data[pos:], uint32(x.newPages[old]))
+		}
+	}
+	if p.overflow {
+		renumber(0)
+		return data, nil
+	}
+	page, err := parsePageHeader(data, p.pageNum)
+	if err != nil {
+		return nil, err
+	}
+	for i, cellOffset := range page.CellPointers {
+		if page.Type == PageTypeInteriorTable || page.Type == PageTypeInteriorIndex {
+			renumber(int(cellOffset))
+		}
+		pos, err := x.overflowPointer(page, i)
+		if err != nil {
+			return nil, err
+		}
+		if pos != -1 {
+			renumber(pos)
+		}
+	}
+	if page.Type == PageTypeInteriorTable || page.Type == PageTypeInteriorIndex {
+		renumber(8)
+	}
+	return data, nil
+}
+
+// firstPage returns page 1 of the new file, given that of db: the file
+// header, followed by sqlite_schema, which must fit on the page.
+func (x *extraction) firstPage(page1 []byte) ([]byte, error) {
+	data := make([]byte, len(page1))
+	copy(data, page1[:HeaderSize])
+	data[18], data[19] = 1, 1                                       // Rollback journal, as WAL mode is not copied.
+	binary.BigEndian.PutUint32(data[24:28], 1)                      // Change counter.
+	binary.BigEndian.PutUint32(data[28:32], uint32(len(x.pages)+1)) // Database size.
+	binary.BigEndian.PutUint32(data[32:36], 0)                      // Freelist trunk.
+	binary.BigEndian.PutUint32(data[36:40], 0)                      // Freelist pages.
+	binary.BigEndian.PutUint32(data[40:44], 1)                      // Schema cookie.
+	binary.BigEndian.PutUint32(data[52:56], 0)                      // No auto-vacuum.
+	binary.BigEndian.PutUint32(data[64:68], 0)                      // No incremental vacuum.
+	binary.BigEndian.PutUint32(data[92:96], 1)                      // Version valid for.
+
+	// Cells are stored from the end of the usable space, their pointers
+	// after the page header.
+	content := x.usable
+	pointer := HeaderSize + 8
+	for i, row := range x.schema {
+		rootPage := int64(0)
+		if row.rootPage > 0 {
+			rootPage = int64(x.newPages[row.rootPage])
+		}
+		record, err := EncodeRecord(Record{row.kind, row.name, row.tableName, rootPage, row.sql})
+		if err != nil {
+			return nil, err
+		}
+		if localPayloadSize(int64(len(record)), x.usable, true) != int64(len(record)) {
+			return nil, fmt.Errorf("schema of %s %s is too large for the first page", row.kind, row.name)
+		}
+		cell := appendVarint(nil, uint64(len(record)))
+		cell = appendVarint(cell, uint64(i+1))
+		cell = append(cell, record...)
+		content -= len(cell)
+		if content < pointer+2 {
+			return nil, errors.New("schema of the extracted tables is too large for the first page")
+		}
+		copy(data[content:], cell)
+		binary.BigEndian.PutUint16(data[pointer:], uint16(content))
+		pointer += 2
+	}
+	header := data[HeaderSize:]
+	header[0] = PageTypeLeafTable
+	binary.BigEndian.PutUint16(header[3:5], uint16(len(x.schema)))
+	binary.BigEndian.PutUint16(header[5:7], uint16(content))
+	return data, nil
+}
@@ -0,0 +1,80 @@
+package golite
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestExtractTables(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.sqlite")
+	runSQL(t, src, `
+		PRAGMA page_size = 1024;
+		CREATE TABLE a (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT UNIQUE, big BLOB);
+		CREATE INDEX a_big ON a(big);
+		CREATE TABLE b (x INTEGER, y TEXT);
+		CREATE INDEX b_y ON b(y);
+		CREATE TABLE w (k TEXT PRIMARY KEY, v TEXT) WITHOUT ROWID;
+		CREATE TRIGGER a_insert AFTER INSERT ON a BEGIN INSERT INTO w VALUES (new.name, 'trigger'); END;
+		CREATE VIEW v AS SELECT * FROM b;
+		WITH RECURSIVE seq(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM seq WHERE i < 2000)
+		INSERT INTO b SELECT i, 'y' || i FROM seq;
+		WITH RECURSIVE seq(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM seq WHERE i < 300)
+		INSERT INTO a (name, big) SELECT 'n' || i, randomblob(i * 10) FROM seq;
+		INSERT INTO w SELECT 'long' || value || printf('%.*c', value * 20, 'x'), value FROM generate_series(1, 100);
+		DELETE FROM b WHERE x % 3 = 0;
+	`)
+	db, err := Open(src)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+
+	dst := filepath.Join(dir, "dst.sqlite")
+	if err := db.ExtractTables(dst, "a", "w"); err != nil {
+		t.Fatalf("ExtractTables() failed: %v", err)
+	}
+	query := func(path, sql string) string {
+		t.Helper()
+		output, err := exec.Command("sqlite3", path, sql).CombinedOutput()
+		if err != nil {
+			t.Fatalf("sqlite3 failed: %v\nOutput: %s", err, output)
+		}
+		return string(output)
+	}
+	if got := query(dst, "PRAGMA integrity_check;"); got != "ok\n" {
+		t.Errorf("integrity_check = %q", got)
+	}
+	want := "a\na_big\na_insert\nsqlite_autoindex_a_1\nsqlite_sequence\nw\n"
+	if got := query(dst, "SELECT name FROM sqlite_schema ORDER BY name;"); got != want {
+		t.Errorf("schema = %q, want %q", got, want)
+	}
+	for _, sql := range []string{
+		"SELECT id, name, hex(big) FROM a ORDER BY id;",
+		"SELECT name FROM a INDEXED BY sqlite_autoindex_a_1 WHERE name > 'n2' ORDER BY name;",
+		"SELECT id FROM a INDEXED BY a_big WHERE big > x'80' ORDER BY big;",
+		"SELECT * FROM w ORDER BY k;",
+		"SELECT * FROM sqlite_sequence;",
+	} {
+		if got, want := query(dst, sql), query(src, sql); got != want {
+			t.Errorf("%s: results differ", sql)
+		}
+	}
+	// The copy is writable and its trigger fires.
+	if got := query(dst, "INSERT INTO a (name) VALUES ('new'); SELECT v FROM w WHERE k = 'new';"); got != "trigger\n" {
+		t.Errorf("trigger inserted %q", got)
+	}
+
+	if err := db.ExtractTables(dst, "b"); !os.IsExist(err) {
+		t.Errorf("ExtractTables() to an existing file: got %v, want an error matching os.IsExist", err)
+	}
+	other := filepath.Join(dir, "other.sqlite")
+	if err := db.ExtractTables(other, "nope"); err == nil {
+		t.Errorf("expected an error for an unknown table")
+	}
+	if _, err := os.Stat(other); !os.IsNotExist(err) {
+		t.Errorf("ExtractTables() left a file behind after an error")
+	}
+}
@@ -0,0 +1,116 @@
+package golite
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// ValidateFreeSpace checks that the page's cell content area is consistently
+// accounted for. It walks the freeblock chain, checking that freeblocks are in
+// ascending order, lie within the cell content area and overlap neither each
+// other nor any cell, and that the bytes covered by neither cells nor
+// freeblocks add up to the fragmented byte count in the page header. It
+// returns a *CorruptError with reason CorruptFreeSpace describing the first
+// inconsistency found.
+func (p *Page) ValidateFreeSpace() error {
+	data := p.RawData
+	contentStart := p.cellContentStart()
+	if contentStart < p.cellPointersEnd() || contentStart > len(data) {
+		return p.freeSpaceError(-1, p.headerOffset+5, "cell content area starts at %d, outside %d..%d", contentStart, p.cellPointersEnd(), len(data))
+	}
+
+	type extent struct {
+		start, end int
+		cell       int // -1 for freeblocks
+	}
+	var extents []extent
+
+	prev := 0
+	for fb := int(p.Freeblock); fb != 0; {
+		if fb < contentStart {
+			return p.freeSpaceError(-1, fb, "freeblock at %d is before the cell content area at %d", fb, contentStart)
+		}
+		if fb <= prev {
+			return p.freeSpaceError(-1, fb, "freeblock at %d is not after the previous freeblock at %d", fb, prev)
+		}
+		if fb+4 > len(data) {
+			return p.freeSpaceError(-1, fb, "freeblock at %d extends beyond the page", fb)
+		}
+		next := int(binary.BigEndian.Uint16(data[fb : fb+2]))
+		size := int(binary.BigEndian.Uint16(data[fb+2 : fb+4]))
+		if size < 4 || fb+size > len(data) {
+			return p.freeSpaceError(-1, fb, "freeblock at %d has invalid size %d", fb, size)
+		}
+		if next != 0 && next < fb+size {
+			return p.freeSpaceError(-1, fb, "freeblock at %d overlaps the next freeblock at %d", fb, next)
+		}
+		extents = append(extents, extent{start: fb, end: fb + size, cell: -1})
+		prev, fb = fb, next
+	}
+
+	for i, ptr := range p.CellPointers {
+		start := int(ptr)
+		if start < contentStart {
+			return p.freeSpaceError(i, start, "cell starts before the cell content area at %d", contentStart)
+		}
+		end := start + p.cellSize(start)
+		if end > len(data) {
+			return p.freeSpaceError(i, start, "cell extends beyond the page")
+		}
+		extents = append(extents, extent{start: start, end: end, cell: i})
+	}
+
+	sort.Slice(extents, func(i, j int) bool { return extents[i].start < extents[j].start })
+	unaccounted := 0
+	pos := contentStart
+	for _, e := range extents {
+		if e.start < pos {
+			return p.freeSpaceError(e.cell, e.start, "%s at %d overlaps the preceding cell or freeblock", extentName(e.cell), e.start)
+		}
+		unaccounted += e.start - pos
+		pos = e.end
+	}
+	unaccounted += len(data) - pos
+	if unaccounted != int(p.Fragmented) {
+		return p.freeSpaceError(-1, p.headerOffset+7, "fragmented byte count %d does not match %d unaccounted bytes", p.Fragmented, unaccounted)
+	}
+	return nil
+}
+
+// extentName names a cell content area extent in error messages.
+func extentName(cell int) string {
+	if cell < 0 {
+		return "freeblock"
+	}
+	return fmt.Sprintf("cell %d", cell)
+}
+
+// freeSpaceError returns a CorruptError for a free space inconsistency.
+func (p *Page) freeSpaceError(cell, offset int, format string, args ...any) error {
+	return newCorruptError(p.pageNum, cell, offset, CorruptFreeSpace, fmt.Errorf(format, args...))
+}
+
+// cellSize returns the number of bytes occupied by the cell at offset, which
+// must already have been validated by ParsePage. SQLite never allocates fewer
+// than 4 bytes for a cell.
+func (p *Page) cellSize(offset int) int {
+	data := p.RawData[offset:]
+	size := 0
+	switch p.Type {
+	case PageTypeLeafTable:
+		payloadSize, n := readVarint(data)
+		_, m := readVarint(data[n:])
+		size = n + m + int(payloadSize)
+	case PageTypeInteriorTable:
+		_, n := readVarint(data[4:])
+		size = 4 + n
+	case PageTypeLeafIndex:
+		payloadSize, n := readVarint(data)
+		size = n + int(payloadSize)
+	case PageTypeInteriorIndex:
+		payloadSize, n := readVarint(data[4:])
+		size = 4 + n + int(payloadSize)
+	}
+	return max(size, 4)
+}
@@ -0,0 +1,102 @@
+package golite
+
+import (
+	"encoding/binary"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPage_ValidateFreeSpace(t *testing.T) {
+	dbPath := createTestDB(t, "freespace_test.sqlite")
+	// Deleting scattered rows leaves freeblocks and fragments behind.
+	runSQL(t, dbPath, "DELETE FROM test WHERE id % 7 = 0; UPDATE test SET name = 'x' WHERE id % 11 = 0;")
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+
+	t.Run("valid pages", func(t *testing.T) {
+		pageCount, err := db.filePageCount()
+		if err != nil {
+			t.Fatalf("filePageCount() failed: %v", err)
+		}
+		freeblocks := 0
+		for pageNum := 1; pageNum <= pageCount; pageNum++ {
+			page, err := db.ReadPage(pageNum)
+			if err != nil {
+				t.Fatalf("ReadPage(%d) failed: %v", pageNum, err)
+			}
+			if _, ok := pageTypeNames[page.Type]; !ok {
+				continue
+			}
+			if err := page.ValidateFreeSpace(); err != nil {
+				t.Errorf("ValidateFreeSpace() on page %d returned an unexpected error: %v", pageNum, err)
+			}
+			freeblocks += len(page.freeblocks())
+		}
+		if freeblocks == 0 {
+			t.Errorf("expected the test database to contain freeblocks")
+		}
+	})
+
+	// leafWithFreeblock returns a copy of the first leaf table page of the
+	// test table that has a freeblock, with its freeblock offset.
+	leafWithFreeblock := func(t *testing.T) (*Page, int) {
+		t.Helper()
+		pageCount, _ := db.filePageCount()
+		for pageNum := 2; pageNum <= pageCount; pageNum++ {
+			page, err := db.ReadPage(pageNum)
+			if err == nil && page.Type == PageTypeLeafTable && page.Freeblock != 0 {
+				return page, int(page.Freeblock)
+			}
+		}
+		t.Fatal("no leaf table page with a freeblock found")
+		return nil, 0
+	}
+
+	testCases := []struct {
+		name    string
+		corrupt func(p *Page, fb int)
+		err     string
+	}{
+		{
+			name:    "wrong fragmented byte count",
+			corrupt: func(p *Page, fb int) { p.Fragmented += 2 },
+			err:     "does not match",
+		},
+		{
+			name: "freeblock overlapping a cell",
+			corrupt: func(p *Page, fb int) {
+				size := binary.BigEndian.Uint16(p.RawData[fb+2:])
+				binary.BigEndian.PutUint16(p.RawData[fb+2:], size+8)
+			},
+			err: "overlaps",
+		},
+		{
+			name:    "freeblock before content area",
+			corrupt: func(p *Page, fb int) { p.Freeblock = uint16(p.cellPointersEnd()) },
+			err:     "before the cell content area",
+		},
+		{
+			name:    "freeblock chain loops back",
+			corrupt: func(p *Page, fb int) { binary.BigEndian.PutUint16(p.RawData[fb:], uint16(fb)) },
+			err:     "overlaps the next freeblock",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			page, fb := leafWithFreeblock(t)
+			tc.corrupt(page, fb)
+			err := page.ValidateFreeSpace()
+			var corrupt *CorruptError
+			if !errors.As(err, &corrupt) || corrupt.Reason != CorruptFreeSpace {
+				t.Fatalf("expected a free space *CorruptError, got %v", err)
+			}
+			if corrupt.PageNum == 0 || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("expected error on a known page mentioning %q, got %v", tc.err, err)
+			}
+		})
+	}
+}
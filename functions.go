@@ -0,0 +1,410 @@
+package golite
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// ScalarFunc is a function that can be called by name with CallFunc. It
+// receives values of the types found in a Record and returns one of them.
+type ScalarFunc func(args []any) (any, error)
+
+// AggregateFunc accumulates the rows of a group: Step is called once per row
+// with the arguments computed for it, then Final returns the result.
+type AggregateFunc interface {
+	Step(args []any) error
+	Final() (any, error)
+}
+
+// funcKey identifies a function by its lower-case name and number of
+// arguments, -1 for a function accepting any number of them.
+type funcKey struct {
+	name  string
+	nargs int
+}
+
+// functions holds the registered scalar and aggregate functions, starting
+// with the built-in ones.
+var functions = struct {
+	mu         sync.RWMutex
+	scalars    map[funcKey]ScalarFunc
+	aggregates map[funcKey]func() AggregateFunc
+}{
+	scalars:    map[funcKey]ScalarFunc{},
+	aggregates: map[funcKey]func() AggregateFunc{},
+}
+
+// RegisterFunc makes fn callable as name with nargs arguments, or with any
+// number of arguments if nargs is -1. Names are case insensitive. As with
+// sqlite3_create_function, registering a function again replaces the
+// previous one with the same name and number of arguments, so built-in
+// functions can be overridden. RegisterFunc panics if fn is nil or nargs is
+// less than -1.
+func RegisterFunc(name string, nargs int, fn func(args []any) (any, error)) {
+	if fn == nil || nargs < -1 {
+		panic("golite: invalid registration of function " + name)
+	}
+	functions.mu.Lock()
+	defer functions.mu.Unlock()
+	functions.scalars[funcKey{strings.ToLower(name), nargs}] = fn
+}
+
+// RegisterAggregate makes an aggregate function available as name with
+// nargs arguments, or any number of arguments if nargs is -1. newFn is
+// called to create the state of each group. It panics if newFn is nil or
+// nargs is less than -1.
+func RegisterAggregate(name string, nargs int, newFn func() AggregateFunc) {
+	if newFn == nil || nargs < -1 {
+		panic("golite: invalid registration of aggregate " + name)
+	}
+	functions.mu.Lock()
+	defer functions.mu.Unlock()
+	functions.aggregates[funcKey{strings.ToLower(name), nargs}] = newFn
+}
+
+// LookupFunc returns the scalar function registered as name for the given
+// number of arguments, preferring one registered for exactly that number.
+func LookupFunc(name string, nargs int) (ScalarFunc, error) {
+	functions.mu.RLock()
+	defer functions.mu.RUnlock()
+	return lookupFunction(functions.scalars, name, nargs)
+}
+
+// CallFunc calls the scalar function registered as name with args.
+func CallFunc(name string, args ...any) (any, error) {
+	fn, err := LookupFunc(name, len(args))
+	if err != nil {
+		return nil, err
+	}
+	return fn(args)
+}
+
+// NewAggregate returns a fresh state of the aggregate function registered as
+// name for the given number of arguments.
+func NewAggregate(name string, nargs int) (AggregateFunc, error) {
+	functions.mu.RLock()
+	defer functions.mu.RUnlock()
+	newFn, err := lookupFunction(functions.aggregates, name, nargs)
+	if err != nil {
+		return nil, err
+	}
+	return newFn(), nil
+}
+
+func lookupFunction[F any](registry map[funcKey]F, name string, nargs int) (F, error) {
+	name = strings.ToLower(name)
+	if fn, ok := registry[funcKey{name, nargs}]; ok {
+		return fn, nil
+	}
+	if fn, ok := registry[funcKey{name, -1}]; ok {
+		return fn, nil
+	}
+	var zero F
+	for key := range registry {
+		if key.name == name {
+			return zero, fmt.Errorf("wrong number of arguments to function %s()", name)
+		}
+	}
+	return zero, fmt.Errorf("%w: %s", ErrNoSuchFunction, name)
+}
+
+// Aggregate feeds every record of input to fn, with the arguments computed
+// by args, and returns the final result.
+func Aggregate(input RecordIterator, fn AggregateFunc, args func(Record) ([]any, error)) (any, error) {
+	for record, err := range input {
+		if err != nil {
+			return nil, err
+		}
+		values, err := args(record)
+		if err != nil {
+			return nil, err
+		}
+		if err := fn.Step(values); err != nil {
+			return nil, err
+		}
+	}
+	return fn.Final()
+}
+
+func init() {
+	// Wrappers adapting the Go functions to ScalarFunc. The number of
+	// arguments is checked by the lookup.
+	unary := func(fn func(any) any) ScalarFunc {
+		return func(args []any) (any, error) { return fn(args[0]), nil }
+	}
+	binary := func(fn func(any, any) any) ScalarFunc {
+		return func(args []any) (any, error) { return fn(args[0], args[1]), nil }
+	}
+	optional := func(fn func(any, ...any) any) ScalarFunc {
+		return func(args []any) (any, error) { return fn(args[0], args[1:]...), nil }
+	}
+	dateFunc := func(fn func(any, ...string) any) ScalarFunc {
+		return func(args []any) (any, error) {
+			if len(args) == 0 {
+				return fn("now"), nil
+			}
+			modifiers, ok := textArgs(args[1:])
+			if !ok {
+				return SQLNull, nil
+			}
+			return fn(args[0], modifiers...), nil
+		}
+	}
+	jsonFunc := func(fn func(any, string) (any, error)) ScalarFunc {
+		return func(args []any) (any, error) {
+			path := "$"
+			if len(args) > 1 {
+				var ok bool
+				if path, ok = textValue(args[1]); !ok {
+					return SQLNull, nil
+				}
+			}
+			return fn(args[0], path)
+		}
+	}
+	builtins := []struct {
+		name  string
+		nargs []int
+		fn    ScalarFunc
+	}{
+		{"length", []int{1}, unary(Length)},
+		{"lower", []int{1}, unary(Lower)},
+		{"upper", []int{1}, unary(Upper)},
+		{"hex", []int{1}, unary(func(v any) any { return Hex(v) })},
+		{"typeof", []int{1}, unary(func(v any) any { return TypeOf(v) })},
+		{"substr", []int{2, 3}, substrFunc},
+		{"substring", []int{2, 3}, substrFunc},
+		{"trim", []int{1, 2}, optional(Trim)},
+		{"ltrim", []int{1, 2}, optional(LTrim)},
+		{"rtrim", []int{1, 2}, optional(RTrim)},
+		{"round", []int{1, 2}, optional(Round)},
+		{"abs", []int{1}, func(args []any) (any, error) { return Abs(args[0]) }},
+		{"coalesce", []int{-1}, func(args []any) (any, error) { return Coalesce(args...), nil }},
+		{"ifnull", []int{2}, binary(IfNull)},
+		{"nullif", []int{2}, binary(NullIf)},
+		{"instr", []int{2}, binary(Instr)},
+		{"printf", []int{-1}, printfFunc},
+		{"format", []int{-1}, printfFunc},
+		{"date", []int{-1}, dateFunc(Date)},
+		{"time", []int{-1}, dateFunc(Time)},
+		{"datetime", []int{-1}, dateFunc(DateTime)},
+		{"julianday", []int{-1}, dateFunc(JulianDay)},
+		{"unixepoch", []int{-1}, dateFunc(UnixEpoch)},
+		{"strftime", []int{-1}, func(args []any) (any, error) {
+			if len(args) == 0 {
+				return SQLNull, nil
+			}
+			format, ok := textValue(args[0])
+			if !ok {
+				return SQLNull, nil
+			}
+			return dateFunc(func(value any, modifiers ...string) any {
+				return Strftime(format, value, modifiers...)
+			})(args[1:])
+		}},
+		{"json_extract", []int{2}, jsonFunc(JSONExtract)},
+		{"json_type", []int{1, 2}, jsonFunc(JSONType)},
+		{"json_array_length", []int{1, 2}, jsonFunc(JSONArrayLength)},
+		{"like", []int{2, 3}, likeFunc},
+		{"glob", []int{2}, globFunc},
+	}
+	for _, builtin := range builtins {
+		for _, nargs := range builtin.nargs {
+			RegisterFunc(builtin.name, nargs, builtin.fn)
+		}
+	}
+
+	RegisterAggregate("count", 0, func() AggregateFunc { return &countAggregate{all: true} })
+	RegisterAggregate("count", 1, func() AggregateFunc { return &countAggregate{} })
+	RegisterAggregate("sum", 1, func() AggregateFunc { return &sumAggregate{} })
+	RegisterAggregate("total", 1, func() AggregateFunc { return &sumAggregate{total: true} })
+	RegisterAggregate("avg", 1, func() AggregateFunc { return &sumAggregate{avg: true} })
+	RegisterAggregate("min", 1, func() AggregateFunc { return &extremumAggregate{sign: -1} })
+	RegisterAggregate("max", 1, func() AggregateFunc { return &extremumAggregate{sign: 1} })
+	RegisterAggregate("group_concat", 1, func() AggregateFunc { return &concatAggregate{} })
+	RegisterAggregate("group_concat", 2, func() AggregateFunc { return &concatAggregate{} })
+}
+
+func substrFunc(args []any) (any, error) {
+	return Substr(args[0], args[1], args[2:]...), nil
+}
+
+func printfFunc(args []any) (any, error) {
+	if len(args) == 0 {
+		return SQLNull, nil
+	}
+	return Printf(args[0], args[1:]...), nil
+}
+
+// globFunc implements glob(pattern, value), which is X GLOB Y in function
+// form.
+func globFunc(args []any) (any, error) {
+	texts, ok := textArgs(args)
+	if !ok {
+		return SQLNull, nil
+	}
+	return boolValue(Glob(texts[0], texts[1])), nil
+}
+
+// likeFunc implements like(pattern, value[, escape]), which is X LIKE Y in
+// function form.
+func likeFunc(args []any) (any, error) {
+	texts, ok := textArgs(args)
+	if !ok {
+		return SQLNull, nil
+	}
+	var escape rune
+	if len(texts) > 2 {
+		if utf8.RuneCountInString(texts[2]) != 1 {
+			return nil, fmt.Errorf("ESCAPE expression must be a single character")
+		}
+		escape, _ = utf8.DecodeRuneInString(texts[2])
+	}
+	return boolValue(Like(texts[0], texts[1], escape)), nil
+}
+
+// textArgs converts arguments to TEXT, returning false if any is NULL.
+func textArgs(args []any) ([]string, bool) {
+	texts := make([]string, len(args))
+	for i, arg := range args {
+		var ok bool
+		if texts[i], ok = textValue(arg); !ok {
+			return nil, false
+		}
+	}
+	return texts, true
+}
+
+// boolValue returns the INTEGER SQLite uses for a boolean.
+func boolValue(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// countAggregate implements count(*), when all is set, and count(X).
+type countAggregate struct {
+	all bool
+	n   int64
+}
+
+func (a *countAggregate) Step(args []any) error {
+	if a.all || !isNull(args[0]) {
+		a.n++
+	}
+	return nil
+}
+
+func (a *countAggregate) Final() (any, error) { return a.n, nil }
+
+// sumAggregate implements sum(X), total(X) and avg(X). As in SQLite, sum
+// stays an INTEGER while all its inputs are and fails on overflow.
+type sumAggregate struct {
+	total, avg bool
+	n          int64
+	isum       int64
+	rsum       float64
+	isReal     bool
+}
+
+func (a *sumAggregate) Step(args []any) error {
+	v := args[0]
+	if isNull(v) {
+		return nil
+	}
+	a.n++
+	if s, ok := v.(string); ok {
+		if n, ok := parseNumeric(s); ok {
+			v = n
+		}
+	}
+	if i, ok := v.(int64); ok && !a.isReal {
+		if (i > 0 && a.isum > math.MaxInt64-i) || (i < 0 && a.isum < math.MinInt64-i) {
+			if !a.total && !a.avg {
+				return ErrIntegerOverflow
+			}
+			a.isReal, a.rsum = true, float64(a.isum)+float64(i)
+			return nil
+		}
+		a.isum += i
+		return nil
+	}
+	if !a.isReal {
+		a.isReal, a.rsum = true, float64(a.isum)
+	}
+	a.rsum += realValue(v)
+	return nil
+}
+
+func (a *sumAggregate) Final() (any, error) {
+	sum := a.rsum
+	if !a.isReal {
+		sum = float64(a.isum)
+	}
+	switch {
+	case a.total:
+		return sum, nil
+	case a.n == 0:
+		return SQLNull, nil
+	case a.avg:
+		return sum / float64(a.n), nil
+	case a.isReal:
+		return sum, nil
+	}
+	return a.isum, nil
+}
+
+// extremumAggregate implements min(X), when sign is -1, and max(X).
+type extremumAggregate struct {
+	sign  int
+	value any
+}
+
+func (a *extremumAggregate) Step(args []any) error {
+	v := args[0]
+	if !isNull(v) && (a.value == nil || compareValues(v, a.value)*a.sign > 0) {
+		a.value = v
+	}
+	return nil
+}
+
+func (a *extremumAggregate) Final() (any, error) {
+	if a.value == nil {
+		return SQLNull, nil
+	}
+	return a.value, nil
+}
+
+// concatAggregate implements group_concat(X[, separator]).
+type concatAggregate struct {
+	b       strings.Builder
+	started bool
+}
+
+func (a *concatAggregate) Step(args []any) error {
+	text, ok := textValue(args[0])
+	if !ok {
+		return nil
+	}
+	if a.started {
+		separator := ","
+		if len(args) > 1 {
+			separator, _ = textValue(args[1])
+		}
+		a.b.WriteString(separator)
+	}
+	a.started = true
+	a.b.WriteString(text)
+	return nil
+}
+
+func (a *concatAggregate) Final() (any, error) {
+	if !a.started {
+		return SQLNull, nil
+	}
+	return a.b.String(), nil
+}
@@ -0,0 +1,176 @@
+package golite
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCallFunc_Builtins(t *testing.T) {
+	tests := []struct {
+		name string
+		args []any
+		want any
+	}{
+		{"LENGTH", []any{"héllo"}, int64(5)},
+		{"substr", []any{"hello", int64(2)}, "ello"},
+		{"substr", []any{"hello", int64(2), int64(2)}, "el"},
+		{"coalesce", []any{SQLNull, SQLNull, int64(3)}, int64(3)},
+		{"printf", []any{"%05.1f", 3.14159}, "003.1"},
+		{"date", []any{"2024-01-31", "+1 month"}, "2024-03-02"},
+		{"strftime", []any{"%Y", int64(0), "unixepoch"}, "1970"},
+		{"date", []any{"2024-01-31", SQLNull}, SQLNull},
+		{"json_extract", []any{`{"a":[1,2]}`, "$.a[1]"}, int64(2)},
+		{"json_type", []any{`{"a":1}`}, "object"},
+		{"like", []any{"h_l%", "HELLO"}, int64(1)},
+		{"like", []any{"a!%", "a%", "!"}, int64(1)},
+		{"glob", []any{"h*", "HELLO"}, int64(0)},
+		{"glob", []any{"h*", SQLNull}, SQLNull},
+	}
+	for _, test := range tests {
+		got, err := CallFunc(test.name, test.args...)
+		if err != nil || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("CallFunc(%q, %#v) = (%#v, %v), want %#v", test.name, test.args, got, err, test.want)
+		}
+	}
+
+	if _, err := CallFunc("no_such_function", int64(1)); !errors.Is(err, ErrNoSuchFunction) {
+		t.Errorf("expected ErrNoSuchFunction, got %v", err)
+	}
+	if _, err := CallFunc("length"); err == nil || errors.Is(err, ErrNoSuchFunction) {
+		t.Errorf("expected a wrong number of arguments error, got %v", err)
+	}
+}
+
+func TestRegisterFunc(t *testing.T) {
+	RegisterFunc("test_reverse", 1, func(args []any) (any, error) {
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, errors.New("test_reverse expects TEXT")
+		}
+		runes := []rune(s)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		return string(runes), nil
+	})
+	RegisterFunc("test_count_args", -1, func(args []any) (any, error) {
+		return int64(len(args)), nil
+	})
+	RegisterFunc("test_count_args", 2, func(args []any) (any, error) {
+		return "two", nil
+	})
+
+	if got, err := CallFunc("Test_Reverse", "abc"); err != nil || got != "cba" {
+		t.Errorf("CallFunc(test_reverse) = (%#v, %v)", got, err)
+	}
+	if _, err := CallFunc("test_reverse", int64(1)); err == nil {
+		t.Errorf("expected the error of the function to be returned")
+	}
+	if got, err := CallFunc("test_count_args", int64(1), int64(2), int64(3)); err != nil || got != int64(3) {
+		t.Errorf("CallFunc(test_count_args) with 3 arguments = (%#v, %v)", got, err)
+	}
+	if got, err := CallFunc("test_count_args", int64(1), int64(2)); err != nil || got != "two" {
+		t.Errorf("expected the function registered for exactly 2 arguments, got (%#v, %v)", got, err)
+	}
+}
+
+// productAggregate multiplies its INTEGER arguments.
+type productAggregate struct{ product int64 }
+
+func (a *productAggregate) Step(args []any) error {
+	if i, ok := args[0].(int64); ok {
+		a.product *= i
+	}
+	return nil
+}
+
+func (a *productAggregate) Final() (any, error) { return a.product, nil }
+
+func TestAggregate(t *testing.T) {
+	// SQLite computes the expected results of the built-in aggregates.
+	dbPath := filepath.Join(t.TempDir(), "aggregate.sqlite")
+	runSQL(t, dbPath, `
+		CREATE TABLE vals (g INTEGER, v BLOB);
+		INSERT INTO vals VALUES (1, 1), (1, 2), (1, NULL), (1, 4),
+			(2, 1.5), (2, 2), (2, 'x'), (3, NULL), (4, 'a'), (4, 'c'), (4, 'b'), (4, '12'),
+			(5, 9223372036854775807), (5, 1);
+		CREATE TABLE results (g INTEGER, count_all BLOB, count BLOB, sum BLOB, total BLOB,
+			avg BLOB, min BLOB, max BLOB, group_concat BLOB);
+		INSERT INTO results SELECT g, count(*), count(v),
+			CASE WHEN g = 5 THEN 'overflow' ELSE sum(iif(g = 5, NULL, v)) END,
+			total(v), avg(v), min(v), max(v), group_concat(v, ';')
+			FROM vals GROUP BY g;
+	`)
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	vals, err := db.Table("vals")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+	results, err := db.Table("results")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+
+	count := 0
+	for record, err := range db.TableScan(results) {
+		if err != nil {
+			t.Fatalf("TableScan() failed: %v", err)
+		}
+		count++
+		want := results.ColumnValues(record)
+		group := want[0].(int64)
+		rows := Filter(db.TableScan(vals), func(r Record) (bool, error) {
+			return vals.ColumnValues(r)[0] == group, nil
+		})
+		for i, col := range results.Columns[1:] {
+			name, nargs := col.Name, 1
+			if name == "count_all" {
+				name, nargs = "count", 0
+			} else if name == "group_concat" {
+				nargs = 2
+			}
+			fn, err := NewAggregate(name, nargs)
+			if err != nil {
+				t.Fatalf("NewAggregate(%q, %d) failed: %v", name, nargs, err)
+			}
+			got, err := Aggregate(rows, fn, func(r Record) ([]any, error) {
+				return []any{vals.ColumnValues(r)[1], ";"}[:nargs], nil
+			})
+			if want[i+1] == "overflow" {
+				if !errors.Is(err, ErrIntegerOverflow) {
+					t.Errorf("%s() for group %d: expected ErrIntegerOverflow, got %v", name, group, err)
+				}
+				continue
+			}
+			if err != nil || !reflect.DeepEqual(got, want[i+1]) {
+				t.Errorf("%s() for group %d = (%#v, %v), want %#v", name, group, got, err, want[i+1])
+			}
+		}
+	}
+	if count == 0 {
+		t.Fatalf("expected results to compare against")
+	}
+
+	RegisterAggregate("test_product", 1, func() AggregateFunc { return &productAggregate{product: 1} })
+	fn, err := NewAggregate("TEST_PRODUCT", 1)
+	if err != nil {
+		t.Fatalf("NewAggregate() failed: %v", err)
+	}
+	got, err := Aggregate(numbers(5, nil), fn, func(r Record) ([]any, error) { return []any{r[0]}, nil })
+	if err != nil || got != int64(120) {
+		t.Errorf("Aggregate(test_product) = (%#v, %v), want 120", got, err)
+	}
+	if _, err := NewAggregate("length", 1); !errors.Is(err, ErrNoSuchFunction) {
+		t.Errorf("expected scalar functions not to be aggregates, got %v", err)
+	}
+	if _, err := Aggregate(numbers(0, errors.New("boom")), &countAggregate{}, nil); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("expected the input error, got %v", err)
+	}
+}
@@ -0,0 +1,248 @@
+package golite
+
+import (
+	"encoding/binary"
+	"hash/maphash"
+	"math"
+)
+
+// KeyFunc extracts the join key of a record, for HashJoin.
+type KeyFunc func(record Record) (Record, error)
+
+// HashTable holds the records of the build side of a hash join, grouped by
+// join key. It is built by BuildHashTable and probed by HashJoin. It is safe
+// for concurrent reads.
+type HashTable struct {
+	seed    maphash.Seed
+	buckets map[uint64][]hashEntry
+	bloom   *BloomFilter
+	n       int
+}
+
+// hashEntry is a record of a HashTable with its join key.
+type hashEntry struct {
+	key    Record
+	record Record
+}
+
+// BuildHashTable reads all the records of build into a HashTable, keyed by
+// key. Records whose key holds a NULL are left out, as they cannot be equal
+// to any key. Keys are compared as CompareRecords does, so that for instance
+// the INTEGER 1 and the REAL 1.0 are the same key.
+//
+// If bloom is true, a BloomFilter of the keys is also built. HashJoin then
+// probes it before looking keys up, and it can be pushed down to the probe
+// side with its Filter method to drop records before they are joined. This
+// pays off when most probe records have no match.
+func BuildHashTable(build RecordIterator, key KeyFunc, bloom bool) (*HashTable, error) {
+	h := &HashTable{seed: maphash.MakeSeed(), buckets: map[uint64][]hashEntry{}}
+	var hashes []uint64
+	for record, err := range build {
+		if err != nil {
+			return nil, err
+		}
+		k, err := key(record)
+		if err != nil {
+			return nil, err
+		}
+		if hasNull(k) {
+			continue
+		}
+		hash := h.hash(k)
+		h.buckets[hash] = append(h.buckets[hash], hashEntry{key: k, record: record})
+		h.n++
+		if bloom {
+			hashes = append(hashes, hash)
+		}
+	}
+	if bloom {
+		h.bloom = newBloomFilter(h.seed, len(hashes))
+		for _, hash := range hashes {
+			h.bloom.addHash(hash)
+		}
+	}
+	return h, nil
+}
+
+// Len returns the number of records in the hash table.
+func (h *HashTable) Len() int {
+	return h.n
+}
+
+// BloomFilter returns the Bloom filter of the keys of the hash table, or nil
+// if it was built without one.
+func (h *HashTable) BloomFilter() *BloomFilter {
+	return h.bloom
+}
+
+// Lookup returns the records of the hash table whose key equals key.
+func (h *HashTable) Lookup(key Record) []Record {
+	if hasNull(key) {
+		return nil
+	}
+	return h.lookupHash(key, h.hash(key))
+}
+
+// lookupHash is Lookup for a key whose hash is already known.
+func (h *HashTable) lookupHash(key Record, hash uint64) []Record {
+	var records []Record
+	for _, entry := range h.buckets[hash] {
+		if len(entry.key) == len(key) && CompareRecords(entry.key, key) == 0 {
+			records = append(records, entry.record)
+		}
+	}
+	return records
+}
+
+// hash returns the hash of a join key.
+func (h *HashTable) hash(key Record) uint64 {
+	return hashKey(h.seed, key)
+}
+
+// HashJoin returns an iterator implementing a hash join: for each record of
+// probe, it yields the concatenation of that record with each record of the
+// table whose key equals key(record), in the order of probe. If the table has
+// a Bloom filter, records whose key it rules out are dropped without looking
+// them up. An error from probe or key ends the iteration.
+func HashJoin(probe RecordIterator, table *HashTable, key KeyFunc) RecordIterator {
+	return func(yield func(Record, error) bool) {
+		for left, err := range probe {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			k, err := key(left)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if hasNull(k) {
+				continue
+			}
+			hash := table.hash(k)
+			if table.bloom != nil && !table.bloom.containsHash(hash) {
+				continue
+			}
+			for _, right := range table.lookupHash(k, hash) {
+				joined := make(Record, 0, len(left)+len(right))
+				if !yield(append(append(joined, left...), right...), nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// BloomFilter is a compact summary of a set of join keys, answering whether
+// a key may be in the set. It has no false negatives, and about 1% of false
+// positives. It is safe for concurrent reads.
+type BloomFilter struct {
+	seed   maphash.Seed
+	bits   []uint64
+	hashes int
+}
+
+// bloomBitsPerKey and bloomHashes give a false positive rate of about 1%.
+const (
+	bloomBitsPerKey = 10
+	bloomHashes     = 7
+)
+
+// newBloomFilter returns an empty Bloom filter sized for n keys.
+func newBloomFilter(seed maphash.Seed, n int) *BloomFilter {
+	words := max(1, (n*bloomBitsPerKey+63)/64)
+	return &BloomFilter{seed: seed, bits: make([]uint64, words), hashes: bloomHashes}
+}
+
+// MayContain reports whether key may be one of the keys of the filter. It is
+// false for keys holding a NULL.
+func (f *BloomFilter) MayContain(key Record) bool {
+	return !hasNull(key) && f.containsHash(hashKey(f.seed, key))
+}
+
+// Filter returns an iterator over the records of input whose key, as returned
+// by key, may be in the filter. Applied to the probe side of a HashJoin, for
+// instance right after a scan, it drops most of the records that would not
+// be joined before any further work is done on them.
+func (f *BloomFilter) Filter(input RecordIterator, key KeyFunc) RecordIterator {
+	return Filter(input, func(record Record) (bool, error) {
+		k, err := key(record)
+		if err != nil {
+			return false, err
+		}
+		return f.MayContain(k), nil
+	})
+}
+
+// addHash adds a key, given by its hash, to the filter. The bits to set are
+// derived from the two halves of the hash by double hashing.
+func (f *BloomFilter) addHash(hash uint64) {
+	n := uint64(len(f.bits)) * 64
+	h1, h2 := hash&0xffffffff, hash>>32|1
+	for i := range uint64(f.hashes) {
+		bit := (h1 + i*h2) % n
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// containsHash reports whether a key, given by its hash, may have been added.
+func (f *BloomFilter) containsHash(hash uint64) bool {
+	n := uint64(len(f.bits)) * 64
+	h1, h2 := hash&0xffffffff, hash>>32|1
+	for i := range uint64(f.hashes) {
+		bit := (h1 + i*h2) % n
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// hashKey hashes a join key so that keys equal according to CompareRecords
+// have the same hash. A REAL with an integer value is hashed as that INTEGER.
+func hashKey(seed maphash.Seed, key Record) uint64 {
+	var h maphash.Hash
+	h.SetSeed(seed)
+	var buf [9]byte
+	for _, value := range key {
+		switch v := value.(type) {
+		case int64:
+			buf[0] = 1
+			binary.LittleEndian.PutUint64(buf[1:], uint64(v))
+			h.Write(buf[:])
+		case float64:
+			if v == math.Trunc(v) && v >= math.MinInt64 && v < math.MaxInt64 {
+				buf[0] = 1
+				binary.LittleEndian.PutUint64(buf[1:], uint64(int64(v)))
+			} else {
+				buf[0] = 2
+				binary.LittleEndian.PutUint64(buf[1:], math.Float64bits(v))
+			}
+			h.Write(buf[:])
+		case string:
+			buf[0] = 3
+			binary.LittleEndian.PutUint64(buf[1:], uint64(len(v)))
+			h.Write(buf[:])
+			h.WriteString(v)
+		case []byte:
+			buf[0] = 4
+			binary.LittleEndian.PutUint64(buf[1:], uint64(len(v)))
+			h.Write(buf[:])
+			h.Write(v)
+		default:
+			// Values of other types all compare equal.
+			h.WriteByte(5)
+		}
+	}
+	return h.Sum64()
+}
+
+// hasNull reports whether a key holds a NULL.
+func hasNull(key Record) bool {
+	for _, value := range key {
+		if isNull(value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package golite
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// firstColumn is a KeyFunc returning the first column of a record.
+func firstColumn(record Record) (Record, error) {
+	return record[:1], nil
+}
+
+func TestHashJoin(t *testing.T) {
+	build := func(yield func(Record, error) bool) {
+		for _, r := range []Record{
+			{int64(2), "two"}, {3.0, "three"}, {"2", "text"}, {SQLNull, "null"},
+			{int64(2), "deux"}, {2.5, "two and a half"}, {[]byte("2"), "blob"},
+		} {
+			if !yield(r, nil) {
+				return
+			}
+		}
+	}
+	probe := func(yield func(Record, error) bool) {
+		for _, r := range []Record{{2.0}, {int64(3)}, {SQLNull}, {"2"}, {int64(4)}, {2.5}} {
+			if !yield(r, nil) {
+				return
+			}
+		}
+	}
+	want := []Record{
+		{2.0, int64(2), "two"}, {2.0, int64(2), "deux"},
+		{int64(3), 3.0, "three"},
+		{"2", "2", "text"},
+		{2.5, 2.5, "two and a half"},
+	}
+	for _, bloom := range []bool{false, true} {
+		table, err := BuildHashTable(build, firstColumn, bloom)
+		if err != nil {
+			t.Fatalf("BuildHashTable() failed: %v", err)
+		}
+		if table.Len() != 6 {
+			t.Errorf("expected 6 records with a non-NULL key, got %d", table.Len())
+		}
+		if (table.BloomFilter() != nil) != bloom {
+			t.Errorf("BloomFilter() = %v with bloom = %v", table.BloomFilter(), bloom)
+		}
+		got, err := CollectRecords(HashJoin(probe, table, firstColumn))
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("HashJoin() with bloom = %v = (%v, %v), want %v", bloom, got, err, want)
+		}
+		if got := table.Lookup(Record{[]byte("2")}); len(got) != 1 || got[0][1] != "blob" {
+			t.Errorf("Lookup(blob) = %v", got)
+		}
+		if got := table.Lookup(Record{SQLNull}); got != nil {
+			t.Errorf("Lookup(NULL) = %v, want nothing", got)
+		}
+	}
+
+	errBoom := errors.New("boom")
+	if _, err := BuildHashTable(numbers(3, errBoom), firstColumn, true); !errors.Is(err, errBoom) {
+		t.Errorf("expected the build error, got %v", err)
+	}
+	table, _ := BuildHashTable(numbers(3, nil), firstColumn, false)
+	if _, err := CollectRecords(HashJoin(numbers(5, errBoom), table, firstColumn)); !errors.Is(err, errBoom) {
+		t.Errorf("expected the probe error, got %v", err)
+	}
+	got, err := collectInts(Take(HashJoin(numbers(5, nil), table, firstColumn), 2))
+	if err != nil || !reflect.DeepEqual(got, []int64{1, 2}) {
+		t.Errorf("expected to stop after 2 records, got (%v, %v)", got, err)
+	}
+}
+
+func TestBloomFilter(t *testing.T) {
+	const n = 10000
+	table, err := BuildHashTable(Filter(numbers(2*n, nil), func(r Record) (bool, error) {
+		return r[0].(int64)%2 == 0, nil
+	}), firstColumn, true)
+	if err != nil {
+		t.Fatalf("BuildHashTable() failed: %v", err)
+	}
+	bloom := table.BloomFilter()
+	falsePositives := 0
+	for i := int64(1); i <= 2*n; i++ {
+		switch {
+		case i%2 == 0 && !bloom.MayContain(Record{i}):
+			t.Fatalf("MayContain(%d) = false for a key of the filter", i)
+		case i%2 == 0 && !bloom.MayContain(Record{float64(i)}):
+			t.Fatalf("MayContain(%d.0) = false for a key of the filter", i)
+		case i%2 == 1 && bloom.MayContain(Record{i}):
+			falsePositives++
+		}
+	}
+	if rate := float64(falsePositives) / n; rate > 0.03 {
+		t.Errorf("false positive rate %.3f, expected about 0.01", rate)
+	}
+	if bloom.MayContain(Record{SQLNull}) {
+		t.Errorf("expected NULL keys to be ruled out")
+	}
+
+	got, err := collectInts(bloom.Filter(numbers(2*n, nil), firstColumn))
+	if err != nil {
+		t.Fatalf("Filter() failed: %v", err)
+	}
+	if len(got) != n+falsePositives {
+		t.Errorf("Filter() kept %d records, want %d", len(got), n+falsePositives)
+	}
+	joined, err := CollectRecords(HashJoin(bloom.Filter(numbers(2*n, nil), firstColumn), table, firstColumn))
+	if err != nil || len(joined) != n {
+		t.Errorf("HashJoin() after Filter() = (%d records, %v), want %d records", len(joined), err, n)
+	}
+}
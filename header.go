@@ -13,12 +13,25 @@ const (
 	HeaderSize = 100
 )
 
+// wal2Version is the read and write version of databases in the WAL2
+// journal mode.
+const wal2Version = 3
+
 // Header represents the parsed 100-byte header of an SQLite database file.
 // It contains key metadata about the database structure.
 type Header struct {
-	// PageSize is the database page size in bytes. Must be a power of two
-	// between 512 and 65536 inclusive.
-	PageSize uint16
+	// PageSize is the database page size in bytes, a power of two between
+	// 512 and 65536 inclusive. The file stores 65536 as 1.
+	PageSize uint32
+	// WriteVersion is the file format write version. 1 for legacy, 2 for WAL,
+	// 3 for WAL2.
+	WriteVersion byte
+	// ReadVersion is the file format read version. 1 for legacy, 2 for WAL,
+	// 3 for WAL2.
+	ReadVersion byte
+	// ReservedSpace is the number of bytes reserved at the end of each page
+	// for use by extensions. The usable size of a page is PageSize minus it.
+	ReservedSpace byte
 	// ChangeCounter is the file change counter.
 	ChangeCounter uint32
 	// DatabaseSize is the size of the database file in pages.
@@ -33,26 +46,69 @@ type Header struct {
 	SchemaFormat uint32
 	// DefaultCacheSize is the suggested default page cache size in bytes.
 	DefaultCacheSize uint32
+	// LargestRootPage is the number of the largest root page in auto-vacuum
+	// and incremental-vacuum databases, and 0 otherwise. Databases in which
+	// it is not 0 have pointer-map pages.
+	LargestRootPage uint32
 	// TextEncoding defines the text encoding used by the database.
 	// 1: UTF-8, 2: UTF-16le, 3: UTF-16be.
 	TextEncoding uint32
 	// UserVersion is the "user version" number, read and set by the user_version pragma.
 	UserVersion uint32
+	// VersionValidFor is the value of ChangeCounter when SQLiteVersion was
+	// last stored. A mismatch means an older SQLite modified the file.
+	VersionValidFor uint32
+	// SQLiteVersion is the SQLITE_VERSION_NUMBER of the library that most
+	// recently modified the file, e.g. 3045001 for 3.45.1.
+	SQLiteVersion uint32
+}
+
+// DatabaseSizeValid reports whether DatabaseSize can be trusted. Versions of
+// SQLite before 3.7.0 did not maintain it, so it is only valid if it is
+// non-zero and the change counter matches VersionValidFor; otherwise the
+// size of the database must be derived from the size of the file.
+func (h *Header) DatabaseSizeValid() bool {
+	return h.DatabaseSize != 0 && h.ChangeCounter == h.VersionValidFor
 }
 
 // ParseHeader reads the 100-byte header data and returns a parsed Header struct.
-// It returns an error if the data is not a valid SQLite header.
+// It returns a *CorruptError if the data is not a valid SQLite header, an
+// error matching ErrEncrypted if it looks like an encrypted database and an
+// *UnsupportedFeatureError if the file format is too recent to be read.
 func ParseHeader(data []byte) (*Header, error) {
 	if len(data) != HeaderSize {
-		return nil, fmt.Errorf("invalid header size: expected %d bytes, got %d", HeaderSize, len(data))
+		return nil, newCorruptError(1, -1, 0, CorruptFileHeader, fmt.Errorf("invalid header size: expected %d bytes, got %d", HeaderSize, len(data)))
 	}
 
 	if string(data[0:16]) != HeaderString {
-		return nil, errors.New("invalid SQLite header string")
+		if looksEncrypted(data) {
+			return nil, ErrEncrypted
+		}
+		return nil, newCorruptError(1, -1, 0, CorruptFileHeader, errors.New("invalid SQLite header string"))
+	}
+	switch readVersion := data[19]; {
+	case readVersion == wal2Version:
+		// The wal2 journal mode of the bedrock and wal2 branches of SQLite
+		// alternates between two WAL files. Reading the main file alone
+		// would miss the frames they hold.
+		return nil, unsupported("WAL2 journal mode")
+	case readVersion > 2:
+		return nil, unsupported(fmt.Sprintf("file format read version %d", readVersion))
+	}
+
+	pageSize := uint32(binary.BigEndian.Uint16(data[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize > 65536 || pageSize&(pageSize-1) != 0 {
+		return nil, newCorruptError(1, -1, 16, CorruptFileHeader, fmt.Errorf("invalid page size %d", pageSize))
 	}
 
 	h := &Header{
-		PageSize:         binary.BigEndian.Uint16(data[16:18]),
+		PageSize:         pageSize,
+		WriteVersion:     data[18],
+		ReadVersion:      data[19],
+		ReservedSpace:    data[20],
 		ChangeCounter:    binary.BigEndian.Uint32(data[24:28]),
 		DatabaseSize:     binary.BigEndian.Uint32(data[28:32]),
 		FreelistTrunk:    binary.BigEndian.Uint32(data[32:36]),
@@ -60,9 +116,62 @@ func ParseHeader(data []byte) (*Header, error) {
 		SchemaCookie:     binary.BigEndian.Uint32(data[40:44]),
 		SchemaFormat:     binary.BigEndian.Uint32(data[44:48]),
 		DefaultCacheSize: binary.BigEndian.Uint32(data[48:52]),
+		LargestRootPage:  binary.BigEndian.Uint32(data[52:56]),
 		TextEncoding:     binary.BigEndian.Uint32(data[56:60]),
 		UserVersion:      binary.BigEndian.Uint32(data[60:64]),
+		VersionValidFor:  binary.BigEndian.Uint32(data[92:96]),
+		SQLiteVersion:    binary.BigEndian.Uint32(data[96:100]),
 	}
 
 	return h, nil
 }
+
+// looksEncrypted reports whether the header data, which does not start with
+// the SQLite header string, looks random. Encryption extensions encrypt the
+// whole first page, header included, while other files rarely use more than a
+// few dozen distinct byte values in their first 100 bytes.
+func looksEncrypted(data []byte) bool {
+	var seen [256]bool
+	distinct := 0
+	for _, b := range data {
+		if !seen[b] {
+			seen[b] = true
+			distinct++
+		}
+	}
+	return distinct >= 60
+}
+
+// emptyHeader returns the header SQLite would write when creating a new
+// database with default settings. It describes zero-length database files,
+// which SQLite treats as empty databases.
+func emptyHeader() *Header {
+	return &Header{
+		PageSize:     4096,
+		WriteVersion: 1,
+		ReadVersion:  1,
+		DatabaseSize: 1,
+		SchemaFormat: 4,
+		TextEncoding: 1,
+	}
+}
+
+// emptyPage1 returns the contents of page 1 of an empty database with the
+// given header: the file header followed by an empty leaf table page.
+func emptyPage1(h *Header) []byte {
+	data := make([]byte, h.PageSize)
+	copy(data, HeaderString)
+	pageSize := uint16(h.PageSize)
+	if h.PageSize == 65536 {
+		pageSize = 1
+	}
+	binary.BigEndian.PutUint16(data[16:18], pageSize)
+	data[18], data[19] = h.WriteVersion, h.ReadVersion
+	data[21], data[22], data[23] = 64, 32, 32 // Payload fractions.
+	binary.BigEndian.PutUint32(data[28:32], h.DatabaseSize)
+	binary.BigEndian.PutUint32(data[44:48], h.SchemaFormat)
+	binary.BigEndian.PutUint32(data[56:60], h.TextEncoding)
+	data[HeaderSize] = PageTypeLeafTable
+	binary.BigEndian.PutUint16(data[HeaderSize+5:HeaderSize+7], uint16(h.PageSize)) // 65536 is stored as 0.
+	return data
+}
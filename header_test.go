@@ -1,6 +1,9 @@
 package golite
 
 import (
+	"encoding/binary"
+	"errors"
+	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -74,6 +77,15 @@ func TestParseHeader(t *testing.T) {
 		if header.DatabaseSize == 0 {
 			t.Error("expected DatabaseSize to be > 0, got 0")
 		}
+
+		// A modern SQLite keeps the version-valid-for number in step with the
+		// change counter, which makes DatabaseSize trustworthy.
+		if header.VersionValidFor != header.ChangeCounter || !header.DatabaseSizeValid() {
+			t.Errorf("expected a valid DatabaseSize, got VersionValidFor %d and ChangeCounter %d", header.VersionValidFor, header.ChangeCounter)
+		}
+		if header.SQLiteVersion < 3007000 {
+			t.Errorf("expected SQLiteVersion >= 3007000, got %d", header.SQLiteVersion)
+		}
 	})
 
 	t.Run("invalid header size", func(t *testing.T) {
@@ -83,6 +95,40 @@ func TestParseHeader(t *testing.T) {
 		}
 	})
 
+	t.Run("page size 65536", func(t *testing.T) {
+		data := make([]byte, HeaderSize)
+		copy(data, HeaderString)
+		binary.BigEndian.PutUint16(data[16:18], 1)
+		header, err := ParseHeader(data)
+		if err != nil {
+			t.Fatalf("ParseHeader() failed with error: %v", err)
+		}
+		if header.PageSize != 65536 {
+			t.Errorf("expected PageSize 65536, got %d", header.PageSize)
+		}
+	})
+
+	for _, pageSize := range []uint16{0, 1000, 256} {
+		t.Run(fmt.Sprintf("invalid page size %d", pageSize), func(t *testing.T) {
+			data := make([]byte, HeaderSize)
+			copy(data, HeaderString)
+			binary.BigEndian.PutUint16(data[16:18], pageSize)
+			data[18], data[19] = 1, 1
+			path := filepath.Join(t.TempDir(), "bad.sqlite")
+			if err := os.WriteFile(path, data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			db, err := Open(path)
+			if err == nil {
+				db.Close()
+				t.Fatal("Open() succeeded, want an error")
+			}
+			if !errors.Is(err, ErrCorrupt) {
+				t.Errorf("Open() error = %v, want ErrCorrupt", err)
+			}
+		})
+	}
+
 	t.Run("invalid header string", func(t *testing.T) {
 		invalidData := make([]byte, HeaderSize)
 		copy(invalidData, []byte("This is not SQLite"))
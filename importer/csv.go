@@ -0,0 +1,97 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/arnodel/golite"
+)
+
+// CSV returns the records of the table read from r, one per CSV record. The
+// fields are TEXT, converted by the affinity of their column, unless they
+// are the null string set by WithNull. A record with the wrong number of
+// fields is an error of its line.
+func CSV(r io.Reader, table golite.TableInfo, opts ...Option) golite.RecordIterator {
+	o := buildOptions(opts)
+	return func(yield func(golite.Record, error) bool) {
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		c := &converter{table: table}
+		// columns holds the column of each field.
+		columns := make([]int, len(table.Columns))
+		for i := range columns {
+			columns[i] = i
+		}
+		if o.header {
+			names, err := cr.Read()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			columns = columns[:0]
+			for _, name := range names {
+				i := lookupColumn(table, name)
+				if i == -1 {
+					yield(nil, fmt.Errorf("table %s has no column named %s", table.Name, name))
+					return
+				}
+				columns = append(columns, i)
+			}
+		}
+		for {
+			fields, err := cr.Read()
+			if err == io.EOF {
+				return
+			}
+			var line int
+			if fields != nil {
+				line, _ = cr.FieldPos(0)
+			}
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				line, err = parseErr.StartLine, parseErr.Err
+			} else if err != nil {
+				yield(nil, err)
+				return
+			}
+			var record golite.Record
+			if err == nil {
+				record, err = csvRecord(fields, columns, table, o)
+			}
+			if err == nil {
+				err = c.convert(record)
+			}
+			if err != nil {
+				if err = o.onError(&LineError{Line: line, Err: err}); err != nil {
+					yield(nil, err)
+					return
+				}
+				continue
+			}
+			if !yield(record, nil) {
+				return
+			}
+		}
+	}
+}
+
+// csvRecord returns the record of the table holding the fields of a CSV
+// record, columns holding the column of each field.
+func csvRecord(fields []string, columns []int, table golite.TableInfo, o options) (golite.Record, error) {
+	if len(fields) != len(columns) {
+		return nil, fmt.Errorf("expected %d fields but found %d", len(columns), len(fields))
+	}
+	record := make(golite.Record, len(table.Columns))
+	for i, field := range fields {
+		if o.nullIsSet && field == o.null {
+			continue
+		}
+		record[columns[i]] = field
+	}
+	return record, nil
+}
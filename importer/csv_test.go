@@ -0,0 +1,140 @@
+package importer
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/arnodel/golite"
+)
+
+const testSQL = "CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT, price REAL, qty INTEGER)"
+
+// testTable returns the TableInfo of the table defined by testSQL.
+func testTable(t *testing.T) golite.TableInfo {
+	t.Helper()
+	table, err := Table(testSQL)
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+	return table
+}
+
+// lineErrors returns an option collecting the line errors into errs and
+// skipping their lines.
+func lineErrors(errs *[]string) Option {
+	return WithErrorHandler(func(err *LineError) error {
+		*errs = append(*errs, err.Error())
+		return nil
+	})
+}
+
+func TestCSV(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		opts     []Option
+		want     []golite.Record
+		wantErrs []string
+	}{
+		{
+			name:  "affinity",
+			input: "1,apple,1.5,3\n2,pear,2,x\n",
+			want: []golite.Record{
+				{int64(1), "apple", 1.5, int64(3)},
+				{int64(2), "pear", int64(2), "x"},
+			},
+		},
+		{
+			name:  "header and null",
+			input: "QTY,name\n,apple\n4,\n",
+			opts:  []Option{WithHeader(), WithNull("")},
+			want: []golite.Record{
+				{golite.SQLNull, "apple", golite.SQLNull, golite.SQLNull},
+				{golite.SQLNull, golite.SQLNull, golite.SQLNull, int64(4)},
+			},
+		},
+		{
+			name:  "line errors",
+			input: "1,a,1,1\n2,b\nx,c,1,1\n1,d,1,1\n\"3\nz\",e,1,1\n4,\"f\n",
+			want: []golite.Record{
+				{int64(1), "a", int64(1), int64(1)},
+			},
+			wantErrs: []string{
+				"line 2: expected 4 fields but found 2",
+				"line 3: datatype mismatch: items.id is not an integer",
+				"line 4: rowid 1 is not greater than that of the previous row, 1",
+				"line 5: datatype mismatch: items.id is not an integer",
+				"line 7: extraneous or missing \" in quoted-field",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var errs []string
+			opts := append(tc.opts, lineErrors(&errs))
+			got, err := golite.CollectRecords(CSV(strings.NewReader(tc.input), testTable(t), opts...))
+			if err != nil {
+				t.Fatalf("CSV() failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("CSV() = %#v, want %#v", got, tc.want)
+			}
+			if !reflect.DeepEqual(errs, tc.wantErrs) {
+				t.Errorf("line errors = %q, want %q", errs, tc.wantErrs)
+			}
+		})
+	}
+}
+
+func TestCSVStopsAtFirstError(t *testing.T) {
+	_, err := golite.CollectRecords(CSV(strings.NewReader("1,a,1,1\nb\n"), testTable(t)))
+	var lineErr *LineError
+	if !errors.As(err, &lineErr) || lineErr.Line != 2 {
+		t.Fatalf("CSV() error = %v, want a *LineError for line 2", err)
+	}
+}
+
+func TestCSVUnknownColumn(t *testing.T) {
+	_, err := golite.CollectRecords(CSV(strings.NewReader("id,colour\n"), testTable(t), WithHeader()))
+	if err == nil || err.Error() != "table items has no column named colour" {
+		t.Fatalf("CSV() error = %v, want unknown column", err)
+	}
+}
+
+func TestCSVBuild(t *testing.T) {
+	table := testTable(t)
+	path := filepath.Join(t.TempDir(), "test.db")
+	input := "name,price\napple,1.5\npear,2\n"
+	err := golite.NewDatabaseBuilder(path).
+		CreateTable(table.SQL).
+		InsertFrom(CSV(strings.NewReader(input), table, WithHeader())).
+		Finalize()
+	if err != nil {
+		t.Fatalf("building the database failed: %v", err)
+	}
+
+	db, err := golite.Open(path)
+	if err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	defer db.Close()
+	schema, err := db.GetSchema()
+	if err != nil {
+		t.Fatalf("GetSchema() failed: %v", err)
+	}
+	got, err := golite.CollectRecords(db.TableScan(schema.Tables["items"]))
+	if err != nil {
+		t.Fatalf("TableScan() failed: %v", err)
+	}
+	want := []golite.Record{
+		{int64(1), "apple", 1.5, golite.SQLNull},
+		{int64(2), "pear", int64(2), golite.SQLNull},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TableScan() = %#v, want %#v", got, want)
+	}
+}
@@ -0,0 +1,147 @@
+// Package importer reads rows from common interchange formats, CSV and
+// newline-delimited JSON, as golite records that can be inserted into a new
+// database with golite.DatabaseBuilder:
+//
+//	table, err := importer.Table("CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT)")
+//	...
+//	err = golite.NewDatabaseBuilder(path).
+//		CreateTable(table.SQL).
+//		InsertFrom(importer.CSV(f, table, importer.WithHeader())).
+//		Finalize()
+//
+// The affinity of the declared type of each column is applied to its values,
+// and lines that cannot be imported are reported as *LineError.
+package importer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/arnodel/golite"
+)
+
+// LineError reports why a line of the input could not be imported.
+type LineError struct {
+	// Line is the number of the line, starting from 1. For CSV, it is the
+	// line where the record starts.
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// options holds the settings shared by all importers.
+type options struct {
+	header    bool
+	null      string
+	nullIsSet bool
+	onError   func(*LineError) error
+}
+
+// Option configures an importer.
+type Option func(*options)
+
+// WithHeader makes the first record of a CSV input name the columns of the
+// fields of the records that follow, matched with those of the table
+// ignoring case. Columns of the table that are not named are NULL. Without
+// it, the fields are the columns of the table in the order they are
+// declared.
+func WithHeader() Option {
+	return func(o *options) {
+		o.header = true
+	}
+}
+
+// WithNull sets the CSV field that stands for NULL. By default, there is
+// none, and empty fields are empty strings, as the sqlite3 shell imports
+// them.
+func WithNull(s string) Option {
+	return func(o *options) {
+		o.null = s
+		o.nullIsSet = true
+	}
+}
+
+// WithErrorHandler sets the function called with the error of each line that
+// cannot be imported. If it returns nil, the line is skipped and the import
+// goes on; otherwise the import stops with the error it returns. By default,
+// the import stops with the *LineError of the first such line.
+func WithErrorHandler(handle func(*LineError) error) Option {
+	return func(o *options) {
+		o.onError = handle
+	}
+}
+
+func buildOptions(opts []Option) options {
+	o := options{onError: func(err *LineError) error { return err }}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Table returns the TableInfo of the table defined by a CREATE TABLE
+// statement, to pass to the importers and to DatabaseBuilder.CreateTable.
+func Table(sql string) (golite.TableInfo, error) {
+	mem, err := golite.NewMemTable(sql)
+	if err != nil {
+		return golite.TableInfo{}, err
+	}
+	return mem.Info(), nil
+}
+
+// converter turns the values read from a line into a record of the table.
+// It checks the rowids as DatabaseBuilder.InsertFrom does, so that a row it
+// would reject is reported as an error of its line rather than ending the
+// import.
+type converter struct {
+	table golite.TableInfo
+	// lastRowID is the rowid of the previous row, if hasRows.
+	lastRowID int64
+	hasRows   bool
+}
+
+// convert applies the affinity of the columns to the values of record,
+// which are in the order the columns are declared.
+func (c *converter) convert(record golite.Record) error {
+	for i, col := range c.table.Columns {
+		if record[i] == nil {
+			record[i] = golite.SQLNull
+		}
+		record[i] = col.Affinity().Apply(record[i])
+	}
+	if c.table.WithoutRowID {
+		return nil
+	}
+	// The builder needs the rows in increasing rowid order.
+	rowID := c.lastRowID + 1
+	if i := c.table.RowIDColumnIndex; i != -1 && record[i] != golite.SQLNull {
+		id, ok := record[i].(int64)
+		if !ok {
+			return fmt.Errorf("datatype mismatch: %s.%s is not an integer", c.table.Name, c.table.Columns[i].Name)
+		}
+		if c.hasRows && id <= c.lastRowID {
+			return fmt.Errorf("rowid %d is not greater than that of the previous row, %d", id, c.lastRowID)
+		}
+		rowID = id
+	}
+	c.lastRowID, c.hasRows = rowID, true
+	return nil
+}
+
+// lookupColumn returns the index of the named column of the table, ignoring
+// case, or -1 if there is none.
+func lookupColumn(table golite.TableInfo, name string) int {
+	for i, col := range table.Columns {
+		if strings.EqualFold(col.Name, name) {
+			return i
+		}
+	}
+	return -1
+}
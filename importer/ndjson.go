@@ -0,0 +1,105 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/arnodel/golite"
+)
+
+// NDJSON returns the records of the table read from r, which holds a JSON
+// object per line. The members of an object are the values of the columns
+// of the same name, matched ignoring case, and columns without a member are
+// NULL. Numbers are INTEGER if they are integers that fit in 64 bits and
+// REAL otherwise, booleans are 1 and 0, and arrays and objects are stored as
+// their JSON text. Each value is then converted by the affinity of its
+// column. Blank lines are skipped.
+func NDJSON(r io.Reader, table golite.TableInfo, opts ...Option) golite.RecordIterator {
+	o := buildOptions(opts)
+	return func(yield func(golite.Record, error) bool) {
+		br := bufio.NewReader(r)
+		c := &converter{table: table}
+		for line := 1; ; line++ {
+			data, err := br.ReadBytes('\n')
+			if err != nil && err != io.EOF {
+				yield(nil, err)
+				return
+			}
+			if len(bytes.TrimSpace(data)) != 0 {
+				record, lerr := jsonRecord(data, table)
+				if lerr == nil {
+					lerr = c.convert(record)
+				}
+				if lerr == nil {
+					if !yield(record, nil) {
+						return
+					}
+				} else if lerr = o.onError(&LineError{Line: line, Err: lerr}); lerr != nil {
+					yield(nil, lerr)
+					return
+				}
+			}
+			if err == io.EOF {
+				return
+			}
+		}
+	}
+}
+
+// jsonRecord returns the record of the table holding the members of the JSON
+// object in data.
+func jsonRecord(data []byte, table golite.TableInfo) (golite.Record, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return nil, fmt.Errorf("expected a JSON object but found %s", typeErr.Value)
+		}
+		return nil, err
+	}
+	record := make(golite.Record, len(table.Columns))
+	for name, raw := range object {
+		i := lookupColumn(table, name)
+		if i == -1 {
+			return nil, fmt.Errorf("table %s has no column named %s", table.Name, name)
+		}
+		value, err := jsonValue(raw)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", name, err)
+		}
+		record[i] = value
+	}
+	return record, nil
+}
+
+// jsonValue returns the SQL value of a JSON value.
+func jsonValue(raw json.RawMessage) (any, error) {
+	d := json.NewDecoder(bytes.NewReader(raw))
+	d.UseNumber()
+	var v any
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+	switch v := v.(type) {
+	case nil:
+		return golite.SQLNull, nil
+	case bool:
+		if v {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	case string:
+		return v, nil
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		return v.Float64()
+	default:
+		return string(raw), nil
+	}
+}
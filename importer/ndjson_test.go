@@ -0,0 +1,46 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/arnodel/golite"
+)
+
+func TestNDJSON(t *testing.T) {
+	input := `{"id": 1, "name": "apple", "price": 1.5, "qty": "3"}
+
+{"NAME": ["a", 1], "qty": true}
+{"id": 5, "name": {"x": null}, "price": null, "qty": 1e3}
+{"id": 2}
+[1, 2]
+{"id": 6, "colour": "red"}
+{"id": "6.0", "qty": 12345678901234567890}
+{"id": 7
+`
+	want := []golite.Record{
+		{int64(1), "apple", 1.5, int64(3)},
+		{golite.SQLNull, `["a", 1]`, golite.SQLNull, int64(1)},
+		{int64(5), `{"x": null}`, golite.SQLNull, 1000.0},
+		{int64(6), golite.SQLNull, golite.SQLNull, 1.2345678901234567e19},
+	}
+	wantErrs := []string{
+		"line 5: rowid 2 is not greater than that of the previous row, 5",
+		"line 6: expected a JSON object but found array",
+		"line 7: table items has no column named colour",
+		"line 9: unexpected end of JSON input",
+	}
+
+	var errs []string
+	got, err := golite.CollectRecords(NDJSON(strings.NewReader(input), testTable(t), lineErrors(&errs)))
+	if err != nil {
+		t.Fatalf("NDJSON() failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("NDJSON() = %#v, want %#v", got, want)
+	}
+	if !reflect.DeepEqual(errs, wantErrs) {
+		t.Errorf("line errors = %q, want %q", errs, wantErrs)
+	}
+}
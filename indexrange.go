@@ -0,0 +1,252 @@
+package golite
+
+import (
+	"bytes"
+	"encoding/binary"
+	"sort"
+	"strings"
+)
+
+// IndexScanRange returns an iterator over the records of an index whose keys
+// lie between lower, inclusive, and upper, exclusive, in index order, so that
+// lower comes first in the index even for a DESC column. A nil
+// bound leaves that side of the range open. A bound is compared with as many
+// leading columns of the records as it has values, after the affinity of the
+// indexed columns has been applied as with IndexSeek, so that for instance
+// lower Record{int64(3)} starts at the first record whose first column is 3.
+// Only the pages that can hold keys in the range are read.
+func (db *Database) IndexScanRange(index IndexInfo, lower, upper Record) RecordIterator {
+	return interruptible(db, func(yield func(Record, error) bool) {
+		r := keyRange{textOrder: db.utf16Order()}
+		for _, col := range index.Columns {
+			r.desc = append(r.desc, col.Desc)
+		}
+		if lower != nil {
+			r.lower = index.coerceKey(lower)
+			db.traceSeek(index.RootPage, r.lower)
+		}
+		if upper != nil {
+			r.upper = index.coerceKey(upper)
+		}
+		db.indexRangePage(index.RootPage, index, r, yield)
+	})
+}
+
+// keyRange is a range of index keys, see IndexScanRange.
+type keyRange struct {
+	lower, upper Record
+	desc         []bool // The columns of the index sorted in descending order.
+	// textOrder is the byte order of the TEXT values of the index if they
+	// are stored in UTF-16 but read as UTF-8, see WithUTF8Text.
+	textOrder binary.ByteOrder
+}
+
+// below reports whether record sorts before the range.
+func (r keyRange) below(record Record) bool {
+	return r.lower != nil && r.compare(record, r.lower) < 0
+}
+
+// above reports whether record sorts after the range.
+func (r keyRange) above(record Record) bool {
+	return r.upper != nil && r.compare(record, r.upper) >= 0
+}
+
+// compare compares the leading columns of record with a bound, in index
+// order.
+func (r keyRange) compare(record, bound Record) int {
+	for i := range min(len(record), len(bound)) {
+		c := r.compareValues(record[i], bound[i])
+		if i < len(r.desc) && r.desc[i] {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return min(len(record), len(bound)) - len(bound)
+}
+
+// compareValues compares two values as the index orders them. The TEXT
+// values of an index of a UTF-16 database are sorted by their bytes in the
+// file, not by code point as UTF-8 strings are.
+func (r keyRange) compareValues(a, b any) int {
+	if r.textOrder != nil {
+		if a, ok := a.(string); ok {
+			if b, ok := b.(string); ok {
+				return strings.Compare(utf16Key(a, r.textOrder), utf16Key(b, r.textOrder))
+			}
+		}
+	}
+	return compareValues(a, b)
+}
+
+// indexRangePage is the recursive helper for IndexScanRange. It returns true
+// to continue scanning, or false once the range is exhausted or the consumer
+// stopped.
+func (db *Database) indexRangePage(pageNum int, index IndexInfo, r keyRange, yield func(Record, error) bool) bool {
+	page, err := db.ReadPage(pageNum)
+	if err != nil {
+		return yield(nil, err)
+	}
+	switch page.Type {
+	case PageTypeLeafIndex:
+		cells := page.LeafIndexCells
+		i := sort.Search(len(cells), func(i int) bool { return !r.below(cells[i].Payload) })
+		for _, cell := range cells[i:] {
+			if r.above(cell.Payload) || !yield(cell.Payload, nil) {
+				return false
+			}
+		}
+		return true
+
+	case PageTypeInteriorIndex:
+		for _, cell := range page.InteriorIndexCells {
+			if r.below(cell.Payload) {
+				// The left subtree only holds keys up to the cell's.
+				continue
+			}
+			if !db.indexRangePage(int(cell.LeftChildPageNum), index, r, yield) {
+				return false
+			}
+			if r.above(cell.Payload) || !yield(cell.Payload, nil) {
+				return false
+			}
+		}
+		return db.indexRangePage(int(page.RightMostPtr), index, r, yield)
+	default:
+		if emptyRoot(page, pageNum, index.RootPage) {
+			return true
+		}
+		return yield(nil, unexpectedPageType(pageNum, page, "index range scan"))
+	}
+}
+
+// maxLikeCaseVariants bounds the number of ranges IndexScanLike searches for
+// the case variants of a prefix.
+const maxLikeCaseVariants = 16
+
+// IndexScanLike returns an iterator over the records of an index whose first
+// column matches pattern with the LIKE operator, as Like does. When the
+// pattern starts with literal characters and the first column of the index
+// has TEXT affinity and ascending order, only the ranges of the index
+// starting with that prefix are read: as LIKE ignores the case of ASCII
+// letters, there is one range per case variant of the prefix, the prefix
+// being shortened so that there are at most 16 of them. Otherwise the whole
+// index is scanned.
+func (db *Database) IndexScanLike(index IndexInfo, pattern string, escape rune) RecordIterator {
+	prefix := likePrefix(pattern, escape)
+	match := func(record Record) (bool, error) {
+		if len(record) == 0 {
+			return false, nil
+		}
+		value, ok := textValue(record[0])
+		return ok && Like(pattern, value, escape), nil
+	}
+	if !index.prefixSearchable() || prefix == "" {
+		return Filter(db.IndexScan(index), match)
+	}
+	letters := 0
+	for i := 0; i < len(prefix); i++ {
+		if c := prefix[i] | 0x20; c >= 'a' && c <= 'z' {
+			if 1<<(letters+1) > maxLikeCaseVariants {
+				prefix = prefix[:i]
+				break
+			}
+			letters++
+		}
+	}
+	variants := caseVariants(prefix)
+	ranges := make([]RecordIterator, len(variants))
+	for i, variant := range variants {
+		ranges[i] = db.indexScanPrefix(index, variant)
+	}
+	return Filter(Chain(ranges...), match)
+}
+
+// IndexScanGlob returns an iterator over the records of an index whose first
+// column matches pattern with the GLOB operator, as Glob does. When the
+// pattern starts with literal characters and the first column of the index
+// has TEXT affinity and ascending order, only the range of the index
+// starting with that prefix is read. Otherwise the whole index is scanned.
+func (db *Database) IndexScanGlob(index IndexInfo, pattern string) RecordIterator {
+	prefix := globPrefix(pattern)
+	match := func(record Record) (bool, error) {
+		if len(record) == 0 {
+			return false, nil
+		}
+		value, ok := textValue(record[0])
+		return ok && Glob(pattern, value), nil
+	}
+	if !index.prefixSearchable() || prefix == "" {
+		return Filter(db.IndexScan(index), match)
+	}
+	return Filter(db.indexScanPrefix(index, prefix), match)
+}
+
+// indexScanPrefix returns the records of an index whose first column is TEXT
+// starting with prefix. As no valid UTF-8 text contains the byte 0xFF, they
+// all sort between prefix and prefix followed by that byte.
+func (db *Database) indexScanPrefix(index IndexInfo, prefix string) RecordIterator {
+	return db.IndexScanRange(index, Record{prefix}, Record{prefix + "\xff"})
+}
+
+// prefixSearchable reports whether the TEXT values of the first column of
+// the index starting with some prefix form a range of the index. It requires
+// TEXT affinity, as otherwise numbers, which sort before all TEXT, could also
+// match a pattern.
+func (index IndexInfo) prefixSearchable() bool {
+	return len(index.Columns) > 0 && !index.Columns[0].Desc && index.Columns[0].affinity == AffinityText
+}
+
+// likePrefix returns the literal characters a LIKE pattern starts with.
+func likePrefix(pattern string, escape rune) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range pattern {
+		switch {
+		case escaped:
+			escaped = false
+		case r == escape && escape != 0:
+			escaped = true
+			continue
+		case r == '%' || r == '_':
+			return b.String()
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// globPrefix returns the literal characters a GLOB pattern starts with.
+func globPrefix(pattern string) string {
+	if i := strings.IndexAny(pattern, "*?["); i >= 0 {
+		return pattern[:i]
+	}
+	return pattern
+}
+
+// caseVariants returns the variants of s with each ASCII letter in upper or
+// lower case, in increasing order.
+func caseVariants(s string) []string {
+	variants := [][]byte{nil}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		lower := c | 0x20
+		if lower < 'a' || lower > 'z' {
+			for j := range variants {
+				variants[j] = append(variants[j], c)
+			}
+			continue
+		}
+		next := make([][]byte, 0, 2*len(variants))
+		for _, v := range variants {
+			next = append(next, append(bytes.Clone(v), lower&^0x20), append(bytes.Clone(v), lower))
+		}
+		variants = next
+	}
+	texts := make([]string, len(variants))
+	for i, v := range variants {
+		texts[i] = string(v)
+	}
+	return texts
+}
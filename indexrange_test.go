@@ -0,0 +1,181 @@
+package golite
+
+import (
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// createWordsDB creates a database with a table of 3000 words in mixed case,
+// indexed on the words and on a numeric column, and a table holding the
+// rowids SQLite finds for each LIKE and GLOB pattern.
+func createWordsDB(t *testing.T) string {
+	t.Helper()
+	dbPath := filepath.Join(t.TempDir(), "words.sqlite")
+	runSQL(t, dbPath, `
+		CREATE TABLE words (w TEXT, n INTEGER);
+		WITH RECURSIVE seq(i) AS (SELECT 0 UNION ALL SELECT i + 1 FROM seq WHERE i < 2999)
+		INSERT INTO words SELECT
+			printf('%s%s_%04d', substr('abcxyzABCXYZ', i % 12 + 1, 1), substr('bBcC', i % 4 + 1, 1), i), i
+			FROM seq;
+		INSERT INTO words VALUES ('a%b', -1), (42, -2), ('', -3), (NULL, -4);
+		CREATE INDEX idx_w ON words(w);
+		CREATE INDEX idx_n ON words(n);
+		CREATE TABLE likes (pattern TEXT, esc TEXT, rowids TEXT);
+		INSERT INTO likes SELECT p, e, (SELECT group_concat(rowid) FROM (
+			SELECT rowid FROM words WHERE CASE WHEN e IS NULL THEN w LIKE p ELSE w LIKE p ESCAPE e END ORDER BY rowid))
+			FROM (SELECT 'ab%' AS p, NULL AS e UNION ALL SELECT 'AB_1%', NULL UNION ALL SELECT 'xc%5', NULL
+				UNION ALL SELECT '%7', NULL UNION ALL SELECT '4%', NULL UNION ALL SELECT 'a!%%', '!'
+				UNION ALL SELECT 'bb\_0123', '\' UNION ALL SELECT 'zz%', NULL);
+		CREATE TABLE globs (pattern TEXT, rowids TEXT);
+		INSERT INTO globs SELECT p, (SELECT group_concat(rowid) FROM (
+			SELECT rowid FROM words WHERE w GLOB p ORDER BY rowid))
+			FROM (SELECT 'ab*' AS p UNION ALL SELECT 'Ab_1*' UNION ALL SELECT '[AB]c*9' UNION ALL SELECT '*77');
+	`)
+	return dbPath
+}
+
+// sortedRowIDs returns the rowids, the last column of the index records
+// yielded by it, sorted and joined with commas.
+func sortedRowIDs(t *testing.T, it RecordIterator) string {
+	t.Helper()
+	var rowIDs []int
+	for record, err := range it {
+		if err != nil {
+			t.Fatalf("iteration failed: %v", err)
+		}
+		rowIDs = append(rowIDs, int(record[len(record)-1].(int64)))
+	}
+	slices.Sort(rowIDs)
+	texts := make([]string, len(rowIDs))
+	for i, id := range rowIDs {
+		texts[i] = strconv.Itoa(id)
+	}
+	return strings.Join(texts, ",")
+}
+
+// pagesRead returns the number of pages read, wherever they came from.
+func (r *recordingTracer) pagesRead() int {
+	n := 0
+	for _, pages := range r.reads {
+		n += len(pages)
+	}
+	return n
+}
+
+func TestIndexScanLike(t *testing.T) {
+	dbPath := createWordsDB(t)
+	tracer := &recordingTracer{}
+	db, err := Open(dbPath, WithTracer(tracer))
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	wordsIndex, err := db.Index("idx_w")
+	if err != nil {
+		t.Fatalf("Index() failed: %v", err)
+	}
+	numbersIndex, err := db.Index("idx_n")
+	if err != nil {
+		t.Fatalf("Index() failed: %v", err)
+	}
+	*tracer = recordingTracer{}
+	for range db.IndexScan(wordsIndex) {
+	}
+	fullScanReads := tracer.pagesRead()
+
+	likes, err := db.Table("likes")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+	for record, err := range db.TableScan(likes) {
+		if err != nil {
+			t.Fatalf("TableScan() failed: %v", err)
+		}
+		values := likes.ColumnValues(record)
+		pattern := values[0].(string)
+		var escape rune
+		if e, ok := values[1].(string); ok {
+			escape = []rune(e)[0]
+		}
+		want, _ := values[2].(string)
+		*tracer = recordingTracer{}
+		if got := sortedRowIDs(t, db.IndexScanLike(wordsIndex, pattern, escape)); got != want {
+			t.Errorf("IndexScanLike(%q) = %s, want %s", pattern, got, want)
+		}
+		if reads := tracer.pagesRead(); pattern[0] != '%' && reads >= fullScanReads {
+			t.Errorf("IndexScanLike(%q) read %d pages, as many as a full scan", pattern, reads)
+		}
+		// Without TEXT affinity, the whole index is scanned.
+		if got := sortedRowIDs(t, db.IndexScanLike(numbersIndex, pattern, escape)); pattern == "4%" && got == "" {
+			t.Errorf("IndexScanLike(%q) on a numeric column found nothing", pattern)
+		}
+	}
+
+	globs, err := db.Table("globs")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+	for record, err := range db.TableScan(globs) {
+		if err != nil {
+			t.Fatalf("TableScan() failed: %v", err)
+		}
+		values := globs.ColumnValues(record)
+		pattern := values[0].(string)
+		want, _ := values[1].(string)
+		if got := sortedRowIDs(t, db.IndexScanGlob(wordsIndex, pattern)); got != want {
+			t.Errorf("IndexScanGlob(%q) = %s, want %s", pattern, got, want)
+		}
+	}
+}
+
+func TestIndexScanRange(t *testing.T) {
+	db, err := Open(createWordsDB(t))
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	index, err := db.Index("idx_n")
+	if err != nil {
+		t.Fatalf("Index() failed: %v", err)
+	}
+
+	tests := []struct {
+		lower, upper Record
+		want         []int64
+	}{
+		{Record{int64(100)}, Record{int64(105)}, []int64{100, 101, 102, 103, 104}},
+		{Record{"2997"}, nil, []int64{2997, 2998, 2999}},
+		{nil, Record{-1.5}, []int64{-4, -3, -2}},
+		{Record{int64(7)}, Record{int64(7)}, nil},
+		{Record{int64(5000)}, nil, nil},
+	}
+	for _, test := range tests {
+		got, err := collectInts(db.IndexScanRange(index, test.lower, test.upper))
+		if err != nil {
+			t.Fatalf("IndexScanRange() failed: %v", err)
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("IndexScanRange(%v, %v) = %v, want %v", test.lower, test.upper, got, test.want)
+		}
+	}
+
+	// Stopping early must not fail.
+	got, err := collectInts(Take(db.IndexScanRange(index, nil, nil), 3))
+	if err != nil || !slices.Equal(got, []int64{-4, -3, -2}) {
+		t.Errorf("expected the first 3 records, got (%v, %v)", got, err)
+	}
+}
+
+func TestCaseVariants(t *testing.T) {
+	got := caseVariants("a1b")
+	want := []string{"A1B", "A1b", "a1B", "a1b"}
+	if !slices.Equal(got, want) {
+		t.Errorf("caseVariants() = %q, want %q", got, want)
+	}
+	if !slices.IsSorted(caseVariants("xYz!")) {
+		t.Errorf("expected the variants in increasing order")
+	}
+}
@@ -0,0 +1,82 @@
+package golite
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// interrupter tracks the iterations in progress on a Database, so that
+// Interrupt can stop them.
+type interrupter struct {
+	// mu guards active, and orders Interrupt with the end of the last
+	// iteration so that the flag is never left set with none in progress.
+	mu     sync.Mutex
+	active int
+	// interrupted is set by Interrupt and cleared when the last iteration in
+	// progress ends. It is read without the lock on every page read.
+	interrupted atomic.Bool
+}
+
+func (in *interrupter) enter() {
+	in.mu.Lock()
+	in.active++
+	in.mu.Unlock()
+}
+
+func (in *interrupter) exit() {
+	in.mu.Lock()
+	in.active--
+	if in.active == 0 {
+		in.interrupted.Store(false)
+	}
+	in.mu.Unlock()
+}
+
+// Interrupt makes every iteration in progress on the database stop promptly
+// with ErrInterrupted, as sqlite3_interrupt does for running statements. It
+// may be called from any goroutine, for instance a signal handler. An
+// iteration notices the interruption before its next page read or record,
+// whichever comes first.
+//
+// Like sqlite3_interrupt, the interruption lasts until no iteration is in
+// progress: iterations started in the meantime are interrupted too, and
+// those started afterwards are not. Interrupt has no effect if no iteration
+// is in progress.
+func (db *Database) Interrupt() {
+	db.interrupt.mu.Lock()
+	if db.interrupt.active > 0 {
+		db.interrupt.interrupted.Store(true)
+	}
+	db.interrupt.mu.Unlock()
+}
+
+// IsInterrupted reports whether Interrupt was called and the iterations it
+// interrupted have not all ended yet.
+func (db *Database) IsInterrupted() bool {
+	return db.interrupt.interrupted.Load()
+}
+
+// interruptible returns an iterator that yields the values of seq, counting
+// as an iteration in progress on db while it runs, and that fails with
+// ErrInterrupted once db is interrupted. The iterators returned by Database
+// methods are wrapped with it; nesting is harmless. It also presents the NULL
+// values of the records it yields as WithNullValue asks.
+func interruptible[S ~func(func(T, error) bool), T any](db *Database, seq S) S {
+	return func(yield func(T, error) bool) {
+		db.interrupt.enter()
+		defer db.interrupt.exit()
+		for v, err := range seq {
+			if err == nil && db.interrupt.interrupted.Load() {
+				var zero T
+				yield(zero, ErrInterrupted)
+				return
+			}
+			if err == nil {
+				db.presentNulls(v)
+			}
+			if !yield(v, err) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package golite
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestInterrupt(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "interrupt.sqlite")
+	runSQL(t, dbPath, `
+		CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT);
+		CREATE INDEX t_name ON t(name);
+		WITH RECURSIVE seq(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM seq WHERE i < 2000)
+		INSERT INTO t SELECT i, 'name' || i FROM seq;
+	`)
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	table, err := db.Table("t")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+	index, err := db.Index("t_name")
+	if err != nil {
+		t.Fatalf("Index() failed: %v", err)
+	}
+
+	scans := map[string]RecordIterator{
+		"TableScan":     db.TableScan(table),
+		"IndexScan":     db.IndexScan(index),
+		"ParallelScan":  db.ParallelScan(table, 4, Ordered),
+		"Execute":       db.Execute(NewLimitPlan(NewScanPlan(table), 1500, 0)),
+		"IndexScanLike": db.IndexScanLike(index, "name1%", 0),
+	}
+	for name, scan := range scans {
+		t.Run(name, func(t *testing.T) {
+			rows := 0
+			var scanErr error
+			for _, err := range scan {
+				if err != nil {
+					scanErr = err
+					break
+				}
+				rows++
+				if rows == 10 {
+					db.Interrupt()
+					if !db.IsInterrupted() {
+						t.Errorf("IsInterrupted() = false during the scan")
+					}
+				}
+			}
+			if !errors.Is(scanErr, ErrInterrupted) {
+				t.Fatalf("scan ended with %v after %d rows, want ErrInterrupted", scanErr, rows)
+			}
+			if rows != 10 {
+				t.Errorf("scan yielded %d rows, want 10", rows)
+			}
+			if db.IsInterrupted() {
+				t.Errorf("IsInterrupted() = true after the scan ended")
+			}
+			// Iterations started afterwards are not interrupted.
+			if _, err := CollectRecords(scan); err != nil {
+				t.Errorf("scan after the interruption failed: %v", err)
+			}
+		})
+	}
+
+	t.Run("nested scans", func(t *testing.T) {
+		var innerErr error
+		for _, err := range db.TableScan(table) {
+			if err != nil {
+				t.Fatalf("outer scan failed: %v", err)
+			}
+			db.Interrupt()
+			// An iteration started while another is interrupted is
+			// interrupted too.
+			_, innerErr = CollectRecords(db.TableSeek(table, 1000))
+			break
+		}
+		if !errors.Is(innerErr, ErrInterrupted) {
+			t.Errorf("inner seek failed with %v, want ErrInterrupted", innerErr)
+		}
+	})
+
+	t.Run("no iteration in progress", func(t *testing.T) {
+		db.Interrupt()
+		if db.IsInterrupted() {
+			t.Errorf("IsInterrupted() = true with no iteration in progress")
+		}
+		records, err := CollectRecords(db.TableScan(table))
+		if err != nil || len(records) != 2000 {
+			t.Errorf("TableScan() = %d records, %v", len(records), err)
+		}
+	})
+
+	t.Run("other goroutine", func(t *testing.T) {
+		started := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			<-started
+			db.Interrupt()
+			close(done)
+		}()
+		var scanErr error
+		rows := 0
+		for _, err := range db.TableScan(table) {
+			if err != nil {
+				scanErr = err
+				break
+			}
+			if rows++; rows == 1 {
+				close(started)
+				<-done
+			}
+		}
+		if !errors.Is(scanErr, ErrInterrupted) {
+			t.Errorf("scan ended with %v after %d rows, want ErrInterrupted", scanErr, rows)
+		}
+	})
+}
@@ -0,0 +1,226 @@
+package golite
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// JournalState is the state of the rollback journal of a database, as found
+// by Open.
+type JournalState int
+
+const (
+	// JournalNone means that there is no rollback journal to play back: the
+	// file is absent, empty or zeroed, as SQLite leaves it after a commit.
+	JournalNone JournalState = iota
+	// JournalCommitted means that the journal names a super-journal that no
+	// longer exists. The transaction spanning several databases it belongs
+	// to was committed, and the journal is stale: the database file is read
+	// as it is.
+	JournalCommitted
+	// JournalRolledBack means that the journal is hot: its transaction was
+	// not committed, either because it is still in progress or because the
+	// process writing it crashed. The database is read as it was before the
+	// transaction, as SQLite does after rolling it back, from the original
+	// pages saved in the journal. Neither file is modified.
+	JournalRolledBack
+)
+
+func (s JournalState) String() string {
+	switch s {
+	case JournalNone:
+		return "none"
+	case JournalCommitted:
+		return "committed"
+	case JournalRolledBack:
+		return "rolled back"
+	}
+	return fmt.Sprintf("JournalState(%d)", int(s))
+}
+
+// Journal describes the rollback journal of a database opened with Open.
+type Journal struct {
+	// Path is the path of the journal file, the database path followed by
+	// "-journal".
+	Path  string
+	State JournalState
+	// SuperJournal is the path of the super-journal named by the journal
+	// when it belongs to a transaction spanning several attached databases,
+	// or "" if it names none.
+	SuperJournal string
+	// Pages is the number of original pages restored from the journal when
+	// State is JournalRolledBack.
+	Pages int
+}
+
+// Journal returns the state of the rollback journal found when the database
+// was opened. It is the zero Journal for databases opened with OpenPager.
+func (db *Database) Journal() Journal {
+	return db.journal
+}
+
+// journalMagic starts every header of a rollback journal, and ends the
+// super-journal record.
+var journalMagic = []byte{0xd9, 0xd5, 0x05, 0xf9, 0x20, 0xa1, 0x63, 0xd7}
+
+// readJournal reads the rollback journal of the database at dbPath. If the
+// journal is hot, it also returns a Pager presenting file as it was before
+// the transaction.
+//
+// A journal is made of segments, each starting at a sector boundary with a
+// header holding the magic, the number of page records that follow, the
+// checksum nonce, the size of the database in pages before the transaction,
+// the sector size and the page size. A page record is the page number, the
+// original content of the page and a checksum. A journal belonging to a
+// transaction spanning several databases ends with the name of the
+// super-journal, whose deletion commits that transaction.
+//
+// Like SQLite, readJournal plays the records back until it finds one whose
+// checksum is wrong, which was not completely written, and ignores what
+// follows. As golite takes no locks, the journal of a transaction in
+// progress is hot too.
+func readJournal(dbPath string, file Pager) (Journal, Pager, error) {
+	j := Journal{Path: dbPath + "-journal"}
+	data, err := os.ReadFile(j.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return j, nil, nil
+	}
+	if err != nil {
+		return j, nil, fmt.Errorf("failed to read rollback journal: %w", err)
+	}
+	if len(data) < 28 || string(data[:8]) != string(journalMagic) {
+		return j, nil, nil
+	}
+	if j.SuperJournal = readSuperJournal(data); j.SuperJournal != "" {
+		if _, err := os.Stat(j.SuperJournal); errors.Is(err, os.ErrNotExist) {
+			j.State = JournalCommitted
+			return j, nil, nil
+		} else if err != nil {
+			return j, nil, fmt.Errorf("failed to check super-journal: %w", err)
+		}
+	}
+
+	be := binary.BigEndian
+	sectorSize := int(be.Uint32(data[20:]))
+	pageSize := int(be.Uint32(data[24:]))
+	if !validJournalSize(sectorSize, 32) || !validJournalSize(pageSize, 512) {
+		return j, nil, nil
+	}
+	p := &rollbackPager{
+		Pager:    file,
+		pageSize: int64(pageSize),
+		size:     int64(be.Uint32(data[16:])) * int64(pageSize),
+		pages:    map[int64][]byte{},
+	}
+	recordSize := 4 + pageSize + 4
+	offset := 0
+	for offset+28 <= len(data) && string(data[offset:offset+8]) == string(journalMagic) {
+		records := int64(be.Uint32(data[offset+8:]))
+		nonce := be.Uint32(data[offset+12:])
+		if records == 0xffffffff {
+			// The count was not written: the records run to the end of the
+			// file.
+			records = int64((len(data) - offset - sectorSize) / recordSize)
+		}
+		offset += sectorSize
+		for range records {
+			if offset+recordSize > len(data) {
+				break
+			}
+			pageNum := be.Uint32(data[offset:])
+			page := data[offset+4 : offset+4+pageSize]
+			if pageNum == 0 || be.Uint32(data[offset+4+pageSize:]) != journalChecksum(nonce, page) {
+				break
+			}
+			p.pages[int64(pageNum)] = page
+			offset += recordSize
+		}
+		// The next segment starts at the next sector boundary.
+		offset = (offset + sectorSize - 1) / sectorSize * sectorSize
+	}
+	j.State = JournalRolledBack
+	j.Pages = len(p.pages)
+	return j, p, nil
+}
+
+// readSuperJournal returns the name of the super-journal recorded at the end
+// of a journal, or "" if there is none. The record is the number of the
+// lock-byte page, the name, its length, the sum of its bytes and the magic.
+func readSuperJournal(data []byte) string {
+	n := len(data)
+	if n < 16+4 || string(data[n-8:]) != string(journalMagic) {
+		return ""
+	}
+	be := binary.BigEndian
+	length := int(be.Uint32(data[n-16:]))
+	if length <= 0 || length > n-16-4 {
+		return ""
+	}
+	name := data[n-16-length : n-16]
+	var sum uint32
+	for _, b := range name {
+		sum += uint32(b)
+	}
+	if sum != be.Uint32(data[n-12:]) {
+		return ""
+	}
+	return strings.TrimRight(string(name), "\x00")
+}
+
+// journalChecksum is the checksum of a page record: the nonce plus every
+// 200th byte of the page, starting from its end.
+func journalChecksum(nonce uint32, page []byte) uint32 {
+	sum := nonce
+	for i := len(page) - 200; i > 0; i -= 200 {
+		sum += uint32(page[i])
+	}
+	return sum
+}
+
+// validJournalSize reports whether a sector or page size read from a journal
+// header is a power of two between least and 65536.
+func validJournalSize(size, least int) bool {
+	return size >= least && size <= 65536 && size&(size-1) == 0
+}
+
+// rollbackPager presents a database file as it was before the transaction
+// of a hot journal: the pages saved in the journal replace those of the
+// file, which is truncated to its original size.
+type rollbackPager struct {
+	Pager
+	pageSize int64
+	size     int64
+	pages    map[int64][]byte
+}
+
+func (p *rollbackPager) ReadAt(b []byte, off int64) (int, error) {
+	if off >= p.size {
+		return 0, io.EOF
+	}
+	var eof error
+	if off+int64(len(b)) > p.size {
+		b, eof = b[:p.size-off], io.EOF
+	}
+	n, err := p.Pager.ReadAt(b, off)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return n, err
+	}
+	// The file may have been truncated by the transaction: the pages it
+	// lacks come from the journal.
+	clear(b[n:])
+	for pageNum := off/p.pageSize + 1; (pageNum-1)*p.pageSize < off+int64(len(b)); pageNum++ {
+		if page, ok := p.pages[pageNum]; ok {
+			start := (pageNum - 1) * p.pageSize
+			copy(b[max(start-off, 0):], page[max(off-start, 0):])
+		}
+	}
+	return len(b), eof
+}
+
+func (p *rollbackPager) Size() (int64, error) {
+	return p.size, nil
+}
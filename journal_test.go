@@ -0,0 +1,119 @@
+package golite
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestJournal(t *testing.T) {
+	dir := t.TempDir()
+	dbPath := filepath.Join(dir, "journal.sqlite")
+	runSQL(t, dbPath, `
+		CREATE TABLE t (x INTEGER, y TEXT);
+		WITH RECURSIVE seq(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM seq WHERE i < 5000)
+		INSERT INTO t SELECT i, 'v' || i FROM seq;
+	`)
+	// With a small cache, the transaction spills changed pages to the
+	// database file before it commits. The shell copies both files while the
+	// journal is hot.
+	hotPath := filepath.Join(dir, "hot.sqlite")
+	script := "PRAGMA cache_size=5;\nBEGIN;\nUPDATE t SET y = 'changed';\nINSERT INTO t SELECT x + 5000, y FROM t;\n" +
+		".shell cp " + dbPath + " " + hotPath + " && cp " + dbPath + "-journal " + hotPath + "-journal\n"
+	runSQL(t, dbPath, script)
+	if _, err := os.Stat(hotPath + "-journal"); err != nil {
+		t.Fatalf("the journal was not copied: %v", err)
+	}
+	journal, err := os.ReadFile(hotPath + "-journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scan := func(t *testing.T, path string) ([]Record, Journal) {
+		t.Helper()
+		db, err := Open(path)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		defer db.Close()
+		table, err := db.Table("t")
+		if err != nil {
+			t.Fatalf("Table() failed: %v", err)
+		}
+		records, err := CollectRecords(db.TableScan(table))
+		if err != nil {
+			t.Fatalf("TableScan() failed: %v", err)
+		}
+		return records, db.Journal()
+	}
+	want, j := scan(t, dbPath)
+	if j.State != JournalNone || j.Path != dbPath+"-journal" {
+		t.Errorf("Journal() = %+v without a journal", j)
+	}
+
+	t.Run("hot", func(t *testing.T) {
+		got, j := scan(t, hotPath)
+		if j.State != JournalRolledBack || j.Pages == 0 || j.SuperJournal != "" {
+			t.Errorf("Journal() = %+v", j)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("TableScan() read %d records, want the %d from before the transaction", len(got), len(want))
+		}
+
+		// Without its journal, the file holds part of the transaction.
+		halfPath := filepath.Join(dir, "half.sqlite")
+		copyFile(t, hotPath, halfPath)
+		if got, _ := scan(t, halfPath); reflect.DeepEqual(got, want) {
+			t.Errorf("the file without its journal reads as before the transaction")
+		}
+	})
+
+	t.Run("zeroed header", func(t *testing.T) {
+		path := filepath.Join(dir, "persist.sqlite")
+		copyFile(t, dbPath, path)
+		if err := os.WriteFile(path+"-journal", make([]byte, 512), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if got, j := scan(t, path); j.State != JournalNone || !reflect.DeepEqual(got, want) {
+			t.Errorf("Journal() = %+v", j)
+		}
+	})
+
+	t.Run("super-journal", func(t *testing.T) {
+		path := filepath.Join(dir, "multi.sqlite")
+		copyFile(t, hotPath, path)
+		super := filepath.Join(dir, "multi.sqlite-mj0123")
+		if err := os.WriteFile(super, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		var sum uint32
+		for _, b := range []byte(super) {
+			sum += uint32(b)
+		}
+		record := binary.BigEndian.AppendUint32(nil, 1<<30/4096+1)
+		record = append(record, super...)
+		record = binary.BigEndian.AppendUint32(record, uint32(len(super)))
+		record = binary.BigEndian.AppendUint32(record, sum)
+		record = append(record, journalMagic...)
+		if err := os.WriteFile(path+"-journal", append(journal, record...), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		// While the super-journal exists, the transaction is not committed.
+		got, j := scan(t, path)
+		if j.State != JournalRolledBack || j.SuperJournal != super || !reflect.DeepEqual(got, want) {
+			t.Errorf("Journal() = %+v with the super-journal", j)
+		}
+
+		// Deleting it commits the transaction in all the databases.
+		if err := os.Remove(super); err != nil {
+			t.Fatal(err)
+		}
+		got, j = scan(t, path)
+		if j.State != JournalCommitted || j.SuperJournal != super || reflect.DeepEqual(got, want) {
+			t.Errorf("Journal() = %+v without the super-journal", j)
+		}
+	})
+}
@@ -0,0 +1,432 @@
+package golite
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// The functions below implement SQLite's JSON functions of the same names.
+// Their JSON argument may be TEXT holding JSON or a BLOB holding JSONB, the
+// binary format of SQLite 3.45 and later, and a NULL argument yields SQLNull.
+// A path selects an element with the syntax of SQLite: "$" for the whole
+// value, followed by ".key", ".\"key\"", "[N]" or "[#-N]" steps.
+
+// JSONExtract returns the element of value selected by path, as SQLite's
+// json_extract does: SQLNull for a JSON null or a missing element, 1 or 0 for
+// true and false, int64 or float64 for numbers, a string for text, and the
+// minified JSON text of arrays and objects.
+func JSONExtract(value any, path string) (any, error) {
+	node, err := jsonLookup(value, path)
+	if err != nil || node == nil {
+		return SQLNull, err
+	}
+	return node.sqlValue(), nil
+}
+
+// JSONType returns the type of the element of value selected by path, as
+// SQLite's json_type does: one of "null", "true", "false", "integer", "real",
+// "text", "array" or "object", or SQLNull if there is no such element.
+func JSONType(value any, path string) (any, error) {
+	node, err := jsonLookup(value, path)
+	if err != nil || node == nil {
+		return SQLNull, err
+	}
+	return node.kind.String(), nil
+}
+
+// JSONArrayLength returns the number of elements of the array of value
+// selected by path, as SQLite's json_array_length does: 0 if the element is
+// not an array, and SQLNull if there is no such element.
+func JSONArrayLength(value any, path string) (any, error) {
+	node, err := jsonLookup(value, path)
+	if err != nil || node == nil {
+		return SQLNull, err
+	}
+	if node.kind != jsonArray {
+		return int64(0), nil
+	}
+	return int64(len(node.elems)), nil
+}
+
+// DecodeJSONB converts a JSONB blob to minified JSON text.
+func DecodeJSONB(blob []byte) (string, error) {
+	node, err := parseJSONB(blob)
+	if err != nil {
+		return "", err
+	}
+	return node.text(), nil
+}
+
+// jsonKind is the type of a jsonNode.
+type jsonKind int
+
+const (
+	jsonNull jsonKind = iota
+	jsonTrue
+	jsonFalse
+	jsonInteger
+	jsonReal
+	jsonText
+	jsonArray
+	jsonObject
+)
+
+// String returns the name of the kind as reported by json_type.
+func (k jsonKind) String() string {
+	return [...]string{"null", "true", "false", "integer", "real", "text", "array", "object"}[k]
+}
+
+// jsonNode is a parsed JSON value. Object members keep their order, so that
+// extracted objects render as they were written.
+type jsonNode struct {
+	kind  jsonKind
+	str   string     // The text of a number or the unescaped value of text.
+	keys  []string   // For objects, the member keys.
+	elems []jsonNode // The array elements or object member values.
+}
+
+// sqlValue converts the node to the value json_extract returns for it.
+func (n *jsonNode) sqlValue() any {
+	switch n.kind {
+	case jsonNull:
+		return SQLNull
+	case jsonTrue:
+		return int64(1)
+	case jsonFalse:
+		return int64(0)
+	case jsonInteger:
+		if i, err := strconv.ParseInt(n.str, 0, 64); err == nil {
+			return i
+		}
+		f, _ := strconv.ParseFloat(n.str, 64) // Integers too large for int64.
+		return f
+	case jsonReal:
+		f, _ := strconv.ParseFloat(n.str, 64)
+		return f
+	case jsonText:
+		return n.str
+	}
+	return n.text()
+}
+
+// text renders the node as minified JSON.
+func (n *jsonNode) text() string {
+	var b strings.Builder
+	n.appendText(&b)
+	return b.String()
+}
+
+func (n *jsonNode) appendText(b *strings.Builder) {
+	switch n.kind {
+	case jsonNull, jsonTrue, jsonFalse:
+		b.WriteString(n.kind.String())
+	case jsonInteger, jsonReal:
+		b.WriteString(n.str)
+	case jsonText:
+		appendJSONString(b, n.str)
+	case jsonArray, jsonObject:
+		open, close := byte('['), byte(']')
+		if n.kind == jsonObject {
+			open, close = '{', '}'
+		}
+		b.WriteByte(open)
+		for i := range n.elems {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if n.kind == jsonObject {
+				appendJSONString(b, n.keys[i])
+				b.WriteByte(':')
+			}
+			n.elems[i].appendText(b)
+		}
+		b.WriteByte(close)
+	}
+}
+
+// appendJSONString appends s as a JSON string literal, without the HTML
+// escaping done by encoding/json.
+func appendJSONString(b *strings.Builder, s string) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s) // Encoding a string cannot fail.
+	b.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
+
+// jsonLookup parses value and returns its element selected by path, or nil
+// if there is none or value is NULL.
+func jsonLookup(value any, path string) (*jsonNode, error) {
+	var node jsonNode
+	var err error
+	switch v := value.(type) {
+	case NullType, nil:
+		return nil, nil
+	case string:
+		node, err = parseJSONText(v)
+	case []byte:
+		node, err = parseJSONB(v)
+	case int64, float64:
+		node, err = parseJSONText(formatScalar(v))
+	default:
+		return nil, fmt.Errorf("unsupported JSON argument of type %T", value)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return node.lookup(path)
+}
+
+// lookup returns the descendant of the node selected by path, or nil if
+// there is none.
+func (n *jsonNode) lookup(path string) (*jsonNode, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("bad JSON path: %q", path)
+	}
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			var key string
+			if strings.HasPrefix(rest, `."`) {
+				end := strings.IndexByte(rest[2:], '"')
+				if end == -1 {
+					return nil, fmt.Errorf("bad JSON path: %q", path)
+				}
+				key, rest = rest[2:2+end], rest[3+end:]
+			} else {
+				end := strings.IndexAny(rest[1:], ".[")
+				if end == -1 {
+					end = len(rest) - 1
+				}
+				key, rest = rest[1:1+end], rest[1+end:]
+			}
+			if n.kind != jsonObject {
+				return nil, nil
+			}
+			found := false
+			for i, k := range n.keys {
+				if k == key {
+					n, found = &n.elems[i], true
+					break
+				}
+			}
+			if !found {
+				return nil, nil
+			}
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("bad JSON path: %q", path)
+			}
+			spec := rest[1:end]
+			rest = rest[end+1:]
+			if n.kind != jsonArray {
+				return nil, nil
+			}
+			i, err := parseJSONIndex(spec, len(n.elems))
+			if err != nil {
+				return nil, fmt.Errorf("bad JSON path: %q", path)
+			}
+			if i < 0 || i >= len(n.elems) {
+				return nil, nil
+			}
+			n = &n.elems[i]
+		default:
+			return nil, fmt.Errorf("bad JSON path: %q", path)
+		}
+	}
+	return n, nil
+}
+
+// parseJSONIndex parses the index of an array path step, "N", "#" or "#-N",
+// for an array of the given length.
+func parseJSONIndex(spec string, length int) (int, error) {
+	if rest, ok := strings.CutPrefix(spec, "#"); ok {
+		if rest == "" {
+			return length, nil
+		}
+		n, err := strconv.Atoi(strings.TrimPrefix(rest, "-"))
+		if err != nil || !strings.HasPrefix(rest, "-") {
+			return 0, errors.New("bad index")
+		}
+		return length - n, nil
+	}
+	return strconv.Atoi(spec)
+}
+
+// parseJSONText parses JSON text.
+func parseJSONText(text string) (jsonNode, error) {
+	dec := json.NewDecoder(strings.NewReader(text))
+	dec.UseNumber()
+	node, err := decodeJSONNode(dec)
+	if err == nil {
+		if _, err = dec.Token(); err == io.EOF {
+			return node, nil
+		}
+	}
+	return jsonNode{}, errors.New("malformed JSON")
+}
+
+// decodeJSONNode reads the next JSON value from dec.
+func decodeJSONNode(dec *json.Decoder) (jsonNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return jsonNode{}, err
+	}
+	switch v := tok.(type) {
+	case nil:
+		return jsonNode{kind: jsonNull}, nil
+	case bool:
+		if v {
+			return jsonNode{kind: jsonTrue}, nil
+		}
+		return jsonNode{kind: jsonFalse}, nil
+	case json.Number:
+		if strings.ContainsAny(string(v), ".eE") {
+			return jsonNode{kind: jsonReal, str: string(v)}, nil
+		}
+		return jsonNode{kind: jsonInteger, str: string(v)}, nil
+	case string:
+		return jsonNode{kind: jsonText, str: v}, nil
+	case json.Delim:
+		node := jsonNode{kind: jsonArray}
+		if v == '{' {
+			node.kind = jsonObject
+		}
+		for dec.More() {
+			if node.kind == jsonObject {
+				key, err := dec.Token()
+				if err != nil {
+					return jsonNode{}, err
+				}
+				node.keys = append(node.keys, key.(string))
+			}
+			elem, err := decodeJSONNode(dec)
+			if err != nil {
+				return jsonNode{}, err
+			}
+			node.elems = append(node.elems, elem)
+		}
+		if _, err := dec.Token(); err != nil { // The closing delimiter.
+			return jsonNode{}, err
+		}
+		return node, nil
+	}
+	return jsonNode{}, fmt.Errorf("unexpected JSON token %v", tok)
+}
+
+// parseJSONB parses a JSONB blob, which must hold exactly one element.
+func parseJSONB(blob []byte) (jsonNode, error) {
+	node, n, err := decodeJSONBNode(blob)
+	if err != nil || n != len(blob) {
+		return jsonNode{}, errors.New("malformed JSONB")
+	}
+	return node, nil
+}
+
+// JSONB element types, stored in the low four bits of an element header.
+const (
+	jsonbNull    = 0
+	jsonbTrue    = 1
+	jsonbFalse   = 2
+	jsonbInt     = 3
+	jsonbInt5    = 4
+	jsonbFloat   = 5
+	jsonbFloat5  = 6
+	jsonbText    = 7
+	jsonbTextJ   = 8
+	jsonbText5   = 9
+	jsonbTextRaw = 10
+	jsonbArray   = 11
+	jsonbObject  = 12
+)
+
+// decodeJSONBNode decodes the JSONB element at the start of data and returns
+// it with the number of bytes it takes.
+func decodeJSONBNode(data []byte) (jsonNode, int, error) {
+	if len(data) == 0 {
+		return jsonNode{}, 0, errors.New("truncated element")
+	}
+	typ, sizeCode := data[0]&0x0f, data[0]>>4
+	headerSize, payloadSize := 1, uint64(sizeCode)
+	if sizeCode >= 12 {
+		n := 1 << (sizeCode - 12) // 1, 2, 4 or 8 bytes of size.
+		if len(data) < 1+n {
+			return jsonNode{}, 0, errors.New("truncated element header")
+		}
+		var buf [8]byte
+		copy(buf[8-n:], data[1:1+n])
+		headerSize, payloadSize = 1+n, binary.BigEndian.Uint64(buf[:])
+	}
+	if payloadSize > uint64(len(data)-headerSize) {
+		return jsonNode{}, 0, errors.New("truncated element payload")
+	}
+	payload := data[headerSize : headerSize+int(payloadSize)]
+	size := headerSize + len(payload)
+
+	switch typ {
+	case jsonbNull:
+		return jsonNode{kind: jsonNull}, size, nil
+	case jsonbTrue:
+		return jsonNode{kind: jsonTrue}, size, nil
+	case jsonbFalse:
+		return jsonNode{kind: jsonFalse}, size, nil
+	case jsonbInt:
+		return jsonNode{kind: jsonInteger, str: string(payload)}, size, nil
+	case jsonbInt5:
+		i, err := strconv.ParseInt(string(payload), 0, 64)
+		if err != nil {
+			return jsonNode{}, 0, err
+		}
+		return jsonNode{kind: jsonInteger, str: strconv.FormatInt(i, 10)}, size, nil
+	case jsonbFloat:
+		return jsonNode{kind: jsonReal, str: string(payload)}, size, nil
+	case jsonbFloat5:
+		f, err := strconv.ParseFloat(string(payload), 64)
+		if err != nil {
+			return jsonNode{}, 0, err
+		}
+		return jsonNode{kind: jsonReal, str: strconv.FormatFloat(f, 'g', -1, 64)}, size, nil
+	case jsonbText, jsonbTextRaw:
+		return jsonNode{kind: jsonText, str: string(payload)}, size, nil
+	case jsonbTextJ, jsonbText5:
+		var s string
+		if err := json.Unmarshal([]byte(`"`+string(payload)+`"`), &s); err != nil {
+			return jsonNode{}, 0, err
+		}
+		return jsonNode{kind: jsonText, str: s}, size, nil
+	case jsonbArray, jsonbObject:
+		node := jsonNode{kind: jsonArray}
+		if typ == jsonbObject {
+			node.kind = jsonObject
+		}
+		for len(payload) > 0 {
+			if node.kind == jsonObject {
+				key, n, err := decodeJSONBNode(payload)
+				if err != nil {
+					return jsonNode{}, 0, err
+				}
+				if key.kind != jsonText {
+					return jsonNode{}, 0, errors.New("object key is not text")
+				}
+				node.keys = append(node.keys, key.str)
+				payload = payload[n:]
+			}
+			elem, n, err := decodeJSONBNode(payload)
+			if err != nil {
+				return jsonNode{}, 0, err
+			}
+			node.elems = append(node.elems, elem)
+			payload = payload[n:]
+		}
+		return node, size, nil
+	}
+	return jsonNode{}, 0, fmt.Errorf("reserved element type %d", typ)
+}
@@ -0,0 +1,87 @@
+package golite
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestJSONFunctions(t *testing.T) {
+	// SQLite computes the expected results, for both JSON text and JSONB.
+	dbPath := filepath.Join(t.TempDir(), "json.sqlite")
+	runSQL(t, dbPath, `
+		CREATE TABLE docs (j TEXT);
+		INSERT INTO docs VALUES
+			('{"a":[1,2.50,"x<y",null,true,false],"b":{"c":"d\"e","n":-12},"k.e y":1e3}'),
+			('[1,[2,3],{"x":9223372036854775807}]'),
+			('"just text"'),
+			('42');
+		CREATE TABLE paths (p TEXT);
+		INSERT INTO paths VALUES ('$'), ('$.a'), ('$.a[1]'), ('$.a[2]'), ('$.a[3]'), ('$.a[4]'),
+			('$.a[#-1]'), ('$.b'), ('$.b.c'), ('$.b.n'), ('$."k.e y"'), ('$.missing'), ('$[1]'),
+			('$[1][0]'), ('$[2].x'), ('$[5]');
+		CREATE TABLE results (j TEXT, b BLOB, p TEXT, e BLOB, t TEXT, l INTEGER);
+		INSERT INTO results SELECT j, jsonb(j), p, json_extract(j, p), json_type(j, p), json_array_length(j, p)
+			FROM docs, paths;
+	`)
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	table, err := db.Table("results")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+
+	count := 0
+	for record, err := range db.TableScan(table) {
+		if err != nil {
+			t.Fatalf("TableScan() failed: %v", err)
+		}
+		count++
+		values := table.ColumnValues(record)
+		path := values[2].(string)
+		for _, doc := range values[:2] {
+			if got, err := JSONExtract(doc, path); err != nil || !reflect.DeepEqual(got, values[3]) {
+				t.Errorf("JSONExtract(%q, %q) = (%#v, %v), want %#v", values[0], path, got, err, values[3])
+			}
+			if got, err := JSONType(doc, path); err != nil || !reflect.DeepEqual(got, values[4]) {
+				t.Errorf("JSONType(%q, %q) = (%#v, %v), want %#v", values[0], path, got, err, values[4])
+			}
+			if got, err := JSONArrayLength(doc, path); err != nil || !reflect.DeepEqual(got, values[5]) {
+				t.Errorf("JSONArrayLength(%q, %q) = (%#v, %v), want %#v", values[0], path, got, err, values[5])
+			}
+		}
+		if text, err := DecodeJSONB(values[1].([]byte)); err != nil || text != minifiedJSON(t, values[0], "$") {
+			t.Errorf("DecodeJSONB() = (%q, %v) for %q", text, err, values[0])
+		}
+	}
+	if count == 0 {
+		t.Fatalf("expected results to compare against")
+	}
+
+	if got, err := JSONExtract(SQLNull, "$"); err != nil || got != SQLNull {
+		t.Errorf("expected NULL for a NULL argument, got (%v, %v)", got, err)
+	}
+	if _, err := JSONExtract("{bad", "$"); err == nil {
+		t.Errorf("expected an error for malformed JSON")
+	}
+	if _, err := JSONExtract("{}", "a"); err == nil {
+		t.Errorf("expected an error for a bad path")
+	}
+	if _, err := DecodeJSONB([]byte{0x0d}); err == nil {
+		t.Errorf("expected an error for a reserved JSONB element type")
+	}
+}
+
+// minifiedJSON returns the minified text of the element of a JSON document
+// selected by path.
+func minifiedJSON(t *testing.T, doc any, path string) string {
+	t.Helper()
+	node, err := jsonLookup(doc, path)
+	if err != nil {
+		t.Fatalf("jsonLookup() failed: %v", err)
+	}
+	return node.text()
+}
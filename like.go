@@ -0,0 +1,164 @@
+package golite
+
+import "unicode/utf8"
+
+// Like reports whether value matches pattern under the rules of SQLite's
+// LIKE operator: "%" matches any sequence of characters, "_" matches any
+// single character, and letters match ignoring case, but only for ASCII
+// letters, as SQLite does without the ICU extension. If escape is not 0, a
+// character following it in the pattern matches itself literally.
+func Like(pattern, value string, escape rune) bool {
+	var elems []patternElem
+	for i := 0; i < len(pattern); {
+		r, size := utf8.DecodeRuneInString(pattern[i:])
+		i += size
+		switch {
+		case r == escape && escape != 0 && i < len(pattern):
+			r, size = utf8.DecodeRuneInString(pattern[i:])
+			i += size
+			elems = append(elems, patternElem{kind: matchRune, r: r})
+		case r == '%':
+			elems = append(elems, patternElem{kind: matchAnySequence})
+		case r == '_':
+			elems = append(elems, patternElem{kind: matchAnyRune})
+		default:
+			elems = append(elems, patternElem{kind: matchRune, r: r})
+		}
+	}
+	return matchPattern(elems, value, true)
+}
+
+// Glob reports whether value matches pattern under the rules of SQLite's
+// GLOB operator: "*" matches any sequence of characters, "?" matches any
+// single character and "[...]" matches one character from a set, which may
+// contain ranges such as "a-z" and is negated by a leading "^". Matching is
+// case sensitive.
+func Glob(pattern, value string) bool {
+	var elems []patternElem
+	for i := 0; i < len(pattern); {
+		r, size := utf8.DecodeRuneInString(pattern[i:])
+		i += size
+		switch r {
+		case '*':
+			elems = append(elems, patternElem{kind: matchAnySequence})
+		case '?':
+			elems = append(elems, patternElem{kind: matchAnyRune})
+		case '[':
+			elem, n, ok := parseGlobClass(pattern[i:])
+			if !ok {
+				return false // SQLite never matches a pattern with an unclosed set.
+			}
+			elems = append(elems, elem)
+			i += n
+		default:
+			elems = append(elems, patternElem{kind: matchRune, r: r})
+		}
+	}
+	return matchPattern(elems, value, false)
+}
+
+// patternKind is the kind of a patternElem.
+type patternKind int
+
+const (
+	matchRune        patternKind = iota // A literal character.
+	matchAnyRune                        // "_" in LIKE, "?" in GLOB.
+	matchAnySequence                    // "%" in LIKE, "*" in GLOB.
+	matchClass                          // "[...]" in GLOB.
+)
+
+// patternElem is an element of a parsed LIKE or GLOB pattern.
+type patternElem struct {
+	kind    patternKind
+	r       rune
+	ranges  [][2]rune // For matchClass, the inclusive ranges of the set.
+	negated bool      // For matchClass, true if the set starts with "^".
+}
+
+// parseGlobClass parses a GLOB character set following its opening "[". It
+// returns the set and the number of bytes it takes, including the closing
+// "]", or false if it is not closed. A "]" right after the opening "[" or
+// "[^" belongs to the set.
+func parseGlobClass(s string) (patternElem, int, bool) {
+	elem := patternElem{kind: matchClass}
+	i := 0
+	if i < len(s) && s[i] == '^' {
+		elem.negated = true
+		i++
+	}
+	first := true
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == ']' && !first {
+			return elem, i + 1, true
+		}
+		first = false
+		i += size
+		hi := r
+		if i+1 < len(s) && s[i] == '-' && s[i+1] != ']' {
+			var n int
+			hi, n = utf8.DecodeRuneInString(s[i+1:])
+			i += 1 + n
+		}
+		elem.ranges = append(elem.ranges, [2]rune{r, hi})
+	}
+	return elem, 0, false
+}
+
+// matches reports whether a single character matches a pattern element that
+// is not matchAnySequence.
+func (e patternElem) matches(r rune, foldASCII bool) bool {
+	switch e.kind {
+	case matchAnyRune:
+		return true
+	case matchClass:
+		for _, rg := range e.ranges {
+			if rg[0] <= r && r <= rg[1] {
+				return !e.negated
+			}
+		}
+		return e.negated
+	}
+	if foldASCII {
+		return foldASCIIRune(e.r) == foldASCIIRune(r)
+	}
+	return e.r == r
+}
+
+// foldASCIIRune lowercases ASCII letters and leaves other characters alone.
+func foldASCIIRune(r rune) rune {
+	if 'A' <= r && r <= 'Z' {
+		return r + 'a' - 'A'
+	}
+	return r
+}
+
+// matchPattern reports whether value matches the pattern elements. When a
+// later element fails to match, it backtracks to the most recent sequence
+// wildcard and lets it absorb one more character, which is enough since all
+// other elements match exactly one character.
+func matchPattern(elems []patternElem, value string, foldASCII bool) bool {
+	p, v := 0, 0
+	starP, starV := -1, 0
+	for v < len(value) {
+		r, size := utf8.DecodeRuneInString(value[v:])
+		switch {
+		case p < len(elems) && elems[p].kind == matchAnySequence:
+			starP, starV = p, v
+			p++
+		case p < len(elems) && elems[p].matches(r, foldASCII):
+			p++
+			v += size
+		case starP != -1:
+			_, skipped := utf8.DecodeRuneInString(value[starV:])
+			starV += skipped
+			p, v = starP+1, starV
+		default:
+			return false
+		}
+	}
+	for p < len(elems) && elems[p].kind == matchAnySequence {
+		p++
+	}
+	return p == len(elems)
+}
@@ -0,0 +1,61 @@
+package golite
+
+import "testing"
+
+func TestLike(t *testing.T) {
+	testCases := []struct {
+		pattern, value string
+		escape         rune
+		want           bool
+	}{
+		{pattern: "abc", value: "abc", want: true},
+		{pattern: "abc", value: "ABC", want: true},
+		{pattern: "ÀB", value: "àb", want: false}, // Only ASCII letters fold.
+		{pattern: "a%", value: "abc", want: true},
+		{pattern: "%c", value: "abc", want: true},
+		{pattern: "%b%", value: "abc", want: true},
+		{pattern: "%", value: "", want: true},
+		{pattern: "_", value: "", want: false},
+		{pattern: "a_c", value: "aéc", want: true},
+		{pattern: "a_c", value: "ac", want: false},
+		{pattern: "%a%b%c%", value: "xaxxbxxcx", want: true},
+		{pattern: "%a%b%c", value: "xaxxbxxcx", want: false},
+		{pattern: "100!%", value: "100%", escape: '!', want: true},
+		{pattern: "100!%", value: "1000", escape: '!', want: false},
+		{pattern: "a!_%", value: "a_b", escape: '!', want: true},
+		{pattern: "a!_%", value: "ab", escape: '!', want: false},
+		{pattern: "a[b]", value: "a[b]", want: true},
+	}
+	for _, tc := range testCases {
+		if got := Like(tc.pattern, tc.value, tc.escape); got != tc.want {
+			t.Errorf("Like(%q, %q, %q) = %v, want %v", tc.pattern, tc.value, tc.escape, got, tc.want)
+		}
+	}
+}
+
+func TestGlob(t *testing.T) {
+	testCases := []struct {
+		pattern, value string
+		want           bool
+	}{
+		{pattern: "abc", value: "abc", want: true},
+		{pattern: "abc", value: "ABC", want: false},
+		{pattern: "a*", value: "abc", want: true},
+		{pattern: "*.go", value: "main.go", want: true},
+		{pattern: "*.go", value: "main.gox", want: false},
+		{pattern: "a?c", value: "aßc", want: true},
+		{pattern: "[a-c]x", value: "bx", want: true},
+		{pattern: "[a-c]x", value: "dx", want: false},
+		{pattern: "[^a-c]x", value: "dx", want: true},
+		{pattern: "[]]", value: "]", want: true},
+		{pattern: "[a-]", value: "-", want: true},
+		{pattern: "[abc", value: "a", want: false},
+		{pattern: "*[0-9]", value: "file7", want: true},
+		{pattern: "a%_", value: "a%_", want: true},
+	}
+	for _, tc := range testCases {
+		if got := Glob(tc.pattern, tc.value); got != tc.want {
+			t.Errorf("Glob(%q, %q) = %v, want %v", tc.pattern, tc.value, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package golite
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// LimitError reports that an iterator returned by Guard or Recursive was
+// aborted because one of its limits was exceeded.
+type LimitError struct {
+	// Limit names the exceeded limit: "max rows" or "deadline" for Guard,
+	// or "max depth" for Recursive.
+	Limit string
+	// Rows is the number of records yielded before the iteration was aborted.
+	Rows int
+}
+
+// Error returns a description of the exceeded limit.
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s limit exceeded after %d rows", e.Limit, e.Rows)
+}
+
+// Is reports whether target is ErrLimitExceeded or, for the deadline limit,
+// context.DeadlineExceeded.
+func (e *LimitError) Is(target error) bool {
+	return target == ErrLimitExceeded || e.Limit == "deadline" && target == context.DeadlineExceeded
+}
+
+// GuardOption sets a limit enforced by Guard.
+type GuardOption func(*guardLimits)
+
+// guardLimits holds the limits set by GuardOptions.
+type guardLimits struct {
+	maxRows  int // Negative if there is no limit.
+	deadline time.Time
+}
+
+// WithMaxRows limits the number of records an iterator returned by Guard may
+// yield to n. Unlike Take, which silently stops after n records, a guarded
+// iterator fails with a *LimitError when input has more.
+func WithMaxRows(n int) GuardOption {
+	return func(l *guardLimits) {
+		l.maxRows = n
+	}
+}
+
+// WithDeadline makes an iterator returned by Guard fail with a *LimitError
+// if input yields a record after the deadline.
+func WithDeadline(deadline time.Time) GuardOption {
+	return func(l *guardLimits) {
+		l.deadline = deadline
+	}
+}
+
+// Guard returns an iterator that yields the records of input, but aborts the
+// iteration with a *LimitError as soon as one of the limits set by opts is
+// exceeded. It protects services running ad-hoc queries against runaway
+// scans. Limits are checked each time input yields, so the deadline is only
+// noticed once the record being read when it passes has been decoded.
+func Guard(input RecordIterator, opts ...GuardOption) RecordIterator {
+	limits := guardLimits{maxRows: -1}
+	for _, opt := range opts {
+		opt(&limits)
+	}
+	return func(yield func(Record, error) bool) {
+		rows := 0
+		for record, err := range input {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !limits.deadline.IsZero() && time.Now().After(limits.deadline) {
+				yield(nil, &LimitError{Limit: "deadline", Rows: rows})
+				return
+			}
+			if limits.maxRows >= 0 && rows >= limits.maxRows {
+				yield(nil, &LimitError{Limit: "max rows", Rows: rows})
+				return
+			}
+			rows++
+			if !yield(record, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package golite
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestGuard(t *testing.T) {
+	t.Run("within limits", func(t *testing.T) {
+		got, err := collectInts(Guard(numbers(3, nil), WithMaxRows(3), WithDeadline(time.Now().Add(time.Hour))))
+		if err != nil || !reflect.DeepEqual(got, []int64{1, 2, 3}) {
+			t.Errorf("got (%v, %v), want ([1 2 3], nil)", got, err)
+		}
+	})
+
+	t.Run("max rows", func(t *testing.T) {
+		got, err := collectInts(Guard(numbers(5, nil), WithMaxRows(2)))
+		var limitErr *LimitError
+		if !errors.As(err, &limitErr) || limitErr.Limit != "max rows" || limitErr.Rows != 2 {
+			t.Fatalf("expected a max rows LimitError, got %v", err)
+		}
+		if !errors.Is(err, ErrLimitExceeded) || errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("unexpected error classification for %v", err)
+		}
+		if !reflect.DeepEqual(got, []int64{1, 2}) {
+			t.Errorf("expected the rows before the limit, got %v", got)
+		}
+	})
+
+	t.Run("deadline", func(t *testing.T) {
+		_, err := collectInts(Guard(numbers(5, nil), WithDeadline(time.Now().Add(-time.Second))))
+		if !errors.Is(err, ErrLimitExceeded) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected a deadline LimitError, got %v", err)
+		}
+	})
+
+	t.Run("input error", func(t *testing.T) {
+		errBoom := errors.New("boom")
+		if _, err := collectInts(Guard(numbers(1, errBoom), WithMaxRows(10))); err != errBoom {
+			t.Errorf("expected the input error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,669 @@
+package golite
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// LogicalPlan is a node of a logical query plan: a pipeline of operators
+// reading the rows of a table, built with NewScanPlan, NewFilterPlan,
+// NewProjectPlan, NewSortPlan and NewLimitPlan. Optimize rewrites a plan so
+// that the work is done as early as possible, and Execute runs it.
+type LogicalPlan interface {
+	// Columns describes the columns of the records the plan yields.
+	Columns() []ColumnInfo
+	// describe returns a one-line description of the node, without its
+	// input.
+	describe() string
+}
+
+// ScanPlan reads the rows of a table that satisfy its constraints, and
+// yields the values of its selected columns. Filters, projections and limits
+// are pushed into it by Optimize, letting Execute use an index.
+type ScanPlan struct {
+	Table       TableInfo
+	Constraints []Constraint
+	// Select names the columns yielded, or is nil for all the columns of the
+	// table.
+	Select []string
+	// Offset rows satisfying the constraints are skipped, and at most Limit
+	// of the following are yielded, or all of them if Limit is negative.
+	Offset, Limit int
+}
+
+// FilterPlan yields the records of its input that satisfy all its
+// constraints, which name columns of the input. Unlike a scan, whose
+// constraints may name any column of its table, a filter only sees the rowid
+// if it is selected.
+type FilterPlan struct {
+	Input       LogicalPlan
+	Constraints []Constraint
+}
+
+// ProjectPlan yields the values of the named columns of its input records.
+type ProjectPlan struct {
+	Input  LogicalPlan
+	Select []string
+}
+
+// SortKey is a column records are sorted by, in ascending order unless Desc.
+type SortKey struct {
+	Column string
+	Desc   bool
+}
+
+// SortPlan yields the records of its input sorted by its keys, as ORDER BY
+// does, keeping the input order of records with equal keys. If Limit is not
+// negative, only the first Limit records are kept, which needs no more
+// memory than they take.
+type SortPlan struct {
+	Input LogicalPlan
+	Keys  []SortKey
+	Limit int
+}
+
+// LimitPlan skips the first Offset records of its input and yields at most
+// Limit of the following, or all of them if Limit is negative.
+type LimitPlan struct {
+	Input         LogicalPlan
+	Offset, Limit int
+}
+
+// NewScanPlan returns a plan yielding all the rows of a table.
+func NewScanPlan(table TableInfo) *ScanPlan {
+	return &ScanPlan{Table: table, Limit: -1}
+}
+
+// NewFilterPlan returns a plan yielding the records of input that satisfy
+// all the constraints.
+func NewFilterPlan(input LogicalPlan, constraints ...Constraint) *FilterPlan {
+	return &FilterPlan{Input: input, Constraints: constraints}
+}
+
+// NewProjectPlan returns a plan yielding the values of the named columns of
+// the records of input.
+func NewProjectPlan(input LogicalPlan, columns ...string) *ProjectPlan {
+	return &ProjectPlan{Input: input, Select: columns}
+}
+
+// NewSortPlan returns a plan yielding the records of input sorted by keys.
+func NewSortPlan(input LogicalPlan, keys ...SortKey) *SortPlan {
+	return &SortPlan{Input: input, Keys: keys, Limit: -1}
+}
+
+// NewLimitPlan returns a plan yielding at most limit records of input after
+// skipping offset, as LIMIT limit OFFSET offset does.
+func NewLimitPlan(input LogicalPlan, limit, offset int) *LimitPlan {
+	return &LimitPlan{Input: input, Limit: limit, Offset: offset}
+}
+
+// Columns returns the selected columns of the table. The rowid is described
+// as an INTEGER column.
+func (p *ScanPlan) Columns() []ColumnInfo {
+	if p.Select == nil {
+		return p.Table.Columns
+	}
+	columns := make([]ColumnInfo, len(p.Select))
+	for i, name := range p.Select {
+		switch column := p.Table.lookupColumn(name); column {
+		case -1:
+			columns[i] = ColumnInfo{Name: name}
+		case rowIDColumn:
+			columns[i] = ColumnInfo{Name: name, Type: "INTEGER"}
+		default:
+			columns[i] = p.Table.Columns[column]
+		}
+	}
+	return columns
+}
+
+// Columns returns the columns of the input.
+func (p *FilterPlan) Columns() []ColumnInfo { return p.Input.Columns() }
+
+// Columns returns the selected columns of the input.
+func (p *ProjectPlan) Columns() []ColumnInfo {
+	input := planSchema(p.Input)
+	columns := make([]ColumnInfo, len(p.Select))
+	for i, name := range p.Select {
+		columns[i] = ColumnInfo{Name: name}
+		if column := input.lookupColumn(name); column >= 0 {
+			columns[i] = input.Columns[column]
+		}
+	}
+	return columns
+}
+
+// Columns returns the columns of the input.
+func (p *SortPlan) Columns() []ColumnInfo { return p.Input.Columns() }
+
+// Columns returns the columns of the input.
+func (p *LimitPlan) Columns() []ColumnInfo { return p.Input.Columns() }
+
+func (p *ScanPlan) describe() string {
+	var b strings.Builder
+	b.WriteString("SCAN " + p.Table.Name)
+	if p.Select != nil {
+		b.WriteString(" (" + strings.Join(p.Select, ", ") + ")")
+	}
+	if len(p.Constraints) > 0 {
+		b.WriteString(" WHERE " + describeConstraints(p.Constraints))
+	}
+	b.WriteString(describeLimit(p.Limit, p.Offset))
+	return b.String()
+}
+
+func (p *FilterPlan) describe() string {
+	return "FILTER " + describeConstraints(p.Constraints)
+}
+
+func (p *ProjectPlan) describe() string {
+	return "PROJECT " + strings.Join(p.Select, ", ")
+}
+
+func (p *SortPlan) describe() string {
+	keys := make([]string, len(p.Keys))
+	for i, key := range p.Keys {
+		keys[i] = key.Column
+		if key.Desc {
+			keys[i] += " DESC"
+		}
+	}
+	return "SORT " + strings.Join(keys, ", ") + describeLimit(p.Limit, 0)
+}
+
+func (p *LimitPlan) describe() string {
+	return strings.TrimSpace(describeLimit(p.Limit, p.Offset))
+}
+
+// String returns the constraint in SQL, such as "b > 3".
+func (c Constraint) String() string {
+	if c.Op == OpIsNull {
+		return c.Column + " IS NULL"
+	}
+	value := c.Value
+	if value == nil {
+		value = SQLNull
+	}
+	return fmt.Sprintf("%s %v %s", c.Column, c.Op, quoteLiteral(value))
+}
+
+// describeConstraints joins constraints with AND.
+func describeConstraints(constraints []Constraint) string {
+	terms := make([]string, len(constraints))
+	for i, c := range constraints {
+		terms[i] = c.String()
+	}
+	return strings.Join(terms, " AND ")
+}
+
+// describeLimit returns the LIMIT and OFFSET clauses, if any, with a
+// leading space.
+func describeLimit(limit, offset int) string {
+	var s string
+	if limit >= 0 {
+		s += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	if offset > 0 {
+		s += fmt.Sprintf(" OFFSET %d", offset)
+	}
+	return s
+}
+
+// ExplainPlan describes a plan, one node per line, each node being followed
+// by its input, indented.
+func ExplainPlan(plan LogicalPlan) string {
+	var b strings.Builder
+	for depth := 0; plan != nil; depth++ {
+		fmt.Fprintf(&b, "%s%s\n", strings.Repeat("  ", depth), plan.describe())
+		plan = planInput(plan)
+	}
+	return b.String()
+}
+
+// planInput returns the input of a plan node, or nil for a scan.
+func planInput(plan LogicalPlan) LogicalPlan {
+	switch p := plan.(type) {
+	case *FilterPlan:
+		return p.Input
+	case *ProjectPlan:
+		return p.Input
+	case *SortPlan:
+		return p.Input
+	case *LimitPlan:
+		return p.Input
+	}
+	return nil
+}
+
+// withInput returns a copy of a plan node reading from input.
+func withInput(plan LogicalPlan, input LogicalPlan) LogicalPlan {
+	switch p := plan.(type) {
+	case *FilterPlan:
+		c := *p
+		c.Input = input
+		return &c
+	case *ProjectPlan:
+		c := *p
+		c.Input = input
+		return &c
+	case *SortPlan:
+		c := *p
+		c.Input = input
+		return &c
+	case *LimitPlan:
+		c := *p
+		c.Input = input
+		return &c
+	}
+	return plan
+}
+
+// planSchema returns a TableInfo describing the records a plan yields, so
+// that their columns can be looked up by name.
+func planSchema(plan LogicalPlan) TableInfo {
+	return TableInfo{Columns: plan.Columns(), RowIDColumnIndex: -1, WithoutRowID: true}
+}
+
+// Rule is a rewrite of a logical plan node applied by Optimize. It returns
+// the rewritten node and true, or the node and false if the rule does not
+// apply. A rule must not modify the nodes it is given.
+type Rule func(plan LogicalPlan) (LogicalPlan, bool)
+
+// DefaultRules are the rules applied by Optimize when none are given. They
+// push filters below sorts and projections and into scans, limits into
+// scans, and projections below limits and sorts and into scans; merge
+// adjacent filters, projections and limits; and bound sorts followed by a
+// limit.
+var DefaultRules = []Rule{
+	MergeFilters,
+	PushFilterDown,
+	MergeLimits,
+	PushLimitDown,
+	MergeProjections,
+	PushProjectionDown,
+}
+
+// Optimize rewrites a plan with rules, or DefaultRules if none are given,
+// until none applies. The inputs of a node are rewritten before the node
+// itself, and a rewritten node is rewritten again, so rules can move an
+// operator down the plan one step at a time. The plan given is not
+// modified.
+func Optimize(plan LogicalPlan, rules ...Rule) LogicalPlan {
+	if len(rules) == 0 {
+		rules = DefaultRules
+	}
+	if input := planInput(plan); input != nil {
+		if optimized := Optimize(input, rules...); optimized != input {
+			plan = withInput(plan, optimized)
+		}
+	}
+	for _, rule := range rules {
+		if rewritten, ok := rule(plan); ok {
+			return Optimize(rewritten, rules...)
+		}
+	}
+	return plan
+}
+
+// MergeFilters merges a filter reading from a filter into one.
+func MergeFilters(plan LogicalPlan) (LogicalPlan, bool) {
+	outer, ok := plan.(*FilterPlan)
+	if !ok {
+		return plan, false
+	}
+	inner, ok := outer.Input.(*FilterPlan)
+	if !ok {
+		return plan, false
+	}
+	return NewFilterPlan(inner.Input, slices.Concat(inner.Constraints, outer.Constraints)...), true
+}
+
+// PushFilterDown moves a filter below a sort or a projection, whose records
+// satisfy the same constraints, and into a scan without a limit.
+func PushFilterDown(plan LogicalPlan) (LogicalPlan, bool) {
+	filter, ok := plan.(*FilterPlan)
+	if !ok {
+		return plan, false
+	}
+	switch input := filter.Input.(type) {
+	case *SortPlan:
+		if input.Limit >= 0 {
+			return plan, false
+		}
+		return withInput(input, withInput(filter, input.Input)), true
+	case *ProjectPlan:
+		return withInput(input, withInput(filter, input.Input)), true
+	case *ScanPlan:
+		if input.Limit >= 0 || input.Offset > 0 {
+			return plan, false
+		}
+		scan := *input
+		scan.Constraints = slices.Concat(scan.Constraints, filter.Constraints)
+		return &scan, true
+	}
+	return plan, false
+}
+
+// MergeLimits merges a limit reading from a limit into one.
+func MergeLimits(plan LogicalPlan) (LogicalPlan, bool) {
+	outer, ok := plan.(*LimitPlan)
+	if !ok {
+		return plan, false
+	}
+	inner, ok := outer.Input.(*LimitPlan)
+	if !ok {
+		return plan, false
+	}
+	offset, limit := combineLimits(inner.Offset, inner.Limit, outer.Offset, outer.Limit)
+	return NewLimitPlan(inner.Input, limit, offset), true
+}
+
+// combineLimits returns the offset and limit of applying a second offset and
+// limit after a first.
+func combineLimits(offset1, limit1, offset2, limit2 int) (offset, limit int) {
+	offset = offset1 + offset2
+	limit = limit2
+	if limit1 >= 0 {
+		limit = max(limit1-offset2, 0)
+		if limit2 >= 0 {
+			limit = min(limit, limit2)
+		}
+	}
+	return offset, limit
+}
+
+// PushLimitDown moves a limit into a scan, and bounds a sort it reads from,
+// directly or through a projection, to the records it needs, keeping the
+// limit above it.
+func PushLimitDown(plan LogicalPlan) (LogicalPlan, bool) {
+	limit, ok := plan.(*LimitPlan)
+	if !ok {
+		return plan, false
+	}
+	switch input := limit.Input.(type) {
+	case *ScanPlan:
+		scan := *input
+		scan.Offset, scan.Limit = combineLimits(scan.Offset, scan.Limit, limit.Offset, limit.Limit)
+		return &scan, true
+	case *SortPlan:
+		if sorted, ok := boundSort(input, limit); ok {
+			return withInput(limit, sorted), true
+		}
+	case *ProjectPlan:
+		if input, ok := input.Input.(*SortPlan); ok {
+			if sorted, ok := boundSort(input, limit); ok {
+				return withInput(limit, withInput(limit.Input, sorted)), true
+			}
+		}
+	}
+	return plan, false
+}
+
+// boundSort returns a copy of a sort keeping only the records needed by a
+// limit, and false if it already keeps no more.
+func boundSort(input *SortPlan, limit *LimitPlan) (*SortPlan, bool) {
+	if limit.Limit < 0 || input.Limit >= 0 && input.Limit <= limit.Offset+limit.Limit {
+		return input, false
+	}
+	sorted := *input
+	sorted.Limit = limit.Offset + limit.Limit
+	return &sorted, true
+}
+
+// MergeProjections merges a projection reading from a projection into one.
+func MergeProjections(plan LogicalPlan) (LogicalPlan, bool) {
+	outer, ok := plan.(*ProjectPlan)
+	if !ok {
+		return plan, false
+	}
+	inner, ok := outer.Input.(*ProjectPlan)
+	if !ok {
+		return plan, false
+	}
+	return NewProjectPlan(inner.Input, outer.Select...), true
+}
+
+// PushProjectionDown moves a projection below a limit, into a scan yielding
+// all the columns of its table, and below a sort, keeping the columns it
+// sorts by until the records are sorted.
+func PushProjectionDown(plan LogicalPlan) (LogicalPlan, bool) {
+	project, ok := plan.(*ProjectPlan)
+	if !ok {
+		return plan, false
+	}
+	switch input := project.Input.(type) {
+	case *LimitPlan:
+		return withInput(input, withInput(project, input.Input)), true
+	case *ScanPlan:
+		if input.Select != nil {
+			return plan, false
+		}
+		scan := *input
+		scan.Select = project.Select
+		return &scan, true
+	case *SortPlan:
+		var columns []string
+		keysSelected := true
+		add := func(name string) bool {
+			if slices.ContainsFunc(columns, func(c string) bool { return strings.EqualFold(c, name) }) {
+				return false
+			}
+			columns = append(columns, name)
+			return true
+		}
+		for _, name := range project.Select {
+			add(name)
+		}
+		for _, key := range input.Keys {
+			if add(key.Column) {
+				keysSelected = false
+			}
+		}
+		if keysSelected {
+			return withInput(input, withInput(project, input.Input)), true
+		}
+		if len(input.Input.Columns()) <= len(columns) {
+			return plan, false // The sort reads no column it does not need.
+		}
+		return withInput(project, withInput(input, NewProjectPlan(input.Input, columns...))), true
+	}
+	return plan, false
+}
+
+// Execute returns an iterator over the records a plan yields. A scan with
+// constraints uses the access path chosen by PlanAccess for its equalities:
+// a rowid seek, or the range of an index found by ExtractRange.
+func (db *Database) Execute(plan LogicalPlan) RecordIterator {
+	return interruptible(db, db.execute(plan))
+}
+
+// execute returns the iterator Execute wraps.
+func (db *Database) execute(plan LogicalPlan) RecordIterator {
+	switch p := plan.(type) {
+	case *ScanPlan:
+		return db.executeScan(p)
+	case *FilterPlan:
+		return func(yield func(Record, error) bool) {
+			match, err := planSchema(p.Input).MatchConstraints(p.Constraints)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			for record, err := range Filter(db.Execute(p.Input), match) {
+				if !yield(record, err) || err != nil {
+					return
+				}
+			}
+		}
+	case *ProjectPlan:
+		return func(yield func(Record, error) bool) {
+			input := planSchema(p.Input)
+			for record, err := range projectColumns(db.Execute(p.Input), input, p.Select) {
+				if !yield(record, err) || err != nil {
+					return
+				}
+			}
+		}
+	case *SortPlan:
+		return db.executeSort(p)
+	case *LimitPlan:
+		return limitRecords(db.Execute(p.Input), p.Offset, p.Limit)
+	}
+	return func(yield func(Record, error) bool) {
+		yield(nil, fmt.Errorf("unknown logical plan node %T", plan))
+	}
+}
+
+// executeScan executes a scan, see Execute.
+func (db *Database) executeScan(p *ScanPlan) RecordIterator {
+	return func(yield func(Record, error) bool) {
+		table := p.Table
+		match, err := table.MatchConstraints(p.Constraints)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		rows := Filter(db.TableScan(table), match)
+		if len(p.Constraints) > 0 {
+			var equalities []string
+			for _, c := range p.Constraints {
+				if c.Op == OpEq && !isNull(c.Value) {
+					equalities = append(equalities, c.Column)
+				}
+			}
+			access, err := db.PlanAccess(table, equalities, p.Select)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			switch access.Path {
+			case RowIDSeekPath:
+				for _, c := range p.Constraints {
+					if column := table.lookupColumn(c.Column); c.Op == OpEq && (column == rowIDColumn || column == table.RowIDColumnIndex) {
+						if rowID, ok := AffinityInteger.apply(c.Value).(int64); ok {
+							rows = Filter(db.TableSeek(table, rowID), match)
+						}
+						break
+					}
+				}
+			case IndexSeekPath:
+				rows = db.ConstrainedScan(table, access.Index, p.Constraints)
+			}
+		}
+		if len(p.Constraints) == 0 && p.Select != nil {
+			// Only the selected columns need to be decoded.
+			rows = limitRecords(db.TableScanColumns(table, p.Select), p.Offset, p.Limit)
+		} else if rows = limitRecords(rows, p.Offset, p.Limit); p.Select != nil {
+			rows = projectColumns(rows, table, p.Select)
+		} else if table.RowIDColumnIndex == -1 && !table.WithoutRowID {
+			rows = projectColumns(rows, table, columnNames(table))
+		}
+		for record, err := range rows {
+			if !yield(record, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// columnNames returns the names of the columns of a table.
+func columnNames(table TableInfo) []string {
+	names := make([]string, len(table.Columns))
+	for i, col := range table.Columns {
+		names[i] = col.Name
+	}
+	return names
+}
+
+// limitRecords skips offset records of input and yields at most n of the
+// following, or all of them if n is negative.
+func limitRecords(input RecordIterator, offset, n int) RecordIterator {
+	if offset > 0 {
+		input = Skip(input, offset)
+	}
+	if n >= 0 {
+		input = Take(input, n)
+	}
+	return input
+}
+
+// projectColumns yields the values of the named columns of the records of
+// input, described by table.
+func projectColumns(input RecordIterator, table TableInfo, columns []string) RecordIterator {
+	return func(yield func(Record, error) bool) {
+		indexes := make([]int, len(columns))
+		for i, name := range columns {
+			if indexes[i] = table.lookupColumn(name); indexes[i] == -1 {
+				yield(nil, fmt.Errorf("no such column: %s", name))
+				return
+			}
+		}
+		for record, err := range input {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			values := make(Record, len(indexes))
+			for i, column := range indexes {
+				values[i] = table.columnValue(record, column)
+			}
+			if !yield(values, nil) {
+				return
+			}
+		}
+	}
+}
+
+// executeSort executes a sort, see Execute. A sort with a limit keeps the
+// records it has read sorted, dropping those past the limit.
+func (db *Database) executeSort(p *SortPlan) RecordIterator {
+	return func(yield func(Record, error) bool) {
+		input := planSchema(p.Input)
+		keys := make([]int, len(p.Keys))
+		for i, key := range p.Keys {
+			if keys[i] = input.lookupColumn(key.Column); keys[i] == -1 {
+				yield(nil, fmt.Errorf("no such column: %s", key.Column))
+				return
+			}
+		}
+		compare := func(a, b Record) int {
+			for i, key := range p.Keys {
+				c := compareValues(input.columnValue(a, keys[i]), input.columnValue(b, keys[i]))
+				if key.Desc {
+					c = -c
+				}
+				if c != 0 {
+					return c
+				}
+			}
+			return 0
+		}
+		var records []Record
+		for record, err := range db.Execute(p.Input) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if p.Limit < 0 {
+				records = append(records, record.Copy())
+				continue
+			}
+			// Insert the record after those that sort before or with it.
+			i := sort.Search(len(records), func(i int) bool { return compare(records[i], record) > 0 })
+			if i < p.Limit {
+				records = slices.Insert(records, i, record.Copy())
+				records = records[:min(len(records), p.Limit)]
+			}
+		}
+		if p.Limit < 0 {
+			slices.SortStableFunc(records, compare)
+		}
+		for _, record := range records {
+			if !yield(record, nil) {
+				return
+			}
+		}
+	}
+}
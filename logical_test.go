@@ -0,0 +1,148 @@
+package golite
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOptimize(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "logical.sqlite")
+	tests := []struct {
+		plan    func(t TableInfo) LogicalPlan
+		explain string
+		query   string
+		columns []string
+	}{
+		{
+			func(t TableInfo) LogicalPlan {
+				scan := NewFilterPlan(NewScanPlan(t), Constraint{"b", OpGt, int64(3)})
+				sorted := NewSortPlan(scan, SortKey{"c", true}, SortKey{"a", false})
+				return NewLimitPlan(NewProjectPlan(sorted, "a", "c"), 5, 2)
+			},
+			"LIMIT 5 OFFSET 2\n  SORT c DESC, a LIMIT 7\n    SCAN t (a, c) WHERE b > 3\n",
+			"SELECT a, c FROM t WHERE b > 3 ORDER BY c DESC, a LIMIT 5 OFFSET 2",
+			[]string{"a", "c"},
+		},
+		{
+			func(t TableInfo) LogicalPlan {
+				sorted := NewSortPlan(NewScanPlan(t), SortKey{"b", true}, SortKey{"a", false})
+				return NewProjectPlan(NewFilterPlan(sorted, Constraint{"b", OpEq, int64(4)}), "a")
+			},
+			"PROJECT a\n  SORT b DESC, a\n    SCAN t (a, b) WHERE b = 4\n",
+			"SELECT a FROM t WHERE b = 4 ORDER BY b DESC, a",
+			[]string{"a"},
+		},
+		{
+			func(t TableInfo) LogicalPlan {
+				return NewLimitPlan(NewFilterPlan(NewScanPlan(t), Constraint{"a", OpGe, int64(17)}, Constraint{"a", OpEq, "17"}), 3, 0)
+			},
+			"SCAN t WHERE a >= 17 AND a = '17' LIMIT 3\n",
+			"SELECT * FROM t WHERE a >= 17 AND a = '17' LIMIT 3",
+			[]string{"a", "b", "c"},
+		},
+		{
+			func(t TableInfo) LogicalPlan {
+				return NewLimitPlan(NewLimitPlan(NewScanPlan(t), 10, 5), 3, 4)
+			},
+			"SCAN t LIMIT 3 OFFSET 9\n",
+			"SELECT * FROM (SELECT * FROM t LIMIT 10 OFFSET 5) LIMIT 3 OFFSET 4",
+			[]string{"a", "b", "c"},
+		},
+		{
+			func(t TableInfo) LogicalPlan {
+				sorted := NewSortPlan(NewScanPlan(t), SortKey{"b", false}, SortKey{"a", false})
+				return NewLimitPlan(NewProjectPlan(sorted, "a"), 3, 1)
+			},
+			"LIMIT 3 OFFSET 1\n  PROJECT a\n    SORT b, a LIMIT 4\n      SCAN t (a, b)\n",
+			"SELECT a FROM t ORDER BY b, a LIMIT 3 OFFSET 1",
+			[]string{"a"},
+		},
+		{
+			func(t TableInfo) LogicalPlan {
+				limited := NewLimitPlan(NewProjectPlan(NewScanPlan(t), "c", "a"), 10, 0)
+				return NewFilterPlan(NewFilterPlan(limited, Constraint{"c", OpEq, "c2"}), Constraint{"a", OpLt, int64(8)})
+			},
+			"FILTER c = 'c2' AND a < 8\n  SCAN t (c, a) LIMIT 10\n",
+			"SELECT * FROM (SELECT c, a FROM t LIMIT 10) WHERE c = 'c2' AND a < 8",
+			[]string{"c", "a"},
+		},
+		{
+			func(t TableInfo) LogicalPlan {
+				sorted := NewSortPlan(NewScanPlan(t), SortKey{"c", false}, SortKey{"a", true})
+				return NewProjectPlan(NewProjectPlan(NewLimitPlan(sorted, 4, 0), "c", "a", "b"), "a", "b")
+			},
+			"LIMIT 4\n  PROJECT a, b\n    SORT c, a DESC LIMIT 4\n      SCAN t (c, a, b)\n",
+			"SELECT a, b FROM t ORDER BY c, a DESC LIMIT 4",
+			[]string{"a", "b"},
+		},
+	}
+	var sql strings.Builder
+	sql.WriteString(`
+		CREATE TABLE t (a INTEGER PRIMARY KEY, b INTEGER, c TEXT);
+		CREATE INDEX t_b ON t(b);
+		WITH RECURSIVE seq(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM seq WHERE i < 200)
+		INSERT INTO t SELECT i, i % 10, 'c' || (i % 7) FROM seq;
+		CREATE TABLE expected (id INTEGER, result TEXT);
+	`)
+	for i, test := range tests {
+		fmt.Fprintf(&sql, "INSERT INTO expected SELECT %d, group_concat(%s) FROM (%s);\n", i, strings.Join(test.columns, " || ':' || "), test.query)
+	}
+	runSQL(t, dbPath, sql.String())
+
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	expected := map[int64]string{}
+	table, err := db.Table("expected")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+	for record, err := range db.TableScan(table) {
+		if err != nil {
+			t.Fatalf("TableScan() failed: %v", err)
+		}
+		values := table.ColumnValues(record)
+		expected[values[0].(int64)], _ = values[1].(string)
+	}
+
+	tTable, err := db.Table("t")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+	for i, test := range tests {
+		plan := test.plan(tTable)
+		before := ExplainPlan(plan)
+		optimized := Optimize(plan)
+		if got := ExplainPlan(optimized); got != test.explain {
+			t.Errorf("Optimize(\n%s) =\n%s\nwant\n%s", before, got, test.explain)
+		}
+		if got := ExplainPlan(plan); got != before {
+			t.Errorf("Optimize() modified its input:\n%s", got)
+		}
+		for _, p := range []LogicalPlan{plan, optimized} {
+			var rows []string
+			for record, err := range db.Execute(p) {
+				if err != nil {
+					t.Fatalf("Execute(\n%s) failed: %v", ExplainPlan(p), err)
+				}
+				values := make([]string, len(record))
+				for j, v := range record {
+					values[j] = formatScalar(v)
+				}
+				rows = append(rows, strings.Join(values, ":"))
+			}
+			if got := strings.Join(rows, ","); got != expected[int64(i)] {
+				t.Errorf("Execute(\n%s) = %s, want %s", ExplainPlan(p), got, expected[int64(i)])
+			}
+		}
+	}
+
+	bad := NewProjectPlan(NewScanPlan(tTable), "nope")
+	if _, err := CollectRecords(db.Execute(Optimize(bad))); err == nil {
+		t.Errorf("expected an error for an unknown column")
+	}
+}
@@ -0,0 +1,113 @@
+package golite
+
+import (
+	"fmt"
+
+	"github.com/arnodel/golite/tempstore"
+)
+
+// Relation is a fully buffered sequence of records, produced by Materialize
+// or MaterializeTo. Unlike a RecordIterator, it can be iterated any number of
+// times and its records can be read by position. It is safe for concurrent
+// reads.
+type Relation struct {
+	// records holds the records of an in-memory relation.
+	records []Record
+	// file holds the encoded records of a relation stored in a temporary
+	// file, each starting at the corresponding offset.
+	file    tempstore.File
+	offsets []int64
+}
+
+// Materialize reads all the records of input into memory and returns them as
+// a Relation. Use it when an input must be consumed several times, such as
+// the inner side of a nested loop join, without running it again.
+func Materialize(input RecordIterator) (*Relation, error) {
+	records, err := CollectRecords(input)
+	if err != nil {
+		return nil, err
+	}
+	return &Relation{records: records}, nil
+}
+
+// MaterializeTo is like Materialize, but stores the records in a temporary
+// file created in store rather than in memory, for inputs too large to hold.
+// The file is deleted when the Relation is closed, or when the store is.
+// The values of the records must be of types accepted by EncodeRecord.
+func MaterializeTo(store *tempstore.Store, input RecordIterator) (*Relation, error) {
+	file, err := store.Create()
+	if err != nil {
+		return nil, err
+	}
+	r := &Relation{file: file}
+	for record, err := range input {
+		if err == nil {
+			var data []byte
+			if data, err = EncodeRecord(record); err == nil {
+				r.offsets = append(r.offsets, file.Size())
+				_, err = file.Write(data)
+			}
+		}
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	r.offsets = append(r.offsets, file.Size())
+	return r, nil
+}
+
+// Len returns the number of records in the relation.
+func (r *Relation) Len() int {
+	if r.file != nil {
+		return len(r.offsets) - 1
+	}
+	return len(r.records)
+}
+
+// Get returns the record at position i, counting from 0.
+func (r *Relation) Get(i int) (Record, error) {
+	if i < 0 || i >= r.Len() {
+		return nil, fmt.Errorf("record %d out of range for a relation of %d records", i, r.Len())
+	}
+	if r.file == nil {
+		return r.records[i], nil
+	}
+	data := make([]byte, r.offsets[i+1]-r.offsets[i])
+	if _, err := r.file.ReadAt(data, r.offsets[i]); err != nil {
+		return nil, fmt.Errorf("failed to read materialized record %d: %w", i, err)
+	}
+	return parseRecord(data) // The values alias data, which is not reused.
+}
+
+// Scan returns an iterator over the records of the relation, in the order
+// they were read from the input.
+func (r *Relation) Scan() RecordIterator {
+	return r.ScanFrom(0)
+}
+
+// ScanFrom returns an iterator over the records of the relation starting at
+// position i.
+func (r *Relation) ScanFrom(i int) RecordIterator {
+	return func(yield func(Record, error) bool) {
+		for ; i < r.Len(); i++ {
+			record, err := r.Get(i)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(record, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Close releases the temporary file of a relation created by MaterializeTo.
+// It does nothing for in-memory relations.
+func (r *Relation) Close() error {
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Close()
+}
@@ -0,0 +1,64 @@
+package golite
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/arnodel/golite/tempstore"
+)
+
+func TestMaterialize(t *testing.T) {
+	input := func(yield func(Record, error) bool) {
+		for i := range 100 {
+			if !yield(Record{int64(i), "row", 1.5, []byte{byte(i)}, SQLNull}, nil) {
+				return
+			}
+		}
+	}
+	want, _ := CollectRecords(input)
+
+	store := tempstore.NewMemory()
+	defer store.Close()
+	materialize := map[string]func(RecordIterator) (*Relation, error){
+		"memory": Materialize,
+		"file": func(input RecordIterator) (*Relation, error) {
+			return MaterializeTo(store, input)
+		},
+	}
+	for name, materialize := range materialize {
+		t.Run(name, func(t *testing.T) {
+			r, err := materialize(input)
+			if err != nil {
+				t.Fatalf("materializing failed: %v", err)
+			}
+			defer r.Close()
+			if r.Len() != 100 {
+				t.Fatalf("expected 100 records, got %d", r.Len())
+			}
+			for range 2 {
+				got, err := CollectRecords(r.Scan())
+				if err != nil || !reflect.DeepEqual(got, want) {
+					t.Fatalf("Scan() returned different records, error %v", err)
+				}
+			}
+			if got, err := r.Get(42); err != nil || !reflect.DeepEqual(got, want[42]) {
+				t.Errorf("Get(42) = (%v, %v), want %v", got, err, want[42])
+			}
+			if got, err := CollectRecords(r.ScanFrom(98)); err != nil || len(got) != 2 {
+				t.Errorf("expected 2 records from ScanFrom(98), got (%d, %v)", len(got), err)
+			}
+			if _, err := r.Get(100); err == nil {
+				t.Errorf("expected an error for an out of range position")
+			}
+
+			errBoom := errors.New("boom")
+			if _, err := materialize(numbers(3, errBoom)); err != errBoom {
+				t.Errorf("expected the input error, got %v", err)
+			}
+		})
+	}
+	if store.Len() != 0 {
+		t.Errorf("expected all temporary files to be released, got %d", store.Len())
+	}
+}
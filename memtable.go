@@ -0,0 +1,283 @@
+package golite
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// TableSource is a table that can be scanned and searched, whether it is
+// stored in a database, as returned by Database.Source, or held in memory by
+// a MemTable. Operators reading a relation through it need not care where it
+// lives.
+type TableSource interface {
+	// Info describes the table.
+	Info() TableInfo
+	// Scan returns an iterator over the rows of the table, as TableScan.
+	Scan() RecordIterator
+	// SeekRowID returns an iterator over the row with the given rowid, if any,
+	// as TableSeek.
+	SeekRowID(rowID int64) RecordIterator
+	// SeekKey returns an iterator over the rows of a WITHOUT ROWID table
+	// whose primary key starts with key, as PrimaryKeySeek.
+	SeekKey(key Record) RecordIterator
+}
+
+// Source returns the TableSource reading table from the database.
+func (db *Database) Source(table TableInfo) TableSource {
+	return diskTable{db: db, info: table}
+}
+
+// diskTable is the TableSource returned by Database.Source.
+type diskTable struct {
+	db   *Database
+	info TableInfo
+}
+
+func (t diskTable) Info() TableInfo                      { return t.info }
+func (t diskTable) Scan() RecordIterator                 { return t.db.TableScan(t.info) }
+func (t diskTable) SeekRowID(rowID int64) RecordIterator { return t.db.TableSeek(t.info, rowID) }
+func (t diskTable) SeekKey(key Record) RecordIterator    { return t.db.PrimaryKeySeek(t.info, key) }
+
+// MemTable is a table held in memory, for the relations an execution builds
+// as it runs: the rows of a common table expression, the distinct records
+// of a DISTINCT, or rows staged in any order before they are passed to
+// DatabaseBuilder.InsertFrom, which needs them in rowid order. Its rows are
+// kept in a slice sorted like the B-Tree of the table would be, by rowid or
+// by primary key for a WITHOUT ROWID table, and it implements TableSource
+// with the same records and order as a table of a database.
+//
+// A MemTable must not be modified while it is scanned, nor from several
+// goroutines at once.
+type MemTable struct {
+	info TableInfo
+	// rows holds the records as Scan yields them, sorted by key.
+	rows []Record
+	// key holds the index in the records of each column of the primary key
+	// of a WITHOUT ROWID table, and index describes its order.
+	key   []int
+	index IndexInfo
+}
+
+// NewMemTable returns an empty MemTable for the table defined by a CREATE
+// TABLE statement. For instance, the distinct pairs of values of a query can
+// be collected with InsertOrIgnore in the table
+//
+//	CREATE TABLE distinct_pairs(a ANY, b ANY, PRIMARY KEY (a, b)) WITHOUT ROWID
+func NewMemTable(sql string) (*MemTable, error) {
+	sql = strings.TrimRight(strings.TrimSpace(sql), ";")
+	name, err := createdName(sql, "TABLE")
+	if err != nil {
+		return nil, err
+	}
+	def, err := parseCreateTable(sql)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema for table %q: %w", name, err)
+	}
+	if def.withoutRowID && def.primaryKey == nil {
+		return nil, fmt.Errorf("PRIMARY KEY missing on table %s", name)
+	}
+	for _, col := range def.columns {
+		if col.Hidden != ColumnNormal {
+			return nil, unsupported("generated columns")
+		}
+	}
+	t := &MemTable{info: TableInfo{
+		Name:             name,
+		SQL:              sql,
+		Columns:          def.columns,
+		RowIDColumnIndex: def.rowIDColumnIndex,
+		ForeignKeys:      def.foreignKeys,
+		Checks:           def.checks,
+		WithoutRowID:     def.withoutRowID,
+		PrimaryKey:       def.primaryKey,
+	}}
+	if index, ok := t.info.PrimaryKeyIndex(); ok {
+		t.index = index
+		for _, col := range index.Columns {
+			t.key = append(t.key, t.info.lookupColumn(col.Name))
+		}
+	}
+	return t, nil
+}
+
+// Info describes the table. Its RootPage is 0.
+func (t *MemTable) Info() TableInfo {
+	return t.info
+}
+
+// Len returns the number of rows in the table.
+func (t *MemTable) Len() int {
+	return len(t.rows)
+}
+
+// Insert adds a row to the table, as INSERT does. The record holds the
+// values of the columns in the order they are declared, to which the
+// affinity of the column is applied. The rowid of a table with a rowid is
+// the value of its INTEGER PRIMARY KEY column or, if it is NULL or the table
+// has none, one more than the largest rowid in the table. Inserting a row
+// whose rowid or primary key is already in the table fails. Other
+// constraints are not checked.
+func (t *MemTable) Insert(record Record) error {
+	inserted, err := t.InsertOrIgnore(record)
+	if err == nil && !inserted {
+		err = fmt.Errorf("UNIQUE constraint failed: %s", t.keyName())
+	}
+	return err
+}
+
+// InsertOrIgnore is like Insert, but does nothing and returns false if the
+// rowid or primary key of the row is already in the table, as INSERT OR
+// IGNORE does.
+func (t *MemTable) InsertOrIgnore(record Record) (bool, error) {
+	if len(record) != len(t.info.Columns) {
+		return false, fmt.Errorf("table %s has %d columns but %d values were supplied", t.info.Name, len(t.info.Columns), len(record))
+	}
+	values := make(Record, len(record))
+	for i, value := range record {
+		if value == nil {
+			value = SQLNull
+		}
+		values[i] = t.info.Columns[i].Affinity().apply(value)
+	}
+
+	if t.info.WithoutRowID {
+		for i, col := range t.key {
+			if isNull(values[col]) {
+				return false, fmt.Errorf("NOT NULL constraint failed: %s.%s", t.info.Name, t.index.Columns[i].Name)
+			}
+		}
+		key := make(Record, len(t.key))
+		for i, col := range t.key {
+			key[i] = values[col]
+		}
+		i, found := t.search(func(row Record) int { return t.compareKeys(row, key) })
+		if found {
+			return false, nil
+		}
+		t.rows = slices.Insert(t.rows, i, values)
+		return true, nil
+	}
+
+	var rowID int64 = 1
+	if n := len(t.rows); n > 0 {
+		last := t.info.RowID(t.rows[n-1])
+		if last == math.MaxInt64 {
+			return false, fmt.Errorf("no rowid left in table %s", t.info.Name)
+		}
+		rowID = last + 1
+	}
+	if i := t.info.RowIDColumnIndex; i != -1 {
+		if !isNull(values[i]) {
+			id, ok := values[i].(int64)
+			if !ok {
+				return false, fmt.Errorf("datatype mismatch: %s.%s is not an integer", t.info.Name, t.info.Columns[i].Name)
+			}
+			rowID = id
+		}
+		values[i] = rowID
+	} else {
+		values = append(Record{rowID}, values...)
+	}
+	i, found := t.searchRowID(rowID)
+	if found {
+		return false, nil
+	}
+	t.rows = slices.Insert(t.rows, i, values)
+	return true, nil
+}
+
+// Scan returns an iterator over the rows of the table in rowid order, or in
+// primary key order for a WITHOUT ROWID table. The records are those
+// TableScan would yield for the table: for a table with a rowid but no
+// INTEGER PRIMARY KEY column, the rowid comes first. The records are those
+// held by the table, and must not be modified.
+func (t *MemTable) Scan() RecordIterator {
+	return func(yield func(Record, error) bool) {
+		for _, row := range t.rows {
+			if !yield(row, nil) {
+				return
+			}
+		}
+	}
+}
+
+// SeekRowID returns an iterator over the row with the given rowid, if any.
+func (t *MemTable) SeekRowID(rowID int64) RecordIterator {
+	return func(yield func(Record, error) bool) {
+		if t.info.WithoutRowID {
+			yield(nil, fmt.Errorf("table %s is a WITHOUT ROWID table", t.info.Name))
+			return
+		}
+		if i, found := t.searchRowID(rowID); found {
+			yield(t.rows[i], nil)
+		}
+	}
+}
+
+// SeekKey returns an iterator over the rows of a WITHOUT ROWID table whose
+// primary key starts with key, which holds values of the primary key
+// columns in key order, converted with their affinity as by IndexSeek.
+func (t *MemTable) SeekKey(key Record) RecordIterator {
+	return func(yield func(Record, error) bool) {
+		if !t.info.WithoutRowID {
+			yield(nil, fmt.Errorf("table %s is not a WITHOUT ROWID table", t.info.Name))
+			return
+		}
+		key := t.index.coerceKey(key)
+		prefix := func(row Record) int { return t.compareKeys(row, key) }
+		i, _ := t.search(prefix)
+		for ; i < len(t.rows) && prefix(t.rows[i]) == 0; i++ {
+			if !yield(t.rows[i], nil) {
+				return
+			}
+		}
+	}
+}
+
+// compareKeys compares the primary key of row with key, which holds values
+// in key order, in the order of the primary key. Only as many columns as key
+// has values are compared.
+func (t *MemTable) compareKeys(row, key Record) int {
+	for i := range min(len(key), len(t.key)) {
+		c := compareValues(row[t.key[i]], key[i])
+		if t.index.Columns[i].Desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// search returns the position of the first row for which compare returns a
+// non-negative value, and whether it returns 0 for it.
+func (t *MemTable) search(compare func(row Record) int) (int, bool) {
+	i := sort.Search(len(t.rows), func(i int) bool { return compare(t.rows[i]) >= 0 })
+	return i, i < len(t.rows) && compare(t.rows[i]) == 0
+}
+
+// searchRowID returns the position of the row with the given rowid, or of
+// the first row with a larger one, and whether it was found.
+func (t *MemTable) searchRowID(rowID int64) (int, bool) {
+	i := sort.Search(len(t.rows), func(i int) bool { return t.info.RowID(t.rows[i]) >= rowID })
+	return i, i < len(t.rows) && t.info.RowID(t.rows[i]) == rowID
+}
+
+// keyName names the rowid or primary key columns in constraint errors.
+func (t *MemTable) keyName() string {
+	if !t.info.WithoutRowID {
+		if i := t.info.RowIDColumnIndex; i != -1 {
+			return t.info.Name + "." + t.info.Columns[i].Name
+		}
+		return t.info.Name + ".rowid"
+	}
+	names := make([]string, len(t.index.Columns))
+	for i, col := range t.index.Columns {
+		names[i] = t.info.Name + "." + col.Name
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,183 @@
+package golite
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestMemTable(t *testing.T) {
+	schemas := []string{
+		"CREATE TABLE aliased (id INTEGER PRIMARY KEY, name TEXT, score REAL)",
+		"CREATE TABLE plain (name TEXT, n INTEGER)",
+		"CREATE TABLE w (k TEXT, n INTEGER, v BLOB, PRIMARY KEY (n DESC, k)) WITHOUT ROWID",
+	}
+	rows := map[string][]Record{}
+	for i := int64(1); i <= 300; i++ {
+		name := "name" + string(rune('a'+i%26))
+		rows["aliased"] = append(rows["aliased"], Record{i * 3, name, float64(i) + 0.5})
+		rows["plain"] = append(rows["plain"], Record{name, "42"})
+		rows["w"] = append(rows["w"], Record{name, i % 7, []byte{byte(i)}})
+	}
+	rows["w"] = rows["w"][:26*7]
+
+	dbPath := filepath.Join(t.TempDir(), "memtable.sqlite")
+	var sql strings.Builder
+	for _, schema := range schemas {
+		sql.WriteString(schema + ";\n")
+	}
+	for name, records := range rows {
+		for _, record := range records {
+			values := make([]string, len(record))
+			for i, value := range record {
+				values[i] = quoteLiteral(value)
+			}
+			fmt.Fprintf(&sql, "INSERT INTO %s VALUES (%s);\n", name, strings.Join(values, ", "))
+		}
+	}
+	runSQL(t, dbPath, sql.String())
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	for _, schema := range schemas {
+		mem, err := NewMemTable(schema)
+		if err != nil {
+			t.Fatalf("NewMemTable() failed: %v", err)
+		}
+		name := mem.Info().Name
+		t.Run(name, func(t *testing.T) {
+			info, err := db.Table(name)
+			if err != nil {
+				t.Fatalf("Table() failed: %v", err)
+			}
+			// Rows without an INTEGER PRIMARY KEY get increasing rowids,
+			// so only the others can be inserted in any order.
+			records := rows[name]
+			if info.RowIDColumnIndex != -1 || info.WithoutRowID {
+				records = slices.Clone(records)
+				rng.Shuffle(len(records), func(i, j int) { records[i], records[j] = records[j], records[i] })
+			}
+			for _, record := range records {
+				if err := mem.Insert(record); err != nil {
+					t.Fatalf("Insert(%v) failed: %v", record, err)
+				}
+			}
+			if mem.Len() != len(records) {
+				t.Errorf("Len() = %d, want %d", mem.Len(), len(records))
+			}
+
+			var sources []TableSource = []TableSource{db.Source(info), mem}
+			results := make([][]Record, len(sources))
+			for i, source := range sources {
+				results[i], err = CollectRecords(source.Scan())
+				if err != nil {
+					t.Fatalf("Scan() failed: %v", err)
+				}
+			}
+			if !reflect.DeepEqual(results[0], results[1]) {
+				t.Errorf("Scan() = %v, want %v", results[1], results[0])
+			}
+
+			var seeks []func(TableSource) RecordIterator
+			if info.WithoutRowID {
+				for _, key := range []Record{{int64(3)}, {"5", "namef"}, {int64(9)}, {}} {
+					seeks = append(seeks, func(s TableSource) RecordIterator { return s.SeekKey(key) })
+				}
+			} else {
+				for _, rowID := range []int64{1, 3, 299, 900, 1000} {
+					seeks = append(seeks, func(s TableSource) RecordIterator { return s.SeekRowID(rowID) })
+				}
+			}
+			for _, seek := range seeks {
+				want, err := CollectRecords(seek(sources[0]))
+				if err != nil {
+					t.Fatalf("seek failed: %v", err)
+				}
+				got, err := CollectRecords(seek(sources[1]))
+				if err != nil {
+					t.Fatalf("seek failed: %v", err)
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("seek = %v, want %v", got, want)
+				}
+			}
+
+			if err := mem.Insert(rows[name][0]); err == nil && info.RowIDColumnIndex != -1 {
+				t.Errorf("inserting a duplicate row succeeded")
+			}
+		})
+	}
+
+	t.Run("distinct", func(t *testing.T) {
+		mem, err := NewMemTable("CREATE TABLE d (a ANY, b ANY, PRIMARY KEY (b, a)) WITHOUT ROWID")
+		if err != nil {
+			t.Fatalf("NewMemTable() failed: %v", err)
+		}
+		var inserted int
+		for _, record := range []Record{{int64(1), "x"}, {1.0, "x"}, {int64(2), "x"}, {int64(1), "y"}, {int64(2), "x"}} {
+			ok, err := mem.InsertOrIgnore(record)
+			if err != nil {
+				t.Fatalf("InsertOrIgnore() failed: %v", err)
+			}
+			if ok {
+				inserted++
+			}
+		}
+		got, _ := CollectRecords(mem.Scan())
+		want := []Record{{int64(1), "x"}, {int64(2), "x"}, {int64(1), "y"}}
+		if inserted != 3 || !reflect.DeepEqual(got, want) {
+			t.Errorf("inserted %d rows %v, want %v", inserted, got, want)
+		}
+		if err := mem.Insert(Record{int64(1), "y"}); err == nil || !strings.Contains(err.Error(), "UNIQUE constraint failed: d.b, d.a") {
+			t.Errorf("Insert() of a duplicate returned %v", err)
+		}
+		if _, err := mem.InsertOrIgnore(Record{SQLNull, "y"}); err == nil {
+			t.Errorf("expected an error for a NULL primary key")
+		}
+		if _, err := CollectRecords(mem.SeekRowID(1)); err == nil {
+			t.Errorf("expected an error seeking a rowid in a WITHOUT ROWID table")
+		}
+	})
+
+	t.Run("staging for a builder", func(t *testing.T) {
+		const schema = "CREATE TABLE staged (id INTEGER PRIMARY KEY, v TEXT)"
+		mem, err := NewMemTable(schema)
+		if err != nil {
+			t.Fatalf("NewMemTable() failed: %v", err)
+		}
+		for _, id := range []int64{5, 2, 9, 1} {
+			if err := mem.Insert(Record{id, "v"}); err != nil {
+				t.Fatalf("Insert() failed: %v", err)
+			}
+		}
+		if err := mem.Insert(Record{nil, "last"}); err != nil {
+			t.Fatalf("Insert() failed: %v", err)
+		}
+		path := filepath.Join(t.TempDir(), "staged.sqlite")
+		if err := NewDatabaseBuilder(path).CreateTable(schema).InsertFrom(mem.Scan()).Finalize(); err != nil {
+			t.Fatalf("building from the staged rows failed: %v", err)
+		}
+		built, err := Open(path)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		defer built.Close()
+		info, err := built.Table("staged")
+		if err != nil {
+			t.Fatalf("Table() failed: %v", err)
+		}
+		got, err := CollectRecords(built.TableScan(info))
+		want, _ := CollectRecords(mem.Scan())
+		if err != nil || !reflect.DeepEqual(got, want) || len(got) != 5 || got[4][0] != int64(10) {
+			t.Errorf("TableScan() = %v, %v, want %v", got, err, want)
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package golite
+
+import "slices"
+
+// MultiSeek returns an iterator over the records of an index whose leading
+// columns equal the values of any of keys, as for "col IN (v1, ..., vn)". As
+// with IndexSeek, the affinity of the indexed columns is applied to the keys.
+// The keys are then sorted and their duplicates dropped, and the index is
+// searched for each in turn, so the records are yielded in index order, each
+// once. Unlike IndexSeek, the matches of a key may span several leaf pages.
+func (db *Database) MultiSeek(index IndexInfo, keys []Record) RecordIterator {
+	return interruptible(db, func(yield func(Record, error) bool) {
+		sorted := make([]Record, len(keys))
+		for i, key := range keys {
+			sorted[i] = index.coerceKey(key)
+		}
+		slices.SortFunc(sorted, CompareRecords)
+		sorted = slices.CompactFunc(sorted, func(a, b Record) bool {
+			return len(a) == len(b) && CompareRecords(a, b) == 0
+		})
+		for _, key := range sorted {
+			for record, err := range db.indexEqualRange(index, key) {
+				if !yield(record, err) || err != nil {
+					return
+				}
+			}
+		}
+	})
+}
+
+// indexEqualRange returns an iterator over the records of an index whose
+// leading columns equal key, to which the affinity of the indexed columns
+// has already been applied. It scans a range starting at key, so that the
+// matches spread over several leaf pages are all found.
+func (db *Database) indexEqualRange(index IndexInfo, key Record) RecordIterator {
+	return func(yield func(Record, error) bool) {
+		for record, err := range db.IndexScanRange(index, key, nil) {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if len(record) < len(key) || CompareRecords(record[:len(key)], key) != 0 {
+				return
+			}
+			if !yield(record, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package golite
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestMultiSeek(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "multiseek.sqlite")
+	runSQL(t, dbPath, `
+		CREATE TABLE t (a INTEGER PRIMARY KEY, b INTEGER, c TEXT);
+		CREATE INDEX t_b ON t(b);
+		CREATE INDEX t_bc ON t(b, c);
+		WITH RECURSIVE seq(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM seq WHERE i < 3000)
+		INSERT INTO t SELECT i, i % 3, 'c' || (i % 5) FROM seq;
+		CREATE TABLE expected (n INTEGER);
+		INSERT INTO expected SELECT count(*) FROM t WHERE b IN (2, 0, 7);
+		INSERT INTO expected SELECT count(*) FROM t WHERE (b, c) IN (VALUES (1, 'c2'), (0, 'c4'));
+	`)
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	expected, err := db.Table("expected")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+	var counts []int64
+	for record, err := range db.TableScan(expected) {
+		if err != nil {
+			t.Fatalf("TableScan() failed: %v", err)
+		}
+		counts = append(counts, expected.ColumnValues(record)[0].(int64))
+	}
+
+	tb, err := db.Index("t_b")
+	if err != nil {
+		t.Fatalf("Index() failed: %v", err)
+	}
+	records, err := CollectRecords(db.MultiSeek(tb, []Record{{int64(2)}, {"0"}, {int64(7)}, {int64(2)}}))
+	if err != nil {
+		t.Fatalf("MultiSeek() failed: %v", err)
+	}
+	if int64(len(records)) != counts[0] {
+		t.Errorf("MultiSeek(t_b) yielded %d records, want %d", len(records), counts[0])
+	}
+	if !slices.IsSortedFunc(records, CompareRecords) {
+		t.Errorf("expected the records in index order")
+	}
+
+	tbc, err := db.Index("t_bc")
+	if err != nil {
+		t.Fatalf("Index() failed: %v", err)
+	}
+	records, err = CollectRecords(db.MultiSeek(tbc, []Record{{int64(1), "c2"}, {int64(0), "c4"}}))
+	if err != nil {
+		t.Fatalf("MultiSeek() failed: %v", err)
+	}
+	if int64(len(records)) != counts[1] {
+		t.Errorf("MultiSeek(t_bc) yielded %d records, want %d", len(records), counts[1])
+	}
+
+	// Stopping early must not fail.
+	records, err = CollectRecords(Take(db.MultiSeek(tb, []Record{{int64(1)}, {int64(0)}}), 2))
+	if err != nil || len(records) != 2 || records[0][0] != int64(0) {
+		t.Errorf("expected the first 2 records for b = 0, got (%v, %v)", records, err)
+	}
+	if records, err := CollectRecords(db.MultiSeek(tb, nil)); err != nil || records != nil {
+		t.Errorf("expected no records without keys, got (%v, %v)", records, err)
+	}
+}
@@ -0,0 +1,66 @@
+package golite
+
+// WithNullValue makes the records yielded by the iterators of the database,
+// such as those of TableScan, IndexSeek or Execute, hold null instead of
+// SQLNull for SQL NULL values. Use nil for records that encoding/json
+// marshals with JSON nulls and that compare equal to nil like the values of
+// database/sql. The functions of golite taking values accept both forms.
+// NullsAs does the same for a single iterator.
+func WithNullValue(null any) OpenOption {
+	return func(db *Database) {
+		db.nullValue = null
+		db.customNull = true
+	}
+}
+
+// NullsAs returns an iterator over the records of records in which SQL NULL
+// values, whether SQLNull or nil, are replaced with null, for instance nil.
+// Records holding a NULL are copied rather than modified.
+func NullsAs(records RecordIterator, null any) RecordIterator {
+	return func(yield func(Record, error) bool) {
+		for record, err := range records {
+			if err == nil {
+				for i, value := range record {
+					if isNull(value) && value != null {
+						record = record.Copy()
+						replaceNulls(record[i:], null)
+						break
+					}
+				}
+			}
+			if !yield(record, err) {
+				return
+			}
+		}
+	}
+}
+
+// presentNulls replaces the NULL values of the records held by v, a value
+// yielded by an iterator of the database, as set by WithNullValue. The
+// records are modified: they were decoded for this iteration.
+func (db *Database) presentNulls(v any) {
+	if !db.customNull {
+		return
+	}
+	switch v := v.(type) {
+	case Record:
+		replaceNulls(v, db.nullValue)
+	case []Row:
+		for _, row := range v {
+			replaceNulls(row.Record, db.nullValue)
+		}
+	case CarvedRecord:
+		replaceNulls(v.Record, db.nullValue)
+	case RecoveredRecord:
+		replaceNulls(v.Record, db.nullValue)
+	}
+}
+
+// replaceNulls replaces the NULL values of record with null.
+func replaceNulls(record Record, null any) {
+	for i, value := range record {
+		if isNull(value) {
+			record[i] = null
+		}
+	}
+}
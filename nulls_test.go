@@ -0,0 +1,82 @@
+package golite
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWithNullValue(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "nulls.sqlite")
+	runSQL(t, dbPath, `
+		CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT UNIQUE, n INTEGER);
+		CREATE INDEX t_n ON t (n);
+		INSERT INTO t VALUES (1, 'a', NULL), (2, NULL, 5), (3, 'c', 7);
+	`)
+	db, err := Open(dbPath, WithNullValue(nil))
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	table, err := db.Table("t")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+	if len(table.Indexes) != 2 {
+		t.Errorf("got %d indexes, want the automatic index and t_n", len(table.Indexes))
+	}
+
+	want := []Record{{int64(1), "a", nil}, {int64(2), nil, int64(5)}, {int64(3), "c", int64(7)}}
+	got, err := CollectRecords(db.TableScan(table))
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("TableScan() = %#v, %v, want %#v", got, err, want)
+	}
+	got, err = CollectRecords(db.Execute(NewScanPlan(table)))
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("Execute() = %#v, %v, want %#v", got, err, want)
+	}
+	b, err := json.Marshal(got)
+	if err != nil || string(b) != `[[1,"a",null],[2,null,5],[3,"c",7]]` {
+		t.Errorf("json.Marshal() = %s, %v", b, err)
+	}
+	index := schemaIndex(t, db, "t_n")
+	got, err = CollectRecords(db.IndexScan(index))
+	if err != nil || !reflect.DeepEqual(got[0], Record{nil, int64(1)}) {
+		t.Errorf("IndexScan() = %#v, %v", got, err)
+	}
+	got, err = CollectRecords(db.IndexSeek(index, Record{nil}))
+	if err != nil || len(got) != 1 {
+		t.Errorf("IndexSeek() of NULL = %#v, %v", got, err)
+	}
+
+	t.Run("NullsAs", func(t *testing.T) {
+		mem, err := NewMemTable("CREATE TABLE m (a ANY, b ANY)")
+		if err != nil {
+			t.Fatal(err)
+		}
+		mem.Insert(Record{SQLNull, "x"})
+		mem.Insert(Record{int64(1), "y"})
+		got, err := CollectRecords(NullsAs(mem.Scan(), nil))
+		want := []Record{{int64(1), nil, "x"}, {int64(2), int64(1), "y"}}
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("NullsAs() = %#v, %v, want %#v", got, err, want)
+		}
+		if rows, _ := CollectRecords(mem.Scan()); rows[0][1] != SQLNull {
+			t.Errorf("NullsAs() modified the rows of the table")
+		}
+	})
+}
+
+func schemaIndex(t *testing.T, db *Database, name string) IndexInfo {
+	t.Helper()
+	schema, err := db.GetSchema()
+	if err != nil {
+		t.Fatalf("GetSchema() failed: %v", err)
+	}
+	index, ok := schema.Indexes[name]
+	if !ok {
+		t.Fatalf("no index %q", name)
+	}
+	return index
+}
@@ -2,6 +2,7 @@ package golite
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 )
 
@@ -43,6 +44,7 @@ type LeafIndexCell struct {
 // It points to a child page and contains a separator key record.
 type InteriorIndexCell struct {
 	LeftChildPageNum uint32
+	PayloadSize      int64
 	Payload          Record
 }
 
@@ -60,40 +62,40 @@ type Page struct {
 	LeafIndexCells     []LeafIndexCell
 	InteriorIndexCells []InteriorIndexCell
 	RawData            []byte
+
+	// headerOffset is the offset of the page header within RawData, which is
+	// non-zero only on page 1.
+	headerOffset int
+	// pageNum is the 1-based page number the page was parsed as.
+	pageNum int
+	// uninitialized is true if the page, past any file header, is all zeros.
+	uninitialized bool
+	// arena allocates the records of the page, and is nil unless it was
+	// parsed for a Database opened with WithRecordArena.
+	arena *recordArena
 }
 
 // ParsePage reads a raw byte slice and parses it into a Page struct.
 // pageNum is the 1-based page number, used to determine the header offset.
+// Every offset and length read from the page is checked against the size of
+// data, so corrupt or hostile pages produce an error rather than a panic.
+// The TEXT and BLOB values of the parsed records are copies of the page
+// contents.
 func ParsePage(data []byte, pageNum int) (*Page, error) {
-	offset := 0
-	if pageNum == 1 {
-		offset = HeaderSize // The first page contains the 100-byte file header.
-	}
-
-	header := data[offset:]
+	return parsePage(data, pageNum, len(data), false, false)
+}
 
-	p := &Page{
-		Type:        header[0],
-		Freeblock:   binary.BigEndian.Uint16(header[1:3]),
-		CellCount:   binary.BigEndian.Uint16(header[3:5]),
-		CellContent: binary.BigEndian.Uint16(header[5:7]),
-		Fragmented:  header[7],
-		RawData:     data,
+// parsePage is like ParsePage for a page whose usable size is usable, but if
+// zeroCopy is true the TEXT and BLOB values of the parsed records alias data
+// instead of being copied, and if useArena is true the records are allocated
+// in bulk, see WithRecordArena.
+func parsePage(data []byte, pageNum, usable int, zeroCopy, useArena bool) (*Page, error) {
+	p, err := parsePageHeader(data, pageNum)
+	if err != nil {
+		return nil, err
 	}
-
-	headerSize := 8
-	// Interior pages have a 4-byte right-most pointer.
-	if p.Type == PageTypeInteriorIndex || p.Type == PageTypeInteriorTable {
-		headerSize = 12
-		p.RightMostPtr = binary.BigEndian.Uint32(header[8:12])
-	}
-
-	// Parse the cell pointer array.
-	p.CellPointers = make([]uint16, p.CellCount)
-	cellPointerStart := offset + headerSize
-	for i := 0; i < int(p.CellCount); i++ {
-		pointerOffset := cellPointerStart + i*2
-		p.CellPointers[i] = binary.BigEndian.Uint16(data[pointerOffset : pointerOffset+2])
+	if useArena {
+		p.arena = &recordArena{}
 	}
 
 	// Parse the cells themselves based on the page type.
@@ -101,27 +103,24 @@ func ParsePage(data []byte, pageNum int) (*Page, error) {
 	case PageTypeLeafTable:
 		p.LeafCells = make([]LeafTableCell, p.CellCount)
 		for i, cellOffset := range p.CellPointers {
-			cellData := data[int(cellOffset):]
-			payloadSize, n := readVarint(cellData)
-			rowID, m := readVarint(cellData[n:])
-			payloadOffset := n + m
-			payload := cellData[payloadOffset : payloadOffset+int(payloadSize)]
-			record, err := ParseRecord(payload)
+			cell, err := parseLeafTableCell(data[int(cellOffset):], usable, p.arena)
 			if err != nil {
-				return nil, fmt.Errorf("failed to parse record in cell %d on page %d: %w", i, pageNum, err)
-			}
-			p.LeafCells[i] = LeafTableCell{
-				PayloadSize: payloadSize,
-				RowID:       rowID,
-				Record:      record,
+				return nil, atCell(err, pageNum, i, int(cellOffset))
 			}
+			p.LeafCells[i] = cell
 		}
 	case PageTypeInteriorTable:
 		p.InteriorCells = make([]InteriorTableCell, p.CellCount)
 		for i, cellOffset := range p.CellPointers {
 			cellData := data[int(cellOffset):]
+			if len(cellData) < 4 {
+				return nil, newCorruptError(pageNum, i, int(cellOffset), CorruptCell, errors.New("interior table cell is truncated"))
+			}
 			leftChildPageNum := binary.BigEndian.Uint32(cellData[0:4])
-			key, _ := readVarint(cellData[4:])
+			key, _, err := readVarintChecked(cellData[4:])
+			if err != nil {
+				return nil, newCorruptError(pageNum, i, int(cellOffset), CorruptCell, fmt.Errorf("failed to read key: %w", err))
+			}
 
 			p.InteriorCells[i] = InteriorTableCell{
 				LeftChildPageNum: leftChildPageNum,
@@ -132,11 +131,17 @@ func ParsePage(data []byte, pageNum int) (*Page, error) {
 		p.LeafIndexCells = make([]LeafIndexCell, p.CellCount)
 		for i, cellOffset := range p.CellPointers {
 			cellData := data[int(cellOffset):]
-			payloadSize, n := readVarint(cellData)
-			payload := cellData[n : n+int(payloadSize)]
-			record, err := ParseRecord(payload)
+			payloadSize, n, err := readVarintChecked(cellData)
 			if err != nil {
-				return nil, fmt.Errorf("failed to parse record in leaf index cell %d on page %d: %w", i, pageNum, err)
+				return nil, newCorruptError(pageNum, i, int(cellOffset), CorruptCell, fmt.Errorf("failed to read payload size: %w", err))
+			}
+			payload, err := cellPayload(cellData[n:], payloadSize, usable, false)
+			if err != nil {
+				return nil, payloadError(pageNum, i, int(cellOffset), err)
+			}
+			record, err := parseRecordIn(payload, p.arena)
+			if err != nil {
+				return nil, newCorruptError(pageNum, i, int(cellOffset), CorruptRecord, err)
 			}
 			p.LeafIndexCells[i] = LeafIndexCell{
 				PayloadSize: payloadSize,
@@ -147,23 +152,264 @@ func ParsePage(data []byte, pageNum int) (*Page, error) {
 		p.InteriorIndexCells = make([]InteriorIndexCell, p.CellCount)
 		for i, cellOffset := range p.CellPointers {
 			cellData := data[int(cellOffset):]
+			if len(cellData) < 4 {
+				return nil, newCorruptError(pageNum, i, int(cellOffset), CorruptCell, errors.New("interior index cell is truncated"))
+			}
 			leftChildPageNum := binary.BigEndian.Uint32(cellData[0:4])
-			payloadSize, n := readVarint(cellData[4:])
-			payload := cellData[4+n : 4+n+int(payloadSize)]
-			record, err := ParseRecord(payload)
+			payloadSize, n, err := readVarintChecked(cellData[4:])
 			if err != nil {
-				return nil, fmt.Errorf("failed to parse record in interior index cell %d on page %d: %w", i, pageNum, err)
+				return nil, newCorruptError(pageNum, i, int(cellOffset), CorruptCell, fmt.Errorf("failed to read payload size: %w", err))
+			}
+			payload, err := cellPayload(cellData[4+n:], payloadSize, usable, false)
+			if err != nil {
+				return nil, payloadError(pageNum, i, int(cellOffset), err)
+			}
+			record, err := parseRecordIn(payload, p.arena)
+			if err != nil {
+				return nil, newCorruptError(pageNum, i, int(cellOffset), CorruptRecord, err)
 			}
 			p.InteriorIndexCells[i] = InteriorIndexCell{
 				LeftChildPageNum: leftChildPageNum,
+				PayloadSize:      payloadSize,
 				Payload:          record,
 			}
 		}
 	}
 
+	switch {
+	case zeroCopy:
+		// The values keep aliasing data.
+	case useArena:
+		p.detachRecordsBulk()
+	default:
+		p.detachRecords()
+	}
 	return p, nil
 }
 
+// parsePageHeader parses the header and the cell pointer array of a page,
+// leaving the cells themselves unparsed.
+func parsePageHeader(data []byte, pageNum int) (*Page, error) {
+	offset := 0
+	if pageNum == 1 {
+		offset = HeaderSize // The first page contains the 100-byte file header.
+	}
+	if len(data) < offset+8 {
+		return nil, newCorruptError(pageNum, -1, offset, CorruptPageHeader, fmt.Errorf("page is too small: %d bytes", len(data)))
+	}
+
+	header := data[offset:]
+
+	p := &Page{
+		Type:        header[0],
+		Freeblock:   binary.BigEndian.Uint16(header[1:3]),
+		CellCount:   binary.BigEndian.Uint16(header[3:5]),
+		CellContent: binary.BigEndian.Uint16(header[5:7]),
+		Fragmented:  header[7],
+		RawData:     data,
+
+		headerOffset: offset,
+		pageNum:      pageNum,
+
+		uninitialized: allZero(header),
+	}
+	headerSize := 8
+	// Interior pages have a 4-byte right-most pointer.
+	if p.Type == PageTypeInteriorIndex || p.Type == PageTypeInteriorTable {
+		headerSize = 12
+		if len(header) < headerSize {
+			return nil, newCorruptError(pageNum, -1, offset, CorruptPageHeader, fmt.Errorf("page is too small for an interior page header: %d bytes", len(data)))
+		}
+		p.RightMostPtr = binary.BigEndian.Uint32(header[8:12])
+	}
+
+	// Parse the cell pointer array.
+	cellPointerStart := offset + headerSize
+	cellPointersEnd := cellPointerStart + 2*int(p.CellCount)
+	if cellPointersEnd > len(data) {
+		return nil, newCorruptError(pageNum, -1, offset, CorruptPageHeader, fmt.Errorf("cell pointer array extends beyond the page: %d cells", p.CellCount))
+	}
+	p.CellPointers = make([]uint16, p.CellCount)
+	for i := 0; i < int(p.CellCount); i++ {
+		pointerOffset := cellPointerStart + i*2
+		cellOffset := binary.BigEndian.Uint16(data[pointerOffset : pointerOffset+2])
+		if int(cellOffset) < cellPointersEnd || int(cellOffset) >= len(data) {
+			return nil, newCorruptError(pageNum, i, pointerOffset, CorruptCellPointer, fmt.Errorf("cell offset %d is out of bounds", cellOffset))
+		}
+		p.CellPointers[i] = cellOffset
+	}
+
+	return p, nil
+}
+
+// detachRecords detaches the records of all the page's cells.
+func (p *Page) detachRecords() {
+	for _, cell := range p.LeafCells {
+		cell.Record.Detach()
+	}
+	for _, cell := range p.LeafIndexCells {
+		cell.Payload.Detach()
+	}
+	for _, cell := range p.InteriorIndexCells {
+		cell.Payload.Detach()
+	}
+}
+
+// IsUninitialized reports whether the page has never been written: apart from
+// the database file header on page 1, all its bytes are zero. Such pages are
+// found at the end of freshly extended or partially written files and have no
+// valid page type.
+func (p *Page) IsUninitialized() bool {
+	return p.uninitialized
+}
+
+// allZero reports whether every byte of data is zero.
+func allZero(data []byte) bool {
+	for _, b := range data {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// parseLeafTableCell parses a leaf table cell starting at the beginning of
+// cellData, on a page whose usable size is usable, allocating its record from
+// arena, which may be nil. Corrupt cells are reported as a *CorruptError
+// with a reason but no location, which the caller adds with atCell.
+func parseLeafTableCell(cellData []byte, usable int, arena *recordArena) (LeafTableCell, error) {
+	payloadSize, rowID, payload, err := leafTableCellPayload(cellData, usable)
+	if err != nil {
+		return LeafTableCell{}, err
+	}
+	record, err := parseRecordIn(payload, arena)
+	if err != nil {
+		return LeafTableCell{}, newCorruptError(0, -1, -1, CorruptRecord, err)
+	}
+	return LeafTableCell{
+		PayloadSize: payloadSize,
+		RowID:       rowID,
+		Record:      record,
+	}, nil
+}
+
+// leafTableCellPayload reads the header of a leaf table cell starting at the
+// beginning of cellData, and returns its payload size, rowid and payload.
+// Errors are as for parseLeafTableCell.
+func leafTableCellPayload(cellData []byte, usable int) (int64, int64, []byte, error) {
+	payloadSize, n, err := readVarintChecked(cellData)
+	if err != nil {
+		return 0, 0, nil, newCorruptError(0, -1, -1, CorruptCell, fmt.Errorf("failed to read payload size: %w", err))
+	}
+	rowID, m, err := readVarintChecked(cellData[n:])
+	if err != nil {
+		return 0, 0, nil, newCorruptError(0, -1, -1, CorruptCell, fmt.Errorf("failed to read rowid: %w", err))
+	}
+	payload, err := cellPayload(cellData[n+m:], payloadSize, usable, true)
+	if err != nil {
+		return 0, 0, nil, payloadError(0, -1, -1, err)
+	}
+	return payloadSize, rowID, payload, nil
+}
+
+// cellPayload returns the first payloadSize bytes of data, which holds the
+// rest of the page after a cell's header varints, for a cell of a page whose
+// usable size is usable. Payloads larger than the maximum a cell stores
+// locally spill onto overflow pages, which are not supported: they are
+// reported as an *UnsupportedFeatureError. Payloads that would extend beyond
+// the page are reported as plain errors.
+func cellPayload(data []byte, payloadSize int64, usable int, tableLeaf bool) ([]byte, error) {
+	if payloadSize < 0 {
+		return nil, fmt.Errorf("invalid payload size %d", payloadSize)
+	}
+	if localPayloadSize(payloadSize, usable, tableLeaf) < payloadSize {
+		return nil, unsupported(fmt.Sprintf("overflow pages (payload of %d bytes)", payloadSize))
+	}
+	if payloadSize > int64(len(data)) {
+		return nil, fmt.Errorf("payload size %d extends beyond the page (%d bytes left)", payloadSize, len(data))
+	}
+	return data[:payloadSize], nil
+}
+
+// payloadError returns the error for a cell whose payload cannot be read
+// because of err, returned by cellPayload: err itself if it reports an
+// unsupported feature, or a *CorruptError at the given location otherwise.
+func payloadError(pageNum, cell, offset int, err error) error {
+	if errors.Is(err, ErrUnsupportedFeature) {
+		return err
+	}
+	return newCorruptError(pageNum, cell, offset, CorruptPayload, err)
+}
+
+// atCell sets the location of err to the given cell if it is a
+// *CorruptError, and returns it.
+func atCell(err error, pageNum, cell, offset int) error {
+	var cerr *CorruptError
+	if errors.As(err, &cerr) {
+		cerr.PageNum, cerr.Cell, cerr.Offset = pageNum, cell, offset
+	}
+	return err
+}
+
+// cellPointersEnd returns the offset just past the cell pointer array.
+func (p *Page) cellPointersEnd() int {
+	headerSize := 8
+	if p.Type == PageTypeInteriorIndex || p.Type == PageTypeInteriorTable {
+		headerSize = 12
+	}
+	return p.headerOffset + headerSize + 2*int(p.CellCount)
+}
+
+// cellContentStart returns the offset of the cell content area. A stored
+// value of 0 stands for 65536.
+func (p *Page) cellContentStart() int {
+	if p.CellContent == 0 {
+		return 65536
+	}
+	return int(p.CellContent)
+}
+
+// FreeBytes returns the number of unused bytes on the page: the gap between
+// the cell pointer array and the cell content area, the freeblocks, and the
+// fragmented bytes.
+func (p *Page) FreeBytes() int {
+	free := max(0, min(p.cellContentStart(), len(p.RawData))-p.cellPointersEnd())
+	for _, fb := range p.freeblocks() {
+		free += fb.end - fb.start + 4
+	}
+	return free + int(p.Fragmented)
+}
+
+// freeRegion is a half-open range of unallocated byte offsets within a page.
+type freeRegion struct {
+	start, end int
+	// freeblock is true if the region is the body of a freeblock, which
+	// starts just after the 4-byte freeblock header.
+	freeblock bool
+}
+
+// freeblocks walks the page's freeblock chain and returns the body of each
+// freeblock, skipping the 4-byte freeblock headers. Freeblocks that are out
+// of bounds or out of order end the walk.
+func (p *Page) freeblocks() []freeRegion {
+	data := p.RawData
+	var regions []freeRegion
+	prev := 0
+	for fb := int(p.Freeblock); fb != 0; {
+		if fb <= prev || fb+4 > len(data) {
+			break
+		}
+		next := int(binary.BigEndian.Uint16(data[fb : fb+2]))
+		size := int(binary.BigEndian.Uint16(data[fb+2 : fb+4]))
+		if size < 4 || fb+size > len(data) {
+			break
+		}
+		regions = append(regions, freeRegion{start: fb + 4, end: fb + size, freeblock: true})
+		prev, fb = fb, next
+	}
+	return regions
+}
+
 // readVarint reads a variable-length integer (varint) from the given byte slice.
 // It returns the integer value and the number of bytes read.
 func readVarint(data []byte) (int64, int) {
@@ -187,3 +433,13 @@ func readVarint(data []byte) (int64, int) {
 	}
 	return value, bytesRead
 }
+
+// readVarintChecked is like readVarint, but returns an error instead of a
+// partial value if data ends before the varint does.
+func readVarintChecked(data []byte) (int64, int, error) {
+	value, n := readVarint(data)
+	if n == 0 || (n < 9 && data[n-1]&0x80 != 0) {
+		return 0, 0, fmt.Errorf("varint truncated after %d bytes", n)
+	}
+	return value, n, nil
+}
@@ -1,6 +1,7 @@
 package golite
 
 import (
+	"encoding/binary"
 	"testing"
 )
 
@@ -198,3 +199,147 @@ func TestReadVarint(t *testing.T) {
 		})
 	}
 }
+
+func TestReadVarintChecked(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   []byte
+		wantVal int64
+		wantLen int
+		wantErr bool
+	}{
+		{"one byte", []byte{0x7f}, 127, 1, false},
+		{"two bytes with trailing data", []byte{0x81, 0x00, 0xff}, 128, 2, false},
+		{"nine bytes", []byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0x80, 0xff}, 255, 9, false},
+		{"empty", []byte{}, 0, 0, true},
+		{"truncated after one byte", []byte{0x81}, 0, 0, true},
+		{"truncated after eight bytes", []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, 0, 0, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			val, length, err := readVarintChecked(tc.input)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("readVarintChecked() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if val != tc.wantVal || length != tc.wantLen {
+				t.Errorf("readVarintChecked() = (%v, %v), want (%v, %v)", val, length, tc.wantVal, tc.wantLen)
+			}
+		})
+	}
+}
+
+// craftPage returns a 64-byte page of the given type whose cell pointer array
+// holds cellOffsets, with content written at the given offsets.
+func craftPage(pageType byte, cellOffsets []uint16, content map[int][]byte) []byte {
+	data := make([]byte, 64)
+	data[0] = pageType
+	binary.BigEndian.PutUint16(data[3:5], uint16(len(cellOffsets)))
+	pointerStart := 8
+	if pageType == PageTypeInteriorIndex || pageType == PageTypeInteriorTable {
+		pointerStart = 12
+	}
+	for i, offset := range cellOffsets {
+		binary.BigEndian.PutUint16(data[pointerStart+2*i:], offset)
+	}
+	for offset, bytes := range content {
+		copy(data[offset:], bytes)
+	}
+	return data
+}
+
+// corruptPageCases are hostile pages, several of them found by
+// FuzzParsePage, that used to make ParsePage panic or over-read.
+var corruptPageCases = []struct {
+	name string
+	data []byte
+	err  string
+}{
+	{
+		name: "page too small",
+		data: []byte{PageTypeLeafTable, 0, 0},
+		err:  "database corrupt at page 2, offset 0: page is too small: 3 bytes",
+	},
+	{
+		name: "interior header truncated",
+		data: []byte{PageTypeInteriorTable, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		err:  "database corrupt at page 2, offset 0: page is too small for an interior page header: 10 bytes",
+	},
+	{
+		name: "cell count too large",
+		data: func() []byte {
+			data := craftPage(PageTypeLeafTable, nil, nil)
+			binary.BigEndian.PutUint16(data[3:5], 100)
+			return data
+		}(),
+		err: "database corrupt at page 2, offset 0: cell pointer array extends beyond the page: 100 cells",
+	},
+	{
+		name: "cell pointer beyond page",
+		data: craftPage(PageTypeLeafTable, []uint16{0xfff0}, nil),
+		err:  "database corrupt at page 2, cell 0, offset 8: cell offset 65520 is out of bounds",
+	},
+	{
+		name: "cell pointer into page header",
+		data: craftPage(PageTypeLeafTable, []uint16{2}, nil),
+		err:  "database corrupt at page 2, cell 0, offset 8: cell offset 2 is out of bounds",
+	},
+	{
+		name: "leaf table payload beyond page",
+		data: craftPage(PageTypeLeafTable, []uint16{40}, map[int][]byte{40: {0x1c, 0x01}}),
+		err:  "database corrupt at page 2, cell 0, offset 40: payload size 28 extends beyond the page (22 bytes left)",
+	},
+	{
+		name: "leaf table rowid truncated",
+		data: craftPage(PageTypeLeafTable, []uint16{62}, map[int][]byte{62: {0x02, 0x81}}),
+		err:  "database corrupt at page 2, cell 0, offset 62: failed to read rowid: varint truncated after 1 bytes",
+	},
+	{
+		name: "interior table cell truncated",
+		data: craftPage(PageTypeInteriorTable, []uint16{62}, nil),
+		err:  "database corrupt at page 2, cell 0, offset 62: interior table cell is truncated",
+	},
+	{
+		name: "interior index payload beyond page",
+		// A page of 512 bytes, the smallest where index cells can hold a
+		// payload of 32 bytes without overflow pages.
+		data: func() []byte {
+			data := craftPage(PageTypeInteriorIndex, []uint16{500}, nil)
+			data = append(data, make([]byte, 512-len(data))...)
+			copy(data[500:], []byte{0, 0, 0, 2, 0x20})
+			return data
+		}(),
+		err: "database corrupt at page 2, cell 0, offset 500: payload size 32 extends beyond the page (7 bytes left)",
+	},
+	{
+		name: "leaf index negative payload size",
+		data: craftPage(PageTypeLeafIndex, []uint16{40}, map[int][]byte{40: {0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}}),
+		err:  "database corrupt at page 2, cell 0, offset 40: invalid payload size -1",
+	},
+}
+
+func TestParsePage_Corrupt(t *testing.T) {
+	for _, tc := range corruptPageCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParsePage(tc.data, 2)
+			if err == nil {
+				t.Fatalf("ParsePage() expected an error, but got nil")
+			}
+			if err.Error() != tc.err {
+				t.Errorf("ParsePage() error = %q, want %q", err.Error(), tc.err)
+			}
+		})
+	}
+}
+
+func FuzzParsePage(f *testing.F) {
+	for _, tc := range corruptPageCases {
+		f.Add(tc.data, 2)
+	}
+	f.Add(craftPage(PageTypeLeafTable, []uint16{60}, map[int][]byte{60: {0x02, 0x01, 0x02, 0x00}}), 2)
+	f.Add(make([]byte, 120), 1)
+	f.Fuzz(func(t *testing.T, data []byte, pageNum int) {
+		// ParsePage must never panic, whatever the input.
+		ParsePage(data, pageNum)
+	})
+}
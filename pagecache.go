@@ -0,0 +1,108 @@
+package golite
+
+import (
+	"container/list"
+	"sync"
+)
+
+// pageCache is a fixed-capacity, least-recently-used cache of raw page data,
+// safe for concurrent use. Cached buffers are shared by every reader of the
+// page and must not be modified.
+type pageCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[int]*list.Element
+	lru      *list.List // Front is most recently used.
+}
+
+// cachedPage is the value stored in the pageCache LRU list.
+type cachedPage struct {
+	pageNum int
+	data    []byte
+}
+
+func newPageCache(capacity int) *pageCache {
+	return &pageCache{
+		capacity: capacity,
+		entries:  make(map[int]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+// get returns the cached data of a page, if present.
+func (c *pageCache) get(pageNum int) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[pageNum]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return elem.Value.(*cachedPage).data, true
+}
+
+// put adds the data of a page to the cache, evicting the least recently used
+// page if the cache is full.
+func (c *pageCache) put(pageNum int, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[pageNum]; ok {
+		elem.Value.(*cachedPage).data = data
+		c.lru.MoveToFront(elem)
+		return
+	}
+	c.entries[pageNum] = c.lru.PushFront(&cachedPage{pageNum: pageNum, data: data})
+	if c.lru.Len() > c.capacity {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedPage).pageNum)
+	}
+}
+
+// clear removes all pages from the cache.
+func (c *pageCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+	c.lru.Init()
+}
+
+// WithPageCache keeps the raw data of up to pages recently read pages in
+// memory, so that repeated seeks and scans do not read them from the file
+// again. Pages returned by ReadPage may then share their RawData with the
+// cache, so it must not be modified.
+func WithPageCache(pages int) OpenOption {
+	return func(db *Database) {
+		if pages > 0 {
+			db.cache = newPageCache(pages)
+		}
+	}
+}
+
+// WithPrefetch makes scans read the next run of child pages in a background
+// goroutine while the records of the current pages are being decoded and
+// consumed, so that high-latency storage is not left idle between reads.
+// Prefetched pages also go into the page cache if WithPageCache is used.
+func WithPrefetch() OpenOption {
+	return func(db *Database) {
+		db.prefetch = true
+	}
+}
+
+// pageRun is the result of reading a run of adjacent pages.
+type pageRun struct {
+	pages [][]byte
+	err   error
+}
+
+// fetchPageRun starts reading a run of pages in the background and returns a
+// channel that delivers the result. The goroutine never blocks, even if the
+// result is not consumed.
+func (db *Database) fetchPageRun(first, count int) <-chan pageRun {
+	ch := make(chan pageRun, 1)
+	go func() {
+		pages, err := db.readPageRun(first, count)
+		ch <- pageRun{pages: pages, err: err}
+	}()
+	return ch
+}
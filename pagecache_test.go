@@ -0,0 +1,82 @@
+package golite
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPageCache(t *testing.T) {
+	c := newPageCache(2)
+	c.put(1, []byte{1})
+	c.put(2, []byte{2})
+	c.get(1) // Page 2 is now the least recently used.
+	c.put(3, []byte{3})
+	if _, ok := c.get(2); ok {
+		t.Error("expected page 2 to have been evicted")
+	}
+	for _, pageNum := range []int{1, 3} {
+		if data, ok := c.get(pageNum); !ok || data[0] != byte(pageNum) {
+			t.Errorf("expected page %d to be cached, got %v, %v", pageNum, data, ok)
+		}
+	}
+}
+
+func TestOpen_WithPageCacheAndPrefetch(t *testing.T) {
+	dbPath := createTestDB(t, "page_cache_test.sqlite")
+	scan := func(db *Database, table TableInfo, limit int) []Record {
+		var records []Record
+		for record, err := range db.TableScan(table) {
+			if err != nil {
+				t.Fatalf("TableScan() failed: %v", err)
+			}
+			records = append(records, record)
+			if len(records) == limit {
+				break
+			}
+		}
+		return records
+	}
+
+	plain, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer plain.Close()
+	schema, err := plain.GetSchema()
+	if err != nil {
+		t.Fatalf("GetSchema() failed: %v", err)
+	}
+	table := schema.Tables["test"]
+	want := scan(plain, table, -1)
+
+	for name, opts := range map[string][]OpenOption{
+		"cache":              {WithPageCache(100)},
+		"prefetch":           {WithPrefetch()},
+		"cache and prefetch": {WithPageCache(100), WithPrefetch()},
+	} {
+		t.Run(name, func(t *testing.T) {
+			db, err := Open(dbPath, opts...)
+			if err != nil {
+				t.Fatalf("Open() failed with error: %v", err)
+			}
+			defer db.Close()
+			for range 2 {
+				if got := scan(db, table, -1); !reflect.DeepEqual(got, want) {
+					t.Errorf("expected %d records, got %d", len(want), len(got))
+				}
+			}
+			if got := scan(db, table, 3); len(got) != 3 {
+				t.Errorf("expected a scan stopped early to return 3 records, got %d", len(got))
+			}
+			if db.cache != nil {
+				owners := make(map[int]TableInfo)
+				plain.collectTablePages(table.RootPage, table, owners)
+				for pageNum := range owners {
+					if _, ok := db.cache.get(pageNum); !ok {
+						t.Errorf("expected page %d to be cached after a full scan", pageNum)
+					}
+				}
+			}
+		})
+	}
+}
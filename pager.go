@@ -0,0 +1,81 @@
+package golite
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Pager provides the bytes of a database file to a Database. Open uses a
+// Pager backed by an *os.File; OpenPager accepts any implementation, such as
+// one reading from remote storage, decrypting pages or serving them from
+// memory.
+//
+// The Database reads whole pages, runs of consecutive pages and a few bytes of
+// the header through ReadAt, possibly from several goroutines at once.
+type Pager interface {
+	io.ReaderAt
+	// Size returns the size of the database file in bytes.
+	Size() (int64, error)
+	// Close releases the resources held by the Pager. It is called by
+	// Database.Close.
+	Close() error
+}
+
+// filePager is the Pager used by Open.
+type filePager struct {
+	*os.File
+}
+
+func (p filePager) Size() (int64, error) {
+	info, err := p.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// memoryPager is the Pager returned by NewMemoryPager.
+type memoryPager struct {
+	*bytes.Reader
+}
+
+func (p memoryPager) Size() (int64, error) {
+	return p.Reader.Size(), nil
+}
+
+func (p memoryPager) Close() error {
+	return nil
+}
+
+// NewMemoryPager returns a Pager serving the database image held in data,
+// which must not be modified while the Pager is in use.
+func NewMemoryPager(data []byte) Pager {
+	return memoryPager{bytes.NewReader(data)}
+}
+
+// Open opens an SQLite database file from the given path. If a hot rollback
+// journal is next to it, the database is read as it was before the
+// transaction of the journal, see Journal.
+func Open(path string, opts ...OpenOption) (*Database, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database file: %w", err)
+	}
+	journal, rollback, err := readJournal(path, filePager{file})
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	var pager Pager = filePager{file}
+	if rollback != nil {
+		pager = rollback
+	}
+	db, err := OpenPager(pager, opts...)
+	if err != nil {
+		return nil, err
+	}
+	db.journal = journal
+	return db, nil
+}
@@ -0,0 +1,55 @@
+package golite
+
+import (
+	"os"
+	"sync/atomic"
+	"testing"
+)
+
+// countingPager is a Pager wrapping another one and counting its reads.
+type countingPager struct {
+	Pager
+	reads atomic.Int64
+}
+
+func (p *countingPager) ReadAt(b []byte, off int64) (int, error) {
+	p.reads.Add(1)
+	return p.Pager.ReadAt(b, off)
+}
+
+func TestOpenPager(t *testing.T) {
+	dbPath := createTestDB(t, "pager_test.sqlite")
+	data, err := os.ReadFile(dbPath)
+	if err != nil {
+		t.Fatalf("failed to read database file: %v", err)
+	}
+	pager := &countingPager{Pager: NewMemoryPager(data)}
+	db, err := OpenPager(pager)
+	if err != nil {
+		t.Fatalf("OpenPager() failed with error: %v", err)
+	}
+	defer db.Close()
+
+	table, err := db.Table("test")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+	records, err := CollectRecords(db.TableScan(table))
+	if err != nil {
+		t.Fatalf("TableScan() failed: %v", err)
+	}
+	if len(records) != 500 {
+		t.Errorf("expected 500 records, got %d", len(records))
+	}
+	if pager.reads.Load() == 0 {
+		t.Errorf("expected the database to read through the pager")
+	}
+
+	if _, err := OpenPager(NewMemoryPager([]byte("not a database, but long enough to hold a header.............................................."))); err == nil {
+		t.Errorf("expected an error for a pager without an SQLite header")
+	}
+	empty, err := OpenPager(NewMemoryPager(nil))
+	if err != nil || empty.PageCount() != 1 {
+		t.Errorf("expected an empty pager to open as an empty database, got error %v", err)
+	}
+}
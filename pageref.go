@@ -0,0 +1,92 @@
+package golite
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// pendingByte is the offset of the bytes SQLite locks to coordinate access
+// to the file. The page holding them, if the file is that large, is never
+// used.
+const pendingByte = 1 << 30
+
+// lockBytePage returns the number of the page holding the lock bytes.
+func (db *Database) lockBytePage() int {
+	return pendingByte/int(db.Header.PageSize) + 1
+}
+
+// usableSize returns the number of bytes of a page available to B-Trees.
+func (db *Database) usableSize() int {
+	return int(db.Header.PageSize) - int(db.Header.ReservedSpace)
+}
+
+// isPtrmapPage reports whether pageNum is a pointer-map page. Databases with
+// auto-vacuum have one every usableSize/5 pages from page 2, each recording
+// the parent of the pages that follow it, skipping the lock-byte page.
+func (db *Database) isPtrmapPage(pageNum int) bool {
+	if db.Header.LargestRootPage == 0 || pageNum < 2 {
+		return false
+	}
+	perMap := db.usableSize()/5 + 1
+	ptrmap := (pageNum-2)/perMap*perMap + 2
+	if ptrmap == db.lockBytePage() {
+		ptrmap++
+	}
+	return pageNum == ptrmap
+}
+
+// pageRefProblem returns why pageNum cannot be the number of a B-Tree page,
+// or "" if it can: it must be within the database and be neither the
+// lock-byte page nor a pointer-map page.
+func (db *Database) pageRefProblem(pageNum int) string {
+	switch {
+	case pageNum < 1:
+		return fmt.Sprintf("invalid page number %d", pageNum)
+	case pageNum > db.PageCount() && pageNum > db.refreshPageCount():
+		return fmt.Sprintf("page %d is beyond the end of the database (%d pages)", pageNum, db.PageCount())
+	case pageNum == db.lockBytePage():
+		return fmt.Sprintf("page %d is the lock-byte page", pageNum)
+	case db.isPtrmapPage(pageNum):
+		return fmt.Sprintf("page %d is a pointer-map page", pageNum)
+	}
+	return ""
+}
+
+// refreshPageCount reads the number of pages in the database again from the
+// header, or from the size of the file if the header cannot be trusted, and
+// returns it. Errors leave the count unchanged.
+func (db *Database) refreshPageCount() int {
+	if db.empty {
+		return db.PageCount()
+	}
+	buf := make([]byte, HeaderSize)
+	if _, err := db.pager.ReadAt(buf, 0); err != nil {
+		return db.PageCount()
+	}
+	h := Header{
+		ChangeCounter:   binary.BigEndian.Uint32(buf[24:28]),
+		DatabaseSize:    binary.BigEndian.Uint32(buf[28:32]),
+		VersionValidFor: binary.BigEndian.Uint32(buf[92:96]),
+	}
+	pageCount := int(h.DatabaseSize)
+	if !h.DatabaseSizeValid() {
+		var err error
+		if pageCount, err = db.filePageCount(); err != nil {
+			return db.PageCount()
+		}
+	}
+	db.pageCount.Store(int64(pageCount))
+	return pageCount
+}
+
+// checkBTreePage returns a *CorruptError if pageNum, which is about to be
+// read as a B-Tree page, cannot be one. Checking the number before reading
+// the page reports a corrupt root page or child pointer precisely, rather
+// than as a failed read or as a page that does not parse.
+func (db *Database) checkBTreePage(pageNum int) error {
+	if problem := db.pageRefProblem(pageNum); problem != "" {
+		return newCorruptError(pageNum, -1, -1, CorruptPageNumber, errors.New(problem))
+	}
+	return nil
+}
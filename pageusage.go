@@ -0,0 +1,291 @@
+package golite
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// PageUsage accounts for every page of a database, as returned by
+// Database.PageUsage. Each of the PageCount pages is either a B-Tree page, an
+// overflow page, a freelist page, a pointer-map page, the lock-byte page or
+// an orphan, so that
+//
+//	BTreePages + OverflowPages + FreelistTrunkPages + FreelistLeafPages +
+//	PtrmapPages + LockBytePages + len(Orphans) == PageCount
+type PageUsage struct {
+	// PageSize is the size of a page in bytes.
+	PageSize int
+	// FilePages is the number of whole pages in the file, derived from its
+	// size. Pages beyond PageCount are not part of the database.
+	FilePages int
+	// HeaderPages is the size of the database in pages recorded in the
+	// header, which is only used if HeaderPagesValid is true.
+	HeaderPages      int
+	HeaderPagesValid bool
+	// PageCount is the number of pages in the database, as returned by
+	// Database.PageCount.
+	PageCount int
+	// BTreePages is the number of pages of table and index B-Trees,
+	// including those of sqlite_schema.
+	BTreePages int
+	// OverflowPages is the number of pages holding the end of records too
+	// large for their B-Tree page.
+	OverflowPages int
+	// FreelistTrunkPages and FreelistLeafPages count the pages of the
+	// freelist, found by following it from the header.
+	FreelistTrunkPages int
+	FreelistLeafPages  int
+	// HeaderFreelistPages is the number of freelist pages recorded in the
+	// header, which should be the sum of the two above.
+	HeaderFreelistPages int
+	// PtrmapPages is the number of pointer-map pages of an auto-vacuum
+	// database.
+	PtrmapPages int
+	// LockBytePages is 1 if the database is large enough to contain the page
+	// holding the lock bytes, which is never used, and 0 otherwise.
+	LockBytePages int
+	// Orphans lists the pages, in increasing order, that are neither
+	// reachable from a B-Tree root nor on the freelist, nor pointer-map
+	// pages. SQLite's integrity_check reports them as "never used".
+	Orphans []int
+}
+
+// PageUsage accounts for every page of the database by walking the B-Trees
+// of all tables and indexes, with their overflow pages, and the freelist.
+// A page reached twice, or a B-Tree page of an unexpected type, is reported
+// as a *CorruptError.
+func (db *Database) PageUsage() (*PageUsage, error) {
+	schema, err := db.GetSchema()
+	if err != nil {
+		return nil, err
+	}
+	filePages, err := db.filePageCount()
+	if err != nil {
+		return nil, err
+	}
+	u := &PageUsage{
+		PageSize:            int(db.Header.PageSize),
+		FilePages:           filePages,
+		HeaderPages:         int(db.Header.DatabaseSize),
+		HeaderPagesValid:    db.Header.DatabaseSizeValid(),
+		PageCount:           db.PageCount(),
+		HeaderFreelistPages: int(db.Header.FreelistPages),
+	}
+	if db.empty {
+		// The file is empty: page 1 exists only in memory.
+		u.BTreePages = 1
+		return u, nil
+	}
+
+	w := &pageWalk{db: db, seen: make([]bool, u.PageCount+1)}
+	roots := []int{SchemaTable().RootPage}
+	for _, table := range schema.TablesOf() {
+		if !table.Virtual {
+			roots = append(roots, table.RootPage)
+		}
+	}
+	for _, index := range sortedIndexes(schema) {
+		roots = append(roots, index.RootPage)
+	}
+	for _, root := range roots {
+		var tree TreePages
+		if err := w.walkTree(root, 0, &tree); err != nil {
+			return nil, err
+		}
+		u.BTreePages += tree.InteriorPages + tree.LeafPages
+		u.OverflowPages += tree.OverflowPages
+	}
+	if err := w.walkFreelist(int(db.Header.FreelistTrunk), u); err != nil {
+		return nil, err
+	}
+	// The walk reads the page count again if it finds a page beyond it.
+	u.PageCount = db.PageCount()
+	for pageNum := 1; pageNum <= u.PageCount; pageNum++ {
+		switch {
+		case pageNum < len(w.seen) && w.seen[pageNum]:
+		case pageNum == db.lockBytePage():
+			u.LockBytePages++
+		case db.isPtrmapPage(pageNum):
+			u.PtrmapPages++
+		default:
+			u.Orphans = append(u.Orphans, pageNum)
+		}
+	}
+	return u, nil
+}
+
+// TreePages describes the pages used by the B-Tree of a table or index, as
+// returned by Database.TablePages and Database.IndexPages.
+type TreePages struct {
+	// InteriorPages and LeafPages count the B-Tree pages of each kind.
+	InteriorPages int
+	LeafPages     int
+	// OverflowPages counts the pages holding the end of records too large
+	// for the B-Tree pages.
+	OverflowPages int
+	// PagesByDepth holds the number of B-Tree pages at each depth, the root
+	// being at depth 0. Its length is the depth of the tree.
+	PagesByDepth []int
+}
+
+// Pages returns the total number of pages used by the tree, including
+// overflow pages.
+func (t *TreePages) Pages() int {
+	return t.InteriorPages + t.LeafPages + t.OverflowPages
+}
+
+// Depth returns the number of levels of the tree.
+func (t *TreePages) Depth() int {
+	return len(t.PagesByDepth)
+}
+
+// TablePages walks the B-Tree of a table and returns how many pages of each
+// kind it uses. A page reached twice is reported as a *CorruptError.
+func (db *Database) TablePages(table TableInfo) (*TreePages, error) {
+	return db.treePages(table.RootPage)
+}
+
+// IndexPages walks the B-Tree of an index and returns how many pages of each
+// kind it uses, which is its storage cost.
+func (db *Database) IndexPages(index IndexInfo) (*TreePages, error) {
+	return db.treePages(index.RootPage)
+}
+
+// treePages walks the B-Tree rooted at pageNum.
+func (db *Database) treePages(pageNum int) (*TreePages, error) {
+	w := &pageWalk{db: db, seen: make([]bool, db.PageCount()+1)}
+	t := &TreePages{}
+	if err := w.walkTree(pageNum, 0, t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// pageWalk records the pages reached by PageUsage.
+type pageWalk struct {
+	db   *Database
+	seen []bool
+	// leafCell, if not nil, is called for each cell of the leaf pages of the
+	// trees walked.
+	leafCell func(page *Page, i int) error
+}
+
+// mark records that the walk reached pageNum, which must not have been
+// reached before.
+func (w *pageWalk) mark(pageNum int) error {
+	if problem := w.db.pageRefProblem(pageNum); problem != "" {
+		return newCorruptError(pageNum, -1, -1, CorruptPageNumber, errors.New(problem))
+	}
+	if pageNum >= len(w.seen) {
+		// The database grew since the walk started.
+		w.seen = append(w.seen, make([]bool, pageNum+1-len(w.seen))...)
+	}
+	if w.seen[pageNum] {
+		return newCorruptError(pageNum, -1, -1, CorruptTree, errors.New("page is referenced more than once"))
+	}
+	w.seen[pageNum] = true
+	return nil
+}
+
+// visit marks pageNum and reads it.
+func (w *pageWalk) visit(pageNum int) ([]byte, error) {
+	if err := w.mark(pageNum); err != nil {
+		return nil, err
+	}
+	return w.db.readPageData(pageNum)
+}
+
+// walkTree counts in t the pages of the B-Tree rooted at pageNum, which is
+// at the given depth, and their overflow pages.
+func (w *pageWalk) walkTree(pageNum, depth int, t *TreePages) error {
+	data, err := w.visit(pageNum)
+	if err != nil {
+		return err
+	}
+	page, err := parsePageHeader(data, pageNum)
+	if err != nil {
+		return err
+	}
+	interior := false
+	switch page.Type {
+	case PageTypeInteriorTable, PageTypeInteriorIndex:
+		interior = true
+		t.InteriorPages++
+	case PageTypeLeafTable, PageTypeLeafIndex:
+		t.LeafPages++
+	default:
+		return unexpectedPageType(pageNum, page, "page accounting")
+	}
+	if depth == len(t.PagesByDepth) {
+		t.PagesByDepth = append(t.PagesByDepth, 0)
+	}
+	t.PagesByDepth[depth]++
+	for i, cellOffset := range page.CellPointers {
+		if !interior && w.leafCell != nil {
+			if err := w.leafCell(page, i); err != nil {
+				return err
+			}
+		}
+		if interior {
+			if int(cellOffset)+4 > len(data) {
+				return newCorruptError(pageNum, i, int(cellOffset), CorruptCell, errors.New("cell extends beyond the page"))
+			}
+			if err := w.walkTree(int(binary.BigEndian.Uint32(data[cellOffset:])), depth+1, t); err != nil {
+				return err
+			}
+		}
+		pos, err := overflowPointer(page, i, w.db.usableSize())
+		if err != nil {
+			return err
+		}
+		if pos != -1 {
+			if err := w.walkOverflow(int(binary.BigEndian.Uint32(data[pos:])), t); err != nil {
+				return err
+			}
+		}
+	}
+	if interior {
+		return w.walkTree(int(page.RightMostPtr), depth+1, t)
+	}
+	return nil
+}
+
+// walkOverflow counts in t the pages of the overflow chain starting at
+// pageNum. Each starts with the number of the next one, or 0 for the last.
+func (w *pageWalk) walkOverflow(pageNum int, t *TreePages) error {
+	for pageNum != 0 {
+		data, err := w.visit(pageNum)
+		if err != nil {
+			return err
+		}
+		t.OverflowPages++
+		pageNum = int(binary.BigEndian.Uint32(data))
+	}
+	return nil
+}
+
+// walkFreelist counts the trunk and leaf pages of the freelist starting at
+// the trunk page pageNum. A trunk page holds the number of the next trunk
+// page, the number of leaf pages that follow, and their numbers.
+func (w *pageWalk) walkFreelist(pageNum int, u *PageUsage) error {
+	for pageNum != 0 {
+		data, err := w.visit(pageNum)
+		if err != nil {
+			return fmt.Errorf("failed to read freelist: %w", err)
+		}
+		u.FreelistTrunkPages++
+		leaves := int(binary.BigEndian.Uint32(data[4:]))
+		if leaves > (w.db.usableSize()-8)/4 {
+			return newCorruptError(pageNum, -1, 4, CorruptTree, fmt.Errorf("freelist trunk page has %d leaves", leaves))
+		}
+		for i := range leaves {
+			if err := w.mark(int(binary.BigEndian.Uint32(data[8+4*i:]))); err != nil {
+				return fmt.Errorf("failed to read freelist: %w", err)
+			}
+			u.FreelistLeafPages++
+		}
+		pageNum = int(binary.BigEndian.Uint32(data))
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package golite
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDatabase_PageUsage(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "pageusage_test.sqlite")
+	runSQL(t, dbPath, `
+		PRAGMA auto_vacuum = INCREMENTAL;
+		CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT, data BLOB);
+		CREATE INDEX t_name ON t (name);
+		WITH RECURSIVE seq(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM seq WHERE i < 2000)
+		INSERT INTO t SELECT i, 'name' || i, CASE WHEN i % 100 = 0 THEN randomblob(10000) END FROM seq;
+		DELETE FROM t WHERE id > 1000;
+	`)
+
+	usage := func(t *testing.T) *PageUsage {
+		t.Helper()
+		db, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		defer db.Close()
+		u, err := db.PageUsage()
+		if err != nil {
+			t.Fatalf("PageUsage() failed: %v", err)
+		}
+		total := u.BTreePages + u.OverflowPages + u.FreelistTrunkPages + u.FreelistLeafPages + u.PtrmapPages + u.LockBytePages + len(u.Orphans)
+		if total != u.PageCount {
+			t.Errorf("pages add up to %d, want %d: %+v", total, u.PageCount, u)
+		}
+		return u
+	}
+
+	u := usage(t)
+	if u.PageCount != u.FilePages || !u.HeaderPagesValid || u.HeaderPages != u.PageCount {
+		t.Errorf("sizes do not agree: %+v", u)
+	}
+	if u.BTreePages < 3 || u.OverflowPages == 0 || u.PtrmapPages == 0 || u.LockBytePages != 0 {
+		t.Errorf("unexpected usage: %+v", u)
+	}
+	if u.FreelistTrunkPages == 0 || u.FreelistTrunkPages+u.FreelistLeafPages != u.HeaderFreelistPages {
+		t.Errorf("freelist of %d trunk and %d leaf pages, header says %d", u.FreelistTrunkPages, u.FreelistLeafPages, u.HeaderFreelistPages)
+	}
+	if len(u.Orphans) != 0 {
+		t.Errorf("unexpected orphans %v", u.Orphans)
+	}
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	table, err := db.Table("t")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+	pages, err := db.TablePages(table)
+	db.Close()
+	if err != nil || pages.OverflowPages != u.OverflowPages || pages.OverflowPages != 10*2 {
+		t.Errorf("TablePages() = %+v, %v, want the %d overflow pages", pages, err, u.OverflowPages)
+	}
+
+	// Dropping the freelist from the header leaves its pages unreachable.
+	f, err := os.OpenFile(dbPath, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = f.WriteAt(make([]byte, 8), 32)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	orphaned := usage(t)
+	if len(orphaned.Orphans) != u.HeaderFreelistPages || orphaned.FreelistTrunkPages+orphaned.FreelistLeafPages != 0 {
+		t.Errorf("got %d orphans, want %d", len(orphaned.Orphans), u.HeaderFreelistPages)
+	}
+}
+
+func TestDatabase_TablePages(t *testing.T) {
+	db, err := Open(createTestDB(t, "treepages_test.sqlite"))
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	report, err := db.Analyze()
+	if err != nil {
+		t.Fatalf("Analyze() failed: %v", err)
+	}
+	schema, err := db.GetSchema()
+	if err != nil {
+		t.Fatalf("GetSchema() failed: %v", err)
+	}
+	for _, o := range report.Objects {
+		var pages *TreePages
+		if o.IsIndex {
+			pages, err = db.IndexPages(schema.Indexes[o.Name])
+		} else {
+			pages, err = db.TablePages(schema.Tables[o.Name])
+		}
+		if err != nil {
+			t.Fatalf("%s: failed: %v", o.Name, err)
+		}
+		if pages.InteriorPages != o.InteriorPages || pages.LeafPages != o.LeafPages || pages.Depth() != o.Depth || pages.OverflowPages != 0 {
+			t.Errorf("%s: got %+v, want %+v", o.Name, pages, o)
+		}
+		if pages.PagesByDepth[0] != 1 || pages.PagesByDepth[pages.Depth()-1] != o.LeafPages {
+			t.Errorf("%s: unexpected pages by depth %v", o.Name, pages.PagesByDepth)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package golite
+
+import "sync"
+
+// ScanOrder selects whether ParallelScan preserves rowid order.
+type ScanOrder int
+
+const (
+	// Ordered yields records in rowid order, like TableScan. Workers may run
+	// ahead of the consumer by a bounded number of records per subtree.
+	Ordered ScanOrder = iota
+	// Unordered yields records as soon as any worker decodes them, which
+	// keeps all workers busy but interleaves subtrees arbitrarily.
+	Unordered
+)
+
+// parallelBatchSize is the number of records workers send to the consumer at
+// a time, to amortize the cost of channel operations.
+const parallelBatchSize = 128
+
+// scanBatch is a group of records decoded by a ParallelScan worker, or the
+// error that stopped it.
+type scanBatch struct {
+	records []Record
+	err     error
+}
+
+// ParallelScan returns an iterator over all records in a table, like
+// TableScan, but reads and decodes the table using up to workers goroutines.
+// The B-Tree is partitioned at the root page: each child subtree is scanned
+// by one worker. If workers is less than 2, or the root page is a leaf, it
+// behaves exactly like TableScan. WITHOUT ROWID tables are scanned by
+// TableScan. Stopping the iteration early stops the workers.
+func (db *Database) ParallelScan(table TableInfo, workers int, order ScanOrder) RecordIterator {
+	if table.WithoutRowID || table.Virtual {
+		return db.TableScan(table)
+	}
+	return interruptible(db, func(yield func(Record, error) bool) {
+		root, err := db.ReadPage(table.RootPage)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		if workers < 2 || root.Type != PageTypeInteriorTable {
+			db.tableScanPage(table.RootPage, table, yield)
+			return
+		}
+		subtrees := make([]int, 0, len(root.InteriorCells)+1)
+		for _, cell := range root.InteriorCells {
+			subtrees = append(subtrees, int(cell.LeftChildPageNum))
+		}
+		subtrees = append(subtrees, int(root.RightMostPtr))
+
+		done := make(chan struct{})
+		defer close(done)
+
+		// In ordered mode, each subtree gets its own channel and the consumer
+		// drains them in turn. Subtrees are handed out in order, so the one
+		// being drained always has a worker making progress on it.
+		outputs := make([]chan scanBatch, len(subtrees))
+		shared := make(chan scanBatch, workers)
+		for i := range outputs {
+			if order == Ordered {
+				outputs[i] = make(chan scanBatch, 4)
+			} else {
+				outputs[i] = shared
+			}
+		}
+
+		jobs := make(chan int)
+		var wg sync.WaitGroup
+		for range min(workers, len(subtrees)) {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range jobs {
+					db.scanSubtree(subtrees[i], table, outputs[i], done)
+					if order == Ordered {
+						close(outputs[i])
+					}
+				}
+			}()
+		}
+		go func() {
+			defer close(jobs)
+			for i := range subtrees {
+				select {
+				case jobs <- i:
+				case <-done:
+					return
+				}
+			}
+		}()
+		if order == Unordered {
+			go func() {
+				wg.Wait()
+				close(shared)
+			}()
+		}
+
+		consume := func(ch chan scanBatch) bool {
+			for batch := range ch {
+				for _, record := range batch.records {
+					if !yield(record, nil) {
+						return false
+					}
+				}
+				if batch.err != nil {
+					yield(nil, batch.err)
+					return false
+				}
+			}
+			return true
+		}
+		if order == Unordered {
+			consume(shared)
+			return
+		}
+		for _, ch := range outputs {
+			if !consume(ch) {
+				return
+			}
+		}
+	})
+}
+
+// scanSubtree scans the B-Tree rooted at pageNum and sends its records to out
+// in batches, stopping early if done is closed.
+func (db *Database) scanSubtree(pageNum int, table TableInfo, out chan<- scanBatch, done <-chan struct{}) {
+	send := func(batch scanBatch) bool {
+		select {
+		case out <- batch:
+			return true
+		case <-done:
+			return false
+		}
+	}
+	batch := make([]Record, 0, parallelBatchSize)
+	ok := db.tableScanPage(pageNum, table, func(record Record, err error) bool {
+		if err != nil {
+			send(scanBatch{records: batch, err: err})
+			return false
+		}
+		batch = append(batch, record)
+		if len(batch) == parallelBatchSize {
+			if !send(scanBatch{records: batch}) {
+				return false
+			}
+			batch = make([]Record, 0, parallelBatchSize)
+		}
+		return true
+	})
+	if ok && len(batch) > 0 {
+		send(scanBatch{records: batch})
+	}
+}
@@ -0,0 +1,129 @@
+package golite
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestDatabase_ParallelScan(t *testing.T) {
+	dbPath := createTestDB(t, "parallel_scan_test.sqlite")
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	schema, err := db.GetSchema()
+	if err != nil {
+		t.Fatalf("GetSchema() failed: %v", err)
+	}
+	table := schema.Tables["test"]
+
+	collect := func(t *testing.T, it RecordIterator) []Record {
+		t.Helper()
+		var records []Record
+		for record, err := range it {
+			if err != nil {
+				t.Fatalf("scan returned an unexpected error: %v", err)
+			}
+			records = append(records, record)
+		}
+		return records
+	}
+	want := collect(t, db.TableScan(table))
+
+	t.Run("ordered", func(t *testing.T) {
+		for _, workers := range []int{1, 2, 3, 8} {
+			if got := collect(t, db.ParallelScan(table, workers, Ordered)); !reflect.DeepEqual(got, want) {
+				t.Errorf("with %d workers: expected %d records in rowid order, got %d", workers, len(want), len(got))
+			}
+		}
+	})
+
+	t.Run("unordered", func(t *testing.T) {
+		got := collect(t, db.ParallelScan(table, 4, Unordered))
+		sort.Slice(got, func(i, j int) bool { return got[i][0].(int64) < got[j][0].(int64) })
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("expected the same %d records as TableScan, got %d", len(want), len(got))
+		}
+	})
+
+	t.Run("early stop", func(t *testing.T) {
+		for _, order := range []ScanOrder{Ordered, Unordered} {
+			count := 0
+			for _, err := range db.ParallelScan(table, 4, order) {
+				if err != nil {
+					t.Fatalf("ParallelScan() returned an unexpected error: %v", err)
+				}
+				count++
+				if count == 10 {
+					break
+				}
+			}
+			if count != 10 {
+				t.Errorf("expected to stop after 10 records, got %d", count)
+			}
+		}
+	})
+
+	t.Run("corrupt subtree", func(t *testing.T) {
+		root, err := db.ReadPage(table.RootPage)
+		if err != nil {
+			t.Fatalf("ReadPage() failed: %v", err)
+		}
+		corruptPath := createTestDB(t, "parallel_scan_corrupt_test.sqlite")
+		corruptPage(t, corruptPath, int(root.RightMostPtr), 4096)
+		corruptDB, err := Open(corruptPath)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		defer corruptDB.Close()
+		for _, order := range []ScanOrder{Ordered, Unordered} {
+			var scanErr error
+			for _, err := range corruptDB.ParallelScan(table, 4, order) {
+				if err != nil {
+					scanErr = err
+				}
+			}
+			if scanErr == nil {
+				t.Errorf("expected an error from the corrupt subtree (order %d)", order)
+			}
+		}
+	})
+}
+
+func TestDatabase_ParallelScan_WithoutRowID(t *testing.T) {
+	// Enough rows for the table to have an interior root page, whose cells
+	// are index cells rather than table cells.
+	dbPath := filepath.Join(t.TempDir(), "parallel_scan_without_rowid.sqlite")
+	runSQL(t, dbPath, `CREATE TABLE kv (k TEXT PRIMARY KEY, v INTEGER) WITHOUT ROWID;
+WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 2000)
+INSERT INTO kv SELECT printf('key%05d', i), i FROM n;`)
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	schema, err := db.GetSchema()
+	if err != nil {
+		t.Fatalf("GetSchema() failed: %v", err)
+	}
+	table := schema.Tables["kv"]
+	want, err := CollectRecords(db.TableScan(table))
+	if err != nil {
+		t.Fatalf("TableScan() failed: %v", err)
+	}
+	if len(want) != 2000 {
+		t.Fatalf("expected 2000 records from TableScan, got %d", len(want))
+	}
+	for _, order := range []ScanOrder{Ordered, Unordered} {
+		got, err := CollectRecords(db.ParallelScan(table, 4, order))
+		if err != nil {
+			t.Fatalf("ParallelScan() failed (order %d): %v", order, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("expected the same %d records as TableScan (order %d), got %d", len(want), order, len(got))
+		}
+	}
+}
@@ -2,44 +2,562 @@ package golite
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 )
 
 // ParseTableSQL parses a CREATE TABLE statement to extract column information.
 // It returns a slice of ColumnInfo and the index of the rowid alias column (-1 if none).
-// NOTE: This is a simplified parser and may not handle all valid SQL syntax,
-// especially complex constraints or types with parentheses.
+// NOTE: This is a simplified parser and may not handle all valid SQL syntax.
 func ParseTableSQL(sql string) ([]ColumnInfo, int, error) {
-	start := strings.Index(sql, "(")
+	def, err := parseCreateTable(sql)
+	if err != nil {
+		return nil, -1, err
+	}
+	return def.columns, def.rowIDColumnIndex, nil
+}
+
+// tableDef is the information extracted from a CREATE TABLE statement.
+type tableDef struct {
+	columns          []ColumnInfo
+	rowIDColumnIndex int
+	withoutRowID     bool
+	virtual          bool
+	primaryKey       []IndexColumn
+	// uniqueKeys lists the column names of the PRIMARY KEY and UNIQUE
+	// constraints for which SQLite creates an automatic index, in the order
+	// the indexes are numbered: that of the constraints in the statement. The
+	// primary key of a WITHOUT ROWID table, which gets no index, is nil.
+	uniqueKeys  [][]string
+	foreignKeys []ForeignKey
+	checks      []CheckConstraint
+}
+
+// parseCreateTable parses a CREATE TABLE statement.
+func parseCreateTable(sql string) (*tableDef, error) {
+	tokens := tokenizeSQL(sql)
+	if len(tokens) > 1 && tokens[1].is("VIRTUAL") {
+		return parseCreateVirtualTable(sql, tokens), nil
+	}
+	start := slices.IndexFunc(tokens, func(tok sqlToken) bool { return tok.text == "(" })
+	if start == -1 {
+		return nil, fmt.Errorf("invalid CREATE TABLE statement: missing opening parenthesis")
+	}
+	end := closingToken(tokens, start)
+	if end == -1 {
+		return nil, fmt.Errorf("invalid CREATE TABLE statement: missing closing parenthesis")
+	}
+
+	def := &tableDef{rowIDColumnIndex: -1}
+	for i := end + 1; i+1 < len(tokens); i++ {
+		if tokens[i].is("WITHOUT") && tokens[i+1].is("ROWID") {
+			def.withoutRowID = true
+		}
+	}
+
+	// The primary key, if it is a single column of type INTEGER, makes that
+	// column an alias for the rowid rather than getting an index.
+	var primaryKey []string
+	var pkDesc []bool
+	var uniqueKeys [][]string // Including those that do not get their own index.
+	addKey := func(key []string, primary bool, desc []bool) {
+		if primary {
+			primaryKey, pkDesc = key, desc
+		} else {
+			uniqueKeys = append(uniqueKeys, key)
+		}
+		for _, existing := range def.uniqueKeys {
+			if slices.Equal(existing, key) {
+				return // SQLite does not create a second, identical index.
+			}
+		}
+		def.uniqueKeys = append(def.uniqueKeys, key)
+	}
+
+	for _, item := range splitTokens(tokens[start+1 : end]) {
+		if len(item) == 0 {
+			return nil, fmt.Errorf("invalid CREATE TABLE statement: empty column definition")
+		}
+		if isTableConstraint(item[0]) {
+			def.parseTableConstraint(sql, item, addKey)
+			continue
+		}
+		col, err := def.parseColumnDef(sql, item, addKey)
+		if err != nil {
+			return nil, err
+		}
+		def.columns = append(def.columns, col)
+	}
+
+	for i := range def.columns {
+		col := &def.columns[i]
+		col.PrimaryKey = slices.ContainsFunc(primaryKey, func(name string) bool { return strings.EqualFold(name, col.Name) })
+		for _, key := range uniqueKeys {
+			if len(key) == 1 && strings.EqualFold(key[0], col.Name) {
+				col.Unique = true
+			}
+		}
+	}
+
+	for i, name := range primaryKey {
+		def.primaryKey = append(def.primaryKey, IndexColumn{Name: name, Desc: pkDesc[i]})
+	}
+
+	if len(primaryKey) == 1 && !pkDesc[0] && !def.withoutRowID {
+		for i, col := range def.columns {
+			if strings.EqualFold(col.Name, primaryKey[0]) && strings.EqualFold(col.Type, "INTEGER") {
+				def.rowIDColumnIndex = i
+				def.uniqueKeys = slices.DeleteFunc(def.uniqueKeys, func(key []string) bool {
+					return len(key) == 1 && strings.EqualFold(key[0], col.Name)
+				})
+			}
+		}
+	}
+	if def.withoutRowID && primaryKey != nil {
+		// The table's own B-Tree is keyed by its primary key, which still
+		// takes a number unless it comes last.
+		if i := slices.IndexFunc(def.uniqueKeys, func(key []string) bool { return slices.Equal(key, primaryKey) }); i != -1 {
+			def.uniqueKeys[i] = nil
+		}
+		for len(def.uniqueKeys) > 0 && def.uniqueKeys[len(def.uniqueKeys)-1] == nil {
+			def.uniqueKeys = def.uniqueKeys[:len(def.uniqueKeys)-1]
+		}
+	}
+	return def, nil
+}
+
+// parseCreateVirtualTable parses a CREATE VIRTUAL TABLE statement, whose
+// tokens are given. The columns of a virtual table are declared by its module,
+// so they are taken to be the module arguments that do not set an option,
+// such as "tokenize = 'porter'" for FTS5.
+func parseCreateVirtualTable(sql string, tokens []sqlToken) *tableDef {
+	def := &tableDef{rowIDColumnIndex: -1, virtual: true}
+	start := slices.IndexFunc(tokens, func(tok sqlToken) bool { return tok.text == "(" })
+	if start == -1 {
+		return def // A module without arguments.
+	}
+	end := closingToken(tokens, start)
+	if end == -1 {
+		end = len(tokens)
+	}
+	for _, item := range splitTokens(tokens[start+1 : end]) {
+		if len(item) == 0 || slices.ContainsFunc(item, func(tok sqlToken) bool { return tok.text == "=" }) {
+			continue
+		}
+		col := ColumnInfo{Name: item[0].unquoted()}
+		if len(item) > 1 {
+			col.Type = sql[item[1].start:item[len(item)-1].end]
+		}
+		if slices.ContainsFunc(item[1:], func(tok sqlToken) bool { return tok.is("HIDDEN") }) {
+			col.Hidden = ColumnHidden
+		}
+		def.columns = append(def.columns, col)
+	}
+	return def
+}
+
+// columnConstraintKeywords are the keywords that end the type of a column
+// definition and start its constraints.
+var columnConstraintKeywords = []string{
+	"CONSTRAINT", "PRIMARY", "NOT", "NULL", "UNIQUE", "CHECK", "DEFAULT",
+	"COLLATE", "REFERENCES", "GENERATED", "AS",
+}
+
+// parseColumnDef parses the tokens of a column definition, reporting its
+// PRIMARY KEY and UNIQUE constraints to addKey and adding its foreign key
+// and CHECK constraints to def.
+func (def *tableDef) parseColumnDef(sql string, item []sqlToken, addKey func(key []string, primary bool, desc []bool)) (ColumnInfo, error) {
+	typeEnd := 1
+	for typeEnd < len(item) && !item[typeEnd].isAny(columnConstraintKeywords...) {
+		if item[typeEnd].text == "(" {
+			typeEnd = closingToken(item, typeEnd)
+			if typeEnd == -1 {
+				return ColumnInfo{}, fmt.Errorf("malformed column definition: %q", sql[item[0].start:item[len(item)-1].end])
+			}
+		}
+		typeEnd++
+	}
+	col := ColumnInfo{Name: item[0].unquoted()}
+	if typeEnd > 1 {
+		col.Type = sql[item[1].start:item[typeEnd-1].end] // Columns may have no type.
+	}
+	if slices.ContainsFunc(item[1:typeEnd], func(tok sqlToken) bool { return tok.is("HIDDEN") }) {
+		col.Hidden = ColumnHidden
+	}
+
+	constraintName := "" // The name given to the next constraint.
+	for i := typeEnd; i < len(item); i++ {
+		if item[i].isAny(columnConstraintKeywords...) && !item[i].isAny("CONSTRAINT", "CHECK") {
+			constraintName = ""
+		}
+		switch {
+		case item[i].is("CONSTRAINT") && i+1 < len(item):
+			i++
+			constraintName = item[i].unquoted()
+			continue
+		case item[i].is("CHECK") && i+1 < len(item) && item[i+1].text == "(":
+			closing := closingToken(item, i+1)
+			if closing == -1 {
+				return col, nil
+			}
+			def.checks = append(def.checks, CheckConstraint{
+				Name:   constraintName,
+				Column: col.Name,
+				Expr:   exprText(sql, item[i+2:closing]),
+			})
+			constraintName = ""
+			i = closing
+		case item[i].is("NOT") && i+1 < len(item) && item[i+1].is("NULL"):
+			col.NotNull = true
+			i++
+		case item[i].is("COLLATE") && i+1 < len(item):
+			i++
+			col.Collation = item[i].unquoted()
+		case item[i].is("AS") && i+1 < len(item) && item[i+1].text == "(":
+			closing := closingToken(item, i+1)
+			if closing == -1 {
+				return col, nil
+			}
+			col.Hidden = ColumnGeneratedVirtual
+			if closing+1 < len(item) && item[closing+1].is("STORED") {
+				col.Hidden = ColumnGeneratedStored
+			}
+			i = closing
+		case item[i].is("DEFAULT") && i+1 < len(item) && !item[i-1].is("SET"): // Not ON DELETE SET DEFAULT.
+			end := i + 1
+			switch {
+			case item[end].text == "(":
+				end = closingToken(item, end)
+				if end == -1 {
+					return col, nil
+				}
+			case item[end].isAny("+", "-") && end+1 < len(item):
+				end++
+			}
+			// Literals such as 1e-5 and x'00' span several adjacent tokens.
+			for end+1 < len(item) && item[end+1].start == item[end].end && item[end+1].text != "(" {
+				end++
+			}
+			col.Default = exprText(sql, item[i+1:end+1])
+			i = end
+		case item[i].text == "(":
+			i = closingToken(item, i) // Skip COLLATE arguments and the like.
+			if i == -1 {
+				return col, nil
+			}
+		case item[i].is("PRIMARY"):
+			desc := i+2 < len(item) && item[i+2].is("DESC")
+			addKey([]string{col.Name}, true, []bool{desc})
+		case item[i].is("UNIQUE"):
+			col.Unique = true
+			addKey([]string{col.Name}, false, []bool{false})
+		case item[i].is("REFERENCES"):
+			fk := parseReferences(item[i+1:])
+			fk.Columns = []string{col.Name}
+			def.foreignKeys = append(def.foreignKeys, fk)
+		}
+	}
+	return col, nil
+}
+
+// isTableConstraint reports whether a table element starting with tok is a
+// table constraint rather than a column definition.
+func isTableConstraint(tok sqlToken) bool {
+	return tok.isAny("CONSTRAINT", "PRIMARY", "UNIQUE", "CHECK", "FOREIGN")
+}
+
+// parseTableConstraint parses the tokens of a table constraint, reporting
+// PRIMARY KEY and UNIQUE constraints to addKey and adding FOREIGN KEY and
+// CHECK constraints to def.
+func (def *tableDef) parseTableConstraint(sql string, item []sqlToken, addKey func(key []string, primary bool, desc []bool)) {
+	name := ""
+	if item[0].is("CONSTRAINT") && len(item) > 2 {
+		name = item[1].unquoted()
+		item = item[2:]
+	}
+	if item[0].is("CHECK") && len(item) > 1 && item[1].text == "(" {
+		if closing := closingToken(item, 1); closing != -1 {
+			def.checks = append(def.checks, CheckConstraint{Name: name, Expr: exprText(sql, item[2:closing])})
+		}
+		return
+	}
+	if !item[0].isAny("PRIMARY", "UNIQUE", "FOREIGN") {
+		return
+	}
+	columns, rest, desc := parseColumnList(item)
+	if columns == nil {
+		return
+	}
+	if item[0].is("FOREIGN") {
+		if len(rest) > 0 && rest[0].is("REFERENCES") {
+			fk := parseReferences(rest[1:])
+			fk.Columns = columns
+			def.foreignKeys = append(def.foreignKeys, fk)
+		}
+		return
+	}
+	addKey(columns, item[0].is("PRIMARY"), desc)
+}
+
+// parseColumnList parses the first parenthesized list of column names in
+// tokens. It returns the names, the tokens following the list and whether
+// each column is marked DESC. The names are nil if there is no list.
+func parseColumnList(tokens []sqlToken) (columns []string, rest []sqlToken, desc []bool) {
+	open := slices.IndexFunc(tokens, func(tok sqlToken) bool { return tok.text == "(" })
+	if open == -1 {
+		return nil, nil, nil
+	}
+	closing := closingToken(tokens, open)
+	if closing == -1 {
+		return nil, nil, nil
+	}
+	columns = []string{}
+	for _, column := range splitTokens(tokens[open+1 : closing]) {
+		if len(column) > 0 {
+			columns = append(columns, column[0].unquoted())
+			desc = append(desc, column[len(column)-1].is("DESC"))
+		}
+	}
+	return columns, tokens[closing+1:], desc
+}
+
+// parseReferences parses the tokens following REFERENCES in a foreign key
+// clause: the parent table, its optional column list and the actions.
+func parseReferences(tokens []sqlToken) ForeignKey {
+	fk := ForeignKey{OnDelete: "NO ACTION", OnUpdate: "NO ACTION"}
+	if len(tokens) == 0 {
+		return fk
+	}
+	fk.ParentTable = tokens[0].unquoted()
+	rest := tokens[1:]
+	if len(rest) > 0 && rest[0].text == "(" {
+		fk.ParentColumns, rest, _ = parseColumnList(rest)
+	}
+	for i := 0; i+2 < len(rest); i++ {
+		if !rest[i].is("ON") || !rest[i+1].isAny("DELETE", "UPDATE") {
+			continue
+		}
+		action := strings.ToUpper(rest[i+2].text)
+		if rest[i+2].isAny("SET", "NO") && i+3 < len(rest) {
+			action += " " + strings.ToUpper(rest[i+3].text)
+		}
+		if rest[i+1].is("DELETE") {
+			fk.OnDelete = action
+		} else {
+			fk.OnUpdate = action
+		}
+	}
+	return fk
+}
+
+// ParseIndexSQL parses a CREATE INDEX statement to extract the columns of the
+// index key, in order. Columns that are expressions rather than plain column
+// names have an empty Name.
+// NOTE: Like ParseTableSQL, this is a simplified parser.
+func ParseIndexSQL(sql string) ([]IndexColumn, error) {
+	def, err := parseCreateIndex(sql)
+	if err != nil {
+		return nil, err
+	}
+	return def.columns, nil
+}
+
+// indexDef is the information extracted from a CREATE INDEX statement.
+type indexDef struct {
+	columns []IndexColumn
+	unique  bool
+}
+
+// parseCreateIndex parses a CREATE INDEX statement.
+func parseCreateIndex(sql string) (*indexDef, error) {
+	tokens := tokenizeSQL(sql)
+	start := slices.IndexFunc(tokens, func(tok sqlToken) bool { return tok.text == "(" })
 	if start == -1 {
-		return nil, -1, fmt.Errorf("invalid CREATE TABLE statement: missing opening parenthesis")
+		return nil, fmt.Errorf("invalid CREATE INDEX statement: missing opening parenthesis")
 	}
-	// We assume the column definitions end at the last parenthesis.
-	// This is fragile but works for simple CREATE TABLE statements.
-	end := strings.LastIndex(sql, ")")
-	if end <= start {
-		return nil, -1, fmt.Errorf("invalid CREATE TABLE statement: missing closing parenthesis")
+	end := closingToken(tokens, start)
+	if end == -1 {
+		return nil, fmt.Errorf("invalid CREATE INDEX statement: missing closing parenthesis")
 	}
 
-	defsStr := sql[start+1 : end]
-	defs := strings.Split(defsStr, ",")
+	def := &indexDef{unique: len(tokens) > 1 && tokens[1].is("UNIQUE")}
+	for _, item := range splitTokens(tokens[start+1 : end]) {
+		if len(item) == 0 {
+			return nil, fmt.Errorf("invalid CREATE INDEX statement: empty index column")
+		}
+		var col IndexColumn
+		switch last := item[len(item)-1]; {
+		case last.is("DESC"):
+			col.Desc = true
+			item = item[:len(item)-1]
+		case last.is("ASC"):
+			item = item[:len(item)-1]
+		}
+		if len(item) >= 3 && item[len(item)-2].is("COLLATE") {
+			item = item[:len(item)-2]
+		}
+		if len(item) == 1 && item[0].text[0] != '\'' && item[0].text != "(" {
+			col.Name = item[0].unquoted()
+		}
+		def.columns = append(def.columns, col)
+	}
+	return def, nil
+}
+
+// viewDef is the information extracted from a CREATE VIEW statement.
+type viewDef struct {
+	columns []string // Nil if the statement does not name the columns.
+	query   string
+}
+
+// parseCreateView parses a CREATE VIEW statement.
+func parseCreateView(sql string) (*viewDef, error) {
+	tokens := tokenizeSQL(sql)
+	as := slices.IndexFunc(tokens, func(tok sqlToken) bool { return tok.is("AS") })
+	if as == -1 || as+1 == len(tokens) {
+		return nil, fmt.Errorf("invalid CREATE VIEW statement: missing AS clause")
+	}
+	def := &viewDef{query: strings.TrimRight(sql[tokens[as+1].start:], "; \t\n")}
+	if tokens[as-1].text == ")" {
+		def.columns, _, _ = parseColumnList(tokens[:as])
+	}
+	return def, nil
+}
+
+// exprText returns the text of the statement sql spanned by tokens, with
+// comments and line breaks inside it preserved.
+func exprText(sql string, tokens []sqlToken) string {
+	if len(tokens) == 0 {
+		return ""
+	}
+	return sql[tokens[0].start:tokens[len(tokens)-1].end]
+}
 
-	var columns []ColumnInfo
-	rowIDColumnIndex := -1
+// sqlToken is a token of an SQL statement, as produced by tokenizeSQL.
+type sqlToken struct {
+	text       string
+	start, end int // The position of the token in the statement.
+}
+
+// is reports whether the token is the given keyword, ignoring case.
+func (t sqlToken) is(keyword string) bool {
+	return strings.EqualFold(t.text, keyword)
+}
 
-	for i, def := range defs {
-		def = strings.TrimSpace(def)
-		parts := strings.Fields(def)
-		if len(parts) < 2 {
-			return nil, -1, fmt.Errorf("malformed column definition: %q", def)
+// isAny reports whether the token is one of the given keywords, ignoring case.
+func (t sqlToken) isAny(keywords ...string) bool {
+	return slices.ContainsFunc(keywords, t.is)
+}
+
+// unquoted returns the text of the token with identifier quotes removed.
+func (t sqlToken) unquoted() string {
+	if len(t.text) >= 2 {
+		switch t.text[0] {
+		case '"', '`', '\'':
+			q := t.text[:1]
+			return strings.ReplaceAll(t.text[1:len(t.text)-1], q+q, q)
+		case '[':
+			return t.text[1 : len(t.text)-1]
 		}
+	}
+	return t.text
+}
 
-		columns = append(columns, ColumnInfo{Name: strings.Trim(parts[0], "\"`"), Type: parts[1]})
+// tokenizeSQL splits an SQL statement into tokens: words and numbers, quoted
+// strings and identifiers, and punctuation. Comments are skipped.
+func tokenizeSQL(sql string) []sqlToken {
+	var tokens []sqlToken
+	isWord := func(c byte) bool {
+		return c == '_' || c == '$' || c == '.' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= 0x80
+	}
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			i++
+			continue
+		case strings.HasPrefix(sql[i:], "--"):
+			if end := strings.IndexByte(sql[i:], '\n'); end != -1 {
+				i += end + 1
+			} else {
+				i = len(sql)
+			}
+			continue
+		case strings.HasPrefix(sql[i:], "/*"):
+			if end := strings.Index(sql[i+2:], "*/"); end != -1 {
+				i += end + 4
+			} else {
+				i = len(sql)
+			}
+			continue
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			quote := c
+			if c == '[' {
+				quote = ']'
+			}
+			i++
+			for i < len(sql) {
+				if sql[i] == quote {
+					// A doubled quote stands for itself, except in [...].
+					if quote != ']' && i+1 < len(sql) && sql[i+1] == quote {
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				i++
+			}
+		case isWord(c):
+			for i < len(sql) && isWord(sql[i]) {
+				i++
+			}
+		default:
+			i++
+			if i < len(sql) && strings.Contains("<>=|!", string(c)) && strings.Contains("<>=|", string(sql[i])) {
+				i++ // Two-character operators such as <=, <> and ||.
+			}
+		}
+		tokens = append(tokens, sqlToken{text: sql[start:i], start: start, end: i})
+	}
+	return tokens
+}
 
-		if strings.Contains(strings.ToUpper(def), "INTEGER PRIMARY KEY") {
-			rowIDColumnIndex = i
+// closingToken returns the index of the token closing the parenthesis at
+// index open, or -1 if it is not closed.
+func closingToken(tokens []sqlToken, open int) int {
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		switch tokens[i].text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return i
+			}
 		}
 	}
+	return -1
+}
 
-	return columns, rowIDColumnIndex, nil
+// splitTokens splits tokens at the commas that are not nested in parentheses.
+func splitTokens(tokens []sqlToken) [][]sqlToken {
+	var parts [][]sqlToken
+	depth, last := 0, 0
+	for i, tok := range tokens {
+		switch tok.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		case ",":
+			if depth == 0 {
+				parts = append(parts, tokens[last:i])
+				last = i + 1
+			}
+		}
+	}
+	return append(parts, tokens[last:])
 }
@@ -27,7 +27,7 @@ func TestParseTableSQL(t *testing.T) {
 			name: "with integer primary key",
 			sql:  "CREATE TABLE products (product_id INTEGER PRIMARY KEY, name TEXT, price REAL)",
 			wantCols: []ColumnInfo{
-				{Name: "product_id", Type: "INTEGER"},
+				{Name: "product_id", Type: "INTEGER", PrimaryKey: true},
 				{Name: "name", Type: "TEXT"},
 				{Name: "price", Type: "REAL"},
 			},
@@ -49,9 +49,55 @@ func TestParseTableSQL(t *testing.T) {
 			wantErr: true,
 		},
 		{
-			name:    "malformed column def",
-			sql:     "CREATE TABLE bad_col (id, name TEXT)",
-			wantErr: true,
+			name: "table constraints, comments and types with parentheses",
+			sql: `CREATE TABLE t (
+				id INTEGER, -- The key.
+				price DECIMAL(10, 2) NOT NULL,
+				kind UNSIGNED BIG INT /* Odd, but valid. */,
+				PRIMARY KEY (id),
+				CHECK (price > 0)
+			)`,
+			wantCols: []ColumnInfo{
+				{Name: "id", Type: "INTEGER", PrimaryKey: true},
+				{Name: "price", Type: "DECIMAL(10, 2)", NotNull: true},
+				{Name: "kind", Type: "UNSIGNED BIG INT"},
+			},
+			wantRowIDIdx: 0,
+		},
+		{
+			name: "descending integer primary key is not a rowid alias",
+			sql:  "CREATE TABLE t (id INTEGER PRIMARY KEY DESC, name TEXT)",
+			wantCols: []ColumnInfo{
+				{Name: "id", Type: "INTEGER", PrimaryKey: true},
+				{Name: "name", Type: "TEXT"},
+			},
+			wantRowIDIdx: -1,
+		},
+		{
+			name: "typeless column",
+			sql:  "CREATE TABLE t (v)",
+			wantCols: []ColumnInfo{
+				{Name: "v"},
+			},
+			wantRowIDIdx: -1,
+		},
+		{
+			name: "column with only constraints",
+			sql:  "CREATE TABLE u (x UNIQUE, y NOT NULL DEFAULT 0)",
+			wantCols: []ColumnInfo{
+				{Name: "x", Unique: true},
+				{Name: "y", NotNull: true, Default: "0"},
+			},
+			wantRowIDIdx: -1,
+		},
+		{
+			name: "typeless column before a typed one",
+			sql:  "CREATE TABLE t (id, name TEXT)",
+			wantCols: []ColumnInfo{
+				{Name: "id"},
+				{Name: "name", Type: "TEXT"},
+			},
+			wantRowIDIdx: -1,
 		},
 	}
 
@@ -74,3 +120,214 @@ func TestParseTableSQL(t *testing.T) {
 		})
 	}
 }
+
+func TestParseIndexSQL(t *testing.T) {
+	testCases := []struct {
+		name     string
+		sql      string
+		wantCols []IndexColumn
+		wantErr  bool
+	}{
+		{
+			name:     "single column",
+			sql:      "CREATE INDEX idx_name ON test(name)",
+			wantCols: []IndexColumn{{Name: "name"}},
+		},
+		{
+			name:     "order and collation",
+			sql:      `CREATE UNIQUE INDEX "i" ON "t" ("a" COLLATE NOCASE DESC, b ASC, [c])`,
+			wantCols: []IndexColumn{{Name: "a", Desc: true}, {Name: "b"}, {Name: "c"}},
+		},
+		{
+			name:     "expression with partial index",
+			sql:      "CREATE INDEX i ON t(lower(a, 'x,y'), b) WHERE b > 0",
+			wantCols: []IndexColumn{{}, {Name: "b"}},
+		},
+		{
+			name:    "missing parenthesis",
+			sql:     "CREATE INDEX i ON t(a",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cols, err := ParseIndexSQL(tc.sql)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParseIndexSQL() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if !tc.wantErr && !reflect.DeepEqual(cols, tc.wantCols) {
+				t.Errorf("ParseIndexSQL() cols = %v, want %v", cols, tc.wantCols)
+			}
+		})
+	}
+}
+
+func TestParseCreateTable_UniqueKeys(t *testing.T) {
+	testCases := []struct {
+		name string
+		sql  string
+		want [][]string
+	}{
+		{
+			name: "rowid alias has no index",
+			sql:  "CREATE TABLE t (id INTEGER PRIMARY KEY, a TEXT UNIQUE)",
+			want: [][]string{{"a"}},
+		},
+		{
+			name: "constraints in statement order",
+			sql:  `CREATE TABLE t (a TEXT PRIMARY KEY, b TEXT, c TEXT UNIQUE, UNIQUE ("b", c), CONSTRAINT u UNIQUE (a))`,
+			want: [][]string{{"a"}, {"c"}, {"b", "c"}},
+		},
+		{
+			name: "without rowid primary key",
+			sql:  "CREATE TABLE t (a TEXT, b TEXT UNIQUE, PRIMARY KEY (a)) WITHOUT ROWID",
+			want: [][]string{{"b"}},
+		},
+		{
+			name: "without rowid primary key takes a number",
+			sql:  "CREATE TABLE t (a TEXT PRIMARY KEY, b TEXT UNIQUE) WITHOUT ROWID",
+			want: [][]string{nil, {"b"}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			def, err := parseCreateTable(tc.sql)
+			if err != nil {
+				t.Fatalf("parseCreateTable() failed: %v", err)
+			}
+			if !reflect.DeepEqual(def.uniqueKeys, tc.want) {
+				t.Errorf("expected unique keys %v, got %v", tc.want, def.uniqueKeys)
+			}
+		})
+	}
+}
+
+func TestParseCreateIndex_Unique(t *testing.T) {
+	testCases := map[string]bool{
+		"CREATE INDEX i ON t(a)":                            false,
+		"create unique index i on t(a, b)":                  true,
+		"CREATE UNIQUE INDEX IF NOT EXISTS i ON t(a)":       true,
+		`CREATE INDEX "unique" ON t(a) WHERE a IS NOT NULL`: false,
+	}
+	for sql, want := range testCases {
+		def, err := parseCreateIndex(sql)
+		if err != nil {
+			t.Fatalf("parseCreateIndex(%q) failed: %v", sql, err)
+		}
+		if def.unique != want {
+			t.Errorf("parseCreateIndex(%q).unique = %v, want %v", sql, def.unique, want)
+		}
+	}
+}
+
+func TestParseCreateTable_ForeignKeys(t *testing.T) {
+	sql := `CREATE TABLE orders (
+		id INTEGER PRIMARY KEY,
+		user_id INTEGER REFERENCES users ON DELETE CASCADE,
+		sku TEXT,
+		region TEXT,
+		CONSTRAINT fk_product FOREIGN KEY (sku, region) REFERENCES "products" (sku, region)
+			ON UPDATE SET NULL ON DELETE NO ACTION DEFERRABLE INITIALLY DEFERRED
+	)`
+	def, err := parseCreateTable(sql)
+	if err != nil {
+		t.Fatalf("parseCreateTable() failed: %v", err)
+	}
+	want := []ForeignKey{
+		{Columns: []string{"user_id"}, ParentTable: "users", OnDelete: "CASCADE", OnUpdate: "NO ACTION"},
+		{Columns: []string{"sku", "region"}, ParentTable: "products", ParentColumns: []string{"sku", "region"}, OnDelete: "NO ACTION", OnUpdate: "SET NULL"},
+	}
+	if !reflect.DeepEqual(def.foreignKeys, want) {
+		t.Errorf("expected foreign keys %+v, got %+v", want, def.foreignKeys)
+	}
+	if len(def.columns) != 4 {
+		t.Errorf("expected 4 columns, got %d", len(def.columns))
+	}
+}
+
+func TestParseCreateTable_Checks(t *testing.T) {
+	sql := `CREATE TABLE items (
+		price REAL CONSTRAINT positive CHECK (price > 0) NOT NULL CHECK(price < 1e6),
+		name TEXT CHECK (name IN ('a,b', 'c)')),
+		qty INTEGER,
+		CHECK (qty >= 0 AND
+		       qty < 100),
+		CONSTRAINT "cheap" CHECK (price * qty < 1000)
+	)`
+	def, err := parseCreateTable(sql)
+	if err != nil {
+		t.Fatalf("parseCreateTable() failed: %v", err)
+	}
+	want := []CheckConstraint{
+		{Name: "positive", Column: "price", Expr: "price > 0"},
+		{Column: "price", Expr: "price < 1e6"},
+		{Column: "name", Expr: "name IN ('a,b', 'c)')"},
+		{Expr: "qty >= 0 AND\n\t\t       qty < 100"},
+		{Name: "cheap", Expr: "price * qty < 1000"},
+	}
+	if !reflect.DeepEqual(def.checks, want) {
+		t.Errorf("expected checks %q, got %q", want, def.checks)
+	}
+	if len(def.columns) != 3 {
+		t.Errorf("expected 3 columns, got %d", len(def.columns))
+	}
+}
+
+func TestParseCreateTable_Defaults(t *testing.T) {
+	sql := `CREATE TABLE settings (
+		a INTEGER DEFAULT 0 NOT NULL,
+		b TEXT DEFAULT 'it''s, fine',
+		c REAL DEFAULT -1.5e-3,
+		d BLOB DEFAULT x'00ff',
+		e TEXT DEFAULT CURRENT_TIMESTAMP,
+		f INTEGER DEFAULT (1 + 2) CHECK (f > 0),
+		g TEXT
+	)`
+	def, err := parseCreateTable(sql)
+	if err != nil {
+		t.Fatalf("parseCreateTable() failed: %v", err)
+	}
+	var got []string
+	for _, col := range def.columns {
+		got = append(got, col.Default)
+	}
+	want := []string{"0", "'it''s, fine'", "-1.5e-3", "x'00ff'", "CURRENT_TIMESTAMP", "(1 + 2)", ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected defaults %q, got %q", want, got)
+	}
+	if len(def.checks) != 1 {
+		t.Errorf("expected the CHECK after the DEFAULT to be parsed, got %v", def.checks)
+	}
+}
+
+func TestParseCreateTable_ColumnFlags(t *testing.T) {
+	sql := `CREATE TABLE people (
+		first TEXT NOT NULL COLLATE NOCASE,
+		last TEXT COLLATE "rtrim" UNIQUE,
+		email TEXT,
+		parent INTEGER REFERENCES people ON DELETE SET NULL,
+		other INTEGER REFERENCES people ON DELETE SET DEFAULT,
+		full TEXT GENERATED ALWAYS AS (first || ' ' || last) STORED,
+		initials TEXT AS (substr(first, 1, 1)),
+		PRIMARY KEY (first, last),
+		UNIQUE (email),
+		UNIQUE (email, last)
+	)`
+	def, err := parseCreateTable(sql)
+	if err != nil {
+		t.Fatalf("parseCreateTable() failed: %v", err)
+	}
+	want := []ColumnInfo{
+		{Name: "first", Type: "TEXT", NotNull: true, PrimaryKey: true, Collation: "NOCASE"},
+		{Name: "last", Type: "TEXT", PrimaryKey: true, Unique: true, Collation: "rtrim"},
+		{Name: "email", Type: "TEXT", Unique: true},
+		{Name: "parent", Type: "INTEGER"},
+		{Name: "other", Type: "INTEGER"},
+		{Name: "full", Type: "TEXT", Hidden: ColumnGeneratedStored},
+		{Name: "initials", Type: "TEXT", Hidden: ColumnGeneratedVirtual},
+	}
+	if !reflect.DeepEqual(def.columns, want) {
+		t.Errorf("expected columns\n%+v\ngot\n%+v", want, def.columns)
+	}
+}
@@ -0,0 +1,274 @@
+package golite
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// AccessPath is a way of finding the rows of a table, chosen by PlanAccess.
+type AccessPath int
+
+const (
+	// FullScanPath reads the whole table with TableScan.
+	FullScanPath AccessPath = iota
+	// RowIDSeekPath finds the row by its rowid with TableSeek.
+	RowIDSeekPath
+	// IndexSeekPath searches an index for the values of its leading columns.
+	IndexSeekPath
+	// SkipScanPath searches an index whose first column is not constrained,
+	// once for each of its distinct values, which pays off when it has few.
+	SkipScanPath
+)
+
+// String returns the name of the access path.
+func (p AccessPath) String() string {
+	switch p {
+	case FullScanPath:
+		return "full scan"
+	case RowIDSeekPath:
+		return "rowid seek"
+	case IndexSeekPath:
+		return "index seek"
+	case SkipScanPath:
+		return "skip-scan"
+	}
+	return fmt.Sprintf("AccessPath(%d)", int(p))
+}
+
+// AccessPlan is the access path chosen by PlanAccess for a table, with its
+// estimated cost.
+type AccessPlan struct {
+	Table TableInfo
+	Path  AccessPath
+	// Index is the index searched by IndexSeekPath and SkipScanPath.
+	Index IndexInfo
+	// EqualityColumns is the number of leading columns of Index whose value
+	// is known, not counting the first column for SkipScanPath.
+	EqualityColumns int
+	// Keys is the number of keys searched for by RowIDSeekPath,
+	// IndexSeekPath and SkipScanPath: 1, unless a column used has one of
+	// the values of an IN list, see PlanAccessIn.
+	Keys int
+	// Covering is true if Index holds all the columns needed, so that the
+	// table need not be read.
+	Covering bool
+	// Rows is the estimated number of rows the access path yields.
+	Rows float64
+	// Cost is the estimated cost of the access path, in index entries read.
+	Cost float64
+	// FromStatistics is true if the estimates are based on the statistics
+	// gathered by ANALYZE rather than on default assumptions.
+	FromStatistics bool
+}
+
+// String describes the plan the way EXPLAIN QUERY PLAN does in SQLite, for
+// instance "SEARCH t USING INDEX t_b (b=?)".
+func (p AccessPlan) String() string {
+	switch p.Path {
+	case RowIDSeekPath:
+		return fmt.Sprintf("SEARCH %s USING INTEGER PRIMARY KEY (rowid=?)", p.Table.Name)
+	case IndexSeekPath, SkipScanPath:
+		kind := "INDEX"
+		if p.Covering {
+			kind = "COVERING INDEX"
+		}
+		var terms []string
+		columns := p.Index.Columns
+		if p.Path == SkipScanPath {
+			terms = append(terms, fmt.Sprintf("ANY(%s)", columns[0].Name))
+			columns = columns[1:]
+		}
+		for _, col := range columns[:p.EqualityColumns] {
+			terms = append(terms, col.Name+"=?")
+		}
+		if p.Table.WithoutRowID && p.Index.RootPage == p.Table.RootPage {
+			return fmt.Sprintf("SEARCH %s USING PRIMARY KEY (%s)", p.Table.Name, strings.Join(terms, " AND "))
+		}
+		return fmt.Sprintf("SEARCH %s USING %s %s (%s)", p.Table.Name, kind, p.Index.Name, strings.Join(terms, " AND "))
+	}
+	return "SCAN " + p.Table.Name
+}
+
+// defaultTableRows is the number of rows assumed for a table without
+// statistics, as SQLite does.
+const defaultTableRows = 1000000
+
+// defaultRowsPerKey is the number of rows assumed to share the values of the
+// first i+1 columns of an index without statistics, as SQLite does. Longer
+// prefixes are assumed to match 5 rows, and full keys of unique indexes 1.
+var defaultRowsPerKey = []float64{10, 9, 8, 7, 6}
+
+// rowLookupCost is the cost of reading a row of the table, relative to that
+// of reading an index entry, as estimated by SQLite.
+const rowLookupCost = 3
+
+// minSkipScanRows is the average number of rows per value of the first
+// column of an index below which SQLite does not consider skip-scans.
+const minSkipScanRows = 18
+
+// PlanAccess chooses how to find the rows of table whose columns named in
+// equalities have known values, for a query reading the named columns, or all
+// of them if columns is nil. It weighs a full scan against a rowid seek, a
+// seek in each index of the table whose leading columns are constrained, and
+// a skip-scan of each index whose first column is not, estimating their costs
+// from the statistics gathered by ANALYZE, read with Statistics. Without
+// statistics, it falls back on SQLite's default assumptions, which favor any
+// usable index and never choose a skip-scan. Partial and expression indexes
+// are not told apart from others.
+func (db *Database) PlanAccess(table TableInfo, equalities, columns []string) (AccessPlan, error) {
+	return db.planAccess(table, equalities, "", 1, columns)
+}
+
+// PlanAccessIn is like PlanAccess, but the value of the column named in is
+// also known to be one of n values, as for "in IN (v1, ..., vn)". A seek that
+// uses that column is then repeated for each value, as MultiSeek does, which
+// multiplies its cost by n. The plan's Keys tells how many seeks it makes.
+func (db *Database) PlanAccessIn(table TableInfo, in string, n int, equalities, columns []string) (AccessPlan, error) {
+	return db.planAccess(table, equalities, in, max(n, 0), columns)
+}
+
+// planAccess implements PlanAccess and PlanAccessIn. The column named in, if
+// any, has one of n values.
+func (db *Database) planAccess(table TableInfo, equalities []string, in string, n int, columns []string) (AccessPlan, error) {
+	schema, err := db.GetSchema()
+	if err != nil {
+		return AccessPlan{}, err
+	}
+	statistics, err := db.Statistics()
+	if err != nil {
+		return AccessPlan{}, err
+	}
+	stats := statistics[table.Name]
+
+	known := map[int]bool{}
+	for _, name := range equalities {
+		column := table.lookupColumn(name)
+		if column == -1 {
+			return AccessPlan{}, fmt.Errorf("no such column: %s", name)
+		}
+		known[column] = true
+	}
+	inColumn := -1
+	if in != "" {
+		if inColumn = table.lookupColumn(in); inColumn == -1 {
+			return AccessPlan{}, fmt.Errorf("no such column: %s", in)
+		}
+		known[inColumn] = true
+	}
+	// keys returns the number of keys searched for by a seek using the given
+	// columns of the table.
+	keys := func(used ...int) int {
+		if inColumn != -1 && slices.Contains(used, inColumn) {
+			return n
+		}
+		return 1
+	}
+	if columns == nil {
+		for _, col := range table.Columns {
+			columns = append(columns, col.Name)
+		}
+	}
+
+	rows := float64(defaultTableRows)
+	if stats != nil {
+		rows = float64(max(stats.Rows, 1))
+	}
+	seekCost := math.Log2(rows) + 1
+	best := AccessPlan{Table: table, Path: FullScanPath, Rows: rows, Cost: rows * rowLookupCost, FromStatistics: stats != nil}
+	consider := func(plan AccessPlan) {
+		if plan.Cost < best.Cost {
+			best = plan
+		}
+	}
+
+	if !table.WithoutRowID {
+		for _, column := range []int{rowIDColumn, table.RowIDColumnIndex} {
+			if column != -1 && known[column] {
+				k := keys(column)
+				consider(AccessPlan{Table: table, Path: RowIDSeekPath, Keys: k, Rows: float64(k), Cost: float64(k) * seekCost, FromStatistics: stats != nil})
+			}
+		}
+	}
+
+	var indexes []IndexInfo
+	for _, index := range schema.Indexes {
+		if strings.EqualFold(index.TableName, table.Name) {
+			indexes = append(indexes, index)
+		}
+	}
+	slices.SortFunc(indexes, func(a, b IndexInfo) int { return strings.Compare(a.Name, b.Name) })
+	if index, ok := table.PrimaryKeyIndex(); ok {
+		indexes = append(indexes, index)
+	}
+	for _, index := range indexes {
+		plan := AccessPlan{Table: table, Index: index, Covering: table.IsCovering(index, columns)}
+		lookupCost := float64(rowLookupCost)
+		if plan.Covering {
+			lookupCost = 0
+		}
+		indexStats, hasStats := IndexStats{}, false
+		if stats != nil {
+			indexStats, hasStats = stats.Indexes[index.Name]
+		}
+		plan.FromStatistics = hasStats
+		// rowsPerKey estimates the number of rows sharing the values of the
+		// first n columns of the index.
+		rowsPerKey := func(n int) float64 {
+			switch {
+			case hasStats && len(indexStats.RowsPerKey) > 0:
+				return float64(indexStats.RowsPerKey[min(n, len(indexStats.RowsPerKey))-1])
+			case index.Unique && n == len(index.Columns):
+				return 1
+			case n <= len(defaultRowsPerKey):
+				return min(rows, defaultRowsPerKey[n-1])
+			}
+			return min(rows, 5)
+		}
+
+		if prefix := knownPrefix(table, index.Columns, known); prefix > 0 {
+			plan.Path, plan.EqualityColumns = IndexSeekPath, prefix
+			plan.Keys = keys(tableColumns(table, index.Columns[:prefix])...)
+			plan.Rows = float64(plan.Keys) * rowsPerKey(prefix)
+			plan.Cost = float64(plan.Keys)*seekCost + plan.Rows*(1+lookupCost)
+			consider(plan)
+			continue
+		}
+		if !hasStats || indexStats.NoSkipScan || len(index.Columns) < 2 || len(indexStats.RowsPerKey) < 2 || rowsPerKey(1) < minSkipScanRows {
+			continue
+		}
+		if prefix := knownPrefix(table, index.Columns[1:], known); prefix > 0 {
+			distinct := rows / rowsPerKey(1)
+			plan.Path, plan.EqualityColumns = SkipScanPath, prefix
+			plan.Keys = keys(tableColumns(table, index.Columns[1:prefix+1])...)
+			plan.Rows = float64(plan.Keys) * distinct * rowsPerKey(prefix+1)
+			plan.Cost = float64(plan.Keys)*distinct*seekCost + plan.Rows*(1+lookupCost)
+			consider(plan)
+		}
+	}
+	return best, nil
+}
+
+// tableColumns returns the indexes in the table's Columns of the named
+// columns, as returned by lookupColumn.
+func tableColumns(table TableInfo, columns []IndexColumn) []int {
+	indexes := make([]int, len(columns))
+	for i, col := range columns {
+		indexes[i] = table.lookupColumn(col.Name)
+	}
+	return indexes
+}
+
+// knownPrefix returns the number of leading columns whose value is known.
+func knownPrefix(table TableInfo, columns []IndexColumn, known map[int]bool) int {
+	for i, col := range columns {
+		if col.Name == "" {
+			return i
+		}
+		if column := table.lookupColumn(col.Name); column == -1 || !known[column] {
+			return i
+		}
+	}
+	return len(columns)
+}
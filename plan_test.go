@@ -0,0 +1,125 @@
+package golite
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// explainQueryPlan returns the plan SQLite chooses for a query on a single
+// table, as the last line of the output of EXPLAIN QUERY PLAN.
+func explainQueryPlan(t *testing.T, dbPath, query string) string {
+	t.Helper()
+	output, err := exec.Command("sqlite3", dbPath, "EXPLAIN QUERY PLAN "+query).CombinedOutput()
+	if err != nil {
+		t.Fatalf("sqlite3 failed: %v\nOutput: %s", err, output)
+	}
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	return strings.TrimLeft(lines[len(lines)-1], "`|- ")
+}
+
+func TestPlanAccess(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "plan.sqlite")
+	runSQL(t, dbPath, `
+		CREATE TABLE t (a INTEGER PRIMARY KEY, b TEXT, c INTEGER, d INTEGER, e TEXT, f TEXT);
+		CREATE INDEX t_b ON t(b);
+		CREATE INDEX t_cd ON t(c, d);
+		CREATE INDEX t_e ON t(e);
+		CREATE INDEX t_f ON t(f);
+		WITH RECURSIVE seq(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM seq WHERE i < 5000)
+		INSERT INTO t SELECT i, 'n' || (i % 50), i % 4, i, iif(i % 2, 'odd', 'even'), 'same' FROM seq;
+		CREATE TABLE w (k TEXT, j INTEGER, v TEXT, PRIMARY KEY (k, j)) WITHOUT ROWID;
+	`)
+
+	queries := []struct {
+		where      string
+		equalities []string
+		columns    []string
+	}{
+		{"a = 7", []string{"a"}, nil},
+		{"rowid = 7", []string{"rowid"}, nil},
+		{"b = 'n1'", []string{"b"}, nil},
+		{"c = 1", []string{"c"}, nil},
+		{"c = 1 AND d = 5", []string{"c", "d"}, nil},
+		{"d = 5", []string{"d"}, nil},
+		{"e = 'odd'", []string{"e"}, nil},
+		{"b = 'n1' AND d = 5", []string{"b", "d"}, nil},
+		{"e = 'odd'", []string{"e"}, []string{"e", "a"}},
+		{"f = 'same'", []string{"f"}, nil},
+		{"1", nil, nil},
+	}
+	check := func(analyzed bool) {
+		t.Helper()
+		db, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		defer db.Close()
+		table, err := db.Table("t")
+		if err != nil {
+			t.Fatalf("Table() failed: %v", err)
+		}
+		for _, q := range queries {
+			selected := "*"
+			if q.columns != nil {
+				selected = strings.Join(q.columns, ", ")
+			}
+			want := explainQueryPlan(t, dbPath, "SELECT "+selected+" FROM t WHERE "+q.where)
+			plan, err := db.PlanAccess(table, q.equalities, q.columns)
+			if err != nil {
+				t.Fatalf("PlanAccess() failed: %v", err)
+			}
+			if got := plan.String(); got != want {
+				t.Errorf("analyzed = %v, WHERE %s: got %q, want %q (%v)", analyzed, q.where, got, want, plan)
+			}
+			if plan.FromStatistics != analyzed {
+				t.Errorf("analyzed = %v, WHERE %s: FromStatistics = %v", analyzed, q.where, plan.FromStatistics)
+			}
+		}
+
+		inQueries := []struct {
+			where string
+			in    string
+			n     int
+		}{
+			{"b IN ('n1', 'n2', 'n3')", "b", 3},
+			{"a IN (1, 2, 3, 4)", "a", 4},
+			{"c IN (1, 2) AND d = 5", "c", 2},
+			{"f IN ('same', 'other')", "f", 2},
+		}
+		for _, q := range inQueries {
+			want := explainQueryPlan(t, dbPath, "SELECT * FROM t WHERE "+q.where)
+			var equalities []string
+			if strings.Contains(q.where, "d = 5") {
+				equalities = []string{"d"}
+			}
+			plan, err := db.PlanAccessIn(table, q.in, q.n, equalities, nil)
+			if err != nil {
+				t.Fatalf("PlanAccessIn() failed: %v", err)
+			}
+			if got := plan.String(); got != want {
+				t.Errorf("analyzed = %v, WHERE %s: got %q, want %q", analyzed, q.where, got, want)
+			}
+			if plan.Path != FullScanPath && plan.Keys != q.n {
+				t.Errorf("analyzed = %v, WHERE %s: %d keys, want %d", analyzed, q.where, plan.Keys, q.n)
+			}
+		}
+
+		w, err := db.Table("w")
+		if err != nil {
+			t.Fatalf("Table() failed: %v", err)
+		}
+		plan, err := db.PlanAccess(w, []string{"k"}, nil)
+		if want := explainQueryPlan(t, dbPath, "SELECT * FROM w WHERE k = 'x'"); err != nil || plan.String() != want {
+			t.Errorf("PlanAccess(w) = (%v, %v), want %q", plan, err, want)
+		}
+		if _, err := db.PlanAccess(table, []string{"nope"}, nil); err == nil {
+			t.Errorf("expected an error for an unknown column")
+		}
+	}
+
+	check(false)
+	runSQL(t, dbPath, "ANALYZE;")
+	check(true)
+}
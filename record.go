@@ -2,11 +2,13 @@ package golite
 
 import (
 	"bytes"
+	"cmp"
 	"encoding/binary"
 	"fmt"
 	"iter"
 	"math"
 	"strings"
+	"unsafe"
 )
 
 // NullType is a sentinel type used to represent a SQL NULL value.
@@ -23,38 +25,182 @@ type Record []any
 type RecordIterator iter.Seq2[Record, error]
 
 // ParseRecord parses a raw byte slice from a cell's payload into a Record.
+// Malformed records are reported as a *CorruptError with reason CorruptRecord.
+// The returned TEXT and BLOB values are copies and do not alias data.
 func ParseRecord(data []byte) (Record, error) {
-	headerSize, n := readVarint(data)
+	record, err := parseRecord(data)
+	if err != nil {
+		return nil, newCorruptError(0, -1, -1, CorruptRecord, err)
+	}
+	record.Detach()
+	return record, nil
+}
+
+// parseRecord is like ParseRecord, but returns plain errors so that callers
+// can add the location of the record, and values that alias data.
+func parseRecord(data []byte) (Record, error) {
+	record, _, err := parseRecordPrefix(data)
+	return record, err
+}
+
+// parseRecordIn is like parseRecord, but allocates the record from arena,
+// which may be nil.
+func parseRecordIn(data []byte, arena *recordArena) (Record, error) {
+	if arena == nil {
+		return parseRecord(data)
+	}
+	serialTypes, headerSize, err := parseRecordHeaderInto(data, arena.serialTypes[:0])
+	arena.serialTypes = serialTypes
+	if err != nil {
+		return nil, err
+	}
+	record, _, err := decodeRecordBody(serialTypes, data[headerSize:], arena)
+	return record, err
+}
+
+// parseRecordPrefix parses a record from the start of data, which may contain
+// trailing bytes. It returns the record and the number of bytes it occupies.
+func parseRecordPrefix(data []byte) (Record, int, error) {
+	serialTypes, headerSize, err := parseRecordHeader(data)
+	if err != nil {
+		return nil, 0, err
+	}
+	record, bodySize, err := decodeRecordBody(serialTypes, data[headerSize:], nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	return record, headerSize + bodySize, nil
+}
+
+// parseRecordHeader reads the header of the record at the start of data. It
+// returns the serial types of the columns and the size of the header.
+func parseRecordHeader(data []byte) ([]int64, int, error) {
+	return parseRecordHeaderInto(data, nil)
+}
+
+// parseRecordHeaderInto is like parseRecordHeader, but appends the serial
+// types to serialTypes.
+func parseRecordHeaderInto(data []byte, serialTypes []int64) ([]int64, int, error) {
+	headerSize, n, err := readVarintChecked(data)
+	if err != nil {
+		return nil, 0, fmt.Errorf("invalid record: failed to read header size: %w", err)
+	}
 	if int(headerSize) > len(data) {
-		return nil, fmt.Errorf("invalid record: header size %d is larger than payload size %d", headerSize, len(data))
+		return nil, 0, fmt.Errorf("invalid record: header size %d is larger than payload size %d", headerSize, len(data))
+	}
+	if int(headerSize) < n {
+		return nil, 0, fmt.Errorf("invalid record: header size %d is too small", headerSize)
 	}
 
 	header := data[n:headerSize]
-	body := data[headerSize:]
-
-	var serialTypes []int64
 	bytesRead := 0
 	for bytesRead < len(header) {
-		st, m := readVarint(header[bytesRead:])
+		st, m, err := readVarintChecked(header[bytesRead:])
+		if err != nil {
+			return nil, 0, fmt.Errorf("invalid record: failed to read serial type %d: %w", len(serialTypes), err)
+		}
 		serialTypes = append(serialTypes, st)
 		bytesRead += m
 	}
+	return serialTypes, int(headerSize), nil
+}
 
-	record := make(Record, 0, len(serialTypes))
+// ColumnLayout describes how the value of a column is stored in a record.
+type ColumnLayout struct {
+	// SerialType is the serial type of the value, as in the record header.
+	// It tells for instance whether an integer was stored in 1 or 8 bytes.
+	SerialType int64
+	// Offset is the offset of the value's bytes within the record.
+	Offset int
+	// Length is the number of bytes taken by the value, which is zero for
+	// NULL and the constants 0 and 1.
+	Length int
+}
+
+// ParseRecordDetailed is like ParseRecord, but also returns the layout of
+// each column within data, so that callers can see how values were stored
+// and extract their raw bytes with data[c.Offset:c.Offset+c.Length].
+func ParseRecordDetailed(data []byte) (Record, []ColumnLayout, error) {
+	serialTypes, headerSize, err := parseRecordHeader(data)
+	if err != nil {
+		return nil, nil, newCorruptError(0, -1, -1, CorruptRecord, err)
+	}
+	record, _, err := decodeRecordBody(serialTypes, data[headerSize:], nil)
+	if err != nil {
+		return nil, nil, newCorruptError(0, -1, -1, CorruptRecord, err)
+	}
+	record.Detach()
+	layout := make([]ColumnLayout, len(serialTypes))
+	offset := headerSize
+	for i, st := range serialTypes {
+		layout[i] = ColumnLayout{SerialType: st, Offset: offset, Length: serialTypeSize(st)}
+		offset += layout[i].Length
+	}
+	return record, layout, nil
+}
+
+// serialTypeSize returns the number of body bytes taken by a value of the
+// given valid serial type.
+func serialTypeSize(st int64) int {
+	switch {
+	case st >= 12:
+		return int(st-12) / 2
+	case st >= 1 && st <= 4:
+		return int(st)
+	case st == 5:
+		return 6
+	case st == 6 || st == 7:
+		return 8
+	}
+	return 0
+}
+
+// decodeRecordBody decodes the values described by serialTypes from the start
+// of body. It returns the record, allocated from arena unless it is nil, and
+// the number of body bytes consumed.
+func decodeRecordBody(serialTypes []int64, body []byte, arena *recordArena) (Record, int, error) {
+	return decodeRecordBodyInto(serialTypes, body, arena.record(len(serialTypes)))
+}
+
+// decodeRecordBodyInto is like decodeRecordBody, but appends the values to
+// record.
+func decodeRecordBodyInto(serialTypes []int64, body []byte, record Record) (Record, int, error) {
 	bodyOffset := 0
 	for i, st := range serialTypes {
 		value, bytesConsumed, err := serialTypeToValue(st, body[bodyOffset:])
 		if err != nil {
-			return nil, fmt.Errorf("invalid record: column %d: %w", i, err)
+			return nil, 0, fmt.Errorf("invalid record: column %d: %w", i, err)
 		}
 		if bodyOffset+bytesConsumed > len(body) {
-			return nil, fmt.Errorf("invalid record: data for column %d extends beyond body", i)
+			return nil, 0, fmt.Errorf("invalid record: data for column %d extends beyond body", i)
 		}
 		record = append(record, value)
 		bodyOffset += bytesConsumed
 	}
+	return record, bodyOffset, nil
+}
 
-	return record, nil
+// Detach replaces every TEXT and BLOB value of the record with a copy, so
+// that the record no longer references the page it was read from. Records
+// read by a Database opened with WithZeroCopy share memory with the page
+// buffer, which stays alive as long as any of its values do; detaching
+// records that are kept for long lets the page be garbage collected.
+func (r Record) Detach() {
+	for i, v := range r {
+		switch v := v.(type) {
+		case string:
+			r[i] = strings.Clone(v)
+		case []byte:
+			r[i] = bytes.Clone(v)
+		}
+	}
+}
+
+// Copy returns a detached copy of the record, leaving r unchanged.
+func (r Record) Copy() Record {
+	c := append(Record(nil), r...)
+	c.Detach()
+	return c
 }
 
 // CompareRecords compares two records according to SQLite's sorting rules.
@@ -88,7 +234,7 @@ func CompareRecords(a, b Record) int {
 // Lower ranks are considered "less than" higher ranks.
 func getTypeRank(v any) int {
 	switch v.(type) {
-	case NullType:
+	case NullType, nil:
 		return 0
 	case int64, float64:
 		return 1 // Numeric types
@@ -102,12 +248,46 @@ func getTypeRank(v any) int {
 	}
 }
 
-// toFloat64 converts a numeric value (int64 or float64) to a float64 for comparison.
-func toFloat64(v any) float64 {
-	if i, ok := v.(int64); ok {
-		return float64(i)
+// compareNumbers compares two numeric values (int64 or float64) exactly.
+func compareNumbers(a, b any) int {
+	switch a := a.(type) {
+	case int64:
+		switch b := b.(type) {
+		case int64:
+			return cmp.Compare(a, b)
+		case float64:
+			return compareIntFloat(a, b)
+		}
+	case float64:
+		switch b := b.(type) {
+		case int64:
+			return -compareIntFloat(b, a)
+		case float64:
+			return cmp.Compare(a, b)
+		}
+	}
+	return 0 // Should not be reached.
+}
+
+// compareIntFloat compares an integer with a float without converting the
+// integer to a float, which loses precision above 2^53. It follows SQLite's
+// sqlite3IntFloatCompare, so that values sort as they do in the file.
+func compareIntFloat(i int64, r float64) int {
+	if math.IsNaN(r) {
+		return 1 // As cmp.Compare, order NaN before all numbers.
+	}
+	if r < math.MinInt64 {
+		return 1
 	}
-	return v.(float64)
+	if r >= math.MaxInt64 { // The constant is rounded up to 2^63.
+		return -1
+	}
+	// r is now in range, so truncating it is exact on the integer part.
+	if c := cmp.Compare(i, int64(r)); c != 0 {
+		return c
+	}
+	// The integer parts are equal, so only a fractional part of r can differ.
+	return cmp.Compare(float64(i), r)
 }
 
 // compareValues compares two individual values based on SQLite's type ordering rules.
@@ -127,14 +307,7 @@ func compareValues(a, b any) int {
 	case 0: // NULL
 		return 0 // All NULLs are equal.
 	case 1: // Numeric
-		fA := toFloat64(a)
-		fB := toFloat64(b)
-		if fA < fB {
-			return -1
-		} else if fA > fB {
-			return 1
-		}
-		return 0
+		return compareNumbers(a, b)
 	case 2: // Text
 		return strings.Compare(a.(string), b.(string))
 	case 3: // Blob
@@ -146,6 +319,8 @@ func compareValues(a, b any) int {
 
 // serialTypeToValue decodes a single value from the record body based on its serial type.
 // It returns the parsed Value and the number of bytes consumed from the body.
+// TEXT and BLOB values alias body, which must therefore never be modified;
+// see Record.Detach.
 func serialTypeToValue(serialType int64, body []byte) (any, int, error) {
 	switch {
 	case serialType >= 12 && serialType%2 == 0: // BLOB
@@ -159,7 +334,10 @@ func serialTypeToValue(serialType int64, body []byte) (any, int, error) {
 		if len(body) < length {
 			return nil, 0, fmt.Errorf("insufficient data for TEXT of length %d", length)
 		}
-		return string(body[:length]), length, nil
+		if length == 0 {
+			return "", 0, nil
+		}
+		return unsafe.String(&body[0], length), length, nil
 	}
 
 	switch serialType {
@@ -28,6 +28,16 @@ func TestParseRecord_Errors(t *testing.T) {
 			input: []byte{0x02, 0x0b}, // Serial type 11 is reserved
 			err:   "invalid record: column 0: unsupported serial type 11",
 		},
+		{
+			name:  "empty payload",
+			input: []byte{},
+			err:   "invalid record: failed to read header size: varint truncated after 0 bytes",
+		},
+		{
+			name:  "serial type truncated by header end",
+			input: []byte{0x02, 0x81, 0x01}, // Header ends in the middle of a 2-byte serial type
+			err:   "invalid record: failed to read serial type 0: varint truncated after 1 bytes",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -138,6 +148,13 @@ func TestCompareRecords(t *testing.T) {
 		{name: "greater blob", a: Record{[]byte{3}}, b: Record{[]byte{2}}, want: 1},
 		{name: "lesser int vs float", a: Record{int64(4)}, b: Record{4.1}, want: -1},
 		{name: "greater int vs float", a: Record{int64(5)}, b: Record{4.9}, want: 1},
+		{name: "large int above float", a: Record{int64(1<<53 + 1)}, b: Record{float64(1 << 53)}, want: 1},
+		{name: "large int equal float", a: Record{int64(1 << 62)}, b: Record{float64(1 << 62)}, want: 0},
+		{name: "max int below 2^63", a: Record{int64(math.MaxInt64)}, b: Record{float64(1 << 63)}, want: -1},
+		{name: "min int equal -2^63", a: Record{int64(math.MinInt64)}, b: Record{float64(math.MinInt64)}, want: 0},
+		{name: "int above huge negative float", a: Record{int64(math.MinInt64)}, b: Record{-1e300}, want: 1},
+		{name: "negative int vs fraction", a: Record{int64(-3)}, b: Record{-2.5}, want: -1},
+		{name: "int vs NaN", a: Record{int64(0)}, b: Record{math.NaN()}, want: 1},
 
 		// Type precedence
 		{name: "null vs int", a: Record{SQLNull}, b: Record{int64(1)}, want: -1},
@@ -190,3 +207,76 @@ func TestReadVarint_Errors(t *testing.T) {
 	shortInput := []byte{0x81}
 	readVarint(shortInput)
 }
+
+func TestRecord_Detach(t *testing.T) {
+	// A record with a TEXT value "abc" and a BLOB value x'0102'.
+	data := []byte{0x03, 0x13, 0x10, 'a', 'b', 'c', 0x01, 0x02}
+
+	t.Run("ParseRecord copies values", func(t *testing.T) {
+		buf := append([]byte(nil), data...)
+		record, err := ParseRecord(buf)
+		if err != nil {
+			t.Fatalf("ParseRecord() failed: %v", err)
+		}
+		clear(buf)
+		if want := (Record{"abc", []byte{1, 2}}); !reflect.DeepEqual(record, want) {
+			t.Errorf("expected %v after overwriting the input, got %v", want, record)
+		}
+	})
+
+	t.Run("zero-copy values alias the buffer until detached", func(t *testing.T) {
+		buf := append([]byte(nil), data...)
+		record, err := parseRecord(buf)
+		if err != nil {
+			t.Fatalf("parseRecord() failed: %v", err)
+		}
+		copied := record.Copy()
+		buf[3], buf[6] = 'x', 9
+		if want := (Record{"xbc", []byte{9, 2}}); !reflect.DeepEqual(record, want) {
+			t.Errorf("expected aliased values %v, got %v", want, record)
+		}
+		record.Detach()
+		clear(buf)
+		if want := (Record{"xbc", []byte{9, 2}}); !reflect.DeepEqual(record, want) {
+			t.Errorf("expected detached values %v, got %v", want, record)
+		}
+		if want := (Record{"abc", []byte{1, 2}}); !reflect.DeepEqual(copied, want) {
+			t.Errorf("expected copied values %v, got %v", want, copied)
+		}
+	})
+}
+
+func TestParseRecordDetailed(t *testing.T) {
+	record := Record{SQLNull, int64(1), int64(300), 1.5, "ab", []byte{7}, int64(-1 << 40)}
+	data, err := EncodeRecord(record)
+	if err != nil {
+		t.Fatalf("EncodeRecord() failed: %v", err)
+	}
+	got, layout, err := ParseRecordDetailed(data)
+	if err != nil {
+		t.Fatalf("ParseRecordDetailed() failed: %v", err)
+	}
+	if !reflect.DeepEqual(got, record) {
+		t.Errorf("expected record %v, got %v", record, got)
+	}
+	// The header is 8 bytes: its size, then one byte per serial type.
+	want := []ColumnLayout{
+		{SerialType: 0, Offset: 8, Length: 0},
+		{SerialType: 9, Offset: 8, Length: 0},
+		{SerialType: 2, Offset: 8, Length: 2},
+		{SerialType: 7, Offset: 10, Length: 8},
+		{SerialType: 17, Offset: 18, Length: 2},
+		{SerialType: 14, Offset: 20, Length: 1},
+		{SerialType: 5, Offset: 21, Length: 6},
+	}
+	if !reflect.DeepEqual(layout, want) {
+		t.Errorf("expected layout %+v, got %+v", want, layout)
+	}
+	if raw := data[layout[4].Offset : layout[4].Offset+layout[4].Length]; string(raw) != "ab" {
+		t.Errorf("expected the raw bytes of column 4 to be \"ab\", got %q", raw)
+	}
+
+	if _, _, err := ParseRecordDetailed([]byte{0x02, 0x0b}); err == nil {
+		t.Error("expected an error for a reserved serial type")
+	}
+}
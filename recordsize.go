@@ -0,0 +1,151 @@
+package golite
+
+import (
+	"errors"
+	"math/bits"
+)
+
+// RecordSizes describes the sizes of the records of a table, as returned by
+// Database.RecordSizes.
+type RecordSizes struct {
+	// Records is the number of records in the table.
+	Records int
+	// Bytes is the total size of the records, and MaxBytes the size of the
+	// largest one. The size of a record is that of its payload: its header,
+	// which holds the serial type of each value, and the values.
+	Bytes    int64
+	MaxBytes int64
+	// Histogram counts the records by size. Its buckets are contiguous, each
+	// twice as wide as the one before, from the bucket of the smallest record
+	// to that of the largest.
+	Histogram []SizeBucket
+	// Columns holds the sizes of the values of each column, in the order the
+	// columns are declared.
+	Columns []ColumnSizes
+	// OverflowRecords is the number of records too large for their B-Tree
+	// page, whose end is stored on OverflowPages overflow pages.
+	OverflowRecords int
+	OverflowPages   int
+}
+
+// SizeBucket counts the records whose size is between Min and Max bytes
+// inclusive.
+type SizeBucket struct {
+	Min, Max int64
+	Count    int
+}
+
+// ColumnSizes describes the sizes of the values of a column, as serialized
+// in the records.
+type ColumnSizes struct {
+	Name string
+	// Bytes is the total size of the values, and MaxBytes the size of the
+	// largest one. NULL, 0 and 1 take no bytes.
+	Bytes    int64
+	MaxBytes int64
+	// Nulls is the number of NULL values. An INTEGER PRIMARY KEY column is
+	// stored as NULL, its value being the rowid. Values missing from records
+	// written before the column was added are not counted.
+	Nulls int
+}
+
+// MeanBytes returns the average size of a record.
+func (r *RecordSizes) MeanBytes() float64 {
+	if r.Records == 0 {
+		return 0
+	}
+	return float64(r.Bytes) / float64(r.Records)
+}
+
+// RecordSizes walks the leaf pages of a table and returns statistics about
+// the sizes of its records and of the values of its columns, to help decide
+// for instance whether a large column should move to a table of its own.
+// Only the record headers are decoded, and overflow pages are counted from
+// the payload sizes without being read.
+func (db *Database) RecordSizes(table TableInfo) (*RecordSizes, error) {
+	r := &RecordSizes{Columns: make([]ColumnSizes, len(table.Columns))}
+	for i, col := range table.Columns {
+		r.Columns[i].Name = col.Name
+	}
+	order := table.StorageOrder()
+	usable := db.usableSize()
+	counts := map[int]int{}
+	var serialTypes []int64
+	w := &pageWalk{db: db, seen: make([]bool, db.PageCount()+1)}
+	w.leafCell = func(page *Page, i int) error {
+		payloadSize, local, err := leafCellPayload(page, i, usable)
+		if err != nil {
+			return err
+		}
+		if serialTypes, _, err = parseRecordHeaderInto(local, serialTypes[:0]); err != nil {
+			if int64(len(local)) < payloadSize {
+				return unsupported("record headers spilling onto overflow pages")
+			}
+			return newCorruptError(page.pageNum, i, int(page.CellPointers[i]), CorruptRecord, err)
+		}
+		r.Records++
+		r.Bytes += payloadSize
+		r.MaxBytes = max(r.MaxBytes, payloadSize)
+		counts[bits.Len64(uint64(payloadSize))]++
+		if rest := payloadSize - int64(len(local)); rest > 0 {
+			r.OverflowRecords++
+			r.OverflowPages += int((rest + int64(usable) - 5) / int64(usable-4))
+		}
+		for j, st := range serialTypes {
+			if j >= len(order) {
+				break
+			}
+			col := &r.Columns[order[j]]
+			size := int64(serialTypeSize(st))
+			col.Bytes += size
+			col.MaxBytes = max(col.MaxBytes, size)
+			if st == 0 {
+				col.Nulls++
+			}
+		}
+		return nil
+	}
+	if err := w.walkTree(table.RootPage, 0, &TreePages{}); err != nil {
+		return nil, err
+	}
+	if r.Records > 0 {
+		lo, hi := bits.Len64(uint64(r.MaxBytes)), 0
+		for k := range counts {
+			lo, hi = min(lo, k), max(hi, k)
+		}
+		for k := lo; k <= hi; k++ {
+			bucket := SizeBucket{Max: 1<<k - 1, Count: counts[k]}
+			if k > 0 {
+				bucket.Min = 1 << (k - 1)
+			}
+			r.Histogram = append(r.Histogram, bucket)
+		}
+	}
+	return r, nil
+}
+
+// leafCellPayload returns the payload size of cell i of a leaf page whose
+// usable size is usable, and the part of the payload stored on the page.
+func leafCellPayload(page *Page, i, usable int) (int64, []byte, error) {
+	offset := int(page.CellPointers[i])
+	data := page.RawData
+	if offset >= len(data) {
+		return 0, nil, newCorruptError(page.pageNum, i, offset, CorruptCell, errors.New("cell extends beyond the page"))
+	}
+	payloadSize, n, err := readVarintChecked(data[offset:])
+	if err != nil {
+		return 0, nil, newCorruptError(page.pageNum, i, offset, CorruptCell, err)
+	}
+	offset += n
+	if page.Type == PageTypeLeafTable {
+		if _, n, err = readVarintChecked(data[offset:]); err != nil {
+			return 0, nil, newCorruptError(page.pageNum, i, offset, CorruptCell, err)
+		}
+		offset += n
+	}
+	local := localPayloadSize(payloadSize, usable, page.Type == PageTypeLeafTable)
+	if local < 0 || offset+int(local) > len(data) {
+		return 0, nil, newCorruptError(page.pageNum, i, offset, CorruptPayload, errors.New("payload extends beyond the page"))
+	}
+	return payloadSize, data[offset : offset+int(local)], nil
+}
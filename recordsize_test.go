@@ -0,0 +1,80 @@
+package golite
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestDatabase_RecordSizes(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "recordsize_test.sqlite")
+	runSQL(t, dbPath, `
+		CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT, data BLOB);
+		WITH RECURSIVE seq(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM seq WHERE i < 100)
+		INSERT INTO t SELECT i, printf('%.*c', i, 'a'), CASE WHEN i % 10 != 0 THEN zeroblob(i * 100) END FROM seq;
+		CREATE TABLE w (k TEXT PRIMARY KEY, v INTEGER) WITHOUT ROWID;
+		INSERT INTO w VALUES ('a', 1), ('bb', 1000), ('ccc', NULL);
+	`)
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+
+	table, err := db.Table("t")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+	sizes, err := db.RecordSizes(table)
+	if err != nil {
+		t.Fatalf("RecordSizes() failed: %v", err)
+	}
+	var dataBytes int64
+	for i := int64(1); i <= 100; i++ {
+		if i%10 != 0 {
+			dataBytes += i * 100
+		}
+	}
+	want := []ColumnSizes{
+		{Name: "id", Nulls: 100},
+		{Name: "name", Bytes: 5050, MaxBytes: 100},
+		{Name: "data", Bytes: dataBytes, MaxBytes: 9900, Nulls: 10},
+	}
+	for i, col := range sizes.Columns {
+		if col != want[i] {
+			t.Errorf("Columns[%d] = %+v, want %+v", i, col, want[i])
+		}
+	}
+	records, bytes := 0, int64(0)
+	for _, bucket := range sizes.Histogram {
+		records += bucket.Count
+		if bucket.Max != 2*bucket.Min-1 {
+			t.Errorf("unexpected bucket %+v", bucket)
+		}
+	}
+	for _, col := range sizes.Columns {
+		bytes += col.Bytes
+	}
+	if sizes.Records != 100 || records != 100 || sizes.Bytes <= bytes || sizes.MaxBytes < 9900+100 {
+		t.Errorf("unexpected sizes %+v", sizes)
+	}
+	pages, err := db.TablePages(table)
+	if err != nil {
+		t.Fatalf("TablePages() failed: %v", err)
+	}
+	if sizes.OverflowRecords == 0 || sizes.OverflowPages != pages.OverflowPages {
+		t.Errorf("%d records on %d overflow pages, want %d pages", sizes.OverflowRecords, sizes.OverflowPages, pages.OverflowPages)
+	}
+
+	table, err = db.Table("w")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+	sizes, err = db.RecordSizes(table)
+	if err != nil {
+		t.Fatalf("RecordSizes() failed: %v", err)
+	}
+	want = []ColumnSizes{{Name: "k", Bytes: 6, MaxBytes: 3}, {Name: "v", Bytes: 2, MaxBytes: 2, Nulls: 1}}
+	if sizes.Records != 3 || sizes.Columns[0] != want[0] || sizes.Columns[1] != want[1] || sizes.Bytes != 4+7+6 {
+		t.Errorf("unexpected sizes %+v", sizes)
+	}
+}
@@ -0,0 +1,197 @@
+package golite
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"iter"
+	"strings"
+)
+
+// RecoveredRecord is a table row salvaged from the database file by Recover.
+type RecoveredRecord struct {
+	// Table is the name of the table whose B-Tree contains the page the row
+	// was found on, or "" if the page is not reachable from any known table.
+	Table string
+	// PageNum is the page the row was found on.
+	PageNum int
+	// RowID is the rowid stored in the row's cell.
+	RowID int64
+	// Record holds the row's values. Rows attributed to a table have the
+	// shape TableScan yields for it; for other rows the rowid is prepended.
+	Record Record
+}
+
+// Recover salvages rows from a database whose B-Tree structure may be damaged.
+// Rather than navigating from root pages, it reads every page in the file,
+// keeps those that look like leaf table pages and yields each cell that can
+// be decoded. When the schema can still be read, rows are attributed to the
+// table owning their page; undecodable pages and cells are skipped silently.
+// Only failures to read the file itself are reported as errors.
+func (db *Database) Recover() iter.Seq2[RecoveredRecord, error] {
+	return interruptible(db, func(yield func(RecoveredRecord, error) bool) {
+		pageCount, err := db.filePageCount()
+		if err != nil {
+			yield(RecoveredRecord{}, err)
+			return
+		}
+		owners := db.recoverPageOwners()
+
+		for pageNum := 1; pageNum <= pageCount; pageNum++ {
+			data, err := db.readPageData(pageNum)
+			if err != nil {
+				yield(RecoveredRecord{}, err)
+				return
+			}
+			table, owned := owners[pageNum]
+			for _, cell := range salvageLeafCells(data, pageNum, db.usableSize()) {
+				db.ownValues(cell.Record)
+				db.decodeText(cell.Record)
+				rec := RecoveredRecord{PageNum: pageNum, RowID: cell.RowID}
+				if owned && len(cell.Record) <= len(table.Columns) {
+					rec.Table = table.Name
+					if order := table.virtualColumnsOrder(); order != nil {
+						rec.Record = table.fromStorage(cell.Record, order)
+					} else {
+						rec.Record = make(Record, len(table.Columns))
+						copy(rec.Record, cell.Record)
+						for i := len(cell.Record); i < len(rec.Record); i++ {
+							rec.Record[i] = table.Columns[i].paddingValue() // Columns added after the row was written.
+						}
+					}
+					if table.RowIDColumnIndex != -1 {
+						rec.Record[table.RowIDColumnIndex] = cell.RowID
+					} else {
+						rec.Record = append(Record{cell.RowID}, rec.Record...)
+					}
+				} else {
+					rec.Record = append(Record{cell.RowID}, cell.Record...)
+				}
+				if !yield(rec, nil) {
+					return
+				}
+			}
+		}
+	})
+}
+
+// RecoverDump writes the rows salvaged by Recover to w as a script of SQL
+// statements, in the spirit of the sqlite3 shell's .recover command. Rows
+// attributed to a table are inserted into it with their rowid; the others
+// are collected in a lost_and_found table with columns (pgno, nfield, id,
+// c0, c1, ...).
+func (db *Database) RecoverDump(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("BEGIN TRANSACTION;\n")
+
+	created := make(map[string]bool)
+	var orphans []RecoveredRecord
+	maxFields := 0
+	schema, _ := db.GetSchema() // A missing schema just means nothing is attributed.
+
+	for rec, err := range db.Recover() {
+		if err != nil {
+			return err
+		}
+		if rec.Table == "" {
+			orphans = append(orphans, rec)
+			maxFields = max(maxFields, len(rec.Record)-1)
+			continue
+		}
+		if strings.HasPrefix(rec.Table, "sqlite_") {
+			continue
+		}
+		table := schema.Tables[rec.Table]
+		if !created[table.Name] {
+			fmt.Fprintf(bw, "%s;\n", table.SQL)
+			created[table.Name] = true
+		}
+		writeRowInsert(bw, table, rec.RowID, table.ColumnValues(rec.Record))
+	}
+
+	if len(orphans) > 0 {
+		bw.WriteString("CREATE TABLE lost_and_found(pgno, nfield, id")
+		for i := 0; i < maxFields; i++ {
+			fmt.Fprintf(bw, ", c%d", i)
+		}
+		bw.WriteString(");\n")
+		for _, rec := range orphans {
+			values := Record{int64(rec.PageNum), int64(len(rec.Record) - 1), rec.RowID}
+			values = append(values, rec.Record[1:]...)
+			for len(values) < maxFields+3 {
+				values = append(values, SQLNull)
+			}
+			writeInsert(bw, "lost_and_found", nil, values)
+		}
+	}
+
+	bw.WriteString("COMMIT;\n")
+	return bw.Flush()
+}
+
+// recoverPageOwners maps each page reachable from a table's root page to that
+// table. Damaged parts of the trees are skipped. It returns nil if the schema
+// cannot be read.
+func (db *Database) recoverPageOwners() map[int]TableInfo {
+	schema, err := db.GetSchema()
+	if err != nil {
+		return nil
+	}
+	owners := make(map[int]TableInfo)
+	for _, table := range schema.Tables {
+		db.collectTablePages(table.RootPage, table, owners)
+	}
+	return owners
+}
+
+// collectTablePages records table as the owner of every page in the B-Tree
+// rooted at pageNum. Pages that are already owned are not revisited, which
+// also protects against cycles in corrupt files.
+func (db *Database) collectTablePages(pageNum int, table TableInfo, owners map[int]TableInfo) {
+	if _, seen := owners[pageNum]; seen {
+		return
+	}
+	page, err := db.ReadPage(pageNum)
+	if err != nil {
+		return
+	}
+	owners[pageNum] = table
+	if page.Type == PageTypeInteriorTable {
+		for _, cell := range page.InteriorCells {
+			db.collectTablePages(int(cell.LeftChildPageNum), table, owners)
+		}
+		db.collectTablePages(int(page.RightMostPtr), table, owners)
+	}
+}
+
+// salvageLeafCells returns the cells that can be decoded from data, if it
+// looks like a leaf table page whose usable size is usable. Cells that fail
+// to decode are skipped.
+func salvageLeafCells(data []byte, pageNum, usable int) []LeafTableCell {
+	offset := 0
+	if pageNum == 1 {
+		offset = HeaderSize
+	}
+	if len(data) < offset+8 || data[offset] != PageTypeLeafTable {
+		return nil
+	}
+	cellCount := int(binary.BigEndian.Uint16(data[offset+3 : offset+5]))
+	pointersStart := offset + 8
+	pointersEnd := pointersStart + 2*cellCount
+	if pointersEnd > len(data) {
+		return nil
+	}
+
+	var cells []LeafTableCell
+	for i := pointersStart; i < pointersEnd; i += 2 {
+		cellOffset := int(binary.BigEndian.Uint16(data[i : i+2]))
+		if cellOffset < pointersEnd || cellOffset >= len(data) {
+			continue
+		}
+		if cell, err := parseLeafTableCell(data[cellOffset:], usable, nil); err == nil {
+			cells = append(cells, cell)
+		}
+	}
+	return cells
+}
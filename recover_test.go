@@ -0,0 +1,134 @@
+package golite
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// corruptPage overwrites a page of the database file at dbPath with zeros.
+func corruptPage(t *testing.T, dbPath string, pageNum int, pageSize int) {
+	t.Helper()
+	f, err := os.OpenFile(dbPath, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatalf("failed to open database for corruption: %v", err)
+	}
+	defer f.Close()
+	if _, err := f.WriteAt(make([]byte, pageSize), int64(pageNum-1)*int64(pageSize)); err != nil {
+		t.Fatalf("failed to corrupt page %d: %v", pageNum, err)
+	}
+}
+
+func TestDatabase_Recover(t *testing.T) {
+	t.Run("intact database", func(t *testing.T) {
+		dbPath := createTestDB(t, "recover_intact_test.sqlite")
+		db, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		defer db.Close()
+
+		counts := make(map[string]int)
+		for rec, err := range db.Recover() {
+			if err != nil {
+				t.Fatalf("Recover() returned an unexpected error: %v", err)
+			}
+			counts[rec.Table]++
+			if rec.Table == "test" && rec.Record[0] != rec.RowID {
+				t.Errorf("expected rowid alias column to hold %d, got %v", rec.RowID, rec.Record[0])
+			}
+		}
+		if counts["test"] != 500 {
+			t.Errorf("expected 500 rows attributed to 'test', got %d", counts["test"])
+		}
+		if counts["sqlite_schema"] != 2 {
+			t.Errorf("expected 2 rows attributed to 'sqlite_schema', got %d", counts["sqlite_schema"])
+		}
+		if counts[""] != 0 {
+			t.Errorf("expected no unattributed rows, got %d", counts[""])
+		}
+	})
+
+	t.Run("broken table root", func(t *testing.T) {
+		dbPath := createTestDB(t, "recover_broken_test.sqlite")
+		db, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		schema, err := db.GetSchema()
+		if err != nil {
+			t.Fatalf("GetSchema() failed: %v", err)
+		}
+		rootPage := schema.Tables["test"].RootPage
+		pageSize := int(db.Header.PageSize)
+		db.Close()
+
+		corruptPage(t, dbPath, rootPage, pageSize)
+
+		db, err = Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		defer db.Close()
+
+		rowIDs := make(map[int64]bool)
+		for rec, err := range db.Recover() {
+			if err != nil {
+				t.Fatalf("Recover() returned an unexpected error: %v", err)
+			}
+			if rec.Table == "" {
+				rowIDs[rec.RowID] = true
+			}
+		}
+		if len(rowIDs) != 500 {
+			t.Errorf("expected to salvage 500 distinct rows, got %d", len(rowIDs))
+		}
+
+		var buf bytes.Buffer
+		if err := db.RecoverDump(&buf); err != nil {
+			t.Fatalf("RecoverDump() failed: %v", err)
+		}
+		restoredPath := filepath.Join(t.TempDir(), "restored.sqlite")
+		cmd := exec.Command("sqlite3", restoredPath)
+		cmd.Stdin = &buf
+		if output, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("sqlite3 failed to load recovered script: %v\nOutput: %s", err, string(output))
+		}
+		output, err := exec.Command("sqlite3", restoredPath, "SELECT count(*) FROM lost_and_found WHERE c1 = 'name42'").CombinedOutput()
+		if err != nil {
+			t.Fatalf("failed to query restored database: %v\nOutput: %s", err, string(output))
+		}
+		if got := strings.TrimSpace(string(output)); got != "1" {
+			t.Errorf("expected to find name42 in lost_and_found, got count %q", got)
+		}
+	})
+	t.Run("rowids", func(t *testing.T) {
+		// Without a rowid alias column, the rowids are only kept if the
+		// script inserts them explicitly.
+		dbPath := filepath.Join(t.TempDir(), "recover_rowids.sqlite")
+		runSQL(t, dbPath, `CREATE TABLE t (v TEXT);
+INSERT INTO t (rowid, v) VALUES (3, 'a'), (7, 'b'), (42, 'c');`)
+		db, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		defer db.Close()
+
+		var buf bytes.Buffer
+		if err := db.RecoverDump(&buf); err != nil {
+			t.Fatalf("RecoverDump() failed: %v", err)
+		}
+		restoredPath := filepath.Join(t.TempDir(), "restored.sqlite")
+		runSQL(t, restoredPath, buf.String())
+		output, err := exec.Command("sqlite3", restoredPath, "SELECT group_concat(rowid || v) FROM t").CombinedOutput()
+		if err != nil {
+			t.Fatalf("failed to query restored database: %v\nOutput: %s", err, string(output))
+		}
+		if got := strings.TrimSpace(string(output)); got != "3a,7b,42c" {
+			t.Errorf("expected the rows to keep their rowids, got %q", got)
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package golite
+
+// Recursive evaluates a recursive query the way SQLite evaluates WITH
+// RECURSIVE with UNION ALL. The records of seed are queued; then each record
+// taken from the front of the queue is yielded and passed to step, whose
+// records are added to the back of the queue, until it is empty. Records are
+// thus yielded in breadth-first order. A typical step seeks the children of
+// a row in a tree, for instance with IndexSeek on a parent column.
+//
+// The depth of a seed record is 0 and that of a record produced by step is
+// one more than that of the record it was given. If maxDepth is positive,
+// producing a record deeper than maxDepth aborts the iteration with a
+// *LimitError, which guards against cycles in the data. Use Guard to limit
+// the total number of records.
+func Recursive(seed RecordIterator, step func(record Record) RecordIterator, maxDepth int) RecordIterator {
+	type queued struct {
+		record Record
+		depth  int
+	}
+	return func(yield func(Record, error) bool) {
+		var queue []queued
+		for record, err := range seed {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			queue = append(queue, queued{record: record})
+		}
+		rows := 0
+		for len(queue) > 0 {
+			item := queue[0]
+			queue[0] = queued{} // Let the record be collected.
+			queue = queue[1:]
+			if !yield(item.record, nil) {
+				return
+			}
+			rows++
+			for record, err := range step(item.record) {
+				if err != nil {
+					yield(nil, err)
+					return
+				}
+				if maxDepth > 0 && item.depth+1 > maxDepth {
+					yield(nil, &LimitError{Limit: "max depth", Rows: rows})
+					return
+				}
+				queue = append(queue, queued{record: record, depth: item.depth + 1})
+			}
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package golite
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRecursive(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "tree.sqlite")
+	runSQL(t, dbPath, `
+		CREATE TABLE category (id INTEGER PRIMARY KEY, parent INTEGER, name TEXT);
+		CREATE INDEX category_parent ON category (parent);
+		INSERT INTO category VALUES
+			(1, NULL, 'root'), (2, 1, 'a'), (3, 1, 'b'), (4, 2, 'a1'), (5, 4, 'a1x'), (6, NULL, 'other');
+	`)
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	table, err := db.Table("category")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+	index, err := db.Index("category_parent")
+	if err != nil {
+		t.Fatalf("Index() failed: %v", err)
+	}
+
+	// children yields the rows whose parent is the given row.
+	children := func(record Record) RecordIterator {
+		return func(yield func(Record, error) bool) {
+			for entry, err := range db.IndexSeek(index, Record{record[0]}) {
+				if err == nil {
+					for child, err := range db.TableSeek(table, entry[1].(int64)) {
+						if !yield(child, err) {
+							return
+						}
+					}
+				} else if !yield(nil, err) {
+					return
+				}
+			}
+		}
+	}
+	names := func(it RecordIterator) ([]string, error) {
+		var names []string
+		for record, err := range it {
+			if err != nil {
+				return names, err
+			}
+			names = append(names, record[2].(string))
+		}
+		return names, nil
+	}
+
+	got, err := names(Recursive(db.TableSeek(table, 1), children, 0))
+	if want := []string{"root", "a", "b", "a1", "a1x"}; err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("got (%v, %v), want %v", got, err, want)
+	}
+
+	got, err = names(Recursive(db.TableSeek(table, 1), children, 2))
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != "max depth" {
+		t.Errorf("expected a max depth LimitError, got %v", err)
+	}
+	if want := []string{"root", "a", "b", "a1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected the rows up to the depth limit %v, got %v", want, got)
+	}
+
+	// A cycle is cut by Guard when there is no depth limit.
+	cycle := func(record Record) RecordIterator { return numbers(1, nil) }
+	if got, err := collectInts(Guard(Recursive(numbers(1, nil), cycle, 0), WithMaxRows(10))); len(got) != 10 || !errors.Is(err, ErrLimitExceeded) {
+		t.Errorf("expected 10 rows and a LimitError, got (%d rows, %v)", len(got), err)
+	}
+}
@@ -0,0 +1,113 @@
+package golite
+
+// TableScanReuse is like TableScan, but yields the same Record for every row,
+// overwriting its values each time, in the manner of bufio.Scanner. The
+// record and its TEXT and BLOB values, which alias the buffer of the page
+// they were read from, are only valid until the next iteration: consumers
+// that process rows immediately avoid an allocation per row, and those that
+// keep rows must Copy them. The records of WITHOUT ROWID tables and of
+// tables with virtual generated columns are not reused.
+func (db *Database) TableScanReuse(table TableInfo) RecordIterator {
+	if table.WithoutRowID || table.Virtual || table.virtualColumnsOrder() != nil {
+		return db.TableScan(table)
+	}
+	return interruptible(db, func(yield func(Record, error) bool) {
+		s := &reuseScan{db: db, table: table}
+		if err := db.checkBTreePage(table.RootPage); err != nil {
+			yield(nil, err)
+			return
+		}
+		data, err := db.readPageData(table.RootPage)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		s.scanPage(table.RootPage, data, yield)
+	})
+}
+
+// reuseScan holds the state of a TableScanReuse.
+type reuseScan struct {
+	db          *Database
+	table       TableInfo
+	record      Record  // The record yielded for each row.
+	serialTypes []int64 // Scratch space for record headers.
+}
+
+// scanPage scans the B-Tree page pageNum, whose raw contents are data. It
+// returns true to continue scanning, or false to stop.
+func (s *reuseScan) scanPage(pageNum int, data []byte, yield func(Record, error) bool) bool {
+	page, err := parsePageHeader(data, pageNum)
+	if err != nil {
+		return yield(nil, err)
+	}
+	if page.Type != PageTypeLeafTable {
+		// Interior pages hold no records, so they are parsed as usual.
+		if page, err = s.db.parsePage(data, pageNum); err != nil {
+			return yield(nil, err)
+		}
+	}
+	switch page.Type {
+	case PageTypeLeafTable:
+		for i, cellOffset := range page.CellPointers {
+			record, err := s.decodeCell(data[int(cellOffset):])
+			if err != nil {
+				return yield(nil, atCell(err, pageNum, i, int(cellOffset)))
+			}
+			s.db.counters.recordsDecoded.Add(1)
+			if s.db.tracer != nil {
+				s.db.tracer.OnRecordDecoded(pageNum, s.table.ColumnValues(record))
+			}
+			if !yield(record, nil) {
+				return false
+			}
+		}
+		return true
+
+	case PageTypeInteriorTable:
+		children := make([]int, 0, len(page.InteriorCells)+1)
+		for _, cell := range page.InteriorCells {
+			children = append(children, int(cell.LeftChildPageNum))
+		}
+		children = append(children, int(page.RightMostPtr))
+		return s.db.visitChildPageData(children, func(i int, data []byte, err error) bool {
+			if err != nil {
+				return yield(nil, err)
+			}
+			return s.scanPage(children[i], data, yield)
+		})
+	default:
+		if emptyRoot(page, pageNum, s.table.RootPage) {
+			return true
+		}
+		return yield(nil, unexpectedPageType(pageNum, page, "scan"))
+	}
+}
+
+// decodeCell decodes the record of a leaf table cell into s.record, in the
+// form TableScan yields it.
+func (s *reuseScan) decodeCell(cellData []byte) (Record, error) {
+	_, rowID, payload, err := leafTableCellPayload(cellData, s.db.usableSize())
+	if err != nil {
+		return nil, err
+	}
+	serialTypes, headerSize, err := parseRecordHeaderInto(payload, s.serialTypes[:0])
+	s.serialTypes = serialTypes
+	if err != nil {
+		return nil, newCorruptError(0, -1, -1, CorruptRecord, err)
+	}
+	record := s.record[:0]
+	if s.table.RowIDColumnIndex == -1 {
+		record = append(record, rowID)
+	}
+	record, _, err = decodeRecordBodyInto(serialTypes, payload[headerSize:], record)
+	s.record = record
+	if err != nil {
+		return nil, newCorruptError(0, -1, -1, CorruptRecord, err)
+	}
+	s.db.decodeText(record)
+	if i := s.table.RowIDColumnIndex; i != -1 && i < len(record) {
+		record[i] = rowID
+	}
+	return record, nil
+}
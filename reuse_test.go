@@ -0,0 +1,80 @@
+package golite
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestTableScanReuse(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "reuse.sqlite")
+	runSQL(t, dbPath, `
+		CREATE TABLE aliased (id INTEGER PRIMARY KEY, name TEXT, data BLOB);
+		CREATE TABLE plain (name TEXT, value REAL);
+		CREATE TABLE w (k TEXT PRIMARY KEY, v INTEGER) WITHOUT ROWID;
+		CREATE TABLE empty (x TEXT);
+		WITH RECURSIVE seq(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM seq WHERE i < 3000)
+		INSERT INTO aliased SELECT i, 'name' || i, randomblob(i % 16) FROM seq;
+		INSERT INTO plain SELECT name, id * 0.5 FROM aliased;
+		INSERT INTO w SELECT name, id FROM aliased WHERE id <= 100;
+		ALTER TABLE plain ADD COLUMN extra TEXT DEFAULT 'x';
+		INSERT INTO plain VALUES ('last', NULL, 'y');
+	`)
+	tracer := &recordingTracer{}
+	db, err := Open(dbPath, WithTracer(tracer))
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+
+	for _, name := range []string{"aliased", "plain", "w", "empty"} {
+		table, err := db.Table(name)
+		if err != nil {
+			t.Fatalf("Table() failed: %v", err)
+		}
+		*tracer = recordingTracer{}
+		want, err := CollectRecords(db.TableScan(table))
+		if err != nil {
+			t.Fatalf("TableScan() failed: %v", err)
+		}
+		wantDecoded := tracer.records
+		*tracer = recordingTracer{}
+		var got []Record
+		var previous Record
+		for record, err := range db.TableScanReuse(table) {
+			if err != nil {
+				t.Fatalf("TableScanReuse(%s) failed: %v", name, err)
+			}
+			if name != "w" && previous != nil && &previous[0] != &record[0] {
+				t.Errorf("TableScanReuse(%s): expected the record to be reused", name)
+			}
+			previous = record
+			got = append(got, record.Copy())
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("TableScanReuse(%s) yielded %d records differing from the %d of TableScan", name, len(got), len(want))
+		}
+		if tracer.records != wantDecoded {
+			t.Errorf("TableScanReuse(%s) traced %d decoded records, want %d", name, tracer.records, wantDecoded)
+		}
+	}
+
+	// Stopping early must not fail.
+	table, _ := db.Table("aliased")
+	ids, err := collectInts(Take(db.TableScanReuse(table), 3))
+	if err != nil || !reflect.DeepEqual(ids, []int64{1, 2, 3}) {
+		t.Errorf("expected the first 3 rows, got (%v, %v)", ids, err)
+	}
+
+	plain := testing.AllocsPerRun(3, func() {
+		for range db.TableScan(table) {
+		}
+	})
+	reused := testing.AllocsPerRun(3, func() {
+		for range db.TableScanReuse(table) {
+		}
+	})
+	if reused >= plain/2 {
+		t.Errorf("expected far fewer allocations when reusing records, got %.0f against %.0f", reused, plain)
+	}
+}
@@ -0,0 +1,96 @@
+package golite
+
+import (
+	"iter"
+	"strings"
+)
+
+// Row is a record yielded by TableScan or TableSeek together with the table it
+// belongs to, so that its values can be looked up by column name rather than
+// by position.
+type Row struct {
+	Table  TableInfo
+	Record Record
+	// Blobs selects how MarshalJSON renders BLOB values.
+	Blobs BlobEncoding
+}
+
+// Row pairs a record yielded by TableScan or TableSeek with the table.
+func (t TableInfo) Row(record Record) Row {
+	return Row{Table: t, Record: record}
+}
+
+// Rows adapts an iterator over the records of a table, such as the one
+// returned by TableScan, into an iterator over Rows.
+func Rows(table TableInfo, records RecordIterator) iter.Seq2[Row, error] {
+	return func(yield func(Row, error) bool) {
+		for record, err := range records {
+			if !yield(Row{Table: table, Record: record}, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Get returns the value of the named column, matched ignoring case. The name
+// "rowid" returns the row's rowid unless the table has a column of that name.
+// Columns added to the table after the row was written read as SQLNull. The
+// second result is false if the table has no such column.
+func (r Row) Get(name string) (any, bool) {
+	column := r.Table.lookupColumn(name)
+	if column == -1 {
+		return nil, false
+	}
+	return r.Table.columnValue(r.Record, column), true
+}
+
+// Map returns the values of the row keyed by column name.
+func (r Row) Map() map[string]any {
+	m := make(map[string]any, len(r.Table.Columns))
+	for i, col := range r.Table.Columns {
+		m[col.Name] = r.Table.columnValue(r.Record, i)
+	}
+	return m
+}
+
+// Values returns the values of the row in the order of the table's columns.
+// Unlike TableInfo.ColumnValues, columns added to the table after the row
+// was written are included, with the value Get reads for them.
+func (r Row) Values() Record {
+	values := make(Record, len(r.Table.Columns))
+	for i := range r.Table.Columns {
+		values[i] = r.Table.columnValue(r.Record, i)
+	}
+	return values
+}
+
+// rowIDColumn is returned by lookupColumn for the name "rowid".
+const rowIDColumn = -2
+
+// lookupColumn returns the index in Columns of the column with the given
+// name, ignoring case. If there is no such column it returns rowIDColumn for
+// the name "rowid", and -1 otherwise.
+func (t TableInfo) lookupColumn(name string) int {
+	for i, col := range t.Columns {
+		if strings.EqualFold(col.Name, name) {
+			return i
+		}
+	}
+	if strings.EqualFold(name, "rowid") && !t.WithoutRowID {
+		return rowIDColumn
+	}
+	return -1
+}
+
+// columnValue returns the value of the column at index i in Columns, or the
+// rowid if i is rowIDColumn, from a record yielded by TableScan or TableSeek.
+func (t TableInfo) columnValue(record Record, i int) any {
+	if i == rowIDColumn {
+		return t.RowID(record)
+	}
+	values := t.ColumnValues(record)
+	if i >= len(values) {
+		return t.Columns[i].paddingValue() // Columns added after the row was written.
+	}
+	return values[i]
+}
@@ -0,0 +1,183 @@
+package golite
+
+import (
+	"encoding/json"
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRow_Get(t *testing.T) {
+	table := TableInfo{
+		Name:             "t",
+		Columns:          []ColumnInfo{{Name: "Name", Type: "TEXT"}, {Name: "added", Type: "INTEGER"}},
+		RowIDColumnIndex: -1,
+	}
+	row := table.Row(Record{int64(3), "bob"})
+
+	testCases := []struct {
+		column string
+		want   any
+		ok     bool
+	}{
+		{column: "name", want: "bob", ok: true},
+		{column: "NAME", want: "bob", ok: true},
+		{column: "added", want: SQLNull, ok: true},
+		{column: "rowid", want: int64(3), ok: true},
+		{column: "missing", want: nil, ok: false},
+	}
+	for _, tc := range testCases {
+		got, ok := row.Get(tc.column)
+		if ok != tc.ok || !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("Get(%q) = (%v, %v), want (%v, %v)", tc.column, got, ok, tc.want, tc.ok)
+		}
+	}
+
+	if want := map[string]any{"Name": "bob", "added": SQLNull}; !reflect.DeepEqual(row.Map(), want) {
+		t.Errorf("Map() = %v, want %v", row.Map(), want)
+	}
+}
+
+func TestRow_GeneratedColumns(t *testing.T) {
+	// Virtual generated columns are not stored, so the values of the columns
+	// that follow them are not at the position of the column in the records
+	// of the B-Tree.
+	dbPath := filepath.Join(t.TempDir(), "generated.sqlite")
+	runSQL(t, dbPath, `CREATE TABLE g (a, b AS (a * 2) VIRTUAL, c AS (a + 1) STORED);
+CREATE TABLE h (x INTEGER, v AS (x * 2) VIRTUAL, id INTEGER PRIMARY KEY, s AS (x + 1) STORED);
+INSERT INTO g (a) VALUES (1);
+INSERT INTO h (x, id) VALUES (3, 7);`)
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	schema, err := db.GetSchema()
+	if err != nil {
+		t.Fatalf("GetSchema() failed: %v", err)
+	}
+
+	testCases := []struct {
+		table   string
+		rowID   int64
+		want    Record
+		wantMap map[string]any
+	}{
+		{"g", 1, Record{int64(1), int64(1), SQLNull, int64(2)}, map[string]any{"a": int64(1), "b": SQLNull, "c": int64(2)}},
+		{"h", 7, Record{int64(3), SQLNull, int64(7), int64(4)}, map[string]any{"x": int64(3), "v": SQLNull, "id": int64(7), "s": int64(4)}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.table, func(t *testing.T) {
+			table := schema.Tables[tc.table]
+			scans := map[string]RecordIterator{
+				"TableScan":      db.TableScan(table),
+				"TableSeek":      db.TableSeek(table, tc.rowID),
+				"TableScanReuse": db.TableScanReuse(table),
+				"ParallelScan":   db.ParallelScan(table, 2, Ordered),
+			}
+			for name, records := range scans {
+				got, err := CollectRecords(records)
+				if err != nil {
+					t.Fatalf("%s failed: %v", name, err)
+				}
+				if len(got) != 1 || !reflect.DeepEqual(got[0], tc.want) {
+					t.Errorf("%s: expected the record %v, got %v", name, tc.want, got)
+					continue
+				}
+				if m := table.Row(got[0]).Map(); !reflect.DeepEqual(m, tc.wantMap) {
+					t.Errorf("%s: Row.Map() = %v, want %v", name, m, tc.wantMap)
+				}
+			}
+		})
+	}
+}
+
+func TestRows(t *testing.T) {
+	dbPath := createTestDB(t, "rows_test.sqlite")
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	table, err := db.Table("test")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+
+	for row, err := range Rows(table, db.TableSeek(table, 42)) {
+		if err != nil {
+			t.Fatalf("Rows() returned an unexpected error: %v", err)
+		}
+		if id, _ := row.Get("id"); id != int64(42) {
+			t.Errorf("expected id 42, got %v", id)
+		}
+		if name, _ := row.Get("name"); name != "name42" {
+			t.Errorf("expected name42, got %v", name)
+		}
+	}
+}
+
+func TestRow_MarshalJSON(t *testing.T) {
+	table := TableInfo{
+		Name: "t",
+		Columns: []ColumnInfo{
+			{Name: "id", Type: "INTEGER"}, {Name: "name", Type: "TEXT"}, {Name: "score", Type: "REAL"},
+			{Name: "data", Type: "BLOB"}, {Name: "note", Type: "TEXT"}, {Name: "added", Type: "TEXT"},
+		},
+		RowIDColumnIndex: 0,
+	}
+	rows := []Row{
+		table.Row(Record{int64(1), "a \"quoted\" é", 2.0, []byte{0, 0xff}, SQLNull}),
+		table.Row(Record{int64(2), "b", math.Inf(1), []byte{}, nil}),
+	}
+	got, err := json.Marshal(rows)
+	if err != nil {
+		t.Fatalf("json.Marshal() failed: %v", err)
+	}
+	want := `[{"id":1,"name":"a \"quoted\" é","score":2.0,"data":"AP8=","note":null,"added":null},` +
+		`{"id":2,"name":"b","score":null,"data":"","note":null,"added":null}]`
+	if string(got) != want {
+		t.Errorf("json.Marshal() = %s, want %s", got, want)
+	}
+
+	var decoded []struct {
+		ID   int64
+		Data []byte
+	}
+	if err := json.Unmarshal(got, &decoded); err != nil || decoded[0].ID != 1 || !reflect.DeepEqual(decoded[0].Data, []byte{0, 0xff}) {
+		t.Errorf("json.Unmarshal() = %+v, %v", decoded, err)
+	}
+
+	row := rows[0]
+	row.Blobs = BlobHex
+	got, err = json.Marshal(row)
+	if err != nil {
+		t.Fatalf("json.Marshal() failed: %v", err)
+	}
+	want = `{"id":1,"name":"a \"quoted\" é","score":2.0,"data":"00ff","note":null,"added":null}`
+	if string(got) != want {
+		t.Errorf("json.Marshal() with hex blobs = %s, want %s", got, want)
+	}
+}
+
+func TestFormatReal(t *testing.T) {
+	testCases := []struct {
+		value float64
+		want  string
+	}{
+		{2, "2.0"},
+		{-0.5, "-0.5"},
+		{0.1, "0.1"},
+		{1e20, "1.0e+20"},
+		{1.5e-7, "1.5e-07"},
+		{math.Inf(1), "Inf"},
+		{math.Inf(-1), "-Inf"},
+		{math.NaN(), "NaN"},
+	}
+	for _, tc := range testCases {
+		if got := FormatReal(tc.value); got != tc.want {
+			t.Errorf("FormatReal(%v) = %q, want %q", tc.value, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package golite
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"iter"
+	"math"
+	"strconv"
+)
+
+// BlobEncoding selects how Row.MarshalJSON renders BLOB values.
+type BlobEncoding int
+
+const (
+	// BlobBase64 renders blobs using standard base64 encoding, as
+	// encoding/json does for a []byte, so that they unmarshal into one.
+	BlobBase64 BlobEncoding = iota
+	// BlobHex renders blobs as lowercase hexadecimal.
+	BlobHex
+)
+
+// MarshalJSON implements json.Marshaler. A row is marshalled as an object
+// whose keys are the names of the columns of its table, in the order they are
+// declared, as Row.Get reads them. NULL is null, INTEGER and REAL values are
+// numbers, TEXT values are strings and BLOB values are strings holding their
+// encoding selected by r.Blobs, base64 by default. REAL values that JSON
+// cannot represent are null. For other layouts, see the export package.
+func (r Row) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, col := range r.Table.Columns {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, err := json.Marshal(col.Name)
+		if err != nil {
+			return nil, err
+		}
+		b.Write(key)
+		b.WriteByte(':')
+		if err := writeJSONValue(&b, r.Table.columnValue(r.Record, i), r.Blobs); err != nil {
+			return nil, err
+		}
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// writeJSONValue writes the JSON form of a record value to b, with blobs
+// encoded as specified.
+func writeJSONValue(b *bytes.Buffer, value any, blobs BlobEncoding) error {
+	switch v := value.(type) {
+	case NullType, nil:
+		b.WriteString("null")
+	case int64:
+		b.WriteString(strconv.FormatInt(v, 10))
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			b.WriteString("null")
+			return nil
+		}
+		b.WriteString(FormatReal(v))
+	case []byte:
+		// Neither encoding produces characters that need escaping.
+		b.WriteByte('"')
+		if blobs == BlobHex {
+			b.WriteString(hex.EncodeToString(v))
+		} else {
+			b.WriteString(base64.StdEncoding.EncodeToString(v))
+		}
+		b.WriteByte('"')
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		b.Write(data)
+	}
+	return nil
+}
+
+// CollectRows reads all the rows of input, such as the iterator returned by
+// Rows, into a slice. The slice can be marshalled to a JSON array of
+// objects, see Row.MarshalJSON. It stops at the first error, returning the
+// rows read until then along with it.
+func CollectRows(input iter.Seq2[Row, error]) ([]Row, error) {
+	var rows []Row
+	for row, err := range input {
+		if err != nil {
+			return rows, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -0,0 +1,750 @@
+package golite
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// The functions below implement SQLite's core scalar functions of the same
+// names, with its NULL handling and type conversions: a NULL argument
+// usually yields SQLNull, numbers are rendered as TEXT where text is
+// expected, and TEXT is read as the longest number it starts with where a
+// number is expected, so that "12abc" counts as 12 and "abc" as 0.
+
+// Length returns the number of characters in a TEXT value before any NUL
+// character, the number of bytes in a BLOB, or the length of the text of a
+// number, as an int64.
+func Length(value any) any {
+	switch v := value.(type) {
+	case NullType, nil:
+		return SQLNull
+	case []byte:
+		return int64(len(v))
+	}
+	s, _ := textValue(value)
+	if i := strings.IndexByte(s, 0); i >= 0 {
+		s = s[:i]
+	}
+	return int64(utf8.RuneCountInString(s))
+}
+
+// Substr returns the substring of value starting at the start-th character,
+// counting from 1, or from the end if start is negative. The optional length
+// is the number of characters to return; when it is negative, the characters
+// preceding start are returned instead. A BLOB is indexed by bytes and
+// yields a BLOB.
+func Substr(value, start any, length ...any) any {
+	if isNull(value) || isNull(start) || len(length) > 0 && isNull(length[0]) {
+		return SQLNull
+	}
+	var chars []rune
+	blob, isBlob := value.([]byte)
+	if isBlob && len(blob) == 0 {
+		return SQLNull // As in SQLite, which sees an empty BLOB as NULL.
+	}
+	n := int64(len(blob))
+	if !isBlob {
+		s, _ := textValue(value)
+		chars = []rune(s)
+		n = int64(len(chars))
+	}
+	p1, p2 := intValue(start), int64(math.MaxInt32)
+	negative := false
+	if len(length) > 0 {
+		p2 = intValue(length[0])
+		if p2 < 0 {
+			p2, negative = -p2, true
+		}
+	}
+	if p1 < 0 {
+		p1 += n
+		if p1 < 0 {
+			p2 = max(p2+p1, 0)
+			p1 = 0
+		}
+	} else if p1 > 0 {
+		p1--
+	} else if p2 > 0 {
+		p2--
+	}
+	if negative {
+		p1 -= p2
+		if p1 < 0 {
+			p2 += p1
+			p1 = 0
+		}
+	}
+	p1 = min(p1, n)
+	end := p1 + min(p2, n-p1)
+	if isBlob {
+		return bytes.Clone(blob[p1:end])
+	}
+	return string(chars[p1:end])
+}
+
+// Lower returns the text of value with ASCII letters converted to lower
+// case, as SQLite does without the ICU extension.
+func Lower(value any) any {
+	return mapASCII(value, 'A', 'Z', 'a'-'A')
+}
+
+// Upper returns the text of value with ASCII letters converted to upper
+// case, as SQLite does without the ICU extension.
+func Upper(value any) any {
+	return mapASCII(value, 'a', 'z', 'A'-'a')
+}
+
+// mapASCII returns the text of value with the bytes between lo and hi
+// shifted by delta. Other bytes, including invalid UTF-8, are kept as is.
+func mapASCII(value any, lo, hi byte, delta int) any {
+	s, ok := textValue(value)
+	if !ok {
+		return SQLNull
+	}
+	b := []byte(s)
+	for i, c := range b {
+		if c >= lo && c <= hi {
+			b[i] = byte(int(c) + delta)
+		}
+	}
+	return string(b)
+}
+
+// Trim returns the text of value with the characters of the optional chars
+// argument, spaces by default, removed from both ends.
+func Trim(value any, chars ...any) any {
+	return trimValue(value, chars, strings.Trim)
+}
+
+// LTrim returns the text of value with the characters of the optional chars
+// argument, spaces by default, removed from its start.
+func LTrim(value any, chars ...any) any {
+	return trimValue(value, chars, strings.TrimLeft)
+}
+
+// RTrim returns the text of value with the characters of the optional chars
+// argument, spaces by default, removed from its end.
+func RTrim(value any, chars ...any) any {
+	return trimValue(value, chars, strings.TrimRight)
+}
+
+func trimValue(value any, chars []any, trim func(string, string) string) any {
+	s, ok := textValue(value)
+	cutset := " "
+	if len(chars) > 0 {
+		var ok2 bool
+		cutset, ok2 = textValue(chars[0])
+		ok = ok && ok2
+	}
+	if !ok {
+		return SQLNull
+	}
+	return trim(s, cutset)
+}
+
+// ErrIntegerOverflow is returned by Abs for the smallest INTEGER, whose
+// absolute value cannot be represented.
+var ErrIntegerOverflow = errors.New("integer overflow")
+
+// Abs returns the absolute value of a number. TEXT and BLOB values are
+// converted to a REAL first.
+func Abs(value any) (any, error) {
+	switch v := value.(type) {
+	case NullType, nil:
+		return SQLNull, nil
+	case int64:
+		if v == math.MinInt64 {
+			return nil, ErrIntegerOverflow
+		}
+		if v < 0 {
+			return -v, nil
+		}
+		return v, nil
+	}
+	return math.Abs(realValue(value)), nil
+}
+
+// Round returns value rounded to the optional number of digits after the
+// decimal point, 0 by default, as a float64. Halfway cases are rounded away
+// from zero.
+func Round(value any, digits ...any) any {
+	if isNull(value) || len(digits) > 0 && isNull(digits[0]) {
+		return SQLNull
+	}
+	n := int64(0)
+	if len(digits) > 0 {
+		n = min(max(intValue(digits[0]), 0), 30)
+	}
+	r := realValue(value)
+	if r < -4503599627370496 || r > 4503599627370496 {
+		return r // Too large to have a fractional part.
+	}
+	return roundHalfAway(r, int(n))
+}
+
+// roundHalfAway rounds r to n decimal places based on its exact binary value,
+// rounding halfway cases away from zero.
+func roundHalfAway(r float64, n int) float64 {
+	if math.IsNaN(r) || math.IsInf(r, 0) {
+		return r
+	}
+	x := new(big.Float).SetPrec(512).SetFloat64(r)
+	scale := new(big.Float).SetPrec(512).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil))
+	x.Mul(x, scale)
+	half := big.NewFloat(0.5)
+	if r < 0 {
+		half.Neg(half)
+	}
+	i, _ := x.Add(x, half).Int(nil)
+	f, _ := strconv.ParseFloat(i.String()+"e-"+strconv.Itoa(n), 64)
+	if f == 0 && math.Signbit(r) {
+		return math.Copysign(0, -1)
+	}
+	return f
+}
+
+// Coalesce returns the first of values that is not NULL, or SQLNull if they
+// all are.
+func Coalesce(values ...any) any {
+	for _, v := range values {
+		if !isNull(v) {
+			return v
+		}
+	}
+	return SQLNull
+}
+
+// IfNull returns value, or alt if value is NULL.
+func IfNull(value, alt any) any {
+	return Coalesce(value, alt)
+}
+
+// NullIf returns value, or SQLNull if it is equal to other. Values of
+// different storage classes are never equal, except INTEGER and REAL ones.
+func NullIf(value, other any) any {
+	if compareValues(value, other) == 0 {
+		return SQLNull
+	}
+	return value
+}
+
+// Hex returns the upper-case hexadecimal rendering of the bytes of a BLOB or
+// of the UTF-8 text of any other value. NULL yields an empty string.
+func Hex(value any) string {
+	b, ok := value.([]byte)
+	if !ok {
+		s, _ := textValue(value)
+		b = []byte(s)
+	}
+	return strings.ToUpper(hex.EncodeToString(b))
+}
+
+// TypeOf returns the storage class of value: "null", "integer", "real",
+// "text" or "blob".
+func TypeOf(value any) string {
+	switch value.(type) {
+	case int64:
+		return "integer"
+	case float64:
+		return "real"
+	case string:
+		return "text"
+	case []byte:
+		return "blob"
+	}
+	return "null"
+}
+
+// Instr returns the 1-based character position of the first occurrence of
+// needle in haystack, or 0 if there is none. If both are BLOBs, the position
+// is counted in bytes.
+func Instr(haystack, needle any) any {
+	if isNull(haystack) || isNull(needle) {
+		return SQLNull
+	}
+	h, hok := haystack.([]byte)
+	n, nok := needle.([]byte)
+	if hok && nok {
+		return int64(bytes.Index(h, n) + 1)
+	}
+	hs, _ := textValue(haystack)
+	ns, _ := textValue(needle)
+	i := strings.Index(hs, ns)
+	if i < 0 {
+		return int64(0)
+	}
+	return int64(utf8.RuneCountInString(hs[:i]) + 1)
+}
+
+// Format is an alias of Printf, as in SQLite.
+func Format(format any, args ...any) any {
+	return Printf(format, args...)
+}
+
+// Printf returns its arguments formatted according to format, as SQLite's
+// printf function does. The conversions are those of C, %d, %i, %u, %f, %e,
+// %E, %g, %G, %x, %X, %o, %c, %s and %%, with the flags "-", "+", " ", "0",
+// "#" and "," (thousands separators for integers), plus SQLite's %q, %Q and %w
+// for quoting. Missing arguments count as NULL.
+func Printf(format any, args ...any) any {
+	f, ok := textValue(format)
+	if !ok {
+		return SQLNull
+	}
+	next := func() any {
+		if len(args) == 0 {
+			return SQLNull
+		}
+		arg := args[0]
+		args = args[1:]
+		return arg
+	}
+	var b strings.Builder
+	for i := 0; i < len(f); i++ {
+		if f[i] != '%' {
+			b.WriteByte(f[i])
+			continue
+		}
+		var spec printfSpec
+		i++
+	flags:
+		for ; i < len(f); i++ {
+			switch f[i] {
+			case '-':
+				spec.left = true
+			case '+':
+				spec.plus = true
+			case ' ':
+				spec.space = true
+			case '0':
+				spec.zero = true
+			case '#':
+				spec.alt = true
+			case ',':
+				spec.comma = true
+			case '!':
+				spec.chars = true
+			default:
+				break flags
+			}
+		}
+		if i < len(f) && f[i] == '*' {
+			spec.width = int(intValue(next()))
+			if spec.width < 0 {
+				spec.left, spec.width = true, -spec.width
+			}
+			i++
+		} else {
+			for ; i < len(f) && f[i] >= '0' && f[i] <= '9'; i++ {
+				spec.width = spec.width*10 + int(f[i]-'0')
+			}
+		}
+		spec.precision = -1
+		if i < len(f) && f[i] == '.' {
+			i++
+			spec.precision = 0
+			if i < len(f) && f[i] == '*' {
+				spec.precision = max(int(intValue(next())), 0)
+				i++
+			} else {
+				for ; i < len(f) && f[i] >= '0' && f[i] <= '9'; i++ {
+					spec.precision = spec.precision*10 + int(f[i]-'0')
+				}
+			}
+		}
+		for i < len(f) && f[i] == 'l' {
+			i++
+		}
+		if i == len(f) {
+			break
+		}
+		if !spec.format(&b, f[i], next) {
+			break // SQLite stops at an unknown conversion.
+		}
+	}
+	return b.String()
+}
+
+// printfSpec holds the flags, width and precision of a Printf conversion.
+type printfSpec struct {
+	left, plus, space, zero, alt, comma bool
+	chars                               bool // Width and precision count characters, not bytes.
+	width, precision                    int
+}
+
+// format writes the conversion verb, consuming arguments with next. It
+// returns false if verb is unknown.
+func (s printfSpec) format(b *strings.Builder, verb byte, next func() any) bool {
+	switch verb {
+	case '%':
+		b.WriteByte('%')
+	case 'd', 'i', 'u', 'x', 'X', 'o':
+		v := intValue(next())
+		var digits, prefix string
+		switch verb {
+		case 'd', 'i':
+			digits = strconv.FormatUint(uint64(v), 10)
+			if v < 0 {
+				digits, prefix = strconv.FormatUint(uint64(-v), 10), "-"
+			} else if s.plus {
+				prefix = "+"
+			} else if s.space {
+				prefix = " "
+			}
+		case 'u':
+			digits = strconv.FormatUint(uint64(v), 10)
+		case 'x', 'X':
+			digits = strconv.FormatUint(uint64(v), 16)
+			if verb == 'X' {
+				digits = strings.ToUpper(digits)
+			}
+			if s.alt && v != 0 {
+				prefix = "0" + string(verb)
+			}
+		case 'o':
+			digits = strconv.FormatUint(uint64(v), 8)
+			if s.alt && v != 0 {
+				prefix = "0"
+			}
+		}
+		if len(digits) < s.precision {
+			digits = strings.Repeat("0", s.precision-len(digits)) + digits
+		}
+		if s.comma && verb != 'x' && verb != 'X' && verb != 'o' {
+			digits = groupThousands(digits)
+		}
+		s.pad(b, prefix, digits)
+	case 'f', 'e', 'E', 'g', 'G':
+		r := realValue(next())
+		precision := s.precision
+		if precision < 0 {
+			precision = 6
+		}
+		flags := ""
+		if s.plus {
+			flags += "+"
+		} else if s.space {
+			flags += " "
+		}
+		if s.alt {
+			flags += "#"
+		}
+		var text string
+		if verb == 'f' {
+			text = fixedText(r, precision)
+			if !strings.HasPrefix(text, "-") && flags != "" && flags != "#" {
+				text = flags[:1] + text
+			}
+		} else {
+			text = fmt.Sprintf("%"+flags+".*"+string(verb), precision, r)
+		}
+		prefix := ""
+		if text[0] == '-' || text[0] == '+' || text[0] == ' ' {
+			prefix, text = text[:1], text[1:]
+		}
+		s.pad(b, prefix, text)
+	case 'c':
+		text := cString(next())
+		if text == "" {
+			text = "\x00" // The terminating NUL of an empty string.
+		} else {
+			_, size := utf8.DecodeRuneInString(text)
+			text = text[:size]
+		}
+		s.zero = false
+		s.pad(b, "", text)
+	case 's', 'z':
+		text := cString(next())
+		s.zero = false
+		s.pad(b, "", s.truncate(text))
+	case 'q', 'Q', 'w':
+		arg := next()
+		text := cString(arg)
+		ok := !isNull(arg)
+		quote := "'"
+		if verb == 'w' {
+			quote = `"`
+		}
+		text = strings.ReplaceAll(s.truncate(text), quote, quote+quote)
+		switch {
+		case !ok && verb == 'Q':
+			text = "NULL"
+		case !ok:
+			text = "(NULL)"
+		case verb == 'Q':
+			text = "'" + text + "'"
+		}
+		s.zero = false
+		s.pad(b, "", text)
+	default:
+		return false
+	}
+	return true
+}
+
+// pad writes prefix and text, padded to the width of the conversion with
+// spaces, or with zeros between the prefix and text if the "0" flag is set.
+func (s printfSpec) pad(b *strings.Builder, prefix, text string) {
+	n := s.width - len(prefix) - len(text)
+	if s.chars {
+		n = s.width - utf8.RuneCountInString(prefix) - utf8.RuneCountInString(text)
+	}
+	switch {
+	case n <= 0:
+		b.WriteString(prefix + text)
+	case s.left:
+		b.WriteString(prefix + text + strings.Repeat(" ", n))
+	case s.zero:
+		b.WriteString(prefix + strings.Repeat("0", n) + text)
+	default:
+		b.WriteString(strings.Repeat(" ", n) + prefix + text)
+	}
+}
+
+// groupThousands inserts commas between groups of three digits.
+func groupThousands(digits string) string {
+	var b strings.Builder
+	for i, c := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// truncate returns the text cut to the precision of the conversion, which
+// counts bytes unless the "!" flag is set.
+func (s printfSpec) truncate(text string) string {
+	n := s.precision
+	switch {
+	case n < 0:
+		return text
+	case !s.chars:
+		return text[:min(n, len(text))]
+	}
+	for i := range text {
+		if n == 0 {
+			return text[:i]
+		}
+		n--
+	}
+	return text
+}
+
+// fixedText formats r with precision digits after the decimal point, like
+// the %f conversion of SQLite's printf, which rounds halfway cases away from
+// zero and renders at most 16 significant digits.
+func fixedText(r float64, precision int) string {
+	if math.IsNaN(r) || math.IsInf(r, 0) {
+		return fmt.Sprintf("%f", r)
+	}
+	mantissa, e, _ := strings.Cut(strconv.FormatFloat(r, 'e', 15, 64), "e")
+	exp, _ := strconv.Atoi(e)
+	if exp < 0 || exp+1+precision <= 16 {
+		return strconv.FormatFloat(roundHalfAway(r, min(precision, 30)), 'f', precision, 64)
+	}
+	sign, digits := "", strings.Replace(mantissa, ".", "", 1)
+	if digits[0] == '-' {
+		sign, digits = "-", digits[1:]
+	}
+	digits += strings.Repeat("0", exp+1+precision-len(digits))
+	if precision == 0 {
+		return sign + digits
+	}
+	return sign + digits[:exp+1] + "." + digits[exp+1:]
+}
+
+// cString converts a value to TEXT the way SQLite's printf reads it, up to
+// the first NUL character.
+func cString(value any) string {
+	s, _ := textValue(value)
+	if i := strings.IndexByte(s, 0); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+func isNull(value any) bool {
+	_, ok := value.(NullType)
+	return ok || value == nil
+}
+
+// textValue converts a value to TEXT the way SQLite does when a function
+// expects text. The second result is false for NULL.
+func textValue(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case float64:
+		return realText(v), true
+	case NullType, nil:
+		return "", false
+	}
+	return fmt.Sprint(value), true
+}
+
+// realText renders a REAL with 15 significant digits and at least one digit
+// after the decimal point, as SQLite does when converting it to TEXT.
+func realText(f float64) string {
+	return formatReal(f, 15)
+}
+
+// FormatReal renders a REAL value as text with the fewest digits that read
+// back as the same value, and at least one digit after the decimal point so
+// that it still reads as a REAL, as the sqlite3 shell displays it. Infinities
+// are "Inf" and "-Inf" and NaN is "NaN".
+func FormatReal(f float64) string {
+	return formatReal(f, -1)
+}
+
+// formatReal implements realText and FormatReal, rendering f with the given
+// number of significant digits, or as few as needed if precision is -1.
+func formatReal(f float64, precision int) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	mantissa, exp, hasExp := strings.Cut(strconv.FormatFloat(f, 'g', precision, 64), "e")
+	if !strings.Contains(mantissa, ".") {
+		mantissa += ".0"
+	}
+	if hasExp {
+		return mantissa + "e" + exp
+	}
+	return mantissa
+}
+
+// numericValue converts a value to a number the way SQLite does when a
+// function expects one: TEXT and BLOB values are read as the longest number
+// they start with, or 0.
+func numericValue(value any) any {
+	switch v := value.(type) {
+	case int64, float64:
+		return v
+	case string:
+		return numericPrefix(v)
+	case []byte:
+		return numericPrefix(string(v))
+	}
+	return int64(0)
+}
+
+// numericPrefix parses the longest number at the start of s, after any
+// leading spaces.
+func numericPrefix(s string) any {
+	s = strings.TrimLeft(s, " \t\n\r\f\v")
+	end, isReal := 0, false
+	if end < len(s) && (s[end] == '+' || s[end] == '-') {
+		end++
+	}
+	digits := func() int {
+		start := end
+		for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+			end++
+		}
+		return end - start
+	}
+	n := digits()
+	if end < len(s) && s[end] == '.' {
+		end++
+		if n+digits() == 0 {
+			return int64(0)
+		}
+		isReal = true
+	} else if n == 0 {
+		return int64(0)
+	}
+	if end < len(s) && (s[end] == 'e' || s[end] == 'E') {
+		mark := end
+		end++
+		if end < len(s) && (s[end] == '+' || s[end] == '-') {
+			end++
+		}
+		if digits() == 0 {
+			end = mark
+		} else {
+			isReal = true
+		}
+	}
+	if !isReal {
+		if i, err := strconv.ParseInt(s[:end], 10, 64); err == nil {
+			return i
+		}
+	}
+	f, _ := strconv.ParseFloat(s[:end], 64)
+	return f
+}
+
+// intValue converts a value to an int64 the way SQLite does when a function
+// expects an integer: REAL values are truncated and TEXT is read as the
+// longest integer it starts with.
+func intValue(value any) int64 {
+	switch v := value.(type) {
+	case int64:
+		return v
+	case string:
+		return integerPrefix(v)
+	case []byte:
+		return integerPrefix(string(v))
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			return 0
+		case v <= math.MinInt64:
+			return math.MinInt64
+		case v >= math.MaxInt64:
+			return math.MaxInt64
+		}
+		return int64(v)
+	}
+	return 0
+}
+
+// integerPrefix parses the longest integer at the start of s, after any
+// leading spaces, clamping it to the range of an int64.
+func integerPrefix(s string) int64 {
+	s = strings.TrimLeft(s, " \t\n\r\f\v")
+	end := 0
+	if end < len(s) && (s[end] == '+' || s[end] == '-') {
+		end++
+	}
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	i, err := strconv.ParseInt(s[:end], 10, 64)
+	if errors.Is(err, strconv.ErrRange) {
+		if s[0] == '-' {
+			return math.MinInt64
+		}
+		return math.MaxInt64
+	}
+	return i
+}
+
+// realValue converts a value to a float64 the way SQLite does when a
+// function expects a REAL.
+func realValue(value any) float64 {
+	switch v := numericValue(value).(type) {
+	case int64:
+		return float64(v)
+	case float64:
+		return v
+	}
+	return 0
+}
@@ -0,0 +1,91 @@
+package golite
+
+import (
+	"errors"
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestScalarFunctions(t *testing.T) {
+	// SQLite computes the expected results for each value.
+	dbPath := filepath.Join(t.TempDir(), "scalar.sqlite")
+	runSQL(t, dbPath, `
+		CREATE TABLE vals (v BLOB);
+		INSERT INTO vals VALUES (NULL), (0), (1), (-42), (1234567), (9223372036854775807), (1.0), (-2.5),
+			(0.125), (2.675), (1e20), (1e-5), (0.1 + 0.2), ('hello'), ('  Mixed Case  '), ('héllo wörld'),
+			('12abc'), ('-3.75e2x'), ('banana'), (''), ('it''s "quoted"'), (x''), (x'00ff61'), (x'616263');
+		CREATE TABLE results (v BLOB, length BLOB, lower BLOB, upper BLOB, trim BLOB, ltrim BLOB,
+			rtrim BLOB, abs BLOB, round0 BLOB, round2 BLOB, hex BLOB, typeof BLOB, substr1 BLOB,
+			substr2 BLOB, substr3 BLOB, substr4 BLOB, instr BLOB, nullif BLOB, coalesce BLOB, printf BLOB);
+		INSERT INTO results SELECT v, length(v), lower(v), upper(v), trim(v), ltrim(v, ' M'),
+			rtrim(v, 'a'), abs(v), round(v), round(v, 2), hex(v), typeof(v), substr(v, 2, 3),
+			substr(v, -3), substr(v, 0, -1), substr(v, 4, -2), instr(v, 'a'), nullif(v, 1),
+			coalesce(v, 'x'),
+			printf('%d|%5.2f|%-8s|%q|%Q|%w|%x|%+.3e|%g|%,d|%08.3f|%.2s|%c|%%', v, v, v, v, v, v, v, v, v, v, v, v, v)
+			FROM vals;
+	`)
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	table, err := db.Table("results")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+
+	count := 0
+	for record, err := range db.TableScan(table) {
+		if err != nil {
+			t.Fatalf("TableScan() failed: %v", err)
+		}
+		count++
+		values := table.ColumnValues(record)
+		v := values[0]
+		abs, err := Abs(v)
+		if err != nil {
+			t.Errorf("Abs(%#v) failed: %v", v, err)
+		}
+		got := []any{
+			Length(v), Lower(v), Upper(v), Trim(v), LTrim(v, " M"), RTrim(v, "a"), abs, Round(v),
+			Round(v, int64(2)), Hex(v), TypeOf(v), Substr(v, int64(2), int64(3)), Substr(v, int64(-3)),
+			Substr(v, int64(0), int64(-1)), Substr(v, int64(4), int64(-2)), Instr(v, "a"),
+			NullIf(v, int64(1)), Coalesce(v, "x"),
+			Printf("%d|%5.2f|%-8s|%q|%Q|%w|%x|%+.3e|%g|%,d|%08.3f|%.2s|%c|%%", v, v, v, v, v, v, v, v, v, v, v, v, v),
+		}
+		for i, want := range values[1:] {
+			if !reflect.DeepEqual(got[i], want) {
+				t.Errorf("%s(%#v) = %#v, want %#v", table.Columns[i+1].Name, v, got[i], want)
+			}
+		}
+	}
+	if count == 0 {
+		t.Fatalf("expected results to compare against")
+	}
+}
+
+func TestScalarFunctions_EdgeCases(t *testing.T) {
+	if _, err := Abs(int64(math.MinInt64)); !errors.Is(err, ErrIntegerOverflow) {
+		t.Errorf("expected ErrIntegerOverflow, got %v", err)
+	}
+	if got := Substr("hello", SQLNull); got != SQLNull {
+		t.Errorf("expected NULL for a NULL start, got %#v", got)
+	}
+	if got := Round(1.5, SQLNull); got != SQLNull {
+		t.Errorf("expected NULL for NULL digits, got %#v", got)
+	}
+	if got := Coalesce(SQLNull, SQLNull); got != SQLNull {
+		t.Errorf("expected NULL, got %#v", got)
+	}
+	if got := IfNull(SQLNull, int64(3)); got != int64(3) {
+		t.Errorf("IfNull() = %#v, want 3", got)
+	}
+	if got := Printf("%*d|%-*d|%.*f|%s", int64(4), int64(7), int64(3), int64(1), int64(1), 2.25); got != "   7|1  |2.3|" {
+		t.Errorf("Printf() = %#v", got)
+	}
+	if got := Format(SQLNull); got != SQLNull {
+		t.Errorf("expected NULL for a NULL format, got %#v", got)
+	}
+}
@@ -0,0 +1,312 @@
+package golite
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeFormats lists the text layouts SQLite's date and time functions produce
+// and accept, tried in order when scanning a TEXT value into a time.Time.
+var timeFormats = []string{
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02T15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+	time.RFC3339Nano,
+}
+
+// Scan copies the columns of the record into the values pointed at by dest,
+// in the manner of database/sql's Rows.Scan. The number of destinations must
+// match the number of columns in the record.
+//
+// Supported destinations are *int64, *int, *float64, *string, *[]byte, *bool,
+// *time.Time, *any and any type implementing sql.Scanner (such as
+// sql.NullString or sql.NullInt64). Values are converted between storage
+// classes where the conversion is lossless; a NULL can only be scanned into
+// *any, *[]byte or an sql.Scanner.
+func (r Record) Scan(dest ...any) error {
+	if len(dest) != len(r) {
+		return fmt.Errorf("expected %d destination arguments in Scan, got %d", len(r), len(dest))
+	}
+	for i, d := range dest {
+		if err := scanValue(r[i], d); err != nil {
+			return fmt.Errorf("column %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// scanValue stores a single record value into the destination pointer.
+func scanValue(src any, dest any) error {
+	if scanner, ok := dest.(sql.Scanner); ok {
+		if isNull(src) {
+			return scanner.Scan(nil)
+		}
+		return scanner.Scan(src)
+	}
+
+	switch d := dest.(type) {
+	case *any:
+		if isNull(src) {
+			*d = nil
+		} else {
+			*d = src
+		}
+		return nil
+	case *[]byte:
+		switch v := src.(type) {
+		case NullType, nil:
+			*d = nil
+		case []byte:
+			*d = append([]byte(nil), v...)
+		case string:
+			*d = []byte(v)
+		default:
+			*d = []byte(formatScalar(v))
+		}
+		return nil
+	}
+
+	if isNull(src) {
+		return fmt.Errorf("cannot scan NULL into %T", dest)
+	}
+
+	switch d := dest.(type) {
+	case *int64:
+		v, err := toInt64(src)
+		if err != nil {
+			return err
+		}
+		*d = v
+	case *int:
+		v, err := toInt64(src)
+		if err != nil {
+			return err
+		}
+		*d = int(v)
+	case *float64:
+		v, err := toFloat64Value(src)
+		if err != nil {
+			return err
+		}
+		*d = v
+	case *string:
+		switch v := src.(type) {
+		case string:
+			*d = v
+		case []byte:
+			*d = string(v)
+		default:
+			*d = formatScalar(v)
+		}
+	case *bool:
+		v, err := toBool(src)
+		if err != nil {
+			return err
+		}
+		*d = v
+	case *time.Time:
+		v, err := toTime(src)
+		if err != nil {
+			return err
+		}
+		*d = v
+	default:
+		return fmt.Errorf("unsupported destination type %T", dest)
+	}
+	return nil
+}
+
+// value returns the value at index i, failing if it is out of range or NULL.
+func (r Record) value(i int) (any, error) {
+	if i < 0 || i >= len(r) {
+		return nil, fmt.Errorf("column %d out of range for a record of %d columns", i, len(r))
+	}
+	if isNull(r[i]) {
+		return nil, fmt.Errorf("column %d is NULL", i)
+	}
+	return r[i], nil
+}
+
+// GetInt64 returns the value at index i as an int64. REAL values with no
+// fractional part and TEXT values that look like such numbers are converted,
+// as SQLite does when storing them in a column with INTEGER affinity.
+func (r Record) GetInt64(i int) (int64, error) {
+	v, err := r.value(i)
+	if err != nil {
+		return 0, err
+	}
+	n, err := toInt64(v)
+	if err != nil {
+		return 0, fmt.Errorf("column %d: %w", i, err)
+	}
+	return n, nil
+}
+
+// GetFloat64 returns the value at index i as a float64. INTEGER values, which
+// SQLite stores to save space even in columns with REAL affinity, and numeric
+// TEXT values are converted.
+func (r Record) GetFloat64(i int) (float64, error) {
+	v, err := r.value(i)
+	if err != nil {
+		return 0, err
+	}
+	f, err := toFloat64Value(v)
+	if err != nil {
+		return 0, fmt.Errorf("column %d: %w", i, err)
+	}
+	return f, nil
+}
+
+// GetString returns the value at index i as a string. Numbers are rendered
+// the way SQLite casts them to TEXT.
+func (r Record) GetString(i int) (string, error) {
+	v, err := r.value(i)
+	if err != nil {
+		return "", err
+	}
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	}
+	return formatScalar(v), nil
+}
+
+// GetBool returns the value at index i as a bool. Numbers are true when
+// non-zero, and TEXT values are parsed with strconv.ParseBool.
+func (r Record) GetBool(i int) (bool, error) {
+	v, err := r.value(i)
+	if err != nil {
+		return false, err
+	}
+	b, err := toBool(v)
+	if err != nil {
+		return false, fmt.Errorf("column %d: %w", i, err)
+	}
+	return b, nil
+}
+
+// GetTime returns the value at index i as a time.Time. TEXT values are
+// parsed with the given layout, or with the formats understood by SQLite's
+// date functions if layout is empty. INTEGER values are interpreted as Unix
+// seconds and REAL values as Julian day numbers.
+func (r Record) GetTime(i int, layout string) (time.Time, error) {
+	v, err := r.value(i)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if s, ok := v.(string); ok && layout != "" {
+		t, err := time.Parse(layout, s)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("column %d: %w", i, err)
+		}
+		return t, nil
+	}
+	t, err := toTime(v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("column %d: %w", i, err)
+	}
+	return t, nil
+}
+
+// toInt64 converts a non-NULL value to an int64, failing if information would be lost.
+func toInt64(src any) (int64, error) {
+	switch v := src.(type) {
+	case int64:
+		return v, nil
+	case float64:
+		if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+			return 0, fmt.Errorf("cannot convert %v to int64 without loss", v)
+		}
+		return int64(v), nil
+	case string:
+		i, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil {
+			// TEXT such as "12.0" is stored as 12 in an INTEGER column.
+			if f, ferr := strconv.ParseFloat(strings.TrimSpace(v), 64); ferr == nil {
+				if i, ierr := toInt64(f); ierr == nil {
+					return i, nil
+				}
+			}
+			return 0, fmt.Errorf("cannot convert %q to int64: %w", v, err)
+		}
+		return i, nil
+	}
+	return 0, fmt.Errorf("cannot convert %T to int64", src)
+}
+
+// toFloat64Value converts a non-NULL value to a float64.
+func toFloat64Value(src any) (float64, error) {
+	switch v := src.(type) {
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("cannot convert %q to float64: %w", v, err)
+		}
+		return f, nil
+	}
+	return 0, fmt.Errorf("cannot convert %T to float64", src)
+}
+
+// toBool converts a non-NULL value to a bool. Numbers are true when non-zero.
+func toBool(src any) (bool, error) {
+	switch v := src.(type) {
+	case int64:
+		return v != 0, nil
+	case float64:
+		return v != 0, nil
+	case string:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return false, fmt.Errorf("cannot convert %q to bool: %w", v, err)
+		}
+		return b, nil
+	}
+	return false, fmt.Errorf("cannot convert %T to bool", src)
+}
+
+// toTime converts a non-NULL value to a time.Time. TEXT values are parsed
+// using the formats SQLite's date functions understand, INTEGER values are
+// interpreted as Unix seconds and REAL values as Julian day numbers.
+func toTime(src any) (time.Time, error) {
+	switch v := src.(type) {
+	case string:
+		for _, layout := range timeFormats {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("cannot parse %q as a time", v)
+	case int64:
+		return time.Unix(v, 0).UTC(), nil
+	case float64:
+		// Julian day 2440587.5 is the Unix epoch.
+		ms := math.Round((v - 2440587.5) * 86400000)
+		return time.UnixMilli(int64(ms)).UTC(), nil
+	}
+	return time.Time{}, fmt.Errorf("cannot convert %T to time.Time", src)
+}
+
+// formatScalar renders a numeric value as text, the way SQLite casts it.
+func formatScalar(v any) string {
+	switch v := v.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return FormatReal(v)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,140 @@
+package golite
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRecord_Scan(t *testing.T) {
+	t.Run("native types", func(t *testing.T) {
+		record := Record{int64(42), 2.5, "hello", []byte{1, 2}, SQLNull}
+		var (
+			i    int64
+			f    float64
+			s    string
+			b    []byte
+			null any
+		)
+		if err := record.Scan(&i, &f, &s, &b, &null); err != nil {
+			t.Fatalf("Scan() failed: %v", err)
+		}
+		if i != 42 || f != 2.5 || s != "hello" || !reflect.DeepEqual(b, []byte{1, 2}) || null != nil {
+			t.Errorf("Scan() got (%v, %v, %q, %v, %v)", i, f, s, b, null)
+		}
+	})
+
+	t.Run("conversions", func(t *testing.T) {
+		record := Record{"17", int64(3), 4.0, int64(1), "2024-03-01 12:30:00", int64(0)}
+		var (
+			i  int64
+			f  float64
+			s  string
+			n  int
+			tm time.Time
+			ok bool
+		)
+		if err := record.Scan(&i, &f, &s, &n, &tm, &ok); err != nil {
+			t.Fatalf("Scan() failed: %v", err)
+		}
+		if i != 17 {
+			t.Errorf("expected 17, got %d", i)
+		}
+		if f != 3 {
+			t.Errorf("expected 3.0, got %v", f)
+		}
+		if s != "4.0" {
+			t.Errorf("expected \"4.0\", got %q", s)
+		}
+		if n != 1 {
+			t.Errorf("expected 1, got %d", n)
+		}
+		if want := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC); !tm.Equal(want) {
+			t.Errorf("expected %v, got %v", want, tm)
+		}
+		if ok {
+			t.Errorf("expected false, got true")
+		}
+	})
+
+	t.Run("sql.Null types", func(t *testing.T) {
+		record := Record{SQLNull, "x", int64(5)}
+		var ns sql.NullString
+		var ns2 sql.NullString
+		var ni sql.NullInt64
+		if err := record.Scan(&ns, &ns2, &ni); err != nil {
+			t.Fatalf("Scan() failed: %v", err)
+		}
+		if ns.Valid {
+			t.Errorf("expected invalid NullString, got %+v", ns)
+		}
+		if !ns2.Valid || ns2.String != "x" {
+			t.Errorf("expected valid NullString \"x\", got %+v", ns2)
+		}
+		if !ni.Valid || ni.Int64 != 5 {
+			t.Errorf("expected valid NullInt64 5, got %+v", ni)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		var s string
+		var i int64
+		if err := (Record{SQLNull}).Scan(&s); err == nil {
+			t.Error("expected an error scanning NULL into *string")
+		}
+		if err := (Record{2.5}).Scan(&i); err == nil {
+			t.Error("expected an error scanning 2.5 into *int64")
+		}
+		if err := (Record{int64(1), int64(2)}).Scan(&i); err == nil {
+			t.Error("expected an error for mismatched destination count")
+		}
+		if err := (Record{int64(1)}).Scan(i); err == nil {
+			t.Error("expected an error for a non-pointer destination")
+		}
+	})
+}
+
+func TestRecord_TypedAccessors(t *testing.T) {
+	record := Record{int64(3), 2.0, " 12.0 ", "2024-03-01", SQLNull, []byte("hi"), "01/02/2024", 2.5}
+
+	if v, err := record.GetInt64(1); err != nil || v != 2 {
+		t.Errorf("GetInt64(1) = (%v, %v), want 2", v, err)
+	}
+	if v, err := record.GetInt64(2); err != nil || v != 12 {
+		t.Errorf("GetInt64(2) = (%v, %v), want 12", v, err)
+	}
+	if v, err := record.GetFloat64(0); err != nil || v != 3 {
+		t.Errorf("GetFloat64(0) = (%v, %v), want 3", v, err)
+	}
+	if v, err := record.GetString(1); err != nil || v != "2.0" {
+		t.Errorf("GetString(1) = (%q, %v), want \"2.0\"", v, err)
+	}
+	if v, err := record.GetString(5); err != nil || v != "hi" {
+		t.Errorf("GetString(5) = (%q, %v), want \"hi\"", v, err)
+	}
+	if v, err := record.GetBool(0); err != nil || !v {
+		t.Errorf("GetBool(0) = (%v, %v), want true", v, err)
+	}
+	if v, err := record.GetTime(3, ""); err != nil || !v.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("GetTime(3) = (%v, %v), want 2024-03-01", v, err)
+	}
+	if v, err := record.GetTime(6, "01/02/2006"); err != nil || !v.Equal(time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("GetTime(6) = (%v, %v), want 2024-01-02", v, err)
+	}
+
+	errorCases := []struct {
+		name string
+		get  func() error
+		err  string
+	}{
+		{name: "NULL", get: func() error { _, err := record.GetString(4); return err }, err: "column 4 is NULL"},
+		{name: "out of range", get: func() error { _, err := record.GetInt64(8); return err }, err: "column 8 out of range for a record of 8 columns"},
+		{name: "lossy", get: func() error { _, err := record.GetInt64(7); return err }, err: "column 7: cannot convert 2.5 to int64 without loss"},
+	}
+	for _, tc := range errorCases {
+		if err := tc.get(); err == nil || err.Error() != tc.err {
+			t.Errorf("%s: expected error %q, got %v", tc.name, tc.err, err)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package golite
+
+import "fmt"
+
+// TableScanColumns is like TableScan, but yields records holding only the
+// values of the named columns, in the order they are named. Names are
+// matched ignoring case, and "rowid" names the rowid unless the table has a
+// column of that name. Only the values of the named columns are decoded, the
+// others being skipped, which makes scans of a few columns of wide tables
+// cheaper. The values of virtual generated columns are NULL.
+func (db *Database) TableScanColumns(table TableInfo, columns []string) RecordIterator {
+	indexes := make([]int, len(columns))
+	for i, name := range columns {
+		if indexes[i] = table.lookupColumn(name); indexes[i] == -1 {
+			return func(yield func(Record, error) bool) {
+				yield(nil, fmt.Errorf("no such column: %s", name))
+			}
+		}
+	}
+	if table.WithoutRowID || table.Virtual {
+		return projectColumns(db.TableScan(table), table, columns)
+	}
+	s := &columnScan{db: db, table: table, positions: make([]int, len(indexes))}
+	order := table.StorageOrder()
+	for i, column := range indexes {
+		s.positions[i] = -1
+		switch {
+		case column == rowIDColumn || column == table.RowIDColumnIndex:
+			s.positions[i] = rowIDColumn
+		default:
+			for j, stored := range order {
+				if stored == column {
+					s.positions[i] = j
+				}
+			}
+			s.padding = append(s.padding, table.Columns[column].paddingValue())
+		}
+	}
+	return interruptible(db, func(yield func(Record, error) bool) {
+		if err := db.checkBTreePage(table.RootPage); err != nil {
+			yield(nil, err)
+			return
+		}
+		data, err := db.readPageData(table.RootPage)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		s.scanPage(table.RootPage, data, yield)
+	})
+}
+
+// columnScan holds the state of a TableScanColumns.
+type columnScan struct {
+	db    *Database
+	table TableInfo
+	// positions holds the position in the stored records of each column to
+	// yield, rowIDColumn for the rowid, or -1 for a column that is not
+	// stored. padding holds the value of the columns that are not the rowid,
+	// in order, for records written before they were added.
+	positions   []int
+	padding     []any
+	serialTypes []int64 // Scratch space for record headers.
+	offsets     []int   // Scratch space for the offsets of the values.
+}
+
+// scanPage scans the B-Tree page pageNum, whose raw contents are data. It
+// returns true to continue scanning, or false to stop.
+func (s *columnScan) scanPage(pageNum int, data []byte, yield func(Record, error) bool) bool {
+	page, err := parsePageHeader(data, pageNum)
+	if err != nil {
+		return yield(nil, err)
+	}
+	switch page.Type {
+	case PageTypeLeafTable:
+		for i, cellOffset := range page.CellPointers {
+			record, err := s.decodeCell(data[int(cellOffset):])
+			if err != nil {
+				return yield(nil, atCell(err, pageNum, i, int(cellOffset)))
+			}
+			s.db.counters.recordsDecoded.Add(1)
+			if !yield(record, nil) {
+				return false
+			}
+		}
+		return true
+
+	case PageTypeInteriorTable:
+		if page, err = s.db.parsePage(data, pageNum); err != nil {
+			return yield(nil, err)
+		}
+		children := make([]int, 0, len(page.InteriorCells)+1)
+		for _, cell := range page.InteriorCells {
+			children = append(children, int(cell.LeftChildPageNum))
+		}
+		children = append(children, int(page.RightMostPtr))
+		return s.db.visitChildPageData(children, func(i int, data []byte, err error) bool {
+			if err != nil {
+				return yield(nil, err)
+			}
+			return s.scanPage(children[i], data, yield)
+		})
+	default:
+		if emptyRoot(page, pageNum, s.table.RootPage) {
+			return true
+		}
+		return yield(nil, unexpectedPageType(pageNum, page, "scan"))
+	}
+}
+
+// decodeCell decodes the values of the columns to yield from a leaf table
+// cell, skipping the others.
+func (s *columnScan) decodeCell(cellData []byte) (Record, error) {
+	_, rowID, payload, err := leafTableCellPayload(cellData, s.db.usableSize())
+	if err != nil {
+		return nil, err
+	}
+	serialTypes, headerSize, err := parseRecordHeaderInto(payload, s.serialTypes[:0])
+	s.serialTypes = serialTypes
+	if err != nil {
+		return nil, newCorruptError(0, -1, -1, CorruptRecord, err)
+	}
+	s.offsets = s.offsets[:0]
+	offset := headerSize
+	for _, st := range serialTypes {
+		s.offsets = append(s.offsets, offset)
+		offset += serialTypeSize(st)
+	}
+	if offset > len(payload) {
+		return nil, newCorruptError(0, -1, -1, CorruptRecord, fmt.Errorf("invalid record: body of %d bytes extends beyond payload of %d bytes", offset-headerSize, len(payload)))
+	}
+
+	record := make(Record, len(s.positions))
+	padding := s.padding
+	for i, position := range s.positions {
+		switch {
+		case position == rowIDColumn:
+			record[i] = rowID
+			continue
+		case position == -1:
+			record[i] = SQLNull
+		case position >= len(serialTypes):
+			record[i] = padding[0]
+		default:
+			value, _, err := serialTypeToValue(serialTypes[position], payload[s.offsets[position]:])
+			if err != nil {
+				return nil, newCorruptError(0, -1, -1, CorruptRecord, fmt.Errorf("invalid record: column %d: %w", position, err))
+			}
+			record[i] = value
+		}
+		padding = padding[1:]
+	}
+	s.db.ownValues(record)
+	s.db.decodeText(record)
+	return record, nil
+}
@@ -0,0 +1,73 @@
+package golite
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDatabase_TableScanColumns(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "scancolumns.sqlite")
+	runSQL(t, dbPath, `
+		CREATE TABLE wide (id INTEGER PRIMARY KEY, a TEXT, b BLOB, c REAL, d INTEGER);
+		WITH RECURSIVE seq(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM seq WHERE i < 2000)
+		INSERT INTO wide SELECT i, 'a' || i, randomblob(100), i + 0.5, CASE WHEN i % 3 THEN i END FROM seq;
+		ALTER TABLE wide ADD COLUMN e TEXT DEFAULT 'dflt';
+		INSERT INTO wide (a, e) VALUES ('new', 'set');
+		CREATE TABLE plain (x TEXT, y INTEGER, z INTEGER GENERATED ALWAYS AS (y * 2) VIRTUAL);
+		INSERT INTO plain (x, y) VALUES ('p', 1), ('q', 2);
+		CREATE TABLE w (k TEXT PRIMARY KEY, v INTEGER) WITHOUT ROWID;
+		INSERT INTO w VALUES ('b', 2), ('a', 1);
+	`)
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+
+	for _, test := range []struct {
+		table   string
+		columns []string
+	}{
+		{"wide", []string{"e", "D", "id", "a"}},
+		{"wide", []string{"c", "rowid", "c"}},
+		{"wide", nil},
+		{"plain", []string{"rowid", "y", "x"}},
+		{"w", []string{"v", "k"}},
+	} {
+		table, err := db.Table(test.table)
+		if err != nil {
+			t.Fatalf("Table() failed: %v", err)
+		}
+		want, err := CollectRecords(projectColumns(db.TableScan(table), table, test.columns))
+		if err != nil {
+			t.Fatalf("TableScan() failed: %v", err)
+		}
+		got, err := CollectRecords(db.TableScanColumns(table, test.columns))
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("TableScanColumns(%s, %v) = %v, %v, want %v", test.table, test.columns, got[:min(len(got), 3)], err, want[:min(len(want), 3)])
+		}
+	}
+
+	table, err := db.Table("wide")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+	got, err := CollectRecords(Take(db.TableScanColumns(table, []string{"e", "a"}), 1))
+	if err != nil || !reflect.DeepEqual(got, []Record{{"dflt", "a1"}}) {
+		t.Errorf("TableScanColumns() = %v, %v", got, err)
+	}
+	if _, err := CollectRecords(db.TableScanColumns(table, []string{"a", "nope"})); err == nil || !strings.Contains(err.Error(), "no such column: nope") {
+		t.Errorf("expected an error for an unknown column, got %v", err)
+	}
+
+	table, err = db.Table("plain")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+	got, err = CollectRecords(db.TableScanColumns(table, []string{"z", "x"}))
+	if err != nil || !reflect.DeepEqual(got, []Record{{SQLNull, "p"}, {SQLNull, "q"}}) {
+		t.Errorf("TableScanColumns() = %v, %v", got, err)
+	}
+}
@@ -1,11 +1,42 @@
 package golite
 
+import (
+	"slices"
+	"strings"
+)
+
 // ColumnInfo holds schema information about a single column in a table.
 type ColumnInfo struct {
 	Name string
 	Type string
+	// Default is the text of the DEFAULT clause of the column, such as "0",
+	// "'none'", "CURRENT_TIMESTAMP" or "(1 + 2)", or "" if it has none.
+	Default string
+	// NotNull is true if the column is declared NOT NULL.
+	NotNull bool
+	// PrimaryKey is true if the column is part of the primary key, whether
+	// declared on the column or in a table-level PRIMARY KEY constraint.
+	PrimaryKey bool
+	// Unique is true if the column alone is declared UNIQUE.
+	Unique bool
+	// Collation is the name of the collating sequence given with COLLATE, or
+	// "" for the default, BINARY.
+	Collation string
+	// Hidden tells whether the column is generated or hidden.
+	Hidden HiddenKind
 }
 
+// HiddenKind tells whether a column is an ordinary column. Its values match
+// the "hidden" column of PRAGMA table_xinfo.
+type HiddenKind int
+
+const (
+	ColumnNormal           HiddenKind = iota // An ordinary column.
+	ColumnHidden                             // A hidden column of a virtual table.
+	ColumnGeneratedVirtual                   // A generated column computed when read.
+	ColumnGeneratedStored                    // A generated column stored in the record.
+)
+
 // TableInfo holds schema information about a single table.
 type TableInfo struct {
 	Name             string
@@ -13,6 +44,101 @@ type TableInfo struct {
 	SQL              string
 	Columns          []ColumnInfo
 	RowIDColumnIndex int // The index of the column that is an alias for the rowid. -1 if none.
+	ForeignKeys      []ForeignKey
+	Checks           []CheckConstraint
+	// WithoutRowID is true for a table created WITHOUT ROWID, which is
+	// stored in a B-Tree keyed by its primary key rather than by rowid.
+	WithoutRowID bool
+	// Virtual is true for a virtual table, created with CREATE VIRTUAL TABLE,
+	// whose rows are provided by a module rather than stored in a B-Tree of
+	// its own. Its RootPage is 0 and its rows cannot be read by golite.
+	Virtual bool
+	// PrimaryKey lists the columns of the primary key in key order, or is nil
+	// if the table has no PRIMARY KEY constraint.
+	PrimaryKey []IndexColumn
+	// Indexes lists the indexes of the table ordered by name, including the
+	// automatic indexes of its UNIQUE and PRIMARY KEY constraints. It is set
+	// by GetSchema.
+	Indexes []*IndexInfo
+}
+
+// CheckConstraint holds a CHECK constraint of a table.
+type CheckConstraint struct {
+	// Name is the name given with CONSTRAINT, or "" if the constraint is
+	// unnamed.
+	Name string
+	// Column is the column the constraint is declared on, or "" for a
+	// table-level constraint.
+	Column string
+	// Expr is the text of the checked expression, without the parentheses
+	// around it.
+	Expr string
+}
+
+// ForeignKey holds schema information about a foreign key constraint, from
+// either a REFERENCES clause on a column or a table-level FOREIGN KEY.
+// golite reports foreign keys but does not enforce them.
+type ForeignKey struct {
+	// Columns are the columns of the child table, the one declaring the key.
+	Columns []string
+	// ParentTable is the referenced table.
+	ParentTable string
+	// ParentColumns are the referenced columns, or nil if the constraint
+	// refers to the primary key of the parent table implicitly.
+	ParentColumns []string
+	// OnDelete and OnUpdate are the actions taken when the parent row is
+	// deleted or updated: "NO ACTION" (the default), "RESTRICT", "SET NULL",
+	// "SET DEFAULT" or "CASCADE".
+	OnDelete, OnUpdate string
+}
+
+// SchemaTable returns the TableInfo of the sqlite_schema table itself. It can
+// be passed to TableScan to read the raw schema records, for instance when
+// GetSchema cannot make sense of some of them.
+func SchemaTable() TableInfo {
+	// The schema table is always rooted at page 1. It has no INTEGER PRIMARY
+	// KEY, so its RowIDColumnIndex is -1.
+	const sql = "CREATE TABLE sqlite_schema(type text, name text, tbl_name text, rootpage integer, sql text)"
+	columns, _, _ := ParseTableSQL(sql)
+	return TableInfo{
+		Name:             "sqlite_schema",
+		RootPage:         1,
+		SQL:              sql,
+		Columns:          columns,
+		RowIDColumnIndex: -1,
+	}
+}
+
+// RowID returns the rowid of a record yielded by TableScan or TableSeek.
+// It returns 0 for a WITHOUT ROWID table.
+func (t TableInfo) RowID(record Record) int64 {
+	if t.WithoutRowID {
+		return 0
+	}
+	index := t.RowIDColumnIndex
+	if index == -1 {
+		index = 0
+	}
+	rowID, _ := record[index].(int64)
+	return rowID
+}
+
+// ColumnValues returns the part of a record yielded by TableScan or TableSeek
+// that corresponds to the table's declared columns, dropping the implicit
+// rowid that is prepended when the table has no rowid alias column.
+func (t TableInfo) ColumnValues(record Record) Record {
+	if t.RowIDColumnIndex == -1 && !t.WithoutRowID && len(record) > 0 {
+		return record[1:]
+	}
+	return record
+}
+
+// IndexColumn holds schema information about a single column of an index key.
+type IndexColumn struct {
+	Name string // The name of the indexed column, or "" if it is an expression.
+	Desc bool
+
+	affinity Affinity // The affinity of the table column, set by GetSchema.
 }
 
 // IndexInfo holds schema information about a single index.
@@ -21,10 +147,152 @@ type IndexInfo struct {
 	TableName string
 	RootPage  int
 	SQL       string
+	Columns   []IndexColumn
+	// Unique is true if no two rows can have the same key in the index, so
+	// that an IndexSeek for a full key yields at most one record. This is the
+	// case for indexes created with CREATE UNIQUE INDEX and for the automatic
+	// indexes of UNIQUE and PRIMARY KEY constraints.
+	Unique bool
+}
+
+// coerceKey returns a copy of a search key with the affinity of each indexed
+// column applied to its value, so that it compares with the index records the
+// way SQLite would compare them.
+func (index IndexInfo) coerceKey(key Record) Record {
+	coerced := make(Record, len(key))
+	for i, value := range key {
+		if i < len(index.Columns) {
+			value = index.Columns[i].affinity.apply(value)
+		}
+		coerced[i] = value
+	}
+	return coerced
+}
+
+// ViewInfo holds schema information about a single view.
+type ViewInfo struct {
+	Name string
+	SQL  string
+	// Columns are the column names listed after the view name, or nil if
+	// the columns are named by the query.
+	Columns []string
+	// Query is the text of the SELECT statement defining the view.
+	Query string
+}
+
+// TriggerInfo holds schema information about a single trigger.
+type TriggerInfo struct {
+	Name string
+	// TableName is the table or view the trigger is attached to.
+	TableName string
+	SQL       string
 }
 
 // Schema holds the parsed schema for the entire database.
 type Schema struct {
-	Tables  map[string]TableInfo
-	Indexes map[string]IndexInfo
+	Tables   map[string]TableInfo
+	Indexes  map[string]IndexInfo
+	Views    map[string]ViewInfo
+	Triggers map[string]TriggerInfo
+}
+
+// SchemaObject names an object of the schema.
+type SchemaObject struct {
+	// Type is "index", "trigger" or "view".
+	Type string
+	Name string
+}
+
+// TablesOf returns the tables of the schema ordered by name. The
+// sqlite_schema table, which is not described by a row of the schema, is
+// left out.
+func (s *Schema) TablesOf() []TableInfo {
+	tables := make([]TableInfo, 0, len(s.Tables))
+	for _, table := range s.Tables {
+		if table.Name != SchemaTable().Name {
+			tables = append(tables, table)
+		}
+	}
+	slices.SortFunc(tables, func(a, b TableInfo) int { return strings.Compare(a.Name, b.Name) })
+	return tables
+}
+
+// IndexesOnTable returns the indexes of the named table ordered by name,
+// including the automatic indexes of its UNIQUE and PRIMARY KEY constraints.
+// Table names are matched without regard to case, as in SQL.
+func (s *Schema) IndexesOnTable(table string) []IndexInfo {
+	var indexes []IndexInfo
+	for _, index := range s.Indexes {
+		if strings.EqualFold(index.TableName, table) {
+			indexes = append(indexes, index)
+		}
+	}
+	slices.SortFunc(indexes, func(a, b IndexInfo) int { return strings.Compare(a.Name, b.Name) })
+	return indexes
+}
+
+// ColumnByName returns the named column of the named table. The second
+// result is false if there is no such table or column. Names are matched
+// without regard to case, as in SQL.
+func (s *Schema) ColumnByName(table, column string) (ColumnInfo, bool) {
+	for _, t := range s.Tables {
+		if strings.EqualFold(t.Name, table) {
+			if i := t.lookupColumn(column); i >= 0 {
+				return t.Columns[i], true
+			}
+			return ColumnInfo{}, false
+		}
+	}
+	return ColumnInfo{}, false
+}
+
+// DependentObjects returns the objects that depend on the named table, which
+// would be dropped or broken with it: its indexes, the triggers attached to
+// it or mentioning it in their body, and the views mentioning it in their
+// query. They are ordered by type, then by name. Mentions are found by
+// looking for the name among the identifiers of the SQL text, so a column or
+// alias with the same name as the table also counts as one.
+func (s *Schema) DependentObjects(table string) []SchemaObject {
+	var objects []SchemaObject
+	for _, index := range s.IndexesOnTable(table) {
+		objects = append(objects, SchemaObject{Type: "index", Name: index.Name})
+	}
+	var triggers, views []SchemaObject
+	for _, trigger := range s.Triggers {
+		if strings.EqualFold(trigger.TableName, table) || mentions(trigger.SQL, table) {
+			triggers = append(triggers, SchemaObject{Type: "trigger", Name: trigger.Name})
+		}
+	}
+	for _, view := range s.Views {
+		if mentions(view.Query, table) {
+			views = append(views, SchemaObject{Type: "view", Name: view.Name})
+		}
+	}
+	byName := func(a, b SchemaObject) int { return strings.Compare(a.Name, b.Name) }
+	slices.SortFunc(triggers, byName)
+	slices.SortFunc(views, byName)
+	return append(append(objects, triggers...), views...)
+}
+
+// mentions reports whether name is one of the identifiers of an SQL text,
+// possibly qualified by or qualifying another one.
+func mentions(sql, name string) bool {
+	for _, token := range tokenizeSQL(sql) {
+		switch token.text[0] {
+		case '\'':
+			// A string literal.
+		case '"', '`', '[':
+			if strings.EqualFold(token.unquoted(), name) {
+				return true
+			}
+		default:
+			// The tokenizer keeps qualified names such as main.t together.
+			for _, part := range strings.Split(token.text, ".") {
+				if strings.EqualFold(part, name) {
+					return true
+				}
+			}
+		}
+	}
+	return false
 }
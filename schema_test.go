@@ -1,6 +1,11 @@
 package golite
 
 import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -81,3 +86,240 @@ func TestDatabase_GetSchema(t *testing.T) {
 		t.Errorf("expected index table name 'test', got %q", testIndex.TableName)
 	}
 }
+
+func TestDatabase_GetSchema_Autoindexes(t *testing.T) {
+	dbPath := createTestDB(t, "autoindex_test.sqlite")
+	runSQL(t, dbPath, `
+		CREATE TABLE users(
+			email TEXT UNIQUE,
+			first TEXT,
+			last TEXT,
+			code TEXT PRIMARY KEY,
+			CONSTRAINT full_name UNIQUE (first, last),
+			UNIQUE (email)
+		);
+		INSERT INTO users VALUES ('a@x', 'Ann', 'Lee', 'A1'), ('b@x', 'Bob', 'Ray', 'B2');
+		CREATE UNIQUE INDEX users_last ON users(last);
+	`)
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	schema, err := db.GetSchema()
+	if err != nil {
+		t.Fatalf("GetSchema() failed with error: %v", err)
+	}
+
+	if got := len(schema.Tables["users"].Columns); got != 4 {
+		t.Errorf("expected table constraints not to be parsed as columns, got %d columns", got)
+	}
+	want := map[string][]string{
+		"sqlite_autoindex_users_1": {"email"},
+		"sqlite_autoindex_users_2": {"code"},
+		"sqlite_autoindex_users_3": {"first", "last"},
+	}
+	for name, columns := range want {
+		index, ok := schema.Indexes[name]
+		if !ok {
+			t.Errorf("schema did not contain %q", name)
+			continue
+		}
+		var got []string
+		for _, col := range index.Columns {
+			got = append(got, col.Name)
+		}
+		if !slices.Equal(got, columns) {
+			t.Errorf("%s: expected columns %v, got %v", name, columns, got)
+		}
+		if !index.Unique {
+			t.Errorf("%s: expected an automatic index to be unique", name)
+		}
+	}
+	if !schema.Indexes["users_last"].Unique {
+		t.Error("expected users_last to be unique")
+	}
+	if schema.Indexes["idx_name"].Unique {
+		t.Error("expected idx_name not to be unique")
+	}
+	if len(schema.Tables["users"].ForeignKeys) != 0 {
+		t.Errorf("expected no foreign keys, got %v", schema.Tables["users"].ForeignKeys)
+	}
+	if _, ok := schema.Indexes["sqlite_autoindex_users_4"]; ok {
+		t.Error("expected the duplicate UNIQUE (email) constraint not to get an index")
+	}
+
+	// Unique lookups can use the automatic indexes.
+	var rowIDs []any
+	for record, err := range db.IndexSeek(schema.Indexes["sqlite_autoindex_users_3"], Record{"Bob", "Ray"}) {
+		if err != nil {
+			t.Fatalf("IndexSeek() failed: %v", err)
+		}
+		rowIDs = append(rowIDs, record[len(record)-1])
+	}
+	if len(rowIDs) != 1 || rowIDs[0] != int64(2) {
+		t.Errorf("expected to find rowid 2, got %v", rowIDs)
+	}
+}
+
+func TestDatabase_GetSchema_Views(t *testing.T) {
+	dbPath := createTestDB(t, "views_test.sqlite")
+	runSQL(t, dbPath, `
+		CREATE VIEW short_names AS SELECT id, name FROM test WHERE length(name) < 6;
+		CREATE VIEW "renamed" (a, "b c") AS
+			SELECT id, name FROM test;
+	`)
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+
+	view, err := db.View("short_names")
+	if err != nil {
+		t.Fatalf("View() failed: %v", err)
+	}
+	if view.Columns != nil || view.Query != "SELECT id, name FROM test WHERE length(name) < 6" {
+		t.Errorf("unexpected view %+v", view)
+	}
+	view, err = db.View("renamed")
+	if err != nil {
+		t.Fatalf("View() failed: %v", err)
+	}
+	if !slices.Equal(view.Columns, []string{"a", "b c"}) || view.Query != "SELECT id, name FROM test" {
+		t.Errorf("unexpected view %+v", view)
+	}
+	if _, err := db.View("test"); err == nil {
+		t.Errorf("expected an error for a table passed to View")
+	}
+}
+
+func TestDatabase_GetSchema_VirtualTables(t *testing.T) {
+	// Virtual tables have a root page of 0, and FTS5 stores its index in
+	// shadow tables, some of them with typeless columns.
+	dbPath := createTestDB(t, "virtual_test.sqlite")
+	runSQL(t, dbPath, `
+		CREATE VIRTUAL TABLE docs USING fts5(title, body, tokenize = 'porter');
+		INSERT INTO docs VALUES ('hello', 'world');
+	`)
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+
+	schema, err := db.GetSchema()
+	if err != nil {
+		t.Fatalf("GetSchema() failed: %v", err)
+	}
+	docs := schema.Tables["docs"]
+	if !docs.Virtual || docs.RootPage != 0 || !reflect.DeepEqual(docs.Columns, []ColumnInfo{{Name: "title"}, {Name: "body"}}) {
+		t.Errorf("unexpected virtual table %+v", docs)
+	}
+	if _, err := CollectRecords(db.TableScan(docs)); !errors.Is(err, ErrUnsupportedFeature) {
+		t.Errorf("expected TableScan() to fail with ErrUnsupportedFeature, got %v", err)
+	}
+	for _, records := range []RecordIterator{db.TableScanReuse(docs), db.TableScanColumns(docs, []string{"title"}), db.TableSeek(docs, 1)} {
+		if _, err := CollectRecords(records); !errors.Is(err, ErrUnsupportedFeature) {
+			t.Errorf("expected ErrUnsupportedFeature, got %v", err)
+		}
+	}
+
+	content, err := CollectRecords(db.TableScan(schema.Tables["docs_content"]))
+	if err != nil {
+		t.Fatalf("scanning the shadow table failed: %v", err)
+	}
+	if want := []Record{{int64(1), "hello", "world"}}; !reflect.DeepEqual(content, want) {
+		t.Errorf("expected the shadow table to hold %v, got %v", want, content)
+	}
+	if _, err := db.DBStat(); err != nil {
+		t.Errorf("DBStat() failed: %v", err)
+	}
+	if _, err := db.PageUsage(); err != nil {
+		t.Errorf("PageUsage() failed: %v", err)
+	}
+}
+
+func TestSchemaIntrospection(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "introspection.sqlite")
+	runSQL(t, dbPath, `
+		CREATE TABLE orders (id INTEGER PRIMARY KEY, customer TEXT UNIQUE, total REAL NOT NULL);
+		CREATE TABLE audit (what TEXT, at TEXT);
+		CREATE INDEX orders_total ON orders(total);
+		CREATE INDEX audit_at ON audit(at);
+		CREATE TRIGGER orders_log AFTER INSERT ON orders BEGIN INSERT INTO audit VALUES ('order', datetime()); END;
+		CREATE TRIGGER audit_guard BEFORE DELETE ON audit BEGIN SELECT raise(ABORT, 'orders'); END;
+		CREATE VIEW big_orders AS SELECT * FROM main.orders WHERE total > 100;
+		CREATE VIEW "audit view" AS SELECT what FROM "audit";
+	`)
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	schema, err := db.GetSchema()
+	if err != nil {
+		t.Fatalf("GetSchema() failed with error: %v", err)
+	}
+
+	var names []string
+	for _, table := range schema.TablesOf() {
+		names = append(names, table.Name)
+	}
+	if !slices.Equal(names, []string{"audit", "orders"}) {
+		t.Errorf("TablesOf() = %v", names)
+	}
+
+	names = nil
+	for _, index := range schema.IndexesOnTable("ORDERS") {
+		names = append(names, index.Name)
+	}
+	if !slices.Equal(names, []string{"orders_total", "sqlite_autoindex_orders_1"}) {
+		t.Errorf("IndexesOnTable() = %v", names)
+	}
+
+	names = nil
+	for _, index := range schema.Tables["orders"].Indexes {
+		names = append(names, index.Name)
+		if !reflect.DeepEqual(*index, schema.Indexes[index.Name]) {
+			t.Errorf("Indexes holds %+v, want %+v", *index, schema.Indexes[index.Name])
+		}
+	}
+	if !slices.Equal(names, []string{"orders_total", "sqlite_autoindex_orders_1"}) {
+		t.Errorf("TableInfo.Indexes = %v", names)
+	}
+
+	if col, ok := schema.ColumnByName("orders", "Total"); !ok || col.Name != "total" || !col.NotNull {
+		t.Errorf("ColumnByName() = %+v, %v", col, ok)
+	}
+	if _, ok := schema.ColumnByName("orders", "missing"); ok {
+		t.Errorf("ColumnByName() found a missing column")
+	}
+	if _, ok := schema.ColumnByName("missing", "id"); ok {
+		t.Errorf("ColumnByName() found a column of a missing table")
+	}
+
+	if trigger := schema.Triggers["orders_log"]; trigger.TableName != "orders" || !strings.HasPrefix(trigger.SQL, "CREATE TRIGGER") {
+		t.Errorf("Triggers[orders_log] = %+v", trigger)
+	}
+	tests := map[string][]SchemaObject{
+		"orders": {
+			{Type: "index", Name: "orders_total"},
+			{Type: "index", Name: "sqlite_autoindex_orders_1"},
+			{Type: "trigger", Name: "orders_log"},
+			{Type: "view", Name: "big_orders"},
+		},
+		"audit": {
+			{Type: "index", Name: "audit_at"},
+			{Type: "trigger", Name: "audit_guard"},
+			{Type: "trigger", Name: "orders_log"},
+			{Type: "view", Name: "audit view"},
+		},
+		"missing": nil,
+	}
+	for table, want := range tests {
+		if got := schema.DependentObjects(table); !reflect.DeepEqual(got, want) {
+			t.Errorf("DependentObjects(%q) = %v, want %v", table, got, want)
+		}
+	}
+}
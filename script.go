@@ -0,0 +1,452 @@
+package golite
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SplitStatements splits an SQL script into its statements, which are
+// separated by semicolons. Semicolons in string literals, quoted
+// identifiers, comments and the BEGIN ... END body of CREATE TRIGGER
+// statements do not separate statements. The statements are returned
+// without their semicolon, nor the comments and white space around them;
+// empty statements are dropped.
+func SplitStatements(sql string) []string {
+	var statements []string
+	tokens := tokenizeSQL(sql)
+	start := 0
+	// body is true in the body of a trigger, and depth counts the CASE
+	// expressions open in it, which also end with END.
+	trigger, body, depth := false, false, 0
+	for i, tok := range tokens {
+		switch {
+		case i == start+1 && tokens[start].is("CREATE"),
+			i == start+2 && tokens[start].is("CREATE") && tokens[start+1].isAny("TEMP", "TEMPORARY"):
+			trigger = tok.is("TRIGGER")
+		case trigger && !body && tok.is("BEGIN"):
+			body = true
+		case body && tok.is("CASE"):
+			depth++
+		case body && tok.is("END"):
+			if depth > 0 {
+				depth--
+			} else {
+				body = false
+			}
+		case tok.text == ";" && !body:
+			if i > start {
+				statements = append(statements, exprText(sql, tokens[start:i]))
+			}
+			start, trigger = i+1, false
+		}
+	}
+	if start < len(tokens) {
+		statements = append(statements, exprText(sql, tokens[start:]))
+	}
+	return statements
+}
+
+// ExecScript executes the statements of an SQL script, such as the output of
+// Database.Dump or of the sqlite3 shell's .dump command, or a migration
+// script, adding the tables, indexes, views and triggers it creates to the
+// database. The rows of the tables are those left by the INSERT, UPDATE and
+// DELETE statements of the script, which ParseStatement must accept, and
+// which can only modify the tables the script creates. As rows are written
+// in rowid order, those of tables with no INTEGER PRIMARY KEY column are
+// numbered from 1.
+//
+// Transaction statements and PRAGMA foreign_keys are ignored, and the
+// sqlite_sequence table is maintained by the builder, so its creation is
+// ignored and inserting into it only raises the sequence of a table. Other
+// statements are reported as *UnsupportedFeatureError. The tables of the
+// script are complete once it is executed: rows cannot be added to them with
+// InsertFrom.
+func (b *DatabaseBuilder) ExecScript(sql string) *DatabaseBuilder {
+	if b.err != nil {
+		return b
+	}
+	s := &script{tables: map[string]*scriptTable{}, sequences: map[string]int64{}}
+	for _, stmt := range SplitStatements(sql) {
+		if err := s.exec(stmt); err != nil {
+			b.err = fmt.Errorf("%w in statement: %s", err, stmt)
+			return b
+		}
+	}
+
+	for _, t := range s.order {
+		b.CreateTable(t.info.SQL)
+		for _, index := range t.indexes {
+			b.CreateIndex(index)
+		}
+		rows, err := t.sortedRows()
+		if err != nil {
+			b.err = err
+			return b
+		}
+		b.InsertFrom(func(yield func(Record, error) bool) {
+			for _, row := range rows {
+				if !yield(t.info.ColumnValues(row), nil) {
+					return
+				}
+			}
+		})
+	}
+	if b.err != nil {
+		return b
+	}
+	if b.err = b.finishTable(); b.err != nil {
+		return b
+	}
+	for i, seq := range b.sequences {
+		for name, value := range s.sequences {
+			if strings.EqualFold(name, seq.name) {
+				b.sequences[i].seq = max(seq.seq, value)
+			}
+		}
+	}
+	for _, row := range s.objects {
+		for _, existing := range b.schema {
+			if strings.EqualFold(existing.name, row.name) {
+				b.err = fmt.Errorf("%s %s already exists", existing.kind, row.name)
+				return b
+			}
+		}
+		b.schema = append(b.schema, row)
+	}
+	return b
+}
+
+// script holds the state of ExecScript: the tables created by the script,
+// with their rows, and the other objects it creates.
+type script struct {
+	tables    map[string]*scriptTable // By name in lower case.
+	order     []*scriptTable          // In the order they are created.
+	objects   []schemaRow             // Views and triggers.
+	sequences map[string]int64        // Rows inserted into sqlite_sequence.
+}
+
+// scriptTable is a table created by a script.
+type scriptTable struct {
+	info    TableInfo
+	indexes []string // The CREATE INDEX statements of the table.
+	// rows holds the rows of the table in the form TableScan yields them,
+	// in the order they were inserted.
+	rows      []Record
+	lastRowID int64 // The largest rowid so far.
+}
+
+// exec executes a statement of the script.
+func (s *script) exec(stmt string) error {
+	tokens := tokenizeSQL(stmt)
+	first := tokens[0]
+	switch {
+	case first.isAny("BEGIN", "COMMIT", "END"):
+		return nil
+	case first.is("PRAGMA") && len(tokens) > 1 && tokens[1].is("foreign_keys"):
+		return nil
+	case first.is("CREATE"):
+		return s.create(stmt, tokens)
+	case first.isAny("INSERT", "REPLACE", "UPDATE", "DELETE"):
+		parsed, err := ParseStatement(stmt)
+		if err != nil {
+			return err
+		}
+		if strings.EqualFold(parsed.TableName(), "sqlite_sequence") {
+			return s.updateSequences(parsed)
+		}
+		t, ok := s.tables[strings.ToLower(parsed.TableName())]
+		if !ok {
+			return fmt.Errorf("no such table: %s", parsed.TableName())
+		}
+		switch parsed := parsed.(type) {
+		case *InsertStatement:
+			return t.insert(parsed)
+		case *UpdateStatement:
+			return t.update(parsed)
+		case *DeleteStatement:
+			return t.delete(parsed)
+		}
+	}
+	return unsupported(fmt.Sprintf("%s statements", strings.ToUpper(first.text)))
+}
+
+// create executes a CREATE statement.
+func (s *script) create(stmt string, tokens []sqlToken) error {
+	kind := ""
+	for _, tok := range tokens[1:min(len(tokens), 4)] {
+		if tok.isAny("TABLE", "INDEX", "VIEW", "TRIGGER") {
+			kind = strings.ToUpper(tok.text)
+			break
+		}
+	}
+	switch kind {
+	case "TABLE":
+		name, err := createdName(stmt, "TABLE")
+		if err != nil {
+			return err
+		}
+		if strings.EqualFold(name, "sqlite_sequence") {
+			return nil
+		}
+		if strings.HasPrefix(strings.ToLower(name), "sqlite_") {
+			return unsupported(fmt.Sprintf("creating %s", name))
+		}
+		if _, ok := s.tables[strings.ToLower(name)]; ok {
+			return fmt.Errorf("table %s already exists", name)
+		}
+		mem, err := NewMemTable(stmt)
+		if err != nil {
+			return err
+		}
+		t := &scriptTable{info: mem.Info()}
+		s.tables[strings.ToLower(name)] = t
+		s.order = append(s.order, t)
+		return nil
+	case "INDEX":
+		on := slices.IndexFunc(tokens, func(tok sqlToken) bool { return tok.is("ON") })
+		if on == -1 || on+1 == len(tokens) {
+			return fmt.Errorf("invalid CREATE INDEX statement: missing ON clause")
+		}
+		t, ok := s.tables[strings.ToLower(tokens[on+1].unquoted())]
+		if !ok {
+			return fmt.Errorf("no such table: %s", tokens[on+1].unquoted())
+		}
+		t.indexes = append(t.indexes, stmt)
+		return nil
+	case "VIEW":
+		name, err := createdName(stmt, "VIEW")
+		if err != nil {
+			return err
+		}
+		s.objects = append(s.objects, schemaRow{kind: "view", name: name, tableName: name, sql: stmt})
+		return nil
+	case "TRIGGER":
+		kw := slices.IndexFunc(tokens, func(tok sqlToken) bool { return tok.is("TRIGGER") })
+		if tokens[1].isAny("TEMP", "TEMPORARY") {
+			return unsupported("temporary triggers")
+		}
+		name := kw + 1
+		if name+2 < len(tokens) && tokens[name].is("IF") && tokens[name+1].is("NOT") && tokens[name+2].is("EXISTS") {
+			name += 3
+		}
+		on := slices.IndexFunc(tokens, func(tok sqlToken) bool { return tok.is("ON") })
+		if name >= len(tokens) || on < name || on+1 == len(tokens) {
+			return fmt.Errorf("invalid CREATE TRIGGER statement")
+		}
+		table := tokens[on+1].unquoted()
+		if _, ok := s.tables[strings.ToLower(table)]; !ok && !slices.ContainsFunc(s.objects, func(row schemaRow) bool {
+			return row.kind == "view" && strings.EqualFold(row.name, table)
+		}) {
+			return fmt.Errorf("no such table: %s", table)
+		}
+		s.objects = append(s.objects, schemaRow{kind: "trigger", name: tokens[name].unquoted(), tableName: table, sql: stmt})
+		return nil
+	}
+	return unsupported("CREATE statements other than CREATE TABLE, INDEX, VIEW and TRIGGER")
+}
+
+// updateSequences executes a statement modifying sqlite_sequence, which can
+// only insert rows or delete them all.
+func (s *script) updateSequences(stmt Statement) error {
+	switch stmt := stmt.(type) {
+	case *InsertStatement:
+		if stmt.Columns != nil || stmt.Query != "" {
+			return unsupported("INSERT INTO sqlite_sequence other than with VALUES for all columns")
+		}
+		for _, row := range stmt.Rows {
+			name, ok := row[0].(string)
+			if len(row) != 2 || !ok {
+				return fmt.Errorf("invalid row of sqlite_sequence")
+			}
+			seq, ok := AffinityInteger.apply(row[1]).(int64)
+			if !ok {
+				return fmt.Errorf("invalid row of sqlite_sequence")
+			}
+			s.sequences[name] = seq
+		}
+		return nil
+	case *DeleteStatement:
+		if len(stmt.Where) == 0 {
+			clear(s.sequences)
+			return nil
+		}
+	}
+	return unsupported("modifying sqlite_sequence")
+}
+
+// rowIDPosition returns the position of the rowid in the records of the
+// table, or -1 for a WITHOUT ROWID table.
+func (t *scriptTable) rowIDPosition() int {
+	switch {
+	case t.info.WithoutRowID:
+		return -1
+	case t.info.RowIDColumnIndex != -1:
+		return t.info.RowIDColumnIndex
+	}
+	return 0
+}
+
+// position returns the position in the records of the table of the named
+// column, which may be the rowid.
+func (t *scriptTable) position(name string) (int, error) {
+	i := t.info.lookupColumn(name)
+	switch {
+	case i == -1:
+		return 0, fmt.Errorf("table %s has no column named %s", t.info.Name, name)
+	case i == rowIDColumn || i == t.info.RowIDColumnIndex:
+		return t.rowIDPosition(), nil
+	case t.info.RowIDColumnIndex == -1 && !t.info.WithoutRowID:
+		return i + 1, nil // The record starts with the rowid.
+	}
+	return i, nil
+}
+
+// setValue stores a value in the given position of a record of the table,
+// applying the affinity of its column.
+func (t *scriptTable) setValue(record Record, position int, value any) {
+	if value == nil {
+		value = SQLNull
+	}
+	if position == t.rowIDPosition() {
+		record[position] = AffinityInteger.apply(value)
+		return
+	}
+	column := position
+	if t.info.RowIDColumnIndex == -1 && !t.info.WithoutRowID {
+		column--
+	}
+	record[position] = t.info.Columns[column].Affinity().apply(value)
+}
+
+func (t *scriptTable) insert(stmt *InsertStatement) error {
+	switch {
+	case stmt.Query != "":
+		return unsupported("INSERT ... SELECT")
+	case stmt.Or == "REPLACE" || stmt.Or == "IGNORE":
+		return unsupported("INSERT OR " + stmt.Or)
+	}
+	width := len(t.info.Columns)
+	if t.info.RowIDColumnIndex == -1 && !t.info.WithoutRowID {
+		width++
+	}
+	positions := make([]int, len(stmt.Columns))
+	for i, name := range stmt.Columns {
+		var err error
+		if positions[i], err = t.position(name); err != nil {
+			return err
+		}
+	}
+	if stmt.Columns == nil {
+		positions = make([]int, len(t.info.Columns))
+		for i := range positions {
+			positions[i] = i + width - len(t.info.Columns)
+		}
+	}
+	for _, values := range stmt.Rows {
+		if len(values) != len(positions) {
+			return fmt.Errorf("table %s has %d columns but %d values were supplied", t.info.Name, len(positions), len(values))
+		}
+		record := make(Record, width)
+		for _, col := range t.info.Columns {
+			value, err := col.DefaultValue()
+			if err != nil {
+				return err
+			}
+			position, _ := t.position(col.Name)
+			t.setValue(record, position, value)
+		}
+		if p := t.rowIDPosition(); p != -1 {
+			record[p] = SQLNull
+		}
+		for i, value := range values {
+			t.setValue(record, positions[i], value)
+		}
+		if p := t.rowIDPosition(); p != -1 {
+			if isNull(record[p]) {
+				record[p] = t.lastRowID + 1
+			}
+			rowID, ok := record[p].(int64)
+			if !ok {
+				return fmt.Errorf("datatype mismatch: the rowid of %s must be an integer", t.info.Name)
+			}
+			t.lastRowID = max(t.lastRowID, rowID)
+		}
+		t.rows = append(t.rows, record)
+	}
+	return nil
+}
+
+func (t *scriptTable) update(stmt *UpdateStatement) error {
+	if stmt.Or == "REPLACE" || stmt.Or == "IGNORE" {
+		return unsupported("UPDATE OR " + stmt.Or)
+	}
+	match, err := t.info.MatchConstraints(stmt.Where)
+	if err != nil {
+		return err
+	}
+	positions := make([]int, len(stmt.Set))
+	for i, assignment := range stmt.Set {
+		if positions[i], err = t.position(assignment.Column); err != nil {
+			return err
+		}
+	}
+	for _, record := range t.rows {
+		ok, err := match(record)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		for i, assignment := range stmt.Set {
+			t.setValue(record, positions[i], assignment.Value)
+		}
+		if p := t.rowIDPosition(); p != -1 {
+			rowID, ok := record[p].(int64)
+			if !ok {
+				return fmt.Errorf("datatype mismatch: the rowid of %s must be an integer", t.info.Name)
+			}
+			t.lastRowID = max(t.lastRowID, rowID)
+		}
+	}
+	return nil
+}
+
+func (t *scriptTable) delete(stmt *DeleteStatement) error {
+	match, err := t.info.MatchConstraints(stmt.Where)
+	if err != nil {
+		return err
+	}
+	var matchErr error
+	t.rows = slices.DeleteFunc(t.rows, func(record Record) bool {
+		ok, err := match(record)
+		if err != nil && matchErr == nil {
+			matchErr = err
+		}
+		return ok
+	})
+	return matchErr
+}
+
+// sortedRows returns the rows of a rowid table in increasing rowid order,
+// and those of a WITHOUT ROWID table as they are.
+func (t *scriptTable) sortedRows() ([]Record, error) {
+	p := t.rowIDPosition()
+	if p == -1 {
+		return t.rows, nil
+	}
+	rows := slices.SortedStableFunc(slices.Values(t.rows), func(x, y Record) int {
+		return compareValues(x[p], y[p])
+	})
+	for i := 1; i < len(rows); i++ {
+		if compareValues(rows[i-1][p], rows[i][p]) == 0 {
+			name := "rowid"
+			if t.info.RowIDColumnIndex != -1 {
+				name = t.info.Columns[t.info.RowIDColumnIndex].Name
+			}
+			return nil, fmt.Errorf("UNIQUE constraint failed: %s.%s", t.info.Name, name)
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,153 @@
+package golite
+
+import (
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	testCases := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		{
+			name: "literals and comments",
+			sql:  "INSERT INTO t VALUES ('a;b', \"c;d\"); -- e;f\n/* g; */ SELECT 1 ;; SELECT 2",
+			want: []string{"INSERT INTO t VALUES ('a;b', \"c;d\")", "SELECT 1", "SELECT 2"},
+		},
+		{
+			name: "trigger body",
+			sql: `BEGIN TRANSACTION;
+CREATE TEMP TRIGGER tr AFTER INSERT ON t WHEN CASE WHEN 1 THEN 1 END BEGIN
+  UPDATE t SET x = CASE new.x WHEN 1 THEN 'one;' ELSE 'other' END;
+  DELETE FROM u;
+END;
+COMMIT;`,
+			want: []string{
+				"BEGIN TRANSACTION",
+				"CREATE TEMP TRIGGER tr AFTER INSERT ON t WHEN CASE WHEN 1 THEN 1 END BEGIN\n  UPDATE t SET x = CASE new.x WHEN 1 THEN 'one;' ELSE 'other' END;\n  DELETE FROM u;\nEND",
+				"COMMIT",
+			},
+		},
+		{
+			name: "blank",
+			sql:  " ; -- nothing\n",
+			want: nil,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := SplitStatements(tc.sql); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("SplitStatements() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// testScript exercises the statements ExecScript supports.
+const testScript = `
+PRAGMA foreign_keys=OFF;
+BEGIN TRANSACTION;
+CREATE TABLE a (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT UNIQUE, n REAL DEFAULT 1.5);
+INSERT INTO a VALUES (5, 'five; 5', 5);
+INSERT INTO a (name) VALUES ('six'), ('seven');
+INSERT INTO a (id, name, n) VALUES (2, 'two', '2.5');
+CREATE TABLE b (x INTEGER, y TEXT);
+INSERT INTO b VALUES (1, 'one'), (2, 'two'), (3, 'three'), (4, 'four');
+DELETE FROM b WHERE x <= 2;
+UPDATE b SET y = 'FOUR', x = '40' WHERE y = 'four';
+INSERT INTO b VALUES (NULL, X'00ff');
+UPDATE a SET n = -1e-1 WHERE id BETWEEN 3 AND 6 AND name > '';
+CREATE INDEX b_y ON b(y);
+CREATE TABLE w (k TEXT PRIMARY KEY, v INTEGER) WITHOUT ROWID;
+INSERT INTO w VALUES ('z', 1), ('y', 2);
+CREATE VIEW v AS SELECT name FROM a WHERE n > 0;
+CREATE TRIGGER tr AFTER INSERT ON b BEGIN
+  UPDATE b SET y = CASE new.x WHEN 1 THEN 'x;' ELSE y END;
+END;
+DELETE FROM sqlite_sequence;
+INSERT INTO sqlite_sequence VALUES ('a', 100);
+COMMIT;
+`
+
+func TestDatabaseBuilder_ExecScript(t *testing.T) {
+	dir := t.TempDir()
+	built := filepath.Join(dir, "built.sqlite")
+	if err := NewDatabaseBuilder(built).ExecScript(testScript).Finalize(); err != nil {
+		t.Fatalf("Finalize() failed: %v", err)
+	}
+	reference := filepath.Join(dir, "reference.sqlite")
+	runSQL(t, reference, testScript)
+
+	query := func(path, sql string) string {
+		t.Helper()
+		output, err := exec.Command("sqlite3", path, sql).CombinedOutput()
+		if err != nil {
+			t.Fatalf("sqlite3 failed: %v\nOutput: %s", err, output)
+		}
+		return string(output)
+	}
+	if got := query(built, "PRAGMA integrity_check;"); got != "ok\n" {
+		t.Errorf("integrity_check = %q", got)
+	}
+	for _, sql := range []string{
+		"SELECT type, name, tbl_name, sql FROM sqlite_schema ORDER BY name;",
+		"SELECT * FROM sqlite_sequence;",
+		"SELECT id, name, typeof(n), n FROM a ORDER BY id;",
+		"SELECT typeof(x), x, typeof(y), y FROM b ORDER BY x;",
+		"SELECT y FROM b INDEXED BY b_y WHERE y > '' ORDER BY y;",
+		"SELECT * FROM w;",
+		"SELECT * FROM v;",
+	} {
+		if got, want := query(built, sql), query(reference, sql); got != want {
+			t.Errorf("%s: got\n%s\nwant\n%s", sql, got, want)
+		}
+	}
+
+	// The dump of the reference replays to the same database.
+	dump := query(reference, ".dump")
+	replayed := filepath.Join(dir, "replayed.sqlite")
+	if err := NewDatabaseBuilder(replayed).ExecScript(dump).Finalize(); err != nil {
+		t.Fatalf("replaying the dump failed: %v", err)
+	}
+	if got := query(replayed, ".dump"); got != dump {
+		t.Errorf("dump of the replayed database:\n%s\nwant\n%s", got, dump)
+	}
+}
+
+func TestDatabaseBuilder_ExecScriptErrors(t *testing.T) {
+	testCases := []struct {
+		name            string
+		sql             string
+		wantUnsupported bool
+	}{
+		{name: "unknown table", sql: "INSERT INTO t VALUES (1)"},
+		{name: "unknown column", sql: "CREATE TABLE t (x INTEGER); INSERT INTO t (y) VALUES (1)"},
+		{name: "duplicate rowid", sql: "CREATE TABLE t (x INTEGER PRIMARY KEY); INSERT INTO t VALUES (1), (1)"},
+		{name: "text rowid", sql: "CREATE TABLE t (x INTEGER PRIMARY KEY); INSERT INTO t VALUES ('a')"},
+		{name: "duplicate view", sql: "CREATE TABLE t (x INTEGER); CREATE VIEW t AS SELECT 1"},
+		{name: "select", sql: "SELECT 1", wantUnsupported: true},
+		{name: "insert select", sql: "CREATE TABLE t (x INTEGER); INSERT INTO t SELECT 1", wantUnsupported: true},
+		{name: "replace", sql: "CREATE TABLE t (x INTEGER); REPLACE INTO t VALUES (1)", wantUnsupported: true},
+		{name: "alter", sql: "CREATE TABLE t (x INTEGER); ALTER TABLE t ADD y", wantUnsupported: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.sqlite")
+			err := NewDatabaseBuilder(path).ExecScript(tc.sql).Finalize()
+			if err == nil {
+				t.Fatal("Finalize() succeeded, want an error")
+			}
+			var unsupportedErr *UnsupportedFeatureError
+			if got := errors.As(err, &unsupportedErr); got != tc.wantUnsupported {
+				t.Errorf("Finalize() error = %v, unsupported = %v, want %v", err, got, tc.wantUnsupported)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package sqlar
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"path"
+	"time"
+)
+
+// Open opens the named file for reading, implementing fs.FS. Directories
+// implied by archived paths can be opened even if they are not stored in the
+// archive themselves.
+func (a *Archive) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return &dir{archive: a, info: fileInfo{&Entry{Name: ".", Mode: fs.ModeDir | 0755}}}, nil
+	}
+	entry, ok := a.entries[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	if entry.Mode.IsDir() {
+		return &dir{archive: a, info: fileInfo{entry}}, nil
+	}
+	data, err := a.readData(entry)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &file{Reader: bytes.NewReader(data), info: fileInfo{entry}}, nil
+}
+
+// Stat returns a FileInfo describing the named entry, implementing
+// fs.StatFS.
+func (a *Archive) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		return fileInfo{&Entry{Name: ".", Mode: fs.ModeDir | 0755}}, nil
+	}
+	entry, ok := a.entries[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return fileInfo{entry}, nil
+}
+
+// ReadDir returns the entries of the named directory sorted by name,
+// implementing fs.ReadDirFS.
+func (a *Archive) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	children, ok := a.children[name]
+	if !ok {
+		if _, exists := a.entries[name]; exists {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+		}
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	entries := make([]fs.DirEntry, len(children))
+	for i, child := range children {
+		entries[i] = fs.FileInfoToDirEntry(fileInfo{a.entries[child]})
+	}
+	return entries, nil
+}
+
+// fileInfo adapts an Entry to fs.FileInfo.
+type fileInfo struct {
+	entry *Entry
+}
+
+func (fi fileInfo) Name() string       { return path.Base(fi.entry.Name) }
+func (fi fileInfo) Size() int64        { return fi.entry.Size }
+func (fi fileInfo) Mode() fs.FileMode  { return fi.entry.Mode }
+func (fi fileInfo) ModTime() time.Time { return fi.entry.ModTime }
+func (fi fileInfo) IsDir() bool        { return fi.entry.Mode.IsDir() }
+func (fi fileInfo) Sys() any           { return nil }
+
+// file is an open regular file or symbolic link, fully decompressed in
+// memory.
+type file struct {
+	*bytes.Reader
+	info fileInfo
+}
+
+func (f *file) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *file) Close() error               { return nil }
+
+// dir is an open directory.
+type dir struct {
+	archive *Archive
+	info    fileInfo
+	offset  int
+}
+
+func (d *dir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *dir) Close() error               { return nil }
+
+func (d *dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.entry.Name, Err: fs.ErrInvalid}
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	entries, err := d.archive.ReadDir(d.info.entry.Name)
+	if err != nil {
+		return nil, err
+	}
+	entries = entries[d.offset:]
+	if n > 0 {
+		if len(entries) == 0 {
+			return nil, io.EOF
+		}
+		entries = entries[:min(n, len(entries))]
+	}
+	d.offset += len(entries)
+	return entries, nil
+}
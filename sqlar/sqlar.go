@@ -0,0 +1,276 @@
+// Package sqlar reads SQLite Archive files, as created by "sqlite3 -A".
+//
+// An SQLite Archive stores files in a table named sqlar with the columns
+// (name, mode, mtime, sz, data). The data column holds the file contents,
+// zlib-compressed unless compression would not have made it smaller, in
+// which case sz equals the length of data.
+//
+// Archive implements fs.FS, so archived files can be served or walked with
+// the standard library's io/fs functions.
+package sqlar
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/arnodel/golite"
+)
+
+// ErrNoArchive is returned by Open when the database has no sqlar table.
+var ErrNoArchive = errors.New("database does not contain an sqlar table")
+
+// Column positions in records yielded by TableScan of the sqlar table, which
+// has no rowid alias so the rowid comes first.
+const (
+	colRowID = iota
+	colName
+	colMode
+	colMtime
+	colSize
+	colData
+)
+
+// Entry describes a file, directory or symbolic link stored in an archive.
+type Entry struct {
+	// Name is the slash-separated path of the entry within the archive.
+	Name string
+	// Mode holds the entry's type and permission bits.
+	Mode fs.FileMode
+	// ModTime is the entry's last modification time.
+	ModTime time.Time
+	// Size is the uncompressed size of the entry's contents.
+	Size int64
+
+	rowID int64
+	// found is true for the entries read from the sqlar table, and false for
+	// the directories synthesized for the parents of their paths.
+	found bool
+}
+
+// Archive is an SQLite Archive stored in a golite Database.
+type Archive struct {
+	db      *golite.Database
+	table   golite.TableInfo
+	entries map[string]*Entry
+	// children maps each directory name ("." for the root) to the names of
+	// its direct children, including directories implied by entry paths.
+	children map[string][]string
+}
+
+// Open locates the sqlar table in db and reads the list of archived entries.
+// It returns ErrNoArchive if there is no such table.
+func Open(db *golite.Database) (*Archive, error) {
+	table, err := findTable(db)
+	if err != nil {
+		return nil, err
+	}
+	a := &Archive{
+		db:       db,
+		table:    table,
+		entries:  make(map[string]*Entry),
+		children: map[string][]string{".": nil},
+	}
+	for record, err := range db.TableScan(table) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sqlar table: %w", err)
+		}
+		entry, err := entryFromRecord(record)
+		if err != nil {
+			return nil, err
+		}
+		a.add(entry)
+	}
+	for _, names := range a.children {
+		sort.Strings(names)
+	}
+	return a, nil
+}
+
+// findTable looks for the sqlar table in the raw schema records. The table's
+// declared columns are fixed by the archive format, so its SQL is not parsed.
+func findTable(db *golite.Database) (golite.TableInfo, error) {
+	for record, err := range db.TableScan(golite.SchemaTable()) {
+		if err != nil {
+			return golite.TableInfo{}, fmt.Errorf("failed to scan schema table: %w", err)
+		}
+		// Schema records are (rowid, type, name, tbl_name, rootpage, sql).
+		if len(record) < 6 || record[1] != "table" || record[2] != "sqlar" {
+			continue
+		}
+		rootPage, ok := record[4].(int64)
+		if !ok {
+			return golite.TableInfo{}, errors.New("malformed schema record for table \"sqlar\"")
+		}
+		sql, _ := record[5].(string)
+		return golite.TableInfo{
+			Name:     "sqlar",
+			RootPage: int(rootPage),
+			SQL:      sql,
+			Columns: []golite.ColumnInfo{
+				{Name: "name", Type: "TEXT"},
+				{Name: "mode", Type: "INT"},
+				{Name: "mtime", Type: "INT"},
+				{Name: "sz", Type: "INT"},
+				{Name: "data", Type: "BLOB"},
+			},
+			RowIDColumnIndex: -1,
+		}, nil
+	}
+	return golite.TableInfo{}, ErrNoArchive
+}
+
+// entryFromRecord builds an Entry from a record of the sqlar table.
+func entryFromRecord(record golite.Record) (*Entry, error) {
+	if len(record) < colData+1 {
+		return nil, fmt.Errorf("malformed sqlar record: expected %d columns, got %d", colData+1, len(record))
+	}
+	name, ok := record[colName].(string)
+	if !ok {
+		return nil, errors.New("malformed sqlar record: name is not a string")
+	}
+	rowID, _ := record[colRowID].(int64)
+	mode, _ := record[colMode].(int64)
+	mtime, _ := record[colMtime].(int64)
+	size, _ := record[colSize].(int64)
+	return &Entry{
+		Name:    strings.TrimSuffix(name, "/"),
+		Mode:    unixMode(mode),
+		ModTime: time.Unix(mtime, 0),
+		Size:    size,
+		rowID:   rowID,
+		found:   true,
+	}, nil
+}
+
+// unixMode converts a Unix st_mode value to an fs.FileMode.
+func unixMode(mode int64) fs.FileMode {
+	m := fs.FileMode(mode & 0777)
+	switch mode & 0170000 {
+	case 0040000:
+		m |= fs.ModeDir
+	case 0120000:
+		m |= fs.ModeSymlink
+	}
+	return m
+}
+
+// add records an entry and links it, and any missing parent directories,
+// into the directory tree.
+func (a *Archive) add(entry *Entry) {
+	if existing, ok := a.entries[entry.Name]; ok && existing.found {
+		return
+	}
+	a.entries[entry.Name] = entry
+	for name := entry.Name; name != "."; {
+		parent := path.Dir(name)
+		if _, ok := a.children[parent]; !ok {
+			a.children[parent] = nil
+		}
+		if !containsString(a.children[parent], name) {
+			a.children[parent] = append(a.children[parent], name)
+		}
+		if parent == "." {
+			break
+		}
+		if _, ok := a.entries[parent]; ok {
+			break
+		}
+		// Synthesize a directory entry for a parent that is not archived.
+		a.entries[parent] = &Entry{Name: parent, Mode: fs.ModeDir | 0755}
+		name = parent
+	}
+	if entry.Mode.IsDir() {
+		if _, ok := a.children[entry.Name]; !ok {
+			a.children[entry.Name] = nil
+		}
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Entries returns the archived entries, sorted by name. Directories implied
+// by entry paths but not stored in the archive are not included.
+func (a *Archive) Entries() []Entry {
+	var entries []Entry
+	for _, entry := range a.entries {
+		if entry.found {
+			entries = append(entries, *entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
+
+// ReadFile returns the uncompressed contents of the named entry. For a
+// symbolic link, the contents are the link target.
+func (a *Archive) ReadFile(name string) ([]byte, error) {
+	entry, ok := a.entries[name]
+	if !ok || !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: fs.ErrNotExist}
+	}
+	if entry.Mode.IsDir() {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	}
+	data, err := a.readData(entry)
+	if err != nil {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: err}
+	}
+	return data, nil
+}
+
+// readData fetches and decompresses the data column of an entry.
+func (a *Archive) readData(entry *Entry) ([]byte, error) {
+	var record golite.Record
+	for r, err := range a.db.TableSeek(a.table, entry.rowID) {
+		if err != nil {
+			return nil, err
+		}
+		record = r
+	}
+	if record == nil {
+		return nil, fmt.Errorf("row %d of sqlar table not found", entry.rowID)
+	}
+
+	var data []byte
+	switch v := record[colData].(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	case golite.NullType:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unexpected data column type %T", v)
+	}
+	if int64(len(data)) == entry.Size {
+		return append([]byte(nil), data...), nil // Stored uncompressed.
+	}
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress: %w", err)
+	}
+	defer zr.Close()
+	out, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress: %w", err)
+	}
+	if int64(len(out)) != entry.Size {
+		return nil, fmt.Errorf("decompressed size %d does not match sz %d", len(out), entry.Size)
+	}
+	return out, nil
+}
@@ -0,0 +1,133 @@
+package sqlar
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/arnodel/golite"
+)
+
+// createArchive writes files to a temporary directory and archives them with
+// "sqlite3 -Ac", returning the opened archive database.
+func createArchive(t *testing.T, files map[string]string) *golite.Database {
+	t.Helper()
+	srcDir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(srcDir, "d", name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("failed to write %s: %v", path, err)
+		}
+	}
+	archivePath := filepath.Join(t.TempDir(), "test.sqlar")
+	cmd := exec.Command("sqlite3", archivePath, "-Ac", "d")
+	cmd.Dir = srcDir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("sqlite3 failed: %v\nOutput: %s", err, string(output))
+	}
+	db, err := golite.Open(archivePath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestArchive(t *testing.T) {
+	files := map[string]string{
+		"a.txt":       "hello\n",
+		"sub/big.txt": strings.Repeat("compressible ", 150),
+		"sub/empty":   "",
+	}
+	archive, err := Open(createArchive(t, files))
+	if err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+
+	t.Run("entries", func(t *testing.T) {
+		var names []string
+		for _, entry := range archive.Entries() {
+			names = append(names, entry.Name)
+		}
+		want := "d d/a.txt d/sub d/sub/big.txt d/sub/empty"
+		if got := strings.Join(names, " "); got != want {
+			t.Errorf("expected entries %q, got %q", want, got)
+		}
+	})
+
+	t.Run("read files", func(t *testing.T) {
+		for name, content := range files {
+			data, err := archive.ReadFile("d/" + name)
+			if err != nil {
+				t.Fatalf("ReadFile(%q) failed: %v", name, err)
+			}
+			if !bytes.Equal(data, []byte(content)) {
+				t.Errorf("ReadFile(%q): expected %d bytes, got %d", name, len(content), len(data))
+			}
+		}
+		if _, err := archive.ReadFile("d/missing"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("expected fs.ErrNotExist for a missing file, got %v", err)
+		}
+	})
+
+	t.Run("fs.FS", func(t *testing.T) {
+		if err := fstest.TestFS(archive, "d/a.txt", "d/sub/big.txt", "d/sub/empty"); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestArchive_RowIDZero(t *testing.T) {
+	// Rowids can be zero or negative, so they do not tell the archived
+	// entries from the directories synthesized for their parents.
+	dbPath := filepath.Join(t.TempDir(), "rowid.sqlar")
+	cmd := exec.Command("sqlite3", dbPath, `CREATE TABLE sqlar(name TEXT PRIMARY KEY, mode INT, mtime INT, sz INT, data BLOB);
+INSERT INTO sqlar (rowid, name, mode, mtime, sz, data) VALUES (0, 'd', 16877, 0, 0, NULL), (-1, 'd/a.txt', 33188, 0, 2, 'hi');`)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("sqlite3 failed: %v\nOutput: %s", err, string(output))
+	}
+	db, err := golite.Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	archive, err := Open(db)
+	if err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+	var names []string
+	for _, entry := range archive.Entries() {
+		names = append(names, entry.Name)
+	}
+	if got := strings.Join(names, " "); got != "d d/a.txt" {
+		t.Errorf("expected entries %q, got %q", "d d/a.txt", got)
+	}
+	if data, err := archive.ReadFile("d/a.txt"); err != nil || string(data) != "hi" {
+		t.Errorf("ReadFile() = %q, %v, want \"hi\"", data, err)
+	}
+}
+
+func TestOpen_NoArchive(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "plain.sqlite")
+	cmd := exec.Command("sqlite3", dbPath, "CREATE TABLE t(x);")
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("sqlite3 failed: %v\nOutput: %s", err, string(output))
+	}
+	db, err := golite.Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	if _, err := Open(db); !errors.Is(err, ErrNoArchive) {
+		t.Errorf("expected ErrNoArchive, got %v", err)
+	}
+}
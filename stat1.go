@@ -0,0 +1,141 @@
+package golite
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// internalTables are the columns of the tables SQLite creates itself: those
+// created by ANALYZE, and sqlite_sequence, created for AUTOINCREMENT. Their
+// columns have no declared type, so they are not parsed from their SQL.
+var internalTables = map[string][]string{
+	"sqlite_stat1":    {"tbl", "idx", "stat"},
+	"sqlite_stat4":    {"tbl", "idx", "neq", "nlt", "ndlt", "sample"},
+	"sqlite_sequence": {"name", "seq"},
+}
+
+// internalTable returns the TableInfo of a table SQLite creates itself, and
+// false if name is not such a table.
+func internalTable(name string, rootPage int, sql string) (TableInfo, bool) {
+	names, ok := internalTables[strings.ToLower(name)]
+	if !ok {
+		return TableInfo{}, false
+	}
+	columns := make([]ColumnInfo, len(names))
+	for i, name := range names {
+		columns[i] = ColumnInfo{Name: name}
+	}
+	return TableInfo{
+		Name:             name,
+		RootPage:         rootPage,
+		SQL:              sql,
+		Columns:          columns,
+		RowIDColumnIndex: -1,
+	}, true
+}
+
+// TableStats holds the statistics gathered by ANALYZE for a table and its
+// indexes, as read from sqlite_stat1.
+type TableStats struct {
+	// Rows is the approximate number of rows in the table.
+	Rows int64
+	// Indexes holds the statistics of the table's indexes, by index name.
+	Indexes map[string]IndexStats
+}
+
+// IndexStats holds the statistics gathered by ANALYZE for an index.
+type IndexStats struct {
+	// Rows is the approximate number of entries in the index.
+	Rows int64
+	// RowsPerKey holds, for each i, the average number of entries that
+	// have the same values in the first i+1 columns of the index.
+	RowsPerKey []int64
+	// Unordered is true if the index must not be used for sorting.
+	Unordered bool
+	// NoSkipScan is true if the index must not be used for skip-scans.
+	NoSkipScan bool
+}
+
+// Statistics reads the statistics gathered by ANALYZE from sqlite_stat1,
+// keyed by table name. It returns an empty map if the database has never
+// been analyzed. Entries that cannot be parsed are ignored, as SQLite does.
+func (db *Database) Statistics() (map[string]*TableStats, error) {
+	stats := map[string]*TableStats{}
+	schema, err := db.GetSchema()
+	if err != nil {
+		return nil, err
+	}
+	table, ok := schema.Tables["sqlite_stat1"]
+	if !ok {
+		return stats, nil
+	}
+	for record, err := range db.TableScan(table) {
+		if err != nil {
+			return nil, err
+		}
+		values := table.ColumnValues(record)
+		if len(values) < 3 {
+			continue
+		}
+		tableName, ok := values[0].(string)
+		if !ok {
+			continue
+		}
+		stat, ok := values[2].(string)
+		if !ok {
+			continue
+		}
+		t := stats[tableName]
+		if t == nil {
+			t = &TableStats{Indexes: map[string]IndexStats{}}
+			stats[tableName] = t
+		}
+		index, err := parseStat1(stat)
+		if err != nil {
+			continue
+		}
+		if indexName, ok := values[1].(string); ok {
+			t.Indexes[indexName] = index
+			if t.Rows == 0 {
+				t.Rows = index.Rows
+			}
+		} else {
+			t.Rows = index.Rows // The row of the table itself.
+		}
+	}
+	return stats, nil
+}
+
+// parseStat1 parses the stat column of sqlite_stat1: the number of entries,
+// followed by the average number of entries per value of each prefix of the
+// index columns, and optional keywords.
+func parseStat1(stat string) (IndexStats, error) {
+	var index IndexStats
+	fields := strings.Fields(stat)
+	if len(fields) == 0 {
+		return index, fmt.Errorf("malformed statistics %q", stat)
+	}
+	rows, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return index, fmt.Errorf("malformed statistics %q", stat)
+	}
+	index.Rows = rows
+	for i, field := range fields[1:] {
+		n, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			// The numbers are followed by keywords, ignored if unknown.
+			for _, keyword := range fields[1+i:] {
+				switch keyword {
+				case "unordered":
+					index.Unordered = true
+				case "noskipscan":
+					index.NoSkipScan = true
+				}
+			}
+			break
+		}
+		index.RowsPerKey = append(index.RowsPerKey, n)
+	}
+	return index, nil
+}
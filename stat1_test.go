@@ -0,0 +1,68 @@
+package golite
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStatistics(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "stat1.sqlite")
+	runSQL(t, dbPath, `
+		CREATE TABLE t (a INTEGER PRIMARY KEY, b TEXT, c INTEGER);
+		CREATE INDEX t_bc ON t(b, c);
+		CREATE TABLE plain (x TEXT);
+		WITH RECURSIVE seq(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM seq WHERE i < 1000)
+		INSERT INTO t SELECT i, 'n' || (i % 10), i % 100 FROM seq;
+		INSERT INTO plain VALUES ('a'), ('b');
+	`)
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	if stats, err := db.Statistics(); err != nil || len(stats) != 0 {
+		t.Errorf("expected no statistics before ANALYZE, got (%v, %v)", stats, err)
+	}
+	db.Close()
+
+	runSQL(t, dbPath, "ANALYZE;")
+	db, err = Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	stats, err := db.Statistics()
+	if err != nil {
+		t.Fatalf("Statistics() failed: %v", err)
+	}
+	want := map[string]*TableStats{
+		"t":     {Rows: 1000, Indexes: map[string]IndexStats{"t_bc": {Rows: 1000, RowsPerKey: []int64{100, 10}}}},
+		"plain": {Rows: 2, Indexes: map[string]IndexStats{}},
+	}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("Statistics() = %v, want %v", stats, want)
+	}
+	if _, err := db.Table("sqlite_stat1"); err != nil {
+		t.Errorf("expected sqlite_stat1 to be readable, got %v", err)
+	}
+}
+
+func TestParseStat1(t *testing.T) {
+	tests := []struct {
+		stat    string
+		want    IndexStats
+		wantErr bool
+	}{
+		{"10", IndexStats{Rows: 10}, false},
+		{"5000 1250 1", IndexStats{Rows: 5000, RowsPerKey: []int64{1250, 1}}, false},
+		{"100 10 unordered sz=12 noskipscan", IndexStats{Rows: 100, RowsPerKey: []int64{10}, Unordered: true, NoSkipScan: true}, false},
+		{"", IndexStats{}, true},
+		{"many", IndexStats{}, true},
+	}
+	for _, test := range tests {
+		got, err := parseStat1(test.stat)
+		if (err != nil) != test.wantErr || !test.wantErr && !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parseStat1(%q) = (%v, %v), want %v", test.stat, got, err, test.want)
+		}
+	}
+}
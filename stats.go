@@ -0,0 +1,49 @@
+package golite
+
+import "sync/atomic"
+
+// Stats holds counters of the work done by a Database since it was opened.
+type Stats struct {
+	// PagesRead is the number of pages read from the file.
+	PagesRead int64
+	// BytesRead is the number of bytes read from the file for those pages.
+	BytesRead int64
+	// CacheHits and CacheMisses count page lookups in the page cache enabled
+	// by WithPageCache. Both are zero without a cache.
+	CacheHits, CacheMisses int64
+	// RecordsDecoded is the number of records decoded from B-Tree cells,
+	// including index entries and interior index keys.
+	RecordsDecoded int64
+}
+
+// counters is the concurrency-safe form of Stats kept by a Database.
+type counters struct {
+	pagesRead, bytesRead   atomic.Int64
+	cacheHits, cacheMisses atomic.Int64
+	recordsDecoded         atomic.Int64
+}
+
+// Stats returns a snapshot of the database's performance counters.
+func (db *Database) Stats() Stats {
+	return Stats{
+		PagesRead:      db.counters.pagesRead.Load(),
+		BytesRead:      db.counters.bytesRead.Load(),
+		CacheHits:      db.counters.cacheHits.Load(),
+		CacheMisses:    db.counters.cacheMisses.Load(),
+		RecordsDecoded: db.counters.recordsDecoded.Load(),
+	}
+}
+
+// parsePage parses a page read from the database, and counts and traces its
+// records.
+func (db *Database) parsePage(data []byte, pageNum int) (*Page, error) {
+	page, err := parsePage(data, pageNum, db.usableSize(), db.zeroCopy, db.arena)
+	if err == nil {
+		if db.utf16Order() != nil {
+			page.eachRecord(db.decodeText)
+		}
+		db.counters.recordsDecoded.Add(int64(len(page.LeafCells) + len(page.LeafIndexCells) + len(page.InteriorIndexCells)))
+		db.traceRecords(page)
+	}
+	return page, err
+}
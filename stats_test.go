@@ -0,0 +1,42 @@
+package golite
+
+import "testing"
+
+func TestDatabase_Stats(t *testing.T) {
+	dbPath := createTestDB(t, "stats_test.sqlite")
+	db, err := Open(dbPath, WithPageCache(100))
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	table, err := db.Table("test")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+
+	scan := func() {
+		for _, err := range db.TableScan(table) {
+			if err != nil {
+				t.Fatalf("TableScan() failed: %v", err)
+			}
+		}
+	}
+	before := db.Stats()
+	scan()
+	cold := db.Stats()
+	if cold.PagesRead <= before.PagesRead || cold.BytesRead != cold.PagesRead*4096 {
+		t.Errorf("expected whole pages to be read by a cold scan, got %+v", cold)
+	}
+	if got := cold.RecordsDecoded - before.RecordsDecoded; got != 500 {
+		t.Errorf("expected 500 records decoded, got %d", got)
+	}
+
+	scan()
+	warm := db.Stats()
+	if warm.PagesRead != cold.PagesRead {
+		t.Errorf("expected a warm scan to read no pages, read %d", warm.PagesRead-cold.PagesRead)
+	}
+	if warm.CacheHits <= cold.CacheHits || warm.CacheMisses != cold.CacheMisses {
+		t.Errorf("expected a warm scan to only hit the cache, got %+v then %+v", cold, warm)
+	}
+}
@@ -0,0 +1,149 @@
+package golite
+
+import (
+	"fmt"
+	"iter"
+	"reflect"
+)
+
+// ScanStruct copies the values of a record yielded by TableScan or TableSeek
+// into the fields of the struct pointed at by dest.
+//
+// A field is mapped to the column named by its `golite:"name"` tag or, if it
+// has no tag, to the column whose name matches the field name, ignoring case.
+// The tag "rowid" maps a field to the row's rowid when the table has no column
+// of that name, and the tag "-" excludes a field. Exported fields of embedded
+// structs are mapped as if they belonged to the outer struct. Untagged fields
+// without a matching column and columns without a matching field are ignored,
+// but a tag naming an unknown column is an error.
+//
+// Field values are converted as by Record.Scan. A NULL can also be scanned
+// into a pointer field, which is then set to nil; other values are scanned
+// into a newly allocated pointee.
+func (t TableInfo) ScanStruct(record Record, dest any) error {
+	v := reflect.ValueOf(dest)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("expected a non-nil pointer to a struct in ScanStruct, got %T", dest)
+	}
+	plan, err := newStructPlan(t, v.Elem().Type())
+	if err != nil {
+		return err
+	}
+	return plan.scan(record, v.Elem())
+}
+
+// ScanInto adapts an iterator over the records of a table, such as the one
+// returned by TableScan, into an iterator over values of the struct type T,
+// filled in as by TableInfo.ScanStruct. The mapping from columns to fields is
+// worked out once, before the first record is read.
+func ScanInto[T any](table TableInfo, records RecordIterator) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		typ := reflect.TypeFor[T]()
+		if typ.Kind() != reflect.Struct {
+			yield(zero, fmt.Errorf("expected a struct type in ScanInto, got %s", typ))
+			return
+		}
+		plan, err := newStructPlan(table, typ)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		for record, err := range records {
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			var value T
+			if err := plan.scan(record, reflect.ValueOf(&value).Elem()); err != nil {
+				yield(zero, err)
+				return
+			}
+			if !yield(value, nil) {
+				return
+			}
+		}
+	}
+}
+
+// structField is a struct field mapped to a column.
+type structField struct {
+	index  []int // As used by reflect.Value.FieldByIndex.
+	name   string
+	column int // Index in TableInfo.Columns, or rowIDColumn.
+}
+
+// structPlan records how the columns of a table map to the fields of a
+// struct type.
+type structPlan struct {
+	table  TableInfo
+	fields []structField
+}
+
+// newStructPlan works out which column each field of the struct type typ is
+// mapped to.
+func newStructPlan(table TableInfo, typ reflect.Type) (*structPlan, error) {
+	plan := &structPlan{table: table}
+	for _, f := range reflect.VisibleFields(typ) {
+		if !f.IsExported() || f.Anonymous && f.Type.Kind() == reflect.Struct || throughPointer(typ, f.Index) {
+			continue
+		}
+		tag, tagged := f.Tag.Lookup("golite")
+		if tag == "-" {
+			continue
+		}
+		name := f.Name
+		if tagged {
+			name = tag
+		}
+		column := table.lookupColumn(name)
+		if column == -1 {
+			if tagged {
+				return nil, fmt.Errorf("field %s: table %s has no column %q", f.Name, table.Name, tag)
+			}
+			continue
+		}
+		plan.fields = append(plan.fields, structField{index: f.Index, name: f.Name, column: column})
+	}
+	return plan, nil
+}
+
+// throughPointer reports whether the field at index in typ is promoted
+// through an embedded pointer, which ScanStruct does not allocate.
+func throughPointer(typ reflect.Type, index []int) bool {
+	for _, i := range index[:len(index)-1] {
+		f := typ.Field(i)
+		if f.Type.Kind() == reflect.Pointer {
+			return true
+		}
+		typ = f.Type
+	}
+	return false
+}
+
+// scan copies the values of record into the struct v.
+func (p *structPlan) scan(record Record, v reflect.Value) error {
+	for _, f := range p.fields {
+		if err := scanField(p.table.columnValue(record, f.column), v.FieldByIndex(f.index)); err != nil {
+			return fmt.Errorf("field %s: %w", f.name, err)
+		}
+	}
+	return nil
+}
+
+// scanField stores a single record value into a struct field.
+func scanField(src any, field reflect.Value) error {
+	if field.Kind() != reflect.Pointer {
+		return scanValue(src, field.Addr().Interface())
+	}
+	if isNull(src) {
+		field.SetZero()
+		return nil
+	}
+	ptr := reflect.New(field.Type().Elem())
+	if err := scanValue(src, ptr.Interface()); err != nil {
+		return err
+	}
+	field.Set(ptr)
+	return nil
+}
@@ -0,0 +1,120 @@
+package golite
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTableInfo_ScanStruct(t *testing.T) {
+	table := TableInfo{
+		Name: "people",
+		Columns: []ColumnInfo{
+			{Name: "name", Type: "TEXT"},
+			{Name: "Age", Type: "INTEGER"},
+			{Name: "email", Type: "TEXT"},
+			{Name: "score", Type: "REAL"},
+			{Name: "nick", Type: "TEXT"},
+		},
+		RowIDColumnIndex: -1,
+	}
+
+	type Base struct {
+		ID int64 `golite:"rowid"`
+	}
+	type Person struct {
+		Base
+		FullName string `golite:"name"`
+		AGE      int
+		Email    *string
+		Score    sql.NullFloat64
+		Nick     *string
+		Ignored  string `golite:"-"`
+		Extra    bool
+		private  string
+	}
+
+	t.Run("values and NULLs", func(t *testing.T) {
+		var p Person
+		record := Record{int64(7), "Ann", int64(31), SQLNull, 1.5, "annie"}
+		if err := table.ScanStruct(record, &p); err != nil {
+			t.Fatalf("ScanStruct() failed: %v", err)
+		}
+		nick := "annie"
+		want := Person{Base: Base{ID: 7}, FullName: "Ann", AGE: 31, Score: sql.NullFloat64{Float64: 1.5, Valid: true}, Nick: &nick}
+		if !reflect.DeepEqual(p, want) {
+			t.Errorf("expected %+v, got %+v", want, p)
+		}
+	})
+
+	t.Run("short record", func(t *testing.T) {
+		email := "old"
+		p := Person{Email: &email}
+		if err := table.ScanStruct(Record{int64(1), "Bob", int64(40)}, &p); err != nil {
+			t.Fatalf("ScanStruct() failed: %v", err)
+		}
+		if p.Email != nil || p.Score.Valid || p.FullName != "Bob" {
+			t.Errorf("expected missing columns to scan as NULL, got %+v", p)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		testCases := []struct {
+			name string
+			dest any
+			err  string
+		}{
+			{name: "not a pointer", dest: Person{}, err: "expected a non-nil pointer to a struct"},
+			{name: "unknown tag", dest: &struct {
+				X string `golite:"missing"`
+			}{}, err: `field X: table people has no column "missing"`},
+			{name: "conversion", dest: &struct{ Name int }{}, err: "field Name: "},
+		}
+		for _, tc := range testCases {
+			t.Run(tc.name, func(t *testing.T) {
+				err := table.ScanStruct(Record{int64(1), "Ann", int64(31), SQLNull, 1.5, SQLNull}, tc.dest)
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Errorf("expected an error containing %q, got %v", tc.err, err)
+				}
+			})
+		}
+	})
+}
+
+func TestScanInto(t *testing.T) {
+	dbPath := createTestDB(t, "scan_into_test.sqlite")
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	table, err := db.Table("test")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+
+	type row struct {
+		ID   int64
+		Name string
+	}
+	count := 0
+	for r, err := range ScanInto[row](table, db.TableScan(table)) {
+		if err != nil {
+			t.Fatalf("ScanInto() returned an unexpected error: %v", err)
+		}
+		count++
+		if want := (row{ID: int64(count), Name: "name" + formatScalar(int64(count))}); r != want {
+			t.Fatalf("expected %+v, got %+v", want, r)
+		}
+	}
+	if count != 500 {
+		t.Errorf("expected 500 rows, got %d", count)
+	}
+
+	for _, err := range ScanInto[int](table, db.TableScan(table)) {
+		if err == nil {
+			t.Errorf("expected an error for a non-struct type")
+		}
+	}
+}
@@ -0,0 +1,173 @@
+// Package tempstore manages the temporary files used by operators that spill
+// data they cannot keep in memory.
+//
+// A Store creates the files, keeps track of them and removes those that are
+// still open when it is closed, so that an operator failing half-way through
+// does not leak files. Stores created with NewMemory keep their files in
+// memory, which suits tests and environments without a writable disk.
+package tempstore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// ErrClosed is returned when creating or using a file of a closed Store.
+var ErrClosed = errors.New("tempstore: store is closed")
+
+// File is a temporary file. Data is appended with Write and read back at any
+// offset with ReadAt. Closing a File deletes it.
+type File interface {
+	io.Writer
+	io.ReaderAt
+	// Size returns the number of bytes written to the file.
+	Size() int64
+	// Close deletes the file. It is safe to call more than once.
+	Close() error
+}
+
+// Store creates and tracks temporary files. It is safe for concurrent use.
+type Store struct {
+	dir    string // Unused for memory stores.
+	memory bool
+
+	mu     sync.Mutex
+	files  map[File]struct{}
+	closed bool
+}
+
+// New returns a Store creating its files in dir, or in the default directory
+// for temporary files if dir is "".
+func New(dir string) *Store {
+	return &Store{dir: dir, files: map[File]struct{}{}}
+}
+
+// NewMemory returns a Store keeping its files in memory.
+func NewMemory() *Store {
+	return &Store{memory: true, files: map[File]struct{}{}}
+}
+
+// Create returns a new, empty temporary file.
+func (s *Store) Create() (File, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil, ErrClosed
+	}
+	var f File
+	if s.memory {
+		f = &memoryFile{store: s}
+	} else {
+		osFile, err := os.CreateTemp(s.dir, "golite-*.tmp")
+		if err != nil {
+			return nil, fmt.Errorf("tempstore: failed to create file: %w", err)
+		}
+		f = &diskFile{store: s, file: osFile}
+	}
+	s.files[f] = struct{}{}
+	return f, nil
+}
+
+// Len returns the number of files created by the store that are still open.
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.files)
+}
+
+// Close deletes all the files of the store that are still open. Files cannot
+// be created once the store is closed.
+func (s *Store) Close() error {
+	s.mu.Lock()
+	files := make([]File, 0, len(s.files))
+	for f := range s.files {
+		files = append(files, f)
+	}
+	s.closed = true
+	s.mu.Unlock()
+
+	var errs []error
+	for _, f := range files {
+		errs = append(errs, f.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// forget stops tracking a closed file, reporting whether it was tracked.
+func (s *Store) forget(f File) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.files[f]
+	delete(s.files, f)
+	return ok
+}
+
+// diskFile is a File stored on disk.
+type diskFile struct {
+	store *Store
+	file  *os.File
+	size  int64
+}
+
+func (f *diskFile) Write(p []byte) (int, error) {
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *diskFile) ReadAt(p []byte, off int64) (int, error) {
+	return f.file.ReadAt(p, off)
+}
+
+func (f *diskFile) Size() int64 {
+	return f.size
+}
+
+func (f *diskFile) Close() error {
+	if !f.store.forget(f) {
+		return nil
+	}
+	err := f.file.Close()
+	if removeErr := os.Remove(f.file.Name()); removeErr != nil && err == nil {
+		err = fmt.Errorf("tempstore: failed to remove file: %w", removeErr)
+	}
+	return err
+}
+
+// memoryFile is a File held in memory.
+type memoryFile struct {
+	store *Store
+	data  []byte
+}
+
+func (f *memoryFile) Write(p []byte) (int, error) {
+	f.data = append(f.data, p...)
+	return len(p), nil
+}
+
+func (f *memoryFile) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("tempstore: negative offset")
+	}
+	if off >= int64(len(f.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *memoryFile) Size() int64 {
+	return int64(len(f.data))
+}
+
+func (f *memoryFile) Close() error {
+	f.store.forget(f)
+	f.data = nil
+	return nil
+}
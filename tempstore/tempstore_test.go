@@ -0,0 +1,78 @@
+package tempstore
+
+import (
+	"errors"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	stores := map[string]func(t *testing.T) *Store{
+		"disk":   func(t *testing.T) *Store { return New(t.TempDir()) },
+		"memory": func(t *testing.T) *Store { return NewMemory() },
+	}
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			store := newStore(t)
+			f, err := store.Create()
+			if err != nil {
+				t.Fatalf("Create() failed: %v", err)
+			}
+			for _, s := range []string{"hello, ", "world"} {
+				if _, err := io.WriteString(f, s); err != nil {
+					t.Fatalf("Write() failed: %v", err)
+				}
+			}
+			if f.Size() != 12 {
+				t.Errorf("expected size 12, got %d", f.Size())
+			}
+			buf := make([]byte, 5)
+			if n, err := f.ReadAt(buf, 7); n != 5 || err != nil || string(buf) != "world" {
+				t.Errorf("ReadAt() = (%d, %v, %q), want (5, nil, \"world\")", n, err, buf)
+			}
+			if _, err := f.ReadAt(buf, 10); err != io.EOF {
+				t.Errorf("expected io.EOF reading past the end, got %v", err)
+			}
+
+			if _, err := store.Create(); err != nil {
+				t.Fatalf("Create() failed: %v", err)
+			}
+			if err := f.Close(); err != nil {
+				t.Errorf("Close() failed: %v", err)
+			}
+			if err := f.Close(); err != nil {
+				t.Errorf("second Close() failed: %v", err)
+			}
+			if store.Len() != 1 {
+				t.Errorf("expected 1 open file, got %d", store.Len())
+			}
+			if err := store.Close(); err != nil {
+				t.Errorf("store Close() failed: %v", err)
+			}
+			if store.Len() != 0 {
+				t.Errorf("expected the store to close its files, got %d open", store.Len())
+			}
+			if _, err := store.Create(); !errors.Is(err, ErrClosed) {
+				t.Errorf("expected ErrClosed, got %v", err)
+			}
+		})
+	}
+
+	t.Run("disk files are removed", func(t *testing.T) {
+		dir := t.TempDir()
+		store := New(dir)
+		for range 3 {
+			if _, err := store.Create(); err != nil {
+				t.Fatalf("Create() failed: %v", err)
+			}
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != 3 {
+			t.Errorf("expected 3 files, got %d", len(entries))
+		}
+		store.Close()
+		if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+			t.Errorf("expected no files left, got %d", len(entries))
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package golite
+
+import (
+	"encoding/binary"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// WithUTF8Text makes a database whose text encoding is UTF-16 present its
+// TEXT values as UTF-8, like those of a UTF-8 database: the values of
+// records, the SQL of the schema, and so what exports and dumps write. Keys
+// passed to IndexSeek, IndexScanRange and the other index searches are
+// UTF-8 too, and are compared with the keys of the index in the order of
+// the file, so that searches find what SQLite would. Other comparisons of
+// TEXT values, for instance by filters, are in code point order.
+//
+// Without it, the TEXT values of a UTF-16 database are strings holding the
+// bytes of the file, as Header.TextEncoding describes them. The option has
+// no effect on UTF-8 databases.
+func WithUTF8Text() OpenOption {
+	return func(db *Database) {
+		db.utf8Text = true
+	}
+}
+
+// utf16Order returns the byte order of the TEXT values of the database if
+// they are stored in UTF-16 and must be presented as UTF-8, and nil
+// otherwise.
+func (db *Database) utf16Order() binary.ByteOrder {
+	if !db.utf8Text {
+		return nil
+	}
+	switch db.Header.TextEncoding {
+	case 2:
+		return binary.LittleEndian
+	case 3:
+		return binary.BigEndian
+	}
+	return nil
+}
+
+// decodeText replaces the UTF-16 TEXT values of a record read from the
+// database with their UTF-8 form, if the database presents them so.
+func (db *Database) decodeText(record Record) {
+	order := db.utf16Order()
+	if order == nil {
+		return
+	}
+	for i, v := range record {
+		if s, ok := v.(string); ok {
+			record[i] = decodeUTF16(s, order)
+		}
+	}
+}
+
+// decodeUTF16 returns the UTF-8 form of s, which holds UTF-16 in the given
+// byte order. Unpaired surrogates become U+FFFD, and a trailing odd byte is
+// dropped, as SQLite does.
+func decodeUTF16(s string, order binary.ByteOrder) string {
+	units := make([]uint16, len(s)/2)
+	for i := range units {
+		units[i] = order.Uint16([]byte(s[2*i : 2*i+2]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// utf16Key returns s, a UTF-8 string, encoded in UTF-16 in the given byte
+// order, so that keys compare like the values of an index of a UTF-16
+// database. Invalid bytes become 0xFFFF, the largest code unit, so that the
+// bound indexScanPrefix puts after a prefix stays above all the values
+// starting with it.
+func utf16Key(s string, order binary.ByteOrder) string {
+	var b strings.Builder
+	b.Grow(2 * len(s))
+	var unit [2]byte
+	put := func(u uint16) {
+		order.PutUint16(unit[:], u)
+		b.Write(unit[:])
+	}
+	for len(s) > 0 {
+		r, n := utf8.DecodeRuneInString(s)
+		s = s[n:]
+		switch {
+		case r == utf8.RuneError && n == 1:
+			put(0xffff)
+		case r >= 0x10000:
+			r1, r2 := utf16.EncodeRune(r)
+			put(uint16(r1))
+			put(uint16(r2))
+		default:
+			put(uint16(r))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,80 @@
+package golite
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithUTF8Text(t *testing.T) {
+	values := []string{"a", "b", "ab", "ā", "āb", "ÿ", "", "😀", "日本"}
+	var inserts strings.Builder
+	for i, v := range values {
+		inserts.WriteString("INSERT INTO t VALUES (" + quoteLiteral(int64(i+1)) + ", " + quoteLiteral(v) + ");\n")
+	}
+	for _, encoding := range []string{"UTF-16le", "UTF-16be", "UTF-8"} {
+		t.Run(encoding, func(t *testing.T) {
+			dbPath := filepath.Join(t.TempDir(), "text.sqlite")
+			runSQL(t, dbPath, "PRAGMA encoding = '"+encoding+"';\n"+
+				"CREATE TABLE t (id INTEGER PRIMARY KEY, ñame TEXT);\nCREATE INDEX t_ñame ON t (ñame);\n"+inserts.String())
+			db, err := Open(dbPath, WithUTF8Text())
+			if err != nil {
+				t.Fatalf("Open() failed with error: %v", err)
+			}
+			defer db.Close()
+
+			table, err := db.Table("t")
+			if err != nil {
+				t.Fatalf("Table() failed: %v", err)
+			}
+			if !strings.Contains(table.SQL, "ñame TEXT") || table.Columns[1].Name != "ñame" {
+				t.Errorf("unexpected schema %q", table.SQL)
+			}
+			records, err := CollectRecords(db.TableScan(table))
+			if err != nil {
+				t.Fatalf("TableScan() failed: %v", err)
+			}
+			for i, record := range records {
+				if record[1] != values[i] {
+					t.Errorf("row %d = %q, want %q", i+1, record[1], values[i])
+				}
+			}
+
+			index := *table.Indexes[0]
+			for _, v := range values {
+				got, err := CollectRecords(db.IndexSeek(index, Record{v}))
+				if err != nil || len(got) != 1 || got[0][0] != v {
+					t.Errorf("IndexSeek(%q) = %v, %v", v, got, err)
+				}
+			}
+			got, err := CollectRecords(db.IndexScanLike(index, "ā%", 0))
+			if err != nil || !reflect.DeepEqual(got, []Record{{"ā", int64(4)}, {"āb", int64(5)}}) {
+				t.Errorf("IndexScanLike() = %v, %v", got, err)
+			}
+
+			var dump bytes.Buffer
+			if err := db.Dump(&dump); err != nil {
+				t.Fatalf("Dump() failed: %v", err)
+			}
+			if !strings.Contains(dump.String(), "'😀'") {
+				t.Errorf("the dump does not hold the UTF-8 values:\n%s", dump.String())
+			}
+		})
+	}
+
+	t.Run("without the option", func(t *testing.T) {
+		dbPath := filepath.Join(t.TempDir(), "raw.sqlite")
+		runSQL(t, dbPath, "PRAGMA encoding = 'UTF-16be';\nCREATE TABLE t (x TEXT);\n")
+		db, err := Open(dbPath)
+		if err != nil {
+			t.Fatalf("Open() failed with error: %v", err)
+		}
+		defer db.Close()
+		got, err := CollectRecords(db.TableScan(SchemaTable()))
+		if err != nil || len(got) != 1 || got[0][2] != "\x00t" {
+			t.Errorf("TableScan() = %q, %v, want the bytes of the file", got, err)
+		}
+	})
+}
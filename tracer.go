@@ -0,0 +1,82 @@
+package golite
+
+// PageReadKind tells where the data of a page read by a Database came from.
+type PageReadKind int
+
+const (
+	// PageReadFile means the page was read from the file on its own.
+	PageReadFile PageReadKind = iota
+	// PageReadBatch means the page was read from the file together with
+	// adjacent pages, in a single read.
+	PageReadBatch
+	// PageReadCache means the page was found in the page cache.
+	PageReadCache
+)
+
+// String returns a short lowercase description of the kind.
+func (k PageReadKind) String() string {
+	switch k {
+	case PageReadFile:
+		return "file"
+	case PageReadBatch:
+		return "batch"
+	case PageReadCache:
+		return "cache"
+	}
+	return "unknown"
+}
+
+// Tracer receives notifications of the work done by a Database, to find out
+// which pages a query touched and why. Its methods may be called concurrently
+// by ParallelScan workers and background prefetches, and should return
+// quickly as they are called inline.
+type Tracer interface {
+	// OnPageRead is called for each page read, before it is parsed.
+	OnPageRead(pageNum int, kind PageReadKind)
+	// OnSeek is called when TableSeek, IndexSeek or IndexScanRange starts
+	// descending the B-Tree rooted at page root, with the key searched for:
+	// the rowid for a TableSeek, the key after affinity conversions for an
+	// IndexSeek, and the lower bound, if any, for an IndexScanRange.
+	OnSeek(root int, key Record)
+	// OnRecordDecoded is called for each record decoded from a cell of page
+	// pageNum. The record must not be modified or retained.
+	OnRecordDecoded(pageNum int, record Record)
+}
+
+// WithTracer makes the database report the pages it reads, the seeks it
+// performs and the records it decodes to t.
+func WithTracer(t Tracer) OpenOption {
+	return func(db *Database) {
+		db.tracer = t
+	}
+}
+
+// tracePageRead reports a page read to the tracer, if any.
+func (db *Database) tracePageRead(pageNum int, kind PageReadKind) {
+	if db.tracer != nil {
+		db.tracer.OnPageRead(pageNum, kind)
+	}
+}
+
+// traceSeek reports a seek to the tracer, if any.
+func (db *Database) traceSeek(root int, key Record) {
+	if db.tracer != nil {
+		db.tracer.OnSeek(root, key)
+	}
+}
+
+// traceRecords reports the records decoded from a page to the tracer, if any.
+func (db *Database) traceRecords(page *Page) {
+	if db.tracer == nil {
+		return
+	}
+	for _, cell := range page.LeafCells {
+		db.tracer.OnRecordDecoded(page.pageNum, cell.Record)
+	}
+	for _, cell := range page.LeafIndexCells {
+		db.tracer.OnRecordDecoded(page.pageNum, cell.Payload)
+	}
+	for _, cell := range page.InteriorIndexCells {
+		db.tracer.OnRecordDecoded(page.pageNum, cell.Payload)
+	}
+}
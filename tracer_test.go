@@ -0,0 +1,90 @@
+package golite
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// recordingTracer records the events it receives.
+type recordingTracer struct {
+	mu      sync.Mutex
+	reads   map[PageReadKind][]int
+	seeks   []Record
+	records int
+}
+
+func (r *recordingTracer) OnPageRead(pageNum int, kind PageReadKind) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.reads == nil {
+		r.reads = make(map[PageReadKind][]int)
+	}
+	r.reads[kind] = append(r.reads[kind], pageNum)
+}
+
+func (r *recordingTracer) OnSeek(root int, key Record) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seeks = append(r.seeks, append(Record{int64(root)}, key...))
+}
+
+func (r *recordingTracer) OnRecordDecoded(pageNum int, record Record) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.records++
+}
+
+func TestWithTracer(t *testing.T) {
+	dbPath := createTestDB(t, "tracer_test.sqlite")
+	tracer := &recordingTracer{}
+	db, err := Open(dbPath, WithTracer(tracer), WithPageCache(100))
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	table, err := db.Table("test")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+	index, err := db.Index("idx_name")
+	if err != nil {
+		t.Fatalf("Index() failed: %v", err)
+	}
+
+	t.Run("seeks", func(t *testing.T) {
+		*tracer = recordingTracer{}
+		for range db.TableSeek(table, 42) {
+		}
+		for range db.IndexSeek(index, Record{"name42"}) {
+		}
+		want := []Record{{int64(table.RootPage), int64(42)}, {int64(index.RootPage), "name42"}}
+		if !reflect.DeepEqual(tracer.seeks, want) {
+			t.Errorf("expected seeks %v, got %v", want, tracer.seeks)
+		}
+		// Each seek reads the root page, then one leaf page.
+		if got := tracer.reads[PageReadFile]; len(got) != 4 || got[0] != table.RootPage || got[2] != index.RootPage {
+			t.Errorf("expected a descent of two pages per seek, got %v", got)
+		}
+	})
+
+	t.Run("scans", func(t *testing.T) {
+		*tracer = recordingTracer{}
+		for range db.TableScan(table) {
+		}
+		if tracer.records != 500 {
+			t.Errorf("expected 500 records decoded, got %d", tracer.records)
+		}
+		if len(tracer.reads[PageReadBatch]) == 0 {
+			t.Errorf("expected the leaf pages to be read in batches, got %v", tracer.reads)
+		}
+		cold := len(tracer.reads[PageReadBatch]) + len(tracer.reads[PageReadFile]) + len(tracer.reads[PageReadCache])
+
+		*tracer = recordingTracer{}
+		for range db.TableScan(table) {
+		}
+		if got := len(tracer.reads[PageReadCache]); got != cold || len(tracer.reads) != 1 {
+			t.Errorf("expected all %d pages to come from the cache, got %v", cold, tracer.reads)
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package golite
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// IndexLookup is a search of an index for the records whose leading columns
+// equal the values of Key, as with IndexSeek.
+type IndexLookup struct {
+	Index IndexInfo
+	Key   Record
+}
+
+// UnionLookup returns an iterator over the rows of table matched by any of
+// the lookups, each row being yielded once, in rowid order or, for a WITHOUT
+// ROWID table, in primary key order. It answers conditions such as
+// "a = ? OR b = ?" with one seek per index rather than a full scan: the rowids
+// found by each lookup are sorted, merged without duplicates, and the rows
+// are then read with TableSeek, or PrimaryKeySeek for a WITHOUT ROWID table.
+// All the lookups are run before the first row is yielded.
+func (db *Database) UnionLookup(table TableInfo, lookups ...IndexLookup) RecordIterator {
+	return interruptible(db, func(yield func(Record, error) bool) {
+		lists := make([][]Record, len(lookups))
+		for i, lookup := range lookups {
+			keys, err := db.lookupRowKeys(table, lookup)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			lists[i] = keys
+		}
+		for _, key := range mergeRowKeys(lists) {
+			var rows RecordIterator
+			if table.WithoutRowID {
+				rows = db.PrimaryKeySeek(table, key)
+			} else {
+				rows = db.TableSeek(table, key[0].(int64))
+			}
+			for row, err := range rows {
+				if !yield(row, err) || err != nil {
+					return
+				}
+			}
+		}
+	})
+}
+
+// lookupRowKeys runs a lookup and returns the keys of the rows it matches, in
+// increasing order: the rowid, or the primary key of a WITHOUT ROWID table.
+func (db *Database) lookupRowKeys(table TableInfo, lookup IndexLookup) ([]Record, error) {
+	index := lookup.Index
+	if !strings.EqualFold(index.TableName, table.Name) {
+		return nil, fmt.Errorf("%s is not an index of table %s", index.Name, table.Name)
+	}
+	if len(lookup.Key) > len(index.Columns) {
+		return nil, fmt.Errorf("key has %d values, index %s has %d columns", len(lookup.Key), index.Name, len(index.Columns))
+	}
+	var positions []int
+	if table.WithoutRowID {
+		for _, col := range table.PrimaryKey {
+			pos := table.indexPosition(index, col.Name)
+			if pos == -1 {
+				return nil, fmt.Errorf("index %s does not hold the primary key of %s", index.Name, table.Name)
+			}
+			positions = append(positions, pos)
+		}
+	}
+
+	var keys []Record
+	for record, err := range db.indexEqualRange(index, index.coerceKey(lookup.Key)) {
+		if err != nil {
+			return nil, err
+		}
+		if !table.WithoutRowID {
+			rowID, ok := record[len(record)-1].(int64)
+			if !ok {
+				return nil, fmt.Errorf("index %s holds a non-integer rowid", index.Name)
+			}
+			keys = append(keys, Record{rowID})
+			continue
+		}
+		rowKey := make(Record, len(positions))
+		for i, pos := range positions {
+			if pos >= len(record) {
+				return nil, fmt.Errorf("index %s does not hold the primary key of %s", index.Name, table.Name)
+			}
+			rowKey[i] = record[pos]
+		}
+		keys = append(keys, rowKey)
+	}
+	slices.SortFunc(keys, CompareRecords)
+	return keys, nil
+}
+
+// mergeRowKeys merges sorted lists of row keys into one sorted list without
+// duplicates.
+func mergeRowKeys(lists [][]Record) []Record {
+	var merged []Record
+	positions := make([]int, len(lists))
+	for {
+		// Find the smallest key at the head of a list.
+		var next Record
+		for i, list := range lists {
+			if positions[i] < len(list) && (next == nil || CompareRecords(list[positions[i]], next) < 0) {
+				next = list[positions[i]]
+			}
+		}
+		if next == nil {
+			return merged
+		}
+		merged = append(merged, next)
+		// Skip it in every list, along with duplicates within a list.
+		for i, list := range lists {
+			for positions[i] < len(list) && CompareRecords(list[positions[i]], next) == 0 {
+				positions[i]++
+			}
+		}
+	}
+}
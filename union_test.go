@@ -0,0 +1,97 @@
+package golite
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestUnionLookup(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "union.sqlite")
+	runSQL(t, dbPath, `
+		CREATE TABLE t (a INTEGER PRIMARY KEY, b TEXT, c INTEGER);
+		CREATE INDEX t_b ON t(b);
+		CREATE INDEX t_c ON t(c);
+		WITH RECURSIVE seq(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM seq WHERE i < 3000)
+		INSERT INTO t SELECT i, 'n' || (i % 40), i % 50 FROM seq;
+		CREATE TABLE w (k TEXT, j INTEGER, v TEXT, PRIMARY KEY (k, j)) WITHOUT ROWID;
+		CREATE INDEX w_v ON w(v);
+		CREATE INDEX w_j ON w(j);
+		INSERT INTO w SELECT 'k' || (a % 13), a, 'v' || (a % 5) FROM t WHERE a <= 400;
+		CREATE TABLE expected (name TEXT, keys TEXT);
+		INSERT INTO expected SELECT 't', group_concat(a) FROM (SELECT a FROM t WHERE b = 'n3' OR c = 5 OR c = '5' ORDER BY a);
+		INSERT INTO expected SELECT 'w', group_concat(k || ':' || j) FROM (SELECT k, j FROM w WHERE v = 'v2' OR j = 17 ORDER BY k, j);
+	`)
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	expected := map[string]string{}
+	table, err := db.Table("expected")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+	for record, err := range db.TableScan(table) {
+		if err != nil {
+			t.Fatalf("TableScan() failed: %v", err)
+		}
+		values := table.ColumnValues(record)
+		expected[values[0].(string)] = values[1].(string)
+	}
+	index := func(name string) IndexInfo {
+		index, err := db.Index(name)
+		if err != nil {
+			t.Fatalf("Index() failed: %v", err)
+		}
+		return index
+	}
+
+	tTable, _ := db.Table("t")
+	var got []string
+	for row, err := range db.UnionLookup(tTable,
+		IndexLookup{index("t_b"), Record{"n3"}},
+		IndexLookup{index("t_c"), Record{int64(5)}},
+		IndexLookup{index("t_c"), Record{"5"}},
+	) {
+		if err != nil {
+			t.Fatalf("UnionLookup() failed: %v", err)
+		}
+		got = append(got, formatScalar(row[0]))
+	}
+	if len(got) == 0 || strings.Join(got, ",") != expected["t"] {
+		t.Errorf("UnionLookup(t) = %s, want %s", strings.Join(got, ","), expected["t"])
+	}
+
+	w, _ := db.Table("w")
+	got = nil
+	for row, err := range db.UnionLookup(w, IndexLookup{index("w_v"), Record{"v2"}}, IndexLookup{index("w_j"), Record{int64(17)}}) {
+		if err != nil {
+			t.Fatalf("UnionLookup() failed: %v", err)
+		}
+		got = append(got, row[0].(string)+":"+formatScalar(row[1]))
+	}
+	if len(got) == 0 || strings.Join(got, ",") != expected["w"] {
+		t.Errorf("UnionLookup(w) = %s, want %s", strings.Join(got, ","), expected["w"])
+	}
+
+	if _, err := CollectRecords(db.UnionLookup(w, IndexLookup{index("t_b"), Record{"n3"}})); err == nil {
+		t.Errorf("expected an error for an index of another table")
+	}
+	if rows, err := CollectRecords(db.UnionLookup(tTable)); err != nil || rows != nil {
+		t.Errorf("expected no rows without lookups, got (%v, %v)", rows, err)
+	}
+}
+
+func TestMergeRowKeys(t *testing.T) {
+	lists := [][]Record{
+		{{int64(1)}, {int64(3)}, {int64(3)}, {int64(7)}},
+		{},
+		{{int64(2)}, {int64(3)}, {int64(9)}},
+	}
+	want := []Record{{int64(1)}, {int64(2)}, {int64(3)}, {int64(7)}, {int64(9)}}
+	if got := mergeRowKeys(lists); !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeRowKeys() = %v, want %v", got, want)
+	}
+}
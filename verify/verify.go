@@ -0,0 +1,624 @@
+// Package verify cross-checks what golite reads from a database against the
+// output of the sqlite3 command-line shell, to build confidence that golite
+// reads a given database correctly.
+//
+// For each table, the number of rows and their values are compared, matching
+// rows on rowid, or on their values for WITHOUT ROWID tables. For each index,
+// the entries read by IndexScan are compared with those SQLite reads from the
+// index, and a sample of keys is looked up with IndexSeek, comparing the
+// number of matches with that SQLite finds through the index. Values are
+// compared exactly, with their storage class: 1 and 1.0 differ. Only the
+// values of REAL columns that SQLite stores as integers, and which golite
+// reads as they are stored, are first converted to reals, as SQLite does.
+package verify
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os/exec"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/arnodel/golite"
+)
+
+// options holds the settings of Verify.
+type options struct {
+	sqlite         string
+	tables         []string
+	lookups        int
+	maxDivergences int
+}
+
+// Option configures Verify.
+type Option func(*options)
+
+// WithSQLite sets the path of the sqlite3 binary to run. The default is
+// "sqlite3", looked up in the PATH.
+func WithSQLite(path string) Option {
+	return func(o *options) {
+		o.sqlite = path
+	}
+}
+
+// WithTables restricts the checks to the named tables and their indexes. By
+// default, all the tables are checked, except the internal sqlite_ ones and
+// virtual tables.
+func WithTables(names ...string) Option {
+	return func(o *options) {
+		o.tables = names
+	}
+}
+
+// WithLookups sets the number of keys looked up in each index. The default
+// is 100.
+func WithLookups(n int) Option {
+	return func(o *options) {
+		o.lookups = n
+	}
+}
+
+// WithMaxDivergences sets the number of divergences reported, beyond which
+// they are only counted. The default is 100.
+func WithMaxDivergences(n int) Option {
+	return func(o *options) {
+		o.maxDivergences = n
+	}
+}
+
+// Check names what was compared when a divergence was found.
+type Check string
+
+const (
+	// CheckCount compares the number of rows of a table.
+	CheckCount Check = "count"
+	// CheckRow compares the values of a row of a table.
+	CheckRow Check = "row"
+	// CheckIndexEntry compares the entries of an index.
+	CheckIndexEntry Check = "index entry"
+	// CheckLookup compares the number of entries of an index matching a key.
+	CheckLookup Check = "lookup"
+)
+
+// Divergence is a difference between what golite and SQLite read.
+type Divergence struct {
+	Check Check
+	Table string
+	// Index is the name of the index checked, for CheckIndexEntry and
+	// CheckLookup.
+	Index string
+	// RowID is the rowid of the row, for CheckRow on a table with a rowid,
+	// and Page the leaf page where golite finds, or would expect to find, a
+	// row with that rowid. They are 0 otherwise.
+	RowID int64
+	Page  int
+	// Golite and SQLite describe what each read: the values of the row or
+	// entry, or "" if it did not read it, or a count.
+	Golite, SQLite string
+}
+
+// String describes the divergence on one line.
+func (d Divergence) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: table %s", d.Check, d.Table)
+	if d.Index != "" {
+		fmt.Fprintf(&b, ", index %s", d.Index)
+	}
+	if d.RowID != 0 || d.Page != 0 {
+		fmt.Fprintf(&b, ", rowid %d, page %d", d.RowID, d.Page)
+	}
+	fmt.Fprintf(&b, ": golite %s, sqlite %s", describe(d.Golite), describe(d.SQLite))
+	return b.String()
+}
+
+func describe(s string) string {
+	if s == "" {
+		return "(missing)"
+	}
+	return s
+}
+
+// Report is the result of Verify.
+type Report struct {
+	Tables, Indexes int
+	// Rows, Entries and Lookups are the numbers of rows, index entries and
+	// index lookups compared.
+	Rows, Entries, Lookups int
+	Divergences            []Divergence
+	// Omitted is the number of divergences found beyond those reported.
+	Omitted int
+	// Skipped lists the indexes that were not checked, on expressions or
+	// partial.
+	Skipped []string
+}
+
+// OK reports whether no divergence was found.
+func (r *Report) OK() bool {
+	return len(r.Divergences) == 0 && r.Omitted == 0
+}
+
+// WriteText writes a summary of the report followed by its divergences.
+func (r *Report) WriteText(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d tables, %d rows; %d indexes, %d entries, %d lookups\n", r.Tables, r.Rows, r.Indexes, r.Entries, r.Lookups)
+	for _, name := range r.Skipped {
+		fmt.Fprintf(&b, "skipped index %s\n", name)
+	}
+	for _, d := range r.Divergences {
+		fmt.Fprintln(&b, d)
+	}
+	if r.Omitted > 0 {
+		fmt.Fprintf(&b, "... and %d more divergences\n", r.Omitted)
+	}
+	if r.OK() {
+		b.WriteString("no divergences\n")
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// Verify opens the database at path with golite and compares what it reads
+// with what the sqlite3 shell reads from the same file. Errors running
+// sqlite3 or reading the database with golite are returned as errors, not
+// divergences.
+func Verify(path string, opts ...Option) (*Report, error) {
+	o := options{sqlite: "sqlite3", lookups: 100, maxDivergences: 100}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	tracer := &leafTracer{}
+	db, err := golite.Open(path, golite.WithTracer(tracer))
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	schema, err := db.GetSchema()
+	if err != nil {
+		return nil, err
+	}
+	v := &verifier{db: db, path: path, opts: o, tracer: tracer, report: &Report{}}
+
+	tables := o.tables
+	if tables == nil {
+		for name, table := range schema.Tables {
+			if !strings.HasPrefix(name, "sqlite_") && table.RootPage > 0 {
+				tables = append(tables, name)
+			}
+		}
+		sort.Strings(tables)
+	}
+	for _, name := range tables {
+		table, err := db.Table(name)
+		if err != nil {
+			return nil, err
+		}
+		if table.RootPage == 0 {
+			return nil, fmt.Errorf("cannot verify virtual table %s", table.Name)
+		}
+		if err := v.verifyTable(table); err != nil {
+			return nil, err
+		}
+		var indexes []golite.IndexInfo
+		for _, index := range schema.Indexes {
+			if strings.EqualFold(index.TableName, table.Name) {
+				indexes = append(indexes, index)
+			}
+		}
+		sort.Slice(indexes, func(i, j int) bool { return indexes[i].Name < indexes[j].Name })
+		for _, index := range indexes {
+			if err := v.verifyIndex(table, index); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return v.report, nil
+}
+
+// verifier holds the state of a Verify.
+type verifier struct {
+	db     *golite.Database
+	path   string
+	opts   options
+	tracer *leafTracer
+	report *Report
+}
+
+// diverge adds a divergence to the report.
+func (v *verifier) diverge(d Divergence) {
+	if len(v.report.Divergences) < v.opts.maxDivergences {
+		v.report.Divergences = append(v.report.Divergences, d)
+	} else {
+		v.report.Omitted++
+	}
+}
+
+// query runs SQL statements with sqlite3 on the database and returns the
+// lines of their output.
+func (v *verifier) query(sql string) ([]string, error) {
+	cmd := exec.Command(v.opts.sqlite, "-readonly", "-batch", "-bail", "-list", "-noheader", "-separator", "|", v.path)
+	cmd.Stdin = strings.NewReader(sql)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %w: %s", v.opts.sqlite, err, strings.TrimSpace(stderr.String()))
+	}
+	if len(output) == 0 {
+		return nil, nil
+	}
+	return strings.Split(strings.TrimSuffix(string(output), "\n"), "\n"), nil
+}
+
+// verifyTable compares the rows of a table.
+func (v *verifier) verifyTable(table golite.TableInfo) error {
+	v.report.Tables++
+	columns := make([]string, len(table.Columns))
+	for i, col := range table.Columns {
+		columns[i] = encodeExpr(quoteIdentifier(col.Name))
+	}
+	from := quoteIdentifier(table.Name)
+	sql := fmt.Sprintf("SELECT count(*) FROM %s;\n", from)
+	if table.WithoutRowID {
+		sql += fmt.Sprintf("SELECT %s FROM %s;\n", strings.Join(columns, ", "), from)
+	} else {
+		sql += fmt.Sprintf("SELECT _rowid_, %s FROM %s ORDER BY _rowid_;\n", strings.Join(columns, ", "), from)
+	}
+	lines, err := v.query(sql)
+	if err != nil {
+		return err
+	}
+	if len(lines) == 0 {
+		return errors.New("no output from sqlite3")
+	}
+	count, expected := lines[0], lines[1:]
+
+	var rows []string
+	for record, err := range v.db.TableScan(table) {
+		if err != nil {
+			return err
+		}
+		values := slices.Clone(table.ColumnValues(record))
+		for i, col := range table.Columns {
+			if i < len(values) {
+				values[i] = asStored(col, values[i])
+			}
+		}
+		row := encodeRecord(values)
+		if !table.WithoutRowID {
+			row = strconv.FormatInt(table.RowID(record), 10) + "|" + row
+		}
+		rows = append(rows, row)
+	}
+	v.report.Rows += len(rows)
+	if strconv.Itoa(len(rows)) != count {
+		v.diverge(Divergence{Check: CheckCount, Table: table.Name, Golite: strconv.Itoa(len(rows)), SQLite: count})
+	}
+
+	if table.WithoutRowID {
+		for _, d := range compareSets(rows, expected) {
+			d.Check, d.Table = CheckRow, table.Name
+			v.diverge(d)
+		}
+		return nil
+	}
+	// The rows are prefixed with their rowid, which is not displayed.
+	rowID := func(row string) int64 {
+		id, _ := strconv.ParseInt(row[:strings.IndexByte(row, '|')], 10, 64)
+		return id
+	}
+	display := func(row string) string {
+		return displayRow(row[strings.IndexByte(row, '|')+1:])
+	}
+	for len(rows) > 0 || len(expected) > 0 {
+		d := Divergence{Check: CheckRow, Table: table.Name}
+		switch {
+		case len(expected) == 0 || len(rows) > 0 && rowID(rows[0]) < rowID(expected[0]):
+			d.RowID, d.Golite = rowID(rows[0]), display(rows[0])
+			rows = rows[1:]
+		case len(rows) == 0 || rowID(expected[0]) < rowID(rows[0]):
+			d.RowID, d.SQLite = rowID(expected[0]), display(expected[0])
+			expected = expected[1:]
+		case rows[0] != expected[0]:
+			d.RowID, d.Golite, d.SQLite = rowID(rows[0]), display(rows[0]), display(expected[0])
+			rows, expected = rows[1:], expected[1:]
+		default:
+			rows, expected = rows[1:], expected[1:]
+			continue
+		}
+		page, err := v.leafPage(table, d.RowID)
+		if err != nil {
+			return err
+		}
+		d.Page = page
+		v.diverge(d)
+	}
+	return nil
+}
+
+// leafPage returns the last page golite reads when seeking a rowid in a
+// table, which is the leaf page holding the row, or that would hold it.
+func (v *verifier) leafPage(table golite.TableInfo, rowID int64) (int, error) {
+	v.tracer.start()
+	defer v.tracer.stop()
+	for _, err := range v.db.TableSeek(table, rowID) {
+		if err != nil {
+			return 0, err
+		}
+	}
+	return v.tracer.leaf(), nil
+}
+
+// verifyIndex compares the entries of an index, and the number of entries
+// matching a sample of keys.
+func (v *verifier) verifyIndex(table golite.TableInfo, index golite.IndexInfo) error {
+	if strings.Contains(strings.ToUpper(index.SQL), "WHERE") || slices.ContainsFunc(index.Columns, func(col golite.IndexColumn) bool { return col.Name == "" }) {
+		v.report.Skipped = append(v.report.Skipped, index.Name)
+		return nil
+	}
+	v.report.Indexes++
+
+	// The entries of an index end with the rowid, or, for a WITHOUT ROWID
+	// table, with the columns of the primary key that they do not already
+	// hold.
+	var names []string
+	for _, col := range index.Columns {
+		names = append(names, col.Name)
+	}
+	if table.WithoutRowID {
+		for _, col := range table.PrimaryKey {
+			if !slices.ContainsFunc(names, func(name string) bool { return strings.EqualFold(name, col.Name) }) {
+				names = append(names, col.Name)
+			}
+		}
+	} else {
+		names = append(names, "_rowid_")
+	}
+	columns := make([]string, len(names))
+	for i, name := range names {
+		if name != "_rowid_" {
+			name = quoteIdentifier(name)
+		}
+		columns[i] = encodeExpr(name)
+	}
+	expected, err := v.query(fmt.Sprintf("SELECT %s FROM %s INDEXED BY %s;\n", strings.Join(columns, ", "), quoteIdentifier(table.Name), quoteIdentifier(index.Name)))
+	if err != nil {
+		return err
+	}
+
+	var entries []string
+	var keys []golite.Record
+	for record, err := range v.db.IndexScan(index) {
+		if err != nil {
+			return err
+		}
+		keys = append(keys, slices.Clone(record[:len(index.Columns)]))
+		record = slices.Clone(record)
+		for i, name := range names {
+			if col := slices.IndexFunc(table.Columns, func(c golite.ColumnInfo) bool { return strings.EqualFold(c.Name, name) }); col != -1 && i < len(record) {
+				record[i] = asStored(table.Columns[col], record[i])
+			}
+		}
+		entries = append(entries, encodeRecord(record))
+	}
+	v.report.Entries += len(entries)
+	for _, d := range compareSets(entries, expected) {
+		d.Check, d.Table, d.Index = CheckIndexEntry, table.Name, index.Name
+		v.diverge(d)
+	}
+	return v.verifyLookups(table, index, sampleKeys(keys, v.opts.lookups))
+}
+
+// sampleKeys returns up to n keys evenly spread among keys, leaving out
+// those with a NULL value, which no key is equal to.
+func sampleKeys(keys []golite.Record, n int) []golite.Record {
+	keys = slices.DeleteFunc(keys, func(key golite.Record) bool {
+		return slices.ContainsFunc(key, func(v any) bool { return v == nil || v == golite.SQLNull })
+	})
+	if n <= 0 {
+		return nil
+	}
+	if len(keys) <= n {
+		return keys
+	}
+	sample := make([]golite.Record, n)
+	for i := range sample {
+		sample[i] = keys[i*len(keys)/n]
+	}
+	return sample
+}
+
+// verifyLookups compares the number of entries of an index equal to each
+// key that golite and SQLite find.
+func (v *verifier) verifyLookups(table golite.TableInfo, index golite.IndexInfo, keys []golite.Record) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	var sql strings.Builder
+	for _, key := range keys {
+		conds := make([]string, len(key))
+		for i, value := range key {
+			conds[i] = quoteIdentifier(index.Columns[i].Name) + " = " + literal(value)
+		}
+		fmt.Fprintf(&sql, "SELECT count(*) FROM %s INDEXED BY %s WHERE %s;\n", quoteIdentifier(table.Name), quoteIdentifier(index.Name), strings.Join(conds, " AND "))
+	}
+	expected, err := v.query(sql.String())
+	if err != nil {
+		return err
+	}
+	if len(expected) != len(keys) {
+		return fmt.Errorf("expected %d lines of output from sqlite3, got %d", len(keys), len(expected))
+	}
+	for i, key := range keys {
+		n := 0
+		for _, err := range v.db.IndexSeek(index, key) {
+			if err != nil {
+				return err
+			}
+			n++
+		}
+		v.report.Lookups++
+		if strconv.Itoa(n) != expected[i] {
+			v.diverge(Divergence{
+				Check: CheckLookup, Table: table.Name, Index: index.Name,
+				Golite: fmt.Sprintf("%d entries equal to (%s)", n, displayRow(encodeRecord(key))),
+				SQLite: expected[i] + " entries",
+			})
+		}
+	}
+	return nil
+}
+
+// asStored returns the value SQLite reads from a column, converting the
+// integers stored in a REAL column to reals.
+func asStored(col golite.ColumnInfo, value any) any {
+	if i, ok := value.(int64); ok && col.Affinity() == golite.AffinityReal {
+		return float64(i)
+	}
+	return value
+}
+
+// compareSets returns the divergences between two lists of encoded rows,
+// compared as multisets.
+func compareSets(golite, sqlite []string) []Divergence {
+	golite, sqlite = slices.Clone(golite), slices.Clone(sqlite)
+	slices.Sort(golite)
+	slices.Sort(sqlite)
+	var divergences []Divergence
+	for len(golite) > 0 || len(sqlite) > 0 {
+		switch {
+		case len(sqlite) == 0 || len(golite) > 0 && golite[0] < sqlite[0]:
+			divergences = append(divergences, Divergence{Golite: displayRow(golite[0])})
+			golite = golite[1:]
+		case len(golite) == 0 || sqlite[0] < golite[0]:
+			divergences = append(divergences, Divergence{SQLite: displayRow(sqlite[0])})
+			sqlite = sqlite[1:]
+		default:
+			golite, sqlite = golite[1:], sqlite[1:]
+		}
+	}
+	return divergences
+}
+
+// Values are compared in an encoding that both sides can produce exactly: a
+// letter giving the storage class followed by the decimal integer, the bits
+// of the real or the bytes of the text or blob in hexadecimal.
+
+// encodeExpr returns an SQL expression encoding the value of expr.
+func encodeExpr(expr string) string {
+	return fmt.Sprintf("CASE typeof(%[1]s) WHEN 'integer' THEN 'i' || %[1]s WHEN 'real' THEN 'r' || hex(ieee754_to_blob(%[1]s)) "+
+		"WHEN 'text' THEN 't' || hex(%[1]s) WHEN 'blob' THEN 'b' || hex(%[1]s) ELSE 'n' END", expr)
+}
+
+// encodeRecord encodes the values of a record, separated by "|".
+func encodeRecord(record golite.Record) string {
+	values := make([]string, len(record))
+	for i, value := range record {
+		switch value := value.(type) {
+		case int64:
+			values[i] = "i" + strconv.FormatInt(value, 10)
+		case float64:
+			values[i] = fmt.Sprintf("r%016X", math.Float64bits(value))
+		case string:
+			values[i] = fmt.Sprintf("t%X", value)
+		case []byte:
+			values[i] = fmt.Sprintf("b%X", value)
+		case golite.NullType:
+			values[i] = "n"
+		default:
+			values[i] = fmt.Sprintf("?%v", value)
+		}
+	}
+	return strings.Join(values, "|")
+}
+
+// displayRow turns an encoded row back into SQL literals, for reports.
+func displayRow(row string) string {
+	values := strings.Split(row, "|")
+	for i, value := range values {
+		if value == "" {
+			continue
+		}
+		data := value[1:]
+		switch value[0] {
+		case 'r':
+			bits, _ := strconv.ParseUint(data, 16, 64)
+			values[i] = strconv.FormatFloat(math.Float64frombits(bits), 'g', -1, 64)
+			if !strings.ContainsAny(values[i], ".eIN") {
+				values[i] += ".0"
+			}
+		case 't':
+			text, _ := hex.DecodeString(data)
+			values[i] = "'" + strings.ReplaceAll(string(text), "'", "''") + "'"
+		case 'b':
+			values[i] = "X'" + data + "'"
+		case 'n':
+			values[i] = "NULL"
+		case 'i':
+			values[i] = data
+		}
+	}
+	return strings.Join(values, ", ")
+}
+
+// literal returns an SQL literal for a value, which is exact for reals.
+func literal(value any) string {
+	switch value := value.(type) {
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case float64:
+		return fmt.Sprintf("ieee754_from_blob(x'%016X')", math.Float64bits(value))
+	case string:
+		return fmt.Sprintf("CAST(x'%X' AS TEXT)", value)
+	case []byte:
+		return fmt.Sprintf("x'%X'", value)
+	}
+	return "NULL"
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// leafTracer records the last page read while it is started.
+type leafTracer struct {
+	mu      sync.Mutex
+	enabled bool
+	last    int
+}
+
+func (t *leafTracer) start() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.enabled, t.last = true, 0
+}
+
+func (t *leafTracer) stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.enabled = false
+}
+
+func (t *leafTracer) leaf() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.last
+}
+
+func (t *leafTracer) OnPageRead(pageNum int, kind golite.PageReadKind) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.enabled {
+		t.last = pageNum
+	}
+}
+
+func (t *leafTracer) OnSeek(root int, key golite.Record) {}
+
+func (t *leafTracer) OnRecordDecoded(pageNum int, record golite.Record) {}
@@ -0,0 +1,128 @@
+package verify
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// createDB creates a database with sqlite3.
+func createDB(t *testing.T, path, sql string) {
+	t.Helper()
+	if output, err := exec.Command("sqlite3", path, sql).CombinedOutput(); err != nil {
+		t.Fatalf("sqlite3 failed: %v\nOutput: %s", err, output)
+	}
+}
+
+const testSchema = `
+	CREATE TABLE t (id INTEGER PRIMARY KEY, name TEXT UNIQUE, score REAL, data BLOB);
+	CREATE INDEX t_score ON t(score DESC, name);
+	CREATE INDEX t_lower ON t(lower(name));
+	CREATE INDEX t_partial ON t(score) WHERE score > 10;
+	CREATE TABLE w (k TEXT, n INTEGER, v BLOB, PRIMARY KEY (n, k)) WITHOUT ROWID;
+	CREATE INDEX w_v ON w(v);
+	CREATE TABLE plain (a INTEGER, b TEXT);
+	INSERT INTO t SELECT value, 'name' || value, value / 3.0, CAST(value AS BLOB) FROM generate_series(1, 500);
+	INSERT INTO t VALUES (1000, NULL, NULL, NULL);
+	INSERT INTO w SELECT 'k' || (value % 5), value / 5, value FROM generate_series(1, 300);
+	INSERT INTO w VALUES ('x', -1, 1.0);
+	INSERT INTO plain SELECT value % 7, 'b' FROM generate_series(1, 100);
+`
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.sqlite")
+	createDB(t, path, testSchema)
+
+	report, err := Verify(path)
+	if err != nil {
+		t.Fatalf("Verify() failed: %v", err)
+	}
+	if !report.OK() {
+		var text strings.Builder
+		report.WriteText(&text)
+		t.Fatalf("Verify() found divergences:\n%s", text.String())
+	}
+	if report.Tables != 3 || report.Rows != 902 || report.Indexes != 3 || report.Entries != 1303 || report.Lookups != 300 {
+		t.Errorf("Verify() = %+v", report)
+	}
+	if strings.Join(report.Skipped, ",") != "t_lower,t_partial" {
+		t.Errorf("Skipped = %v", report.Skipped)
+	}
+
+	report, err = Verify(path, WithTables("w"), WithLookups(5))
+	if err != nil {
+		t.Fatalf("Verify() failed: %v", err)
+	}
+	if report.Tables != 1 || report.Rows != 301 || report.Lookups != 5 || !report.OK() {
+		t.Errorf("Verify(WithTables(\"w\")) = %+v", report)
+	}
+
+	if _, err := Verify(path, WithSQLite(filepath.Join(dir, "nope"))); err == nil {
+		t.Errorf("expected an error for a missing sqlite3 binary")
+	}
+}
+
+func TestVerifyDivergences(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.sqlite")
+	createDB(t, path, testSchema)
+	// The reference reads another database, in which a few rows differ.
+	other := filepath.Join(dir, "other.sqlite")
+	createDB(t, other, testSchema+`
+		UPDATE t SET score = 2 WHERE id = 3;
+		DELETE FROM t WHERE id = 250;
+		INSERT INTO t VALUES (2000, 'new', 0, x'00');
+		UPDATE w SET v = 'changed' WHERE n = 10 AND k = 'k0';
+	`)
+	sqlite := filepath.Join(dir, "sqlite3")
+	script := "#!/bin/sh\nexec sqlite3 -readonly -batch -bail -list -noheader -separator '|' " + other + "\n"
+	if err := os.WriteFile(sqlite, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Verify(path, WithSQLite(sqlite), WithMaxDivergences(6))
+	if err != nil {
+		t.Fatalf("Verify() failed: %v", err)
+	}
+	var text strings.Builder
+	if err := report.WriteText(&text); err != nil {
+		t.Fatalf("WriteText() failed: %v", err)
+	}
+	got := text.String()
+	for _, want := range []string{
+		"row: table t, rowid 3, page ",
+		": golite 3, 'name3', 1.0, X'33', sqlite 3, 'name3', 2.0, X'33'",
+		"row: table t, rowid 250, page ",
+		"row: table t, rowid 2000, page ",
+		": golite (missing), sqlite 2000, 'new', 0.0, X'00'",
+		"index entry: table t, index sqlite_autoindex_t_1: golite (missing), sqlite 'new', 2000",
+		"more divergences",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report does not contain %q:\n%s", want, got)
+		}
+	}
+	if report.OK() || len(report.Divergences) != 6 || report.Omitted == 0 {
+		t.Errorf("Verify() = %+v", report)
+	}
+	for _, d := range report.Divergences {
+		if d.Check == CheckRow && d.Page < 2 {
+			t.Errorf("divergence %v has no page", d)
+		}
+	}
+
+	report, err = Verify(path, WithSQLite(sqlite), WithTables("w"))
+	if err != nil {
+		t.Fatalf("Verify() failed: %v", err)
+	}
+	checks := map[Check]int{}
+	for _, d := range report.Divergences {
+		checks[d.Check]++
+	}
+	if checks[CheckRow] != 2 || checks[CheckIndexEntry] != 2 || checks[CheckCount] != 0 {
+		t.Errorf("Verify(WithTables(\"w\")) found %v", report.Divergences)
+	}
+}
@@ -0,0 +1,106 @@
+package golite
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"time"
+)
+
+// DefaultWatchInterval is the polling interval used by Watch when it is given
+// an interval of zero.
+const DefaultWatchInterval = time.Second
+
+// WatchEvent reports a change to the database file, or the error that
+// stopped Watch.
+type WatchEvent struct {
+	// ChangeCounter is the file change counter, which SQLite increments on
+	// every transaction that modifies the file.
+	ChangeCounter uint32
+	// SchemaCookie is the schema cookie, which SQLite increments whenever
+	// the schema changes.
+	SchemaCookie uint32
+	// PageCount is the number of pages in the database after the change.
+	PageCount int
+	// SchemaChanged is true if the schema cookie differs from the previous
+	// event, so that a cached Schema should be read again.
+	SchemaChanged bool
+
+	Err error
+}
+
+// Watch polls the header of the database file every interval, or every
+// DefaultWatchInterval if interval is zero, and sends an event on the
+// returned channel each time the change counter moves. The channel is closed
+// when ctx is done or after an event carrying an error.
+//
+// Only changes committed to the database file itself are noticed: rollback
+// journal mode commits are, but transactions still in a write-ahead log are
+// not.
+func (db *Database) Watch(ctx context.Context, interval time.Duration) <-chan WatchEvent {
+	if interval <= 0 {
+		interval = DefaultWatchInterval
+	}
+	ch := make(chan WatchEvent)
+	last, err := db.readWatchState()
+	go func() {
+		defer close(ch)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err == nil {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+				}
+				var current WatchEvent
+				current, err = db.readWatchState()
+				if err == nil && current.ChangeCounter == last.ChangeCounter {
+					continue
+				}
+				current.SchemaChanged = current.SchemaCookie != last.SchemaCookie
+				last = current
+			}
+			event := last
+			if err != nil {
+				event = WatchEvent{Err: err}
+			}
+			select {
+			case ch <- event:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+// readWatchState reads the fields of a WatchEvent from the file header.
+func (db *Database) readWatchState() (WatchEvent, error) {
+	size, err := db.pager.Size()
+	if err != nil {
+		return WatchEvent{}, fmt.Errorf("failed to get the size of the database file: %w", err)
+	}
+	if size < HeaderSize {
+		return WatchEvent{PageCount: 1}, nil // The file is still empty.
+	}
+	buf := make([]byte, HeaderSize-24) // From the change counter to the end.
+	if _, err := db.pager.ReadAt(buf, 24); err != nil {
+		return WatchEvent{}, fmt.Errorf("failed to read database header: %w", err)
+	}
+	event := WatchEvent{
+		ChangeCounter: binary.BigEndian.Uint32(buf[0:4]),
+		PageCount:     int(binary.BigEndian.Uint32(buf[4:8])),
+		SchemaCookie:  binary.BigEndian.Uint32(buf[16:20]),
+	}
+	if event.PageCount == 0 || event.ChangeCounter != binary.BigEndian.Uint32(buf[68:72]) {
+		// The header was written by a legacy version of SQLite, so the size
+		// it records cannot be trusted.
+		event.PageCount = int(size / int64(db.Header.PageSize))
+	}
+	return event, nil
+}
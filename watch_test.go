@@ -0,0 +1,52 @@
+package golite
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDatabase_Watch(t *testing.T) {
+	dbPath := createTestDB(t, "watch_test.sqlite")
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := db.Watch(ctx, 5*time.Millisecond)
+	next := func() WatchEvent {
+		t.Helper()
+		select {
+		case event := <-events:
+			if event.Err != nil {
+				t.Fatalf("Watch() reported an error: %v", event.Err)
+			}
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for a change event")
+			return WatchEvent{}
+		}
+	}
+
+	runSQL(t, dbPath, "INSERT INTO test (name) VALUES ('watched');")
+	event := next()
+	if event.SchemaChanged {
+		t.Errorf("expected an insert not to change the schema")
+	}
+	if event.PageCount < db.PageCount() {
+		t.Errorf("expected at least %d pages, got %d", db.PageCount(), event.PageCount)
+	}
+
+	runSQL(t, dbPath, "CREATE TABLE other (x INTEGER);")
+	if event := next(); !event.SchemaChanged {
+		t.Errorf("expected a CREATE TABLE to change the schema")
+	}
+
+	cancel()
+	for range events {
+		// Wait for the channel to be closed.
+	}
+}
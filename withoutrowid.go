@@ -0,0 +1,118 @@
+package golite
+
+import (
+	"fmt"
+	"slices"
+)
+
+// StorageOrder returns the layout of the records stored in the table's
+// B-Tree: the index in Columns of the column held by each of their values.
+//
+// For a WITHOUT ROWID table, the B-Tree is an index B-Tree whose records hold
+// the primary key columns first, in key order, followed by the other columns
+// in the order they are declared. For other tables, the columns are stored in
+// declaration order. In both cases virtual generated columns are not stored.
+func (t TableInfo) StorageOrder() []int {
+	order := make([]int, 0, len(t.Columns))
+	isKey := make([]bool, len(t.Columns))
+	if t.WithoutRowID {
+		for _, col := range t.PrimaryKey {
+			if i := t.lookupColumn(col.Name); i >= 0 && !isKey[i] {
+				isKey[i] = true
+				order = append(order, i)
+			}
+		}
+	}
+	for i, col := range t.Columns {
+		if !isKey[i] && col.Hidden != ColumnGeneratedVirtual {
+			order = append(order, i)
+		}
+	}
+	return order
+}
+
+// PrimaryKeyIndex returns an IndexInfo describing the B-Tree of a WITHOUT
+// ROWID table, so that it can be searched with IndexSeek and IndexScan. The
+// records these yield are in StorageOrder. The second result is false if the
+// table is not a WITHOUT ROWID table.
+func (t TableInfo) PrimaryKeyIndex() (IndexInfo, bool) {
+	if !t.WithoutRowID {
+		return IndexInfo{}, false
+	}
+	columns := make([]IndexColumn, len(t.PrimaryKey))
+	for i, col := range t.PrimaryKey {
+		columns[i] = col
+		if j := t.lookupColumn(col.Name); j >= 0 {
+			columns[i].affinity = t.Columns[j].Affinity()
+		}
+	}
+	return IndexInfo{
+		Name:      t.Name,
+		TableName: t.Name,
+		RootPage:  t.RootPage,
+		SQL:       t.SQL,
+		Columns:   columns,
+		Unique:    true,
+	}, true
+}
+
+// virtualColumnsOrder returns the StorageOrder of a rowid table with virtual
+// generated columns, whose stored records must be rearranged with
+// fromStorage to line up with Columns, or nil if the records of the table's
+// B-Tree are already in declaration order.
+func (t TableInfo) virtualColumnsOrder() []int {
+	if t.WithoutRowID || !slices.ContainsFunc(t.Columns, func(col ColumnInfo) bool { return col.Hidden == ColumnGeneratedVirtual }) {
+		return nil
+	}
+	return t.StorageOrder()
+}
+
+// fromStorage rearranges a record stored in the table's B-Tree into
+// declaration order. Columns missing from the record, because they are
+// virtual or were added after it was written, get their default value.
+func (t TableInfo) fromStorage(record Record, order []int) Record {
+	values := make(Record, len(t.Columns))
+	for i, col := range t.Columns {
+		values[i] = SQLNull
+		if col.Hidden != ColumnGeneratedVirtual {
+			values[i] = col.paddingValue()
+		}
+	}
+	for i, column := range order {
+		if i < len(record) {
+			values[column] = record[i]
+		}
+	}
+	return values
+}
+
+// withoutRowIDRecords adapts an iterator over the records of the B-Tree of a
+// WITHOUT ROWID table into an iterator over records in declaration order.
+func withoutRowIDRecords(table TableInfo, records RecordIterator) RecordIterator {
+	order := table.StorageOrder()
+	return func(yield func(Record, error) bool) {
+		for record, err := range records {
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(table.fromStorage(record, order), nil) {
+				return
+			}
+		}
+	}
+}
+
+// PrimaryKeySeek searches a WITHOUT ROWID table for the rows whose primary
+// key starts with key, which holds values of the primary key columns in key
+// order. The yielded records hold the table's columns in declaration order,
+// as with TableScan.
+func (db *Database) PrimaryKeySeek(table TableInfo, key Record) RecordIterator {
+	index, ok := table.PrimaryKeyIndex()
+	if !ok {
+		return func(yield func(Record, error) bool) {
+			yield(nil, fmt.Errorf("table %s is not a WITHOUT ROWID table", table.Name))
+		}
+	}
+	return withoutRowIDRecords(table, db.IndexSeek(index, key))
+}
@@ -0,0 +1,74 @@
+package golite
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWithoutRowID(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "without_rowid.sqlite")
+	runSQL(t, dbPath, `
+		CREATE TABLE kv (value TEXT, grp INTEGER, key TEXT, PRIMARY KEY (grp, key DESC)) WITHOUT ROWID;
+		INSERT INTO kv VALUES ('a1', 1, 'a'), ('b1', 1, 'b'), ('a2', 2, 'a');
+		ALTER TABLE kv ADD COLUMN note TEXT DEFAULT 'none';
+	`)
+	db, err := Open(dbPath)
+	if err != nil {
+		t.Fatalf("Open() failed with error: %v", err)
+	}
+	defer db.Close()
+	table, err := db.Table("kv")
+	if err != nil {
+		t.Fatalf("Table() failed: %v", err)
+	}
+
+	if !table.WithoutRowID {
+		t.Errorf("expected a WITHOUT ROWID table")
+	}
+	wantKey := []IndexColumn{{Name: "grp"}, {Name: "key", Desc: true}}
+	if !reflect.DeepEqual(table.PrimaryKey, wantKey) {
+		t.Errorf("expected primary key %+v, got %+v", wantKey, table.PrimaryKey)
+	}
+	if got, want := table.StorageOrder(), []int{1, 2, 0, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expected storage order %v, got %v", want, got)
+	}
+
+	t.Run("scan", func(t *testing.T) {
+		records, err := CollectRecords(db.TableScan(table))
+		if err != nil {
+			t.Fatalf("TableScan() failed: %v", err)
+		}
+		want := []Record{
+			{"b1", int64(1), "b", "none"},
+			{"a1", int64(1), "a", "none"},
+			{"a2", int64(2), "a", "none"},
+		}
+		if !reflect.DeepEqual(records, want) {
+			t.Errorf("expected %v, got %v", want, records)
+		}
+		if name, _ := table.Row(records[0]).Get("value"); name != "b1" {
+			t.Errorf("expected Row to read declared columns, got %v", name)
+		}
+	})
+
+	t.Run("seek", func(t *testing.T) {
+		records, err := CollectRecords(db.PrimaryKeySeek(table, Record{"2"}))
+		if err != nil {
+			t.Fatalf("PrimaryKeySeek() failed: %v", err)
+		}
+		if want := []Record{{"a2", int64(2), "a", "none"}}; !reflect.DeepEqual(records, want) {
+			t.Errorf("expected %v, got %v", want, records)
+		}
+	})
+
+	t.Run("rowid table", func(t *testing.T) {
+		test := TableInfo{Name: "test", Columns: []ColumnInfo{{Name: "id"}}}
+		if _, ok := test.PrimaryKeyIndex(); ok {
+			t.Errorf("expected no primary key index for a rowid table")
+		}
+		if _, err := CollectRecords(db.PrimaryKeySeek(test, Record{int64(1)})); err == nil {
+			t.Errorf("expected an error seeking a rowid table by primary key")
+		}
+	})
+}